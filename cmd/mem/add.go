@@ -9,22 +9,37 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func NewAddCmd(addUC *internal.AddMemoryUseCase) *cobra.Command {
+func NewAddCmd(addUC *internal.AddMemoryUseCase, aliasUC *internal.AliasUseCase) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "add <key> [content]",
 		Short: "Append content to a memory",
-		Long:  `Append content to an existing memory or create a new one. Reads from stdin if content is not provided.`,
-		Args:  cobra.RangeArgs(1, 2),
-		RunE:  makeAddRunner(addUC),
+		Long: `Append content to an existing memory or create a new one. Reads from stdin if content is not provided.
+
+For memories kept as a log, --timestamp starts the entry with the current
+time, in journal.timestamp_format or RFC 3339, or in the Go time layout given
+as --timestamp=<layout>. --section adds a "## <date>" heading whenever the
+entry is the first of a new day.`,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: makeAddRunner(addUC, aliasUC),
 	}
 
 	cmd.Flags().StringP("message", "m", "", "Commit message")
+	cmd.Flags().String("timestamp", "", "Prefix the entry with the time, optionally in this Go time layout")
+	cmd.Flags().Lookup("timestamp").NoOptDefVal = timestampDefault
+	cmd.Flags().Bool("section", false, "Add a \"## <date>\" heading when the day changed since the last one")
 	return cmd
 }
 
-func makeAddRunner(addUC *internal.AddMemoryUseCase) func(*cobra.Command, []string) error {
+// timestampDefault is the --timestamp value when the flag is given without
+// a layout; it stands for journal.timestamp_format or RFC 3339.
+const timestampDefault = "<format>"
+
+func makeAddRunner(addUC *internal.AddMemoryUseCase, aliasUC *internal.AliasUseCase) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		key := args[0]
+		key, err := resolveKeyArg(cmd, aliasUC, args[0])
+		if err != nil {
+			return err
+		}
 
 		content, err := resolveAddContent(args)
 		if err != nil {
@@ -33,19 +48,40 @@ func makeAddRunner(addUC *internal.AddMemoryUseCase) func(*cobra.Command, []stri
 
 		scopeHint, _ := cmd.Flags().GetString("scope")
 		message, _ := cmd.Flags().GetString("message")
+		asJSON, _ := cmd.Flags().GetBool("json")
 
-		_, err = addUC.Execute(cmd.Context(), internal.AddMemoryInput{
+		timestamp, _ := cmd.Flags().GetString("timestamp")
+		section, _ := cmd.Flags().GetBool("section")
+		layout := timestamp
+		if layout == timestampDefault {
+			layout = ""
+		}
+
+		out, err := addUC.Execute(cmd.Context(), internal.AddMemoryInput{
 			Key: key, Content: content, Scope: scopeHint, Message: message,
+			Timestamp: timestamp != "", TimestampFormat: layout, Section: section,
 		})
 		if err != nil {
 			return fmt.Errorf("add to memory: %w", err)
 		}
 
+		if asJSON {
+			return outputWriteResultJSON(cmd, addResultJSON("add", key, out))
+		}
 		fmt.Fprintf(cmd.OutOrStdout(), "Appended to %s\n", key)
 		return nil
 	}
 }
 
+// addResultJSON reports an append as created when the memory was new.
+func addResultJSON(op, key string, out *internal.AddMemoryOutput) map[string]any {
+	outcome := "appended"
+	if out.Created {
+		outcome = "created"
+	}
+	return writeResultJSON(op, key, outcome, &out.CommitOutput)
+}
+
 func resolveAddContent(args []string) (string, error) {
 	if len(args) >= 2 {
 		return args[1], nil
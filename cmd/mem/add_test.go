@@ -45,7 +45,7 @@ func setupAddTest(t *testing.T) (*internal.GitRepository, *internal.AddMemoryUse
 func TestAddCmdCreatesNew(t *testing.T) {
 	repo, addUC := setupAddTest(t)
 
-	cmd := NewAddCmd(addUC)
+	cmd := NewAddCmd(addUC, nil)
 	cmd.SetArgs([]string{"new/key", "hello world"})
 
 	var out bytes.Buffer
@@ -88,7 +88,7 @@ func TestAddCmdAppendsExisting(t *testing.T) {
 	}
 
 	// Append via add command
-	cmd := NewAddCmd(addUC)
+	cmd := NewAddCmd(addUC, nil)
 	cmd.SetArgs([]string{"existing", "second"})
 
 	var out bytes.Buffer
@@ -117,7 +117,7 @@ func TestAddCmdCreatesHistoryNode(t *testing.T) {
 	repo, addUC := setupAddTest(t)
 
 	// Add first
-	cmd := NewAddCmd(addUC)
+	cmd := NewAddCmd(addUC, nil)
 	cmd.SetArgs([]string{"tracked", "version 1"})
 	var out bytes.Buffer
 	cmd.SetOut(&out)
@@ -126,7 +126,7 @@ func TestAddCmdCreatesHistoryNode(t *testing.T) {
 	}
 
 	// Add second
-	cmd2 := NewAddCmd(addUC)
+	cmd2 := NewAddCmd(addUC, nil)
 	cmd2.SetArgs([]string{"tracked", "version 2"})
 	cmd2.SetOut(&out)
 	if err := cmd2.Execute(); err != nil {
@@ -144,3 +144,24 @@ func TestAddCmdCreatesHistoryNode(t *testing.T) {
 		t.Errorf("expected at least 3 commits, got %d", len(commits))
 	}
 }
+
+func TestAddCmdTimestampSection(t *testing.T) {
+	repo, addUC := setupAddTest(t)
+
+	cmd := NewAddCmd(addUC, nil)
+	cmd.SetArgs([]string{"notes/journal", "--timestamp=2006", "--section", "shipped"})
+	cmd.SetOut(&bytes.Buffer{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	mem, err := repo.Get(context.Background(), internal.Key("notes/journal"))
+	if err != nil {
+		t.Fatalf("get memory: %v", err)
+	}
+	now := time.Now()
+	want := "## " + now.Format(internal.JournalSectionLayout) + "\n" + now.Format("2006") + " shipped"
+	if string(mem.Content) != want {
+		t.Errorf("content = %q, want %q", mem.Content, want)
+	}
+}
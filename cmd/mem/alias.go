@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewAliasCmd(aliasUC *internal.AliasUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alias",
+		Short: "Manage key aliases",
+		Long:  `Define short names for frequently used keys. Refer to them as @name in get, set, add, edit, del and new.`,
+	}
+
+	cmd.AddCommand(
+		newAliasListCmd(aliasUC),
+		newAliasAddCmd(aliasUC),
+		newAliasRemoveCmd(aliasUC),
+	)
+
+	return cmd
+}
+
+func newAliasListCmd(aliasUC *internal.AliasUseCase) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List defined aliases",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			aliases, err := aliasUC.List(internal.AliasInput{Scope: scopeHint})
+			if err != nil {
+				return fmt.Errorf("list aliases: %w", err)
+			}
+
+			if len(aliases) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No aliases defined.")
+				return nil
+			}
+
+			names := make([]string, 0, len(aliases))
+			for name := range aliases {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s%s -> %s\n", internal.AliasPrefix, name, aliases[name])
+			}
+			return nil
+		},
+	}
+}
+
+func newAliasAddCmd(aliasUC *internal.AliasUseCase) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <name> <key|@alias>",
+		Short: "Add or replace an alias",
+		Long: `Point <name> at a key, or at another alias written as @alias. Aliases of
+aliases are followed up to 8 hops; an alias that would lead back to itself
+is refused.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			if err := aliasUC.Add(internal.AliasInput{
+				Name: args[0], Key: args[1], Scope: scopeHint,
+			}); err != nil {
+				return fmt.Errorf("add alias: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Added alias %s -> %s\n", args[0], args[1])
+			return nil
+		},
+	}
+}
+
+func newAliasRemoveCmd(aliasUC *internal.AliasUseCase) *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove <name>",
+		Aliases: []string{"rm"},
+		Short:   "Remove an alias",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			if err := aliasUC.Remove(internal.AliasInput{Name: args[0], Scope: scopeHint}); err != nil {
+				return fmt.Errorf("remove alias: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed alias %s\n", args[0])
+			return nil
+		},
+	}
+}
+
+// resolveKeyArg expands an @alias key argument. The use cases expand
+// aliases themselves; write commands resolve up front as well so their
+// output and commit messages name the key rather than the alias. A nil use
+// case leaves the argument untouched.
+func resolveKeyArg(cmd *cobra.Command, aliasUC *internal.AliasUseCase, arg string) (string, error) {
+	if aliasUC == nil {
+		return arg, nil
+	}
+	scopeHint, _ := cmd.Flags().GetString("scope")
+	return aliasUC.Resolve(internal.AliasInput{Name: arg, Scope: scopeHint})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/4thel00z/memories/pkg/v1/memtest"
+)
+
+func TestGetCmdResolvesAlias(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resolver := internal.NewScopeResolverAt(memtest.InitStore(t), "")
+	scope, _ := resolver.Project()
+	repo, err := internal.NewGitRepository(scope)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+
+	key, _ := internal.NewKey("projects/backend/deploy-notes")
+	if err := repo.Save(context.Background(), &internal.Memory{
+		Key: key, Content: []byte("ship it"), CreatedAt: time.Now(), UpdatedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("save memory: %v", err)
+	}
+
+	aliasUC := internal.NewAliasUseCase(resolver)
+	repoFor := func(s internal.Scope) (internal.MemoryRepository, error) { return repo, nil }
+	getUC := internal.NewGetMemoryUseCase(resolver, repoFor)
+
+	add := NewAliasCmd(aliasUC)
+	add.SetArgs([]string{"add", "deploy", "projects/backend/deploy-notes"})
+	add.SetOut(&bytes.Buffer{})
+	if err := add.Execute(); err != nil {
+		t.Fatalf("alias add: %v", err)
+	}
+
+	cmd := NewGetCmd(getUC)
+	cmd.SetArgs([]string{"@deploy"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("get @deploy: %v", err)
+	}
+	if out.String() != "ship it" {
+		t.Errorf("output = %q, want %q", out.String(), "ship it")
+	}
+
+	list := NewAliasCmd(aliasUC)
+	list.SetArgs([]string{"list"})
+	var listOut bytes.Buffer
+	list.SetOut(&listOut)
+	if err := list.Execute(); err != nil {
+		t.Fatalf("alias list: %v", err)
+	}
+	if listOut.String() != "@deploy -> projects/backend/deploy-notes\n" {
+		t.Errorf("list = %q", listOut.String())
+	}
+
+	missing := NewGetCmd(getUC)
+	missing.SetArgs([]string{"@nope"})
+	missing.SetOut(&bytes.Buffer{})
+	missing.SetErr(&bytes.Buffer{})
+	err = missing.Execute()
+	if err == nil || !strings.Contains(err.Error(), "@deploy") {
+		t.Errorf("err = %v, want list of defined aliases", err)
+	}
+
+	broken := NewAliasCmd(aliasUC)
+	broken.SetArgs([]string{"add", "old", "notes/gone"})
+	broken.SetOut(&bytes.Buffer{})
+	if err := broken.Execute(); err != nil {
+		t.Fatalf("alias add: %v", err)
+	}
+	dangling := NewGetCmd(getUC)
+	dangling.SetArgs([]string{"@old"})
+	dangling.SetOut(&bytes.Buffer{})
+	dangling.SetErr(&bytes.Buffer{})
+	err = dangling.Execute()
+	if !errors.Is(err, internal.ErrNotFound) || !strings.Contains(err.Error(), `alias @old points to missing key "notes/gone"`) {
+		t.Errorf("err = %v, want broken alias message", err)
+	}
+}
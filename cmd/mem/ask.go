@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewAskCmd(askUC *internal.AskUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ask <prompt>",
+		Short: "Ask an LLM, optionally grounded in memories",
+		Long: `Send a prompt to the provider for the ask task (models.ask, or
+default_provider) and stream the answer to stdout. With --context the
+memories under a prefix are sent along, so the answer can draw on your notes:
+
+  mem ask --context notes/release "when does v2 ship?"`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			contextPrefix, _ := cmd.Flags().GetString("context")
+			provider, _ := cmd.Flags().GetString("provider")
+			model, _ := cmd.Flags().GetString("model")
+			noRedact, _ := cmd.Flags().GetBool("no-redact")
+
+			chunks, err := askUC.Execute(cmd.Context(), internal.AskInput{
+				Prompt:        strings.Join(args, " "),
+				ContextPrefix: contextPrefix,
+				Scope:         scopeHint,
+				Provider:      provider,
+				Model:         model,
+				NoRedact:      noRedact,
+			})
+			if err != nil {
+				return fmt.Errorf("ask: %w", err)
+			}
+
+			if asJSON {
+				var answer strings.Builder
+				for chunk := range chunks {
+					answer.WriteString(chunk)
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(map[string]any{"answer": answer.String()})
+			}
+
+			for chunk := range chunks {
+				fmt.Fprint(cmd.OutOrStdout(), chunk)
+			}
+			fmt.Fprintln(cmd.OutOrStdout())
+			return nil
+		},
+	}
+
+	cmd.Flags().String("context", "", "Send the memories under this prefix along with the prompt")
+	cmd.Flags().String("provider", "", "Provider to use for this run (default: models.ask or default_provider)")
+	cmd.Flags().String("model", "", "Model to use for this run")
+	cmd.Flags().Bool("no-redact", false, "Send content to the provider without redacting secrets")
+	return cmd
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/4thel00z/memories/internal"
+)
+
+// streamProvider streams fixed chunks and records the prompt it got.
+type streamProvider struct {
+	internal.NoModels
+	chunks []string
+	prompt string
+}
+
+func (p *streamProvider) Complete(context.Context, string) (string, error)  { return "", nil }
+func (p *streamProvider) GenerateObject(context.Context, string, any) error { return nil }
+
+func (p *streamProvider) Stream(_ context.Context, prompt string) (<-chan string, error) {
+	p.prompt = prompt
+	ch := make(chan string, len(p.chunks))
+	for _, c := range p.chunks {
+		ch <- c
+	}
+	close(ch)
+	return ch, nil
+}
+
+func setupAskTest(t *testing.T) (*internal.AskUseCase, *streamProvider) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	scope := internal.Scope{
+		Type:    internal.ScopeProject,
+		Path:    tmpDir,
+		MemPath: filepath.Join(tmpDir, ".mem"),
+	}
+	if err := os.MkdirAll(scope.VectorPath(), 0755); err != nil {
+		t.Fatalf("mkdir vectors: %v", err)
+	}
+	if err := internal.InitRepository(scope); err != nil {
+		t.Fatalf("init repo: %v", err)
+	}
+	repo, err := internal.NewGitRepository(scope)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+	for k, content := range map[string]string{
+		"notes/release":  "v2 ships on friday",
+		"notes/freeze":   "code freeze thursday",
+		"todo/groceries": "milk",
+	} {
+		key, _ := internal.NewKey(k)
+		if err := repo.Save(context.Background(), internal.NewMemory(key, []byte(content))); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+
+	provider := &streamProvider{chunks: []string{"v2 ships ", "on ", "Friday."}}
+	repoFor := func(s internal.Scope) (internal.MemoryRepository, error) { return repo, nil }
+	return internal.NewAskUseCase(internal.NewScopeResolver(), repoFor, internal.StaticProvider(provider)), provider
+}
+
+func TestAskCmdStreamsWithContext(t *testing.T) {
+	askUC, provider := setupAskTest(t)
+
+	cmd := NewAskCmd(askUC)
+	cmd.SetArgs([]string{"--context", "notes/", "when", "does", "v2", "ship?"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	if got, want := out.String(), "v2 ships on Friday.\n"; got != want {
+		t.Errorf("output = %q, want %q", got, want)
+	}
+	for _, want := range []string{"## notes/release\nv2 ships on friday", "code freeze thursday", "Question: when does v2 ship?"} {
+		if !strings.Contains(provider.prompt, want) {
+			t.Errorf("prompt missing %q:\n%s", want, provider.prompt)
+		}
+	}
+	if strings.Contains(provider.prompt, "milk") {
+		t.Errorf("prompt includes memories outside the context prefix:\n%s", provider.prompt)
+	}
+}
+
+func TestAskCmdWithoutContext(t *testing.T) {
+	askUC, provider := setupAskTest(t)
+
+	cmd := NewAskCmd(askUC)
+	cmd.SetArgs([]string{"--json", "hello"})
+	cmd.Root().PersistentFlags().Bool("json", false, "")
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	if provider.prompt != "hello" {
+		t.Errorf("prompt = %q, want the bare question", provider.prompt)
+	}
+	var data map[string]string
+	if err := json.Unmarshal(out.Bytes(), &data); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if data["answer"] != "v2 ships on Friday." {
+		t.Errorf("answer = %q", data["answer"])
+	}
+}
+
+func TestAskCmdEmptyContext(t *testing.T) {
+	askUC, _ := setupAskTest(t)
+
+	cmd := NewAskCmd(askUC)
+	cmd.SetArgs([]string{"--context", "missing/", "anything?"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "no memories") {
+		t.Errorf("expected no memories error, got %v", err)
+	}
+}
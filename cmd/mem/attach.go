@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewAttachCmd(attachUC *internal.AttachmentUseCase, commitUC *internal.CommitUseCase, aliasUC *internal.AliasUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "attach <key> <file>",
+		Short: "Attach a file to a memory",
+		Long: `Store a file under .mem/attachments by its SHA-256 and add an
+"attachment: <sha256> <name>" line to the memory. The bytes never enter the
+memory itself, so list, search and the embedder stay fast. Set
+attachments.track in the config to commit blobs to git.`,
+		Args: cobra.ExactArgs(2),
+		RunE: makeAttachRunner(attachUC, commitUC, aliasUC),
+	}
+
+	cmd.Flags().StringP("message", "m", "", "Commit message")
+	cmd.Flags().String("name", "", "Attach under this name instead of the file's base name")
+
+	cmd.AddCommand(
+		newAttachGetCmd(attachUC, aliasUC),
+		newAttachListCmd(attachUC, aliasUC),
+		newAttachGCCmd(attachUC, commitUC),
+	)
+
+	return cmd
+}
+
+func makeAttachRunner(attachUC *internal.AttachmentUseCase, commitUC *internal.CommitUseCase, aliasUC *internal.AliasUseCase) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		scopeHint, _ := cmd.Flags().GetString("scope")
+		message, _ := cmd.Flags().GetString("message")
+		name, _ := cmd.Flags().GetString("name")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		key, err := resolveKeyArg(cmd, aliasUC, args[0])
+		if err != nil {
+			return err
+		}
+
+		a, err := attachUC.Attach(cmd.Context(), internal.AttachInput{
+			Key: key, Path: args[1], Name: name, Scope: scopeHint,
+		})
+		if err != nil {
+			return fmt.Errorf("attach: %w", err)
+		}
+
+		if _, err := autoCommit(cmd.Context(), commitUC, message, "attach", key, scopeHint); err != nil {
+			return fmt.Errorf("commit: %w", err)
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(map[string]any{
+				"key":  key,
+				"name": a.Name,
+				"hash": a.Hash,
+			})
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Attached %s to %s (%s)\n", a.Name, key, a.Hash[:12])
+		return nil
+	}
+}
+
+func newAttachGetCmd(attachUC *internal.AttachmentUseCase, aliasUC *internal.AliasUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "get <key> <name>",
+		Short: "Write an attachment to a file or stdout",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			output, _ := cmd.Flags().GetString("output")
+
+			key, err := resolveKeyArg(cmd, aliasUC, args[0])
+			if err != nil {
+				return err
+			}
+
+			blob, err := attachUC.Open(cmd.Context(), internal.AttachInput{Key: key, Name: args[1], Scope: scopeHint})
+			if err != nil {
+				return fmt.Errorf("get attachment: %w", err)
+			}
+			defer blob.Close()
+
+			var w io.Writer = cmd.OutOrStdout()
+			if output != "" && output != "-" {
+				f, err := os.Create(output)
+				if err != nil {
+					return fmt.Errorf("create output: %w", err)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			if _, err := io.Copy(w, blob); err != nil {
+				return fmt.Errorf("write attachment: %w", err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("output", "o", "", "Write to this file instead of stdout")
+	return cmd
+}
+
+func newAttachListCmd(attachUC *internal.AttachmentUseCase, aliasUC *internal.AliasUseCase) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list <key>",
+		Short: "List a memory's attachments",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			key, err := resolveKeyArg(cmd, aliasUC, args[0])
+			if err != nil {
+				return err
+			}
+
+			attachments, err := attachUC.List(cmd.Context(), internal.AttachInput{Key: key, Scope: scopeHint})
+			if err != nil {
+				return fmt.Errorf("list attachments: %w", err)
+			}
+
+			if asJSON {
+				items := make([]map[string]any, 0, len(attachments))
+				for _, a := range attachments {
+					items = append(items, map[string]any{"name": a.Name, "hash": a.Hash})
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(map[string]any{"key": key, "attachments": items})
+			}
+
+			if len(attachments) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s has no attachments.\n", key)
+				return nil
+			}
+			for _, a := range attachments {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s  %s\n", a.Hash[:12], a.Name)
+			}
+			return nil
+		},
+	}
+}
+
+func newAttachGCCmd(attachUC *internal.AttachmentUseCase, commitUC *internal.CommitUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Remove attachments no memory references",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			out, err := attachUC.GC(cmd.Context(), internal.AttachInput{Scope: scopeHint, DryRun: dryRun})
+			if err != nil {
+				return fmt.Errorf("collect attachments: %w", err)
+			}
+			if !dryRun && out.Tracked && len(out.Hashes) > 0 {
+				if _, err := autoCommit(cmd.Context(), commitUC, "", "attach", "gc", scopeHint); err != nil {
+					return fmt.Errorf("commit: %w", err)
+				}
+			}
+
+			if asJSON {
+				hashes := out.Hashes
+				if hashes == nil {
+					hashes = []string{}
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(map[string]any{"removed": hashes, "dry_run": dryRun})
+			}
+			printCollected(cmd, out)
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Show what would be removed without removing it")
+	return cmd
+}
+
+// printCollected reports the blobs removed by attachment garbage collection.
+func printCollected(cmd *cobra.Command, out *internal.AttachGCOutput) {
+	verb := "Removed"
+	if out.DryRun {
+		verb = "Would remove"
+	}
+	for _, hash := range out.Hashes {
+		fmt.Fprintf(cmd.OutOrStdout(), "%s attachment %s\n", verb, hash[:12])
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+// autotagJSON is the --json shape of mem autotag.
+type autotagJSON struct {
+	Key        string   `json:"key"`
+	Tags       []string `json:"tags"`
+	Category   string   `json:"category"`
+	Confidence float32  `json:"confidence"`
+}
+
+func NewAutoTagCmd(autoTagUC *internal.AutoTagUseCase, aliasUC *internal.AliasUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "autotag <key>",
+		Short: "Suggest tags and a category for a memory using AI",
+		Long: `Ask the provider for the autotag task (models.autotag, or
+default_provider) to tag a memory. Prints the suggested tags, a category and
+how confident the provider is. Nothing is written to the store.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := resolveKeyArg(cmd, aliasUC, args[0])
+			if err != nil {
+				return err
+			}
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			asJSON, _ := cmd.Flags().GetBool("json")
+			provider, _ := cmd.Flags().GetString("provider")
+			model, _ := cmd.Flags().GetString("model")
+			noRedact, _ := cmd.Flags().GetBool("no-redact")
+
+			out, err := autoTagUC.Execute(cmd.Context(), internal.AutoTagInput{
+				Key: key, Scope: scopeHint, Provider: provider, Model: model, NoRedact: noRedact,
+			})
+			if errors.Is(err, internal.ErrNotFound) {
+				return fmt.Errorf("autotag: %s: %w", key, err)
+			}
+			if err != nil {
+				return fmt.Errorf("autotag: %w", err)
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(autotagJSON{
+					Key:        key,
+					Tags:       out.Tags,
+					Category:   out.Category,
+					Confidence: out.Confidence,
+				})
+			}
+
+			w := cmd.OutOrStdout()
+			fmt.Fprintf(w, "Tags:       %s\n", strings.Join(out.Tags, ", "))
+			fmt.Fprintf(w, "Category:   %s\n", out.Category)
+			fmt.Fprintf(w, "Confidence: %.2f\n", out.Confidence)
+			return nil
+		},
+	}
+
+	cmd.Flags().String("provider", "", "Provider to use for this run (default: models.autotag or default_provider)")
+	cmd.Flags().String("model", "", "Model to use for this run")
+	cmd.Flags().Bool("no-redact", false, "Send content to the provider without redacting secrets")
+	return cmd
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/4thel00z/memories/internal"
+)
+
+// tagProvider returns fixed tags from GenerateObject and records the prompt.
+type tagProvider struct {
+	internal.NoModels
+	tags   internal.AutoTag
+	prompt string
+}
+
+func (p *tagProvider) Complete(context.Context, string) (string, error) { return "", nil }
+
+func (p *tagProvider) GenerateObject(_ context.Context, prompt string, target any) error {
+	p.prompt = prompt
+	*target.(*internal.AutoTag) = p.tags
+	return nil
+}
+
+func (p *tagProvider) Stream(context.Context, string) (<-chan string, error) { return nil, nil }
+
+func setupAutoTagTest(t *testing.T) (func(args ...string) (string, error), *tagProvider) {
+	t.Helper()
+	a, repo := setupE2E(t)
+	provider := &tagProvider{tags: internal.AutoTag{
+		Tags:       []string{"release", "v2"},
+		Category:   "planning",
+		Confidence: 0.9,
+	}}
+	repoFor := func(internal.Scope) (internal.MemoryRepository, error) { return repo, nil }
+	a.uc.AutoTag = internal.NewAutoTagUseCase(a.resolver, repoFor, internal.StaticProvider(provider))
+
+	run := func(args ...string) (string, error) {
+		root := NewRootCmd("test", a)
+		root.SetArgs(args)
+		var out bytes.Buffer
+		root.SetOut(&out)
+		root.SetErr(&out)
+		err := root.Execute()
+		return out.String(), err
+	}
+	if _, err := run("set", "notes/release", "ship v2 on friday"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	return run, provider
+}
+
+func TestAutoTagCmd(t *testing.T) {
+	run, provider := setupAutoTagTest(t)
+
+	out, err := run("autotag", "notes/release")
+	if err != nil {
+		t.Fatalf("autotag: %v", err)
+	}
+	for _, want := range []string{"Tags:       release, v2\n", "Category:   planning\n", "Confidence: 0.90\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if !strings.Contains(provider.prompt, "ship v2 on friday") {
+		t.Errorf("prompt does not carry the memory: %q", provider.prompt)
+	}
+}
+
+func TestAutoTagCmdJSON(t *testing.T) {
+	run, _ := setupAutoTagTest(t)
+
+	out, err := run("autotag", "notes/release", "--json")
+	if err != nil {
+		t.Fatalf("autotag --json: %v", err)
+	}
+	var got autotagJSON
+	if err := json.Unmarshal([]byte(out), &got); err != nil {
+		t.Fatalf("unmarshal %q: %v", out, err)
+	}
+	if got.Key != "notes/release" || got.Category != "planning" || got.Confidence != 0.9 || len(got.Tags) != 2 {
+		t.Errorf("autotag --json = %+v", got)
+	}
+}
+
+func TestAutoTagCmdNotFound(t *testing.T) {
+	run, _ := setupAutoTagTest(t)
+
+	if _, err := run("autotag", "notes/missing"); !errors.Is(err, internal.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
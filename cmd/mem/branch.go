@@ -1,6 +1,8 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/4thel00z/memories/internal"
@@ -13,16 +15,23 @@ func NewBranchCmd(
 	createUC *internal.BranchCreateUseCase,
 	switchUC *internal.BranchSwitchUseCase,
 	deleteUC *internal.BranchDeleteUseCase,
+	renameUC *internal.BranchRenameUseCase,
 ) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "branch [name]",
-		Short: "List, create, or delete branches",
-		Long:  `List branches, create and switch to a new branch, or delete an existing branch.`,
-		Args:  cobra.MaximumNArgs(1),
-		RunE:  makeBranchRunner(currentUC, listUC, createUC, switchUC, deleteUC),
+		Short: "List, create, rename or delete branches",
+		Long: `List branches, create and switch to a new branch, or delete an existing branch.
+Naming a branch that already exists switches to it. With -m, rename a branch:
+"mem branch -m old new", or "mem branch -m new" for the current branch.`,
+		Args: cobra.MaximumNArgs(2),
+		RunE: makeBranchRunner(currentUC, listUC, createUC, switchUC, deleteUC, renameUC),
 	}
 
 	cmd.Flags().BoolP("delete", "d", false, "Delete branch")
+	cmd.Flags().BoolP("move", "m", false, "Rename branch")
+	cmd.Flags().BoolP("force", "f", false, "Switch despite uncommitted changes, or delete an unmerged branch")
+	cmd.Flags().String("from", "", "Ref to create the branch from (default HEAD)")
+	cmd.Flags().Bool("no-switch", false, "Create the branch without switching to it")
 	return cmd
 }
 
@@ -32,10 +41,20 @@ func makeBranchRunner(
 	createUC *internal.BranchCreateUseCase,
 	switchUC *internal.BranchSwitchUseCase,
 	deleteUC *internal.BranchDeleteUseCase,
+	renameUC *internal.BranchRenameUseCase,
 ) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 		scopeHint, _ := cmd.Flags().GetString("scope")
 		del, _ := cmd.Flags().GetBool("delete")
+		move, _ := cmd.Flags().GetBool("move")
+		force, _ := cmd.Flags().GetBool("force")
+
+		if move {
+			return renameBranch(cmd, renameUC, args, scopeHint)
+		}
+		if len(args) > 1 {
+			return fmt.Errorf("accepts at most 1 arg without -m, received %d", len(args))
+		}
 
 		if len(args) == 0 {
 			return listBranches(cmd, currentUC, listUC, scopeHint)
@@ -43,10 +62,12 @@ func makeBranchRunner(
 
 		name := args[0]
 		if del {
-			return deleteBranch(cmd, deleteUC, name, scopeHint)
+			return deleteBranch(cmd, deleteUC, internal.BranchInput{Name: name, Scope: scopeHint, Force: force})
 		}
 
-		return createAndSwitchBranch(cmd, createUC, switchUC, name, scopeHint)
+		from, _ := cmd.Flags().GetString("from")
+		noSwitch, _ := cmd.Flags().GetBool("no-switch")
+		return createAndSwitchBranch(cmd, createUC, switchUC, internal.BranchInput{Name: name, From: from, Scope: scopeHint, Force: force}, !noSwitch)
 	}
 }
 
@@ -61,6 +82,11 @@ func listBranches(cmd *cobra.Command, currentUC *internal.BranchCurrentUseCase,
 		return fmt.Errorf("list branches: %w", err)
 	}
 
+	asJSON, _ := cmd.Flags().GetBool("json")
+	if asJSON {
+		return outputBranchesJSON(cmd, current.Name, out)
+	}
+
 	for _, b := range out.Branches {
 		prefix := "  "
 		if b.Name == current.Name {
@@ -71,21 +97,72 @@ func listBranches(cmd *cobra.Command, currentUC *internal.BranchCurrentUseCase,
 	return nil
 }
 
-func deleteBranch(cmd *cobra.Command, deleteUC *internal.BranchDeleteUseCase, name, scopeHint string) error {
-	if err := deleteUC.Execute(cmd.Context(), internal.BranchInput{Name: name, Scope: scopeHint}); err != nil {
+func outputBranchesJSON(cmd *cobra.Command, current string, out *internal.BranchListOutput) error {
+	data := make([]map[string]any, len(out.Branches))
+	for i, b := range out.Branches {
+		data[i] = map[string]any{
+			"name":       b.Name,
+			"head":       b.Head,
+			"created_at": b.CreatedAt,
+			"current":    b.Name == current,
+		}
+	}
+
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+func renameBranch(cmd *cobra.Command, renameUC *internal.BranchRenameUseCase, args []string, scopeHint string) error {
+	input := internal.BranchInput{Scope: scopeHint}
+	switch len(args) {
+	case 1:
+		input.NewName = args[0]
+	case 2:
+		input.Name, input.NewName = args[0], args[1]
+	default:
+		return fmt.Errorf("-m needs a new branch name")
+	}
+
+	if err := renameUC.Execute(cmd.Context(), input); err != nil {
+		return fmt.Errorf("rename branch: %w", err)
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Renamed branch to %s\n", input.NewName)
+	return nil
+}
+
+func deleteBranch(cmd *cobra.Command, deleteUC *internal.BranchDeleteUseCase, input internal.BranchInput) error {
+	if err := deleteUC.Execute(cmd.Context(), input); err != nil {
 		return fmt.Errorf("delete branch: %w", err)
 	}
-	fmt.Fprintf(cmd.OutOrStdout(), "Deleted branch %s\n", name)
+	fmt.Fprintf(cmd.OutOrStdout(), "Deleted branch %s\n", input.Name)
 	return nil
 }
 
-func createAndSwitchBranch(cmd *cobra.Command, createUC *internal.BranchCreateUseCase, switchUC *internal.BranchSwitchUseCase, name, scopeHint string) error {
-	if _, err := createUC.Execute(cmd.Context(), internal.BranchInput{Name: name, Scope: scopeHint}); err != nil {
-		return fmt.Errorf("create branch: %w", err)
+// createAndSwitchBranch creates input.Name and, if doSwitch is set, checks it
+// out. A plain `mem branch <name>` on an existing branch just switches to it;
+// with --from or --no-switch the existing branch is an error.
+func createAndSwitchBranch(cmd *cobra.Command, createUC *internal.BranchCreateUseCase, switchUC *internal.BranchSwitchUseCase, input internal.BranchInput, doSwitch bool) error {
+	created := true
+	if _, err := createUC.Execute(cmd.Context(), input); err != nil {
+		if !errors.Is(err, internal.ErrBranchExists) || input.From != "" || !doSwitch {
+			return fmt.Errorf("create branch: %w", err)
+		}
+		created = false
+	}
+
+	if !doSwitch {
+		fmt.Fprintf(cmd.OutOrStdout(), "Created branch %s\n", input.Name)
+		return nil
 	}
-	if err := switchUC.Execute(cmd.Context(), internal.BranchInput{Name: name, Scope: scopeHint}); err != nil {
+
+	if err := switchUC.Execute(cmd.Context(), internal.BranchInput{Name: input.Name, Scope: input.Scope, Force: input.Force}); err != nil {
 		return fmt.Errorf("switch branch: %w", err)
 	}
-	fmt.Fprintf(cmd.OutOrStdout(), "Switched to new branch %s\n", name)
+	if created {
+		fmt.Fprintf(cmd.OutOrStdout(), "Switched to new branch %s\n", input.Name)
+	} else {
+		fmt.Fprintf(cmd.OutOrStdout(), "Switched to branch %s\n", input.Name)
+	}
 	return nil
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -17,6 +18,7 @@ func setupBranchTest(t *testing.T) (
 	*internal.BranchCreateUseCase,
 	*internal.BranchSwitchUseCase,
 	*internal.BranchDeleteUseCase,
+	*internal.BranchRenameUseCase,
 ) {
 	t.Helper()
 	tmpDir := t.TempDir()
@@ -45,13 +47,14 @@ func setupBranchTest(t *testing.T) (
 		internal.NewBranchListUseCase(resolver, branchFor),
 		internal.NewBranchCreateUseCase(resolver, branchFor),
 		internal.NewBranchSwitchUseCase(resolver, branchFor),
-		internal.NewBranchDeleteUseCase(resolver, branchFor)
+		internal.NewBranchDeleteUseCase(resolver, branchFor),
+		internal.NewBranchRenameUseCase(resolver, branchFor)
 }
 
 func TestBranchCmdList(t *testing.T) {
-	currentUC, listUC, createUC, switchUC, deleteUC := setupBranchTest(t)
+	currentUC, listUC, createUC, switchUC, deleteUC, renameUC := setupBranchTest(t)
 
-	cmd := NewBranchCmd(currentUC, listUC, createUC, switchUC, deleteUC)
+	cmd := NewBranchCmd(currentUC, listUC, createUC, switchUC, deleteUC, renameUC)
 
 	var out bytes.Buffer
 	cmd.SetOut(&out)
@@ -67,9 +70,9 @@ func TestBranchCmdList(t *testing.T) {
 }
 
 func TestBranchCmdCreateAndSwitch(t *testing.T) {
-	currentUC, listUC, createUC, switchUC, deleteUC := setupBranchTest(t)
+	currentUC, listUC, createUC, switchUC, deleteUC, renameUC := setupBranchTest(t)
 
-	cmd := NewBranchCmd(currentUC, listUC, createUC, switchUC, deleteUC)
+	cmd := NewBranchCmd(currentUC, listUC, createUC, switchUC, deleteUC, renameUC)
 	cmd.SetArgs([]string{"feature"})
 
 	var out bytes.Buffer
@@ -93,11 +96,71 @@ func TestBranchCmdCreateAndSwitch(t *testing.T) {
 	}
 }
 
+func TestBranchCmdNoSwitch(t *testing.T) {
+	currentUC, listUC, createUC, switchUC, deleteUC, renameUC := setupBranchTest(t)
+
+	before, err := currentUC.Execute(context.Background(), internal.BranchInput{})
+	if err != nil {
+		t.Fatalf("get current: %v", err)
+	}
+
+	cmd := NewBranchCmd(currentUC, listUC, createUC, switchUC, deleteUC, renameUC)
+	cmd.SetArgs([]string{"--no-switch", "feature"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !strings.Contains(out.String(), "Created branch feature") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+
+	after, err := currentUC.Execute(context.Background(), internal.BranchInput{})
+	if err != nil {
+		t.Fatalf("get current: %v", err)
+	}
+	if after.Name != before.Name {
+		t.Errorf("current branch = %q, want %q", after.Name, before.Name)
+	}
+
+	// Creating it again is an error rather than a silent reset.
+	again := NewBranchCmd(currentUC, listUC, createUC, switchUC, deleteUC, renameUC)
+	again.SetArgs([]string{"--no-switch", "feature"})
+	again.SetOut(&out)
+	again.SetErr(&out)
+	if err := again.Execute(); !errors.Is(err, internal.ErrBranchExists) {
+		t.Errorf("expected ErrBranchExists, got %v", err)
+	}
+}
+
+func TestBranchCmdRename(t *testing.T) {
+	currentUC, listUC, createUC, switchUC, deleteUC, renameUC := setupBranchTest(t)
+
+	cmd := NewBranchCmd(currentUC, listUC, createUC, switchUC, deleteUC, renameUC)
+	cmd.SetArgs([]string{"-m", "trunk"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if !strings.Contains(out.String(), "Renamed branch to trunk") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+
+	current, err := currentUC.Execute(context.Background(), internal.BranchInput{})
+	if err != nil {
+		t.Fatalf("get current: %v", err)
+	}
+	if current.Name != "trunk" {
+		t.Errorf("current branch = %q, want %q", current.Name, "trunk")
+	}
+}
+
 func TestBranchCmdDelete(t *testing.T) {
-	currentUC, listUC, createUC, switchUC, deleteUC := setupBranchTest(t)
+	currentUC, listUC, createUC, switchUC, deleteUC, renameUC := setupBranchTest(t)
 
 	// Create a branch first
-	createCmd := NewBranchCmd(currentUC, listUC, createUC, switchUC, deleteUC)
+	createCmd := NewBranchCmd(currentUC, listUC, createUC, switchUC, deleteUC, renameUC)
 	createCmd.SetArgs([]string{"to-delete"})
 	var buf bytes.Buffer
 	createCmd.SetOut(&buf)
@@ -106,7 +169,7 @@ func TestBranchCmdDelete(t *testing.T) {
 	}
 
 	// Switch back to main so we can delete
-	switchCmd := NewBranchCmd(currentUC, listUC, createUC, switchUC, deleteUC)
+	switchCmd := NewBranchCmd(currentUC, listUC, createUC, switchUC, deleteUC, renameUC)
 	switchCmd.SetArgs([]string{"main"})
 	switchCmd.SetOut(&buf)
 	if err := switchCmd.Execute(); err != nil {
@@ -114,7 +177,7 @@ func TestBranchCmdDelete(t *testing.T) {
 	}
 
 	// Delete
-	delCmd := NewBranchCmd(currentUC, listUC, createUC, switchUC, deleteUC)
+	delCmd := NewBranchCmd(currentUC, listUC, createUC, switchUC, deleteUC, renameUC)
 	delCmd.SetArgs([]string{"-d", "to-delete"})
 	var out bytes.Buffer
 	delCmd.SetOut(&out)
@@ -129,7 +192,7 @@ func TestBranchCmdDelete(t *testing.T) {
 }
 
 func TestBranchCmdDeleteCurrentFails(t *testing.T) {
-	currentUC, listUC, createUC, switchUC, deleteUC := setupBranchTest(t)
+	currentUC, listUC, createUC, switchUC, deleteUC, renameUC := setupBranchTest(t)
 
 	// Try to delete current branch
 	current, err := currentUC.Execute(context.Background(), internal.BranchInput{})
@@ -137,7 +200,7 @@ func TestBranchCmdDeleteCurrentFails(t *testing.T) {
 		t.Fatalf("get current: %v", err)
 	}
 
-	cmd := NewBranchCmd(currentUC, listUC, createUC, switchUC, deleteUC)
+	cmd := NewBranchCmd(currentUC, listUC, createUC, switchUC, deleteUC, renameUC)
 	cmd.SetArgs([]string{"-d", current.Name})
 
 	var out bytes.Buffer
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
@@ -19,6 +20,7 @@ func NewCommitCmd(commitUC *internal.CommitUseCase) *cobra.Command {
 	}
 
 	cmd.Flags().StringP("message", "m", "", "Commit message")
+	cmd.Flags().Bool("no-sign", false, "Do not sign this commit, even if git.signing_key is set")
 	return cmd
 }
 
@@ -26,10 +28,11 @@ func makeCommitRunner(commitUC *internal.CommitUseCase) func(*cobra.Command, []s
 	return func(cmd *cobra.Command, args []string) error {
 		message, _ := cmd.Flags().GetString("message")
 		scopeHint, _ := cmd.Flags().GetString("scope")
+		noSign, _ := cmd.Flags().GetBool("no-sign")
 
 		if message == "" {
 			var err error
-			message, err = getMessageFromEditor()
+			message, err = getMessageFromEditor(cmd)
 			if err != nil {
 				return fmt.Errorf("get message: %w", err)
 			}
@@ -40,8 +43,12 @@ func makeCommitRunner(commitUC *internal.CommitUseCase) func(*cobra.Command, []s
 		}
 
 		out, err := commitUC.Execute(cmd.Context(), internal.CommitInput{
-			Message: message, Scope: scopeHint,
+			Message: message, Scope: scopeHint, NoSign: noSign,
 		})
+		if errors.Is(err, internal.ErrNothingToCommit) {
+			fmt.Fprintln(cmd.OutOrStdout(), "Nothing to commit")
+			return nil
+		}
 		if err != nil {
 			return fmt.Errorf("commit: %w", err)
 		}
@@ -51,10 +58,14 @@ func makeCommitRunner(commitUC *internal.CommitUseCase) func(*cobra.Command, []s
 	}
 }
 
-func getMessageFromEditor() (string, error) {
-	editor := os.Getenv("EDITOR")
-	if editor == "" {
-		editor = "vi"
+func getMessageFromEditor(cmd *cobra.Command) (string, error) {
+	if err := requireInteractive(cmd, "message required (use -m)"); err != nil {
+		return "", err
+	}
+
+	argv, err := editorArgv("")
+	if err != nil {
+		return "", err
 	}
 
 	tmpFile, err := os.CreateTemp("", "mem-commit-*.txt")
@@ -68,7 +79,7 @@ func getMessageFromEditor() (string, error) {
 	}
 	tmpFile.Close()
 
-	c := exec.Command(editor, tmpFile.Name())
+	c := exec.Command(argv[0], append(argv[1:], tmpFile.Name())...)
 	c.Stdin = os.Stdin
 	c.Stdout = os.Stdout
 	c.Stderr = os.Stderr
@@ -5,6 +5,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -93,6 +94,7 @@ func TestCommitCmdNoMessage(t *testing.T) {
 
 	// Set EDITOR to false so it exits immediately with error
 	t.Setenv("EDITOR", "false")
+	stubTerminal(t, true)
 
 	cmd := NewCommitCmd(commitUC)
 
@@ -106,6 +108,59 @@ func TestCommitCmdNoMessage(t *testing.T) {
 	}
 }
 
+// stubTerminal makes commands believe stdin is (or is not) a terminal.
+func stubTerminal(t *testing.T, isTerminal bool) {
+	t.Helper()
+	orig := stdinIsTerminal
+	stdinIsTerminal = func() bool { return isTerminal }
+	t.Cleanup(func() { stdinIsTerminal = orig })
+}
+
+func TestCommitCmdNonInteractive(t *testing.T) {
+	for _, flag := range []string{"--yes", "--non-interactive"} {
+		t.Run(flag, func(t *testing.T) {
+			a, _ := setupE2E(t)
+			stubTerminal(t, true)
+
+			// The editor would leave a marker behind if it ever ran.
+			marker := filepath.Join(t.TempDir(), "editor-ran")
+			editorScript := filepath.Join(t.TempDir(), "editor.sh")
+			if err := os.WriteFile(editorScript, []byte("#!/bin/sh\ntouch "+marker+"\necho msg > \"$1\"\n"), 0755); err != nil {
+				t.Fatalf("write editor script: %v", err)
+			}
+			t.Setenv("EDITOR", editorScript)
+
+			root := NewRootCmd("test", a)
+			root.SetArgs([]string{flag, "commit"})
+			root.SetOut(&bytes.Buffer{})
+			root.SetErr(&bytes.Buffer{})
+
+			err := root.Execute()
+			if err == nil || !strings.Contains(err.Error(), "non-interactive mode") {
+				t.Fatalf("expected non-interactive error, got %v", err)
+			}
+			if _, err := os.Stat(marker); err == nil {
+				t.Error("editor was launched in non-interactive mode")
+			}
+		})
+	}
+}
+
+func TestCommitCmdNoTerminal(t *testing.T) {
+	_, commitUC := setupCommitTest(t)
+	stubTerminal(t, false)
+	t.Setenv("EDITOR", "true")
+
+	cmd := NewCommitCmd(commitUC)
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	err := cmd.Execute()
+	if err == nil || !strings.Contains(err.Error(), "message required") {
+		t.Fatalf("expected message required error, got %v", err)
+	}
+}
+
 func TestCommitCmdEmptyWorktree(t *testing.T) {
 	_, commitUC := setupCommitTest(t)
 
@@ -116,8 +171,39 @@ func TestCommitCmdEmptyWorktree(t *testing.T) {
 	cmd.SetOut(&out)
 	cmd.SetErr(&out)
 
-	err := cmd.Execute()
-	if err == nil {
-		t.Error("expected error for empty worktree commit")
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("clean worktree commit should succeed, got %v", err)
+	}
+	if !strings.Contains(out.String(), "Nothing to commit") {
+		t.Errorf("expected 'Nothing to commit', got %q", out.String())
+	}
+}
+
+func TestCommitCmdNoSign(t *testing.T) {
+	repo, commitUC := setupCommitTest(t)
+	repo.SetSigningKey(filepath.Join(t.TempDir(), "missing.asc"))
+
+	key, _ := internal.NewKey("unsigned")
+	if err := repo.Save(context.Background(), internal.NewMemory(key, []byte("content"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	cmd := NewCommitCmd(commitUC)
+	cmd.SetArgs([]string{"-m", "test: signed"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "signing key") {
+		t.Fatalf("commit with an unusable signing key: want a signing key error, got %v", err)
+	}
+
+	cmd = NewCommitCmd(commitUC)
+	cmd.SetArgs([]string{"-m", "test: unsigned", "--no-sign"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("commit --no-sign: %v", err)
+	}
+	if !strings.Contains(out.String(), "test: unsigned") {
+		t.Errorf("output = %q", out.String())
 	}
 }
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewCompactCmd(compactUC *internal.CompactUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "compact <prefix>",
+		Short: "Condense old memories under a prefix into one document",
+		Long: `Ask the provider to condense the memories under <prefix> into a single
+document at --target (default knowledge/<prefix>), then delete the
+originals, or move them under archive/ with --archive, and commit once.
+An existing target is included in the prompt, so repeated runs extend it.
+
+Run it from cron or a scheduled job to keep hook output from piling up:
+
+  mem compact hooks/commits --older-than 30d --target knowledge/commits --archive
+
+--dry-run lists the memories that would be consumed and the estimated
+prompt size in tokens without calling the provider.`,
+		Args: cobra.ExactArgs(1),
+		RunE: makeCompactRunner(compactUC),
+	}
+
+	cmd.Flags().String("older-than", "", "Only consume memories not updated within this age (e.g. 30d, 12h)")
+	cmd.Flags().String("target", "", "Key of the condensed document (default knowledge/<prefix>)")
+	cmd.Flags().Bool("archive", false, "Move consumed memories under archive/ instead of deleting them")
+	cmd.Flags().Bool("dry-run", false, "Show what would be consumed without changing anything")
+	cmd.Flags().String("provider", "", "Provider to use instead of the configured one")
+	cmd.Flags().String("model", "", "Model to use instead of the configured one")
+	cmd.Flags().StringP("message", "m", "", "Commit message")
+	cmd.Flags().Bool("no-redact", false, "Send content to the provider without redacting secrets")
+	return cmd
+}
+
+func makeCompactRunner(compactUC *internal.CompactUseCase) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		scopeHint, _ := cmd.Flags().GetString("scope")
+		olderThan, _ := cmd.Flags().GetString("older-than")
+		target, _ := cmd.Flags().GetString("target")
+		archive, _ := cmd.Flags().GetBool("archive")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		provider, _ := cmd.Flags().GetString("provider")
+		model, _ := cmd.Flags().GetString("model")
+		message, _ := cmd.Flags().GetString("message")
+		noRedact, _ := cmd.Flags().GetBool("no-redact")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		input := internal.CompactInput{
+			Prefix: args[0], Target: target, Archive: archive, DryRun: dryRun,
+			Scope: scopeHint, Provider: provider, Model: model, Message: message,
+			NoRedact: noRedact,
+		}
+		if olderThan != "" {
+			age, err := parseAge(olderThan)
+			if err != nil {
+				return fmt.Errorf("invalid --older-than: %w", err)
+			}
+			input.OlderThan = age
+		}
+
+		out, err := compactUC.Execute(cmd.Context(), input)
+		if err != nil {
+			return fmt.Errorf("compact: %w", err)
+		}
+
+		if asJSON {
+			data := map[string]any{
+				"target":       out.Target,
+				"consumed":     emptyIfNil(out.Consumed),
+				"archived":     emptyIfNil(out.Archived),
+				"input_tokens": out.InputTokens,
+				"dry_run":      out.DryRun,
+			}
+			if out.Commit != nil {
+				data["commit"] = out.Commit.Hash
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(data)
+		}
+
+		if len(out.Consumed) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "Nothing to compact.")
+			return nil
+		}
+
+		for _, key := range out.Consumed {
+			fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", key)
+		}
+		if out.DryRun {
+			fmt.Fprintf(cmd.OutOrStdout(), "Would condense %d memories into %s (~%d input tokens)\n", len(out.Consumed), out.Target, out.InputTokens)
+			return nil
+		}
+
+		verb := "deleted"
+		if archive {
+			verb = "archived"
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Condensed %d memories into %s, originals %s", len(out.Consumed), out.Target, verb)
+		if out.Commit != nil {
+			fmt.Fprintf(cmd.OutOrStdout(), " (%s)", out.Commit.Hash[:7])
+		}
+		fmt.Fprintln(cmd.OutOrStdout())
+		return nil
+	}
+}
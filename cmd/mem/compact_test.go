@@ -0,0 +1,53 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/4thel00z/memories/internal"
+)
+
+func TestCompactCmdDryRun(t *testing.T) {
+	a, repo := setupE2E(t)
+
+	for _, k := range []string{"hooks/commits/a", "hooks/commits/b", "notes/other"} {
+		root := NewRootCmd("test", a)
+		root.SetArgs([]string{"set", k, "content of " + k})
+		root.SetOut(&bytes.Buffer{})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("set %s: %v", k, err)
+		}
+	}
+
+	root := NewRootCmd("test", a)
+	root.SetArgs([]string{"compact", "hooks/commits", "--target", "knowledge/commits", "--dry-run"})
+	var out bytes.Buffer
+	root.SetOut(&out)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("compact --dry-run: %v", err)
+	}
+
+	output := out.String()
+	if !strings.Contains(output, "hooks/commits/a") || !strings.Contains(output, "Would condense 2 memories into knowledge/commits") {
+		t.Errorf("unexpected output: %q", output)
+	}
+	if strings.Contains(output, "notes/other") {
+		t.Errorf("prefix filter ignored: %q", output)
+	}
+	if exists, _ := repo.Exists(context.Background(), internal.Key("hooks/commits/a")); !exists {
+		t.Error("dry run must not delete memories")
+	}
+
+	root = NewRootCmd("test", a)
+	root.SetArgs([]string{"compact", "hooks/commits", "--older-than", "30d"})
+	out.Reset()
+	root.SetOut(&out)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("compact --older-than: %v", err)
+	}
+	if !strings.Contains(out.String(), "Nothing to compact.") {
+		t.Errorf("fresh memories should not be compacted: %q", out.String())
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// addCompletionInstall adds `completion install` next to the shells of
+// cobra's default completion command, which is created here rather than
+// on first execution so the subcommand has a parent.
+func addCompletionInstall(root *cobra.Command) {
+	root.InitDefaultCompletionCmd()
+	for _, cmd := range root.Commands() {
+		if cmd.Name() == "completion" {
+			cmd.AddCommand(newCompletionInstallCmd())
+		}
+	}
+}
+
+func newCompletionInstallCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "install [bash|zsh|fish]",
+		Short: "Install the completion script for your shell",
+		Long: `Write the completion script to where the shell looks for it, creating
+directories as needed. The shell defaults to the one in $SHELL.
+
+  bash  $XDG_DATA_HOME/bash-completion/completions/mem (~/.local/share/...)
+  zsh   ~/.zsh/completions/_mem, which must be on $fpath
+  fish  $XDG_CONFIG_HOME/fish/completions/mem.fish (~/.config/...)
+
+An existing file is only replaced if it is a mem completion script, unless
+--force is given. --stdout prints the script instead.`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: []string{"bash", "zsh", "fish"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			toStdout, _ := cmd.Flags().GetBool("stdout")
+			force, _ := cmd.Flags().GetBool("force")
+
+			shell := filepath.Base(os.Getenv("SHELL"))
+			if len(args) == 1 {
+				shell = args[0]
+			}
+			var script bytes.Buffer
+			if err := genCompletion(cmd.Root(), shell, &script); err != nil {
+				return err
+			}
+			if toStdout {
+				_, err := cmd.OutOrStdout().Write(script.Bytes())
+				return err
+			}
+
+			path, err := completionPath(shell)
+			if err != nil {
+				return err
+			}
+			if existing, err := os.ReadFile(path); err == nil && !force && !isCompletionScript(cmd.Root(), existing) {
+				return fmt.Errorf("%s exists and is not a mem completion script; use --force to overwrite it", path)
+			}
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return fmt.Errorf("create completion directory: %w", err)
+			}
+			if err := os.WriteFile(path, script.Bytes(), 0644); err != nil {
+				return fmt.Errorf("write completion: %w", err)
+			}
+
+			w := cmd.OutOrStdout()
+			fmt.Fprintf(w, "Wrote %s completion to %s\n", shell, path)
+			switch shell {
+			case "zsh":
+				fmt.Fprintf(w, "Make sure ~/.zshrc has fpath=(%s $fpath) before compinit, then restart your shell.\n", filepath.Dir(path))
+			case "bash":
+				fmt.Fprintln(w, "Restart your shell to load it (needs the bash-completion package).")
+			case "fish":
+				fmt.Fprintln(w, "fish loads it on the next completion; no restart needed.")
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("stdout", false, "Print the script instead of installing it")
+	cmd.Flags().Bool("force", false, "Overwrite an existing file that is not a mem completion script")
+	return cmd
+}
+
+func genCompletion(root *cobra.Command, shell string, w *bytes.Buffer) error {
+	switch shell {
+	case "bash":
+		return root.GenBashCompletionV2(w, true)
+	case "zsh":
+		return root.GenZshCompletion(w)
+	case "fish":
+		return root.GenFishCompletion(w, true)
+	case "", ".":
+		return errors.New("cannot detect the shell from $SHELL; name it: mem completion install bash|zsh|fish")
+	default:
+		return fmt.Errorf("cannot install completion for %s; use `mem completion %s` and see its help", shell, shell)
+	}
+}
+
+// completionPath is where shell looks for mem's completion script.
+func completionPath(shell string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	dataHome := cmp.Or(os.Getenv("XDG_DATA_HOME"), filepath.Join(home, ".local", "share"))
+	configHome := cmp.Or(os.Getenv("XDG_CONFIG_HOME"), filepath.Join(home, ".config"))
+	switch shell {
+	case "bash":
+		return filepath.Join(dataHome, "bash-completion", "completions", "mem"), nil
+	case "zsh":
+		return filepath.Join(home, ".zsh", "completions", "_mem"), nil
+	default:
+		return filepath.Join(configHome, "fish", "completions", "mem.fish"), nil
+	}
+}
+
+// isCompletionScript reports whether data is a completion script cobra
+// generated for root; they all define helpers named __<root>_*.
+func isCompletionScript(root *cobra.Command, data []byte) bool {
+	return strings.Contains(string(data), "__"+root.Name()+"_")
+}
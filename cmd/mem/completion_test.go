@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// completionRoot is a root with one subcommand, enough for cobra to add
+// its completion command.
+func completionRoot() *cobra.Command {
+	root := NewRootCmd("1.0.0", nil)
+	root.AddCommand(&cobra.Command{Use: "get", Run: func(*cobra.Command, []string) {}})
+	addCompletionInstall(root)
+	return root
+}
+
+func runCompletion(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	root := completionRoot()
+	root.SetArgs(args)
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&bytes.Buffer{})
+	err := root.Execute()
+	return out.String(), err
+}
+
+func TestCompletionInstall(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("SHELL", "/usr/bin/zsh")
+
+	out, err := runCompletion(t, "completion", "install")
+	if err != nil {
+		t.Fatalf("install: %v", err)
+	}
+	path := filepath.Join(home, ".zsh", "completions", "_mem")
+	if !strings.Contains(out, path) || !strings.Contains(out, "restart your shell") {
+		t.Errorf("output = %q", out)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "#compdef mem") {
+		t.Fatalf("zsh script = %q (%v)", data, err)
+	}
+
+	// Its own script is replaced without --force.
+	if _, err := runCompletion(t, "completion", "install", "zsh"); err != nil {
+		t.Errorf("reinstall: %v", err)
+	}
+
+	if _, err := runCompletion(t, "completion", "install", "fish"); err != nil {
+		t.Fatalf("install fish: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".config", "fish", "completions", "mem.fish")); err != nil {
+		t.Errorf("fish script: %v", err)
+	}
+}
+
+func TestCompletionInstallRefusesForeignFile(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", dataHome)
+
+	path := filepath.Join(dataHome, "bash-completion", "completions", "mem")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("# someone else's mem\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := runCompletion(t, "completion", "install", "bash"); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Fatalf("install over a foreign file: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "# someone else's mem\n" {
+		t.Errorf("foreign file changed to %q", data)
+	}
+
+	if _, err := runCompletion(t, "completion", "install", "bash", "--force"); err != nil {
+		t.Fatalf("install --force: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "__mem_") {
+		t.Errorf("bash script not written: %q", data)
+	}
+}
+
+func TestCompletionInstallStdout(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	out, err := runCompletion(t, "completion", "install", "fish", "--stdout")
+	if err != nil {
+		t.Fatalf("install --stdout: %v", err)
+	}
+	if !strings.Contains(out, "complete -c mem") {
+		t.Errorf("output = %q", out)
+	}
+	if entries, _ := os.ReadDir(home); len(entries) != 0 {
+		t.Errorf("--stdout wrote files: %v", entries)
+	}
+}
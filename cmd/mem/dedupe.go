@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewDedupeCmd(dedupeUC *internal.DedupeUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dedupe",
+		Short: "Find and merge near-duplicate memories",
+		Long: `Group memories whose similarity is at or above --threshold. Uses index
+vectors when every memory is indexed, and word shingles otherwise.
+
+Without --apply only the clusters are reported. With --apply each cluster
+keeps its most recently updated memory, appends lines it is missing from
+the others, deletes the rest and commits once.
+
+--identical only groups memories with exactly the same content. With
+--apply the newest copy stays and the others become alias memories that
+point at it, so their keys still work with mem get. An alias memory's
+content is "mem-alias: <key>"; mem set, add and edit refuse to write that
+content themselves.`,
+		Args: cobra.NoArgs,
+		RunE: makeDedupeRunner(dedupeUC),
+	}
+
+	cmd.Flags().String("prefix", "", "Only consider memories under this prefix")
+	cmd.Flags().Float64("threshold", internal.DefaultDedupeThreshold, "Similarity (0-1) at which memories count as duplicates")
+	cmd.Flags().Bool("identical", false, "Only group memories with identical content, ignoring --threshold")
+	cmd.Flags().Bool("apply", false, "Merge clusters and commit")
+	cmd.Flags().Bool("dry-run", false, "Report clusters without changing anything (overrides --apply)")
+	cmd.Flags().StringP("message", "m", "", "Commit message")
+	return cmd
+}
+
+func makeDedupeRunner(dedupeUC *internal.DedupeUseCase) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, _ []string) error {
+		scopeHint, _ := cmd.Flags().GetString("scope")
+		prefix, _ := cmd.Flags().GetString("prefix")
+		threshold, _ := cmd.Flags().GetFloat64("threshold")
+		identical, _ := cmd.Flags().GetBool("identical")
+		apply, _ := cmd.Flags().GetBool("apply")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		message, _ := cmd.Flags().GetString("message")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		apply = apply && !dryRun
+
+		out, err := dedupeUC.Execute(cmd.Context(), internal.DedupeInput{
+			Prefix: prefix, Threshold: threshold, Scope: scopeHint, Apply: apply, Message: message,
+			Identical: identical,
+		})
+		if err != nil {
+			return fmt.Errorf("dedupe: %w", err)
+		}
+
+		if asJSON {
+			clusters := make([]map[string]any, 0, len(out.Clusters))
+			for _, c := range out.Clusters {
+				clusters = append(clusters, map[string]any{
+					"survivor":   c.Survivor,
+					"duplicates": c.Duplicates,
+				})
+			}
+			data := map[string]any{
+				"method":   out.Method,
+				"clusters": clusters,
+				"applied":  apply,
+			}
+			if out.Commit != nil {
+				data["commit"] = out.Commit.Hash
+			}
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(data)
+		}
+
+		if len(out.Clusters) == 0 {
+			fmt.Fprintln(cmd.OutOrStdout(), "No duplicates found.")
+			return nil
+		}
+
+		for _, c := range out.Clusters {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s (keep)\n", c.Survivor)
+			for _, dup := range c.Duplicates {
+				fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", dup)
+			}
+		}
+
+		if out.Commit != nil && identical {
+			fmt.Fprintf(cmd.OutOrStdout(), "Aliased the duplicates of %d clusters in %s\n", len(out.Clusters), out.Commit.Hash[:7])
+		} else if out.Commit != nil {
+			fmt.Fprintf(cmd.OutOrStdout(), "Merged %d clusters (%s) in %s\n", len(out.Clusters), out.Method, out.Commit.Hash[:7])
+		} else {
+			fmt.Fprintf(cmd.OutOrStdout(), "Found %d clusters (%s); run with --apply to merge\n", len(out.Clusters), out.Method)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/4thel00z/memories/internal"
+)
+
+func TestDedupeCmdDryRunThenApply(t *testing.T) {
+	a, repo := setupE2E(t)
+
+	for _, kv := range [][2]string{
+		{"hooks/commits/a", "added parser for config files and tests"},
+		{"hooks/commits/b", "added parser for config files and tests"},
+		{"hooks/commits/c", "bumped dependency versions"},
+		{"notes/other", "added parser for config files and tests"},
+	} {
+		root := NewRootCmd("test", a)
+		root.SetArgs([]string{"set", kv[0], kv[1]})
+		root.SetOut(&bytes.Buffer{})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("set %s: %v", kv[0], err)
+		}
+	}
+
+	root := NewRootCmd("test", a)
+	root.SetArgs([]string{"dedupe", "--prefix", "hooks/commits", "--threshold", "0.95", "--apply", "--dry-run"})
+	var out bytes.Buffer
+	root.SetOut(&out)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("dedupe --dry-run: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "Found 1 clusters") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+	if strings.Contains(out.String(), "notes/other") {
+		t.Errorf("prefix filter ignored: %q", out.String())
+	}
+	for _, key := range []string{"hooks/commits/a", "hooks/commits/b"} {
+		if exists, _ := repo.Exists(context.Background(), internal.Key(key)); !exists {
+			t.Errorf("dry run must not delete %s", key)
+		}
+	}
+
+	root = NewRootCmd("test", a)
+	root.SetArgs([]string{"dedupe", "--prefix", "hooks/commits", "--apply"})
+	out.Reset()
+	root.SetOut(&out)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("dedupe --apply: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "Merged 1 clusters") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+	memories, _ := repo.List(context.Background(), "hooks/commits")
+	if len(memories) != 2 {
+		t.Errorf("expected 2 memories after merge, got %d", len(memories))
+	}
+}
+
+func TestDedupeCmdIdenticalAliasesRoundTrip(t *testing.T) {
+	a, repo := setupE2E(t)
+	ctx := context.Background()
+
+	run := func(args ...string) string {
+		t.Helper()
+		root := NewRootCmd("test", a)
+		root.SetArgs(args)
+		var out bytes.Buffer
+		root.SetOut(&out)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		return out.String()
+	}
+
+	summary := "feat: add parser\n\nParses config files.\n"
+	run("set", "hooks/commits/a", summary)
+	run("set", "hooks/commits/b", summary)
+	run("set", "hooks/commits/c", summary+"And tests.\n")
+
+	if out := run("stats", "--duplicates"); !strings.Contains(out, "1 duplicate groups") {
+		t.Errorf("stats --duplicates = %q, want 1 duplicate group", out)
+	}
+
+	out := run("dedupe", "--identical", "--apply")
+	if !strings.Contains(out, "Aliased the duplicates of 1 clusters") {
+		t.Errorf("unexpected output: %q", out)
+	}
+
+	aliases := 0
+	for _, key := range []string{"hooks/commits/a", "hooks/commits/b"} {
+		mem, err := repo.Get(ctx, internal.Key(key))
+		if err != nil {
+			t.Fatalf("get %s: %v", key, err)
+		}
+		if strings.HasPrefix(string(mem.Content), internal.AliasMemoryMarker) {
+			aliases++
+		}
+		if got := run("get", key); got != summary {
+			t.Errorf("mem get %s = %q, want the shared content %q", key, got, summary)
+		}
+	}
+	if aliases != 1 {
+		t.Errorf("%d alias memories, want 1 beside the survivor", aliases)
+	}
+	if got := run("get", "hooks/commits/c"); got == summary {
+		t.Error("a memory with different content must not be aliased")
+	}
+
+	if out := run("stats", "--duplicates"); !strings.Contains(out, "0 duplicate groups") {
+		t.Errorf("stats --duplicates after dedupe = %q, want no duplicates", out)
+	}
+}
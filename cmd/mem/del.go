@@ -2,42 +2,125 @@ package main
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/4thel00z/memories/internal"
 	"github.com/spf13/cobra"
 )
 
-func NewDelCmd(delUC *internal.DeleteMemoryUseCase, commitUC *internal.CommitUseCase) *cobra.Command {
+func NewDelCmd(delUC *internal.DeleteMemoryUseCase, attachUC *internal.AttachmentUseCase, commitUC *internal.CommitUseCase, aliasUC *internal.AliasUseCase) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "del <key>",
 		Aliases: []string{"delete", "rm"},
 		Short:   "Delete a memory",
-		Long:    `Delete a memory by key.`,
-		Args:    cobra.ExactArgs(1),
-		RunE:    makeDelRunner(delUC, commitUC),
+		Long: `Delete a memory by key, or every memory under a prefix with --prefix.
+With --soft the memory is moved to the trash instead; bring it back with
+mem restore-trash. With --gc, attachments no memory references any more are
+removed as well.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: makeDelRunner(delUC, attachUC, commitUC, aliasUC),
 	}
 
 	cmd.Flags().StringP("message", "m", "", "Commit message")
+	cmd.Flags().String("prefix", "", "Delete all memories under this prefix")
+	cmd.Flags().Bool("dry-run", false, "Show what would be deleted without deleting")
+	cmd.Flags().Bool("soft", false, "Move to the trash instead of deleting")
+	cmd.Flags().Bool("gc", false, "Also remove attachments that are no longer referenced")
 	return cmd
 }
 
-func makeDelRunner(delUC *internal.DeleteMemoryUseCase, commitUC *internal.CommitUseCase) func(*cobra.Command, []string) error {
+func makeDelRunner(delUC *internal.DeleteMemoryUseCase, attachUC *internal.AttachmentUseCase, commitUC *internal.CommitUseCase, aliasUC *internal.AliasUseCase) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		key := args[0]
 		scopeHint, _ := cmd.Flags().GetString("scope")
 		message, _ := cmd.Flags().GetString("message")
+		prefix, _ := cmd.Flags().GetString("prefix")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		soft, _ := cmd.Flags().GetBool("soft")
+		gc, _ := cmd.Flags().GetBool("gc")
+		asJSON, _ := cmd.Flags().GetBool("json")
 
-		if err := delUC.Execute(cmd.Context(), internal.DeleteMemoryInput{
-			Key: key, Scope: scopeHint,
-		}); err != nil {
+		if (len(args) == 0) == (prefix == "") {
+			return fmt.Errorf("provide either a key or --prefix")
+		}
+
+		key := prefix + "*"
+		if len(args) > 0 {
+			resolved, err := resolveKeyArg(cmd, aliasUC, args[0])
+			if err != nil {
+				return err
+			}
+			key = resolved
+		}
+
+		out, err := delUC.Execute(cmd.Context(), internal.DeleteMemoryInput{
+			Key: key, Prefix: prefix, Scope: scopeHint, DryRun: dryRun, Soft: soft,
+		})
+		if err != nil {
 			return fmt.Errorf("delete memory: %w", err)
 		}
 
-		if err := autoCommit(cmd.Context(), commitUC, message, "del", key, scopeHint); err != nil {
-			return fmt.Errorf("commit: %w", err)
+		// Collect before committing so removed tracked blobs land in the
+		// same commit as the delete.
+		var collected *internal.AttachGCOutput
+		if gc && attachUC != nil {
+			collected, err = attachUC.GC(cmd.Context(), internal.AttachInput{Scope: scopeHint, DryRun: dryRun})
+			if err != nil {
+				return fmt.Errorf("collect attachments: %w", err)
+			}
 		}
 
-		fmt.Fprintf(cmd.OutOrStdout(), "Deleted %s\n", key)
+		action := "del"
+		if soft {
+			action = "trash"
+		}
+		var commit *internal.CommitOutput
+		if !dryRun {
+			subject := key
+			if prefix != "" {
+				subject = keysSubject(out.Keys)
+			}
+			if commit, err = autoCommit(cmd.Context(), commitUC, message, action, subject, scopeHint); err != nil {
+				return fmt.Errorf("commit: %w", err)
+			}
+		}
+
+		if asJSON {
+			result := writeResultJSON("del", key, "deleted", commit)
+			result["deleted"] = !dryRun
+			result["keys"] = out.Keys
+			result["dry_run"] = out.DryRun
+			result["soft"] = soft
+			if collected != nil {
+				result["attachments_removed"] = collected.Hashes
+			}
+			return outputWriteResultJSON(cmd, result)
+		}
+
+		verb := "Deleted"
+		switch {
+		case dryRun && soft:
+			verb = "Would trash"
+		case dryRun:
+			verb = "Would delete"
+		case soft:
+			verb = "Trashed"
+		}
+		for _, k := range out.Keys {
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", verb, k)
+		}
+		if collected != nil {
+			printCollected(cmd, collected)
+		}
 		return nil
 	}
 }
+
+// keysSubject names the keys a prefix delete removed for its commit
+// message: all of them when there are few, else the first few and a count.
+func keysSubject(keys []string) string {
+	const shown = 3
+	if len(keys) <= shown {
+		return strings.Join(keys, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(keys[:shown], ", "), len(keys)-shown)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -54,7 +55,7 @@ func TestDelCmd(t *testing.T) {
 	delUC := internal.NewDeleteMemoryUseCase(resolver, repoFor, nilIndex)
 	commitUC := internal.NewCommitUseCase(resolver, histFor)
 
-	cmd := NewDelCmd(delUC, commitUC)
+	cmd := NewDelCmd(delUC, nil, commitUC, nil)
 	cmd.SetArgs([]string{"to-delete"})
 
 	var out bytes.Buffer
@@ -102,7 +103,7 @@ func TestDelCmdNotFound(t *testing.T) {
 	delUC := internal.NewDeleteMemoryUseCase(resolver, repoFor, nilIndex)
 	commitUC := internal.NewCommitUseCase(resolver, histFor)
 
-	cmd := NewDelCmd(delUC, commitUC)
+	cmd := NewDelCmd(delUC, nil, commitUC, nil)
 	cmd.SetArgs([]string{"nonexistent"})
 
 	var out bytes.Buffer
@@ -114,3 +115,61 @@ func TestDelCmdNotFound(t *testing.T) {
 		t.Error("expected error for nonexistent key")
 	}
 }
+
+func TestDelCmdPrefixDryRun(t *testing.T) {
+	a, repo := setupE2E(t)
+
+	for _, key := range []string{"tmp/a", "tmp/b", "keep/c"} {
+		root := NewRootCmd("test", a)
+		root.SetArgs([]string{"set", key, "x"})
+		root.SetOut(&bytes.Buffer{})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+	}
+
+	root := NewRootCmd("test", a)
+	root.SetArgs([]string{"del", "--prefix", "tmp", "--dry-run"})
+	var out bytes.Buffer
+	root.SetOut(&out)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("del --dry-run: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "Would delete tmp/a") || !strings.Contains(out.String(), "Would delete tmp/b") {
+		t.Errorf("unexpected plan output: %q", out.String())
+	}
+
+	for _, key := range []string{"tmp/a", "tmp/b"} {
+		exists, err := repo.Exists(context.Background(), internal.Key(key))
+		if err != nil {
+			t.Fatalf("exists: %v", err)
+		}
+		if !exists {
+			t.Errorf("%s was deleted during dry run", key)
+		}
+	}
+
+	root = NewRootCmd("test", a)
+	root.SetArgs([]string{"del", "--prefix", "tmp"})
+	root.SetOut(&bytes.Buffer{})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("del --prefix: %v", err)
+	}
+	commits, err := repo.Log(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if got := strings.TrimSpace(commits[0].Message); got != "del: tmp/a, tmp/b" {
+		t.Errorf("commit message = %q, want the deleted keys", got)
+	}
+
+	exists, _ := repo.Exists(context.Background(), internal.Key("tmp/a"))
+	if exists {
+		t.Error("tmp/a still exists after prefix delete")
+	}
+	exists, _ = repo.Exists(context.Background(), internal.Key("keep/c"))
+	if !exists {
+		t.Error("keep/c should not be deleted")
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/4thel00z/memories/internal"
@@ -11,11 +12,13 @@ func NewDiffCmd(diffUC *internal.DiffUseCase) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "diff [ref]",
 		Short: "Show changes",
-		Long:  `Show uncommitted changes or diff against a specific ref.`,
-		Args:  cobra.MaximumNArgs(1),
-		RunE:  makeDiffRunner(diffUC),
+		Long: `Show uncommitted changes or diff against a specific ref. With --stat
+only the changed keys and their added and removed line counts are shown.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: makeDiffRunner(diffUC),
 	}
 
+	cmd.Flags().Bool("stat", false, "Show changed lines per key instead of the patch")
 	return cmd
 }
 
@@ -27,20 +30,79 @@ func makeDiffRunner(diffUC *internal.DiffUseCase) func(*cobra.Command, []string)
 		}
 
 		scopeHint, _ := cmd.Flags().GetString("scope")
+		stat, _ := cmd.Flags().GetBool("stat")
+		asJSON, _ := cmd.Flags().GetBool("json")
 
 		out, err := diffUC.Execute(cmd.Context(), internal.DiffInput{
-			Ref: ref, Scope: scopeHint,
+			Ref: ref, Scope: scopeHint, Stat: stat,
 		})
 		if err != nil {
 			return fmt.Errorf("get diff: %w", err)
 		}
 
+		if stat {
+			if asJSON {
+				return outputDiffStatJSON(cmd, out.Stats)
+			}
+			printDiffStat(cmd, out.Stats)
+			return nil
+		}
+
 		if out.Diff == "" {
 			fmt.Fprintln(cmd.OutOrStdout(), "No changes.")
 			return nil
 		}
 
-		fmt.Fprint(cmd.OutOrStdout(), out.Diff)
+		diff := out.Diff
+		if colorEnabled(cmd) {
+			diff = colorDiff(diff)
+		}
+		stop := startPager(cmd)
+		defer stop()
+		fmt.Fprint(cmd.OutOrStdout(), diff)
 		return nil
 	}
 }
+
+func printDiffStat(cmd *cobra.Command, stats []internal.FileStat) {
+	w := cmd.OutOrStdout()
+	if len(stats) == 0 {
+		fmt.Fprintln(w, "No changes.")
+		return
+	}
+
+	width := 0
+	for _, st := range stats {
+		width = max(width, len(st.Path))
+	}
+
+	var added, removed int
+	for _, st := range stats {
+		fmt.Fprintf(w, " %-*s | +%d -%d\n", width, st.Path, st.Added, st.Removed)
+		added += st.Added
+		removed += st.Removed
+	}
+	fmt.Fprintf(w, " %d files changed, %d insertions(+), %d deletions(-)\n", len(stats), added, removed)
+}
+
+func outputDiffStatJSON(cmd *cobra.Command, stats []internal.FileStat) error {
+	files := make([]map[string]any, 0, len(stats))
+	var added, removed int
+	for _, st := range stats {
+		files = append(files, map[string]any{
+			"key":     st.Path,
+			"added":   st.Added,
+			"removed": st.Removed,
+		})
+		added += st.Added
+		removed += st.Removed
+	}
+
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{
+		"files":   files,
+		"added":   added,
+		"removed": removed,
+	})
+}
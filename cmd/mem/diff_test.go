@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -90,3 +91,82 @@ func TestDiffCmdWithChanges(t *testing.T) {
 		t.Errorf("expected 'diff content' in output, got %q", output)
 	}
 }
+
+// commitTwoKeysAndModify commits two memories, then stages edits that add
+// two lines and remove one in notes/a and add one line to notes/b.
+func commitTwoKeysAndModify(t *testing.T, repo *internal.GitRepository) {
+	t.Helper()
+	ctx := context.Background()
+	save := func(k, content string) {
+		key, _ := internal.NewKey(k)
+		if err := repo.Save(ctx, internal.NewMemory(key, []byte(content))); err != nil {
+			t.Fatalf("save %s: %v", k, err)
+		}
+	}
+
+	save("notes/a", "one\ntwo\nthree\n")
+	save("notes/b", "alpha\n")
+	if _, err := repo.Commit(ctx, "add notes"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	save("notes/a", "one\nTWO\nthree\nfour\n")
+	save("notes/b", "alpha\nbeta\n")
+}
+
+func TestDiffCmdStat(t *testing.T) {
+	repo, diffUC := setupDiffTest(t)
+	commitTwoKeysAndModify(t, repo)
+
+	cmd := NewDiffCmd(diffUC)
+	cmd.SetArgs([]string{"--stat"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	want := " notes/a | +2 -1\n notes/b | +1 -0\n 2 files changed, 3 insertions(+), 1 deletions(-)\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestDiffCmdStatAgainstRef(t *testing.T) {
+	repo, diffUC := setupDiffTest(t)
+	commitTwoKeysAndModify(t, repo)
+	if _, err := repo.Commit(context.Background(), "edit notes"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	cmd := NewDiffCmd(diffUC)
+	cmd.Root().PersistentFlags().Bool("json", false, "")
+	cmd.SetArgs([]string{"HEAD~1", "--stat", "--json"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	var got struct {
+		Files []struct {
+			Key     string `json:"key"`
+			Added   int    `json:"added"`
+			Removed int    `json:"removed"`
+		} `json:"files"`
+		Added   int `json:"added"`
+		Removed int `json:"removed"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("parse %q: %v", out.String(), err)
+	}
+	if len(got.Files) != 2 || got.Files[0].Key != "notes/a" || got.Files[0].Added != 2 || got.Files[0].Removed != 1 ||
+		got.Files[1].Key != "notes/b" || got.Files[1].Added != 1 || got.Files[1].Removed != 0 {
+		t.Errorf("files = %+v", got.Files)
+	}
+	if got.Added != 3 || got.Removed != 1 {
+		t.Errorf("totals = +%d -%d, want +3 -1", got.Added, got.Removed)
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewDoctorCmd(doctorUC *internal.DoctorUseCase) *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check memory keys for problems across platforms",
+		Long: `Report keys that differ only in case, which are separate files on Linux
+but collide on case-insensitive filesystems such as the macOS default, and
+keys the configured key policy would rename. Run ` + "`mem migrate-keys`" + ` to
+rename the latter. Exits non-zero while problems remain.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			out, err := doctorUC.Execute(cmd.Context(), internal.DoctorInput{Scope: scopeHint})
+			if err != nil {
+				return fmt.Errorf("doctor: %w", err)
+			}
+
+			if asJSON {
+				collisions := out.Collisions
+				if collisions == nil {
+					collisions = [][]string{}
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(map[string]any{
+					"memories":      out.Memories,
+					"collisions":    collisions,
+					"nonconforming": emptyIfNil(out.Nonconforming),
+					"legacy_global": out.LegacyGlobal,
+				}); err != nil {
+					return err
+				}
+			} else {
+				printDoctor(cmd, out)
+			}
+
+			if n := out.Problems(); n > 0 {
+				return fmt.Errorf("doctor: %d problems found", n)
+			}
+			return nil
+		},
+	}
+}
+
+func printDoctor(cmd *cobra.Command, out *internal.DoctorOutput) {
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "%d memories\n", out.Memories)
+
+	if len(out.Collisions) > 0 {
+		fmt.Fprintln(w, "\nKeys that collide on case-insensitive filesystems:")
+		for _, group := range out.Collisions {
+			fmt.Fprintf(w, "  %s\n", strings.Join(group, ", "))
+		}
+	}
+	if len(out.Nonconforming) > 0 {
+		fmt.Fprintln(w, "\nKeys the key policy would rename (run `mem migrate-keys`):")
+		for _, k := range out.Nonconforming {
+			fmt.Fprintf(w, "  %s\n", k)
+		}
+	}
+
+	if out.LegacyGlobal != "" {
+		fmt.Fprintf(w, "\nThe global store is in %s although $XDG_DATA_HOME is set.\n", out.LegacyGlobal)
+		fmt.Fprintf(w, "Move it to use the XDG location: mv %s %s\n", out.LegacyGlobal, out.XDGGlobal)
+	}
+
+	if out.Problems() == 0 {
+		fmt.Fprintln(w, "\nOK")
+	}
+}
+
+func NewMigrateKeysCmd(migrateUC *internal.MigrateKeysUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate-keys",
+		Short: "Rename memories to match the key policy",
+		Long: `Rename every memory whose key the configured key policy would change, for
+example after setting keys.case_sensitivity to lower, and commit the
+renames at once. Nothing is renamed if two memories would end up with the
+same key; merge or delete them first.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			message, _ := cmd.Flags().GetString("message")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			out, err := migrateUC.Execute(cmd.Context(), internal.MigrateKeysInput{
+				Scope: scopeHint, DryRun: dryRun, Message: message,
+			})
+			if err != nil {
+				return fmt.Errorf("migrate-keys: %w", err)
+			}
+
+			if asJSON {
+				renamed := make([]map[string]string, len(out.Renamed))
+				for i, r := range out.Renamed {
+					renamed[i] = map[string]string{"from": r.From, "to": r.To}
+				}
+				data := map[string]any{
+					"renamed": renamed,
+					"dry_run": out.DryRun,
+				}
+				if out.Commit != nil {
+					data["commit"] = out.Commit.Hash
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(data)
+			}
+
+			if len(out.Renamed) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "All keys conform to the key policy.")
+				return nil
+			}
+			for _, r := range out.Renamed {
+				fmt.Fprintf(cmd.OutOrStdout(), "  %s -> %s\n", r.From, r.To)
+			}
+			if out.DryRun {
+				fmt.Fprintf(cmd.OutOrStdout(), "Would rename %d memories\n", len(out.Renamed))
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Renamed %d memories", len(out.Renamed))
+			if out.Commit != nil {
+				fmt.Fprintf(cmd.OutOrStdout(), " (%s)", out.Commit.Hash[:7])
+			}
+			fmt.Fprintln(cmd.OutOrStdout())
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Show the renames without changing anything")
+	cmd.Flags().StringP("message", "m", "", "Commit message")
+	return cmd
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDoctorCmdReportsCollisions(t *testing.T) {
+	a, _ := setupE2E(t)
+
+	for _, k := range []string{"Notes/todo", "notes/todo"} {
+		root := NewRootCmd("test", a)
+		root.SetArgs([]string{"set", k, "content of " + k})
+		root.SetOut(&bytes.Buffer{})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("set %s: %v", k, err)
+		}
+	}
+
+	root := NewRootCmd("test", a)
+	root.SetArgs([]string{"doctor"})
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&bytes.Buffer{})
+	if err := root.Execute(); err == nil || !strings.Contains(err.Error(), "1 problems found") {
+		t.Fatalf("expected doctor to fail with one problem, got %v", err)
+	}
+	if !strings.Contains(out.String(), "Notes/todo, notes/todo") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
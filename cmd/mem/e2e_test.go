@@ -3,51 +3,38 @@ package main
 import (
 	"bytes"
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/4thel00z/memories/internal"
+	"github.com/4thel00z/memories/pkg/v1/memtest"
 )
 
 // setupE2E initializes a full app with all use cases backed by a real git repo.
 func setupE2E(t *testing.T) (*app, *internal.GitRepository) {
 	t.Helper()
-	tmpDir := t.TempDir()
-
-	origWd, _ := os.Getwd()
-	t.Cleanup(func() { _ = os.Chdir(origWd) })
-	if err := os.Chdir(tmpDir); err != nil {
-		t.Fatalf("chdir: %v", err)
-	}
-
-	scope := internal.Scope{
-		Type:    internal.ScopeProject,
-		Path:    tmpDir,
-		MemPath: filepath.Join(tmpDir, ".mem"),
-	}
-
-	if err := os.MkdirAll(scope.VectorPath(), 0755); err != nil {
-		t.Fatalf("mkdir vectors: %v", err)
-	}
-	if err := internal.InitRepository(scope); err != nil {
-		t.Fatalf("init repo: %v", err)
-	}
+	tmpDir := memtest.InitStore(t)
+	resolver := internal.NewScopeResolverAt(tmpDir, "")
+	scope, _ := resolver.Project()
 
 	repo, err := internal.NewGitRepository(scope)
 	if err != nil {
 		t.Fatalf("new repo: %v", err)
 	}
 
-	resolver := internal.NewScopeResolver()
 	repoFor := func(s internal.Scope) (internal.MemoryRepository, error) { return repo, nil }
 	histFor := func(s internal.Scope) (internal.HistoryRepository, error) { return repo, nil }
 	branchFor := func(s internal.Scope) (internal.BranchRepository, error) { return repo, nil }
+	blobsFor := func(s internal.Scope) (internal.AttachmentStore, error) { return repo, nil }
+	snapFor := func(s internal.Scope) (internal.SnapshotRepository, error) { return repo, nil }
 	nilIndex := func(s internal.Scope) (internal.VectorIndex, error) { return nil, internal.ErrNoIndex }
 
 	uc := &internal.UseCases{
 		SetMemory:      internal.NewSetMemoryUseCase(resolver, repoFor, nilIndex, nil, nil),
+		BulkSet:        internal.NewBulkSetUseCase(resolver, repoFor, histFor, nilIndex, nil, nil),
 		GetMemory:      internal.NewGetMemoryUseCase(resolver, repoFor),
 		DeleteMemory:   internal.NewDeleteMemoryUseCase(resolver, repoFor, nilIndex),
 		ListMemories:   internal.NewListMemoriesUseCase(resolver, repoFor),
@@ -59,18 +46,35 @@ func setupE2E(t *testing.T) (*app, *internal.GitRepository) {
 		KeywordSearch:  internal.NewKeywordSearchUseCase(resolver, repoFor),
 		SemanticSearch: internal.NewSemanticSearchUseCase(resolver, nilIndex, nil),
 		RebuildIndex:   internal.NewRebuildIndexUseCase(resolver, repoFor, nilIndex, nil),
+		SyncIndex:      internal.NewSyncIndexUseCase(resolver, branchFor),
+		Verify:         internal.NewVerifyUseCase(resolver, repoFor, nilIndex, nil),
+		Doctor:         internal.NewDoctorUseCase(resolver, repoFor),
+		MigrateKeys:    internal.NewMigrateKeysUseCase(resolver, repoFor, histFor, nilIndex, nil),
 		Summarize:      internal.NewSummarizeUseCase(resolver, repoFor, nil),
+		Ask:            internal.NewAskUseCase(resolver, repoFor, nil),
 		AutoTag:        internal.NewAutoTagUseCase(resolver, repoFor, nil),
 		BranchCurrent:  internal.NewBranchCurrentUseCase(resolver, branchFor),
 		BranchList:     internal.NewBranchListUseCase(resolver, branchFor),
 		BranchCreate:   internal.NewBranchCreateUseCase(resolver, branchFor),
 		BranchSwitch:   internal.NewBranchSwitchUseCase(resolver, branchFor),
 		BranchDelete:   internal.NewBranchDeleteUseCase(resolver, branchFor),
+		BranchRename:   internal.NewBranchRenameUseCase(resolver, branchFor),
 		ProviderList:   internal.NewProviderListUseCase(resolver),
 		ProviderAdd:    internal.NewProviderAddUseCase(resolver),
 		ProviderRemove: internal.NewProviderRemoveUseCase(resolver),
 		ProviderSetDef: internal.NewProviderSetDefaultUseCase(resolver),
 		ProviderTest:   internal.NewProviderTestUseCase(resolver),
+		ProviderModels: internal.NewProviderModelsUseCase(resolver),
+		ProviderUsage:  internal.NewProviderUsageUseCase(resolver),
+		Template:       internal.NewTemplateUseCase(resolver),
+		Alias:          internal.NewAliasUseCase(resolver),
+		Dedupe:         internal.NewDedupeUseCase(resolver, repoFor, histFor, nilIndex, nil),
+		Compact:        internal.NewCompactUseCase(resolver, repoFor, histFor, nilIndex, nil, nil),
+		Stats:          internal.NewStatsUseCase(resolver, repoFor, histFor, branchFor),
+		Trash:          internal.NewTrashUseCase(resolver, repoFor, nilIndex, nil),
+		Touch:          internal.NewTouchUseCase(resolver, repoFor, histFor),
+		Attachment:     internal.NewAttachmentUseCase(resolver, repoFor, blobsFor),
+		Snapshot:       internal.NewSnapshotUseCase(resolver, snapFor, branchFor),
 	}
 
 	a := &app{
@@ -328,8 +332,10 @@ func TestE2EDiffWorkflow(t *testing.T) {
 	root.SetArgs([]string{"commit", "-m", "initial"})
 	var out bytes.Buffer
 	root.SetOut(&out)
-	// May fail if nothing to commit - that's fine, init already committed
-	_ = root.Execute()
+	// init already committed everything, so this reports nothing to commit
+	if err := root.Execute(); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
 
 	// Set a memory (auto-commits)
 	root = NewRootCmd("test", a)
@@ -403,3 +409,311 @@ func TestE2EGetJSON(t *testing.T) {
 		t.Errorf("JSON output should contain created_at, got: %q", output)
 	}
 }
+
+func TestE2ESoftDeleteAndRestore(t *testing.T) {
+	a, repo := setupE2E(t)
+
+	run := func(args ...string) string {
+		t.Helper()
+		root := NewRootCmd("test", a)
+		root.SetArgs(args)
+		var out bytes.Buffer
+		root.SetOut(&out)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		return out.String()
+	}
+
+	run("set", "notes/todo", "write tests")
+	if out := run("del", "--soft", "notes/todo"); !strings.Contains(out, "Trashed notes/todo") {
+		t.Errorf("unexpected del output: %q", out)
+	}
+	if out := run("list"); strings.Contains(out, "notes/todo") {
+		t.Errorf("trashed key should not be listed: %q", out)
+	}
+	if out := run("trash", "list"); strings.TrimSpace(out) != "notes/todo" {
+		t.Errorf("trash list = %q", out)
+	}
+
+	commits, err := repo.Log(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if commits[0].Message != "trash: notes/todo" {
+		t.Errorf("commit message = %q, want %q", commits[0].Message, "trash: notes/todo")
+	}
+
+	run("restore-trash", "notes/todo")
+	if out := run("get", "notes/todo"); out != "write tests" {
+		t.Errorf("get after restore = %q", out)
+	}
+	if out := run("trash", "list"); !strings.Contains(out, "Trash is empty") {
+		t.Errorf("trash list after restore = %q", out)
+	}
+}
+
+func TestE2EBranchGuards(t *testing.T) {
+	a, repo := setupE2E(t)
+	ctx := context.Background()
+
+	run := func(args ...string) error {
+		root := NewRootCmd("test", a)
+		root.SetArgs(args)
+		root.SetOut(&bytes.Buffer{})
+		root.SetErr(&bytes.Buffer{})
+		return root.Execute()
+	}
+
+	main, err := repo.Current(ctx)
+	if err != nil {
+		t.Fatalf("current: %v", err)
+	}
+
+	// A branch with a commit main does not have.
+	if err := run("branch", "feature"); err != nil {
+		t.Fatalf("branch feature: %v", err)
+	}
+	if err := run("set", "feature/note", "wip"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	// Stage a change without committing it.
+	key, _ := internal.NewKey("feature/note")
+	if err := repo.Save(ctx, internal.NewMemory(key, []byte("edited"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	err = run("branch", main.Name)
+	if !errors.Is(err, internal.ErrUncommittedChanges) {
+		t.Fatalf("expected ErrUncommittedChanges, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "(1 files); commit or use --force") {
+		t.Errorf("unexpected error message: %v", err)
+	}
+
+	if err := run("branch", "--force", main.Name); err != nil {
+		t.Fatalf("forced switch: %v", err)
+	}
+
+	if err := run("branch", "-d", "feature"); !errors.Is(err, internal.ErrBranchNotMerged) {
+		t.Fatalf("expected ErrBranchNotMerged, got %v", err)
+	}
+	if err := run("branch", "-d", "--force", "feature"); err != nil {
+		t.Fatalf("forced delete: %v", err)
+	}
+}
+
+func TestE2EReadOnlyFlag(t *testing.T) {
+	a, _ := setupE2E(t)
+
+	run := func(args ...string) (string, error) {
+		root := NewRootCmd("test", a)
+		root.SetArgs(args)
+		var out bytes.Buffer
+		root.SetOut(&out)
+		root.SetErr(&bytes.Buffer{})
+		err := root.Execute()
+		return out.String(), err
+	}
+
+	if _, err := run("set", "notes/todo", "write tests"); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"--readonly", "set", "notes/todo", "changed"},
+		{"--readonly", "add", "notes/todo", "more"},
+		{"--readonly", "del", "notes/todo"},
+		{"--readonly", "commit", "-m", "nothing"},
+		{"--readonly", "branch", "feature"},
+	} {
+		if _, err := run(args...); !errors.Is(err, internal.ErrReadOnly) {
+			t.Errorf("%v: expected ErrReadOnly, got %v", args, err)
+		}
+	}
+
+	if out, err := run("--readonly", "get", "notes/todo"); err != nil || out != "write tests" {
+		t.Errorf("get --readonly = %q, %v", out, err)
+	}
+	if out, err := run("--readonly", "list"); err != nil || !strings.Contains(out, "notes/todo") {
+		t.Errorf("list --readonly = %q, %v", out, err)
+	}
+	if _, err := run("--readonly", "log"); err != nil {
+		t.Errorf("log --readonly: %v", err)
+	}
+	if _, err := run("--readonly", "trash", "list"); err != nil {
+		t.Errorf("trash list --readonly: %v", err)
+	}
+}
+
+func TestE2EAttachments(t *testing.T) {
+	a, repo := setupE2E(t)
+	ctx := context.Background()
+
+	run := func(args ...string) string {
+		t.Helper()
+		root := NewRootCmd("test", a)
+		root.SetArgs(args)
+		var out bytes.Buffer
+		root.SetOut(&out)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		return out.String()
+	}
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "diagram.png")
+	if err := os.WriteFile(src, []byte("\x89PNG not really"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	run("set", "arch/overview", "see diagram")
+	if out := run("attach", "arch/overview", src); !strings.Contains(out, "Attached diagram.png to arch/overview") {
+		t.Errorf("unexpected attach output: %q", out)
+	}
+
+	commits, err := repo.Log(ctx, 1)
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if commits[0].Message != "attach: arch/overview" {
+		t.Errorf("commit message = %q", commits[0].Message)
+	}
+
+	if out := run("attach", "list", "arch/overview"); !strings.Contains(out, "diagram.png") {
+		t.Errorf("attach list = %q", out)
+	}
+
+	dst := filepath.Join(dir, "out.png")
+	run("attach", "get", "arch/overview", "diagram.png", "-o", dst)
+	if data, err := os.ReadFile(dst); err != nil || string(data) != "\x89PNG not really" {
+		t.Errorf("retrieved %q, %v", data, err)
+	}
+
+	if out := run("search", "diagram"); strings.Contains(out, "attachments/") {
+		t.Errorf("search should not see blobs: %q", out)
+	}
+
+	if out := run("del", "--gc", "arch/overview"); !strings.Contains(out, "Removed attachment") {
+		t.Errorf("del --gc output = %q", out)
+	}
+	if hashes, _ := repo.ListBlobs(ctx); len(hashes) != 0 {
+		t.Errorf("blobs left after del --gc: %v", hashes)
+	}
+}
+
+func TestE2ESnapshots(t *testing.T) {
+	a, repo := setupE2E(t)
+	ctx := context.Background()
+
+	run := func(args ...string) (string, error) {
+		t.Helper()
+		root := NewRootCmd("test", a)
+		root.SetArgs(args)
+		var out bytes.Buffer
+		root.SetOut(&out)
+		err := root.Execute()
+		return out.String(), err
+	}
+	mustRun := func(args ...string) string {
+		t.Helper()
+		out, err := run(args...)
+		if err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		return out
+	}
+
+	mustRun("set", "notes/a", "original a")
+	mustRun("set", "notes/b", "original b")
+	mustRun("snapshot", "create", "before-agent", "-m", "pre rewrite")
+
+	if _, err := run("snapshot", "create", "before-agent"); !errors.Is(err, internal.ErrSnapshotExists) {
+		t.Errorf("duplicate snapshot: expected ErrSnapshotExists, got %v", err)
+	}
+	mustRun("snapshot", "create", "before-agent", "--force", "-m", "pre rewrite")
+
+	mustRun("set", "notes/a", "rewritten a")
+	mustRun("del", "notes/b")
+	mustRun("set", "notes/c", "new c")
+
+	if out := mustRun("snapshot", "list"); !strings.Contains(out, "before-agent") || !strings.Contains(out, "pre rewrite") {
+		t.Errorf("snapshot list = %q", out)
+	}
+
+	if out := mustRun("snapshot", "restore", "before-agent"); !strings.Contains(out, "Restored snapshot before-agent") {
+		t.Errorf("restore output = %q", out)
+	}
+
+	for key, want := range map[string]string{"notes/a": "original a", "notes/b": "original b"} {
+		mem, err := repo.Get(ctx, internal.Key(key))
+		if err != nil || string(mem.Content) != want {
+			t.Errorf("%s after restore = %v, %v; want %q", key, mem, err, want)
+		}
+	}
+	if ok, _ := repo.Exists(ctx, "notes/c"); ok {
+		t.Error("notes/c should not survive the restore")
+	}
+
+	commits, err := repo.Log(ctx, 0)
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if commits[0].Message != "restore: snapshot before-agent" {
+		t.Errorf("restore commit = %q", commits[0].Message)
+	}
+	if commits[1].Message != "set: notes/c" {
+		t.Errorf("restore should keep later history, next commit = %q", commits[1].Message)
+	}
+
+	scope := a.resolver.Resolve("")
+	if !internal.IndexStale(internal.IndexPath(ctx, scope, repo)) {
+		t.Error("restore should mark the index stale")
+	}
+
+	if out := mustRun("snapshot", "restore", "before-agent"); !strings.Contains(out, "Already at snapshot") {
+		t.Errorf("second restore output = %q", out)
+	}
+}
+
+func TestE2ESetFromFile(t *testing.T) {
+	a, repo := setupE2E(t)
+	ctx := context.Background()
+
+	path := filepath.Join(t.TempDir(), "pairs.json")
+	if err := os.WriteFile(path, []byte(`{"seed/a": "alpha", "seed/b": "beta"}`), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	root := NewRootCmd("test", a)
+	root.SetArgs([]string{"set", "--from-file", path})
+	var out bytes.Buffer
+	root.SetOut(&out)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("set --from-file: %v", err)
+	}
+	if !strings.Contains(out.String(), "Set 2 keys") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+
+	commits, err := repo.Log(ctx, 1)
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if commits[0].Message != "set: 2 keys" {
+		t.Errorf("commit message = %q", commits[0].Message)
+	}
+	if mem, err := repo.Get(ctx, "seed/b"); err != nil || string(mem.Content) != "beta" {
+		t.Errorf("seed/b = %v, %v", mem, err)
+	}
+
+	root = NewRootCmd("test", a)
+	root.SetArgs([]string{"set", "--from-file", path, "extra"})
+	root.SetOut(&bytes.Buffer{})
+	root.SetErr(&bytes.Buffer{})
+	if err := root.Execute(); err == nil {
+		t.Error("expected --from-file with a key argument to fail")
+	}
+}
@@ -1,33 +1,54 @@
 package main
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
+	"strings"
 
 	"github.com/4thel00z/memories/internal"
 	"github.com/spf13/cobra"
 )
 
-func NewEditCmd(getUC *internal.GetMemoryUseCase, setUC *internal.SetMemoryUseCase, commitUC *internal.CommitUseCase) *cobra.Command {
+func NewEditCmd(getUC *internal.GetMemoryUseCase, setUC *internal.SetMemoryUseCase, addUC *internal.AddMemoryUseCase, commitUC *internal.CommitUseCase, aliasUC *internal.AliasUseCase) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "edit <key>",
 		Short: "Edit a memory in $EDITOR",
-		Long:  `Open a memory in your editor. Creates the memory if it doesn't exist. Auto-commits on save.`,
-		Args:  cobra.ExactArgs(1),
-		RunE:  makeEditRunner(getUC, setUC, commitUC),
+		Long: `Open a memory in your editor. Creates the memory if it doesn't exist. Auto-commits on save.
+
+The editor is --editor, else $VISUAL, else $EDITOR, else vi. Its value is
+split like a shell would, so EDITOR="code --wait" works. --append opens an
+empty buffer and appends what you write to the memory instead of replacing it.
+
+--review, or editor.review in the config, shows a diff of your change once
+the editor closes and asks whether to save it (y), drop it (n) or edit it
+again (e).`,
+		Args: cobra.ExactArgs(1),
+		RunE: makeEditRunner(getUC, setUC, addUC, commitUC, aliasUC),
 	}
 
 	cmd.Flags().StringP("message", "m", "", "Commit message")
+	cmd.Flags().Bool("append", false, "Append the edited text instead of replacing the memory")
+	cmd.Flags().String("editor", "", "Editor command, overriding $VISUAL and $EDITOR")
+	cmd.Flags().Bool("review", false, "Show the diff and confirm before saving")
 	return cmd
 }
 
-func makeEditRunner(getUC *internal.GetMemoryUseCase, setUC *internal.SetMemoryUseCase, commitUC *internal.CommitUseCase) func(*cobra.Command, []string) error {
+func makeEditRunner(getUC *internal.GetMemoryUseCase, setUC *internal.SetMemoryUseCase, addUC *internal.AddMemoryUseCase, commitUC *internal.CommitUseCase, aliasUC *internal.AliasUseCase) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		key := args[0]
+		key, err := resolveKeyArg(cmd, aliasUC, args[0])
+		if err != nil {
+			return err
+		}
 		scopeHint, _ := cmd.Flags().GetString("scope")
 		message, _ := cmd.Flags().GetString("message")
 
+		if appendOnly, _ := cmd.Flags().GetBool("append"); appendOnly {
+			return appendInEditor(cmd, addUC, key, scopeHint, message)
+		}
+
 		existing, err := getUC.Execute(cmd.Context(), internal.GetMemoryInput{
 			Key: key, Scope: scopeHint,
 		})
@@ -35,53 +56,58 @@ func makeEditRunner(getUC *internal.GetMemoryUseCase, setUC *internal.SetMemoryU
 			return fmt.Errorf("get memory: %w", err)
 		}
 
-		tmpFile, err := os.CreateTemp("", "mem-edit-*.txt")
-		if err != nil {
-			return fmt.Errorf("create temp file: %w", err)
-		}
-		defer os.Remove(tmpFile.Name())
-
+		initial := ""
 		if existing != nil {
-			if _, err := tmpFile.WriteString(existing.Content); err != nil {
-				return fmt.Errorf("write temp file: %w", err)
-			}
+			initial = existing.Content
 		}
-		tmpFile.Close()
 
-		editor := os.Getenv("EDITOR")
-		if editor == "" {
-			editor = "vi"
-		}
-
-		c := exec.Command(editor, tmpFile.Name())
-		c.Stdin = os.Stdin
-		c.Stdout = os.Stdout
-		c.Stderr = os.Stderr
-
-		if err := c.Run(); err != nil {
-			return fmt.Errorf("editor: %w", err)
-		}
-
-		content, err := os.ReadFile(tmpFile.Name())
+		content, err := editInEditor(cmd, initial)
 		if err != nil {
-			return fmt.Errorf("read edited file: %w", err)
+			return err
 		}
 
-		if existing != nil && string(content) == existing.Content {
+		asJSON, _ := cmd.Flags().GetBool("json")
+		changed := existing == nil || content != existing.Content
+		if changed && reviewWanted(cmd) {
+			var keep bool
+			content, keep, err = reviewEdit(cmd, key, initial, content)
+			if err != nil {
+				return err
+			}
+			if !keep {
+				if asJSON {
+					return outputWriteResultJSON(cmd, unchangedResultJSON("edit", key))
+				}
+				fmt.Fprintln(cmd.OutOrStdout(), "Discarded.")
+				return nil
+			}
+		}
+		if existing != nil && content == existing.Content {
+			if asJSON {
+				return outputWriteResultJSON(cmd, unchangedResultJSON("edit", key))
+			}
 			fmt.Fprintln(cmd.OutOrStdout(), "No changes.")
 			return nil
 		}
 
 		if err := setUC.Execute(cmd.Context(), internal.SetMemoryInput{
-			Key: key, Content: string(content), Scope: scopeHint,
+			Key: key, Content: content, Scope: scopeHint,
 		}); err != nil {
 			return fmt.Errorf("save memory: %w", err)
 		}
 
-		if err := autoCommit(cmd.Context(), commitUC, message, "edit", key, scopeHint); err != nil {
+		commit, err := autoCommit(cmd.Context(), commitUC, message, "edit", key, scopeHint)
+		if err != nil {
 			return fmt.Errorf("commit: %w", err)
 		}
 
+		if asJSON {
+			outcome := "created"
+			if existing != nil {
+				outcome = "updated"
+			}
+			return outputWriteResultJSON(cmd, writeResultJSON("edit", key, outcome, commit))
+		}
 		if existing != nil {
 			fmt.Fprintf(cmd.OutOrStdout(), "Updated %s\n", key)
 		} else {
@@ -90,3 +116,210 @@ func makeEditRunner(getUC *internal.GetMemoryUseCase, setUC *internal.SetMemoryU
 		return nil
 	}
 }
+
+// appendInEditor composes new text in an empty buffer and appends it to key.
+func appendInEditor(cmd *cobra.Command, addUC *internal.AddMemoryUseCase, key, scopeHint, message string) error {
+	content, err := editInEditor(cmd, "")
+	if err != nil {
+		return err
+	}
+	asJSON, _ := cmd.Flags().GetBool("json")
+	if strings.TrimSpace(content) == "" {
+		if asJSON {
+			return outputWriteResultJSON(cmd, unchangedResultJSON("edit", key))
+		}
+		fmt.Fprintln(cmd.OutOrStdout(), "No changes.")
+		return nil
+	}
+
+	out, err := addUC.Execute(cmd.Context(), internal.AddMemoryInput{
+		Key: key, Content: content, Scope: scopeHint, Message: message,
+	})
+	if err != nil {
+		return fmt.Errorf("add to memory: %w", err)
+	}
+	if asJSON {
+		return outputWriteResultJSON(cmd, addResultJSON("edit", key, out))
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Appended to %s\n", key)
+	return nil
+}
+
+// reviewWanted reports whether mem edit should confirm a change before
+// saving it: with --review or editor.review, and only when someone can
+// answer on stdin.
+func reviewWanted(cmd *cobra.Command) bool {
+	review, _ := cmd.Flags().GetBool("review")
+	if !review {
+		if cfg, err := loadScopeConfig(cmd); err == nil {
+			review = cfg.Editor.Review
+		}
+	}
+	return review && requireInteractive(cmd, "cannot review") == nil
+}
+
+// reviewEdit shows the diff from old to edited and asks whether to keep
+// it, reopening the editor on "e". It returns the content to save and
+// whether to save it; end of input counts as "n".
+func reviewEdit(cmd *cobra.Command, key, old, edited string) (string, bool, error) {
+	in := bufio.NewReader(cmd.InOrStdin())
+	w := cmd.ErrOrStderr()
+	for {
+		diff := internal.ContentDiff(key, old, edited)
+		if colorEnabled(cmd) {
+			diff = colorDiff(diff)
+		}
+		fmt.Fprint(w, diff)
+		fmt.Fprint(w, "Save this change? [y/n/e] ")
+
+		answer, err := in.ReadString('\n')
+		if err != nil && answer == "" {
+			fmt.Fprintln(w)
+			return edited, false, nil
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return edited, true, nil
+		case "n", "no":
+			return edited, false, nil
+		case "e", "edit":
+			if edited, err = editInEditor(cmd, edited); err != nil {
+				return "", false, err
+			}
+		default:
+			fmt.Fprintln(w, "Please answer y, n or e.")
+		}
+	}
+}
+
+// editInEditor opens initial in the user's editor and returns the saved text.
+func editInEditor(cmd *cobra.Command, initial string) (string, error) {
+	if err := requireInteractive(cmd, "cannot open an editor (use mem set)"); err != nil {
+		return "", err
+	}
+
+	argv, err := editorArgv(editorOverride(cmd))
+	if err != nil {
+		return "", err
+	}
+
+	tmpFile, err := os.CreateTemp("", "mem-edit-*"+editorExtension(cmd))
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.WriteString(initial); err != nil {
+		return "", fmt.Errorf("write temp file: %w", err)
+	}
+	tmpFile.Close()
+
+	c := exec.Command(argv[0], append(argv[1:], tmpFile.Name())...)
+	c.Stdin = os.Stdin
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+
+	if err := c.Run(); err != nil {
+		return "", fmt.Errorf("editor: %w", err)
+	}
+
+	content, err := os.ReadFile(tmpFile.Name())
+	if err != nil {
+		return "", fmt.Errorf("read edited file: %w", err)
+	}
+	return string(content), nil
+}
+
+// editorOverride returns the --editor command. mem set also has an
+// --editor flag, but there it is a switch, so only string flags count.
+func editorOverride(cmd *cobra.Command) string {
+	if f := cmd.Flags().Lookup("editor"); f != nil && f.Value.Type() == "string" {
+		return f.Value.String()
+	}
+	return ""
+}
+
+// editorExtension returns the temp file extension configured for the store.
+func editorExtension(cmd *cobra.Command) string {
+	cfg, err := loadScopeConfig(cmd)
+	if err != nil {
+		return internal.DefaultEditorExtension
+	}
+	return cfg.Editor.TempExtension()
+}
+
+// editorArgv returns the editor to run: override, else $VISUAL, else
+// $EDITOR, else vi, split into words like a shell would.
+func editorArgv(override string) ([]string, error) {
+	editor := override
+	for _, env := range []string{"VISUAL", "EDITOR"} {
+		if editor == "" {
+			editor = os.Getenv(env)
+		}
+	}
+	argv, err := splitWords(editor)
+	if err != nil {
+		return nil, fmt.Errorf("parse editor %q: %w", editor, err)
+	}
+	if len(argv) == 0 {
+		return []string{"vi"}, nil
+	}
+	return argv, nil
+}
+
+var errUnterminatedQuote = errors.New("unterminated quote or escape")
+
+// splitWords splits s on whitespace, honouring single quotes, double quotes
+// and backslash escapes the way sh does, without expanding anything.
+func splitWords(s string) ([]string, error) {
+	var (
+		words  []string
+		word   strings.Builder
+		inWord bool
+		quote  rune
+		escape bool
+	)
+	for _, r := range s {
+		switch {
+		case escape:
+			// Inside double quotes a backslash only escapes these.
+			if quote == '"' && !strings.ContainsRune(`"\$`+"`", r) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escape = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escape, inWord = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 || escape {
+		return nil, errUnterminatedQuote
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -32,6 +34,9 @@ func setupEditTest(t *testing.T) (*internal.GitRepository, *internal.GetMemoryUs
 		t.Fatalf("new repo: %v", err)
 	}
 
+	// Tests pick the editor through $EDITOR, which $VISUAL would override.
+	t.Setenv("VISUAL", "")
+
 	resolver := internal.NewScopeResolver()
 	repoFor := func(s internal.Scope) (internal.MemoryRepository, error) { return repo, nil }
 	histFor := func(s internal.Scope) (internal.HistoryRepository, error) { return repo, nil }
@@ -53,8 +58,9 @@ func TestEditCmdCreatesNew(t *testing.T) {
 		t.Fatalf("write editor script: %v", err)
 	}
 	t.Setenv("EDITOR", editorScript)
+	stubTerminal(t, true)
 
-	cmd := NewEditCmd(getUC, setUC, commitUC)
+	cmd := NewEditCmd(getUC, setUC, nil, commitUC, nil)
 	cmd.SetArgs([]string{"new/edited"})
 
 	var out bytes.Buffer
@@ -102,8 +108,9 @@ func TestEditCmdUpdatesExisting(t *testing.T) {
 		t.Fatalf("write editor script: %v", err)
 	}
 	t.Setenv("EDITOR", editorScript)
+	stubTerminal(t, true)
 
-	cmd := NewEditCmd(getUC, setUC, commitUC)
+	cmd := NewEditCmd(getUC, setUC, nil, commitUC, nil)
 	cmd.SetArgs([]string{"existing/edit"})
 
 	var out bytes.Buffer
@@ -151,8 +158,9 @@ func TestEditCmdNoChanges(t *testing.T) {
 		t.Fatalf("write editor script: %v", err)
 	}
 	t.Setenv("EDITOR", editorScript)
+	stubTerminal(t, true)
 
-	cmd := NewEditCmd(getUC, setUC, commitUC)
+	cmd := NewEditCmd(getUC, setUC, nil, commitUC, nil)
 	cmd.SetArgs([]string{"nochange"})
 
 	var out bytes.Buffer
@@ -166,3 +174,205 @@ func TestEditCmdNoChanges(t *testing.T) {
 		t.Errorf("output = %q, want %q", out.String(), "No changes.\n")
 	}
 }
+
+// runReviewedEdit edits review/key, which holds "old content", with an
+// editor that appends "added" on every run, answering the review prompt
+// with answers. It returns stdout, stderr and the saved content.
+func runReviewedEdit(t *testing.T, answers string) (string, string, string) {
+	t.Helper()
+	repo, getUC, setUC, commitUC := setupEditTest(t)
+	ctx := context.Background()
+
+	key, _ := internal.NewKey("review/key")
+	if err := repo.Save(ctx, internal.NewMemory(key, []byte("old content\n"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := repo.Commit(ctx, "setup"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	editorScript := filepath.Join(t.TempDir(), "editor.sh")
+	if err := os.WriteFile(editorScript, []byte("#!/bin/sh\necho added >> \"$1\"\n"), 0755); err != nil {
+		t.Fatalf("write editor script: %v", err)
+	}
+	t.Setenv("EDITOR", editorScript)
+	stubTerminal(t, true)
+
+	cmd := NewEditCmd(getUC, setUC, nil, commitUC, nil)
+	cmd.SetArgs([]string{"review/key", "--review"})
+	cmd.SetIn(strings.NewReader(answers))
+	var out, errOut bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&errOut)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	mem, err := repo.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	return out.String(), errOut.String(), string(mem.Content)
+}
+
+func TestEditCmdReview(t *testing.T) {
+	tests := []struct {
+		name    string
+		answers string
+		out     string
+		content string
+	}{
+		{name: "confirm", answers: "y\n", out: "Updated review/key\n", content: "old content\nadded\n"},
+		{name: "decline", answers: "n\n", out: "Discarded.\n", content: "old content\n"},
+		{name: "end of input", answers: "", out: "Discarded.\n", content: "old content\n"},
+		{name: "edit again", answers: "e\ny\n", out: "Updated review/key\n", content: "old content\nadded\nadded\n"},
+		{name: "unknown answer", answers: "maybe\ny\n", out: "Updated review/key\n", content: "old content\nadded\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, errOut, content := runReviewedEdit(t, tt.answers)
+			if out != tt.out {
+				t.Errorf("output = %q, want %q", out, tt.out)
+			}
+			if content != tt.content {
+				t.Errorf("content = %q, want %q", content, tt.content)
+			}
+			if !strings.Contains(errOut, "--- a/review/key\n+++ b/review/key\n old content\n+added\n") {
+				t.Errorf("review did not show the diff:\n%s", errOut)
+			}
+			if !strings.Contains(errOut, "Save this change? [y/n/e] ") {
+				t.Errorf("review did not prompt:\n%s", errOut)
+			}
+		})
+	}
+}
+
+func TestEditReviewSkippedWithoutTerminal(t *testing.T) {
+	cmd := NewEditCmd(nil, nil, nil, nil, nil)
+	if err := cmd.ParseFlags([]string{"--review"}); err != nil {
+		t.Fatalf("parse flags: %v", err)
+	}
+
+	stubTerminal(t, false)
+	if reviewWanted(cmd) {
+		t.Error("expected no review when stdin is not a terminal")
+	}
+	stubTerminal(t, true)
+	if !reviewWanted(cmd) {
+		t.Error("expected a review with --review on a terminal")
+	}
+}
+
+func TestEditCmdAppendWithEditorFlag(t *testing.T) {
+	repo, getUC, setUC, commitUC := setupEditTest(t)
+	ctx := context.Background()
+
+	key, _ := internal.NewKey("journal")
+	if err := repo.Save(ctx, internal.NewMemory(key, []byte("day one"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := repo.Commit(ctx, "setup"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	// The editor lives in a directory with a space and takes the text to
+	// write as its first argument; it also records the file it was given.
+	dir := filepath.Join(t.TempDir(), "my editors")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	seen := filepath.Join(dir, "seen")
+	script := filepath.Join(dir, "ed.sh")
+	body := "#!/bin/sh\necho \"$2\" > '" + seen + "'\n[ -s \"$2\" ] && exit 1\nprintf '%s' \"$1\" > \"$2\"\n"
+	if err := os.WriteFile(script, []byte(body), 0755); err != nil {
+		t.Fatalf("write editor script: %v", err)
+	}
+	t.Setenv("EDITOR", "false")
+	stubTerminal(t, true)
+
+	resolver := internal.NewScopeResolver()
+	repoFor := func(s internal.Scope) (internal.MemoryRepository, error) { return repo, nil }
+	histFor := func(s internal.Scope) (internal.HistoryRepository, error) { return repo, nil }
+	nilIndex := func(s internal.Scope) (internal.VectorIndex, error) { return nil, internal.ErrNoIndex }
+	addUC := internal.NewAddMemoryUseCase(resolver, repoFor, histFor, nilIndex, nil, nil)
+
+	cmd := NewEditCmd(getUC, setUC, addUC, commitUC, nil)
+	cmd.SetArgs([]string{"journal", "--append", "--editor", "'" + script + "' \"day two\""})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	if out.String() != "Appended to journal\n" {
+		t.Errorf("output = %q", out.String())
+	}
+	mem, err := repo.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if string(mem.Content) != "day one\nday two" {
+		t.Errorf("content = %q, want %q", mem.Content, "day one\nday two")
+	}
+	tmp, err := os.ReadFile(seen)
+	if err != nil {
+		t.Fatalf("read seen: %v", err)
+	}
+	if !strings.HasSuffix(strings.TrimSpace(string(tmp)), ".md") {
+		t.Errorf("temp file %q should have a .md extension", strings.TrimSpace(string(tmp)))
+	}
+}
+
+func TestEditorArgv(t *testing.T) {
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", "")
+	if got, _ := editorArgv(""); !slices.Equal(got, []string{"vi"}) {
+		t.Errorf("default = %q, want [vi]", got)
+	}
+
+	t.Setenv("EDITOR", "code --wait")
+	if got, _ := editorArgv(""); !slices.Equal(got, []string{"code", "--wait"}) {
+		t.Errorf("EDITOR = %q, want [code --wait]", got)
+	}
+
+	t.Setenv("VISUAL", "nvim")
+	if got, _ := editorArgv(""); !slices.Equal(got, []string{"nvim"}) {
+		t.Errorf("VISUAL should win over EDITOR, got %q", got)
+	}
+	if got, _ := editorArgv("nano -w"); !slices.Equal(got, []string{"nano", "-w"}) {
+		t.Errorf("override should win over VISUAL, got %q", got)
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", nil},
+		{"  vi  ", []string{"vi"}},
+		{"code --wait", []string{"code", "--wait"}},
+		{`"/Applications/Sublime Text.app/subl" -w`, []string{"/Applications/Sublime Text.app/subl", "-w"}},
+		{`'/opt/my editor/ed' -n`, []string{"/opt/my editor/ed", "-n"}},
+		{`/opt/my\ editor/ed`, []string{"/opt/my editor/ed"}},
+		{`emacs -e "(setq x \"y\")"`, []string{"emacs", "-e", `(setq x "y")`}},
+		{`a "" b`, []string{"a", "", "b"}},
+		{`C:\\bin\\ed.exe`, []string{`C:\bin\ed.exe`}},
+	}
+	for _, tt := range tests {
+		got, err := splitWords(tt.in)
+		if err != nil {
+			t.Errorf("splitWords(%q): %v", tt.in, err)
+			continue
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("splitWords(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{`"open`, `'open`, `trailing\`} {
+		if _, err := splitWords(in); err == nil {
+			t.Errorf("splitWords(%q) should fail", in)
+		}
+	}
+}
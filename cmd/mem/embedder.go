@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewEmbedderCmd(infoUC *internal.EmbedderInfoUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "embedder",
+		Short: "Inspect the local embedder",
+		Long:  `Show which device, model, and dimension the local embedder is using.`,
+	}
+
+	cmd.AddCommand(newEmbedderInfoCmd(infoUC))
+	return cmd
+}
+
+func newEmbedderInfoCmd(infoUC *internal.EmbedderInfoUseCase) *cobra.Command {
+	return &cobra.Command{
+		Use:   "info",
+		Short: "Show embedder device, model, and dimension",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			out, err := infoUC.Execute(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("embedder info: %w", err)
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(map[string]any{
+					"device":    out.Device,
+					"model":     out.Model,
+					"dimension": out.Dimension,
+				})
+			}
+
+			writeEmbedderInfo(cmd, out)
+			return nil
+		},
+	}
+}
+
+// printEmbedderInfo writes embedder details, or a note when none is loaded.
+func printEmbedderInfo(cmd *cobra.Command, infoUC *internal.EmbedderInfoUseCase) {
+	out, err := infoUC.Execute(cmd.Context())
+	if err != nil {
+		fmt.Fprintln(cmd.OutOrStdout(), "Embedder:  not available")
+		return
+	}
+	writeEmbedderInfo(cmd, out)
+}
+
+func writeEmbedderInfo(cmd *cobra.Command, out *internal.EmbedderInfoOutput) {
+	fmt.Fprintf(cmd.OutOrStdout(), "Device:    %s\n", strings.ToUpper(out.Device))
+	fmt.Fprintf(cmd.OutOrStdout(), "Model:     %s\n", out.Model)
+	fmt.Fprintf(cmd.OutOrStdout(), "Dimension: %d\n", out.Dimension)
+}
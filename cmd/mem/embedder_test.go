@@ -0,0 +1,44 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/4thel00z/memories/internal"
+)
+
+func TestEmbedderInfoCmd(t *testing.T) {
+	infoUC := internal.NewEmbedderInfoUseCase(internal.StaticEmbedder(&stubEmbedder{dim: 8, device: "cuda"}))
+
+	cmd := NewEmbedderCmd(infoUC)
+	cmd.SetArgs([]string{"info"})
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	output := out.String()
+	if !strings.Contains(output, "Device:    CUDA") {
+		t.Errorf("expected non-empty device in output, got %q", output)
+	}
+	if !strings.Contains(output, "Dimension: 8") {
+		t.Errorf("expected dimension in output, got %q", output)
+	}
+}
+
+func TestEmbedderInfoCmdNoEmbedder(t *testing.T) {
+	cmd := NewEmbedderCmd(internal.NewEmbedderInfoUseCase(nil))
+	cmd.SetArgs([]string{"info"})
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error without embedder")
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 )
 
@@ -63,11 +65,46 @@ func extractExternalName(dir string, entry os.DirEntry) string {
 		return ""
 	}
 
-	if info.Mode()&0111 == 0 {
+	command, ok := externalCommandName(name, info.Mode(), runtime.GOOS)
+	if !ok {
 		return ""
 	}
+	return command
+}
+
+// externalCommandName returns the subcommand a mem-* file provides. Unix
+// needs the executable bit; Windows has none and goes by the extension,
+// which is not part of the command name.
+func externalCommandName(file string, mode os.FileMode, goos string) (string, bool) {
+	name := strings.TrimPrefix(file, externalPrefix)
+	if goos != "windows" {
+		if mode&0111 == 0 {
+			return "", false
+		}
+		return name, true
+	}
+
+	ext := strings.ToLower(filepath.Ext(name))
+	if ext == "" || !slices.Contains(windowsExecExts(), ext) {
+		return "", false
+	}
+	return name[:len(name)-len(ext)], true
+}
 
-	return strings.TrimPrefix(name, externalPrefix)
+// windowsExecExts lists the extensions Windows runs, read from PATHEXT as
+// exec.LookPath does.
+func windowsExecExts() []string {
+	pathext := os.Getenv("PATHEXT")
+	if pathext == "" {
+		pathext = ".com;.exe;.bat;.cmd"
+	}
+	var exts []string
+	for _, ext := range strings.Split(strings.ToLower(pathext), ";") {
+		if ext != "" {
+			exts = append(exts, ext)
+		}
+	}
+	return exts
 }
 
 func executeExternal(ctx context.Context, name string, args []string, version string) error {
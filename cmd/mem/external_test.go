@@ -112,6 +112,31 @@ func TestExtractExternalNameNotExecutable(t *testing.T) {
 	t.Fatal("mem-noexec not found in dir entries")
 }
 
+func TestExternalCommandName(t *testing.T) {
+	t.Setenv("PATHEXT", ".COM;.EXE;.BAT;.CMD")
+
+	tests := []struct {
+		file string
+		mode os.FileMode
+		goos string
+		want string
+		ok   bool
+	}{
+		{"mem-hello", 0755, "linux", "hello", true},
+		{"mem-hello", 0644, "darwin", "", false},
+		{"mem-hello.exe", 0644, "windows", "hello", true},
+		{"mem-hello.CMD", 0644, "windows", "hello", true},
+		{"mem-hello.ps1", 0644, "windows", "", false},
+		{"mem-hello", 0755, "windows", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := externalCommandName(tt.file, tt.mode, tt.goos)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("externalCommandName(%q, %v, %s) = %q, %v; want %q, %v", tt.file, tt.mode, tt.goos, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
 func TestBuildExternalEnv(t *testing.T) {
 	env := buildExternalEnv("1.0.0")
 
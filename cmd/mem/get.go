@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 
 	"github.com/4thel00z/memories/internal"
 	"github.com/spf13/cobra"
@@ -12,19 +13,33 @@ func NewGetCmd(getUC *internal.GetMemoryUseCase) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "get <key>",
 		Short: "Retrieve a memory",
-		Long:  `Retrieve and display the content of a memory.`,
-		Args:  cobra.ExactArgs(1),
-		RunE:  makeGetRunner(getUC),
+		Long: `Retrieve and display the content of a memory.
+
+--stream copies the content straight to stdout without reading it whole or
+paging it, for memories too large to hold in memory.`,
+		Args: cobra.ExactArgs(1),
+		RunE: makeGetRunner(getUC),
 	}
 
+	cmd.Flags().Bool("stream", false, "Write the content to stdout as it is read, without buffering or paging")
 	return cmd
 }
 
+// makeGetRunner passes the key as given: GetMemoryUseCase expands @aliases
+// itself.
 func makeGetRunner(getUC *internal.GetMemoryUseCase) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 		key := args[0]
 		scopeHint, _ := cmd.Flags().GetString("scope")
 		asJSON, _ := cmd.Flags().GetBool("json")
+		stream, _ := cmd.Flags().GetBool("stream")
+
+		if stream {
+			if asJSON {
+				return fmt.Errorf("--stream cannot be combined with --json")
+			}
+			return streamMemory(cmd, getUC, key, scopeHint)
+		}
 
 		out, err := getUC.Execute(cmd.Context(), internal.GetMemoryInput{
 			Key: key, Scope: scopeHint,
@@ -37,11 +52,28 @@ func makeGetRunner(getUC *internal.GetMemoryUseCase) func(*cobra.Command, []stri
 			return outputGetMemoryJSON(cmd, out)
 		}
 
+		stop := startPager(cmd)
+		defer stop()
+
 		fmt.Fprint(cmd.OutOrStdout(), out.Content)
 		return nil
 	}
 }
 
+// streamMemory copies the memory at key to stdout as it is read.
+func streamMemory(cmd *cobra.Command, getUC *internal.GetMemoryUseCase, key, scopeHint string) error {
+	rc, err := getUC.Open(cmd.Context(), internal.GetMemoryInput{Key: key, Scope: scopeHint})
+	if err != nil {
+		return fmt.Errorf("get memory: %w", err)
+	}
+	defer rc.Close()
+
+	if _, err := io.Copy(cmd.OutOrStdout(), rc); err != nil {
+		return fmt.Errorf("stream memory: %w", err)
+	}
+	return nil
+}
+
 func outputGetMemoryJSON(cmd *cobra.Command, out *internal.GetMemoryOutput) error {
 	data := map[string]any{
 		"key":        out.Key,
@@ -49,6 +81,9 @@ func outputGetMemoryJSON(cmd *cobra.Command, out *internal.GetMemoryOutput) erro
 		"created_at": out.CreatedAt,
 		"updated_at": out.UpdatedAt,
 	}
+	if out.AliasOf != "" {
+		data["alias_of"] = out.AliasOf
+	}
 
 	enc := json.NewEncoder(cmd.OutOrStdout())
 	enc.SetIndent("", "  ")
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/4thel00z/memories/internal"
 	"github.com/spf13/cobra"
@@ -22,6 +23,7 @@ func NewHookCmd(uc *internal.RunHookUseCase) *cobra.Command {
 		Args:  cobra.ExactArgs(1),
 		RunE:  makeHookRunRunner(uc),
 	}
+	runCmd.Flags().Bool("no-redact", false, "Send the commit to the provider without redacting secrets")
 
 	hookCmd.AddCommand(runCmd)
 	return hookCmd
@@ -40,9 +42,11 @@ func makeHookRunRunner(uc *internal.RunHookUseCase) func(*cobra.Command, []strin
 			return nil
 		}
 
+		noRedact, _ := cmd.Flags().GetBool("no-redact")
 		if err := uc.Execute(cmd.Context(), internal.RunHookInput{
 			HookType:      hookType,
 			CommitContext: *cc,
+			NoRedact:      noRedact,
 		}); err != nil {
 			fmt.Fprintf(cmd.ErrOrStderr(), "mem hook: %v\n", err)
 		}
@@ -62,11 +66,22 @@ func gatherCommitContext() (*internal.CommitContext, error) {
 		return nil, fmt.Errorf("get commit message: %w", err)
 	}
 
+	body, err := gitOutput("log", "-1", "--format=%b")
+	if err != nil {
+		return nil, fmt.Errorf("get commit body: %w", err)
+	}
+
 	author, err := gitOutput("log", "-1", "--format=%an")
 	if err != nil {
 		return nil, fmt.Errorf("get commit author: %w", err)
 	}
 
+	date, err := gitOutput("log", "-1", "--format=%cI")
+	if err != nil {
+		return nil, fmt.Errorf("get commit date: %w", err)
+	}
+	committed, _ := time.Parse(time.RFC3339, strings.TrimSpace(date))
+
 	diff, err := gitOutput("diff", "HEAD~1..HEAD")
 	if err != nil {
 		diff = ""
@@ -75,7 +90,9 @@ func gatherCommitContext() (*internal.CommitContext, error) {
 	return &internal.CommitContext{
 		Hash:    strings.TrimSpace(hash),
 		Message: strings.TrimSpace(message),
+		Body:    strings.TrimSpace(body),
 		Author:  strings.TrimSpace(author),
+		Time:    committed,
 		Diff:    diff,
 	}, nil
 }
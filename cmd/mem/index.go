@@ -1,57 +1,194 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/4thel00z/memories/internal"
 	"github.com/spf13/cobra"
 )
 
-func NewIndexCmd(rebuildUC *internal.RebuildIndexUseCase) *cobra.Command {
+func NewIndexCmd(rebuildUC *internal.RebuildIndexUseCase, infoUC *internal.EmbedderInfoUseCase, syncUC *internal.SyncIndexUseCase, vectorUC *internal.IndexVectorUseCase) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "index",
 		Short: "Manage the vector search index",
-		Long:  `Rebuild or inspect the semantic search index.`,
+		Long:  `Rebuild or inspect the semantic search index, or sync it with the index_remote bucket.`,
 	}
 
 	cmd.AddCommand(
 		newIndexRebuildCmd(rebuildUC),
-		newIndexStatusCmd(),
+		newIndexStatusCmd(infoUC),
+		newIndexVectorCmd(vectorUC),
+		newIndexSyncCmd("push", "Upload the index to the index_remote bucket", "Pushed index to", syncUC.Push),
+		newIndexSyncCmd("pull", "Download the index from the index_remote bucket", "Pulled index from", syncUC.Pull),
 	)
 
 	return cmd
 }
 
+// newIndexSyncCmd builds mem index push and pull, which differ only in the
+// direction of the copy.
+func newIndexSyncCmd(use, short, done string, sync func(context.Context, internal.SyncIndexInput) (*internal.SyncIndexOutput, error)) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Long: short + ` configured under index_remote, so a fresh
+machine or container can search without rebuilding. Each branch's index is
+kept under its own path in the bucket.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			out, err := sync(cmd.Context(), internal.SyncIndexInput{Scope: scopeHint})
+			if err != nil {
+				return fmt.Errorf("index %s: %w", use, err)
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(map[string]any{
+					"dir":    out.Dir,
+					"remote": out.Remote,
+					"bytes":  out.Bytes,
+				})
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s %s (%d bytes)\n", done, out.Remote, out.Bytes)
+			return nil
+		},
+	}
+}
+
 func newIndexRebuildCmd(rebuildUC *internal.RebuildIndexUseCase) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "rebuild",
 		Short: "Rebuild the search index",
+		Long: `Re-embed every memory and rebuild the search index. With --since <ref>,
+only the memories added or changed between <ref> and HEAD are re-embedded,
+and the entries of memories deleted since are dropped. A stale index still
+needs a full rebuild.`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			scopeHint, _ := cmd.Flags().GetString("scope")
 			trees, _ := cmd.Flags().GetInt("trees")
+			dryRun, _ := cmd.Flags().GetBool("dry-run")
+			since, _ := cmd.Flags().GetString("since")
 
-			if err := rebuildUC.Execute(cmd.Context(), internal.RebuildIndexInput{
-				Scope: scopeHint, NumTrees: trees,
-			}); err != nil {
+			out, err := rebuildUC.Execute(cmd.Context(), internal.RebuildIndexInput{
+				Scope: scopeHint, NumTrees: trees, DryRun: dryRun, Since: since,
+			})
+			if err != nil {
 				return fmt.Errorf("rebuild index: %w", err)
 			}
 
+			if dryRun {
+				fmt.Fprintf(cmd.OutOrStdout(), "Would embed %d memories:\n", len(out.Keys))
+				for _, k := range out.Keys {
+					fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", k)
+				}
+				if len(out.Removed) > 0 {
+					fmt.Fprintf(cmd.OutOrStdout(), "Would drop %d deleted memories:\n", len(out.Removed))
+					for _, k := range out.Removed {
+						fmt.Fprintf(cmd.OutOrStdout(), "  %s\n", k)
+					}
+				}
+				return nil
+			}
+
+			if since != "" {
+				fmt.Fprintf(cmd.OutOrStdout(), "Index updated: %d re-embedded, %d removed since %s.\n", len(out.Keys), len(out.Removed), since)
+				return nil
+			}
 			fmt.Fprintln(cmd.OutOrStdout(), "Index rebuilt successfully.")
 			return nil
 		},
 	}
 
-	cmd.Flags().Int("trees", 10, "Number of trees for the index")
+	cmd.Flags().Int("trees", 0, "Number of trees for the index (default embeddings.num_trees, or 10)")
+	cmd.Flags().Bool("dry-run", false, "List the memories that would be embedded without rebuilding")
+	cmd.Flags().String("since", "", "Only re-embed memories changed between this ref (commit, branch or snapshot) and HEAD")
 	return cmd
 }
 
-func newIndexStatusCmd() *cobra.Command {
+func newIndexStatusCmd(infoUC *internal.EmbedderInfoUseCase) *cobra.Command {
 	return &cobra.Command{
 		Use:   "status",
 		Short: "Show index status",
 		RunE: func(cmd *cobra.Command, _ []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
 			fmt.Fprintln(cmd.OutOrStdout(), "Index status: use 'mem index rebuild' to build.")
+			pending := internal.PendingIndexUpdates(resolverFrom(cmd).Resolve(scopeHint))
+			fmt.Fprintf(cmd.OutOrStdout(), "Pending updates: %d\n", pending)
+			printEmbedderInfo(cmd, infoUC)
+			return nil
+		},
+	}
+}
+
+func newIndexVectorCmd(vectorUC *internal.IndexVectorUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vector <key>",
+		Short: "Print the stored vector of a memory",
+		Long: `Print the vector the index stores for a memory, with its norm, to look
+into surprising semantic search results. A memory indexed in chunks has
+one vector per chunk. --dims prints only the first dimensions.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			dims, _ := cmd.Flags().GetInt("dims")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			out, err := vectorUC.Execute(cmd.Context(), internal.IndexVectorInput{
+				Key: args[0], Scope: scopeHint,
+			})
+			if err != nil {
+				return fmt.Errorf("index vector: %w", err)
+			}
+
+			if asJSON {
+				vectors := make([]map[string]any, 0, len(out.Vectors))
+				for _, v := range out.Vectors {
+					vectors = append(vectors, map[string]any{
+						"key":       v.Key,
+						"dimension": len(v.Vector),
+						"norm":      v.Norm,
+						"vector":    firstDims(v.Vector, dims),
+					})
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(vectors)
+			}
+
+			w := cmd.OutOrStdout()
+			for _, v := range out.Vectors {
+				fmt.Fprintf(w, "%s  dimension %d  norm %.6f\n", v.Key, len(v.Vector), v.Norm)
+				shown := firstDims(v.Vector, dims)
+				for i, x := range shown {
+					if i > 0 {
+						fmt.Fprint(w, " ")
+					}
+					fmt.Fprintf(w, "%.6f", x)
+				}
+				if len(shown) < len(v.Vector) {
+					fmt.Fprintf(w, " ... (%d more)", len(v.Vector)-len(shown))
+				}
+				fmt.Fprintln(w)
+			}
 			return nil
 		},
 	}
+
+	cmd.Flags().Int("dims", 0, "Print only the first this many dimensions (0: all)")
+	return cmd
+}
+
+// firstDims returns the first n values of vec, or all of them if n is not
+// positive.
+func firstDims(vec []float32, n int) []float32 {
+	if n <= 0 || n >= len(vec) {
+		return vec
+	}
+	return vec[:n]
 }
@@ -64,7 +64,7 @@ func setupIndexTest(t *testing.T) *internal.RebuildIndexUseCase {
 func TestIndexStatusCmd(t *testing.T) {
 	rebuildUC := setupIndexTest(t)
 
-	cmd := NewIndexCmd(rebuildUC)
+	cmd := NewIndexCmd(rebuildUC, internal.NewEmbedderInfoUseCase(nil), nil, nil)
 	cmd.SetArgs([]string{"status"})
 
 	var out bytes.Buffer
@@ -82,7 +82,7 @@ func TestIndexStatusCmd(t *testing.T) {
 func TestIndexRebuildNoEmbedder(t *testing.T) {
 	rebuildUC := setupIndexTest(t)
 
-	cmd := NewIndexCmd(rebuildUC)
+	cmd := NewIndexCmd(rebuildUC, internal.NewEmbedderInfoUseCase(nil), nil, nil)
 	cmd.SetArgs([]string{"rebuild"})
 
 	var out bytes.Buffer
@@ -94,3 +94,46 @@ func TestIndexRebuildNoEmbedder(t *testing.T) {
 		t.Error("expected error for rebuild without embedder")
 	}
 }
+
+func TestIndexRebuildDryRunNoEmbedder(t *testing.T) {
+	rebuildUC := setupIndexTest(t)
+
+	cmd := NewIndexCmd(rebuildUC, internal.NewEmbedderInfoUseCase(nil), nil, nil)
+	cmd.SetArgs([]string{"rebuild", "--dry-run"})
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "Would embed 3 memories") {
+		t.Errorf("unexpected plan output: %q", out.String())
+	}
+}
+
+func TestLoadIndexDimensionChange(t *testing.T) {
+	dir := t.TempDir()
+	mapping := `{"key_to_id":{"a":0},"id_to_key":{"0":"a"},"next_id":1,"dimension":3}`
+	if err := os.WriteFile(filepath.Join(dir, internal.MappingFilename), []byte(mapping), 0644); err != nil {
+		t.Fatalf("write mapping: %v", err)
+	}
+	idx, err := internal.NewAnnoyIndex(dir, 768)
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+
+	var logs bytes.Buffer
+	loadIndex(context.Background(), internal.NewLogger(&logs, false, false), idx, dir)
+
+	if !strings.Contains(logs.String(), "mem index rebuild") {
+		t.Errorf("expected a rebuild warning, got %q", logs.String())
+	}
+	if idx.Len() != 0 {
+		t.Errorf("stale index should not be used, has %d keys", idx.Len())
+	}
+	if !internal.IndexStale(dir) {
+		t.Error("index should be marked stale")
+	}
+}
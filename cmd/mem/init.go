@@ -9,55 +9,119 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func NewInitCmd() *cobra.Command {
+func NewInitCmd(resolver *internal.ScopeResolver) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "init",
+		Use:   "init [path]",
 		Short: "Initialize a new memory store",
-		Long:  `Initialize a new .mem directory with git-based storage.`,
-		RunE:  runInit,
+		Long: `Initialize a new .mem directory with git-based storage in path, or the
+current directory if no path is given. With --global, initialize the global
+store in $MEM_HOME or ~/.mem instead. With --scope <name>, initialize the
+custom scope of that name from the global config, creating its directory.
+
+The store starts on the branch given with --branch, or else git.default_branch
+from the global config, or main. The choice is kept in the store's config.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runInit(cmd, resolver, args)
+		},
 	}
 
-	cmd.Flags().Bool("global", false, "Initialize global scope (~/.mem)")
+	cmd.Flags().Bool("global", false, "Initialize global scope ($MEM_HOME or ~/.mem)")
+	cmd.Flags().Bool("force", false, "Re-initialize an existing store, keeping its history and config")
+	cmd.Flags().String("branch", "", "Branch the new store starts on (default git.default_branch or main)")
 	return cmd
 }
 
-func runInit(cmd *cobra.Command, _ []string) error {
+func runInit(cmd *cobra.Command, resolver *internal.ScopeResolver, args []string) error {
 	isGlobal, _ := cmd.Flags().GetBool("global")
+	force, _ := cmd.Flags().GetBool("force")
+	scopeHint, _ := cmd.Flags().GetString("scope")
+	if scopeHint == "project" {
+		scopeHint = ""
+	}
 
-	resolver := internal.NewScopeResolver()
+	if (isGlobal || scopeHint != "") && len(args) > 0 {
+		return fmt.Errorf("--global or --scope and a path are mutually exclusive")
+	}
 
 	var scope internal.Scope
 	if isGlobal {
 		scope = resolver.Global()
+	} else if scopeHint != "" {
+		var err error
+		if scope, err = resolver.Lookup(scopeHint); err != nil {
+			return err
+		}
 	} else {
-		cwd, err := os.Getwd()
+		root, err := resolver.WorkDir()
 		if err != nil {
-			return fmt.Errorf("get working directory: %w", err)
+			return fmt.Errorf("resolve path: %w", err)
+		}
+		if len(args) > 0 {
+			if filepath.IsAbs(args[0]) {
+				root = filepath.Clean(args[0])
+			} else {
+				root = filepath.Join(root, args[0])
+			}
 		}
 		scope = internal.Scope{
 			Type:    internal.ScopeProject,
-			Path:    cwd,
-			MemPath: filepath.Join(cwd, ".mem"),
+			Path:    root,
+			MemPath: filepath.Join(root, ".mem"),
 		}
 	}
 
-	if _, err := os.Stat(scope.MemPath); err == nil {
-		return fmt.Errorf("already initialized at %s", scope.MemPath)
+	if _, err := os.Stat(scope.MemPath); err == nil && !force {
+		return fmt.Errorf("already initialized at %s (use --force to re-initialize)", scope.MemPath)
 	}
 
+	_, statErr := os.Stat(filepath.Join(scope.MemPath, ".git"))
+	fresh := os.IsNotExist(statErr)
+
 	if err := os.MkdirAll(scope.VectorPath(), 0755); err != nil {
 		return fmt.Errorf("create vectors directory: %w", err)
 	}
+	if fresh {
+		if err := setInitBranch(cmd, resolver, scope); err != nil {
+			return err
+		}
+	}
 
+	// A forced re-init keeps an existing repository, its history and its
+	// config, and only fills in what is missing.
 	if err := internal.InitRepository(scope); err != nil {
 		return fmt.Errorf("init repository: %w", err)
 	}
 
-	cfg := internal.DefaultConfig()
-	if err := internal.SaveConfig(scope, cfg); err != nil {
+	if _, err := internal.EnsureConfig(scope); err != nil {
 		return fmt.Errorf("save config: %w", err)
 	}
 
 	fmt.Fprintf(cmd.OutOrStdout(), "Initialized memory store at %s\n", scope.MemPath)
 	return nil
 }
+
+// setInitBranch records the branch a new store starts on in its config,
+// where InitRepository reads it: --branch, or the global config's
+// git.default_branch for stores other than the global one.
+func setInitBranch(cmd *cobra.Command, resolver *internal.ScopeResolver, scope internal.Scope) error {
+	branch, _ := cmd.Flags().GetString("branch")
+	if branch == "" && scope.Type != internal.ScopeGlobal {
+		if global, err := internal.LoadConfig(resolver.Global()); err == nil {
+			branch = global.Git.DefaultBranch
+		}
+	}
+	if branch == "" {
+		return nil
+	}
+
+	cfg, err := internal.LoadConfig(scope)
+	if err != nil {
+		return err
+	}
+	cfg.Git.DefaultBranch = branch
+	if err := internal.SaveConfig(scope, cfg); err != nil {
+		return fmt.Errorf("save config: %w", err)
+	}
+	return nil
+}
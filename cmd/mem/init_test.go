@@ -2,21 +2,21 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/4thel00z/memories/internal"
 )
 
 func TestInitCmd(t *testing.T) {
 	tmpDir := t.TempDir()
-	origWd, _ := os.Getwd()
-	defer func() { _ = os.Chdir(origWd) }()
-
-	if err := os.Chdir(tmpDir); err != nil {
-		t.Fatalf("chdir: %v", err)
-	}
+	resolver := internal.NewScopeResolverAt(tmpDir, "")
 
-	cmd := NewInitCmd()
+	cmd := NewInitCmd(resolver)
 	var out bytes.Buffer
 	cmd.SetOut(&out)
 
@@ -40,21 +40,48 @@ func TestInitCmd(t *testing.T) {
 	}
 }
 
-func TestInitCmdAlreadyInitialized(t *testing.T) {
+func TestInitCmdBranch(t *testing.T) {
 	tmpDir := t.TempDir()
-	origWd, _ := os.Getwd()
-	defer func() { _ = os.Chdir(origWd) }()
+	resolver := internal.NewScopeResolverAt(tmpDir, t.TempDir())
 
-	if err := os.Chdir(tmpDir); err != nil {
-		t.Fatalf("chdir: %v", err)
+	cmd := NewInitCmd(resolver)
+	cmd.SetArgs([]string{"--branch", "master"})
+	cmd.SetOut(&bytes.Buffer{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
 	}
 
+	scope, _ := resolver.Project()
+	repo, err := internal.NewGitRepository(scope)
+	if err != nil {
+		t.Fatalf("open repo: %v", err)
+	}
+	current, err := repo.Current(context.Background())
+	if err != nil {
+		t.Fatalf("current: %v", err)
+	}
+	if current.Name != "master" {
+		t.Errorf("current branch = %q, want master", current.Name)
+	}
+	cfg, err := internal.LoadConfig(scope)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.Git.DefaultBranch != "master" {
+		t.Errorf("git.default_branch = %q", cfg.Git.DefaultBranch)
+	}
+}
+
+func TestInitCmdAlreadyInitialized(t *testing.T) {
+	tmpDir := t.TempDir()
+	resolver := internal.NewScopeResolverAt(tmpDir, "")
+
 	memPath := filepath.Join(tmpDir, ".mem")
 	if err := os.MkdirAll(memPath, 0755); err != nil {
 		t.Fatalf("mkdir: %v", err)
 	}
 
-	cmd := NewInitCmd()
+	cmd := NewInitCmd(resolver)
 	var out bytes.Buffer
 	cmd.SetOut(&out)
 	cmd.SetErr(&out)
@@ -72,7 +99,7 @@ func TestInitCmdGlobal(t *testing.T) {
 	defer os.Setenv("HOME", origHome)
 	os.Setenv("HOME", tmpDir)
 
-	cmd := NewInitCmd()
+	cmd := NewInitCmd(internal.NewScopeResolver())
 	cmd.SetArgs([]string{"--global"})
 	var out bytes.Buffer
 	cmd.SetOut(&out)
@@ -86,3 +113,150 @@ func TestInitCmdGlobal(t *testing.T) {
 		t.Error("global .mem directory not created")
 	}
 }
+
+func TestInitCmdGlobalMemHome(t *testing.T) {
+	memHome := filepath.Join(t.TempDir(), "store")
+	t.Setenv("MEM_HOME", memHome)
+
+	cmd := NewInitCmd(internal.NewScopeResolver())
+	cmd.SetArgs([]string{"--global"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	for _, p := range []string{".git", "vectors", "config.yaml"} {
+		if _, err := os.Stat(filepath.Join(memHome, p)); os.IsNotExist(err) {
+			t.Errorf("%s not created in $MEM_HOME", p)
+		}
+	}
+}
+
+func TestInitCmdExplicitPath(t *testing.T) {
+	target := filepath.Join(t.TempDir(), "project")
+
+	cmd := NewInitCmd(internal.NewScopeResolver())
+	cmd.SetArgs([]string{target})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	memPath := filepath.Join(target, ".mem")
+	for _, p := range []string{".git", "vectors", "config.yaml"} {
+		if _, err := os.Stat(filepath.Join(memPath, p)); os.IsNotExist(err) {
+			t.Errorf("%s not created", p)
+		}
+	}
+	if !strings.Contains(out.String(), memPath) {
+		t.Errorf("expected %q in output, got %q", memPath, out.String())
+	}
+}
+
+func TestInitCmdRelativePath(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	cmd := NewInitCmd(internal.NewScopeResolverAt(tmpDir, ""))
+	cmd.SetArgs([]string{"sub"})
+	cmd.SetOut(&bytes.Buffer{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, "sub", ".mem", "config.yaml")); err != nil {
+		t.Errorf("relative path not resolved against the resolver's directory: %v", err)
+	}
+}
+
+func TestInitCmdForce(t *testing.T) {
+	target := t.TempDir()
+
+	cmd := NewInitCmd(internal.NewScopeResolver())
+	cmd.SetArgs([]string{target})
+	cmd.SetOut(&bytes.Buffer{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("first init: %v", err)
+	}
+
+	cmd = NewInitCmd(internal.NewScopeResolver())
+	cmd.SetArgs([]string{target})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil {
+		t.Fatal("expected error re-initializing without --force")
+	}
+
+	scope := internal.Scope{Type: internal.ScopeProject, Path: target, MemPath: filepath.Join(target, ".mem")}
+	cfg := internal.DefaultConfig()
+	cfg.DefaultProvider = "mine"
+	if err := internal.SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	cmd = NewInitCmd(internal.NewScopeResolver())
+	cmd.SetArgs([]string{"--force", target})
+	cmd.SetOut(&bytes.Buffer{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("init --force: %v", err)
+	}
+
+	loaded, err := internal.LoadConfig(scope)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if loaded.DefaultProvider != "mine" {
+		t.Error("init --force should keep the existing config")
+	}
+}
+
+func TestInitCmdGlobalWithPath(t *testing.T) {
+	cmd := NewInitCmd(internal.NewScopeResolver())
+	cmd.SetArgs([]string{"--global", t.TempDir()})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error combining --global with a path")
+	}
+}
+
+func TestInitCmdCustomScope(t *testing.T) {
+	tmp := t.TempDir()
+	t.Setenv("MEM_HOME", filepath.Join(tmp, "global"))
+
+	workDir := filepath.Join(tmp, "stores", "work")
+	cfg := internal.DefaultConfig()
+	cfg.Scopes = map[string]string{"work": workDir}
+	if err := os.MkdirAll(filepath.Join(tmp, "global"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := internal.SaveConfig(internal.NewScopeResolver().Global(), cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	root := NewRootCmd("test", &app{resolver: internal.NewScopeResolver(), uc: &internal.UseCases{}})
+	root.SetArgs([]string{"init", "--scope", "work"})
+	var out bytes.Buffer
+	root.SetOut(&out)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	for _, p := range []string{".git", "vectors", "config.yaml"} {
+		if _, err := os.Stat(filepath.Join(workDir, ".mem", p)); os.IsNotExist(err) {
+			t.Errorf("%s not created in the work scope", p)
+		}
+	}
+
+	root = NewRootCmd("test", &app{resolver: internal.NewScopeResolver(), uc: &internal.UseCases{}})
+	root.SetArgs([]string{"init", "--scope", "wrok"})
+	root.SetOut(&out)
+	root.SetErr(&out)
+	if err := root.Execute(); !errors.Is(err, internal.ErrUnknownScope) {
+		t.Errorf("expected ErrUnknownScope, got %v", err)
+	}
+}
@@ -15,7 +15,7 @@ func NewInstallCmd(uc *internal.InstallHookUseCase) *cobra.Command {
 		RunE:  makeInstallRunner(uc),
 	}
 
-	cmd.Flags().String("strategy", "extract", "Hook strategy (summarize|extract|script|all)")
+	cmd.Flags().String("strategy", "extract", "Hook strategy (extract|record|llm-extract|summarize|script|all)")
 	cmd.Flags().String("script", "", "Path to custom hook script (used with strategy=script or all)")
 	cmd.Flags().Bool("force", false, "Overwrite existing hook (backs up original)")
 
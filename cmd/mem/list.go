@@ -8,16 +8,33 @@ import (
 	"github.com/spf13/cobra"
 )
 
+type listPageJSON struct {
+	Memories []map[string]any `json:"memories"`
+	Next     string           `json:"next,omitempty"`
+}
+
 func NewListCmd(listUC *internal.ListMemoriesUseCase) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "list [prefix]",
 		Aliases: []string{"ls"},
 		Short:   "List memories",
-		Long:    `List all memories, optionally filtered by prefix.`,
-		Args:    cobra.MaximumNArgs(1),
-		RunE:    makeListRunner(listUC),
+		Long: `List all memories, optionally filtered by prefix, sorted by key.
+The prefix matches whole path segments: "foo" lists foo and foo/y but
+not foobar/x. --raw-prefix matches any key starting with the prefix.
+--sort updated or --sort created lists the newest first instead.
+
+--limit lists at most that many memories. When it cuts the list short,
+the last key listed is the cursor for the next page: pass it to --after.
+With --limit or --after, --json prints {"memories": [...], "next": key},
+where next is left out on the last page.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: makeListRunner(listUC),
 	}
 
+	cmd.Flags().String("sort", internal.SortByKey, "Sort by key, updated or created")
+	cmd.Flags().Int("limit", 0, "List at most this many memories (0: all)")
+	cmd.Flags().String("after", "", "List the memories following this key, e.g. the next cursor of a previous page")
+	cmd.Flags().Bool("raw-prefix", false, "Match the prefix as a plain string, e.g. foo also lists foobar/x")
 	return cmd
 }
 
@@ -29,27 +46,40 @@ func makeListRunner(listUC *internal.ListMemoriesUseCase) func(*cobra.Command, [
 		}
 
 		scopeHint, _ := cmd.Flags().GetString("scope")
+		sortBy, _ := cmd.Flags().GetString("sort")
+		rawPrefix, _ := cmd.Flags().GetBool("raw-prefix")
+		limit, _ := cmd.Flags().GetInt("limit")
+		after, _ := cmd.Flags().GetString("after")
 		asJSON, _ := cmd.Flags().GetBool("json")
+		if limit < 0 {
+			return fmt.Errorf("--limit must not be negative, got %d", limit)
+		}
 
 		out, err := listUC.Execute(cmd.Context(), internal.ListMemoriesInput{
-			Prefix: prefix, Scope: scopeHint,
+			Prefix: prefix, RawPrefix: rawPrefix, Scope: scopeHint, SortBy: sortBy,
+			Limit: limit, After: after,
 		})
 		if err != nil {
 			return fmt.Errorf("list memories: %w", err)
 		}
 
 		if asJSON {
-			return outputListJSON(cmd, out)
+			return outputListJSON(cmd, out, limit > 0 || after != "")
 		}
 
 		for _, mem := range out.Memories {
 			fmt.Fprintln(cmd.OutOrStdout(), mem.Key)
 		}
+		if out.Next != "" {
+			fmt.Fprintf(cmd.ErrOrStderr(), "More memories follow; list them with --after %s\n", out.Next)
+		}
 		return nil
 	}
 }
 
-func outputListJSON(cmd *cobra.Command, out *internal.ListMemoriesOutput) error {
+// outputListJSON prints the memories as an array, or for a page as an
+// object that also holds the cursor of the next page.
+func outputListJSON(cmd *cobra.Command, out *internal.ListMemoriesOutput, paged bool) error {
 	data := make([]map[string]any, 0, len(out.Memories))
 	for _, mem := range out.Memories {
 		data = append(data, map[string]any{
@@ -61,5 +91,8 @@ func outputListJSON(cmd *cobra.Command, out *internal.ListMemoriesOutput) error
 
 	enc := json.NewEncoder(cmd.OutOrStdout())
 	enc.SetIndent("", "  ")
+	if paged {
+		return enc.Encode(listPageJSON{Memories: data, Next: out.Next})
+	}
 	return enc.Encode(data)
 }
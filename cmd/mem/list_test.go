@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -86,7 +87,7 @@ func TestListCmdWithPrefix(t *testing.T) {
 		t.Fatalf("new repo: %v", err)
 	}
 
-	for _, name := range []string{"foo/a", "foo/b", "bar/c"} {
+	for _, name := range []string{"foo/a", "foo/b", "foobar/d", "bar/c"} {
 		key, _ := internal.NewKey(name)
 		mem := &internal.Memory{
 			Key:       key,
@@ -122,6 +123,16 @@ func TestListCmdWithPrefix(t *testing.T) {
 			t.Errorf("unexpected key %q, expected foo/ prefix", line)
 		}
 	}
+	cmd = NewListCmd(listUC)
+	cmd.SetArgs([]string{"foo", "--raw-prefix"})
+	out.Reset()
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute --raw-prefix: %v", err)
+	}
+	if got := strings.Fields(out.String()); len(got) != 3 || got[2] != "foobar/d" {
+		t.Errorf("--raw-prefix listed %v, want foo/a foo/b foobar/d", got)
+	}
 }
 
 func TestListCmdEmpty(t *testing.T) {
@@ -161,3 +172,72 @@ func TestListCmdEmpty(t *testing.T) {
 		t.Errorf("expected empty output, got %q", out.String())
 	}
 }
+
+func TestListCmdPaging(t *testing.T) {
+	tmpDir := t.TempDir()
+	scope := internal.Scope{
+		Type:    internal.ScopeProject,
+		Path:    tmpDir,
+		MemPath: filepath.Join(tmpDir, ".mem"),
+	}
+	if err := internal.InitRepository(scope); err != nil {
+		t.Fatalf("init repo: %v", err)
+	}
+	repo, err := internal.NewGitRepository(scope)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+	for _, name := range []string{"c", "a", "b"} {
+		key, _ := internal.NewKey(name)
+		if err := repo.Save(context.Background(), internal.NewMemory(key, []byte(name))); err != nil {
+			t.Fatalf("save %s: %v", name, err)
+		}
+	}
+	listUC := internal.NewListMemoriesUseCase(internal.NewScopeResolver(),
+		func(internal.Scope) (internal.MemoryRepository, error) { return repo, nil })
+
+	list := func(args ...string) (string, string) {
+		t.Helper()
+		cmd := NewListCmd(listUC)
+		cmd.Root().PersistentFlags().Bool("json", false, "")
+		cmd.SetArgs(args)
+		var out, errOut bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetErr(&errOut)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("list %v: %v", args, err)
+		}
+		return out.String(), errOut.String()
+	}
+
+	out, hint := list("--limit", "2")
+	if out != "a\nb\n" || !strings.Contains(hint, "--after b") {
+		t.Errorf("--limit 2 printed %q and %q, want a, b and a hint to continue after b", out, hint)
+	}
+
+	var page listPageJSON
+	out, _ = list("--limit", "2", "--json")
+	if err := json.Unmarshal([]byte(out), &page); err != nil {
+		t.Fatalf("parse %q: %v", out, err)
+	}
+	if len(page.Memories) != 2 || page.Next != "b" {
+		t.Errorf("first page = %+v, want 2 memories and next b", page)
+	}
+
+	page = listPageJSON{}
+	out, _ = list("--limit", "2", "--after", "b", "--json")
+	if err := json.Unmarshal([]byte(out), &page); err != nil {
+		t.Fatalf("parse %q: %v", out, err)
+	}
+	if len(page.Memories) != 1 || page.Memories[0]["key"] != "c" || page.Next != "" {
+		t.Errorf("last page = %+v, want only c and no next", page)
+	}
+	if strings.Contains(out, `"next"`) {
+		t.Errorf("last page %q should leave next out", out)
+	}
+
+	out, _ = list("--json")
+	if !strings.HasPrefix(strings.TrimSpace(out), "[") {
+		t.Errorf("unpaged --json = %q, want a plain array", out)
+	}
+}
@@ -2,7 +2,12 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/4thel00z/memories/internal"
 	"github.com/spf13/cobra"
@@ -12,12 +17,25 @@ func NewLogCmd(logUC *internal.LogUseCase) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "log",
 		Short: "Show commit history",
-		Long:  `Show the commit history for the memory store.`,
-		RunE:  makeLogRunner(logUC),
+		Long: `Show the commit history for the memory store.
+
+With --graph, commits from every branch are shown one per line with ASCII
+lines tracing their ancestry, like git log --graph --all --oneline.
+
+--since and --until take a date (2006-01-02, optionally with a time, or
+RFC 3339) or an age such as 12h or 7d. --grep and --author match a
+case-insensitive substring of the message and the author. Filters combine,
+and -n counts the commits that match them all.`,
+		RunE: makeLogRunner(logUC),
 	}
 
 	cmd.Flags().IntP("number", "n", 10, "Limit number of commits")
 	cmd.Flags().Bool("oneline", false, "Show each commit on one line")
+	cmd.Flags().Bool("graph", false, "Draw the history of all branches as a graph")
+	cmd.Flags().String("since", "", "Only commits after this date or age, e.g. 2024-01-31 or 7d")
+	cmd.Flags().String("until", "", "Only commits before this date or age")
+	cmd.Flags().String("grep", "", "Only commits whose message contains this text")
+	cmd.Flags().String("author", "", "Only commits whose author contains this text")
 	return cmd
 }
 
@@ -25,12 +43,37 @@ func makeLogRunner(logUC *internal.LogUseCase) func(*cobra.Command, []string) er
 	return func(cmd *cobra.Command, args []string) error {
 		limit, _ := cmd.Flags().GetInt("number")
 		oneline, _ := cmd.Flags().GetBool("oneline")
+		graph, _ := cmd.Flags().GetBool("graph")
 		scopeHint, _ := cmd.Flags().GetString("scope")
 		asJSON, _ := cmd.Flags().GetBool("json")
 
-		out, err := logUC.Execute(cmd.Context(), internal.LogInput{
-			Limit: limit, Scope: scopeHint,
-		})
+		input := internal.LogInput{Limit: limit, Scope: scopeHint, All: graph}
+		input.Grep, _ = cmd.Flags().GetString("grep")
+		input.Author, _ = cmd.Flags().GetString("author")
+		now := time.Now()
+		if since, _ := cmd.Flags().GetString("since"); since != "" {
+			t, err := parseTimeFlag(since, now)
+			if err != nil {
+				return fmt.Errorf("invalid --since: %w", err)
+			}
+			input.Since = t
+		}
+		if until, _ := cmd.Flags().GetString("until"); until != "" {
+			t, err := parseTimeFlag(until, now)
+			if err != nil {
+				return fmt.Errorf("invalid --until: %w", err)
+			}
+			input.Until = t
+		}
+
+		out, err := logUC.Execute(cmd.Context(), input)
+		if errors.Is(err, internal.ErrNoCommits) {
+			if asJSON {
+				return outputCommitsJSON(cmd, nil)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "No commits yet; run `mem set` or `mem commit`.")
+			return nil
+		}
 		if err != nil {
 			return fmt.Errorf("get log: %w", err)
 		}
@@ -39,12 +82,22 @@ func makeLogRunner(logUC *internal.LogUseCase) func(*cobra.Command, []string) er
 			return outputCommitsJSON(cmd, out.Commits)
 		}
 
+		color := colorEnabled(cmd)
+		stop := startPager(cmd)
+		defer stop()
+
+		if graph {
+			writeLogGraph(cmd.OutOrStdout(), out.Commits, color)
+			return nil
+		}
+
 		for _, c := range out.Commits {
 			if oneline {
-				fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", c.Hash[:7], c.Message)
+				fmt.Fprintf(cmd.OutOrStdout(), "%s %s\n", colorize(color, ansiYellow, c.Hash[:7]), c.Message)
 			} else {
-				fmt.Fprintf(cmd.OutOrStdout(), "commit %s\n", c.Hash)
-				fmt.Fprintf(cmd.OutOrStdout(), "Date:   %s\n\n", c.Timestamp.Format("Mon Jan 2 15:04:05 2006 -0700"))
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\n", colorize(color, ansiYellow, "commit "+c.Hash))
+				fmt.Fprintf(cmd.OutOrStdout(), "Author: %s\n", authorLine(c))
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\n\n", colorize(color, ansiDim, "Date:   "+c.Timestamp.Format("Mon Jan 2 15:04:05 2006 -0700")))
 				fmt.Fprintf(cmd.OutOrStdout(), "    %s\n\n", c.Message)
 			}
 		}
@@ -52,17 +105,141 @@ func makeLogRunner(logUC *internal.LogUseCase) func(*cobra.Command, []string) er
 	}
 }
 
+// authorLine formats an author as git does, "name <email>".
+func authorLine(c internal.CommitOutput) string {
+	if c.Email == "" {
+		return c.Author
+	}
+	return fmt.Sprintf("%s <%s>", c.Author, c.Email)
+}
+
+// timeLayouts are the dates parseTimeFlag accepts, in local time unless
+// they carry a zone.
+var timeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02"}
+
+// parseTimeFlag parses a date in one of timeLayouts, or an age such as 12h
+// or 7d counted back from now.
+func parseTimeFlag(s string, now time.Time) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	age, err := parseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a date nor an age such as 7d", s)
+	}
+	return now.Add(-age), nil
+}
+
 func outputCommitsJSON(cmd *cobra.Command, commits []internal.CommitOutput) error {
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(commitsToJSON(commits))
+}
+
+func commitsToJSON(commits []internal.CommitOutput) []map[string]any {
 	out := make([]map[string]any, 0, len(commits))
 	for _, c := range commits {
-		out = append(out, map[string]any{
+		entry := map[string]any{
 			"hash":      c.Hash,
 			"message":   c.Message,
+			"author":    c.Author,
+			"email":     c.Email,
 			"timestamp": c.Timestamp,
-		})
+			"parents":   emptyIfNil(c.Parents),
+		}
+		if c.Refs != nil {
+			entry["refs"] = c.Refs
+		}
+		out = append(out, entry)
 	}
+	return out
+}
 
-	enc := json.NewEncoder(cmd.OutOrStdout())
-	enc.SetIndent("", "  ")
-	return enc.Encode(out)
+// writeLogGraph draws commits, children before parents, one per line. Each
+// column is a lane waiting for a commit: "*" marks the commit on its lane,
+// "|/" joins lanes that reach the same parent and "|\" opens a lane for a
+// second parent.
+func writeLogGraph(w io.Writer, commits []internal.CommitOutput, color bool) {
+	var lanes []string
+	for _, c := range commits {
+		col := slices.Index(lanes, c.Hash)
+		if col < 0 {
+			lanes = append(lanes, c.Hash)
+			col = len(lanes) - 1
+		}
+		// Lanes to the right that were waiting for the same commit join it.
+		for j := len(lanes) - 1; j > col; j-- {
+			if lanes[j] != c.Hash {
+				continue
+			}
+			fmt.Fprintln(w, graphJoin(len(lanes), j))
+			lanes = slices.Delete(lanes, j, j+1)
+		}
+
+		line := graphCells(len(lanes), col, "*")
+		refs := ""
+		if len(c.Refs) > 0 {
+			refs = colorize(color, ansiGreen, "("+strings.Join(c.Refs, ", ")+")") + " "
+		}
+		fmt.Fprintf(w, "%s%s %s%s\n", line, colorize(color, ansiYellow, c.Hash[:7]), refs, c.Message)
+
+		if len(c.Parents) == 0 {
+			lanes = slices.Delete(lanes, col, col+1)
+			continue
+		}
+		lanes[col] = c.Parents[0]
+		for _, p := range c.Parents[1:] {
+			if slices.Contains(lanes, p) {
+				continue
+			}
+			fmt.Fprintln(w, graphFork(len(lanes), col))
+			lanes = slices.Insert(lanes, col+1, p)
+		}
+	}
+}
+
+func graphCells(n, col int, mark string) string {
+	var b strings.Builder
+	for i := range n {
+		if i == col {
+			b.WriteString(mark + " ")
+		} else {
+			b.WriteString("| ")
+		}
+	}
+	return b.String()
+}
+
+// graphJoin draws lane j folding into the lane on its left.
+func graphJoin(n, j int) string {
+	var b strings.Builder
+	for i := range n {
+		switch {
+		case i < j-1:
+			b.WriteString("| ")
+		case i == j-1:
+			b.WriteString("|/")
+		case i > j:
+			b.WriteString(" /")
+		}
+	}
+	return strings.TrimRight(b.String(), " ")
+}
+
+// graphFork draws a new lane opening to the right of col.
+func graphFork(n, col int) string {
+	var b strings.Builder
+	for i := range n {
+		switch {
+		case i < col:
+			b.WriteString("| ")
+		case i == col:
+			b.WriteString("|\\")
+		default:
+			b.WriteString(" \\")
+		}
+	}
+	return b.String()
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -77,6 +78,10 @@ func TestLogCmd(t *testing.T) {
 	if !strings.Contains(output, "add: third") {
 		t.Errorf("missing 'add: third' in output: %s", output)
 	}
+	author := "Author: " + internal.DefaultAuthor + " <" + internal.DefaultEmail + ">"
+	if !strings.Contains(output, author) {
+		t.Errorf("missing %q in output: %s", author, output)
+	}
 }
 
 func TestLogCmdOneline(t *testing.T) {
@@ -124,3 +129,135 @@ func TestLogCmdLimit(t *testing.T) {
 		t.Errorf("expected 2 entries with -n 2, got %d: %v", len(lines), lines)
 	}
 }
+
+func TestLogCmdGraph(t *testing.T) {
+	repo, logUC := setupLogTest(t)
+	ctx := context.Background()
+
+	if _, err := repo.Create(ctx, "feature", ""); err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	commit := func(name string) {
+		t.Helper()
+		key, _ := internal.NewKey(name)
+		if err := repo.Save(ctx, internal.NewMemory(key, []byte(name))); err != nil {
+			t.Fatalf("save %s: %v", name, err)
+		}
+		if _, err := repo.Commit(ctx, "add: "+name); err != nil {
+			t.Fatalf("commit %s: %v", name, err)
+		}
+	}
+	commit("on-main")
+	if err := repo.Switch(ctx, "feature", false); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	commit("on-feature")
+
+	cmd := NewLogCmd(logUC)
+	cmd.SetArgs([]string{"--graph", "-n", "0"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	output := out.String()
+	for _, want := range []string{"(feature) add: on-feature", "add: on-main", "|/", "* "} {
+		if !strings.Contains(output, want) {
+			t.Errorf("graph missing %q:\n%s", want, output)
+		}
+	}
+	// The diverged tips sit on two lanes until they meet at "add: third".
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	if !strings.HasPrefix(lines[1], "| * ") {
+		t.Errorf("second tip should be on its own lane, got %q:\n%s", lines[1], output)
+	}
+	if !strings.Contains(lines[3], "add: third") {
+		t.Errorf("branches should join at add: third, got %q:\n%s", lines[3], output)
+	}
+}
+
+func TestLogCmdFilters(t *testing.T) {
+	_, logUC := setupLogTest(t)
+
+	run := func(args ...string) []string {
+		t.Helper()
+		cmd := NewLogCmd(logUC)
+		cmd.SetArgs(append([]string{"--oneline"}, args...))
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		text := strings.TrimSpace(out.String())
+		if text == "" {
+			return nil
+		}
+		return strings.Split(text, "\n")
+	}
+
+	if lines := run("--grep", "SECOND"); len(lines) != 1 || !strings.HasSuffix(lines[0], "add: second") {
+		t.Errorf("--grep: got %v", lines)
+	}
+	// -n counts matches, not the commits walked to find them.
+	if lines := run("--grep", "add:", "-n", "3"); len(lines) != 3 {
+		t.Errorf("--grep with -n 3: got %v", lines)
+	}
+	if lines := run("--author", "mem", "--grep", "first"); len(lines) != 1 {
+		t.Errorf("--author and --grep: got %v", lines)
+	}
+	if lines := run("--author", "nobody"); len(lines) != 0 {
+		t.Errorf("--author nobody: got %v", lines)
+	}
+	if lines := run("--since", "1h", "--grep", "third"); len(lines) != 1 {
+		t.Errorf("--since 1h: got %v", lines)
+	}
+	if lines := run("--until", "2000-01-01"); len(lines) != 0 {
+		t.Errorf("--until 2000-01-01: got %v", lines)
+	}
+
+	cmd := NewLogCmd(logUC)
+	cmd.SetArgs([]string{"--since", "yesterday"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "--since") {
+		t.Errorf("expected an invalid --since error, got %v", err)
+	}
+}
+
+func TestLogJSONIncludesAuthorAndParents(t *testing.T) {
+	a, _ := setupE2E(t)
+	for _, v := range []string{"one", "two"} {
+		root := NewRootCmd("test", a)
+		root.SetArgs([]string{"set", "k", v})
+		root.SetOut(&bytes.Buffer{})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("set: %v", err)
+		}
+	}
+
+	root := NewRootCmd("test", a)
+	root.SetArgs([]string{"log", "--json", "-n", "2"})
+	var out bytes.Buffer
+	root.SetOut(&out)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	var commits []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &commits); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	if len(commits) != 2 {
+		t.Fatalf("expected 2 commits, got %d", len(commits))
+	}
+	if commits[0]["author"] != internal.DefaultAuthor || commits[0]["email"] != internal.DefaultEmail {
+		t.Errorf("author = %v <%v>, want %q <%q>", commits[0]["author"], commits[0]["email"], internal.DefaultAuthor, internal.DefaultEmail)
+	}
+	parents, _ := commits[0]["parents"].([]any)
+	if len(parents) != 1 || parents[0] != commits[1]["hash"] {
+		t.Errorf("parents = %v, want [%v]", commits[0]["parents"], commits[1]["hash"])
+	}
+	if _, ok := commits[0]["refs"]; ok {
+		t.Error("refs should only be reported with --graph")
+	}
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
@@ -21,8 +22,10 @@ func main() {
 		return
 	}
 
-	debug := hasDebugFlag()
-	app := newApp(debug)
+	debug := hasFlag("--debug")
+	quiet := hasFlag("--quiet") || hasFlag("-q")
+	app := newApp(debug, quiet)
+	ctx = internal.WithLogger(ctx, app.logger)
 	rootCmd := NewRootCmd(version, app)
 	if err := fang.Execute(ctx, rootCmd); err != nil {
 		os.Exit(1)
@@ -54,133 +57,234 @@ func tryExternalCommand(ctx context.Context) bool {
 type app struct {
 	resolver *internal.ScopeResolver
 	uc       *internal.UseCases
+	logger   *slog.Logger
 }
 
-func hasDebugFlag() bool {
+func hasFlag(name string) bool {
 	for _, arg := range os.Args[1:] {
-		if arg == "--debug" {
+		if arg == name {
 			return true
 		}
 	}
 	return false
 }
 
-func newApp(debug bool) *app {
-	resolver := internal.NewScopeResolver()
+func newApp(debug, quiet bool) *app {
+	// Logs always go to stderr so --json output on stdout stays parseable.
+	logger := internal.NewLogger(os.Stderr, debug, quiet)
+	slog.SetDefault(logger)
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		logger.Warn("failed to get working directory", "error", err)
+	}
+	resolver := internal.NewScopeResolverAt(cwd, "")
+	if resolver.MaxDepth() == 0 {
+		if cfg, err := internal.LoadConfig(resolver.Global()); err == nil {
+			resolver.SetMaxDepth(cfg.MaxDepth)
+		}
+	}
 
 	repoFor := func(scope internal.Scope) (internal.MemoryRepository, error) {
-		return internal.NewGitRepository(scope)
+		repo, err := internal.NewGitRepository(scope)
+		if err != nil {
+			return nil, err
+		}
+		exclude, err := internal.NewIgnoreMatcher(scope)
+		if err != nil {
+			logger.Warn("failed to read ignore file", "error", err)
+			exclude = &internal.IgnoreMatcher{}
+		}
+		if cfg, err := internal.LoadConfig(scope); err == nil {
+			repo.SetDurable(cfg.Storage.Durable)
+			exclude.Add(cfg.Storage.ListExcludes...)
+		}
+		repo.SetExclude(exclude)
+		return repo, nil
+	}
+	blobsFor := func(scope internal.Scope) (internal.AttachmentStore, error) {
+		repo, err := internal.NewGitRepository(scope)
+		if err != nil {
+			return nil, err
+		}
+		if cfg, err := internal.LoadConfig(scope); err == nil {
+			repo.SetDurable(cfg.Storage.Durable)
+		}
+		return repo, nil
 	}
 	histFor := func(scope internal.Scope) (internal.HistoryRepository, error) {
-		return internal.NewGitRepository(scope)
+		repo, err := internal.NewGitRepository(scope)
+		if err != nil {
+			return nil, err
+		}
+		if cfg, err := internal.LoadConfig(scope); err == nil {
+			repo.SetSigningKey(cfg.Git.SigningKey)
+		}
+		return repo, nil
 	}
 	branchFor := func(scope internal.Scope) (internal.BranchRepository, error) {
 		return internal.NewGitRepository(scope)
 	}
+	snapFor := func(scope internal.Scope) (internal.SnapshotRepository, error) {
+		repo, err := internal.NewGitRepository(scope)
+		if err != nil {
+			return nil, err
+		}
+		if cfg, err := internal.LoadConfig(scope); err == nil {
+			repo.SetDurable(cfg.Storage.Durable)
+		}
+		return repo, nil
+	}
 
-	// Lazy embedder + index initialization (only loaded on first use)
+	// The embedder is loaded on first use, so commands that never embed,
+	// such as get and list, never load the model.
 	var (
 		embedderOnce sync.Once
 		embedder     internal.Embedder
 	)
-
-	lazyEmbedder := func() internal.Embedder {
+	lazyEmbedder := internal.EmbedderFunc(func() internal.Embedder {
 		embedderOnce.Do(func() {
-			cacheDir, err := internal.DefaultCacheDir()
-			if err != nil {
-				slog.Warn("failed to get cache dir for embedder", "error", err)
-				return
-			}
-
-			// Load config from resolved scope for model URL and token
-			modelURL, modelFilename, token := embeddingsFromConfig(resolver)
-
-			dl := internal.NewDownloader(cacheDir, token)
-			modelPath, err := dl.EnsureModel(context.Background(),
-				modelURL, modelFilename, nil)
-			if err != nil {
-				slog.Warn("failed to download embedding model", "error", err)
-				return
-			}
-
-			var embedOpts []internal.EmbedderOption
-			if debug {
-				embedOpts = append(embedOpts, internal.WithDebug())
-			}
-			e, err := internal.NewLocalEmbedder(modelPath, 0, embedOpts...)
-			if err != nil {
-				slog.Warn("failed to initialize embedder", "error", err)
-				return
-			}
-
-			embedder = e
+			embedder = loadEmbedder(resolver, logger, debug)
 		})
 		return embedder
-	}
+	})
 
 	indexFor := func(scope internal.Scope) (internal.VectorIndex, error) {
 		e := lazyEmbedder()
 		if e == nil {
 			return nil, internal.ErrNoIndex
 		}
-		idx, err := internal.NewAnnoyIndex(scope.VectorPath(), e.Dimension())
+		var branches internal.BranchRepository
+		if repo, err := internal.NewGitRepository(scope); err == nil {
+			branches = repo
+		}
+		dir := internal.IndexPath(context.Background(), scope, branches)
+		idx, err := internal.NewAnnoyIndex(dir, e.Dimension())
 		if err != nil {
 			return nil, err
 		}
-		if err := idx.Load(context.Background()); err != nil {
-			slog.Warn("failed to load index", "error", err)
+		if cfg, err := internal.LoadConfig(scope); err == nil {
+			idx.SetSearchK(cfg.Embeddings.SearchK)
+			if cfg.IndexRemote.PullOnLoad {
+				if syncer, err := internal.NewIndexSyncer(cfg.IndexRemote); err == nil {
+					idx.SetRemote(func(ctx context.Context, dir string) error {
+						_, err := syncer.Pull(ctx, dir, internal.RemoteIndexDir(scope, dir))
+						return err
+					})
+				}
+			}
 		}
+		loadIndex(context.Background(), logger, idx, dir)
 		return idx, nil
 	}
 
-	setMemoryUC := internal.NewSetMemoryUseCase(resolver, repoFor, indexFor, lazyEmbedder(), nil)
-	rebuildIndexUC := internal.NewRebuildIndexUseCase(resolver, repoFor, indexFor, lazyEmbedder())
+	providers := internal.NewProviderFactory()
+
+	setMemoryUC := internal.NewSetMemoryUseCase(resolver, repoFor, indexFor, lazyEmbedder, nil)
+	rebuildIndexUC := internal.NewRebuildIndexUseCase(resolver, repoFor, indexFor, lazyEmbedder)
 
 	hookStoreFn := func(ctx context.Context, key, content string) error {
 		return setMemoryUC.Execute(ctx, internal.SetMemoryInput{Key: key, Content: content})
 	}
 	var hookReindexFn internal.ReindexFunc = func(ctx context.Context) error {
-		return rebuildIndexUC.Execute(ctx, internal.RebuildIndexInput{NumTrees: 10})
+		_, err := rebuildIndexUC.Execute(ctx, internal.RebuildIndexInput{})
+		return err
 	}
 
 	uc := &internal.UseCases{
 		SetMemory:      setMemoryUC,
+		BulkSet:        internal.NewBulkSetUseCase(resolver, repoFor, histFor, indexFor, lazyEmbedder, nil),
 		GetMemory:      internal.NewGetMemoryUseCase(resolver, repoFor),
 		DeleteMemory:   internal.NewDeleteMemoryUseCase(resolver, repoFor, indexFor),
 		ListMemories:   internal.NewListMemoriesUseCase(resolver, repoFor),
-		AddMemory:      internal.NewAddMemoryUseCase(resolver, repoFor, histFor, indexFor, lazyEmbedder(), nil),
-		EditMemory:     internal.NewEditMemoryUseCase(resolver, repoFor, histFor, indexFor, lazyEmbedder(), nil),
+		AddMemory:      internal.NewAddMemoryUseCase(resolver, repoFor, histFor, indexFor, lazyEmbedder, nil),
+		EditMemory:     internal.NewEditMemoryUseCase(resolver, repoFor, histFor, indexFor, lazyEmbedder, nil),
 		Commit:         internal.NewCommitUseCase(resolver, histFor),
+		Verify:         internal.NewVerifyUseCase(resolver, repoFor, indexFor, lazyEmbedder),
+		Doctor:         internal.NewDoctorUseCase(resolver, repoFor),
+		MigrateKeys:    internal.NewMigrateKeysUseCase(resolver, repoFor, histFor, indexFor, lazyEmbedder),
 		Log:            internal.NewLogUseCase(resolver, histFor),
 		Diff:           internal.NewDiffUseCase(resolver, histFor),
 		Revert:         internal.NewRevertUseCase(resolver, histFor),
 		KeywordSearch:  internal.NewKeywordSearchUseCase(resolver, repoFor),
-		SemanticSearch: internal.NewSemanticSearchUseCase(resolver, indexFor, lazyEmbedder()),
+		SemanticSearch: internal.NewSemanticSearchUseCase(resolver, indexFor, lazyEmbedder),
 		RebuildIndex:   rebuildIndexUC,
-		Summarize:      internal.NewSummarizeUseCase(resolver, repoFor, nil),
-		AutoTag:        internal.NewAutoTagUseCase(resolver, repoFor, nil),
+		SyncIndex:      internal.NewSyncIndexUseCase(resolver, branchFor),
+		IndexVector:    internal.NewIndexVectorUseCase(resolver, indexFor),
+		Warmup:         internal.NewWarmupUseCase(resolver, indexFor, lazyEmbedder),
+		EmbedderInfo:   internal.NewEmbedderInfoUseCase(lazyEmbedder),
+		Summarize:      internal.NewSummarizeUseCase(resolver, repoFor, providers.For),
+		Ask:            internal.NewAskUseCase(resolver, repoFor, providers.For),
+		AutoTag:        internal.NewAutoTagUseCase(resolver, repoFor, providers.For),
 		BranchCurrent:  internal.NewBranchCurrentUseCase(resolver, branchFor),
 		BranchList:     internal.NewBranchListUseCase(resolver, branchFor),
 		BranchCreate:   internal.NewBranchCreateUseCase(resolver, branchFor),
 		BranchSwitch:   internal.NewBranchSwitchUseCase(resolver, branchFor),
 		BranchDelete:   internal.NewBranchDeleteUseCase(resolver, branchFor),
+		BranchRename:   internal.NewBranchRenameUseCase(resolver, branchFor),
 		ProviderList:   internal.NewProviderListUseCase(resolver),
 		ProviderAdd:    internal.NewProviderAddUseCase(resolver),
 		ProviderRemove: internal.NewProviderRemoveUseCase(resolver),
 		ProviderSetDef: internal.NewProviderSetDefaultUseCase(resolver),
 		ProviderTest:   internal.NewProviderTestUseCase(resolver),
+		ProviderModels: internal.NewProviderModelsUseCase(resolver),
+		ProviderUsage:  internal.NewProviderUsageUseCase(resolver),
 		InstallHook:    internal.NewInstallHookUseCase(resolver),
 		UninstallHook:  internal.NewUninstallHookUseCase(resolver),
-		RunHook:        internal.NewRunHookUseCase(resolver, nil, hookStoreFn, hookReindexFn),
+		RunHook:        internal.NewRunHookUseCase(resolver, providers.For, hookStoreFn, hookReindexFn),
+		Template:       internal.NewTemplateUseCase(resolver),
+		Alias:          internal.NewAliasUseCase(resolver),
+		Dedupe:         internal.NewDedupeUseCase(resolver, repoFor, histFor, indexFor, lazyEmbedder),
+		Compact:        internal.NewCompactUseCase(resolver, repoFor, histFor, indexFor, lazyEmbedder, providers.For),
+		Stats:          internal.NewStatsUseCase(resolver, repoFor, histFor, branchFor),
+		Trash:          internal.NewTrashUseCase(resolver, repoFor, indexFor, lazyEmbedder),
+		Touch:          internal.NewTouchUseCase(resolver, repoFor, histFor),
+		Attachment:     internal.NewAttachmentUseCase(resolver, repoFor, blobsFor),
+		Snapshot:       internal.NewSnapshotUseCase(resolver, snapFor, branchFor),
 	}
 
 	return &app{
 		resolver: resolver,
 		uc:       uc,
+		logger:   logger,
+	}
+}
+
+// loadEmbedder downloads the embedding model if needed and loads it. It
+// returns nil, after logging why, when that fails. Tests replace it.
+var loadEmbedder = func(resolver *internal.ScopeResolver, logger *slog.Logger, debug bool) internal.Embedder {
+	cacheDir, err := internal.DefaultCacheDir()
+	if err != nil {
+		logger.Warn("failed to get cache dir for embedder", "error", err)
+		return nil
+	}
+
+	// Load config from resolved scope for model URL and token
+	modelURL, modelFilename, token, concurrency := embeddingsFromConfig(resolver)
+
+	dl := internal.NewDownloader(cacheDir, token)
+	modelPath, err := dl.EnsureModel(context.Background(),
+		modelURL, modelFilename, nil)
+	if err != nil {
+		logger.Warn("failed to download embedding model", "error", err)
+		return nil
+	}
+
+	var embedOpts []internal.EmbedderOption
+	if debug {
+		embedOpts = append(embedOpts, internal.WithDebug())
+	}
+	e, err := internal.NewLocalEmbedderPool(modelPath, 0, concurrency, embedOpts...)
+	if err != nil {
+		logger.Warn("failed to initialize embedder", "error", err)
+		return nil
 	}
+
+	logger.Debug("embedder initialized", "model", modelPath, "device", e.Device(), "dimension", e.Dimension(), "concurrency", concurrency)
+	return e
 }
 
-func embeddingsFromConfig(resolver *internal.ScopeResolver) (modelURL, modelFilename, token string) {
+func embeddingsFromConfig(resolver *internal.ScopeResolver) (modelURL, modelFilename, token string, concurrency int) {
 	modelURL = internal.DefaultModelURL
 	modelFilename = internal.DefaultModelFilename
 
@@ -197,5 +301,23 @@ func embeddingsFromConfig(resolver *internal.ScopeResolver) (modelURL, modelFile
 		modelFilename = cfg.Embeddings.Model
 	}
 	token = cfg.Embeddings.Token
+	concurrency = cfg.Embeddings.Concurrency
 	return
 }
+
+// loadIndex loads idx from dir. An index built with a different embedding
+// dimension is left unloaded and marked stale, so searches ask for a
+// rebuild instead of failing on mismatched vectors.
+func loadIndex(ctx context.Context, logger *slog.Logger, idx *internal.AnnoyIndex, dir string) {
+	err := idx.Load(ctx)
+	if errors.Is(err, internal.ErrIndexDimension) {
+		logger.Warn("embedding model changed; ignoring the search index until you run `mem index rebuild`", "error", err)
+		if err := internal.MarkIndexStale(dir); err != nil {
+			logger.Warn("failed to mark index stale", "error", err)
+		}
+		return
+	}
+	if err != nil {
+		logger.Warn("failed to load index", "error", err)
+	}
+}
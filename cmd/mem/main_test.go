@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/4thel00z/memories/pkg/v1/memtest"
+)
+
+func TestNewAppLoadsEmbedderLazily(t *testing.T) {
+	dir := memtest.InitStore(t)
+	t.Chdir(dir)
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CACHE_HOME", "")
+
+	loads := 0
+	origLoad := loadEmbedder
+	loadEmbedder = func(*internal.ScopeResolver, *slog.Logger, bool) internal.Embedder {
+		loads++
+		return nil
+	}
+	origLogger := slog.Default()
+	t.Cleanup(func() {
+		loadEmbedder = origLoad
+		slog.SetDefault(origLogger)
+	})
+
+	repo, err := internal.NewGitRepository(internal.Scope{
+		Type:    internal.ScopeProject,
+		Path:    dir,
+		MemPath: filepath.Join(dir, ".mem"),
+	})
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+	key, _ := internal.NewKey("notes/lazy")
+	if err := repo.Save(context.Background(), internal.NewMemory(key, []byte("no model needed"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	a := newApp(false, true)
+	run := func(args ...string) {
+		t.Helper()
+		root := NewRootCmd("test", a)
+		root.SetArgs(args)
+		root.SetOut(&bytes.Buffer{})
+		root.SetErr(&bytes.Buffer{})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+
+	run("get", "notes/lazy")
+	run("list")
+	if loads != 0 {
+		t.Fatalf("get and list loaded the embedder %d times", loads)
+	}
+
+	run("set", "notes/other", "embedded on set")
+	run("set", "notes/third", "loaded once")
+	if loads != 1 {
+		t.Errorf("set loaded the embedder %d times, want 1", loads)
+	}
+}
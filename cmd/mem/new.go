@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewNewCmd(
+	templateUC *internal.TemplateUseCase,
+	getUC *internal.GetMemoryUseCase,
+	setUC *internal.SetMemoryUseCase,
+	commitUC *internal.CommitUseCase,
+	aliasUC *internal.AliasUseCase,
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "new --template <name> <key>",
+		Short: "Create a memory from a template",
+		Long:  `Render a template, open it in $EDITOR, and commit the result as a new memory.`,
+		Args:  cobra.ExactArgs(1),
+		RunE:  makeNewRunner(templateUC, getUC, setUC, commitUC, aliasUC),
+	}
+
+	cmd.Flags().StringP("template", "t", "", "Template name")
+	cmd.Flags().StringArray("var", nil, "Template variable as key=value (repeatable)")
+	cmd.Flags().StringP("message", "m", "", "Commit message")
+	_ = cmd.MarkFlagRequired("template")
+	return cmd
+}
+
+func makeNewRunner(
+	templateUC *internal.TemplateUseCase,
+	getUC *internal.GetMemoryUseCase,
+	setUC *internal.SetMemoryUseCase,
+	commitUC *internal.CommitUseCase,
+	aliasUC *internal.AliasUseCase,
+) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		key, err := resolveKeyArg(cmd, aliasUC, args[0])
+		if err != nil {
+			return err
+		}
+		scopeHint, _ := cmd.Flags().GetString("scope")
+		message, _ := cmd.Flags().GetString("message")
+		name, _ := cmd.Flags().GetString("template")
+		rawVars, _ := cmd.Flags().GetStringArray("var")
+
+		vars, err := parseTemplateVars(rawVars)
+		if err != nil {
+			return err
+		}
+
+		if _, err := getUC.Execute(cmd.Context(), internal.GetMemoryInput{
+			Key: key, Scope: scopeHint,
+		}); err == nil {
+			return fmt.Errorf("memory %s already exists (use 'mem edit')", key)
+		}
+
+		rendered, err := templateUC.Render(cmd.Context(), internal.RenderTemplateInput{
+			Name: name, Key: key, Author: templateAuthor(), Vars: vars, Scope: scopeHint,
+		})
+		if err != nil {
+			return err
+		}
+
+		content, err := editInEditor(cmd, rendered)
+		if err != nil {
+			return err
+		}
+
+		if err := setUC.Execute(cmd.Context(), internal.SetMemoryInput{
+			Key: key, Content: content, Scope: scopeHint,
+		}); err != nil {
+			return fmt.Errorf("save memory: %w", err)
+		}
+
+		if _, err := autoCommit(cmd.Context(), commitUC, message, "new", key, scopeHint); err != nil {
+			return fmt.Errorf("commit: %w", err)
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Created %s from template %s\n", key, name)
+		return nil
+	}
+}
+
+func parseTemplateVars(raw []string) (map[string]string, error) {
+	vars := make(map[string]string, len(raw))
+	for _, kv := range raw {
+		k, v, ok := strings.Cut(kv, "=")
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid --var %q (expected key=value)", kv)
+		}
+		vars[k] = v
+	}
+	return vars, nil
+}
+
+// templateAuthor prefers the git identity and falls back to $USER.
+func templateAuthor() string {
+	if name, err := gitOutput("config", "user.name"); err == nil {
+		if name = strings.TrimSpace(name); name != "" {
+			return name
+		}
+	}
+	return os.Getenv("USER")
+}
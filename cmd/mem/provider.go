@@ -1,7 +1,13 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
 	"github.com/4thel00z/memories/internal"
 	"github.com/spf13/cobra"
@@ -13,19 +19,24 @@ func NewProviderCmd(
 	removeUC *internal.ProviderRemoveUseCase,
 	setDefUC *internal.ProviderSetDefaultUseCase,
 	testUC *internal.ProviderTestUseCase,
+	modelsUC *internal.ProviderModelsUseCase,
+	usageUC *internal.ProviderUsageUseCase,
 ) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "provider",
 		Short: "Manage LLM providers",
-		Long:  `List, add, remove, and test LLM providers.`,
+		Long:  `List, add, remove, and test LLM providers, list the models they offer and report what they cost.`,
 	}
 
 	cmd.AddCommand(
 		newProviderListCmd(listUC),
+		newProviderTypesCmd(),
 		newProviderAddCmd(addUC),
 		newProviderRemoveCmd(removeUC),
 		newProviderDefaultCmd(setDefUC),
 		newProviderTestCmd(testUC),
+		newProviderModelsCmd(modelsUC),
+		newProviderUsageCmd(usageUC),
 	)
 
 	return cmd
@@ -55,26 +66,54 @@ func newProviderListCmd(listUC *internal.ProviderListUseCase) *cobra.Command {
 	}
 }
 
+func newProviderTypesCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "types",
+		Short: "List supported provider types",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			for _, t := range internal.ProviderTypes() {
+				fmt.Fprintln(cmd.OutOrStdout(), t)
+			}
+			return nil
+		},
+	}
+}
+
 func newProviderAddCmd(addUC *internal.ProviderAddUseCase) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "add <name>",
 		Short: "Add a provider",
-		Args:  cobra.ExactArgs(1),
+		Long: `Add a provider under name. --type picks the implementation (see
+"mem provider types") and defaults to the name, so "mem provider add openai"
+needs no --type while "mem provider add local --type openai --base-url ..."
+adds a second OpenAI-compatible endpoint.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := args[0]
 			scopeHint, _ := cmd.Flags().GetString("scope")
+			providerType, _ := cmd.Flags().GetString("type")
 			apiKey, _ := cmd.Flags().GetString("api-key")
 			baseURL, _ := cmd.Flags().GetString("base-url")
 			model, _ := cmd.Flags().GetString("model")
+			maxTokens, _ := cmd.Flags().GetInt("max-tokens")
+
+			config := internal.ProviderConfig{
+				Type:      providerType,
+				APIKey:    apiKey,
+				BaseURL:   baseURL,
+				Model:     model,
+				MaxTokens: maxTokens,
+			}
+			if cmd.Flags().Changed("temperature") {
+				temperature, _ := cmd.Flags().GetFloat64("temperature")
+				config.Temperature = &temperature
+			}
 
 			if err := addUC.Execute(internal.ProviderInput{
-				Name:  name,
-				Scope: scopeHint,
-				Config: internal.ProviderConfig{
-					APIKey:  apiKey,
-					BaseURL: baseURL,
-					Model:   model,
-				},
+				Name:   name,
+				Scope:  scopeHint,
+				Config: config,
 			}); err != nil {
 				return fmt.Errorf("add provider: %w", err)
 			}
@@ -84,9 +123,12 @@ func newProviderAddCmd(addUC *internal.ProviderAddUseCase) *cobra.Command {
 		},
 	}
 
+	cmd.Flags().String("type", "", "Provider type (default: the name)")
 	cmd.Flags().String("api-key", "", "API key")
 	cmd.Flags().String("base-url", "", "Base URL")
 	cmd.Flags().String("model", "", "Model name")
+	cmd.Flags().Float64("temperature", 0, "Sampling temperature, e.g. 0 for reproducible summaries (default: provider default)")
+	cmd.Flags().Int("max-tokens", 0, "Maximum tokens per response (default: provider default)")
 	return cmd
 }
 
@@ -123,12 +165,29 @@ func newProviderDefaultCmd(setDefUC *internal.ProviderSetDefaultUseCase) *cobra.
 }
 
 func newProviderTestCmd(testUC *internal.ProviderTestUseCase) *cobra.Command {
-	return &cobra.Command{
+	cmd := &cobra.Command{
 		Use:   "test <name>",
 		Short: "Test provider connectivity",
-		Args:  cobra.ExactArgs(1),
+		Long: `Test a provider by asking it for a short completion.
+
+With --embeddings, request an embedding instead and check that its
+dimension matches embeddings.dimension, so a misconfigured embeddings
+endpoint shows up before a rebuild. The model is --model, defaulting to
+embeddings.model. Supported for openai and openrouter.`,
+		Args: cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			scopeHint, _ := cmd.Flags().GetString("scope")
+			if embeddings, _ := cmd.Flags().GetBool("embeddings"); embeddings {
+				model, _ := cmd.Flags().GetString("model")
+				input := internal.ProviderInput{Name: args[0], Scope: scopeHint, Config: internal.ProviderConfig{Model: model}}
+				out, err := testUC.Embeddings(cmd.Context(), input)
+				if err != nil {
+					return fmt.Errorf("test embeddings: %w", err)
+				}
+				fmt.Fprintf(cmd.OutOrStdout(), "Provider %s embeddings are working (model %s, dimension %d, %s)\n",
+					args[0], out.Model, out.Dimension, out.Latency.Round(time.Millisecond))
+				return nil
+			}
 			if err := testUC.Execute(cmd.Context(), internal.ProviderInput{Name: args[0], Scope: scopeHint}); err != nil {
 				return fmt.Errorf("test provider: %w", err)
 			}
@@ -136,4 +195,141 @@ func newProviderTestCmd(testUC *internal.ProviderTestUseCase) *cobra.Command {
 			return nil
 		},
 	}
+	cmd.Flags().Bool("embeddings", false, "Test the embeddings endpoint instead of chat")
+	cmd.Flags().String("model", "", "Embedding model for --embeddings (default embeddings.model)")
+	return cmd
+}
+
+func newProviderModelsCmd(modelsUC *internal.ProviderModelsUseCase) *cobra.Command {
+	return &cobra.Command{
+		Use:   "models <name>",
+		Short: "List models available from a provider",
+		Long: `List the model ids a provider accepts, using its configured API key and
+base URL. Supported for openai and openrouter.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			models, err := modelsUC.Execute(cmd.Context(), internal.ProviderInput{Name: args[0], Scope: scopeHint})
+			if errors.Is(err, internal.ErrModelsUnsupported) {
+				fmt.Fprintf(cmd.OutOrStdout(), "Provider %s cannot list its models; see its documentation for valid model names.\n", args[0])
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("list models: %w", err)
+			}
+
+			if len(models) == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "Provider %s returned no models.\n", args[0])
+				return nil
+			}
+			for _, m := range models {
+				fmt.Fprintln(cmd.OutOrStdout(), m)
+			}
+			return nil
+		},
+	}
+}
+
+func newProviderUsageCmd(usageUC *internal.ProviderUsageUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "usage",
+		Short: "Show calls, tokens and cost per provider and task",
+		Long: `Sum the provider calls recorded in .mem/usage.jsonl by provider, task and
+model. Tokens marked with ~ were estimated from character counts because the
+provider did not report them. Costs use the per-million-token prices under
+prices: in the config, e.g.
+
+  prices:
+    gpt-4o-mini: {input: 0.15, output: 0.6}`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			sinceFlag, _ := cmd.Flags().GetString("since")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			input := internal.ProviderUsageInput{Scope: scopeHint}
+			if sinceFlag != "" {
+				age, err := parseAge(sinceFlag)
+				if err != nil {
+					return fmt.Errorf("invalid --since: %w", err)
+				}
+				input.Since = time.Now().Add(-age)
+			}
+
+			out, err := usageUC.Execute(input)
+			if err != nil {
+				return fmt.Errorf("provider usage: %w", err)
+			}
+
+			if asJSON {
+				rows := make([]map[string]any, len(out.Rows))
+				for i, row := range out.Rows {
+					rows[i] = usageRowToJSON(row)
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(map[string]any{"rows": rows, "total": usageRowToJSON(out.Total)})
+			}
+
+			if len(out.Rows) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No provider calls recorded.")
+				return nil
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "PROVIDER\tTASK\tMODEL\tCALLS\tINPUT\tOUTPUT\tCOST")
+			for _, row := range out.Rows {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", row.Provider, row.Task, row.Model, formatUsage(row))
+			}
+			fmt.Fprintf(w, "total\t\t\t%s\n", formatUsage(out.Total))
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().String("since", "", "Only count calls in this window, e.g. 7d or 12h")
+	return cmd
+}
+
+// formatUsage renders the calls, tokens and cost columns of a usage row.
+func formatUsage(row internal.UsageRow) string {
+	approx := ""
+	if row.Estimated > 0 {
+		approx = "~"
+	}
+	cost := "-"
+	if row.Priced {
+		cost = fmt.Sprintf("$%.4f", row.Cost)
+	} else if row.Cost > 0 {
+		cost = fmt.Sprintf("$%.4f+", row.Cost)
+	}
+	return fmt.Sprintf("%d\t%s%d\t%s%d\t%s", row.Calls, approx, row.InputTokens, approx, row.OutputTokens, cost)
+}
+
+func usageRowToJSON(row internal.UsageRow) map[string]any {
+	data := map[string]any{
+		"calls":         row.Calls,
+		"input_tokens":  row.InputTokens,
+		"output_tokens": row.OutputTokens,
+		"estimated":     row.Estimated,
+	}
+	if row.Provider != "" {
+		data["provider"] = row.Provider
+		data["model"] = row.Model
+		data["task"] = row.Task
+	}
+	if row.Priced {
+		data["cost"] = row.Cost
+	}
+	return data
+}
+
+// parseAge parses a duration such as 12h, or a number of days such as 7d.
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%q is not a number of days", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
 }
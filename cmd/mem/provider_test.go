@@ -2,12 +2,16 @@ package main
 
 import (
 	"bytes"
-	"os"
-	"path/filepath"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/4thel00z/memories/internal"
+	"github.com/4thel00z/memories/pkg/v1/memtest"
 )
 
 func setupProviderTest(t *testing.T) (
@@ -16,46 +20,46 @@ func setupProviderTest(t *testing.T) (
 	*internal.ProviderRemoveUseCase,
 	*internal.ProviderSetDefaultUseCase,
 	*internal.ProviderTestUseCase,
+	*internal.ProviderModelsUseCase,
 ) {
 	t.Helper()
-	tmpDir := t.TempDir()
-
-	origWd, _ := os.Getwd()
-	t.Cleanup(func() { _ = os.Chdir(origWd) })
-	if err := os.Chdir(tmpDir); err != nil {
-		t.Fatalf("chdir: %v", err)
-	}
-
-	scope := internal.Scope{
-		Type:    internal.ScopeProject,
-		Path:    tmpDir,
-		MemPath: filepath.Join(tmpDir, ".mem"),
-	}
+	return providerUseCases(setupProviderStore(t))
+}
 
-	if err := os.MkdirAll(scope.VectorPath(), 0755); err != nil {
-		t.Fatalf("mkdir vectors: %v", err)
-	}
-	if err := internal.InitRepository(scope); err != nil {
-		t.Fatalf("init repo: %v", err)
-	}
+// setupProviderStore creates a store with the default config and returns
+// a resolver whose project scope is that store.
+func setupProviderStore(t *testing.T) *internal.ScopeResolver {
+	t.Helper()
+	resolver := internal.NewScopeResolverAt(memtest.InitStore(t), "")
+	scope, _ := resolver.Project()
 
 	cfg := internal.DefaultConfig()
 	if err := internal.SaveConfig(scope, cfg); err != nil {
 		t.Fatalf("save config: %v", err)
 	}
+	return resolver
+}
 
-	resolver := internal.NewScopeResolver()
+func providerUseCases(resolver *internal.ScopeResolver) (
+	*internal.ProviderListUseCase,
+	*internal.ProviderAddUseCase,
+	*internal.ProviderRemoveUseCase,
+	*internal.ProviderSetDefaultUseCase,
+	*internal.ProviderTestUseCase,
+	*internal.ProviderModelsUseCase,
+) {
 	return internal.NewProviderListUseCase(resolver),
 		internal.NewProviderAddUseCase(resolver),
 		internal.NewProviderRemoveUseCase(resolver),
 		internal.NewProviderSetDefaultUseCase(resolver),
-		internal.NewProviderTestUseCase(resolver)
+		internal.NewProviderTestUseCase(resolver),
+		internal.NewProviderModelsUseCase(resolver)
 }
 
 func TestProviderListEmpty(t *testing.T) {
-	listUC, addUC, removeUC, setDefUC, testUC := setupProviderTest(t)
+	listUC, addUC, removeUC, setDefUC, testUC, modelsUC := setupProviderTest(t)
 
-	cmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC)
+	cmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
 	cmd.SetArgs([]string{"list"})
 
 	var out bytes.Buffer
@@ -71,10 +75,10 @@ func TestProviderListEmpty(t *testing.T) {
 }
 
 func TestProviderAddAndList(t *testing.T) {
-	listUC, addUC, removeUC, setDefUC, testUC := setupProviderTest(t)
+	listUC, addUC, removeUC, setDefUC, testUC, modelsUC := setupProviderTest(t)
 
 	// Add a provider
-	addCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC)
+	addCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
 	addCmd.SetArgs([]string{"add", "openai", "--api-key", "sk-test", "--model", "gpt-4"})
 	var addOut bytes.Buffer
 	addCmd.SetOut(&addOut)
@@ -88,7 +92,7 @@ func TestProviderAddAndList(t *testing.T) {
 	}
 
 	// List should show it
-	listCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC)
+	listCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
 	listCmd.SetArgs([]string{"list"})
 	var listOut bytes.Buffer
 	listCmd.SetOut(&listOut)
@@ -103,18 +107,18 @@ func TestProviderAddAndList(t *testing.T) {
 }
 
 func TestProviderRemove(t *testing.T) {
-	listUC, addUC, removeUC, setDefUC, testUC := setupProviderTest(t)
+	listUC, addUC, removeUC, setDefUC, testUC, modelsUC := setupProviderTest(t)
 
 	// Add then remove
-	addCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC)
-	addCmd.SetArgs([]string{"add", "todelete", "--api-key", "x"})
+	addCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
+	addCmd.SetArgs([]string{"add", "todelete", "--type", "openai", "--api-key", "x"})
 	var buf bytes.Buffer
 	addCmd.SetOut(&buf)
 	if err := addCmd.Execute(); err != nil {
 		t.Fatalf("add: %v", err)
 	}
 
-	rmCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC)
+	rmCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
 	rmCmd.SetArgs([]string{"remove", "todelete"})
 	var rmOut bytes.Buffer
 	rmCmd.SetOut(&rmOut)
@@ -129,11 +133,11 @@ func TestProviderRemove(t *testing.T) {
 }
 
 func TestProviderSetDefault(t *testing.T) {
-	listUC, addUC, removeUC, setDefUC, testUC := setupProviderTest(t)
+	listUC, addUC, removeUC, setDefUC, testUC, modelsUC := setupProviderTest(t)
 
 	// Add a provider first
-	addCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC)
-	addCmd.SetArgs([]string{"add", "myp", "--api-key", "x"})
+	addCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
+	addCmd.SetArgs([]string{"add", "myp", "--type", "anthropic", "--api-key", "x"})
 	var buf bytes.Buffer
 	addCmd.SetOut(&buf)
 	if err := addCmd.Execute(); err != nil {
@@ -141,7 +145,7 @@ func TestProviderSetDefault(t *testing.T) {
 	}
 
 	// Set as default
-	defCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC)
+	defCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
 	defCmd.SetArgs([]string{"default", "myp"})
 	var defOut bytes.Buffer
 	defCmd.SetOut(&defOut)
@@ -156,9 +160,9 @@ func TestProviderSetDefault(t *testing.T) {
 }
 
 func TestProviderSetDefaultNonexistent(t *testing.T) {
-	listUC, addUC, removeUC, setDefUC, testUC := setupProviderTest(t)
+	listUC, addUC, removeUC, setDefUC, testUC, modelsUC := setupProviderTest(t)
 
-	cmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC)
+	cmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
 	cmd.SetArgs([]string{"default", "nonexistent"})
 	var out bytes.Buffer
 	cmd.SetOut(&out)
@@ -169,3 +173,261 @@ func TestProviderSetDefaultNonexistent(t *testing.T) {
 		t.Error("expected error for nonexistent provider")
 	}
 }
+
+func TestProviderAddSamplingFlags(t *testing.T) {
+	resolver := setupProviderStore(t)
+	listUC, addUC, removeUC, setDefUC, testUC, modelsUC := providerUseCases(resolver)
+
+	for _, args := range [][]string{
+		{"add", "openai", "--model", "gpt-4", "--temperature", "0", "--max-tokens", "256"},
+		{"add", "openrouter", "--model", "x"},
+	} {
+		cmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
+		cmd.SetArgs(args)
+		cmd.SetOut(&bytes.Buffer{})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+	}
+
+	cfg, err := internal.LoadConfig(resolver.Resolve(""))
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+
+	openai := cfg.Providers["openai"]
+	if openai.Temperature == nil || *openai.Temperature != 0 {
+		t.Errorf("openai temperature = %v, want explicit 0", openai.Temperature)
+	}
+	if openai.MaxTokens != 256 {
+		t.Errorf("openai max tokens = %d, want 256", openai.MaxTokens)
+	}
+
+	openrouter := cfg.Providers["openrouter"]
+	if openrouter.Temperature != nil || openrouter.MaxTokens != 0 {
+		t.Errorf("expected provider defaults for openrouter, got %+v", openrouter)
+	}
+}
+
+func TestProviderModels(t *testing.T) {
+	listUC, addUC, removeUC, setDefUC, testUC, modelsUC := setupProviderTest(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[{"id":"model-b"},{"id":"model-a"}]}`))
+	}))
+	defer srv.Close()
+
+	addCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
+	addCmd.SetArgs([]string{"add", "openai", "--api-key", "sk-test", "--base-url", srv.URL})
+	addCmd.SetOut(&bytes.Buffer{})
+	if err := addCmd.Execute(); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	cmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
+	cmd.SetArgs([]string{"models", "openai"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("models: %v", err)
+	}
+
+	if got := out.String(); got != "model-a\nmodel-b\n" {
+		t.Errorf("models output = %q", got)
+	}
+}
+
+func TestProviderModelsUnsupported(t *testing.T) {
+	listUC, addUC, removeUC, setDefUC, testUC, modelsUC := setupProviderTest(t)
+
+	addCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
+	addCmd.SetArgs([]string{"add", "anthropic", "--api-key", "x", "--model", "claude"})
+	addCmd.SetOut(&bytes.Buffer{})
+	if err := addCmd.Execute(); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	cmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
+	cmd.SetArgs([]string{"models", "anthropic"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("models: %v", err)
+	}
+	if !strings.Contains(out.String(), "cannot list its models") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
+
+func TestProviderAddValidatesType(t *testing.T) {
+	resolver := setupProviderStore(t)
+	listUC, addUC, removeUC, setDefUC, testUC, modelsUC := providerUseCases(resolver)
+
+	cmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
+	cmd.SetArgs([]string{"add", "foobar", "--api-key", "x"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); !errors.Is(err, internal.ErrUnsupportedProvider) {
+		t.Fatalf("expected ErrUnsupportedProvider, got %v", err)
+	}
+
+	cmd = NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
+	cmd.SetArgs([]string{"add", "local", "--type", "openai", "--base-url", "http://localhost:8080"})
+	cmd.SetOut(&bytes.Buffer{})
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("add typed: %v", err)
+	}
+
+	cfg, err := internal.LoadConfig(resolver.Resolve(""))
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if _, ok := cfg.Providers["foobar"]; ok {
+		t.Error("invalid provider should not be saved")
+	}
+	if got := cfg.Providers["local"].Type; got != "openai" {
+		t.Errorf("local type = %q, want openai", got)
+	}
+}
+
+func TestProviderTypes(t *testing.T) {
+	listUC, addUC, removeUC, setDefUC, testUC, modelsUC := setupProviderTest(t)
+
+	cmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
+	cmd.SetArgs([]string{"types"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("types: %v", err)
+	}
+	if got := out.String(); got != "anthropic\nopenai\nopenrouter\n" {
+		t.Errorf("types output = %q", got)
+	}
+}
+
+func TestProviderModelsUsesType(t *testing.T) {
+	listUC, addUC, removeUC, setDefUC, testUC, modelsUC := setupProviderTest(t)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[{"id":"local-model"}]}`))
+	}))
+	defer srv.Close()
+
+	addCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
+	addCmd.SetArgs([]string{"add", "local", "--type", "openai", "--base-url", srv.URL})
+	addCmd.SetOut(&bytes.Buffer{})
+	if err := addCmd.Execute(); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	cmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
+	cmd.SetArgs([]string{"models", "local"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("models: %v", err)
+	}
+	if got := out.String(); got != "local-model\n" {
+		t.Errorf("models output = %q", got)
+	}
+}
+
+func TestProviderTestEmbeddings(t *testing.T) {
+	resolver := setupProviderStore(t)
+	listUC, addUC, removeUC, setDefUC, testUC, modelsUC := providerUseCases(resolver)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			http.NotFound(w, r)
+			return
+		}
+		vec := make([]float32, 768)
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": []map[string]any{{"embedding": vec}}})
+	}))
+	defer srv.Close()
+
+	addCmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
+	addCmd.SetArgs([]string{"add", "local", "--type", "openai", "--base-url", srv.URL})
+	addCmd.SetOut(&bytes.Buffer{})
+	if err := addCmd.Execute(); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	cmd := NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
+	cmd.SetArgs([]string{"test", "local", "--embeddings", "--model", "embed-small"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("test --embeddings: %v", err)
+	}
+	if got := out.String(); !strings.Contains(got, "model embed-small, dimension 768") {
+		t.Errorf("output = %q", got)
+	}
+
+	scope, _ := resolver.Project()
+	cfg, err := internal.LoadConfig(scope)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.Embeddings.Dimension = 1536
+	if err := internal.SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	cmd = NewProviderCmd(listUC, addUC, removeUC, setDefUC, testUC, modelsUC, nil)
+	cmd.SetArgs([]string{"test", "local", "--embeddings"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); !errors.Is(err, internal.ErrIndexDimension) {
+		t.Errorf("dimension mismatch: want ErrIndexDimension, got %v", err)
+	}
+}
+
+func TestProviderUsage(t *testing.T) {
+	resolver := setupProviderStore(t)
+	scope := resolver.Resolve("")
+
+	cfg, err := internal.LoadConfig(scope)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.Prices = map[string]internal.ModelPrice{"gpt-4o-mini": {Input: 0.15, Output: 0.6}}
+	if err := internal.SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	for _, rec := range []internal.UsageRecord{
+		{Time: time.Now().Add(-10 * 24 * time.Hour), Provider: "openai", Model: "gpt-4o-mini", Task: "summarize", InputTokens: 1000},
+		{Time: time.Now(), Provider: "openai", Model: "gpt-4o-mini", Task: "hook_summarize", InputTokens: 1000, OutputTokens: 100},
+	} {
+		if err := internal.AppendUsage(scope.UsagePath(), rec); err != nil {
+			t.Fatalf("append usage: %v", err)
+		}
+	}
+
+	cmd := NewProviderCmd(nil, nil, nil, nil, nil, nil, internal.NewProviderUsageUseCase(resolver))
+	cmd.SetArgs([]string{"usage", "--since", "7d"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+
+	output := out.String()
+	if !strings.Contains(output, "hook_summarize") || strings.Contains(output, "\nopenai  summarize") {
+		t.Errorf("expected only the last week's calls, got:\n%s", output)
+	}
+	if !strings.Contains(output, "$0.0002") {
+		t.Errorf("expected cost 1000*0.15/1e6 + 100*0.6/1e6, got:\n%s", output)
+	}
+}
+
+func TestParseAge(t *testing.T) {
+	for in, want := range map[string]time.Duration{"7d": 7 * 24 * time.Hour, "12h": 12 * time.Hour, "0d": 0} {
+		if got, err := parseAge(in); err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	if _, err := parseAge("xd"); err == nil {
+		t.Error("expected error for xd")
+	}
+}
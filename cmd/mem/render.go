@@ -0,0 +1,163 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiDim     = "\x1b[2m"
+	ansiRed     = "\x1b[31m"
+	ansiGreen   = "\x1b[32m"
+	ansiYellow  = "\x1b[33m"
+	ansiMagenta = "\x1b[35m"
+	ansiCyan    = "\x1b[36m"
+)
+
+// isTerminal reports whether w is a terminal rather than a file, pipe or
+// buffer.
+func isTerminal(w any) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// colorEnabled reports whether output for cmd should carry ANSI colors:
+// only on a terminal, and never with --json, --no-color or NO_COLOR set.
+// Check it before startPager, which replaces the output with a pipe.
+func colorEnabled(cmd *cobra.Command) bool {
+	asJSON, _ := cmd.Flags().GetBool("json")
+	noColor, _ := cmd.Flags().GetBool("no-color")
+	if asJSON || noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	return outputIsTerminal(cmd.OutOrStdout())
+}
+
+// outputIsTerminal reports whether command output goes to a terminal.
+// Tests replace it.
+var outputIsTerminal = isTerminal
+
+// colorize wraps s in color when on is set.
+func colorize(on bool, color, s string) string {
+	if !on {
+		return s
+	}
+	return color + s + ansiReset
+}
+
+// highlight marks every case-insensitive match of query in s in bold red,
+// like grep --color.
+func highlight(on bool, s, query string) string {
+	if !on {
+		return s
+	}
+	var b strings.Builder
+	last := 0
+	for _, r := range internal.MatchRanges(s, query) {
+		b.WriteString(s[last:r[0]])
+		b.WriteString(ansiBold + ansiRed + s[r[0]:r[1]] + ansiReset)
+		last = r[1]
+	}
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+// colorDiff colors a unified diff the way git does: file headers bold,
+// hunk headers cyan, added lines green and removed lines red.
+func colorDiff(diff string) string {
+	lines := strings.SplitAfter(diff, "\n")
+	var b strings.Builder
+	for _, line := range lines {
+		text := strings.TrimSuffix(line, "\n")
+		var color string
+		switch {
+		case strings.HasPrefix(text, "diff "), strings.HasPrefix(text, "+++"), strings.HasPrefix(text, "---"):
+			color = ansiBold
+		case strings.HasPrefix(text, "@@"):
+			color = ansiCyan
+		case strings.HasPrefix(text, "+"):
+			color = ansiGreen
+		case strings.HasPrefix(text, "-"):
+			color = ansiRed
+		}
+		if color == "" || text == "" {
+			b.WriteString(line)
+			continue
+		}
+		b.WriteString(color + text + ansiReset + line[len(text):])
+	}
+	return b.String()
+}
+
+// defaultPager quits at once when the output fits on one screen, so short
+// output reads as if it was never paged.
+const defaultPager = "less -FRX"
+
+// pagerArgv returns the pager for cmd's output, or nil when it should not
+// be paged: with --no-pager or --json, when output is not a terminal, or
+// when the pager is empty or "cat". The config's pager wins over $PAGER.
+func pagerArgv(cmd *cobra.Command) []string {
+	noPager, _ := cmd.Flags().GetBool("no-pager")
+	asJSON, _ := cmd.Flags().GetBool("json")
+	if noPager || asJSON || !outputIsTerminal(cmd.OutOrStdout()) {
+		return nil
+	}
+
+	pager, ok := os.LookupEnv("PAGER")
+	if !ok {
+		pager = defaultPager
+	}
+	if cfg, err := loadScopeConfig(cmd); err == nil && cfg.Pager != "" {
+		pager = cfg.Pager
+	}
+	args, err := splitWords(pager)
+	if err != nil || len(args) == 0 || args[0] == "cat" {
+		return nil
+	}
+	return args
+}
+
+// startPager sends the output of cmd through the pager chosen by
+// pagerArgv, and returns a func that closes the pager and waits for the
+// user to quit it.
+func startPager(cmd *cobra.Command) (stop func()) {
+	stop = func() {}
+	out := cmd.OutOrStdout()
+	args := pagerArgv(cmd)
+	if args == nil {
+		return stop
+	}
+
+	c := exec.Command(args[0], args[1:]...)
+	c.Stdout = out
+	c.Stderr = cmd.ErrOrStderr()
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		// Like git: a bare "less" also quits when the output fits on one
+		// screen and keeps colors.
+		c.Env = append(os.Environ(), "LESS=FRX")
+	}
+	w, err := c.StdinPipe()
+	if err != nil {
+		return stop
+	}
+	if err := c.Start(); err != nil {
+		return stop
+	}
+
+	cmd.SetOut(w)
+	return func() {
+		_ = w.Close()
+		_ = c.Wait()
+		cmd.SetOut(out)
+	}
+}
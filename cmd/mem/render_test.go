@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func TestColorDiff(t *testing.T) {
+	diff := "diff --git a/k.md b/k.md\n--- a/k.md\n+++ b/k.md\n@@ -1 +1 @@\n-old\n+new\n same\n"
+
+	got := colorDiff(diff)
+
+	for _, want := range []string{
+		ansiBold + "--- a/k.md" + ansiReset + "\n",
+		ansiCyan + "@@ -1 +1 @@" + ansiReset + "\n",
+		ansiRed + "-old" + ansiReset + "\n",
+		ansiGreen + "+new" + ansiReset + "\n",
+		"\n same\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("colored diff missing %q:\n%q", want, got)
+		}
+	}
+}
+
+// Output to a buffer is never a terminal, so neither log, diff nor search
+// may color it or start a pager.
+func TestNoColorWhenNotTerminal(t *testing.T) {
+	t.Setenv("PAGER", "false")
+
+	repo, diffUC := setupDiffTest(t)
+	commitTwoKeysAndModify(t, repo)
+	_, logUC := setupLogTest(t)
+
+	for name, cmd := range map[string]func() (string, error){
+		"diff": func() (string, error) {
+			c := NewDiffCmd(diffUC)
+			var out bytes.Buffer
+			c.SetOut(&out)
+			err := c.Execute()
+			return out.String(), err
+		},
+		"log": func() (string, error) {
+			c := NewLogCmd(logUC)
+			var out bytes.Buffer
+			c.SetOut(&out)
+			err := c.Execute()
+			return out.String(), err
+		},
+		"search": func() (string, error) {
+			a, _ := setupE2E(t)
+			root := NewRootCmd("test", a)
+			root.SetArgs([]string{"set", "notes/hay", "the needle"})
+			root.SetOut(&bytes.Buffer{})
+			if err := root.Execute(); err != nil {
+				return "", err
+			}
+			root = NewRootCmd("test", a)
+			root.SetArgs([]string{"search", "needle"})
+			var out bytes.Buffer
+			root.SetOut(&out)
+			err := root.Execute()
+			return out.String(), err
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			output, err := cmd()
+			if err != nil {
+				t.Fatalf("execute: %v", err)
+			}
+			if output == "" {
+				t.Fatal("expected output")
+			}
+			if strings.Contains(output, "\x1b[") {
+				t.Errorf("expected no color codes, got %q", output)
+			}
+		})
+	}
+}
+
+func stubOutputTerminal(t *testing.T, terminal bool) {
+	t.Helper()
+	orig := outputIsTerminal
+	outputIsTerminal = func(any) bool { return terminal }
+	t.Cleanup(func() { outputIsTerminal = orig })
+}
+
+func TestPagerArgv(t *testing.T) {
+	a, _ := setupE2E(t)
+	stubOutputTerminal(t, true)
+
+	argv := func(pager *string, args ...string) []string {
+		t.Helper()
+		if pager == nil {
+			t.Setenv("PAGER", "")
+			os.Unsetenv("PAGER")
+		} else {
+			t.Setenv("PAGER", *pager)
+		}
+		cmd := &cobra.Command{Use: "get"}
+		cmd.SetContext(withResolver(context.Background(), a.resolver))
+		addPersistentFlags(cmd)
+		if err := cmd.ParseFlags(args); err != nil {
+			t.Fatalf("parse flags: %v", err)
+		}
+		return pagerArgv(cmd)
+	}
+	str := func(s string) *string { return &s }
+
+	if got := argv(nil); !slices.Equal(got, []string{"less", "-FRX"}) {
+		t.Errorf("default pager = %q, want less -FRX", got)
+	}
+	if got := argv(str("most -s")); !slices.Equal(got, []string{"most", "-s"}) {
+		t.Errorf("$PAGER = %q, want most -s", got)
+	}
+	for _, pager := range []string{"", "cat"} {
+		if got := argv(str(pager)); got != nil {
+			t.Errorf("PAGER=%q should disable paging, got %q", pager, got)
+		}
+	}
+	for _, flag := range []string{"--no-pager", "--json"} {
+		if got := argv(nil, flag); got != nil {
+			t.Errorf("%s should disable paging, got %q", flag, got)
+		}
+	}
+
+	scope, _ := a.resolver.Project()
+	cfg := internal.DefaultConfig()
+	cfg.Pager = "bat --paging=always"
+	if err := internal.SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	if got := argv(str("most")); !slices.Equal(got, []string{"bat", "--paging=always"}) {
+		t.Errorf("config pager should win over $PAGER, got %q", got)
+	}
+
+	stubOutputTerminal(t, false)
+	if got := argv(nil); got != nil {
+		t.Errorf("redirected output should not be paged, got %q", got)
+	}
+}
+
+// PAGER=false would swallow the output, so getting all of it shows that get
+// wrote straight to the non-terminal output.
+func TestGetNotPagedWhenNotTerminal(t *testing.T) {
+	t.Setenv("PAGER", "false")
+	a, _ := setupE2E(t)
+
+	root := NewRootCmd("test", a)
+	root.SetArgs([]string{"set", "notes/long", strings.Repeat("line\n", 500)})
+	root.SetOut(&bytes.Buffer{})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	root = NewRootCmd("test", a)
+	root.SetArgs([]string{"get", "notes/long"})
+	var out bytes.Buffer
+	root.SetOut(&out)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got := strings.Count(out.String(), "line\n"); got != 500 {
+		t.Errorf("got %d lines, want 500", got)
+	}
+}
+
+func TestSearchAndLogColorOnTerminal(t *testing.T) {
+	t.Setenv("PAGER", "cat")
+	t.Setenv("NO_COLOR", "")
+	a, _ := setupE2E(t)
+	stubOutputTerminal(t, true)
+
+	run := func(args ...string) string {
+		t.Helper()
+		root := NewRootCmd("test", a)
+		root.SetArgs(args)
+		var out bytes.Buffer
+		root.SetOut(&out)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		return out.String()
+	}
+	run("set", "notes/hay", "some hay\nthe Needle is here\n")
+
+	if out := run("search", "needle"); !strings.Contains(out, ansiBold+ansiRed+"Needle"+ansiReset) {
+		t.Errorf("search should highlight the match, got %q", out)
+	}
+	if out := run("log"); !strings.Contains(out, ansiDim+"Date:") {
+		t.Errorf("log should dim dates, got %q", out)
+	}
+
+	for _, args := range [][]string{
+		{"search", "needle", "--json"},
+		{"search", "needle", "--no-color"},
+		{"log", "--json"},
+	} {
+		if out := run(args...); strings.Contains(out, "\x1b[") {
+			t.Errorf("%v: expected no color codes, got %q", args, out)
+		}
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if out := run("search", "needle"); strings.Contains(out, "\x1b[") {
+		t.Errorf("NO_COLOR: expected no color codes, got %q", out)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewRevertCmd(revertUC *internal.RevertUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "revert <ref>",
+		Short: "Reset the store to a previous commit",
+		Long:  `Hard-reset the memory store to the given ref, discarding later commits and uncommitted changes.`,
+		Args:  cobra.ExactArgs(1),
+		RunE:  makeRevertRunner(revertUC),
+	}
+
+	cmd.Flags().Bool("dry-run", false, "Show which commits would be discarded without resetting")
+	return cmd
+}
+
+func makeRevertRunner(revertUC *internal.RevertUseCase) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		scopeHint, _ := cmd.Flags().GetString("scope")
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		out, err := revertUC.Execute(cmd.Context(), internal.RevertInput{
+			Ref: args[0], Scope: scopeHint, DryRun: dryRun,
+		})
+		if err != nil {
+			return fmt.Errorf("revert: %w", err)
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(map[string]any{
+				"target":    out.Target.Hash,
+				"discarded": commitsToJSON(out.Discarded),
+				"dry_run":   out.DryRun,
+			})
+		}
+
+		verb := "Reset"
+		if dryRun {
+			verb = "Would reset"
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s to %s %s\n", verb, out.Target.Hash[:7], out.Target.Message)
+		for _, c := range out.Discarded {
+			fmt.Fprintf(cmd.OutOrStdout(), "  discard %s %s\n", c.Hash[:7], c.Message)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/4thel00z/memories/internal"
+)
+
+func TestRevertCmdDryRun(t *testing.T) {
+	a, repo := setupE2E(t)
+
+	log, err := repo.Log(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	initHash := log[0].Hash
+
+	root := NewRootCmd("test", a)
+	root.SetArgs([]string{"set", "later", "value"})
+	root.SetOut(&bytes.Buffer{})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	root = NewRootCmd("test", a)
+	root.SetArgs([]string{"revert", initHash, "--dry-run"})
+	var out bytes.Buffer
+	root.SetOut(&out)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("revert --dry-run: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "Would reset to "+initHash[:7]) {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+	if !strings.Contains(out.String(), "set: later") {
+		t.Errorf("expected discarded commit in plan, got %q", out.String())
+	}
+
+	exists, _ := repo.Exists(context.Background(), internal.Key("later"))
+	if !exists {
+		t.Error("dry run must not reset the store")
+	}
+
+	root = NewRootCmd("test", a)
+	root.SetArgs([]string{"revert", initHash})
+	root.SetOut(&bytes.Buffer{})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("revert: %v", err)
+	}
+
+	exists, _ = repo.Exists(context.Background(), internal.Key("later"))
+	if exists {
+		t.Error("revert should remove memories added after the target")
+	}
+}
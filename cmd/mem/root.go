@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strings"
 
+	"github.com/4thel00z/memories/internal"
 	"github.com/spf13/cobra"
 )
 
@@ -23,6 +28,15 @@ func NewRootCmd(version string, a *app) *cobra.Command {
 	setHelpWithExternals(rootCmd)
 
 	if a != nil {
+		validateScope := validateScopeFlag(a.resolver)
+		rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+			cmd.SetContext(withResolver(cmd.Context(), a.resolver))
+			cmd.SetContext(internal.WithIndexSkips(cmd.Context(), &internal.IndexSkips{}))
+			applyReadOnlyFlag(cmd)
+			applyDepthFlag(cmd, a.resolver)
+			return validateScope(cmd, args)
+		}
+		rootCmd.PersistentPostRun = reportIndexSkips
 		addSubcommands(rootCmd, a)
 	}
 
@@ -34,33 +48,155 @@ func addPersistentFlags(cmd *cobra.Command) {
 	cmd.PersistentFlags().String("branch", "", "Target branch")
 	cmd.PersistentFlags().Bool("json", false, "Output in JSON format")
 	cmd.PersistentFlags().Bool("debug", false, "Enable verbose output (e.g. model loading logs)")
+	cmd.PersistentFlags().BoolP("quiet", "q", false, "Suppress warnings")
+	cmd.PersistentFlags().Int("depth", 0, "Search at most this many parent directories for a project store (0: up to the root; also MEM_MAX_DEPTH)")
+	cmd.PersistentFlags().Bool("readonly", false, "Refuse any command that would change the store")
+	cmd.PersistentFlags().BoolP("yes", "y", false, "Never prompt or open an editor; fail instead (implied when stdin is not a terminal)")
+	cmd.PersistentFlags().Bool("non-interactive", false, "Same as --yes")
+	cmd.PersistentFlags().Bool("no-color", false, "Disable colored output (also NO_COLOR)")
+	cmd.PersistentFlags().Bool("no-pager", false, "Never page long output")
+}
+
+// errNonInteractive is returned instead of opening an editor or prompting
+// when nobody is there to answer.
+var errNonInteractive = errors.New("non-interactive mode")
+
+// stdinIsTerminal reports whether stdin is a terminal. Tests replace it.
+var stdinIsTerminal = func() bool { return isTerminal(os.Stdin) }
+
+// requireInteractive fails with errNonInteractive when --yes or
+// --non-interactive is set or stdin is not a terminal, so scripts and CI
+// get an error instead of an editor that never exits. what says what was
+// missing, e.g. "message required".
+func requireInteractive(cmd *cobra.Command, what string) error {
+	yes, _ := cmd.Flags().GetBool("yes")
+	nonInteractive, _ := cmd.Flags().GetBool("non-interactive")
+	if yes || nonInteractive || !stdinIsTerminal() {
+		return fmt.Errorf("%s in %w", what, errNonInteractive)
+	}
+	return nil
+}
+
+type resolverKey struct{}
+
+// withResolver makes resolverFrom return r for commands run with ctx, so
+// helpers that only get the command resolve scopes like the use cases do.
+func withResolver(ctx context.Context, r *internal.ScopeResolver) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, resolverKey{}, r)
+}
+
+// resolverFrom returns the app's resolver, or one for the working
+// directory when the command runs outside the root command.
+func resolverFrom(cmd *cobra.Command) *internal.ScopeResolver {
+	if ctx := cmd.Context(); ctx != nil {
+		if r, ok := ctx.Value(resolverKey{}).(*internal.ScopeResolver); ok {
+			return r
+		}
+	}
+	return internal.NewScopeResolver()
+}
+
+// loadScopeConfig loads the config of the store --scope points at.
+func loadScopeConfig(cmd *cobra.Command) (*internal.Config, error) {
+	scopeHint, _ := cmd.Flags().GetString("scope")
+	return internal.LoadConfig(resolverFrom(cmd).Resolve(scopeHint))
+}
+
+// applyReadOnlyFlag marks the command's context read-only so the use cases
+// reject writes; reads are unaffected.
+func applyReadOnlyFlag(cmd *cobra.Command) {
+	if readOnly, _ := cmd.Flags().GetBool("readonly"); readOnly {
+		cmd.SetContext(internal.WithReadOnly(cmd.Context()))
+	}
+}
+
+// applyDepthFlag limits the project store lookup to --depth parent
+// directories, overriding $MEM_MAX_DEPTH and the config.
+func applyDepthFlag(cmd *cobra.Command, resolver *internal.ScopeResolver) {
+	if cmd.Flags().Changed("depth") {
+		depth, _ := cmd.Flags().GetInt("depth")
+		resolver.SetMaxDepth(depth)
+	}
+}
+
+// reportIndexSkips prints one notice for the index updates the command
+// skipped, so that semantic search does not silently miss memories.
+func reportIndexSkips(cmd *cobra.Command, _ []string) {
+	skips := internal.IndexSkipsFrom(cmd.Context())
+	n := len(skips.Keys())
+	if quiet, _ := cmd.Flags().GetBool("quiet"); n == 0 || quiet {
+		return
+	}
+	what := "1 memory"
+	if n > 1 {
+		what = fmt.Sprintf("%d memories", n)
+	}
+	fmt.Fprintf(cmd.ErrOrStderr(), "note: semantic index not updated for %s (%s); run mem index rebuild later\n",
+		what, strings.Join(skips.Reasons(), ", "))
+}
+
+// validateScopeFlag rejects an unknown --scope before any use case runs,
+// since use cases fall back to the default scope rather than fail.
+func validateScopeFlag(resolver *internal.ScopeResolver) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, _ []string) error {
+		hint, _ := cmd.Flags().GetString("scope")
+		if hint == "" {
+			return nil
+		}
+		_, err := resolver.Lookup(hint)
+		return err
+	}
 }
 
 func addSubcommands(root *cobra.Command, a *app) {
 	uc := a.uc
 	root.AddCommand(
-		NewInitCmd(),
-		NewSetCmd(uc.SetMemory, uc.Commit),
+		NewInitCmd(a.resolver),
+		NewSetCmd(uc.GetMemory, uc.SetMemory, uc.BulkSet, uc.Commit, uc.Alias),
 		NewGetCmd(uc.GetMemory),
-		NewDelCmd(uc.DeleteMemory, uc.Commit),
+		NewDelCmd(uc.DeleteMemory, uc.Attachment, uc.Commit, uc.Alias),
+		NewTrashCmd(uc.Trash, uc.Commit),
+		NewRestoreTrashCmd(uc.Trash, uc.Commit, uc.Alias),
+		NewAttachCmd(uc.Attachment, uc.Commit, uc.Alias),
 		NewListCmd(uc.ListMemories),
-		NewAddCmd(uc.AddMemory),
+		NewAddCmd(uc.AddMemory, uc.Alias),
+		NewTouchCmd(uc.Touch, uc.Alias),
 		NewCommitCmd(uc.Commit),
 		NewStatusCmd(uc.BranchCurrent),
 		NewLogCmd(uc.Log),
 		NewDiffCmd(uc.Diff),
-		NewBranchCmd(uc.BranchCurrent, uc.BranchList, uc.BranchCreate, uc.BranchSwitch, uc.BranchDelete),
+		NewRevertCmd(uc.Revert),
+		NewSnapshotCmd(uc.Snapshot, uc.Commit),
+		NewBranchCmd(uc.BranchCurrent, uc.BranchList, uc.BranchCreate, uc.BranchSwitch, uc.BranchDelete, uc.BranchRename),
 		NewSearchCmd(uc.KeywordSearch, uc.SemanticSearch),
-		NewProviderCmd(uc.ProviderList, uc.ProviderAdd, uc.ProviderRemove, uc.ProviderSetDef, uc.ProviderTest),
-		NewIndexCmd(uc.RebuildIndex),
-		NewSummarizeCmd(uc.Summarize),
-		NewEditCmd(uc.GetMemory, uc.SetMemory, uc.Commit),
-		NewWatchCmd(uc.Commit),
+		NewProviderCmd(uc.ProviderList, uc.ProviderAdd, uc.ProviderRemove, uc.ProviderSetDef, uc.ProviderTest, uc.ProviderModels, uc.ProviderUsage),
+		NewIndexCmd(uc.RebuildIndex, uc.EmbedderInfo, uc.SyncIndex, uc.IndexVector),
+		NewVerifyCmd(uc.Verify),
+		NewDoctorCmd(uc.Doctor),
+		NewMigrateKeysCmd(uc.MigrateKeys),
+		NewEmbedderCmd(uc.EmbedderInfo),
+		NewWarmupCmd(uc.Warmup),
+		NewSummarizeCmd(uc.Summarize, uc.GetMemory, uc.SetMemory, uc.Commit),
+		NewAutoTagCmd(uc.AutoTag, uc.Alias),
+		NewAskCmd(uc.Ask),
+		NewEditCmd(uc.GetMemory, uc.SetMemory, uc.AddMemory, uc.Commit, uc.Alias),
+		NewTemplateCmd(uc.Template),
+		NewNewCmd(uc.Template, uc.GetMemory, uc.SetMemory, uc.Commit, uc.Alias),
+		NewAliasCmd(uc.Alias),
+		NewDedupeCmd(uc.Dedupe),
+		NewCompactCmd(uc.Compact),
+		NewStatsCmd(uc.Stats),
+		NewTuiCmd(uc.ListMemories, uc.GetMemory, uc.SetMemory, uc.DeleteMemory, uc.Commit, uc.KeywordSearch, uc.SemanticSearch),
+		NewWatchCmd(a.resolver, uc.Commit),
 		NewSkillCmd(),
 		NewInstallCmd(uc.InstallHook),
 		NewUninstallCmd(uc.UninstallHook),
 		NewHookCmd(uc.RunHook),
 	)
+	addCompletionInstall(root)
 }
 
 func setHelpWithExternals(cmd *cobra.Command) {
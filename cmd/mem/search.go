@@ -12,13 +12,24 @@ func NewSearchCmd(keywordUC *internal.KeywordSearchUseCase, semanticUC *internal
 	cmd := &cobra.Command{
 		Use:   "search <query>",
 		Short: "Search memories",
-		Long:  `Search memories by keyword or semantic similarity.`,
-		Args:  cobra.ExactArgs(1),
-		RunE:  makeSearchRunner(keywordUC, semanticUC),
+		Long: `Search memories by keyword or semantic similarity.
+
+Keyword search prints each matching key with the first line that matches,
+the match highlighted on a terminal. --in restricts either search to the
+keys under a prefix, e.g. --in notes/.
+
+--explain shows why a semantic result ranked where it did: the raw angular
+distance the index measured, the cosine similarity it stands for, and the
+scope whose index returned it. The score is 1 - distance/2.`,
+		Args: cobra.ExactArgs(1),
+		RunE: makeSearchRunner(keywordUC, semanticUC),
 	}
 
 	cmd.Flags().BoolP("semantic", "s", false, "Use semantic search")
 	cmd.Flags().IntP("number", "n", 10, "Maximum results")
+	cmd.Flags().String("in", "", "Only search keys under this prefix")
+	cmd.Flags().Bool("explain", false, "Show the distance, similarity and index behind each semantic result")
+	cmd.Flags().Int("search-k", 0, "Index nodes to inspect in semantic search; higher improves recall but is slower (default: embeddings.search_k or Annoy's default)")
 	return cmd
 }
 
@@ -27,20 +38,26 @@ func makeSearchRunner(keywordUC *internal.KeywordSearchUseCase, semanticUC *inte
 		query := args[0]
 		semantic, _ := cmd.Flags().GetBool("semantic")
 		limit, _ := cmd.Flags().GetInt("number")
+		searchK, _ := cmd.Flags().GetInt("search-k")
 		scopeHint, _ := cmd.Flags().GetString("scope")
 		asJSON, _ := cmd.Flags().GetBool("json")
+		prefix, _ := cmd.Flags().GetString("in")
+		explain, _ := cmd.Flags().GetBool("explain")
 
 		if semantic {
-			return runSemanticSearch(cmd, semanticUC, query, limit, scopeHint, asJSON)
+			return runSemanticSearch(cmd, semanticUC, internal.SearchInput{
+				Query: query, Limit: limit, Scope: scopeHint, SearchK: searchK, Prefix: prefix,
+			}, asJSON, explain)
+		}
+		if explain {
+			return fmt.Errorf("--explain only applies to semantic search (-s)")
 		}
-		return runKeywordSearch(cmd, keywordUC, query, scopeHint, asJSON)
+		return runKeywordSearch(cmd, keywordUC, internal.SearchInput{Query: query, Scope: scopeHint, Prefix: prefix}, asJSON)
 	}
 }
 
-func runKeywordSearch(cmd *cobra.Command, keywordUC *internal.KeywordSearchUseCase, query, scopeHint string, asJSON bool) error {
-	out, err := keywordUC.Execute(cmd.Context(), internal.SearchInput{
-		Query: query, Scope: scopeHint,
-	})
+func runKeywordSearch(cmd *cobra.Command, keywordUC *internal.KeywordSearchUseCase, input internal.SearchInput, asJSON bool) error {
+	out, err := keywordUC.Execute(cmd.Context(), input)
 	if err != nil {
 		return fmt.Errorf("keyword search: %w", err)
 	}
@@ -49,30 +66,61 @@ func runKeywordSearch(cmd *cobra.Command, keywordUC *internal.KeywordSearchUseCa
 		return outputSearchResultsJSON(cmd, out.Results)
 	}
 
+	color := colorEnabled(cmd)
 	for _, r := range out.Results {
-		fmt.Fprintln(cmd.OutOrStdout(), r.Key)
+		key := colorize(color, ansiMagenta, r.Key)
+		if r.Snippet == "" {
+			fmt.Fprintln(cmd.OutOrStdout(), key)
+			continue
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "%s  %s\n", key, highlight(color, r.Snippet, input.Query))
 	}
 	return nil
 }
 
-func runSemanticSearch(cmd *cobra.Command, semanticUC *internal.SemanticSearchUseCase, query string, limit int, scopeHint string, asJSON bool) error {
-	out, err := semanticUC.Execute(cmd.Context(), internal.SearchInput{
-		Query: query, Limit: limit, Scope: scopeHint,
-	})
+func runSemanticSearch(cmd *cobra.Command, semanticUC *internal.SemanticSearchUseCase, input internal.SearchInput, asJSON, explain bool) error {
+	out, err := semanticUC.Execute(cmd.Context(), input)
 	if err != nil {
 		return fmt.Errorf("semantic search: %w", err)
 	}
 
+	if asJSON && explain {
+		return outputExplainedResultsJSON(cmd, out.Results)
+	}
 	if asJSON {
 		return outputSearchResultsJSON(cmd, out.Results)
 	}
 
+	color := colorEnabled(cmd)
 	for _, r := range out.Results {
-		fmt.Fprintf(cmd.OutOrStdout(), "%.4f  %s\n", r.Score, r.Key)
+		fmt.Fprintf(cmd.OutOrStdout(), "%s  %s\n", colorize(color, ansiDim, fmt.Sprintf("%.4f", r.Score)), colorize(color, ansiMagenta, r.Key))
+		if explain {
+			fmt.Fprintln(cmd.OutOrStdout(), colorize(color, ansiDim,
+				fmt.Sprintf("        distance %.4f  similarity %.4f  index %s", r.Distance, r.Similarity, r.Index)))
+		}
 	}
 	return nil
 }
 
+// outputExplainedResultsJSON is the --json --explain shape of semantic
+// search: the usual key and score plus what they were derived from.
+func outputExplainedResultsJSON(cmd *cobra.Command, results []internal.SearchResultOutput) error {
+	out := make([]map[string]any, 0, len(results))
+	for _, r := range results {
+		out = append(out, map[string]any{
+			"key":        r.Key,
+			"score":      r.Score,
+			"distance":   r.Distance,
+			"similarity": r.Similarity,
+			"index":      r.Index,
+		})
+	}
+
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
 func outputSearchResultsJSON(cmd *cobra.Command, results []internal.SearchResultOutput) error {
 	out := make([]map[string]any, 0, len(results))
 	for _, r := range results {
@@ -3,6 +3,8 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
 	"strings"
@@ -83,6 +85,28 @@ func TestSearchCmdKeyword(t *testing.T) {
 	}
 }
 
+func TestSearchCmdKeywordIn(t *testing.T) {
+	keywordUC, semanticUC := setupSearchTest(t)
+
+	cmd := NewSearchCmd(keywordUC, semanticUC)
+	cmd.SetArgs([]string{"and", "--in", "notes/"})
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	output := out.String()
+	if !strings.Contains(output, "notes/meeting") {
+		t.Errorf("expected 'notes/meeting' in results, got %q", output)
+	}
+	if strings.Contains(output, "project/") {
+		t.Errorf("expected no results outside notes/, got %q", output)
+	}
+}
+
 func TestSearchCmdKeywordNoMatch(t *testing.T) {
 	keywordUC, semanticUC := setupSearchTest(t)
 
@@ -135,3 +159,130 @@ func TestSearchCmdSemanticNoEmbedder(t *testing.T) {
 		t.Error("expected error for semantic search without embedder")
 	}
 }
+
+func setupSemanticIndexTest(t *testing.T, seed bool) *internal.SemanticSearchUseCase {
+	t.Helper()
+	idx, err := internal.NewAnnoyIndex(t.TempDir(), 3)
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+	if seed {
+		key, _ := internal.NewKey("notes/unindexed")
+		if err := idx.Add(context.Background(), key, internal.Embedding{Vector: []float32{1, 0, 0}}); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+
+	indexFor := func(s internal.Scope) (internal.VectorIndex, error) { return idx, nil }
+	return internal.NewSemanticSearchUseCase(internal.NewScopeResolver(), indexFor, internal.StaticEmbedder(&stubEmbedder{dim: 3}))
+}
+
+func TestSearchCmdSemanticUnbuiltIndex(t *testing.T) {
+	keywordUC, _ := setupSearchTest(t)
+	semanticUC := setupSemanticIndexTest(t, true)
+
+	cmd := NewSearchCmd(keywordUC, semanticUC)
+	cmd.SetArgs([]string{"-s", "anything"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+
+	err := cmd.Execute()
+	if !errors.Is(err, internal.ErrIndexNotBuilt) {
+		t.Fatalf("expected ErrIndexNotBuilt, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "mem index rebuild") {
+		t.Errorf("expected rebuild hint, got %q", err.Error())
+	}
+}
+
+func TestSearchCmdSemanticEmptyIndex(t *testing.T) {
+	keywordUC, _ := setupSearchTest(t)
+	semanticUC := setupSemanticIndexTest(t, false)
+
+	cmd := NewSearchCmd(keywordUC, semanticUC)
+	cmd.SetArgs([]string{"-s", "anything"})
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if strings.TrimSpace(out.String()) != "" {
+		t.Errorf("expected empty output for empty index, got %q", out.String())
+	}
+}
+
+// axisEmbedder embeds every text as the first unit vector.
+type axisEmbedder struct{ stubEmbedder }
+
+func (axisEmbedder) Embed(context.Context, string) ([]float32, error) {
+	return []float32{1, 0, 0}, nil
+}
+
+func TestSearchCmdSemanticExplain(t *testing.T) {
+	keywordUC, _ := setupSearchTest(t)
+
+	idx, err := internal.NewAnnoyIndex(t.TempDir(), 3)
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+	ctx := context.Background()
+	for key, vec := range map[string][]float32{"notes/near": {1, 0, 0}, "notes/far": {0, 1, 0}} {
+		k, _ := internal.NewKey(key)
+		if err := idx.Add(ctx, k, internal.Embedding{Vector: vec}); err != nil {
+			t.Fatalf("add %s: %v", key, err)
+		}
+	}
+	if err := idx.Build(ctx, 10); err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	indexFor := func(s internal.Scope) (internal.VectorIndex, error) { return idx, nil }
+	semanticUC := internal.NewSemanticSearchUseCase(internal.NewScopeResolver(), indexFor, internal.StaticEmbedder(&axisEmbedder{stubEmbedder{dim: 3}}))
+
+	cmd := NewSearchCmd(keywordUC, semanticUC)
+	cmd.SetArgs([]string{"-s", "--explain", "anything"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	for _, want := range []string{"notes/near", "distance 0.0000", "similarity 1.0000", "distance 1.4142", "index "} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("explain output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	cmd = NewSearchCmd(keywordUC, semanticUC)
+	cmd.Root().PersistentFlags().Bool("json", false, "")
+	cmd.SetArgs([]string{"-s", "--explain", "--json", "-n", "1", "anything"})
+	out.Reset()
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute --json: %v", err)
+	}
+	var results []map[string]any
+	if err := json.Unmarshal(out.Bytes(), &results); err != nil {
+		t.Fatalf("decode %q: %v", out.String(), err)
+	}
+	if len(results) != 1 || results[0]["key"] != "notes/near" {
+		t.Fatalf("results = %v", results)
+	}
+	for _, field := range []string{"distance", "similarity", "index"} {
+		if _, ok := results[0][field]; !ok {
+			t.Errorf("explained JSON lacks %q: %v", field, results[0])
+		}
+	}
+}
+
+func TestSearchCmdExplainNeedsSemantic(t *testing.T) {
+	keywordUC, semanticUC := setupSearchTest(t)
+
+	cmd := NewSearchCmd(keywordUC, semanticUC)
+	cmd.SetArgs([]string{"--explain", "milk"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "semantic") {
+		t.Errorf("expected --explain to require -s, got %v", err)
+	}
+}
@@ -2,38 +2,75 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"strings"
 
 	"github.com/4thel00z/memories/internal"
 	"github.com/spf13/cobra"
 )
 
-func NewSetCmd(setUC *internal.SetMemoryUseCase, commitUC *internal.CommitUseCase) *cobra.Command {
+func NewSetCmd(getUC *internal.GetMemoryUseCase, setUC *internal.SetMemoryUseCase, bulkUC *internal.BulkSetUseCase, commitUC *internal.CommitUseCase, aliasUC *internal.AliasUseCase) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "set <key> [value]",
 		Short: "Create or update a memory",
-		Long:  `Create or update a memory with the given key. Reads from stdin if value is not provided.`,
-		Args:  cobra.RangeArgs(1, 2),
-		RunE:  makeSetRunner(setUC, commitUC),
+		Long: `Create or update a memory with the given key. Reads from stdin if value is not provided
+or is "-". --value-file reads the value from a file ("-" for stdin) and
+--editor composes it in $EDITOR, so multi-line content needs no quoting.
+
+With --from-file, set every key in a JSON object ({"k1":"v1","k2":"v2"}) or
+a TSV file (key<TAB>value per line, 
+ escapes newlines) in one commit.
+Nothing is written if any key fails. Use "-" to read the file from stdin.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if cmd.Flags().Changed("from-file") {
+				return cobra.NoArgs(cmd, args)
+			}
+			if cmd.Flags().Changed("value-file") || cmd.Flags().Changed("editor") {
+				return cobra.ExactArgs(1)(cmd, args)
+			}
+			return cobra.RangeArgs(1, 2)(cmd, args)
+		},
+		RunE: makeSetRunner(getUC, setUC, bulkUC, commitUC, aliasUC),
 	}
 
 	cmd.Flags().StringP("message", "m", "", "Commit message")
+	cmd.Flags().String("from-file", "", "Set all key/value pairs from a JSON or TSV file in one commit")
+	cmd.Flags().String("value-file", "", `Read the value from a file ("-" for stdin)`)
+	cmd.Flags().Bool("editor", false, "Compose the value in $EDITOR")
+	cmd.MarkFlagsMutuallyExclusive("from-file", "value-file", "editor")
 	return cmd
 }
 
-func makeSetRunner(setUC *internal.SetMemoryUseCase, commitUC *internal.CommitUseCase) func(*cobra.Command, []string) error {
+func makeSetRunner(getUC *internal.GetMemoryUseCase, setUC *internal.SetMemoryUseCase, bulkUC *internal.BulkSetUseCase, commitUC *internal.CommitUseCase, aliasUC *internal.AliasUseCase) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
-		key := args[0]
+		if path, _ := cmd.Flags().GetString("from-file"); path != "" {
+			return bulkSet(cmd, bulkUC, path)
+		}
+
+		key, err := resolveKeyArg(cmd, aliasUC, args[0])
+		if err != nil {
+			return err
+		}
 
-		content, err := resolveContent(args)
+		content, err := resolveContent(cmd, args)
 		if err != nil {
 			return err
 		}
 
 		scopeHint, _ := cmd.Flags().GetString("scope")
 		message, _ := cmd.Flags().GetString("message")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		outcome := "created"
+		if getUC != nil {
+			if _, err := getUC.Execute(cmd.Context(), internal.GetMemoryInput{Key: key, Scope: scopeHint}); err == nil {
+				outcome = "updated"
+			}
+		}
 
 		if err := setUC.Execute(cmd.Context(), internal.SetMemoryInput{
 			Key: key, Content: content, Scope: scopeHint,
@@ -41,38 +78,133 @@ func makeSetRunner(setUC *internal.SetMemoryUseCase, commitUC *internal.CommitUs
 			return fmt.Errorf("set memory: %w", err)
 		}
 
-		if err := autoCommit(cmd.Context(), commitUC, message, "set", key, scopeHint); err != nil {
+		commit, err := autoCommit(cmd.Context(), commitUC, message, "set", key, scopeHint)
+		if err != nil {
 			return fmt.Errorf("commit: %w", err)
 		}
 
+		if asJSON {
+			return outputWriteResultJSON(cmd, writeResultJSON("set", key, outcome, commit))
+		}
 		fmt.Fprintf(cmd.OutOrStdout(), "Set %s\n", key)
 		return nil
 	}
 }
 
-func resolveContent(args []string) (string, error) {
-	if len(args) >= 2 {
+func bulkSet(cmd *cobra.Command, bulkUC *internal.BulkSetUseCase, path string) error {
+	if bulkUC == nil {
+		return fmt.Errorf("--from-file is not available")
+	}
+
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(cmd.InOrStdin())
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+
+	pairs, err := internal.ParseKeyValues(data)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", path, err)
+	}
+
+	scopeHint, _ := cmd.Flags().GetString("scope")
+	message, _ := cmd.Flags().GetString("message")
+	out, err := bulkUC.Execute(cmd.Context(), internal.BulkSetInput{
+		Pairs: pairs, Scope: scopeHint, Message: message,
+	})
+	if err != nil {
+		return fmt.Errorf("set memories: %w", err)
+	}
+
+	if out.Commit == nil {
+		fmt.Fprintf(cmd.OutOrStdout(), "Set %d keys (no changes)\n", len(out.Keys))
+		return nil
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "Set %d keys [%s]\n", len(out.Keys), out.Commit.Hash[:7])
+	return nil
+}
+
+// resolveContent returns the value for set: composed in $EDITOR, read from
+// --value-file, given as the second argument, or read from stdin.
+func resolveContent(cmd *cobra.Command, args []string) (string, error) {
+	if useEditor, _ := cmd.Flags().GetBool("editor"); useEditor {
+		content, err := editInEditor(cmd, "")
+		if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(content) == "" {
+			return "", fmt.Errorf("aborting: empty value")
+		}
+		return content, nil
+	}
+
+	path, _ := cmd.Flags().GetString("value-file")
+	if path == "" && len(args) >= 2 && args[1] != "-" {
 		return args[1], nil
 	}
+	if path != "" && path != "-" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("read %s: %w", path, err)
+		}
+		return string(data), nil
+	}
 
-	data, err := io.ReadAll(os.Stdin)
+	data, err := io.ReadAll(cmd.InOrStdin())
 	if err != nil {
 		return "", fmt.Errorf("read stdin: %w", err)
 	}
 	return string(data), nil
 }
 
-func autoCommit(ctx context.Context, commitUC *internal.CommitUseCase, message, action, key, scopeHint string) error {
+// autoCommit commits staged changes as "<action>: <key>", or as
+// git.message_template has it, unless message is set. It returns the
+// commit, or nil when there was nothing to commit.
+func autoCommit(ctx context.Context, commitUC *internal.CommitUseCase, message, action, key, scopeHint string) (*internal.CommitOutput, error) {
 	if commitUC == nil {
-		return nil
+		return nil, nil
 	}
 
-	if message == "" {
-		message = fmt.Sprintf("%s: %s", action, key)
+	commit, err := commitUC.Execute(ctx, internal.CommitInput{
+		Message: message, Scope: scopeHint, Op: action, Key: key,
+	})
+	if errors.Is(err, internal.ErrNothingToCommit) {
+		return nil, nil
 	}
+	return commit, err
+}
 
-	_, err := commitUC.Execute(ctx, internal.CommitInput{
-		Message: message, Scope: scopeHint,
-	})
-	return err
+// writeResultJSON is the --json result of a write command: the op, the key,
+// the outcome as a true field (created, updated, appended or deleted) and
+// the hash of the commit made, null if there was nothing to commit.
+func writeResultJSON(op, key, outcome string, commit *internal.CommitOutput) map[string]any {
+	result := map[string]any{
+		"op":          op,
+		"key":         key,
+		outcome:       true,
+		"commit_hash": nil,
+	}
+	if commit != nil {
+		result["commit_hash"] = commit.Hash
+	}
+	return result
+}
+
+// unchangedResultJSON reports a write that changed nothing: updated is false
+// and there is no commit.
+func unchangedResultJSON(op, key string) map[string]any {
+	result := writeResultJSON(op, key, "updated", nil)
+	result["updated"] = false
+	return result
+}
+
+func outputWriteResultJSON(cmd *cobra.Command, result map[string]any) error {
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
 }
@@ -2,8 +2,11 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/4thel00z/memories/internal"
@@ -37,7 +40,7 @@ func TestSetCmd(t *testing.T) {
 	setUC := internal.NewSetMemoryUseCase(resolver, repoFor, nilIndex, nil, nil)
 	commitUC := internal.NewCommitUseCase(resolver, histFor)
 
-	cmd := NewSetCmd(setUC, commitUC)
+	cmd := NewSetCmd(nil, setUC, nil, commitUC, nil)
 	cmd.SetArgs([]string{"test/key", "test value"})
 
 	var out bytes.Buffer
@@ -87,7 +90,7 @@ func TestSetCmdOverwrite(t *testing.T) {
 	commitUC := internal.NewCommitUseCase(resolver, histFor)
 
 	// Set initial value
-	cmd := NewSetCmd(setUC, commitUC)
+	cmd := NewSetCmd(nil, setUC, nil, commitUC, nil)
 	cmd.SetArgs([]string{"mykey", "first"})
 	var out bytes.Buffer
 	cmd.SetOut(&out)
@@ -96,7 +99,7 @@ func TestSetCmdOverwrite(t *testing.T) {
 	}
 
 	// Overwrite
-	cmd2 := NewSetCmd(setUC, commitUC)
+	cmd2 := NewSetCmd(nil, setUC, nil, commitUC, nil)
 	cmd2.SetArgs([]string{"mykey", "second"})
 	cmd2.SetOut(&out)
 	if err := cmd2.Execute(); err != nil {
@@ -112,3 +115,173 @@ func TestSetCmdOverwrite(t *testing.T) {
 		t.Errorf("content = %q, want %q", string(mem.Content), "second")
 	}
 }
+
+func setupSetTest(t *testing.T) (*internal.GitRepository, *internal.SetMemoryUseCase, *internal.CommitUseCase) {
+	t.Helper()
+	tmpDir := t.TempDir()
+	scope := internal.Scope{
+		Type:    internal.ScopeProject,
+		Path:    tmpDir,
+		MemPath: filepath.Join(tmpDir, ".mem"),
+	}
+
+	if err := os.MkdirAll(scope.VectorPath(), 0755); err != nil {
+		t.Fatalf("mkdir vectors: %v", err)
+	}
+	if err := internal.InitRepository(scope); err != nil {
+		t.Fatalf("init repo: %v", err)
+	}
+
+	repo, err := internal.NewGitRepository(scope)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+
+	resolver := internal.NewScopeResolver()
+	repoFor := func(s internal.Scope) (internal.MemoryRepository, error) { return repo, nil }
+	histFor := func(s internal.Scope) (internal.HistoryRepository, error) { return repo, nil }
+	nilIndex := func(s internal.Scope) (internal.VectorIndex, error) { return nil, internal.ErrNoIndex }
+
+	return repo, internal.NewSetMemoryUseCase(resolver, repoFor, nilIndex, nil, nil), internal.NewCommitUseCase(resolver, histFor)
+}
+
+func TestSetCmdValueSources(t *testing.T) {
+	valueFile := filepath.Join(t.TempDir(), "value.md")
+	if err := os.WriteFile(valueFile, []byte("from a file\nwith \"quotes\"\n"), 0644); err != nil {
+		t.Fatalf("write value file: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name  string
+		args  []string
+		stdin string
+		want  string
+	}{
+		{"dash reads stdin", []string{"k", "-"}, "piped\nvalue\n", "piped\nvalue\n"},
+		{"value file", []string{"k", "--value-file", valueFile}, "", "from a file\nwith \"quotes\"\n"},
+		{"value file from stdin", []string{"k", "--value-file", "-"}, "heredoc\n", "heredoc\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, setUC, commitUC := setupSetTest(t)
+
+			cmd := NewSetCmd(nil, setUC, nil, commitUC, nil)
+			cmd.SetArgs(tt.args)
+			cmd.SetIn(strings.NewReader(tt.stdin))
+			cmd.SetOut(&bytes.Buffer{})
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("execute: %v", err)
+			}
+
+			mem, err := repo.Get(context.Background(), internal.Key("k"))
+			if err != nil {
+				t.Fatalf("get memory: %v", err)
+			}
+			if string(mem.Content) != tt.want {
+				t.Errorf("content = %q, want %q", mem.Content, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetCmdEditor(t *testing.T) {
+	repo, setUC, commitUC := setupSetTest(t)
+
+	editorScript := filepath.Join(t.TempDir(), "editor.sh")
+	if err := os.WriteFile(editorScript, []byte("#!/bin/sh\nprintf '# Title\\n\\nIt'\"'\"'s multi-line.\\n' > \"$1\"\n"), 0755); err != nil {
+		t.Fatalf("write editor script: %v", err)
+	}
+	t.Setenv("EDITOR", editorScript)
+	stubTerminal(t, true)
+
+	cmd := NewSetCmd(nil, setUC, nil, commitUC, nil)
+	cmd.SetArgs([]string{"notes/composed", "--editor"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	mem, err := repo.Get(context.Background(), internal.Key("notes/composed"))
+	if err != nil {
+		t.Fatalf("get memory: %v", err)
+	}
+	if want := "# Title\n\nIt's multi-line.\n"; string(mem.Content) != want {
+		t.Errorf("content = %q, want %q", mem.Content, want)
+	}
+
+	// An editor that leaves the file empty aborts without writing.
+	t.Setenv("EDITOR", "true")
+	cmd = NewSetCmd(nil, setUC, nil, commitUC, nil)
+	cmd.SetArgs([]string{"notes/empty", "--editor"})
+	cmd.SetOut(&bytes.Buffer{})
+	if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "empty value") {
+		t.Errorf("expected an empty value error, got %v", err)
+	}
+	if exists, _ := repo.Exists(context.Background(), internal.Key("notes/empty")); exists {
+		t.Error("an empty editor session must not create the memory")
+	}
+}
+
+func TestWriteCommandsJSON(t *testing.T) {
+	a, _ := setupE2E(t)
+	run := func(args ...string) map[string]any {
+		t.Helper()
+		root := NewRootCmd("test", a)
+		root.SetArgs(append(args, "--json"))
+		var out bytes.Buffer
+		root.SetOut(&out)
+		if err := root.Execute(); err != nil {
+			t.Fatalf("%v: %v", args, err)
+		}
+		var result map[string]any
+		if err := json.Unmarshal(out.Bytes(), &result); err != nil {
+			t.Fatalf("%v: invalid JSON %q: %v", args, out.String(), err)
+		}
+		return result
+	}
+	check := func(result map[string]any, op, key, outcome string) {
+		t.Helper()
+		if result["op"] != op || result["key"] != key || result[outcome] != true {
+			t.Errorf("want op=%s key=%s %s=true, got %v", op, key, outcome, result)
+		}
+		if hash, _ := result["commit_hash"].(string); len(hash) != 40 {
+			t.Errorf("%s: commit_hash = %v, want a full hash", op, result["commit_hash"])
+		}
+	}
+
+	created := run("set", "notes/a", "one")
+	check(created, "set", "notes/a", "created")
+	if _, ok := created["updated"]; ok {
+		t.Errorf("a create should not report updated: %v", created)
+	}
+	overwritten := run("set", "notes/a", "two")
+	check(overwritten, "set", "notes/a", "updated")
+	if overwritten["commit_hash"] == created["commit_hash"] {
+		t.Error("overwrite should report its own commit")
+	}
+
+	// Setting the same value again commits nothing.
+	if same := run("set", "notes/a", "two"); same["commit_hash"] != nil {
+		t.Errorf("unchanged set should have no commit, got %v", same["commit_hash"])
+	}
+
+	check(run("add", "notes/a", "three"), "add", "notes/a", "appended")
+	check(run("add", "notes/b", "new"), "add", "notes/b", "created")
+	deleted := run("del", "notes/b")
+	check(deleted, "del", "notes/b", "deleted")
+	if keys, _ := deleted["keys"].([]any); len(keys) != 1 {
+		t.Errorf("del keys = %v", deleted["keys"])
+	}
+
+	editor := filepath.Join(t.TempDir(), "editor.sh")
+	if err := os.WriteFile(editor, []byte("#!/bin/sh\necho edited > \"$1\"\n"), 0755); err != nil {
+		t.Fatalf("write editor: %v", err)
+	}
+	t.Setenv("VISUAL", "")
+	t.Setenv("EDITOR", editor)
+	stubTerminal(t, true)
+	check(run("edit", "notes/a"), "edit", "notes/a", "updated")
+	if unchanged := run("edit", "notes/a"); unchanged["updated"] != false || unchanged["commit_hash"] != nil {
+		t.Errorf("unchanged edit = %v", unchanged)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewSnapshotCmd(snapUC *internal.SnapshotUseCase, commitUC *internal.CommitUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "snapshot",
+		Short: "Create, list and restore named snapshots",
+		Long: `Snapshots are tags on the .mem repository. Take one before letting an
+agent rewrite memories, then "mem snapshot restore <name>" to bring the
+store back. A restore is a new commit, so history after the snapshot is kept.`,
+	}
+
+	cmd.AddCommand(
+		newSnapshotCreateCmd(snapUC),
+		newSnapshotListCmd(snapUC),
+		newSnapshotRestoreCmd(snapUC, commitUC),
+	)
+	return cmd
+}
+
+func newSnapshotCreateCmd(snapUC *internal.SnapshotUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "create <name>",
+		Short: "Snapshot the last commit",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			message, _ := cmd.Flags().GetString("message")
+			force, _ := cmd.Flags().GetBool("force")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			snap, err := snapUC.Create(cmd.Context(), internal.SnapshotInput{
+				Name: args[0], Message: message, Scope: scopeHint, Force: force,
+			})
+			if err != nil {
+				return fmt.Errorf("create snapshot: %w", err)
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(snapshotToJSON(*snap))
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Created snapshot %s at %s\n", snap.Name, snap.Commit[:7])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("message", "m", "", "Describe the snapshot")
+	cmd.Flags().BoolP("force", "f", false, "Replace an existing snapshot of the same name")
+	return cmd
+}
+
+func newSnapshotListCmd(snapUC *internal.SnapshotUseCase) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List snapshots, oldest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			snapshots, err := snapUC.List(cmd.Context(), internal.SnapshotInput{Scope: scopeHint})
+			if err != nil {
+				return fmt.Errorf("list snapshots: %w", err)
+			}
+
+			if asJSON {
+				data := make([]map[string]any, len(snapshots))
+				for i, s := range snapshots {
+					data[i] = snapshotToJSON(s)
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(data)
+			}
+
+			if len(snapshots) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No snapshots.")
+				return nil
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			for _, s := range snapshots {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.Name, s.CreatedAt.Format("2006-01-02 15:04"), s.Commit[:7], s.Message)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newSnapshotRestoreCmd(snapUC *internal.SnapshotUseCase, commitUC *internal.CommitUseCase) *cobra.Command {
+	return &cobra.Command{
+		Use:   "restore <name>",
+		Short: "Restore the store to a snapshot as a new commit",
+		Long: `Bring every memory back to how it was in the snapshot and commit the
+result as "restore: snapshot <name>". Commits made since the snapshot stay
+in the log. The vector index is marked stale; run "mem index rebuild".`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			out, err := snapUC.Restore(cmd.Context(), internal.SnapshotInput{Name: args[0], Scope: scopeHint})
+			if err != nil {
+				return fmt.Errorf("restore snapshot: %w", err)
+			}
+			if out.Changed > 0 {
+				if _, err := autoCommit(cmd.Context(), commitUC, "", "restore", "snapshot "+out.Snapshot.Name, scopeHint); err != nil {
+					return fmt.Errorf("commit: %w", err)
+				}
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(map[string]any{
+					"snapshot": snapshotToJSON(out.Snapshot),
+					"changed":  out.Changed,
+				})
+			}
+
+			if out.Changed == 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), "Already at snapshot %s\n", out.Snapshot.Name)
+				return nil
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Restored snapshot %s (%d files changed)\n", out.Snapshot.Name, out.Changed)
+			fmt.Fprintln(cmd.OutOrStdout(), "The search index is stale; run `mem index rebuild`.")
+			return nil
+		},
+	}
+}
+
+func snapshotToJSON(s internal.SnapshotOutput) map[string]any {
+	return map[string]any{
+		"name":       s.Name,
+		"commit":     s.Commit,
+		"message":    s.Message,
+		"created_at": s.CreatedAt,
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewStatsCmd(statsUC *internal.StatsUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats",
+		Short: "Show store statistics",
+		Long: `Summarise the store: memories and bytes per top-level prefix, the largest
+keys, commits per week, index coverage and branch count. Only file sizes
+are read, so this stays fast on large stores.
+
+--duplicates also reads every memory to find the ones with identical
+content and reports how many bytes are unique. mem dedupe --identical
+--apply replaces the copies with alias memories.`,
+		Args: cobra.NoArgs,
+		RunE: makeStatsRunner(statsUC),
+	}
+
+	cmd.Flags().String("prefix", "", "Only count memories under this prefix")
+	cmd.Flags().Int("top", internal.DefaultStatsTop, "Number of largest keys to show")
+	cmd.Flags().Int("weeks", internal.DefaultStatsWeeks, "Number of weeks of commit history to show")
+	cmd.Flags().Bool("duplicates", false, "Find memories with identical content (reads every memory)")
+	return cmd
+}
+
+func makeStatsRunner(statsUC *internal.StatsUseCase) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, _ []string) error {
+		scopeHint, _ := cmd.Flags().GetString("scope")
+		prefix, _ := cmd.Flags().GetString("prefix")
+		top, _ := cmd.Flags().GetInt("top")
+		weeks, _ := cmd.Flags().GetInt("weeks")
+		duplicates, _ := cmd.Flags().GetBool("duplicates")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		out, err := statsUC.Execute(cmd.Context(), internal.StatsInput{
+			Prefix: prefix, Scope: scopeHint, Top: top, Weeks: weeks, Duplicates: duplicates,
+		})
+		if err != nil {
+			return fmt.Errorf("stats: %w", err)
+		}
+
+		if asJSON {
+			return outputStatsJSON(cmd, out, duplicates)
+		}
+
+		w := cmd.OutOrStdout()
+		fmt.Fprintf(w, "Memories  %d (%s)\n", out.Memories, formatBytes(out.Bytes))
+		fmt.Fprintf(w, "Indexed   %d/%d (%.0f%%)\n", out.Indexed, out.Memories, coverage(out))
+		fmt.Fprintf(w, "Branches  %d\n", out.Branches)
+
+		if len(out.Prefixes) > 0 {
+			fmt.Fprintln(w, "\nPrefix")
+			for _, p := range out.Prefixes {
+				fmt.Fprintf(w, "  %-24s %6d  %10s\n", p.Prefix, p.Memories, formatBytes(p.Bytes))
+			}
+		}
+
+		if len(out.Largest) > 0 {
+			fmt.Fprintln(w, "\nLargest")
+			for _, k := range out.Largest {
+				fmt.Fprintf(w, "  %-32s %10s\n", k.Key, formatBytes(k.Bytes))
+			}
+		}
+
+		if duplicates {
+			fmt.Fprintf(w, "\nUnique    %s (%s in %d duplicate groups)\n",
+				formatBytes(out.Bytes-out.WastedBytes), formatBytes(out.WastedBytes), len(out.Duplicates))
+			for _, g := range out.Duplicates[:min(top, len(out.Duplicates))] {
+				fmt.Fprintf(w, "  %s  %d copies  %10s wasted\n", g.Hash[:12], len(g.Keys), formatBytes(g.Wasted()))
+				for _, key := range g.Keys {
+					fmt.Fprintf(w, "    %s\n", key)
+				}
+			}
+		}
+
+		if len(out.Weeks) > 0 {
+			fmt.Fprintln(w, "\nCommits per week")
+			for _, wk := range out.Weeks {
+				fmt.Fprintf(w, "  %s  %4d\n", wk.Start.Format("2006-01-02"), wk.Commits)
+			}
+		}
+		return nil
+	}
+}
+
+func outputStatsJSON(cmd *cobra.Command, out *internal.StatsOutput, duplicates bool) error {
+	prefixes := make([]map[string]any, 0, len(out.Prefixes))
+	for _, p := range out.Prefixes {
+		prefixes = append(prefixes, map[string]any{
+			"prefix":   p.Prefix,
+			"memories": p.Memories,
+			"bytes":    p.Bytes,
+		})
+	}
+
+	largest := make([]map[string]any, 0, len(out.Largest))
+	for _, k := range out.Largest {
+		largest = append(largest, map[string]any{
+			"key":   k.Key,
+			"bytes": k.Bytes,
+		})
+	}
+
+	weeks := make([]map[string]any, 0, len(out.Weeks))
+	for _, wk := range out.Weeks {
+		weeks = append(weeks, map[string]any{
+			"week":    wk.Start.Format("2006-01-02"),
+			"commits": wk.Commits,
+		})
+	}
+
+	data := map[string]any{
+		"memories":         out.Memories,
+		"bytes":            out.Bytes,
+		"prefixes":         prefixes,
+		"largest":          largest,
+		"commits_per_week": weeks,
+		"indexed":          out.Indexed,
+		"branches":         out.Branches,
+	}
+	if duplicates {
+		groups := make([]map[string]any, 0, len(out.Duplicates))
+		for _, g := range out.Duplicates {
+			groups = append(groups, map[string]any{
+				"sha256": g.Hash,
+				"keys":   g.Keys,
+				"bytes":  g.Bytes,
+				"wasted": g.Wasted(),
+			})
+		}
+		data["duplicates"] = groups
+		data["wasted_bytes"] = out.WastedBytes
+		data["unique_bytes"] = out.Bytes - out.WastedBytes
+	}
+
+	enc := json.NewEncoder(cmd.OutOrStdout())
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+func coverage(out *internal.StatsOutput) float64 {
+	if out.Memories == 0 {
+		return 0
+	}
+	return 100 * float64(out.Indexed) / float64(out.Memories)
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
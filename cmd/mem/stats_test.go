@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestStatsCmd(t *testing.T) {
+	a, _ := setupE2E(t)
+
+	for _, kv := range [][2]string{
+		{"notes/a", "hello world"},
+		{"notes/b", "hi"},
+		{"hooks/commits/c", "bumped deps"},
+	} {
+		root := NewRootCmd("test", a)
+		root.SetArgs([]string{"set", kv[0], kv[1]})
+		root.SetOut(&bytes.Buffer{})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("set %s: %v", kv[0], err)
+		}
+	}
+
+	root := NewRootCmd("test", a)
+	root.SetArgs([]string{"stats"})
+	var out bytes.Buffer
+	root.SetOut(&out)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+
+	for _, want := range []string{"Memories  3", "Branches  1", "notes", "hooks", "Commits per week"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("expected %q in output, got %q", want, out.String())
+		}
+	}
+
+	root = NewRootCmd("test", a)
+	root.SetArgs([]string{"stats", "--prefix", "notes", "--json"})
+	out.Reset()
+	root.SetOut(&out)
+	if err := root.Execute(); err != nil {
+		t.Fatalf("stats --json: %v", err)
+	}
+
+	var data map[string]any
+	if err := json.Unmarshal(out.Bytes(), &data); err != nil {
+		t.Fatalf("unmarshal: %v (%q)", err, out.String())
+	}
+	if data["memories"] != float64(2) {
+		t.Errorf("memories = %v, want 2", data["memories"])
+	}
+}
@@ -30,6 +30,12 @@ func makeStatusRunner(currentUC *internal.BranchCurrentUseCase) func(*cobra.Comm
 		}
 
 		fmt.Fprintf(cmd.OutOrStdout(), "On branch %s\n", out.Name)
+		if out.Head == "" {
+			fmt.Fprintln(cmd.OutOrStdout(), "\nNo commits yet; run `mem set` or `mem commit`.")
+		}
+		if n := internal.PendingIndexUpdates(resolverFrom(cmd).Resolve(scopeHint)); n > 0 {
+			fmt.Fprintf(cmd.OutOrStdout(), "\n%d index updates pending; run `mem index rebuild`.\n", n)
+		}
 		return nil
 	}
 }
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/4thel00z/memories/internal"
+	"github.com/go-git/go-git/v5"
 )
 
 func TestStatusCmd(t *testing.T) {
@@ -48,3 +49,38 @@ func TestStatusCmd(t *testing.T) {
 		t.Errorf("expected 'On branch' in output, got %q", out.String())
 	}
 }
+
+func TestStatusAndLogWithoutCommits(t *testing.T) {
+	scope := internal.Scope{Type: internal.ScopeProject, MemPath: filepath.Join(t.TempDir(), ".mem")}
+	if _, err := git.PlainInit(scope.MemPath, false); err != nil {
+		t.Fatalf("plain init: %v", err)
+	}
+	repo, err := internal.NewGitRepository(scope)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+
+	resolver := internal.NewScopeResolver()
+	branchFor := func(internal.Scope) (internal.BranchRepository, error) { return repo, nil }
+	histFor := func(internal.Scope) (internal.HistoryRepository, error) { return repo, nil }
+
+	status := NewStatusCmd(internal.NewBranchCurrentUseCase(resolver, branchFor))
+	var out bytes.Buffer
+	status.SetOut(&out)
+	if err := status.Execute(); err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if !strings.Contains(out.String(), "No commits yet") {
+		t.Errorf("status output = %q", out.String())
+	}
+
+	log := NewLogCmd(internal.NewLogUseCase(resolver, histFor))
+	out.Reset()
+	log.SetOut(&out)
+	if err := log.Execute(); err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if !strings.Contains(out.String(), "No commits yet") {
+		t.Errorf("log output = %q", out.String())
+	}
+}
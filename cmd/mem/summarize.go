@@ -1,26 +1,66 @@
 package main
 
 import (
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
+	"time"
 
 	"github.com/4thel00z/memories/internal"
 	"github.com/spf13/cobra"
 )
 
-func NewSummarizeCmd(summarizeUC *internal.SummarizeUseCase) *cobra.Command {
+// Summary output formats for --format.
+const (
+	summaryFormatText     = "text"
+	summaryFormatMarkdown = "markdown"
+	summaryFormatJSON     = "json"
+)
+
+// summarySaveDefault is the --save value when the flag is given without
+// one; it stands for summaries/<prefix>.
+const summarySaveDefault = "summaries/<prefix>"
+
+func NewSummarizeCmd(
+	summarizeUC *internal.SummarizeUseCase,
+	getUC *internal.GetMemoryUseCase,
+	setUC *internal.SetMemoryUseCase,
+	commitUC *internal.CommitUseCase,
+) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "summarize [prefix]",
 		Short: "Summarize memories using AI",
-		Long:  `Generate an AI-powered summary of memories, optionally filtered by prefix.`,
-		Args:  cobra.MaximumNArgs(1),
-		RunE:  makeSummarizeRunner(summarizeUC),
+		Long: `Generate an AI-powered summary of memories, optionally filtered by prefix.
+Use --format markdown to paste the summary into docs, or --format json for
+pipelines.
+
+--save also stores the summary as a Markdown memory, with the time it was
+generated and the keys it covers, and commits it as "summarize: <prefix>".
+Without a value it saves to summaries/<prefix>; pass --save=<key> for another
+key. An existing summary is only replaced with --force.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: makeSummarizeRunner(summarizeUC, getUC, setUC, commitUC),
 	}
 
+	cmd.Flags().String("format", summaryFormatText, "Output format: text, markdown or json")
+	cmd.Flags().String("provider", "", "Provider to use for this run (default: models.summarize or default_provider)")
+	cmd.Flags().String("model", "", "Model to use for this run")
+	cmd.Flags().Bool("no-redact", false, "Send content to the provider without redacting secrets")
+	cmd.Flags().String("save", "", "Also save the summary as a memory at this key")
+	cmd.Flags().Lookup("save").NoOptDefVal = summarySaveDefault
+	cmd.Flags().BoolP("force", "f", false, "Replace an existing memory at the --save key")
 	return cmd
 }
 
-func makeSummarizeRunner(summarizeUC *internal.SummarizeUseCase) func(*cobra.Command, []string) error {
+func makeSummarizeRunner(
+	summarizeUC *internal.SummarizeUseCase,
+	getUC *internal.GetMemoryUseCase,
+	setUC *internal.SetMemoryUseCase,
+	commitUC *internal.CommitUseCase,
+) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, args []string) error {
 		prefix := ""
 		if len(args) > 0 {
@@ -29,30 +69,147 @@ func makeSummarizeRunner(summarizeUC *internal.SummarizeUseCase) func(*cobra.Com
 
 		scopeHint, _ := cmd.Flags().GetString("scope")
 		asJSON, _ := cmd.Flags().GetBool("json")
+		format, _ := cmd.Flags().GetString("format")
+		provider, _ := cmd.Flags().GetString("provider")
+		model, _ := cmd.Flags().GetString("model")
+		saveKey, _ := cmd.Flags().GetString("save")
+		force, _ := cmd.Flags().GetBool("force")
+		noRedact, _ := cmd.Flags().GetBool("no-redact")
+		if asJSON {
+			format = summaryFormatJSON
+		}
+
+		var render func(io.Writer, *internal.SummarizeOutput) error
+		switch format {
+		case summaryFormatText:
+			render = renderSummaryText
+		case summaryFormatMarkdown:
+			render = renderSummaryMarkdown
+		case summaryFormatJSON:
+			render = renderSummaryJSON
+		default:
+			return fmt.Errorf("unknown format %q (want text, markdown or json)", format)
+		}
 
 		out, err := summarizeUC.Execute(cmd.Context(), internal.SummarizeInput{
-			Prefix: prefix, Scope: scopeHint,
+			Prefix: prefix, Scope: scopeHint, Provider: provider, Model: model, NoRedact: noRedact,
 		})
 		if err != nil {
 			return fmt.Errorf("summarize: %w", err)
 		}
 
-		if asJSON {
-			enc := json.NewEncoder(cmd.OutOrStdout())
-			enc.SetIndent("", "  ")
-			return enc.Encode(out)
+		stop := func() {}
+		if format != summaryFormatJSON {
+			stop = startPager(cmd)
+		}
+		err = render(cmd.OutOrStdout(), out)
+		stop()
+		if err != nil {
+			return err
+		}
+		if saveKey == "" {
+			return nil
 		}
 
-		fmt.Fprintf(cmd.OutOrStdout(), "# %s\n\n%s\n", out.Title, out.Overview)
-		if len(out.KeyPoints) > 0 {
-			fmt.Fprintln(cmd.OutOrStdout(), "\nKey Points:")
-			for _, p := range out.KeyPoints {
-				fmt.Fprintf(cmd.OutOrStdout(), "  - %s\n", p)
-			}
+		if saveKey == summarySaveDefault {
+			saveKey = "summaries/" + cmp.Or(strings.Trim(prefix, "/"), "all")
+		}
+		if err := saveSummary(cmd, getUC, setUC, commitUC, saveKey, prefix, force, out); err != nil {
+			return err
 		}
-		if len(out.Tags) > 0 {
-			fmt.Fprintf(cmd.OutOrStdout(), "\nTags: %v\n", out.Tags)
+		if format != summaryFormatJSON {
+			fmt.Fprintf(cmd.OutOrStdout(), "\nSaved summary to %s\n", saveKey)
 		}
 		return nil
 	}
 }
+
+// saveSummary stores out as a memory at key and commits it. Unless force is
+// set it refuses to replace an existing memory.
+func saveSummary(
+	cmd *cobra.Command,
+	getUC *internal.GetMemoryUseCase,
+	setUC *internal.SetMemoryUseCase,
+	commitUC *internal.CommitUseCase,
+	key, prefix string,
+	force bool,
+	out *internal.SummarizeOutput,
+) error {
+	scopeHint, _ := cmd.Flags().GetString("scope")
+
+	if !force {
+		_, err := getUC.Execute(cmd.Context(), internal.GetMemoryInput{Key: key, Scope: scopeHint})
+		if err == nil {
+			return fmt.Errorf("memory %s already exists; use --force to replace it", key)
+		}
+		if !errors.Is(err, internal.ErrNotFound) {
+			return fmt.Errorf("get memory: %w", err)
+		}
+	}
+
+	var doc strings.Builder
+	renderSummaryDocument(&doc, out, prefix, time.Now())
+	if err := setUC.Execute(cmd.Context(), internal.SetMemoryInput{
+		Key: key, Content: doc.String(), Scope: scopeHint,
+	}); err != nil {
+		return fmt.Errorf("save summary: %w", err)
+	}
+
+	if _, err := autoCommit(cmd.Context(), commitUC, "", "summarize", cmp.Or(prefix, "all"), scopeHint); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// renderSummaryDocument writes the Markdown summary behind a front matter
+// block recording when it was generated and from which keys, so a later
+// run can tell whether it is stale.
+func renderSummaryDocument(w io.Writer, out *internal.SummarizeOutput, prefix string, generatedAt time.Time) {
+	fmt.Fprintln(w, "---")
+	fmt.Fprintf(w, "generated_at: %s\n", generatedAt.UTC().Format(time.RFC3339))
+	fmt.Fprintf(w, "prefix: %q\n", prefix)
+	fmt.Fprintln(w, "sources:")
+	for _, key := range out.Sources {
+		fmt.Fprintf(w, "  - %s\n", key)
+	}
+	fmt.Fprint(w, "---\n\n")
+	_ = renderSummaryMarkdown(w, out)
+}
+
+func renderSummaryText(w io.Writer, out *internal.SummarizeOutput) error {
+	fmt.Fprintf(w, "# %s\n\n%s\n", out.Title, out.Overview)
+	if len(out.KeyPoints) > 0 {
+		fmt.Fprintln(w, "\nKey Points:")
+		for _, p := range out.KeyPoints {
+			fmt.Fprintf(w, "  - %s\n", p)
+		}
+	}
+	if len(out.Tags) > 0 {
+		fmt.Fprintf(w, "\nTags: %v\n", out.Tags)
+	}
+	return nil
+}
+
+func renderSummaryMarkdown(w io.Writer, out *internal.SummarizeOutput) error {
+	fmt.Fprintf(w, "# %s\n\n%s\n", out.Title, out.Overview)
+	if len(out.KeyPoints) > 0 {
+		fmt.Fprint(w, "\n## Key Points\n\n")
+		for _, p := range out.KeyPoints {
+			fmt.Fprintf(w, "- %s\n", p)
+		}
+	}
+	if len(out.Tags) > 0 {
+		tags := make([]string, len(out.Tags))
+		for i, t := range out.Tags {
+			tags[i] = "`" + t + "`"
+		}
+		fmt.Fprintf(w, "\n---\n\nTags: %s\n", strings.Join(tags, ", "))
+	}
+	return nil
+}
+
+func renderSummaryJSON(w io.Writer, out *internal.SummarizeOutput) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
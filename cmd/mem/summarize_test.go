@@ -2,8 +2,12 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/4thel00z/memories/internal"
@@ -34,7 +38,7 @@ func TestSummarizeCmdNoProvider(t *testing.T) {
 
 	summarizeUC := internal.NewSummarizeUseCase(resolver, repoFor, nil)
 
-	cmd := NewSummarizeCmd(summarizeUC)
+	cmd := NewSummarizeCmd(summarizeUC, nil, nil, nil)
 
 	var out bytes.Buffer
 	cmd.SetOut(&out)
@@ -45,3 +49,179 @@ func TestSummarizeCmdNoProvider(t *testing.T) {
 		t.Error("expected error for summarize without provider")
 	}
 }
+
+// summaryProvider returns a fixed summary from GenerateObject.
+type summaryProvider struct {
+	internal.NoModels
+	summary internal.Summary
+}
+
+func (p *summaryProvider) Complete(context.Context, string) (string, error) { return "", nil }
+
+func (p *summaryProvider) GenerateObject(_ context.Context, _ string, target any) error {
+	*target.(*internal.Summary) = p.summary
+	return nil
+}
+
+func (p *summaryProvider) Stream(context.Context, string) (<-chan string, error) { return nil, nil }
+
+func runSummarize(t *testing.T, args ...string) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	scope := internal.Scope{
+		Type:    internal.ScopeProject,
+		Path:    tmpDir,
+		MemPath: filepath.Join(tmpDir, ".mem"),
+	}
+	if err := os.MkdirAll(scope.VectorPath(), 0755); err != nil {
+		t.Fatalf("mkdir vectors: %v", err)
+	}
+	if err := internal.InitRepository(scope); err != nil {
+		t.Fatalf("init repo: %v", err)
+	}
+	repo, err := internal.NewGitRepository(scope)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+	key, _ := internal.NewKey("notes/release")
+	if err := repo.Save(context.Background(), internal.NewMemory(key, []byte("ship v2 on friday"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	provider := &summaryProvider{summary: internal.Summary{
+		Title:     "Release",
+		Overview:  "v2 ships Friday.",
+		KeyPoints: []string{"freeze Thursday", "ship Friday"},
+		Tags:      []string{"release", "v2"},
+	}}
+	repoFor := func(s internal.Scope) (internal.MemoryRepository, error) { return repo, nil }
+	cmd := NewSummarizeCmd(internal.NewSummarizeUseCase(internal.NewScopeResolver(), repoFor, internal.StaticProvider(provider)), nil, nil, nil)
+	cmd.SetArgs(args)
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	return out.String()
+}
+
+func TestSummarizeCmdFormatText(t *testing.T) {
+	got := runSummarize(t)
+	want := "# Release\n\nv2 ships Friday.\n\nKey Points:\n  - freeze Thursday\n  - ship Friday\n\nTags: [release v2]\n"
+	if got != want {
+		t.Errorf("text output =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSummarizeCmdFormatMarkdown(t *testing.T) {
+	got := runSummarize(t, "--format", "markdown")
+	want := "# Release\n\nv2 ships Friday.\n\n## Key Points\n\n- freeze Thursday\n- ship Friday\n\n---\n\nTags: `release`, `v2`\n"
+	if got != want {
+		t.Errorf("markdown output =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSummarizeCmdFormatJSON(t *testing.T) {
+	var out internal.SummarizeOutput
+	if err := json.Unmarshal([]byte(runSummarize(t, "--format", "json")), &out); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if out.Title != "Release" || len(out.KeyPoints) != 2 || len(out.Tags) != 2 {
+		t.Errorf("unexpected json summary: %+v", out)
+	}
+}
+
+func TestSummarizeCmdUnknownFormat(t *testing.T) {
+	cmd := NewSummarizeCmd(internal.NewSummarizeUseCase(internal.NewScopeResolver(), nil, nil), nil, nil, nil)
+	cmd.SetArgs([]string{"--format", "yaml"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestSummarizeCmdProviderOverride(t *testing.T) {
+	var got internal.ProviderOverride
+	var task string
+	providerFor := func(_ context.Context, _ internal.Scope, tk string, override internal.ProviderOverride) (internal.Provider, error) {
+		task, got = tk, override
+		return nil, internal.ErrNoProvider
+	}
+
+	cmd := NewSummarizeCmd(internal.NewSummarizeUseCase(internal.NewScopeResolver(), nil, providerFor), nil, nil, nil)
+	cmd.SetArgs([]string{"--provider", "local", "--model", "tiny"})
+	cmd.SetOut(&bytes.Buffer{})
+	cmd.SetErr(&bytes.Buffer{})
+	if err := cmd.Execute(); !errors.Is(err, internal.ErrNoProvider) {
+		t.Fatalf("expected ErrNoProvider, got %v", err)
+	}
+	if task != internal.TaskSummarize || got != (internal.ProviderOverride{Provider: "local", Model: "tiny"}) {
+		t.Errorf("provider lookup = %s %+v", task, got)
+	}
+}
+
+func TestSummarizeCmdSave(t *testing.T) {
+	a, repo := setupE2E(t)
+	repoFor := func(internal.Scope) (internal.MemoryRepository, error) { return repo, nil }
+	a.uc.Summarize = internal.NewSummarizeUseCase(a.resolver, repoFor, internal.StaticProvider(&summaryProvider{
+		summary: internal.Summary{Title: "Project", Overview: "A Go tool.", KeyPoints: []string{"written in go"}, Tags: []string{"go"}},
+	}))
+
+	run := func(args ...string) (string, error) {
+		root := NewRootCmd("test", a)
+		root.SetArgs(args)
+		var out bytes.Buffer
+		root.SetOut(&out)
+		root.SetErr(&out)
+		err := root.Execute()
+		return out.String(), err
+	}
+	for _, kv := range [][2]string{{"project/name", "memories"}, {"project/lang", "go"}} {
+		if _, err := run("set", kv[0], kv[1]); err != nil {
+			t.Fatalf("set: %v", err)
+		}
+	}
+
+	out, err := run("summarize", "project/", "--save")
+	if err != nil {
+		t.Fatalf("summarize --save: %v", err)
+	}
+	if !strings.Contains(out, "Saved summary to summaries/project") {
+		t.Errorf("expected save confirmation, got:\n%s", out)
+	}
+
+	saved, err := run("get", "summaries/project")
+	if err != nil {
+		t.Fatalf("get summary: %v", err)
+	}
+	for _, want := range []string{"generated_at: ", "prefix: \"project/\"", "  - project/lang\n  - project/name\n", "# Project", "- written in go"} {
+		if !strings.Contains(saved, want) {
+			t.Errorf("saved summary missing %q:\n%s", want, saved)
+		}
+	}
+
+	commits, err := repo.Log(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if commits[0].Message != "summarize: project/" {
+		t.Errorf("commit message = %q, want %q", commits[0].Message, "summarize: project/")
+	}
+
+	if _, err := run("summarize", "project/", "--save"); err == nil || !strings.Contains(err.Error(), "--force") {
+		t.Errorf("expected an existing summary to need --force, got %v", err)
+	}
+	if _, err := run("summarize", "project/", "--save", "--force"); err != nil {
+		t.Errorf("summarize --save --force: %v", err)
+	}
+
+	if _, err := run("summarize", "project/", "--save=notes/overview"); err != nil {
+		t.Fatalf("summarize --save=key: %v", err)
+	}
+	if _, err := run("get", "notes/overview"); err != nil {
+		t.Errorf("expected summary at notes/overview: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewTemplateCmd(templateUC *internal.TemplateUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "template",
+		Short: "Manage memory templates",
+		Long:  `List and add templates stored under .mem/templates/<name>.tmpl.`,
+	}
+
+	cmd.AddCommand(
+		newTemplateListCmd(templateUC),
+		newTemplateAddCmd(templateUC),
+	)
+
+	return cmd
+}
+
+func newTemplateListCmd(templateUC *internal.TemplateUseCase) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List available templates",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			names, err := templateUC.List(internal.TemplateInput{Scope: scopeHint})
+			if err != nil {
+				return fmt.Errorf("list templates: %w", err)
+			}
+
+			if len(names) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No templates defined.")
+				return nil
+			}
+
+			for _, name := range names {
+				fmt.Fprintln(cmd.OutOrStdout(), name)
+			}
+			return nil
+		},
+	}
+}
+
+func newTemplateAddCmd(templateUC *internal.TemplateUseCase) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <name> [file]",
+		Short: "Add a template from a file or stdin",
+		Args:  cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+
+			var data []byte
+			var err error
+			if len(args) == 2 {
+				data, err = os.ReadFile(args[1])
+			} else {
+				data, err = io.ReadAll(cmd.InOrStdin())
+			}
+			if err != nil {
+				return fmt.Errorf("read template: %w", err)
+			}
+
+			if err := templateUC.Add(internal.TemplateInput{
+				Name: args[0], Content: string(data), Scope: scopeHint,
+			}); err != nil {
+				return fmt.Errorf("add template: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Added template %s\n", args[0])
+			return nil
+		},
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/4thel00z/memories/pkg/v1/memtest"
+)
+
+func TestNewCmdFromTemplate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resolver := internal.NewScopeResolverAt(memtest.InitStore(t), "")
+	scope, _ := resolver.Project()
+	repo, err := internal.NewGitRepository(scope)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+
+	repoFor := func(s internal.Scope) (internal.MemoryRepository, error) { return repo, nil }
+	histFor := func(s internal.Scope) (internal.HistoryRepository, error) { return repo, nil }
+	nilIndex := func(s internal.Scope) (internal.VectorIndex, error) { return nil, internal.ErrNoIndex }
+	getUC := internal.NewGetMemoryUseCase(resolver, repoFor)
+	setUC := internal.NewSetMemoryUseCase(resolver, repoFor, nilIndex, nil, nil)
+	commitUC := internal.NewCommitUseCase(resolver, histFor)
+	templateUC := internal.NewTemplateUseCase(resolver)
+
+	add := NewTemplateCmd(templateUC)
+	add.SetArgs([]string{"add", "adr"})
+	add.SetIn(strings.NewReader("# ADR {{.Key}}\nstatus: {{.status}}\n"))
+	add.SetOut(&bytes.Buffer{})
+	if err := add.Execute(); err != nil {
+		t.Fatalf("template add: %v", err)
+	}
+
+	// Editor leaves the rendered content untouched.
+	editorScript := filepath.Join(t.TempDir(), "editor.sh")
+	if err := os.WriteFile(editorScript, []byte("#!/bin/sh\nexit 0\n"), 0755); err != nil {
+		t.Fatalf("write editor script: %v", err)
+	}
+	t.Setenv("EDITOR", editorScript)
+	stubTerminal(t, true)
+
+	cmd := NewNewCmd(templateUC, getUC, setUC, commitUC, nil)
+	cmd.SetArgs([]string{"--template", "adr", "--var", "status=proposed", "notes/adr/0005"})
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("new: %v", err)
+	}
+
+	mem, err := repo.Get(context.Background(), internal.Key("notes/adr/0005"))
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if want := "# ADR notes/adr/0005\nstatus: proposed\n"; string(mem.Content) != want {
+		t.Errorf("content = %q, want %q", mem.Content, want)
+	}
+
+	missing := NewNewCmd(templateUC, getUC, setUC, commitUC, nil)
+	missing.SetArgs([]string{"--template", "rfc", "notes/rfc"})
+	missing.SetOut(&bytes.Buffer{})
+	missing.SetErr(&bytes.Buffer{})
+	err = missing.Execute()
+	if err == nil || !strings.Contains(err.Error(), "available: adr") {
+		t.Errorf("err = %v, want listing of available templates", err)
+	}
+}
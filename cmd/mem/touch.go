@@ -0,0 +1,42 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewTouchCmd(touchUC *internal.TouchUseCase, aliasUC *internal.AliasUseCase) *cobra.Command {
+	return &cobra.Command{
+		Use:   "touch <key>",
+		Short: "Bump a memory to now without changing it",
+		Long: `Rewrite a memory with its current content so its updated time is now,
+and record a "touch: <key>" commit even though nothing changed. Useful when
+ranking by recency. Fails if the memory does not exist.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			key, err := resolveKeyArg(cmd, aliasUC, args[0])
+			if err != nil {
+				return err
+			}
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			out, err := touchUC.Execute(cmd.Context(), internal.TouchInput{Key: key, Scope: scopeHint})
+			if errors.Is(err, internal.ErrNotFound) {
+				return fmt.Errorf("touch: %s: %w", key, err)
+			}
+			if err != nil {
+				return fmt.Errorf("touch: %w", err)
+			}
+
+			if asJSON {
+				return outputWriteResultJSON(cmd, writeResultJSON("touch", key, "touched", out))
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "[%s] %s\n", out.Hash[:7], out.Message)
+			return nil
+		},
+	}
+}
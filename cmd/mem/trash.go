@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewTrashCmd(trashUC *internal.TrashUseCase, commitUC *internal.CommitUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "Manage soft-deleted memories",
+		Long:  `List or empty memories moved to the trash with mem del --soft.`,
+	}
+
+	cmd.AddCommand(
+		newTrashListCmd(trashUC),
+		newTrashEmptyCmd(trashUC, commitUC),
+	)
+
+	return cmd
+}
+
+func newTrashListCmd(trashUC *internal.TrashUseCase) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List trashed memories",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			keys, err := trashUC.List(cmd.Context(), internal.TrashInput{Scope: scopeHint})
+			if err != nil {
+				return fmt.Errorf("list trash: %w", err)
+			}
+
+			if asJSON {
+				if keys == nil {
+					keys = []string{}
+				}
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(map[string]any{"keys": keys})
+			}
+
+			if len(keys) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "Trash is empty.")
+				return nil
+			}
+			for _, k := range keys {
+				fmt.Fprintln(cmd.OutOrStdout(), k)
+			}
+			return nil
+		},
+	}
+}
+
+func newTrashEmptyCmd(trashUC *internal.TrashUseCase, commitUC *internal.CommitUseCase) *cobra.Command {
+	return &cobra.Command{
+		Use:   "empty",
+		Short: "Permanently delete all trashed memories",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+
+			keys, err := trashUC.Empty(cmd.Context(), internal.TrashInput{Scope: scopeHint})
+			if err != nil {
+				return fmt.Errorf("empty trash: %w", err)
+			}
+
+			if len(keys) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "Trash is empty.")
+				return nil
+			}
+			if _, err := autoCommit(cmd.Context(), commitUC, "", "trash", "empty", scopeHint); err != nil {
+				return fmt.Errorf("commit: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Deleted %d trashed memories\n", len(keys))
+			return nil
+		},
+	}
+}
+
+func NewRestoreTrashCmd(trashUC *internal.TrashUseCase, commitUC *internal.CommitUseCase, aliasUC *internal.AliasUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore-trash <key>",
+		Short: "Restore a memory from the trash",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			message, _ := cmd.Flags().GetString("message")
+
+			key, err := resolveKeyArg(cmd, aliasUC, args[0])
+			if err != nil {
+				return err
+			}
+
+			if err := trashUC.Restore(cmd.Context(), internal.TrashInput{Key: key, Scope: scopeHint}); err != nil {
+				return fmt.Errorf("restore: %w", err)
+			}
+			if _, err := autoCommit(cmd.Context(), commitUC, message, "restore", key, scopeHint); err != nil {
+				return fmt.Errorf("commit: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Restored %s\n", key)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP("message", "m", "", "Commit message")
+	return cmd
+}
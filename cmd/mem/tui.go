@@ -0,0 +1,665 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/4thel00z/memories/internal"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/cobra"
+)
+
+func NewTuiCmd(
+	listUC *internal.ListMemoriesUseCase,
+	getUC *internal.GetMemoryUseCase,
+	setUC *internal.SetMemoryUseCase,
+	delUC *internal.DeleteMemoryUseCase,
+	commitUC *internal.CommitUseCase,
+	keywordUC *internal.KeywordSearchUseCase,
+	semanticUC *internal.SemanticSearchUseCase,
+) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tui",
+		Short: "Browse memories interactively",
+		Long: `Browse the store in a terminal UI: the key tree on the left, the selected
+memory on the right.
+
+  j/k, ↑/↓  move            /  filter keys
+  e         edit in $EDITOR  s  search
+  d         delete           esc  clear filter or search
+  q         quit
+
+Edits and deletes auto-commit like mem edit and mem del.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			semantic, _ := cmd.Flags().GetBool("semantic")
+
+			backend := newUseCaseBackend(listUC, getUC, setUC, delUC, commitUC, keywordUC, semanticUC, scopeHint, semantic)
+			m := newTuiModel(cmd.Context(), backend, execEditor)
+
+			_, err := tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(cmd.Context())).Run()
+			return err
+		},
+	}
+
+	cmd.Flags().BoolP("semantic", "s", false, "Use semantic search when searching with s")
+	return cmd
+}
+
+// tuiBackend is what the browser needs from the store. Save and Delete
+// commit their change.
+type tuiBackend interface {
+	List(ctx context.Context) ([]string, error)
+	Get(ctx context.Context, key string) (string, error)
+	Save(ctx context.Context, key, content string) error
+	Delete(ctx context.Context, key string) error
+	Search(ctx context.Context, query string) ([]string, error)
+}
+
+type useCaseBackend struct {
+	listUC     *internal.ListMemoriesUseCase
+	getUC      *internal.GetMemoryUseCase
+	setUC      *internal.SetMemoryUseCase
+	delUC      *internal.DeleteMemoryUseCase
+	commitUC   *internal.CommitUseCase
+	keywordUC  *internal.KeywordSearchUseCase
+	semanticUC *internal.SemanticSearchUseCase
+	scope      string
+	semantic   bool
+}
+
+func newUseCaseBackend(
+	listUC *internal.ListMemoriesUseCase,
+	getUC *internal.GetMemoryUseCase,
+	setUC *internal.SetMemoryUseCase,
+	delUC *internal.DeleteMemoryUseCase,
+	commitUC *internal.CommitUseCase,
+	keywordUC *internal.KeywordSearchUseCase,
+	semanticUC *internal.SemanticSearchUseCase,
+	scope string,
+	semantic bool,
+) *useCaseBackend {
+	return &useCaseBackend{
+		listUC:     listUC,
+		getUC:      getUC,
+		setUC:      setUC,
+		delUC:      delUC,
+		commitUC:   commitUC,
+		keywordUC:  keywordUC,
+		semanticUC: semanticUC,
+		scope:      scope,
+		semantic:   semantic,
+	}
+}
+
+func (b *useCaseBackend) List(ctx context.Context) ([]string, error) {
+	out, err := b.listUC.Execute(ctx, internal.ListMemoriesInput{Scope: b.scope})
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(out.Memories))
+	for i, mem := range out.Memories {
+		keys[i] = mem.Key
+	}
+	return keys, nil
+}
+
+func (b *useCaseBackend) Get(ctx context.Context, key string) (string, error) {
+	out, err := b.getUC.Execute(ctx, internal.GetMemoryInput{Key: key, Scope: b.scope})
+	if err != nil {
+		return "", err
+	}
+	return out.Content, nil
+}
+
+func (b *useCaseBackend) Save(ctx context.Context, key, content string) error {
+	if err := b.setUC.Execute(ctx, internal.SetMemoryInput{Key: key, Content: content, Scope: b.scope}); err != nil {
+		return fmt.Errorf("save memory: %w", err)
+	}
+	if _, err := autoCommit(ctx, b.commitUC, "", "edit", key, b.scope); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+func (b *useCaseBackend) Delete(ctx context.Context, key string) error {
+	if _, err := b.delUC.Execute(ctx, internal.DeleteMemoryInput{Key: key, Scope: b.scope}); err != nil {
+		return fmt.Errorf("delete memory: %w", err)
+	}
+	if _, err := autoCommit(ctx, b.commitUC, "", "del", key, b.scope); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+func (b *useCaseBackend) Search(ctx context.Context, query string) ([]string, error) {
+	var (
+		out *internal.SearchOutput
+		err error
+	)
+	if b.semantic {
+		out, err = b.semanticUC.Execute(ctx, internal.SearchInput{Query: query, Limit: 50, Scope: b.scope})
+	} else {
+		out, err = b.keywordUC.Execute(ctx, internal.SearchInput{Query: query, Scope: b.scope})
+	}
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, len(out.Results))
+	for i, r := range out.Results {
+		keys[i] = r.Key
+	}
+	return keys, nil
+}
+
+// editorFunc opens content for key in an editor and reports the result as
+// an editedMsg.
+type editorFunc func(key, content string) tea.Cmd
+
+// execEditor suspends the program and runs the user's editor on a temp
+// file holding content.
+func execEditor(key, content string) tea.Cmd {
+	argv, err := editorArgv("")
+	if err != nil {
+		return func() tea.Msg { return editedMsg{key: key, err: err} }
+	}
+	tmpFile, err := os.CreateTemp("", "mem-edit-*"+internal.DefaultEditorExtension)
+	if err != nil {
+		return func() tea.Msg { return editedMsg{key: key, err: fmt.Errorf("create temp file: %w", err)} }
+	}
+	path := tmpFile.Name()
+	_, err = tmpFile.WriteString(content)
+	tmpFile.Close()
+	if err != nil {
+		os.Remove(path)
+		return func() tea.Msg { return editedMsg{key: key, err: fmt.Errorf("write temp file: %w", err)} }
+	}
+
+	return tea.ExecProcess(exec.Command(argv[0], append(argv[1:], path)...), func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return editedMsg{key: key, err: fmt.Errorf("editor: %w", err)}
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return editedMsg{key: key, err: fmt.Errorf("read edited file: %w", err)}
+		}
+		return editedMsg{key: key, content: string(data), original: content}
+	})
+}
+
+type tuiMode int
+
+const (
+	modeBrowse tuiMode = iota
+	modeFilter
+	modeSearch
+	modeConfirmDelete
+)
+
+type keysMsg struct {
+	keys []string
+	err  error
+}
+
+type previewMsg struct {
+	key     string
+	content string
+	err     error
+}
+
+type searchMsg struct {
+	query string
+	keys  []string
+	err   error
+}
+
+type editedMsg struct {
+	key      string
+	content  string
+	original string
+	err      error
+}
+
+// doneMsg reports a finished save or delete; the key list is reloaded after.
+type doneMsg struct {
+	status string
+	err    error
+}
+
+// treeRow is one line of the key tree. Directory rows have an empty key.
+type treeRow struct {
+	depth int
+	label string
+	key   string
+}
+
+type tuiModel struct {
+	ctx     context.Context
+	backend tuiBackend
+	editor  editorFunc
+
+	keys    []string // all keys in the store
+	results []string // search results; nil when not searching
+	query   string   // last search query
+	filter  string
+	input   string // text being typed in filter or search mode
+	mode    tuiMode
+
+	rows   []treeRow
+	cursor int
+
+	previewKey string
+	preview    string
+	status     string
+	statusErr  bool
+
+	width  int
+	height int
+}
+
+func newTuiModel(ctx context.Context, backend tuiBackend, editor editorFunc) *tuiModel {
+	return &tuiModel{
+		ctx:     ctx,
+		backend: backend,
+		editor:  editor,
+		width:   80,
+		height:  24,
+	}
+}
+
+var _ tea.Model = (*tuiModel)(nil)
+
+func (m *tuiModel) Init() tea.Cmd {
+	return m.loadKeys()
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case keysMsg:
+		if msg.err != nil {
+			m.setError(fmt.Errorf("list memories: %w", msg.err))
+			return m, nil
+		}
+		m.keys = msg.keys
+		return m, m.refresh()
+
+	case searchMsg:
+		if msg.err != nil {
+			m.setError(fmt.Errorf("search: %w", msg.err))
+			return m, nil
+		}
+		m.query = msg.query
+		m.results = msg.keys
+		if m.results == nil {
+			m.results = []string{}
+		}
+		m.cursor = 0
+		m.setStatus(fmt.Sprintf("%d results for %q", len(msg.keys), msg.query))
+		return m, m.refresh()
+
+	case previewMsg:
+		if msg.key != m.selectedKey() {
+			return m, nil
+		}
+		m.previewKey = msg.key
+		m.preview = msg.content
+		if msg.err != nil {
+			m.preview = ""
+			m.setError(fmt.Errorf("get %s: %w", msg.key, msg.err))
+		}
+		return m, nil
+
+	case editedMsg:
+		if msg.err != nil {
+			m.setError(msg.err)
+			return m, nil
+		}
+		if msg.content == msg.original {
+			m.setStatus("No changes.")
+			return m, nil
+		}
+		return m, m.save(msg.key, msg.content)
+
+	case doneMsg:
+		if msg.err != nil {
+			m.setError(msg.err)
+		} else {
+			m.setStatus(msg.status)
+		}
+		m.previewKey = ""
+		return m, m.loadKeys()
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+func (m *tuiModel) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if msg.Type == tea.KeyCtrlC {
+		return m, tea.Quit
+	}
+
+	switch m.mode {
+	case modeFilter, modeSearch:
+		return m.handleInput(msg)
+	case modeConfirmDelete:
+		m.mode = modeBrowse
+		key := m.selectedKey()
+		if msg.String() != "y" || key == "" {
+			m.setStatus("Delete cancelled.")
+			return m, nil
+		}
+		return m, m.remove(key)
+	}
+
+	switch msg.String() {
+	case "q":
+		return m, tea.Quit
+	case "j", "down":
+		return m, m.move(1)
+	case "k", "up":
+		return m, m.move(-1)
+	case "/":
+		m.mode = modeFilter
+		m.input = m.filter
+	case "s":
+		m.mode = modeSearch
+		m.input = ""
+	case "esc":
+		if m.results != nil {
+			m.results = nil
+			m.query = ""
+		} else {
+			m.filter = ""
+		}
+		m.cursor = 0
+		m.setStatus("")
+		return m, m.refresh()
+	case "e":
+		key := m.selectedKey()
+		if key == "" {
+			return m, nil
+		}
+		if m.previewKey != key {
+			m.setError(fmt.Errorf("%s is still loading", key))
+			return m, nil
+		}
+		return m, m.editor(key, m.preview)
+	case "d":
+		if key := m.selectedKey(); key != "" {
+			m.mode = modeConfirmDelete
+			m.setStatus(fmt.Sprintf("Delete %s? (y/n)", key))
+		}
+	}
+	return m, nil
+}
+
+func (m *tuiModel) handleInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = modeBrowse
+		m.input = ""
+		return m, nil
+	case tea.KeyEnter:
+		mode := m.mode
+		m.mode = modeBrowse
+		if mode == modeSearch {
+			if strings.TrimSpace(m.input) == "" {
+				return m, nil
+			}
+			return m, m.search(m.input)
+		}
+		m.filter = m.input
+		m.cursor = 0
+		return m, m.refresh()
+	case tea.KeyBackspace:
+		if r := []rune(m.input); len(r) > 0 {
+			m.input = string(r[:len(r)-1])
+		}
+	case tea.KeyRunes, tea.KeySpace:
+		m.input += string(msg.Runes)
+	}
+
+	if m.mode == modeFilter {
+		m.filter = m.input
+		m.cursor = 0
+		return m, m.refresh()
+	}
+	return m, nil
+}
+
+// refresh rebuilds the rows and loads the preview for the selection.
+func (m *tuiModel) refresh() tea.Cmd {
+	source := m.keys
+	if m.results != nil {
+		source = m.results
+	}
+
+	var visible []string
+	for _, key := range source {
+		if m.filter == "" || strings.Contains(key, m.filter) {
+			visible = append(visible, key)
+		}
+	}
+
+	if m.results != nil {
+		// Search results keep their rank order and are shown flat.
+		m.rows = make([]treeRow, len(visible))
+		for i, key := range visible {
+			m.rows[i] = treeRow{label: key, key: key}
+		}
+	} else {
+		m.rows = buildTree(visible)
+	}
+
+	if m.cursor >= len(m.rows) {
+		m.cursor = len(m.rows) - 1
+	}
+	if m.cursor < 0 {
+		m.cursor = 0
+	}
+	if m.selectedKey() == "" {
+		return m.move(1)
+	}
+	return m.loadPreview()
+}
+
+// move steps the cursor by delta, skipping directory rows.
+func (m *tuiModel) move(delta int) tea.Cmd {
+	for i := m.cursor + delta; i >= 0 && i < len(m.rows); i += delta {
+		if m.rows[i].key != "" {
+			m.cursor = i
+			break
+		}
+	}
+	return m.loadPreview()
+}
+
+func (m *tuiModel) selectedKey() string {
+	if m.cursor < 0 || m.cursor >= len(m.rows) {
+		return ""
+	}
+	return m.rows[m.cursor].key
+}
+
+func (m *tuiModel) setStatus(s string) {
+	m.status = s
+	m.statusErr = false
+}
+
+func (m *tuiModel) setError(err error) {
+	m.status = err.Error()
+	m.statusErr = true
+}
+
+func (m *tuiModel) loadKeys() tea.Cmd {
+	return func() tea.Msg {
+		keys, err := m.backend.List(m.ctx)
+		return keysMsg{keys: keys, err: err}
+	}
+}
+
+func (m *tuiModel) loadPreview() tea.Cmd {
+	key := m.selectedKey()
+	if key == "" || key == m.previewKey {
+		return nil
+	}
+	return func() tea.Msg {
+		content, err := m.backend.Get(m.ctx, key)
+		return previewMsg{key: key, content: content, err: err}
+	}
+}
+
+func (m *tuiModel) search(query string) tea.Cmd {
+	return func() tea.Msg {
+		keys, err := m.backend.Search(m.ctx, query)
+		return searchMsg{query: query, keys: keys, err: err}
+	}
+}
+
+func (m *tuiModel) save(key, content string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.backend.Save(m.ctx, key, content); err != nil {
+			return doneMsg{err: err}
+		}
+		return doneMsg{status: fmt.Sprintf("Updated %s", key)}
+	}
+}
+
+func (m *tuiModel) remove(key string) tea.Cmd {
+	return func() tea.Msg {
+		if err := m.backend.Delete(m.ctx, key); err != nil {
+			return doneMsg{err: err}
+		}
+		return doneMsg{status: fmt.Sprintf("Deleted %s", key)}
+	}
+}
+
+var (
+	cursorStyle = lipgloss.NewStyle().Reverse(true)
+	dirStyle    = lipgloss.NewStyle().Faint(true)
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	paneStyle   = lipgloss.NewStyle().Border(lipgloss.NormalBorder())
+)
+
+func (m *tuiModel) View() string {
+	bodyHeight := max(m.height-4, 1) // borders plus status line
+	leftWidth := max(m.width/3, 10)
+	rightWidth := max(m.width-leftWidth-4, 10)
+
+	left := paneStyle.Width(leftWidth).Height(bodyHeight).Render(m.viewTree(leftWidth, bodyHeight))
+	right := paneStyle.Width(rightWidth).Height(bodyHeight).Render(m.viewPreview(rightWidth, bodyHeight))
+
+	return lipgloss.JoinHorizontal(lipgloss.Top, left, right) + "\n" + m.viewStatus()
+}
+
+func (m *tuiModel) viewTree(width, height int) string {
+	if len(m.rows) == 0 {
+		return dirStyle.Render("no memories")
+	}
+
+	// Scroll so the cursor stays visible.
+	start := 0
+	if m.cursor >= height {
+		start = m.cursor - height + 1
+	}
+	end := min(start+height, len(m.rows))
+
+	lines := make([]string, 0, end-start)
+	for i := start; i < end; i++ {
+		row := m.rows[i]
+		line := truncate(strings.Repeat("  ", row.depth)+row.label, width)
+		switch {
+		case i == m.cursor:
+			line = cursorStyle.Render(line)
+		case row.key == "":
+			line = dirStyle.Render(line)
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *tuiModel) viewPreview(width, height int) string {
+	if m.previewKey == "" || m.previewKey != m.selectedKey() {
+		return ""
+	}
+	lines := strings.Split(m.preview, "\n")
+	if len(lines) > height {
+		lines = lines[:height]
+	}
+	for i, line := range lines {
+		lines[i] = truncate(line, width)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func (m *tuiModel) viewStatus() string {
+	switch m.mode {
+	case modeFilter:
+		return "/" + m.input
+	case modeSearch:
+		return "search: " + m.input
+	}
+
+	if m.statusErr {
+		return errorStyle.Render(m.status)
+	}
+	if m.status != "" {
+		return m.status
+	}
+	if m.filter != "" {
+		return fmt.Sprintf("filter: %s", m.filter)
+	}
+	return "j/k move  / filter  s search  e edit  d delete  q quit"
+}
+
+// buildTree turns sorted keys into tree rows, emitting a directory row the
+// first time each path prefix appears.
+func buildTree(keys []string) []treeRow {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+
+	var rows []treeRow
+	var prev []string
+	for _, key := range sorted {
+		parts := strings.Split(key, "/")
+		dirs := parts[:len(parts)-1]
+
+		shared := 0
+		for shared < len(dirs) && shared < len(prev) && dirs[shared] == prev[shared] {
+			shared++
+		}
+		for i := shared; i < len(dirs); i++ {
+			rows = append(rows, treeRow{depth: i, label: dirs[i] + "/"})
+		}
+
+		rows = append(rows, treeRow{depth: len(dirs), label: parts[len(parts)-1], key: key})
+		prev = dirs
+	}
+	return rows
+}
+
+func truncate(s string, width int) string {
+	r := []rune(s)
+	if len(r) <= width {
+		return s
+	}
+	if width <= 1 {
+		return string(r[:width])
+	}
+	return string(r[:width-1]) + "…"
+}
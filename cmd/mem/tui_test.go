@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type fakeTuiBackend struct {
+	memories  map[string]string
+	deleteErr error
+	saved     []string
+	deleted   []string
+}
+
+func (f *fakeTuiBackend) List(context.Context) ([]string, error) {
+	keys := make([]string, 0, len(f.memories))
+	for k := range f.memories {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (f *fakeTuiBackend) Get(_ context.Context, key string) (string, error) {
+	content, ok := f.memories[key]
+	if !ok {
+		return "", errors.New("not found")
+	}
+	return content, nil
+}
+
+func (f *fakeTuiBackend) Save(_ context.Context, key, content string) error {
+	f.memories[key] = content
+	f.saved = append(f.saved, key)
+	return nil
+}
+
+func (f *fakeTuiBackend) Delete(_ context.Context, key string) error {
+	if f.deleteErr != nil {
+		return f.deleteErr
+	}
+	delete(f.memories, key)
+	f.deleted = append(f.deleted, key)
+	return nil
+}
+
+func (f *fakeTuiBackend) Search(_ context.Context, query string) ([]string, error) {
+	var keys []string
+	for k, v := range f.memories {
+		if strings.Contains(v, query) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func newFakeTuiBackend() *fakeTuiBackend {
+	return &fakeTuiBackend{memories: map[string]string{
+		"notes/meeting": "quarterly targets",
+		"notes/todo":    "buy milk",
+		"readme":        "project readme",
+	}}
+}
+
+// drive runs cmd and feeds every resulting message back into the model,
+// like the tea runtime would, until no command is left.
+func drive(t *testing.T, m *tuiModel, cmd tea.Cmd) {
+	t.Helper()
+	for i := 0; cmd != nil; i++ {
+		if i > 20 {
+			t.Fatal("too many commands")
+		}
+		msg := cmd()
+		if _, ok := msg.(tea.QuitMsg); ok {
+			return
+		}
+		_, cmd = m.Update(msg)
+	}
+}
+
+func press(t *testing.T, m *tuiModel, keys ...string) {
+	t.Helper()
+	for _, k := range keys {
+		var msg tea.KeyMsg
+		switch k {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+		}
+		_, cmd := m.Update(msg)
+		drive(t, m, cmd)
+	}
+}
+
+func startTui(t *testing.T, backend tuiBackend, editor editorFunc) *tuiModel {
+	t.Helper()
+	m := newTuiModel(context.Background(), backend, editor)
+	drive(t, m, m.Init())
+	return m
+}
+
+func TestTuiLoadsTreeAndPreview(t *testing.T) {
+	m := startTui(t, newFakeTuiBackend(), nil)
+
+	if got := m.selectedKey(); got != "notes/meeting" {
+		t.Fatalf("selected = %q, want notes/meeting", got)
+	}
+	view := m.View()
+	for _, want := range []string{"notes/", "meeting", "todo", "readme", "quarterly targets"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expected %q in view:\n%s", want, view)
+		}
+	}
+
+	press(t, m, "j")
+	if got := m.selectedKey(); got != "notes/todo" {
+		t.Errorf("selected after j = %q, want notes/todo", got)
+	}
+	if !strings.Contains(m.View(), "buy milk") {
+		t.Errorf("preview not updated:\n%s", m.View())
+	}
+}
+
+func TestTuiFilter(t *testing.T) {
+	m := startTui(t, newFakeTuiBackend(), nil)
+
+	press(t, m, "/", "r", "e", "a", "d", "enter")
+
+	if len(m.rows) != 1 || m.selectedKey() != "readme" {
+		t.Errorf("rows = %+v, want only readme", m.rows)
+	}
+
+	press(t, m, "esc")
+	if m.filter != "" || len(m.rows) != 4 {
+		t.Errorf("esc should clear the filter, rows = %+v", m.rows)
+	}
+}
+
+func TestTuiSearch(t *testing.T) {
+	m := startTui(t, newFakeTuiBackend(), nil)
+
+	press(t, m, "s", "m", "i", "l", "k", "enter")
+
+	if len(m.rows) != 1 || m.selectedKey() != "notes/todo" {
+		t.Errorf("rows = %+v, want only notes/todo", m.rows)
+	}
+	if !strings.Contains(m.View(), `1 results for "milk"`) {
+		t.Errorf("expected result count in status:\n%s", m.View())
+	}
+}
+
+func TestTuiDeleteConfirm(t *testing.T) {
+	backend := newFakeTuiBackend()
+	m := startTui(t, backend, nil)
+
+	press(t, m, "d", "n")
+	if len(backend.deleted) != 0 {
+		t.Fatalf("n should cancel, deleted %v", backend.deleted)
+	}
+
+	press(t, m, "d", "y")
+	if len(backend.deleted) != 1 || backend.deleted[0] != "notes/meeting" {
+		t.Fatalf("deleted = %v, want notes/meeting", backend.deleted)
+	}
+	for _, r := range m.rows {
+		if r.key == "notes/meeting" {
+			t.Errorf("deleted key still in tree: %+v", m.rows)
+		}
+	}
+	if !strings.Contains(m.View(), "Deleted notes/meeting") {
+		t.Errorf("expected status message:\n%s", m.View())
+	}
+}
+
+func TestTuiDeleteErrorInStatusBar(t *testing.T) {
+	backend := newFakeTuiBackend()
+	backend.deleteErr = errors.New("disk on fire")
+	m := startTui(t, backend, nil)
+
+	press(t, m, "d", "y")
+
+	if !m.statusErr || !strings.Contains(m.View(), "disk on fire") {
+		t.Errorf("expected error in status bar:\n%s", m.View())
+	}
+	if len(m.rows) != 4 {
+		t.Errorf("tree should be unchanged, rows = %+v", m.rows)
+	}
+}
+
+func TestTuiEditSaves(t *testing.T) {
+	backend := newFakeTuiBackend()
+	editor := func(key, content string) tea.Cmd {
+		return func() tea.Msg {
+			return editedMsg{key: key, content: content + " and eggs", original: content}
+		}
+	}
+	m := startTui(t, backend, editor)
+
+	press(t, m, "j", "e")
+
+	if len(backend.saved) != 1 || backend.memories["notes/todo"] != "buy milk and eggs" {
+		t.Errorf("saved = %v, memories = %v", backend.saved, backend.memories)
+	}
+	if !strings.Contains(m.View(), "buy milk and eggs") {
+		t.Errorf("preview should show the edit:\n%s", m.View())
+	}
+}
+
+func TestBuildTree(t *testing.T) {
+	rows := buildTree([]string{"b/y/z", "a", "b/x"})
+
+	var got []string
+	for _, r := range rows {
+		got = append(got, strings.Repeat(" ", r.depth)+r.label)
+	}
+	want := []string{"a", "b/", " x", " y/", "  z"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("tree = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewVerifyCmd(verifyUC *internal.VerifyUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check the store and search index for drift",
+		Long: `Cross-check the memories in the store against the search index and report
+index entries whose memory is gone and memories that were never indexed.
+Uncommitted changes and a stale index are reported too. --fix removes the
+orphaned entries and embeds the missing memories; commit or rebuild for the
+rest. Exits non-zero while problems remain.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			scopeHint, _ := cmd.Flags().GetString("scope")
+			fix, _ := cmd.Flags().GetBool("fix")
+			asJSON, _ := cmd.Flags().GetBool("json")
+
+			out, err := verifyUC.Execute(cmd.Context(), internal.VerifyInput{Scope: scopeHint, Fix: fix})
+			if err != nil {
+				return fmt.Errorf("verify: %w", err)
+			}
+
+			if asJSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(map[string]any{
+					"memories":    out.Memories,
+					"indexed":     out.Indexed,
+					"orphaned":    emptyIfNil(out.Orphaned),
+					"unindexed":   emptyIfNil(out.Unindexed),
+					"uncommitted": emptyIfNil(out.Uncommitted),
+					"stale":       out.Stale,
+					"fixed":       out.Fixed,
+				}); err != nil {
+					return err
+				}
+			} else {
+				printVerify(cmd, out)
+			}
+
+			if n := out.Problems(); n > 0 {
+				return fmt.Errorf("verify: %d problems found", n)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().Bool("fix", false, "Remove orphaned index entries and index missing memories")
+	return cmd
+}
+
+func printVerify(cmd *cobra.Command, out *internal.VerifyOutput) {
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "%d memories, %d indexed\n", out.Memories, out.Indexed)
+
+	verb := ""
+	if out.Fixed {
+		verb = " (fixed)"
+	}
+	printKeys := func(title string, keys []string) {
+		if len(keys) == 0 {
+			return
+		}
+		fmt.Fprintf(w, "\n%s:\n", title)
+		for _, k := range keys {
+			fmt.Fprintf(w, "  %s\n", k)
+		}
+	}
+	printKeys("Orphaned index entries"+verb, out.Orphaned)
+	printKeys("Unindexed memories"+verb, out.Unindexed)
+	printKeys("Uncommitted changes (run `mem commit`)", out.Uncommitted)
+	if out.Stale {
+		fmt.Fprintln(w, "\nThe index is marked stale; run `mem index rebuild`.")
+	}
+
+	if out.Problems() == 0 {
+		fmt.Fprintln(w, "\nOK")
+	}
+}
+
+// emptyIfNil keeps empty lists as [] rather than null in JSON output.
+func emptyIfNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
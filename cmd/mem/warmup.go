@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/4thel00z/memories/internal"
+	"github.com/spf13/cobra"
+)
+
+func NewWarmupCmd(warmupUC *internal.WarmupUseCase) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "warmup",
+		Short: "Preload the embedder and search index",
+		Long:  `Load the embedding model and vector index up front so the first semantic search responds immediately.`,
+		Args:  cobra.NoArgs,
+		RunE:  makeWarmupRunner(warmupUC),
+	}
+
+	return cmd
+}
+
+func makeWarmupRunner(warmupUC *internal.WarmupUseCase) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, _ []string) error {
+		scopeHint, _ := cmd.Flags().GetString("scope")
+		asJSON, _ := cmd.Flags().GetBool("json")
+
+		out, err := warmupUC.Execute(cmd.Context(), internal.WarmupInput{Scope: scopeHint})
+		if err != nil {
+			return fmt.Errorf("warmup: %w", err)
+		}
+
+		if asJSON {
+			enc := json.NewEncoder(cmd.OutOrStdout())
+			enc.SetIndent("", "  ")
+			return enc.Encode(map[string]any{
+				"device":    out.Device,
+				"dimension": out.Dimension,
+			})
+		}
+
+		fmt.Fprintf(cmd.OutOrStdout(), "Embedder ready (device: %s, dimension: %d)\n",
+			strings.ToUpper(out.Device), out.Dimension)
+		return nil
+	}
+}
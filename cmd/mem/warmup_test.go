@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/4thel00z/memories/internal"
+)
+
+type stubEmbedder struct {
+	dim    int
+	device string
+}
+
+func (s *stubEmbedder) Embed(_ context.Context, _ string) ([]float32, error) {
+	return make([]float32, s.dim), nil
+}
+
+func (s *stubEmbedder) EmbedBatch(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		out[i] = make([]float32, s.dim)
+	}
+	return out, nil
+}
+
+func (s *stubEmbedder) Dimension() int { return s.dim }
+func (s *stubEmbedder) Device() string { return s.device }
+func (s *stubEmbedder) Model() string  { return "stub.gguf" }
+func (s *stubEmbedder) Close() error   { return nil }
+
+func TestWarmupCmdNoEmbedder(t *testing.T) {
+	resolver := internal.NewScopeResolver()
+	nilIndex := func(s internal.Scope) (internal.VectorIndex, error) { return nil, internal.ErrNoIndex }
+
+	cmd := NewWarmupCmd(internal.NewWarmupUseCase(resolver, nilIndex, nil))
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+
+	if err := cmd.Execute(); err == nil {
+		t.Error("expected error for warmup without embedder")
+	}
+}
+
+func TestWarmupCmd(t *testing.T) {
+	resolver := internal.NewScopeResolver()
+	vecDir := t.TempDir()
+	indexFor := func(s internal.Scope) (internal.VectorIndex, error) {
+		return internal.NewAnnoyIndex(vecDir, 3)
+	}
+
+	cmd := NewWarmupCmd(internal.NewWarmupUseCase(resolver, indexFor, internal.StaticEmbedder(&stubEmbedder{dim: 3, device: "cpu"})))
+
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	if err := cmd.Execute(); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	if !strings.Contains(out.String(), "CPU") || !strings.Contains(out.String(), "dimension: 3") {
+		t.Errorf("unexpected output: %q", out.String())
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -12,28 +13,27 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func NewWatchCmd(commitUC *internal.CommitUseCase) *cobra.Command {
+func NewWatchCmd(resolver *internal.ScopeResolver, commitUC *internal.CommitUseCase) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "watch",
 		Short: "Watch for changes and auto-commit",
 		Long:  `Watch the memory store for file changes and automatically commit them.`,
-		RunE:  makeWatchRunner(commitUC),
+		RunE:  makeWatchRunner(resolver, commitUC),
 	}
 
 	cmd.Flags().Duration("debounce", 500*time.Millisecond, "Debounce window for batching changes")
 	return cmd
 }
 
-func makeWatchRunner(commitUC *internal.CommitUseCase) func(*cobra.Command, []string) error {
+func makeWatchRunner(resolver *internal.ScopeResolver, commitUC *internal.CommitUseCase) func(*cobra.Command, []string) error {
 	return func(cmd *cobra.Command, _ []string) error {
 		scopeHint, _ := cmd.Flags().GetString("scope")
 		debounce, _ := cmd.Flags().GetDuration("debounce")
 
-		resolver := internal.NewScopeResolver()
 		scope := resolver.Resolve(scopeHint)
 
 		if _, err := os.Stat(scope.MemPath); os.IsNotExist(err) {
-			return fmt.Errorf("not initialized: %s", scope.MemPath)
+			return &internal.NotInitializedError{Path: scope.MemPath}
 		}
 
 		watcher, err := fsnotify.NewWatcher()
@@ -79,7 +79,11 @@ func makeWatchRunner(commitUC *internal.CommitUseCase) func(*cobra.Command, []st
 				out, commitErr := commitUC.Execute(cmd.Context(), internal.CommitInput{
 					Message: "auto: watch commit", Scope: scopeHint,
 				})
+				if errors.Is(commitErr, internal.ErrNothingToCommit) {
+					continue
+				}
 				if commitErr != nil {
+					fmt.Fprintf(cmd.ErrOrStderr(), "commit error: %v\n", commitErr)
 					continue
 				}
 				fmt.Fprintf(cmd.OutOrStdout(), "[%s] %s\n", out.Hash[:7], out.Message)
@@ -0,0 +1,310 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// AliasPrefix marks a key argument as an alias reference, e.g. "@deploy".
+const AliasPrefix = "@"
+
+// MaxAliasHops bounds how many aliases Resolve follows from one name.
+const MaxAliasHops = 8
+
+var (
+	ErrAliasNotFound = errors.New("alias not found")
+	ErrAliasCycle    = errors.New("alias cycle")
+	ErrAliasTooDeep  = errors.New("alias chain too long")
+	// ErrAliasMemoryContent refuses a write whose content would read back
+	// as an alias memory and silently redirect gets to another key.
+	ErrAliasMemoryContent = errors.New("content reads as an alias memory")
+)
+
+var aliasNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+type AliasInput struct {
+	Name  string
+	Key   string
+	Scope string
+}
+
+// --- AliasUseCase ---
+
+// AliasUseCase manages the aliases map in config.yaml. Resolution cascades
+// from project to global scope unless a scope is given explicitly.
+type AliasUseCase struct {
+	resolver *ScopeResolver
+}
+
+func NewAliasUseCase(resolver *ScopeResolver) *AliasUseCase {
+	return &AliasUseCase{resolver: resolver}
+}
+
+// List returns all aliases visible from the given scope. Project aliases
+// shadow global ones with the same name.
+func (uc *AliasUseCase) List(input AliasInput) (map[string]string, error) {
+	return visibleAliases(uc.resolver, input.Scope)
+}
+
+// visibleAliases merges the aliases of the scope named by scopeHint, or of
+// every scope in the cascade, with nearer scopes shadowing farther ones.
+func visibleAliases(resolver *ScopeResolver, scopeHint string) (map[string]string, error) {
+	aliases := make(map[string]string)
+
+	scopes := resolver.Cascade()
+	if scopeHint != "" {
+		scopes = []Scope{resolver.Resolve(scopeHint)}
+	}
+	for i := len(scopes) - 1; i >= 0; i-- {
+		cfg, err := LoadConfig(scopes[i])
+		if err != nil {
+			return nil, err
+		}
+		for name, key := range cfg.Aliases {
+			aliases[name] = key
+		}
+	}
+	return aliases, nil
+}
+
+// Add points an alias at a key, or at another alias written as "@name",
+// in the resolved scope. An alias that would lead back to itself is
+// refused.
+func (uc *AliasUseCase) Add(input AliasInput) error {
+	name := strings.TrimPrefix(input.Name, AliasPrefix)
+	if !aliasNamePattern.MatchString(name) {
+		return fmt.Errorf("invalid alias name %q", input.Name)
+	}
+
+	target := input.Key
+	if next, ok := strings.CutPrefix(target, AliasPrefix); ok {
+		if !aliasNamePattern.MatchString(next) {
+			return fmt.Errorf("invalid alias name %q", target)
+		}
+		aliases, err := uc.List(input)
+		if err != nil {
+			return err
+		}
+		aliases[name] = target
+		if _, err := followAliases(aliases, name); errors.Is(err, ErrAliasCycle) {
+			return err
+		}
+	} else {
+		key, err := NewKey(target)
+		if err != nil {
+			return err
+		}
+		target = key.String()
+	}
+
+	scope := uc.resolver.Resolve(input.Scope)
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Aliases == nil {
+		cfg.Aliases = make(map[string]string)
+	}
+	cfg.Aliases[name] = target
+	return SaveConfig(scope, cfg)
+}
+
+// Remove deletes an alias from the resolved scope.
+func (uc *AliasUseCase) Remove(input AliasInput) error {
+	name := strings.TrimPrefix(input.Name, AliasPrefix)
+
+	scope := uc.resolver.Resolve(input.Scope)
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := cfg.Aliases[name]; !ok {
+		return fmt.Errorf("%w: %q", ErrAliasNotFound, name)
+	}
+	delete(cfg.Aliases, name)
+	return SaveConfig(scope, cfg)
+}
+
+// Resolve expands a leading "@name" to its configured key, following
+// aliases of aliases. Anything else is returned unchanged.
+func (uc *AliasUseCase) Resolve(input AliasInput) (string, error) {
+	return resolveAliasKey(uc.resolver, input.Scope, input.Name)
+}
+
+// resolveAliasKey is Resolve for the use cases that take a key, so every
+// caller, not only the CLI, accepts "@name" wherever a key goes.
+func resolveAliasKey(resolver *ScopeResolver, scopeHint, key string) (string, error) {
+	name, ok := strings.CutPrefix(key, AliasPrefix)
+	if !ok {
+		return key, nil
+	}
+
+	aliases, err := visibleAliases(resolver, scopeHint)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := aliases[name]; ok {
+		return followAliases(aliases, name)
+	}
+
+	if len(aliases) == 0 {
+		return "", fmt.Errorf("%w: %q (no aliases defined)", ErrAliasNotFound, name)
+	}
+	names := make([]string, 0, len(aliases))
+	for n := range aliases {
+		names = append(names, AliasPrefix+n)
+	}
+	sort.Strings(names)
+	return "", fmt.Errorf("%w: %q (available: %s)", ErrAliasNotFound, name, strings.Join(names, ", "))
+}
+
+// aliasedKey parses key after expanding a leading "@name".
+func aliasedKey(resolver *ScopeResolver, scopeHint, key string) (Key, error) {
+	name, err := resolveAliasKey(resolver, scopeHint, key)
+	if err != nil {
+		return "", err
+	}
+	return NewKey(name)
+}
+
+// aliasNotFound is ErrNotFound for key, naming the alias asked for when
+// key was reached through one.
+func aliasNotFound(asked, key string) error {
+	if asked != key {
+		return fmt.Errorf("alias %s points to missing key %q: %w", asked, key, ErrNotFound)
+	}
+	return ErrNotFound
+}
+
+// followAliases resolves name through aliases until it reaches a key.
+func followAliases(aliases map[string]string, name string) (string, error) {
+	path := []string{AliasPrefix + name}
+	for {
+		target, ok := aliases[name]
+		if !ok {
+			return "", fmt.Errorf("%w: %q (via %s)", ErrAliasNotFound, name, strings.Join(path[:len(path)-1], " -> "))
+		}
+		next, isAlias := strings.CutPrefix(target, AliasPrefix)
+		if !isAlias {
+			return target, nil
+		}
+
+		var err error
+		if path, err = aliasHop(path, target); err != nil {
+			return "", err
+		}
+		name = next
+	}
+}
+
+// AliasMemoryMarker starts the content of an alias memory: a memory that
+// stands in for another key of the same store, such as the ones mem dedupe
+// --identical leaves behind. Getting it returns the target instead.
+const AliasMemoryMarker = "mem-alias: "
+
+// aliasMemoryContent returns the content of an alias memory for target.
+func aliasMemoryContent(target Key) []byte {
+	return []byte(AliasMemoryMarker + target.String() + "\n")
+}
+
+// aliasMemoryTarget returns the key an alias memory points at, and false
+// for ordinary content.
+func aliasMemoryTarget(content []byte) (Key, bool) {
+	rest, ok := strings.CutPrefix(string(content), AliasMemoryMarker)
+	if !ok {
+		return "", false
+	}
+	key, err := NewKey(strings.TrimSuffix(rest, "\n"))
+	return key, err == nil
+}
+
+// checkAliasMemoryContent refuses content that parses as an alias memory.
+// Only mem dedupe writes those, straight to the repository.
+func checkAliasMemoryContent(key Key, content []byte) error {
+	if target, ok := aliasMemoryTarget(content); ok {
+		return fmt.Errorf("%w: %s would redirect to %s", ErrAliasMemoryContent, key, target)
+	}
+	return nil
+}
+
+// followAliasMemory returns the memory mem points at if it is an alias
+// memory, following alias memories of alias memories, and mem otherwise.
+func followAliasMemory(ctx context.Context, repo MemoryRepository, mem *Memory) (*Memory, error) {
+	path := []string{mem.Key.String()}
+	for {
+		target, ok := aliasMemoryTarget(mem.Content)
+		if !ok {
+			return mem, nil
+		}
+
+		var err error
+		if path, err = aliasHop(path, target.String()); err != nil {
+			return nil, err
+		}
+
+		next, err := repo.Get(ctx, target)
+		if err != nil {
+			return nil, fmt.Errorf("alias memory %s -> %s: %w", strings.Join(path[:len(path)-1], " -> "), target, err)
+		}
+		mem = next
+	}
+}
+
+// aliasMemoryMaxLen bounds how much of a streamed memory is read to tell
+// whether it is an alias memory; longer content is always an ordinary one.
+const aliasMemoryMaxLen = 4096
+
+// followAliasReader is the streaming counterpart of followAliasMemory: it
+// returns rc, the opened content of key, or if key is an alias memory the
+// opened content of the memory it points at. Only the first
+// aliasMemoryMaxLen bytes are read up front, so large memories stream
+// untouched.
+func followAliasReader(ctx context.Context, repo MemoryRepository, key Key, rc io.ReadCloser) (io.ReadCloser, error) {
+	path := []string{key.String()}
+	for {
+		head, err := io.ReadAll(io.LimitReader(rc, aliasMemoryMaxLen+1))
+		if err != nil {
+			rc.Close()
+			return nil, fmt.Errorf("read memory: %w", err)
+		}
+		target, ok := aliasMemoryTarget(head)
+		if !ok || len(head) > aliasMemoryMaxLen {
+			return struct {
+				io.Reader
+				io.Closer
+			}{io.MultiReader(bytes.NewReader(head), rc), rc}, nil
+		}
+		rc.Close()
+
+		if path, err = aliasHop(path, target.String()); err != nil {
+			return nil, err
+		}
+		if rc, err = openMemory(ctx, repo, target); err != nil {
+			return nil, fmt.Errorf("alias memory %s -> %s: %w", strings.Join(path[:len(path)-1], " -> "), target, err)
+		}
+	}
+}
+
+// aliasHop appends next to path, the chain of aliases or alias memories
+// followed so far. It fails with ErrAliasCycle when next was already
+// visited and with ErrAliasTooDeep past MaxAliasHops.
+func aliasHop(path []string, next string) ([]string, error) {
+	seen := slices.Contains(path, next)
+	path = append(path, next)
+	if seen {
+		return nil, fmt.Errorf("%w: %s", ErrAliasCycle, strings.Join(path, " -> "))
+	}
+	if len(path) > MaxAliasHops {
+		return nil, fmt.Errorf("%w: %s: more than %d hops", ErrAliasTooDeep, path[0], MaxAliasHops)
+	}
+	return path, nil
+}
@@ -0,0 +1,157 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestAliasAddResolveRemove(t *testing.T) {
+	_, resolver := setupUseCaseTest(t)
+	t.Setenv("HOME", t.TempDir())
+	uc := NewAliasUseCase(resolver)
+
+	if err := uc.Add(AliasInput{Name: "deploy", Key: "projects/backend/deploy-notes"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	got, err := uc.Resolve(AliasInput{Name: "@deploy"})
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if got != "projects/backend/deploy-notes" {
+		t.Errorf("resolve = %q, want projects/backend/deploy-notes", got)
+	}
+
+	if got, _ := uc.Resolve(AliasInput{Name: "plain/key"}); got != "plain/key" {
+		t.Errorf("plain key resolved to %q", got)
+	}
+
+	_, err = uc.Resolve(AliasInput{Name: "@nope"})
+	if !errors.Is(err, ErrAliasNotFound) {
+		t.Fatalf("err = %v, want ErrAliasNotFound", err)
+	}
+	if !strings.Contains(err.Error(), "available: @deploy") {
+		t.Errorf("error %q should list defined aliases", err)
+	}
+
+	if err := uc.Remove(AliasInput{Name: "@deploy"}); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if _, err := uc.Resolve(AliasInput{Name: "@deploy"}); !errors.Is(err, ErrAliasNotFound) {
+		t.Errorf("after remove: err = %v, want ErrAliasNotFound", err)
+	}
+}
+
+func TestAliasAddRejectsBadInput(t *testing.T) {
+	_, resolver := setupUseCaseTest(t)
+	uc := NewAliasUseCase(resolver)
+
+	if err := uc.Add(AliasInput{Name: "bad name", Key: "a/b"}); err == nil {
+		t.Error("expected error for invalid alias name")
+	}
+	if err := uc.Add(AliasInput{Name: "ok", Key: ""}); !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("err = %v, want ErrInvalidKey", err)
+	}
+}
+
+func TestAliasChainsAndCycles(t *testing.T) {
+	_, resolver := setupUseCaseTest(t)
+	t.Setenv("HOME", t.TempDir())
+	uc := NewAliasUseCase(resolver)
+
+	if err := uc.Add(AliasInput{Name: "deploy", Key: "projects/backend/deploy-notes"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := uc.Add(AliasInput{Name: "ship", Key: "@deploy"}); err != nil {
+		t.Fatalf("add chained alias: %v", err)
+	}
+	if got, err := uc.Resolve(AliasInput{Name: "@ship"}); err != nil || got != "projects/backend/deploy-notes" {
+		t.Errorf("resolve @ship = %q, %v", got, err)
+	}
+
+	if err := uc.Add(AliasInput{Name: "deploy", Key: "@ship"}); !errors.Is(err, ErrAliasCycle) {
+		t.Errorf("err = %v, want ErrAliasCycle", err)
+	}
+
+	// A cycle written into config.yaml by hand is caught on resolve.
+	scope := resolver.Resolve("")
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	cfg.Aliases["deploy"] = "@ship"
+	if err := SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	_, err = uc.Resolve(AliasInput{Name: "@ship"})
+	if !errors.Is(err, ErrAliasCycle) || !strings.Contains(err.Error(), "@ship -> @deploy -> @ship") {
+		t.Errorf("err = %v, want cycle @ship -> @deploy -> @ship", err)
+	}
+
+	cfg.Aliases["deploy"] = "@gone"
+	if err := SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	if _, err := uc.Resolve(AliasInput{Name: "@ship"}); !errors.Is(err, ErrAliasNotFound) {
+		t.Errorf("err = %v, want ErrAliasNotFound for a dangling chain", err)
+	}
+}
+
+func TestAliasMaxHops(t *testing.T) {
+	aliases := map[string]string{"a0": "notes/end"}
+	for i := 1; i <= MaxAliasHops+1; i++ {
+		aliases[fmt.Sprintf("a%d", i)] = fmt.Sprintf("@a%d", i-1)
+	}
+
+	if got, err := followAliases(aliases, fmt.Sprintf("a%d", MaxAliasHops-1)); err != nil || got != "notes/end" {
+		t.Errorf("follow within the limit = %q, %v", got, err)
+	}
+	if _, err := followAliases(aliases, fmt.Sprintf("a%d", MaxAliasHops+1)); !errors.Is(err, ErrAliasTooDeep) {
+		t.Errorf("past the hop limit: err = %v, want ErrAliasTooDeep", err)
+	}
+}
+
+func TestKeyedUseCasesResolveAliases(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	t.Setenv("HOME", t.TempDir())
+	ctx := context.Background()
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+
+	aliases := NewAliasUseCase(resolver)
+	if err := aliases.Add(AliasInput{Name: "deploy", Key: "projects/deploy"}); err != nil {
+		t.Fatalf("add alias: %v", err)
+	}
+	if err := aliases.Add(AliasInput{Name: "old", Key: "notes/gone"}); err != nil {
+		t.Fatalf("add alias: %v", err)
+	}
+
+	set := NewSetMemoryUseCase(resolver, repoFor, nil, nil, nil)
+	if err := set.Execute(ctx, SetMemoryInput{Key: "@deploy", Content: "ship it"}); err != nil {
+		t.Fatalf("set @deploy: %v", err)
+	}
+
+	get := NewGetMemoryUseCase(resolver, repoFor)
+	out, err := get.Execute(ctx, GetMemoryInput{Key: "@deploy"})
+	if err != nil {
+		t.Fatalf("get @deploy: %v", err)
+	}
+	if out.Key != "projects/deploy" || out.Content != "ship it" {
+		t.Errorf("get @deploy = %+v, want projects/deploy", out)
+	}
+
+	_, err = get.Execute(ctx, GetMemoryInput{Key: "@old"})
+	if !errors.Is(err, ErrNotFound) || !strings.Contains(err.Error(), `alias @old points to missing key "notes/gone"`) {
+		t.Errorf("get @old: err = %v, want the missing key named", err)
+	}
+
+	del := NewDeleteMemoryUseCase(resolver, repoFor, nil)
+	if _, err := del.Execute(ctx, DeleteMemoryInput{Key: "@deploy"}); err != nil {
+		t.Fatalf("del @deploy: %v", err)
+	}
+	if exists, _ := repo.Exists(ctx, "projects/deploy"); exists {
+		t.Error("del @deploy left projects/deploy in place")
+	}
+}
@@ -3,9 +3,11 @@ package internal
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
 
 	"github.com/4thel00z/goannoy/builder"
@@ -15,6 +17,9 @@ import (
 const (
 	IndexFilename   = "index.ann"
 	MappingFilename = "mapping.json"
+	// StaleFilename marks an index whose store changed underneath it, e.g.
+	// by a snapshot restore. Only a full rebuild removes it.
+	StaleFilename = "stale"
 )
 
 var _ VectorIndex = (*AnnoyIndex)(nil)
@@ -29,12 +34,19 @@ type AnnoyIndex struct {
 	basePath  string
 	built     bool
 	dirty     bool
+	searchK   int
+	// fetch, if set, downloads the index into basePath when Load finds no
+	// local copy.
+	fetch func(ctx context.Context, dir string) error
 }
 
 type indexMapping struct {
 	KeyToID map[string]uint32 `json:"key_to_id"`
 	IDToKey map[uint32]string `json:"id_to_key"`
 	NextID  uint32            `json:"next_id"`
+	// Dimension is the vector size the index was built with. Mappings
+	// written before it was recorded leave it zero.
+	Dimension int `json:"dimension,omitempty"`
 }
 
 func NewAnnoyIndex(basePath string, dimension int) (*AnnoyIndex, error) {
@@ -57,6 +69,7 @@ func NewAnnoyIndex(basePath string, dimension int) (*AnnoyIndex, error) {
 		basePath:  basePath,
 		built:     false,
 		dirty:     false,
+		searchK:   -1,
 	}, nil
 }
 
@@ -111,10 +124,11 @@ func (a *AnnoyIndex) Remove(ctx context.Context, key Key) error {
 		return nil
 	}
 
+	// The vector stays in the trees until the next Add recreates the index;
+	// Search skips ids that no longer map to a key.
 	delete(a.keyToID, keyStr)
 	delete(a.idToKey, id)
 	a.dirty = true
-	a.built = false
 
 	return nil
 }
@@ -123,14 +137,11 @@ func (a *AnnoyIndex) Search(ctx context.Context, query Embedding, k int) ([]Sear
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
-	if !a.built {
-		return nil, fmt.Errorf("index not built")
-	}
-
 	if len(query.Vector) != a.dimension {
 		return nil, fmt.Errorf("dimension mismatch: expected %d, got %d", a.dimension, len(query.Vector))
 	}
 
+	// An empty index has nothing to find, built or not.
 	numItems := len(a.keyToID)
 	if k > numItems {
 		k = numItems
@@ -139,8 +150,12 @@ func (a *AnnoyIndex) Search(ctx context.Context, query Embedding, k int) ([]Sear
 		return nil, nil
 	}
 
+	if !a.built {
+		return nil, ErrIndexNotBuilt
+	}
+
 	searchCtx := a.idx.CreateContext()
-	ids, distances := a.idx.GetNnsByVector(query.Vector, k, -1, searchCtx)
+	ids, distances := a.idx.GetNnsByVector(query.Vector, k, a.searchK, searchCtx)
 
 	results := make([]SearchResult, 0, len(ids))
 	for i, id := range ids {
@@ -149,21 +164,23 @@ func (a *AnnoyIndex) Search(ctx context.Context, query Embedding, k int) ([]Sear
 			continue
 		}
 
-		key, err := NewKey(keyStr)
+		key, err := parseIndexKey(keyStr)
 		if err != nil {
 			continue
 		}
 
 		// Convert angular distance to similarity score (0-1, higher is better)
 		// Angular distance is in range [0, 2], so score = 1 - dist/2
-		var score float32
+		var score, distance float32
 		if i < len(distances) {
-			score = 1.0 - distances[i]/2.0
+			distance = distances[i]
+			score = 1.0 - distance/2.0
 		}
 
 		results = append(results, SearchResult{
-			Key:   key,
-			Score: score,
+			Key:      key,
+			Score:    score,
+			Distance: distance,
 		})
 	}
 
@@ -174,6 +191,12 @@ func (a *AnnoyIndex) Build(ctx context.Context, numTrees int) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
+	// Annoy refuses to build twice. Nothing was added since the last build,
+	// so the existing trees are still current.
+	if a.built {
+		return nil
+	}
+
 	a.idx.Build(numTrees, -1)
 	a.built = true
 	return nil
@@ -189,9 +212,10 @@ func (a *AnnoyIndex) Save(ctx context.Context) error {
 	}
 
 	mapping := indexMapping{
-		KeyToID: a.keyToID,
-		IDToKey: a.idToKey,
-		NextID:  a.nextID,
+		KeyToID:   a.keyToID,
+		IDToKey:   a.idToKey,
+		NextID:    a.nextID,
+		Dimension: a.dimension,
 	}
 
 	mappingPath := filepath.Join(a.basePath, MappingFilename)
@@ -200,7 +224,7 @@ func (a *AnnoyIndex) Save(ctx context.Context) error {
 		return fmt.Errorf("marshal mapping: %w", err)
 	}
 
-	if err := os.WriteFile(mappingPath, data, 0644); err != nil {
+	if err := writeFileAtomic(mappingPath, data, true); err != nil {
 		return fmt.Errorf("write mapping: %w", err)
 	}
 
@@ -208,12 +232,27 @@ func (a *AnnoyIndex) Save(ctx context.Context) error {
 	return nil
 }
 
+// SetRemote makes Load call fetch to download the index into the index
+// directory when there is no local copy. fetch returns
+// ErrRemoteIndexNotFound when the remote has none either.
+func (a *AnnoyIndex) SetRemote(fetch func(ctx context.Context, dir string) error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.fetch = fetch
+}
+
 func (a *AnnoyIndex) Load(ctx context.Context) error {
 	a.mu.Lock()
 	defer a.mu.Unlock()
 
 	mappingPath := filepath.Join(a.basePath, MappingFilename)
 	data, err := os.ReadFile(mappingPath)
+	if os.IsNotExist(err) && a.fetch != nil {
+		if err := a.fetch(ctx, a.basePath); err != nil && !errors.Is(err, ErrRemoteIndexNotFound) {
+			return fmt.Errorf("fetch remote index: %w", err)
+		}
+		data, err = os.ReadFile(mappingPath)
+	}
 	if os.IsNotExist(err) {
 		return nil
 	}
@@ -225,6 +264,9 @@ func (a *AnnoyIndex) Load(ctx context.Context) error {
 	if err := json.Unmarshal(data, &mapping); err != nil {
 		return fmt.Errorf("unmarshal mapping: %w", err)
 	}
+	if mapping.Dimension != 0 && mapping.Dimension != a.dimension {
+		return fmt.Errorf("%w: index has %d, embedder has %d", ErrIndexDimension, mapping.Dimension, a.dimension)
+	}
 
 	a.keyToID = mapping.KeyToID
 	a.idToKey = mapping.IDToKey
@@ -244,6 +286,107 @@ func (a *AnnoyIndex) Load(ctx context.Context) error {
 	return nil
 }
 
+// IndexedKeys reads the keys recorded in the index mapping under basePath
+// without loading the index itself. A missing mapping yields no keys.
+func IndexedKeys(basePath string) ([]string, error) {
+	data, err := os.ReadFile(filepath.Join(basePath, MappingFilename))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read mapping: %w", err)
+	}
+
+	var mapping indexMapping
+	if err := json.Unmarshal(data, &mapping); err != nil {
+		return nil, fmt.Errorf("unmarshal mapping: %w", err)
+	}
+
+	keys := make([]string, 0, len(mapping.KeyToID))
+	for key := range mapping.KeyToID {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// MarkIndexStale records that the index under basePath no longer matches
+// the store and needs a rebuild.
+func MarkIndexStale(basePath string) error {
+	if err := os.MkdirAll(basePath, 0755); err != nil {
+		return fmt.Errorf("create vectors directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(basePath, StaleFilename), nil, 0644); err != nil {
+		return fmt.Errorf("mark index stale: %w", err)
+	}
+	return nil
+}
+
+// IndexStale reports whether the index under basePath was marked stale.
+func IndexStale(basePath string) bool {
+	_, err := os.Stat(filepath.Join(basePath, StaleFilename))
+	return err == nil
+}
+
+// Stale reports whether the index was marked stale since its last rebuild.
+func (a *AnnoyIndex) Stale() bool {
+	return IndexStale(a.basePath)
+}
+
+// ClearStale removes the stale marker after a full rebuild.
+func (a *AnnoyIndex) ClearStale() error {
+	err := os.Remove(filepath.Join(a.basePath, StaleFilename))
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("clear stale marker: %w", err)
+	}
+	return nil
+}
+
+// SetSearchK sets how many tree nodes a search inspects. Larger values
+// improve recall at the cost of latency; n <= 0 restores Annoy's default
+// of k * number of trees.
+func (a *AnnoyIndex) SetSearchK(n int) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if n <= 0 {
+		n = -1
+	}
+	a.searchK = n
+}
+
+// Vector returns the stored vector for key.
+func (a *AnnoyIndex) Vector(key Key) ([]float32, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	id, exists := a.keyToID[key.String()]
+	if !exists {
+		return nil, false
+	}
+	return a.idx.GetItem(id), true
+}
+
+// Len returns the number of keys in the index.
+func (a *AnnoyIndex) Len() int {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return len(a.keyToID)
+}
+
+// Keys returns every key in the index, chunk keys included, sorted.
+func (a *AnnoyIndex) Keys() []Key {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	keys := make([]Key, 0, len(a.keyToID))
+	for k := range a.keyToID {
+		keys = append(keys, Key(k))
+	}
+	slices.Sort(keys)
+	return keys
+}
+
 func (a *AnnoyIndex) Contains(ctx context.Context, key Key) bool {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
@@ -2,6 +2,11 @@ package internal
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -73,6 +78,68 @@ func TestAnnoyIndexRemove(t *testing.T) {
 	}
 }
 
+func TestAnnoyIndexKeys(t *testing.T) {
+	idx, err := NewAnnoyIndex(t.TempDir(), 3)
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+
+	ctx := context.Background()
+	for _, k := range []Key{"b", "a", "c"} {
+		if err := idx.Add(ctx, k, Embedding{Vector: []float32{1.0, 0.0, 0.0}}); err != nil {
+			t.Fatalf("add %s: %v", k, err)
+		}
+	}
+	if err := idx.Remove(ctx, "c"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+
+	if got := idx.Keys(); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("keys = %v, want [a b]", got)
+	}
+}
+
+func TestAnnoyIndexReAddAfterRemove(t *testing.T) {
+	ctx := context.Background()
+
+	idx, err := NewAnnoyIndex(t.TempDir(), 3)
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+
+	key1, _ := NewKey("doc/one")
+	key2, _ := NewKey("doc/two")
+	_ = idx.Add(ctx, key1, Embedding{Vector: []float32{1.0, 0.0, 0.0}})
+	_ = idx.Add(ctx, key2, Embedding{Vector: []float32{0.0, 1.0, 0.0}})
+	if err := idx.Build(ctx, 2); err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	if err := idx.Remove(ctx, key1); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	results, err := idx.Search(ctx, Embedding{Vector: []float32{1.0, 0.0, 0.0}}, 2)
+	if err != nil {
+		t.Fatalf("search after remove: %v", err)
+	}
+	for _, r := range results {
+		if r.Key == key1 {
+			t.Errorf("removed key %s still returned", key1)
+		}
+	}
+
+	// Re-adding and rebuilding must not panic.
+	if err := idx.Add(ctx, key1, Embedding{Vector: []float32{1.0, 0.0, 0.0}}); err != nil {
+		t.Fatalf("re-add: %v", err)
+	}
+	if err := idx.Build(ctx, 2); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	if !idx.Contains(ctx, key1) {
+		t.Error("expected re-added key to exist")
+	}
+}
+
 func TestAnnoyIndexDimensionMismatch(t *testing.T) {
 	tmpDir := t.TempDir()
 	dim := 3
@@ -109,9 +176,32 @@ func TestAnnoyIndexSearchBeforeBuild(t *testing.T) {
 		t.Fatalf("new index: %v", err)
 	}
 
-	_, err = idx.Search(context.Background(), Embedding{Vector: []float32{1.0, 0.0, 0.0}}, 1)
-	if err == nil {
-		t.Error("expected error when searching before build")
+	ctx := context.Background()
+	key, _ := NewKey("a")
+	if err := idx.Add(ctx, key, Embedding{Vector: []float32{1.0, 0.0, 0.0}}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	_, err = idx.Search(ctx, Embedding{Vector: []float32{1.0, 0.0, 0.0}}, 1)
+	if !errors.Is(err, ErrIndexNotBuilt) {
+		t.Errorf("expected ErrIndexNotBuilt when searching before build, got %v", err)
+	}
+}
+
+func TestAnnoyIndexSearchEmpty(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	idx, err := NewAnnoyIndex(tmpDir, 3)
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+
+	results, err := idx.Search(context.Background(), Embedding{Vector: []float32{1.0, 0.0, 0.0}}, 5)
+	if err != nil {
+		t.Fatalf("search empty index: %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected no results, got %v", results)
 	}
 }
 
@@ -249,3 +339,121 @@ func TestAnnoyIndexSaveAndLoad(t *testing.T) {
 		t.Errorf("expected 'persist/me', got %q", results[0].Key.String())
 	}
 }
+
+func TestAnnoyIndexSearchKRecall(t *testing.T) {
+	const (
+		dim     = 16
+		n       = 500
+		queries = 100
+	)
+	ctx := context.Background()
+	rng := rand.New(rand.NewSource(1))
+
+	idx, err := NewAnnoyIndex(t.TempDir(), dim)
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+
+	vectors := make([][]float32, n)
+	for i := range vectors {
+		v := make([]float32, dim)
+		for j := range v {
+			v[j] = rng.Float32()*2 - 1
+		}
+		vectors[i] = v
+		key, _ := NewKey(fmt.Sprintf("doc/%d", i))
+		if err := idx.Add(ctx, key, Embedding{Vector: v}); err != nil {
+			t.Fatalf("add: %v", err)
+		}
+	}
+	if err := idx.Build(ctx, 2); err != nil {
+		t.Fatalf("build: %v", err)
+	}
+
+	// Each query is a slightly perturbed copy of a stored vector, so the
+	// correct top-1 is known.
+	hits := func(searchK int) int {
+		idx.SetSearchK(searchK)
+		qrng := rand.New(rand.NewSource(2))
+		found := 0
+		for q := 0; q < queries; q++ {
+			target := qrng.Intn(n)
+			query := make([]float32, dim)
+			for j := range query {
+				query[j] = vectors[target][j] + (qrng.Float32()-0.5)*0.05
+			}
+			results, err := idx.Search(ctx, Embedding{Vector: query}, 1)
+			if err != nil {
+				t.Fatalf("search: %v", err)
+			}
+			if len(results) > 0 && results[0].Key.String() == fmt.Sprintf("doc/%d", target) {
+				found++
+			}
+		}
+		return found
+	}
+
+	low := hits(1)
+	high := hits(n * 2)
+
+	if high < low {
+		t.Errorf("searchK=%d found %d/%d top-1 matches, fewer than searchK=1 (%d)", n*2, high, queries, low)
+	}
+	if high != queries {
+		t.Errorf("exhaustive searchK found %d/%d top-1 matches, want all", high, queries)
+	}
+}
+
+func TestAnnoyIndexStaleMarker(t *testing.T) {
+	dir := t.TempDir()
+	idx, err := NewAnnoyIndex(dir, 3)
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+	if idx.Stale() {
+		t.Fatal("fresh index should not be stale")
+	}
+	if err := MarkIndexStale(dir); err != nil {
+		t.Fatalf("mark stale: %v", err)
+	}
+	if !idx.Stale() {
+		t.Error("index should be stale after MarkIndexStale")
+	}
+	if err := idx.ClearStale(); err != nil {
+		t.Fatalf("clear stale: %v", err)
+	}
+	if idx.Stale() || IndexStale(dir) {
+		t.Error("index should not be stale after ClearStale")
+	}
+}
+
+func TestAnnoyIndexLoadDimensionMismatch(t *testing.T) {
+	dir := t.TempDir()
+	mapping := `{"key_to_id":{"a":0},"id_to_key":{"0":"a"},"next_id":1,"dimension":3}`
+	if err := os.WriteFile(filepath.Join(dir, MappingFilename), []byte(mapping), 0644); err != nil {
+		t.Fatalf("write mapping: %v", err)
+	}
+
+	idx, err := NewAnnoyIndex(dir, 4)
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+	if err := idx.Load(context.Background()); !errors.Is(err, ErrIndexDimension) {
+		t.Fatalf("Load = %v, want ErrIndexDimension", err)
+	}
+	if idx.Len() != 0 {
+		t.Errorf("mismatched index should stay empty, has %d keys", idx.Len())
+	}
+
+	// Mappings written before the dimension was recorded still load.
+	legacy := `{"key_to_id":{"a":0},"id_to_key":{"0":"a"},"next_id":1}`
+	if err := os.WriteFile(filepath.Join(dir, MappingFilename), []byte(legacy), 0644); err != nil {
+		t.Fatalf("write mapping: %v", err)
+	}
+	if err := idx.Load(context.Background()); err != nil {
+		t.Fatalf("Load legacy mapping: %v", err)
+	}
+	if idx.Len() != 1 {
+		t.Errorf("legacy mapping should load, has %d keys", idx.Len())
+	}
+}
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+type AskInput struct {
+	Prompt        string
+	ContextPrefix string // memories under this prefix are sent along with the prompt
+	Scope         string
+	Provider      string // overrides the configured provider for this run
+	Model         string // overrides the configured model for this run
+	NoRedact      bool   // sends content unredacted, ignoring the redaction config
+}
+
+// --- AskUseCase ---
+
+// AskUseCase streams a completion for a free-form question, optionally
+// grounded in the memories under a prefix.
+type AskUseCase struct {
+	resolver    *ScopeResolver
+	repoFor     func(Scope) (MemoryRepository, error)
+	providerFor ProviderFunc
+}
+
+func NewAskUseCase(
+	resolver *ScopeResolver,
+	repoFor func(Scope) (MemoryRepository, error),
+	providerFor ProviderFunc,
+) *AskUseCase {
+	return &AskUseCase{
+		resolver:    resolver,
+		repoFor:     repoFor,
+		providerFor: providerFor,
+	}
+}
+
+// Execute returns the answer as a stream of text chunks. The channel is
+// closed when the answer is complete.
+func (uc *AskUseCase) Execute(ctx context.Context, input AskInput) (<-chan string, error) {
+	if strings.TrimSpace(input.Prompt) == "" {
+		return nil, fmt.Errorf("prompt is empty")
+	}
+	if uc.providerFor == nil {
+		return nil, ErrNoProvider
+	}
+
+	scope := uc.resolver.Resolve(input.Scope)
+	provider, err := uc.providerFor(ctx, scope, TaskAsk, ProviderOverride{Provider: input.Provider, Model: input.Model})
+	if err != nil {
+		return nil, err
+	}
+
+	redactor, err := promptRedactor(scope, input.NoRedact)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := redactor.Redact(input.Prompt)
+	if input.ContextPrefix != "" {
+		prompt, err = uc.groundedPrompt(ctx, scope, redactor, input.ContextPrefix, input.Prompt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return provider.Stream(ctx, prompt)
+}
+
+// groundedPrompt puts the memories under prefix ahead of question, in the
+// same "## key" layout summarize uses, with redactor applied to both.
+func (uc *AskUseCase) groundedPrompt(ctx context.Context, scope Scope, redactor *Redactor, prefix, question string) (string, error) {
+	repo, err := uc.repoFor(scope)
+	if err != nil {
+		return "", fmt.Errorf("get repository: %w", err)
+	}
+	memories, err := repo.List(ctx, prefix)
+	if err != nil {
+		return "", fmt.Errorf("list memories: %w", err)
+	}
+	if len(memories) == 0 {
+		return "", fmt.Errorf("no memories under %q", prefix)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Answer the question using the notes below. If they do not cover it, say so.\n\n")
+	for _, mem := range memories {
+		fmt.Fprintf(&sb, "## %s\n%s\n\n", mem.Key, redactor.Redact(string(mem.Content)))
+	}
+	sb.WriteString("Question: ")
+	sb.WriteString(redactor.Redact(question))
+	return sb.String(), nil
+}
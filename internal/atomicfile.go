@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// renameFile is os.Rename, replaceable in tests.
+var renameFile = os.Rename
+
+// writeFileAtomic writes data to a temp file next to path and renames it
+// into place, so readers see either the old or the new content and never a
+// partial write. With durable set, the file and its directory are synced.
+func writeFileAtomic(path string, data []byte, durable bool) error {
+	return writeAtomic(path, durable, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// writeAtomic is writeFileAtomic for content produced by write, such as a
+// download streamed to disk. If write fails, path is left untouched.
+func writeAtomic(path string, durable bool, write func(io.Writer) error) error {
+	dir := filepath.Dir(path)
+	f, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	tmp := f.Name()
+
+	err = write(f)
+	if err == nil && durable {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0644)
+	}
+	if err == nil {
+		err = renameFile(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	if durable {
+		d, err := os.Open(dir)
+		if err != nil {
+			return fmt.Errorf("open directory: %w", err)
+		}
+		defer d.Close()
+		if err := d.Sync(); err != nil {
+			return fmt.Errorf("sync directory: %w", err)
+		}
+	}
+	return nil
+}
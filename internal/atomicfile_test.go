@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// failingWriter writes part of its input and then fails, like a disk
+// filling up mid-write.
+type failingWriter struct{ w io.Writer }
+
+func (f failingWriter) Write(p []byte) (int, error) {
+	n, _ := f.w.Write(p[:len(p)/2])
+	return n, errors.New("disk full")
+}
+
+func TestWriteAtomicFailedWriteKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "file")
+	if err := os.WriteFile(path, []byte("original"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := writeAtomic(path, true, func(w io.Writer) error {
+		_, err := failingWriter{w}.Write([]byte("replacement"))
+		return err
+	})
+	if err == nil {
+		t.Fatal("expected the write to fail")
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(got) != "original" {
+		t.Errorf("content = %q, want original intact", got)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("temp file left behind: %v", entries)
+	}
+}
+
+func TestSaveConfigFailureKeepsOriginal(t *testing.T) {
+	scope := Scope{Type: ScopeProject, MemPath: t.TempDir()}
+	if err := SaveConfig(scope, DefaultConfig()); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	before, err := os.ReadFile(scope.ConfigPath())
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	renameFile = func(string, string) error { return errors.New("crash") }
+	t.Cleanup(func() { renameFile = os.Rename })
+
+	cfg := DefaultConfig()
+	cfg.Embeddings.NumTrees = 99
+	if err := SaveConfig(scope, cfg); err == nil {
+		t.Fatal("expected save to fail")
+	}
+
+	after, err := os.ReadFile(scope.ConfigPath())
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(after) != string(before) {
+		t.Errorf("config changed by a failed save:\n%s", after)
+	}
+}
@@ -0,0 +1,273 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// AttachmentsDir holds attachment blobs inside the store, one file per
+// SHA-256 of the content.
+const AttachmentsDir = "attachments"
+
+// attachmentPrefix starts the line that references an attachment from a
+// memory: "attachment: <sha256> <filename>".
+const attachmentPrefix = "attachment: "
+
+var ErrAttachmentNotFound = errors.New("attachment not found")
+
+var (
+	blobHashPattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	attachmentLine  = regexp.MustCompile(`^attachment: ([0-9a-f]{64}) (.+)$`)
+)
+
+type Attachment struct {
+	Hash string
+	Name string
+}
+
+// Line is the reference written into the memory for a.
+func (a Attachment) Line() string {
+	return attachmentPrefix + a.Hash + " " + a.Name
+}
+
+// AttachmentStore keeps attachment blobs by content hash. Blobs are only
+// read when asked for by hash, so listing and searching memories never
+// touches them.
+type AttachmentStore interface {
+	// PutBlob stores the content of r and returns its hex SHA-256. With
+	// track set the blob is staged for the next commit.
+	PutBlob(ctx context.Context, r io.Reader, track bool) (string, error)
+	OpenBlob(ctx context.Context, hash string) (io.ReadCloser, error)
+	RemoveBlob(ctx context.Context, hash string) error
+	ListBlobs(ctx context.Context) ([]string, error)
+}
+
+// ParseAttachments returns the attachments referenced by content, in order.
+func ParseAttachments(content string) []Attachment {
+	var attachments []Attachment
+	for _, line := range strings.Split(content, "\n") {
+		if m := attachmentLine.FindStringSubmatch(line); m != nil {
+			attachments = append(attachments, Attachment{Hash: m[1], Name: m[2]})
+		}
+	}
+	return attachments
+}
+
+// withAttachment returns content referencing a, replacing an earlier
+// reference with the same name.
+func withAttachment(content string, a Attachment) string {
+	lines := strings.Split(strings.TrimRight(content, "\n"), "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if m := attachmentLine.FindStringSubmatch(line); m != nil && m[2] == a.Name {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	if len(kept) == 1 && kept[0] == "" {
+		kept = kept[:0]
+	}
+	return strings.Join(append(kept, a.Line()), "\n") + "\n"
+}
+
+type AttachInput struct {
+	Key    string
+	Path   string // file to attach
+	Name   string // name to attach under; defaults to the base name of Path
+	Scope  string
+	DryRun bool // GC only: report unreferenced blobs without removing them
+}
+
+type AttachGCOutput struct {
+	Hashes  []string
+	DryRun  bool
+	Tracked bool // blobs are committed, so removing them needs a commit
+}
+
+// --- AttachmentUseCase ---
+
+// AttachmentUseCase stores files next to memories. The memory only gains a
+// reference line, so the text store and the embedder never see the bytes.
+type AttachmentUseCase struct {
+	resolver *ScopeResolver
+	repoFor  func(Scope) (MemoryRepository, error)
+	blobsFor func(Scope) (AttachmentStore, error)
+}
+
+func NewAttachmentUseCase(
+	resolver *ScopeResolver,
+	repoFor func(Scope) (MemoryRepository, error),
+	blobsFor func(Scope) (AttachmentStore, error),
+) *AttachmentUseCase {
+	return &AttachmentUseCase{
+		resolver: resolver,
+		repoFor:  repoFor,
+		blobsFor: blobsFor,
+	}
+}
+
+// Attach stores the file at input.Path and references it from input.Key,
+// which must exist. Attaching a file under a name already in use replaces
+// the reference.
+func (uc *AttachmentUseCase) Attach(ctx context.Context, input AttachInput) (*Attachment, error) {
+	key, err := aliasedKey(uc.resolver, input.Scope, input.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	name := input.Name
+	if name == "" {
+		name = filepath.Base(input.Path)
+	}
+	if strings.ContainsAny(name, "\r\n") || strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("invalid attachment name %q", name)
+	}
+
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.repoFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return nil, err
+	}
+
+	mem, err := repo.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	blobs, err := uc.blobsFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get attachment store: %w", err)
+	}
+
+	f, err := os.Open(input.Path)
+	if err != nil {
+		return nil, fmt.Errorf("open attachment: %w", err)
+	}
+	defer f.Close()
+
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+	hash, err := blobs.PutBlob(ctx, f, cfg.Attachments.Track)
+	if err != nil {
+		return nil, fmt.Errorf("store attachment: %w", err)
+	}
+
+	attachment := Attachment{Hash: hash, Name: name}
+	mem.Content = []byte(withAttachment(string(mem.Content), attachment))
+	if err := repo.Save(ctx, mem); err != nil {
+		return nil, fmt.Errorf("save memory: %w", err)
+	}
+	return &attachment, nil
+}
+
+// Open returns the content of the attachment input.Name of input.Key.
+func (uc *AttachmentUseCase) Open(ctx context.Context, input AttachInput) (io.ReadCloser, error) {
+	attachments, scope, err := uc.list(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, a := range attachments {
+		if a.Name != input.Name {
+			continue
+		}
+		blobs, err := uc.blobsFor(scope)
+		if err != nil {
+			return nil, fmt.Errorf("get attachment store: %w", err)
+		}
+		return blobs.OpenBlob(ctx, a.Hash)
+	}
+	return nil, fmt.Errorf("%w: %s has no attachment %q", ErrAttachmentNotFound, input.Key, input.Name)
+}
+
+// List returns the attachments referenced by input.Key.
+func (uc *AttachmentUseCase) List(ctx context.Context, input AttachInput) ([]Attachment, error) {
+	attachments, _, err := uc.list(ctx, input)
+	return attachments, err
+}
+
+func (uc *AttachmentUseCase) list(ctx context.Context, input AttachInput) ([]Attachment, Scope, error) {
+	key, err := aliasedKey(uc.resolver, input.Scope, input.Key)
+	if err != nil {
+		return nil, Scope{}, err
+	}
+
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.repoFor(scope)
+	if err != nil {
+		return nil, scope, fmt.Errorf("get repository: %w", err)
+	}
+
+	mem, err := repo.Get(ctx, key)
+	if err != nil {
+		return nil, scope, err
+	}
+	return ParseAttachments(string(mem.Content)), scope, nil
+}
+
+// GC removes blobs no memory references and returns their hashes. Trashed
+// memories keep their attachments alive so they can still be restored.
+func (uc *AttachmentUseCase) GC(ctx context.Context, input AttachInput) (*AttachGCOutput, error) {
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.repoFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+	if !input.DryRun {
+		if err := checkWritable(ctx, scope, repo); err != nil {
+			return nil, err
+		}
+	}
+
+	referenced := make(map[string]bool)
+	for _, prefix := range []string{"", TrashPrefix} {
+		memories, err := repo.List(ctx, prefix)
+		if err != nil {
+			return nil, fmt.Errorf("list memories: %w", err)
+		}
+		for _, mem := range memories {
+			for _, a := range ParseAttachments(string(mem.Content)) {
+				referenced[a.Hash] = true
+			}
+		}
+	}
+
+	blobs, err := uc.blobsFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get attachment store: %w", err)
+	}
+	hashes, err := blobs.ListBlobs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list attachments: %w", err)
+	}
+
+	output := &AttachGCOutput{DryRun: input.DryRun}
+	if cfg, err := LoadConfig(scope); err == nil {
+		output.Tracked = cfg.Attachments.Track
+	}
+	for _, hash := range hashes {
+		if referenced[hash] {
+			continue
+		}
+		if !input.DryRun {
+			if err := blobs.RemoveBlob(ctx, hash); err != nil {
+				return nil, fmt.Errorf("remove attachment %s: %w", hash, err)
+			}
+		}
+		output.Hashes = append(output.Hashes, hash)
+	}
+	sort.Strings(output.Hashes)
+	return output, nil
+}
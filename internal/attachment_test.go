@@ -0,0 +1,172 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTempFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestAttachOpenAndList(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	blobsFor := func(Scope) (AttachmentStore, error) { return repo, nil }
+	uc := NewAttachmentUseCase(resolver, repoFor, blobsFor)
+
+	key, _ := NewKey("arch/overview")
+	if err := repo.Save(ctx, NewMemory(key, []byte("the big picture"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	diagram := writeTempFile(t, "diagram.png", "\x89PNG fake image bytes")
+	a, err := uc.Attach(ctx, AttachInput{Key: "arch/overview", Path: diagram})
+	if err != nil {
+		t.Fatalf("attach: %v", err)
+	}
+	if a.Name != "diagram.png" || len(a.Hash) != 64 {
+		t.Errorf("attachment = %+v", a)
+	}
+
+	mem, _ := repo.Get(ctx, key)
+	want := "the big picture\nattachment: " + a.Hash + " diagram.png\n"
+	if string(mem.Content) != want {
+		t.Errorf("content = %q, want %q", mem.Content, want)
+	}
+
+	// Re-attaching the same name replaces the reference.
+	updated := writeTempFile(t, "diagram.png", "a newer diagram")
+	b, err := uc.Attach(ctx, AttachInput{Key: "arch/overview", Path: updated})
+	if err != nil {
+		t.Fatalf("re-attach: %v", err)
+	}
+	list, err := uc.List(ctx, AttachInput{Key: "arch/overview"})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list) != 1 || list[0].Hash != b.Hash {
+		t.Errorf("attachments = %+v, want only %s", list, b.Hash)
+	}
+
+	blob, err := uc.Open(ctx, AttachInput{Key: "arch/overview", Name: "diagram.png"})
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	data, _ := io.ReadAll(blob)
+	_ = blob.Close()
+	if string(data) != "a newer diagram" {
+		t.Errorf("blob = %q", data)
+	}
+
+	if _, err := uc.Open(ctx, AttachInput{Key: "arch/overview", Name: "missing.pdf"}); !errors.Is(err, ErrAttachmentNotFound) {
+		t.Errorf("expected ErrAttachmentNotFound, got %v", err)
+	}
+
+	// Blobs never show up as memories.
+	memories, err := repo.List(ctx, "")
+	if err != nil {
+		t.Fatalf("list memories: %v", err)
+	}
+	if len(memories) != 1 || memories[0].Key != key {
+		t.Errorf("memories = %v, want only %s", memories, key)
+	}
+}
+
+func TestAttachRequiresMemory(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	blobsFor := func(Scope) (AttachmentStore, error) { return repo, nil }
+	uc := NewAttachmentUseCase(resolver, repoFor, blobsFor)
+
+	path := writeTempFile(t, "notes.pdf", "pdf")
+	if _, err := uc.Attach(context.Background(), AttachInput{Key: "nope", Path: path}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestAttachmentGC(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	blobsFor := func(Scope) (AttachmentStore, error) { return repo, nil }
+	uc := NewAttachmentUseCase(resolver, repoFor, blobsFor)
+
+	for _, k := range []string{"keep", "drop", "trashed"} {
+		key, _ := NewKey(k)
+		if err := repo.Save(ctx, NewMemory(key, []byte(k))); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+		if _, err := uc.Attach(ctx, AttachInput{Key: k, Path: writeTempFile(t, k+".bin", "blob for "+k)}); err != nil {
+			t.Fatalf("attach %s: %v", k, err)
+		}
+	}
+	drop, _ := uc.List(ctx, AttachInput{Key: "drop"})
+
+	if err := repo.Delete(ctx, "drop"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := moveToTrash(ctx, repo, "trashed"); err != nil {
+		t.Fatalf("trash: %v", err)
+	}
+
+	out, err := uc.GC(ctx, AttachInput{DryRun: true})
+	if err != nil {
+		t.Fatalf("gc dry run: %v", err)
+	}
+	if len(out.Hashes) != 1 || out.Hashes[0] != drop[0].Hash {
+		t.Fatalf("dry run = %v, want %s", out.Hashes, drop[0].Hash)
+	}
+	if hashes, _ := repo.ListBlobs(ctx); len(hashes) != 3 {
+		t.Errorf("dry run removed blobs: %v", hashes)
+	}
+
+	if _, err := uc.GC(ctx, AttachInput{}); err != nil {
+		t.Fatalf("gc: %v", err)
+	}
+	hashes, _ := repo.ListBlobs(ctx)
+	if len(hashes) != 2 || strings.Contains(strings.Join(hashes, ","), drop[0].Hash) {
+		t.Errorf("blobs after gc = %v", hashes)
+	}
+}
+
+func TestPutBlobTracking(t *testing.T) {
+	repo, _ := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	untracked, err := repo.PutBlob(ctx, strings.NewReader("loose"), false)
+	if err != nil {
+		t.Fatalf("put untracked: %v", err)
+	}
+	tracked, err := repo.PutBlob(ctx, strings.NewReader("kept in git"), true)
+	if err != nil {
+		t.Fatalf("put tracked: %v", err)
+	}
+
+	status, err := repo.worktree.Status()
+	if err != nil {
+		t.Fatalf("status: %v", err)
+	}
+	if s := status.File(filepath.Join(AttachmentsDir, tracked)); s.Staging == ' ' || s.Staging == '?' {
+		t.Errorf("tracked blob not staged: %q", s.Staging)
+	}
+	if s := status.File(filepath.Join(AttachmentsDir, untracked)); s.Staging != '?' {
+		t.Errorf("untracked blob staged: %q", s.Staging)
+	}
+
+	again, err := repo.PutBlob(ctx, strings.NewReader("loose"), false)
+	if err != nil || again != untracked {
+		t.Errorf("same content stored as %s (%v), want %s", again, err, untracked)
+	}
+}
@@ -2,9 +2,21 @@ package internal
 
 import (
 	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"time"
 )
 
+var (
+	ErrBranchExists       = errors.New("branch already exists")
+	ErrBranchNotMerged    = errors.New("branch is not fully merged")
+	ErrUncommittedChanges = errors.New("you have uncommitted changes")
+	ErrNothingToCommit    = errors.New("nothing to commit")
+	ErrNoCommits          = errors.New("store has no commits yet; run mem set or mem commit")
+	ErrNotAncestor        = errors.New("commit is not an ancestor of HEAD")
+)
+
 type Branch struct {
 	Name      string
 	Head      string // commit hash
@@ -15,22 +27,91 @@ type Commit struct {
 	Hash      string
 	Message   string
 	Author    string
+	Email     string
 	Timestamp time.Time
 	Parents   []string
+	Refs      []string // branches whose tip this is; only LogAll sets it
+	Diff      string   // patch against the first parent; only Show sets it
+}
+
+// FileStat counts the lines added to and removed from one file.
+type FileStat struct {
+	Path    string
+	Added   int
+	Removed int
 }
 
 type BranchRepository interface {
 	Current(ctx context.Context) (*Branch, error)
 	ListBranches(ctx context.Context) ([]*Branch, error)
-	Create(ctx context.Context, name string) (*Branch, error)
-	Switch(ctx context.Context, name string) error
-	DeleteBranch(ctx context.Context, name string) error
+	// Create makes a branch at from, or at HEAD if from is empty.
+	Create(ctx context.Context, name, from string) (*Branch, error)
+	// Switch checks out name. Unless force is set it refuses with
+	// ErrUncommittedChanges rather than discard staged or modified files.
+	Switch(ctx context.Context, name string, force bool) error
+	// DeleteBranch removes name. Unless force is set it refuses with
+	// ErrBranchNotMerged when the branch has commits HEAD does not.
+	DeleteBranch(ctx context.Context, name string, force bool) error
+	// RenameBranch moves oldName to newName, following it with HEAD if it
+	// is the current branch.
+	RenameBranch(ctx context.Context, oldName, newName string) error
 }
 
 type HistoryRepository interface {
+	// Commit records the staged changes. It fails with ErrNothingToCommit
+	// when the worktree is clean.
 	Commit(ctx context.Context, message string) (*Commit, error)
+	// CommitAllowEmpty is Commit that records a commit even when nothing
+	// is staged.
+	CommitAllowEmpty(ctx context.Context, message string) (*Commit, error)
 	Log(ctx context.Context, limit int) ([]*Commit, error)
+	// LogAll lists the commits reachable from any branch, each once and
+	// never before its children.
+	LogAll(ctx context.Context, limit int) ([]*Commit, error)
 	Diff(ctx context.Context, ref string) (string, error)
+	// DiffStat summarises Diff as line counts per file.
+	DiffStat(ctx context.Context, ref string) ([]FileStat, error)
+	// Show returns the commit at ref with its patch against its first
+	// parent. The root commit is shown against the empty tree.
 	Show(ctx context.Context, ref string) (*Commit, error)
 	Revert(ctx context.Context, ref string) error
 }
+
+// IndexPath returns the vector index directory for the scope's current
+// branch, so a search never returns keys that only exist on another branch.
+// It falls back to the scope's VectorPath when the branch is unknown.
+func IndexPath(ctx context.Context, scope Scope, branches BranchRepository) string {
+	if branches == nil {
+		return scope.VectorPath()
+	}
+	current, err := branches.Current(ctx)
+	if err != nil {
+		return scope.VectorPath()
+	}
+	dir := scope.BranchVectorPath(current.Name)
+	migrateLegacyIndex(scope.VectorPath(), dir)
+	return dir
+}
+
+// migrateLegacyIndex moves an index saved directly in vectors, before
+// indexes were kept per branch, into dir, the current branch's directory.
+// It does nothing once dir exists. If the move fails the index in dir is
+// marked stale, so search asks for a rebuild instead of finding nothing.
+func migrateLegacyIndex(legacy, dir string) {
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(legacy, IndexFilename)); err != nil {
+		return
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	for _, name := range []string{IndexFilename, MappingFilename, StaleFilename} {
+		err := os.Rename(filepath.Join(legacy, name), filepath.Join(dir, name))
+		if err != nil && !os.IsNotExist(err) {
+			_ = MarkIndexStale(dir)
+			return
+		}
+	}
+}
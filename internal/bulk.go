@@ -0,0 +1,236 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// KeyValue is one memory to write in a bulk set.
+type KeyValue struct {
+	Key     string
+	Content string
+}
+
+type BulkSetInput struct {
+	Pairs   []KeyValue
+	Scope   string
+	Message string   // commit message; "set: N keys" if empty
+	Tags    []string // set on every memory
+	// NoCommit leaves the memories staged for a later commit.
+	NoCommit bool
+}
+
+type BulkSetOutput struct {
+	Keys   []string
+	Commit *CommitOutput // nil when every value was already stored
+}
+
+// ParseKeyValues reads pairs for a bulk set. A JSON object maps keys to
+// contents and yields them sorted by key. Anything else is read as TSV: one
+// "key<TAB>content" per line, with \n, \t and \\ escapes in the content.
+// Blank lines and lines starting with # are skipped.
+func ParseKeyValues(data []byte) ([]KeyValue, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var object map[string]string
+		if err := json.Unmarshal(trimmed, &object); err != nil {
+			return nil, fmt.Errorf("parse JSON: %w", err)
+		}
+		pairs := make([]KeyValue, 0, len(object))
+		for key, content := range object {
+			pairs = append(pairs, KeyValue{Key: key, Content: content})
+		}
+		sort.Slice(pairs, func(i, j int) bool { return pairs[i].Key < pairs[j].Key })
+		return pairs, nil
+	}
+
+	var pairs []KeyValue
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, content, ok := strings.Cut(line, "\t")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key<TAB>content", i+1)
+		}
+		pairs = append(pairs, KeyValue{Key: key, Content: tsvUnescaper.Replace(content)})
+	}
+	return pairs, nil
+}
+
+var tsvUnescaper = strings.NewReplacer(`\\`, `\`, `\n`, "\n", `\t`, "\t")
+
+// --- BulkSetUseCase ---
+
+// BulkSetUseCase writes many memories as a single commit, the fast path
+// for seeding a store. Either every memory is committed or none is.
+type BulkSetUseCase struct {
+	resolver *ScopeResolver
+	repoFor  func(Scope) (MemoryRepository, error)
+	histFor  func(Scope) (HistoryRepository, error)
+	indexFor func(Scope) (VectorIndex, error)
+	embedder EmbedderFunc
+	ignore   func(Scope) (*IgnoreMatcher, error)
+}
+
+func NewBulkSetUseCase(
+	resolver *ScopeResolver,
+	repoFor func(Scope) (MemoryRepository, error),
+	histFor func(Scope) (HistoryRepository, error),
+	indexFor func(Scope) (VectorIndex, error),
+	embedder EmbedderFunc,
+	ignore func(Scope) (*IgnoreMatcher, error),
+) *BulkSetUseCase {
+	return &BulkSetUseCase{
+		resolver: resolver,
+		repoFor:  repoFor,
+		histFor:  histFor,
+		indexFor: indexFor,
+		embedder: embedder,
+		ignore:   ignore,
+	}
+}
+
+// Execute validates every key before writing any, stages all memories and
+// commits once, unless NoCommit is set. If a write or the commit fails, the
+// memories already staged are put back as they were.
+func (uc *BulkSetUseCase) Execute(ctx context.Context, input BulkSetInput) (*BulkSetOutput, error) {
+	if len(input.Pairs) == 0 {
+		return nil, fmt.Errorf("no memories to set")
+	}
+
+	scope := uc.resolver.Resolve(input.Scope)
+
+	var matcher *IgnoreMatcher
+	if uc.ignore != nil {
+		matcher, _ = uc.ignore(scope)
+	}
+
+	keys := make([]Key, len(input.Pairs))
+	seen := make(map[Key]bool, len(input.Pairs))
+	for i, pair := range input.Pairs {
+		key, err := policyKey(scope, pair.Key)
+		if err != nil {
+			return nil, err
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("duplicate key %q", pair.Key)
+		}
+		seen[key] = true
+		if matcher != nil && matcher.MatchKey(key) {
+			return nil, fmt.Errorf("key %q is blocked by .memignore", pair.Key)
+		}
+		if err := checkAliasMemoryContent(key, []byte(pair.Content)); err != nil {
+			return nil, err
+		}
+		keys[i] = key
+	}
+
+	repo, err := uc.repoFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return nil, err
+	}
+	hist, err := uc.histFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get history repository: %w", err)
+	}
+
+	// previous holds what each written key contained before, nil for keys
+	// that did not exist, so a failure can undo the writes.
+	previous := make([]*Memory, 0, len(keys))
+	rollback := func() {
+		for i := len(previous) - 1; i >= 0; i-- {
+			if previous[i] != nil {
+				_ = repo.Save(ctx, previous[i])
+			} else {
+				_ = repo.Delete(ctx, keys[i])
+			}
+		}
+	}
+
+	now := time.Now()
+	for i, key := range keys {
+		existing, err := repo.Get(ctx, key)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			rollback()
+			return nil, fmt.Errorf("read %s: %w", key, err)
+		}
+		previous = append(previous, existing)
+
+		mem := &Memory{Key: key, Content: []byte(input.Pairs[i].Content), Metadata: Metadata{Tags: input.Tags}, CreatedAt: now, UpdatedAt: now}
+		if err := repo.Save(ctx, mem); err != nil {
+			previous = previous[:len(previous)-1]
+			rollback()
+			return nil, fmt.Errorf("save %s: %w", key, err)
+		}
+	}
+
+	message := input.Message
+	if message == "" {
+		message = fmt.Sprintf("set: %d keys", len(keys))
+	}
+
+	output := &BulkSetOutput{Keys: make([]string, len(keys))}
+	for i, key := range keys {
+		output.Keys[i] = key.String()
+	}
+
+	if input.NoCommit {
+		uc.index(ctx, scope, keys, input.Pairs)
+		return output, nil
+	}
+	commit, err := hist.Commit(ctx, message)
+	if errors.Is(err, ErrNothingToCommit) {
+		return output, nil
+	}
+	if err != nil {
+		rollback()
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	output.Commit = &CommitOutput{
+		Hash:      commit.Hash,
+		Message:   commit.Message,
+		Timestamp: commit.Timestamp,
+	}
+
+	uc.index(ctx, scope, keys, input.Pairs)
+	return output, nil
+}
+
+// index embeds the committed memories. Like a single set, a failure only
+// leaves the index behind and is recorded as a skipped update.
+func (uc *BulkSetUseCase) index(ctx context.Context, scope Scope, keys []Key, pairs []KeyValue) {
+	if uc.embedder == nil || uc.indexFor == nil {
+		return
+	}
+	skipAll := func(reason string, err error) {
+		for _, key := range keys {
+			skipIndexUpdate(ctx, scope, key, reason, err)
+		}
+	}
+	embedder := uc.embedder.Get()
+	if embedder == nil {
+		skipAll(SkipModelUnavailable, ErrNoEmbedder)
+		return
+	}
+	index, err := uc.indexFor(scope)
+	if err != nil {
+		skipAll(SkipIndexUnavailable, err)
+		return
+	}
+	for i, key := range keys {
+		if err := indexMemory(ctx, scope, index, embedder, key, pairs[i].Content); err != nil {
+			skipIndexUpdate(ctx, scope, key, SkipEmbeddingFailed, err)
+		}
+	}
+	recordIndexSize(ctx, index)
+}
@@ -0,0 +1,130 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseKeyValues(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []KeyValue
+	}{
+		{
+			name: "json",
+			data: `{"b/two": "second", "a/one": "first\nline"}`,
+			want: []KeyValue{{"a/one", "first\nline"}, {"b/two", "second"}},
+		},
+		{
+			name: "tsv",
+			data: "# seed\na/one\tfirst\\nline\n\nb/two\tsecond\ttabbed\r\n",
+			want: []KeyValue{{"a/one", "first\nline"}, {"b/two", "second\ttabbed"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseKeyValues([]byte(tt.data))
+			if err != nil {
+				t.Fatalf("parse: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseKeyValues([]byte("no tab here\n")); err == nil {
+		t.Error("expected error for a TSV line without a tab")
+	}
+}
+
+func TestBulkSetSingleCommit(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	histFor := func(Scope) (HistoryRepository, error) { return repo, nil }
+	uc := NewBulkSetUseCase(resolver, repoFor, histFor, nil, nil, nil)
+
+	before, err := repo.Log(ctx, 0)
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+
+	out, err := uc.Execute(ctx, BulkSetInput{Pairs: []KeyValue{{"a", "1"}, {"b", "2"}, {"c/d", "3"}}})
+	if err != nil {
+		t.Fatalf("bulk set: %v", err)
+	}
+	if out.Commit == nil || out.Commit.Message != "set: 3 keys" {
+		t.Errorf("commit = %+v, want \"set: 3 keys\"", out.Commit)
+	}
+
+	after, err := repo.Log(ctx, 0)
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if len(after) != len(before)+1 {
+		t.Errorf("bulk set made %d commits, want 1", len(after)-len(before))
+	}
+	for _, kv := range []KeyValue{{"a", "1"}, {"b", "2"}, {"c/d", "3"}} {
+		mem, err := repo.Get(ctx, Key(kv.Key))
+		if err != nil || string(mem.Content) != kv.Content {
+			t.Errorf("%s = %v, %v; want %q", kv.Key, mem, err, kv.Content)
+		}
+	}
+}
+
+// failingRepo fails to save one key.
+type failingRepo struct {
+	*GitRepository
+	failKey Key
+}
+
+func (r *failingRepo) Save(ctx context.Context, mem *Memory) error {
+	if mem.Key == r.failKey {
+		return errors.New("disk full")
+	}
+	return r.GitRepository.Save(ctx, mem)
+}
+
+func TestBulkSetRollsBackOnFailure(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	if err := repo.Save(ctx, &Memory{Key: "existing", Content: []byte("old")}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := repo.Commit(ctx, "set: existing"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	failing := &failingRepo{GitRepository: repo, failKey: "zz"}
+	repoFor := func(Scope) (MemoryRepository, error) { return failing, nil }
+	histFor := func(Scope) (HistoryRepository, error) { return repo, nil }
+	uc := NewBulkSetUseCase(resolver, repoFor, histFor, nil, nil, nil)
+
+	before, _ := repo.Log(ctx, 0)
+	_, err := uc.Execute(ctx, BulkSetInput{Pairs: []KeyValue{
+		{"existing", "new"}, {"fresh", "x"}, {"zz", "boom"},
+	}})
+	if err == nil {
+		t.Fatal("expected bulk set to fail")
+	}
+
+	mem, err := repo.Get(ctx, "existing")
+	if err != nil || string(mem.Content) != "old" {
+		t.Errorf("existing = %v, %v; want old content back", mem, err)
+	}
+	if ok, _ := repo.Exists(ctx, "fresh"); ok {
+		t.Error("fresh should have been rolled back")
+	}
+	if n, err := repo.uncommittedFiles(); err != nil || n != 0 {
+		t.Errorf("uncommitted files after rollback = %d, %v", n, err)
+	}
+	if after, _ := repo.Log(ctx, 0); len(after) != len(before) {
+		t.Errorf("failed bulk set committed %d times", len(after)-len(before))
+	}
+}
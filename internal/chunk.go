@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ChunkSeparator joins a key and a chunk number in the index. It cannot
+// appear in a memory key, so chunk keys never collide with real ones.
+const ChunkSeparator = "#"
+
+// ChunkKey returns the index key of chunk n of key.
+func ChunkKey(key Key, n int) Key {
+	return Key(key.String() + ChunkSeparator + strconv.Itoa(n))
+}
+
+// ParentKey returns the memory key a chunk key belongs to. Keys without a
+// chunk suffix are returned unchanged.
+func ParentKey(key Key) Key {
+	parent, n, ok := strings.Cut(key.String(), ChunkSeparator)
+	if !ok {
+		return key
+	}
+	if _, err := strconv.Atoi(n); err != nil {
+		return key
+	}
+	return Key(parent)
+}
+
+// parseIndexKey validates a key read back from an index mapping, which may
+// carry a chunk suffix.
+func parseIndexKey(s string) (Key, error) {
+	key := Key(s)
+	if _, err := NewKey(ParentKey(key).String()); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// SplitChunks splits text into windows of at most size runes, each starting
+// size-overlap runes after the previous one. Text that fits in one window,
+// or a size <= 0, yields text itself.
+func SplitChunks(text string, size, overlap int) []string {
+	runes := []rune(text)
+	if size <= 0 || len(runes) <= size {
+		return []string{text}
+	}
+	if overlap < 0 || overlap >= size {
+		overlap = 0
+	}
+
+	var chunks []string
+	for start := 0; ; start += size - overlap {
+		end := min(start+size, len(runes))
+		chunks = append(chunks, string(runes[start:end]))
+		if end == len(runes) {
+			return chunks
+		}
+	}
+}
+
+// indexMemory replaces the index entries for key with embeddings of content,
+// split into chunks as configured for scope.
+func indexMemory(ctx context.Context, scope Scope, index VectorIndex, embedder Embedder, key Key, content string) error {
+	size, overlap := chunkSettings(ctx, scope)
+	chunks := SplitChunks(content, size, overlap)
+
+	vectors := make([][]float32, len(chunks))
+	for i, chunk := range chunks {
+		vec, err := embed(ctx, embedder, chunk)
+		if err != nil {
+			return fmt.Errorf("embed chunk %d: %w", i, err)
+		}
+		vectors[i] = vec
+	}
+
+	unindexMemory(ctx, index, key)
+	if len(vectors) == 1 {
+		return index.Add(ctx, key, NewEmbedding(vectors[0], "local"))
+	}
+	for i, vec := range vectors {
+		if err := index.Add(ctx, ChunkKey(key, i), NewEmbedding(vec, "local")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unindexMemory removes key and all of its chunks from index. Chunks are
+// numbered from zero without gaps, so the first missing one ends the scan.
+func unindexMemory(ctx context.Context, index VectorIndex, key Key) {
+	_ = index.Remove(ctx, key)
+	for n := 0; index.Contains(ctx, ChunkKey(key, n)); n++ {
+		_ = index.Remove(ctx, ChunkKey(key, n))
+	}
+}
+
+// chunkSettings reads the chunk size and overlap configured for scope. A
+// config that cannot be read disables chunking.
+func chunkSettings(ctx context.Context, scope Scope) (size, overlap int) {
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		LoggerFrom(ctx).Warn("chunking disabled: failed to load config", "error", err)
+		return 0, 0
+	}
+	return cfg.Embeddings.ChunkSize, cfg.Embeddings.ChunkOverlap
+}
+
+// searchParents searches index for the limit best memories under prefix.
+// Several chunks of one memory, or memories outside prefix, can crowd the
+// top hits, so the search widens until it has limit matching memories or
+// the index runs out.
+func searchParents(ctx context.Context, index VectorIndex, query Embedding, limit int, prefix string) ([]SearchResult, error) {
+	k := limit
+	for {
+		results, err := index.Search(ctx, query, k)
+		if err != nil {
+			return nil, err
+		}
+		parents := parentResults(results)
+		if prefix != "" {
+			parents = slices.DeleteFunc(parents, func(r SearchResult) bool { return !underPrefix(r.Key.String(), prefix) })
+		}
+		if len(parents) >= limit || len(results) < k {
+			return parents[:min(limit, len(parents))], nil
+		}
+		k *= 2
+	}
+}
+
+// parentResults maps chunk hits back to their memory keys, keeping the best
+// scoring hit of each memory and the order of the input.
+func parentResults(results []SearchResult) []SearchResult {
+	seen := make(map[Key]bool, len(results))
+	parents := make([]SearchResult, 0, len(results))
+	for _, r := range results {
+		key := ParentKey(r.Key)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		r.Key = key
+		parents = append(parents, r)
+	}
+	return parents
+}
@@ -0,0 +1,241 @@
+package internal
+
+import (
+	"context"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSplitChunks(t *testing.T) {
+	tests := []struct {
+		name          string
+		text          string
+		size, overlap int
+		want          []string
+	}{
+		{"disabled", "abcdef", 0, 0, []string{"abcdef"}},
+		{"fits", "abc", 3, 1, []string{"abc"}},
+		{"no overlap", "abcdefg", 3, 0, []string{"abc", "def", "g"}},
+		{"overlap", "abcdefg", 4, 2, []string{"abcd", "cdef", "efg"}},
+		{"overlap too large", "abcdef", 3, 3, []string{"abc", "def"}},
+		{"runes", "äöüßéè", 4, 1, []string{"äöüß", "ßéè"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitChunks(tt.text, tt.size, tt.overlap); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitChunks(%q, %d, %d) = %q, want %q", tt.text, tt.size, tt.overlap, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParentKey(t *testing.T) {
+	for in, want := range map[Key]Key{
+		"notes/long#3":   "notes/long",
+		"notes/long":     "notes/long",
+		"notes/long#x":   "notes/long#x",
+		ChunkKey("a", 0): "a",
+	} {
+		if got := ParentKey(in); got != want {
+			t.Errorf("ParentKey(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// topicEmbedder points texts that mention "kubernetes" one way and
+// everything else another.
+type topicEmbedder struct{ constEmbedder }
+
+func (topicEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	if strings.Contains(text, "kubernetes") {
+		return []float32{0, 1, 0}, nil
+	}
+	return []float32{1, 0, 0}, nil
+}
+
+// exactIndex is a brute-force VectorIndex, so search results do not depend
+// on how Annoy splits a handful of near-identical vectors.
+type exactIndex struct {
+	vectors map[Key][]float32
+}
+
+func newExactIndex() *exactIndex {
+	return &exactIndex{vectors: make(map[Key][]float32)}
+}
+
+func (x *exactIndex) Add(_ context.Context, key Key, emb Embedding) error {
+	x.vectors[key] = emb.Vector
+	return nil
+}
+
+func (x *exactIndex) Remove(_ context.Context, key Key) error {
+	delete(x.vectors, key)
+	return nil
+}
+
+func (x *exactIndex) Search(_ context.Context, query Embedding, k int) ([]SearchResult, error) {
+	var results []SearchResult
+	for key, vec := range x.vectors {
+		results = append(results, SearchResult{Key: key, Score: float32(cosine(query.Vector, vec))})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].Key < results[j].Key
+	})
+	return results[:min(k, len(results))], nil
+}
+
+func (x *exactIndex) Build(context.Context, int) error { return nil }
+func (x *exactIndex) Save(context.Context) error       { return nil }
+func (x *exactIndex) Load(context.Context) error       { return nil }
+func (x *exactIndex) Len() int                         { return len(x.vectors) }
+
+func (x *exactIndex) Keys() []Key {
+	keys := make([]Key, 0, len(x.vectors))
+	for key := range x.vectors {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func (x *exactIndex) Contains(_ context.Context, key Key) bool {
+	_, ok := x.vectors[key]
+	return ok
+}
+
+func TestSearchPrefix(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	idx := newExactIndex()
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	indexFor := func(Scope) (VectorIndex, error) { return idx, nil }
+	setUC := NewSetMemoryUseCase(resolver, repoFor, indexFor, StaticEmbedder(topicEmbedder{}), nil)
+
+	for _, key := range []string{"ops/a", "ops/b", "notes/a", "notesx", "notes/b"} {
+		if err := setUC.Execute(ctx, SetMemoryInput{Key: key, Content: "kubernetes " + key}); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+	}
+
+	keyword, err := NewKeywordSearchUseCase(resolver, repoFor).Execute(ctx, SearchInput{Query: "kubernetes", Prefix: "notes/"})
+	if err != nil {
+		t.Fatalf("keyword search: %v", err)
+	}
+	semantic, err := NewSemanticSearchUseCase(resolver, indexFor, StaticEmbedder(topicEmbedder{})).Execute(ctx, SearchInput{Query: "kubernetes", Limit: 2, Prefix: "notes/"})
+	if err != nil {
+		t.Fatalf("semantic search: %v", err)
+	}
+
+	for name, out := range map[string]*SearchOutput{"keyword": keyword, "semantic": semantic} {
+		var keys []string
+		for _, r := range out.Results {
+			keys = append(keys, r.Key)
+		}
+		slices.Sort(keys)
+		if !slices.Equal(keys, []string{"notes/a", "notes/b"}) {
+			t.Errorf("%s search in notes/ = %v", name, keys)
+		}
+	}
+}
+
+func TestChunkedMemorySearchReturnsParent(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	scope := resolver.Resolve("")
+
+	cfg := DefaultConfig()
+	cfg.Embeddings.ChunkSize = 40
+	cfg.Embeddings.ChunkOverlap = 10
+	if err := SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	idx := newExactIndex()
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	indexFor := func(Scope) (VectorIndex, error) { return idx, nil }
+	setUC := NewSetMemoryUseCase(resolver, repoFor, indexFor, StaticEmbedder(topicEmbedder{}), nil)
+	searchUC := NewSemanticSearchUseCase(resolver, indexFor, StaticEmbedder(topicEmbedder{}))
+
+	long := strings.Repeat("the deploy runbook covers many steps. ", 6) +
+		"the cluster runs on kubernetes with two kubernetes node pools."
+	if err := setUC.Execute(ctx, SetMemoryInput{Key: "ops/runbook", Content: long}); err != nil {
+		t.Fatalf("set long: %v", err)
+	}
+	if err := setUC.Execute(ctx, SetMemoryInput{Key: "ops/short", Content: "kubernetes"}); err != nil {
+		t.Fatalf("set short: %v", err)
+	}
+
+	chunks := len(SplitChunks(long, 40, 10))
+	if chunks < 3 {
+		t.Fatalf("long doc split into %d chunks, want several", chunks)
+	}
+	if idx.Contains(ctx, "ops/runbook") {
+		t.Error("chunked memory should not be indexed under its own key")
+	}
+	for n := range chunks {
+		if !idx.Contains(ctx, ChunkKey("ops/runbook", n)) {
+			t.Errorf("missing chunk %d", n)
+		}
+	}
+	if !idx.Contains(ctx, "ops/short") {
+		t.Error("short memory should be indexed under its own key")
+	}
+
+	out, err := searchUC.Execute(ctx, SearchInput{Query: "kubernetes", Limit: 2})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	var keys []string
+	for _, r := range out.Results {
+		keys = append(keys, r.Key)
+	}
+	if len(keys) != 2 || !slices.Contains(keys, "ops/runbook") || !slices.Contains(keys, "ops/short") {
+		t.Errorf("results = %v, want ops/runbook and ops/short once each", keys)
+	}
+
+	// Shrinking the memory drops its chunks.
+	if err := setUC.Execute(ctx, SetMemoryInput{Key: "ops/runbook", Content: "short now"}); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if !idx.Contains(ctx, "ops/runbook") || idx.Contains(ctx, ChunkKey("ops/runbook", 0)) {
+		t.Error("re-set memory should replace its chunks with a single entry")
+	}
+}
+
+func TestDeleteRemovesChunks(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	cfg := DefaultConfig()
+	cfg.Embeddings.ChunkSize = 10
+	if err := SaveConfig(resolver.Resolve(""), cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	idx := newExactIndex()
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	indexFor := func(Scope) (VectorIndex, error) { return idx, nil }
+
+	setUC := NewSetMemoryUseCase(resolver, repoFor, indexFor, StaticEmbedder(constEmbedder{}), nil)
+	if err := setUC.Execute(ctx, SetMemoryInput{Key: "doc", Content: strings.Repeat("x", 35)}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if idx.Len() != 4 {
+		t.Fatalf("index has %d entries, want 4 chunks", idx.Len())
+	}
+
+	deleteUC := NewDeleteMemoryUseCase(resolver, repoFor, indexFor)
+	if _, err := deleteUC.Execute(ctx, DeleteMemoryInput{Key: "doc"}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if idx.Len() != 0 {
+		t.Errorf("index has %d entries after delete, want 0", idx.Len())
+	}
+}
@@ -0,0 +1,39 @@
+package internal
+
+import (
+	"context"
+	"strings"
+	"text/template"
+)
+
+// commitMessageData is what git.message_template is executed with.
+type commitMessageData struct {
+	Op  string // the write, e.g. add, edit or set
+	Key string
+}
+
+// commitMessage renders the scope's git.message_template for op on key,
+// or returns fallback when none is configured. A template that fails to
+// render is logged and falls back too, as the write it describes has
+// already happened.
+func commitMessage(ctx context.Context, scope Scope, op, key, fallback string) string {
+	cfg, err := LoadConfig(scope)
+	if err != nil || cfg.Git.MessageTemplate == "" {
+		return fallback
+	}
+
+	tmpl, err := template.New("message_template").Option("missingkey=error").Parse(cfg.Git.MessageTemplate)
+	if err != nil {
+		LoggerFrom(ctx).Warn("ignoring git.message_template", "error", err)
+		return fallback
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, commitMessageData{Op: op, Key: key}); err != nil {
+		LoggerFrom(ctx).Warn("ignoring git.message_template", "error", err)
+		return fallback
+	}
+	if message := strings.TrimSpace(sb.String()); message != "" {
+		return message
+	}
+	return fallback
+}
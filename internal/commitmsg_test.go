@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCommitMessageTemplate(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	histFor := func(Scope) (HistoryRepository, error) { return repo, nil }
+	nilIndex := func(Scope) (VectorIndex, error) { return nil, ErrNoIndex }
+
+	setTemplate := func(tmpl string) {
+		t.Helper()
+		scope := resolver.Resolve("")
+		cfg, err := LoadConfig(scope)
+		if err != nil {
+			t.Fatalf("load config: %v", err)
+		}
+		cfg.Git.MessageTemplate = tmpl
+		if err := SaveConfig(scope, cfg); err != nil {
+			t.Fatalf("save config: %v", err)
+		}
+	}
+
+	add := NewAddMemoryUseCase(resolver, repoFor, histFor, nilIndex, nil, nil)
+	set := NewSetMemoryUseCase(resolver, repoFor, nilIndex, nil, nil)
+	commit := NewCommitUseCase(resolver, histFor)
+
+	tests := []struct {
+		name     string
+		template string
+		message  string // explicit -m
+		want     string
+	}{
+		{name: "default", want: "add: append to changelog"},
+		{name: "template", template: "chore({{.Op}}): {{.Key}}", want: "chore(add): changelog"},
+		{name: "explicit message wins", template: "chore({{.Op}}): {{.Key}}", message: "release notes", want: "release notes"},
+		{name: "unknown field falls back", template: "{{.Author}}: {{.Key}}", want: "add: append to changelog"},
+		{name: "blank output falls back", template: "{{/* nothing */}}", want: "add: append to changelog"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTemplate(tt.template)
+			out, err := add.Execute(ctx, AddMemoryInput{Key: "changelog", Content: "- " + tt.name, Message: tt.message})
+			if err != nil {
+				t.Fatalf("add: %v", err)
+			}
+			if out.Message != tt.want {
+				t.Errorf("message = %q, want %q", out.Message, tt.want)
+			}
+		})
+	}
+
+	// Commits made for a write, like the CLI's set, use it as well.
+	setTemplate("{{.Op}} {{.Key}} [mem]")
+	if err := set.Execute(ctx, SetMemoryInput{Key: "notes/a", Content: "a"}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	out, err := commit.Execute(ctx, CommitInput{Op: "set", Key: "notes/a"})
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if out.Message != "set notes/a [mem]" {
+		t.Errorf("set message = %q, want %q", out.Message, "set notes/a [mem]")
+	}
+}
@@ -0,0 +1,228 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ArchivePrefix is where compaction moves the memories it consumed when
+// asked to keep them.
+const ArchivePrefix = "archive/"
+
+type CompactInput struct {
+	Prefix    string
+	OlderThan time.Duration // only memories not updated for this long; zero means all
+	Target    string        // defaults to "knowledge/<prefix>"
+	Archive   bool          // move consumed memories under ArchivePrefix instead of deleting them
+	DryRun    bool
+	Scope     string
+	Provider  string
+	Model     string
+	Message   string
+	NoRedact  bool // sends content unredacted, ignoring the redaction config
+}
+
+type CompactOutput struct {
+	Target      string
+	Consumed    []string
+	Archived    []string // keys the consumed memories were moved to
+	InputTokens int      // estimated size of the prompt sent to the provider
+	DryRun      bool
+	Commit      *CommitOutput
+}
+
+// DefaultCompactTarget is the key a prefix is condensed into when no target
+// is given.
+func DefaultCompactTarget(prefix string) string {
+	return "knowledge/" + strings.Trim(prefix, "/")
+}
+
+// --- CompactUseCase ---
+
+// CompactUseCase condenses many small memories under a prefix, such as
+// hook output, into one document written by the provider. An existing
+// target is part of the prompt, so repeated runs extend it rather than
+// replacing it.
+type CompactUseCase struct {
+	resolver    *ScopeResolver
+	repoFor     func(Scope) (MemoryRepository, error)
+	histFor     func(Scope) (HistoryRepository, error)
+	indexFor    func(Scope) (VectorIndex, error)
+	embedder    EmbedderFunc
+	providerFor ProviderFunc
+}
+
+func NewCompactUseCase(
+	resolver *ScopeResolver,
+	repoFor func(Scope) (MemoryRepository, error),
+	histFor func(Scope) (HistoryRepository, error),
+	indexFor func(Scope) (VectorIndex, error),
+	embedder EmbedderFunc,
+	providerFor ProviderFunc,
+) *CompactUseCase {
+	return &CompactUseCase{
+		resolver:    resolver,
+		repoFor:     repoFor,
+		histFor:     histFor,
+		indexFor:    indexFor,
+		embedder:    embedder,
+		providerFor: providerFor,
+	}
+}
+
+func (uc *CompactUseCase) Execute(ctx context.Context, input CompactInput) (*CompactOutput, error) {
+	prefix := strings.Trim(input.Prefix, "/")
+	if prefix == "" {
+		return nil, fmt.Errorf("a prefix is required")
+	}
+	target, err := NewKey(input.Target)
+	if input.Target == "" {
+		target, err = NewKey(DefaultCompactTarget(prefix))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid target: %w", err)
+	}
+
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.repoFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+
+	memories, err := repo.List(ctx, prefix+"/")
+	if err != nil {
+		return nil, fmt.Errorf("list memories: %w", err)
+	}
+
+	cutoff := time.Now().Add(-input.OlderThan)
+	var consumed []*Memory
+	for _, mem := range memories {
+		if mem.Key == target || strings.HasPrefix(mem.Key.String(), ArchivePrefix) {
+			continue
+		}
+		if input.OlderThan > 0 && mem.UpdatedAt.After(cutoff) {
+			continue
+		}
+		consumed = append(consumed, mem)
+	}
+
+	var existing string
+	if mem, err := repo.Get(ctx, target); err == nil {
+		existing = string(mem.Content)
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("get target: %w", err)
+	}
+
+	redactor, err := promptRedactor(scope, input.NoRedact)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := compactPrompt(prefix, existing, consumed, redactor)
+	output := &CompactOutput{
+		Target:      target.String(),
+		InputTokens: len(prompt) / charsPerToken,
+		DryRun:      input.DryRun,
+	}
+	for _, mem := range consumed {
+		output.Consumed = append(output.Consumed, mem.Key.String())
+	}
+
+	if input.DryRun || len(consumed) == 0 {
+		return output, nil
+	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return nil, err
+	}
+	if uc.providerFor == nil {
+		return nil, ErrNoProvider
+	}
+	provider, err := uc.providerFor(ctx, scope, TaskCompact, ProviderOverride{Provider: input.Provider, Model: input.Model})
+	if err != nil {
+		return nil, err
+	}
+
+	document, err := provider.Complete(ctx, prompt)
+	if err != nil {
+		return nil, fmt.Errorf("condense memories: %w", err)
+	}
+	document = strings.TrimSpace(document) + "\n"
+
+	var index VectorIndex
+	if uc.indexFor != nil {
+		index, _ = uc.indexFor(scope)
+	}
+
+	if err := repo.Save(ctx, NewMemory(target, []byte(document))); err != nil {
+		return nil, fmt.Errorf("save %s: %w", target, err)
+	}
+	if embedder := uc.embedder.Get(); index != nil && embedder != nil {
+		if err := indexMemory(ctx, scope, index, embedder, target, document); err != nil {
+			skipIndexUpdate(ctx, scope, target, SkipEmbeddingFailed, err)
+		}
+	}
+
+	for _, mem := range consumed {
+		if input.Archive {
+			archived := Key(ArchivePrefix + mem.Key.String())
+			if err := repo.Save(ctx, &Memory{Key: archived, Content: mem.Content, Metadata: mem.Metadata}); err != nil {
+				return nil, fmt.Errorf("archive %s: %w", mem.Key, err)
+			}
+			output.Archived = append(output.Archived, archived.String())
+		}
+		if err := repo.Delete(ctx, mem.Key); err != nil {
+			return nil, fmt.Errorf("delete %s: %w", mem.Key, err)
+		}
+		if index != nil {
+			unindexMemory(ctx, index, mem.Key)
+		}
+	}
+
+	message := input.Message
+	if message == "" {
+		message = fmt.Sprintf("compact: %d memories from %s into %s", len(consumed), prefix, target)
+	}
+
+	hist, err := uc.histFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get history repository: %w", err)
+	}
+
+	// A re-run that condenses to the same document changes nothing.
+	commit, err := hist.Commit(ctx, message)
+	if errors.Is(err, ErrNothingToCommit) {
+		return output, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	output.Commit = &CommitOutput{
+		Hash:      commit.Hash,
+		Message:   commit.Message,
+		Timestamp: commit.Timestamp,
+	}
+	return output, nil
+}
+
+// compactPrompt asks for one document that keeps the durable facts of the
+// existing document and the memories, and drops the rest. Content passes
+// through redactor first.
+func compactPrompt(prefix, existing string, memories []*Memory, redactor *Redactor) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `Condense the following memories under %q into a single markdown document
+of durable knowledge. Keep decisions, facts and conventions that stay true;
+merge repeated points and drop transient details. Reply with the document only.
+`, prefix)
+	if existing != "" {
+		fmt.Fprintf(&sb, "\nThe current document, which the result replaces and must still cover:\n\n%s\n", redactor.Redact(existing))
+	}
+	sb.WriteString("\nMemories:\n\n")
+	for _, mem := range memories {
+		fmt.Fprintf(&sb, "## %s\n%s\n\n", mem.Key, redactor.Redact(string(mem.Content)))
+	}
+	return sb.String()
+}
@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func setupCompactTest(t *testing.T) (*GitRepository, *CompactUseCase, *string) {
+	t.Helper()
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	for _, k := range []string{"hooks/commits/a", "hooks/commits/b", "hooks/commits/new", "notes/keep"} {
+		key, _ := NewKey(k)
+		if err := repo.Save(ctx, NewMemory(key, []byte("content of "+k))); err != nil {
+			t.Fatalf("save %s: %v", k, err)
+		}
+		if k != "hooks/commits/new" {
+			if err := os.Chtimes(repo.keyToPath(key), old, old); err != nil {
+				t.Fatalf("chtimes: %v", err)
+			}
+		}
+	}
+	if _, err := repo.Commit(ctx, "add memories"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	var prompt string
+	provider := &mockProvider{completeFn: func(_ context.Context, p string) (string, error) {
+		prompt = p
+		return "# Commits\n\nCondensed.", nil
+	}}
+	uc := NewCompactUseCase(resolver,
+		func(Scope) (MemoryRepository, error) { return repo, nil },
+		func(Scope) (HistoryRepository, error) { return repo, nil },
+		nil, nil,
+		func(context.Context, Scope, string, ProviderOverride) (Provider, error) { return provider, nil })
+	return repo, uc, &prompt
+}
+
+func TestCompactArchivesOldMemories(t *testing.T) {
+	repo, uc, prompt := setupCompactTest(t)
+	ctx := context.Background()
+
+	out, err := uc.Execute(ctx, CompactInput{
+		Prefix: "hooks/commits", OlderThan: 30 * 24 * time.Hour, Target: "knowledge/commits", Archive: true,
+	})
+	if err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+
+	if want := []string{"hooks/commits/a", "hooks/commits/b"}; !slices.Equal(out.Consumed, want) {
+		t.Errorf("consumed = %v, want %v", out.Consumed, want)
+	}
+	if !strings.Contains(*prompt, "content of hooks/commits/a") || strings.Contains(*prompt, "hooks/commits/new") {
+		t.Errorf("prompt should hold only the old memories:\n%s", *prompt)
+	}
+	if out.Commit == nil {
+		t.Fatal("expected a commit")
+	}
+
+	target, err := repo.Get(ctx, "knowledge/commits")
+	if err != nil {
+		t.Fatalf("get target: %v", err)
+	}
+	if string(target.Content) != "# Commits\n\nCondensed.\n" {
+		t.Errorf("target = %q", target.Content)
+	}
+	if _, err := repo.Get(ctx, "hooks/commits/a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("original should be gone, got %v", err)
+	}
+	for _, k := range []Key{"archive/hooks/commits/a", "hooks/commits/new", "notes/keep"} {
+		if _, err := repo.Get(ctx, k); err != nil {
+			t.Errorf("get %s: %v", k, err)
+		}
+	}
+
+	if files, _ := repo.Uncommitted(ctx); len(files) != 0 {
+		t.Errorf("uncommitted after compact: %v", files)
+	}
+}
+
+func TestCompactDryRun(t *testing.T) {
+	repo, uc, prompt := setupCompactTest(t)
+	ctx := context.Background()
+
+	out, err := uc.Execute(ctx, CompactInput{Prefix: "hooks/commits/", DryRun: true})
+	if err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if len(out.Consumed) != 3 || out.Target != "knowledge/hooks/commits" || out.InputTokens == 0 {
+		t.Errorf("dry run = %+v", out)
+	}
+	if *prompt != "" {
+		t.Error("dry run must not call the provider")
+	}
+	if _, err := repo.Get(ctx, "hooks/commits/a"); err != nil {
+		t.Errorf("dry run must not delete: %v", err)
+	}
+}
+
+func TestCompactIncludesExistingTarget(t *testing.T) {
+	repo, uc, prompt := setupCompactTest(t)
+	ctx := context.Background()
+
+	if err := repo.Save(ctx, NewMemory("knowledge/commits", []byte("Earlier knowledge."))); err != nil {
+		t.Fatalf("save target: %v", err)
+	}
+	if _, err := uc.Execute(ctx, CompactInput{Prefix: "hooks/commits", Target: "knowledge/commits"}); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if !strings.Contains(*prompt, "Earlier knowledge.") {
+		t.Errorf("prompt should include the existing target:\n%s", *prompt)
+	}
+}
+
+func TestCompactRerunWithoutChangesCommitsNothing(t *testing.T) {
+	repo, uc, _ := setupCompactTest(t)
+	ctx := context.Background()
+
+	if _, err := uc.Execute(ctx, CompactInput{Prefix: "hooks/commits"}); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+
+	// A memory written and compacted away before any commit leaves the
+	// store as it was.
+	if err := repo.Save(ctx, NewMemory("hooks/commits/late", []byte("late"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	out, err := uc.Execute(ctx, CompactInput{Prefix: "hooks/commits"})
+	if err != nil {
+		t.Fatalf("re-run compact: %v", err)
+	}
+	if out.Commit != nil || !slices.Equal(out.Consumed, []string{"hooks/commits/late"}) {
+		t.Errorf("re-run = %+v, want late consumed and no commit", out)
+	}
+}
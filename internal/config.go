@@ -3,6 +3,8 @@ package internal
 import (
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
@@ -25,12 +27,40 @@ type EmbeddingsConfig struct {
 	ModelURL  string `yaml:"model_url,omitempty"`
 	Token     string `yaml:"token,omitempty"`
 	Dimension int    `yaml:"dimension"`
+	SearchK   int    `yaml:"search_k,omitempty"`
+	// NumTrees is how many Annoy trees a rebuild builds. More trees find
+	// better neighbours but take longer to build and more space.
+	NumTrees int `yaml:"num_trees,omitempty"`
+	// ChunkSize splits memories longer than this many characters into
+	// overlapping chunks that are indexed separately. 0 disables chunking.
+	ChunkSize    int `yaml:"chunk_size,omitempty"`
+	ChunkOverlap int `yaml:"chunk_overlap,omitempty"`
+	// Concurrency is how many embeddings run in parallel, each in its own
+	// context on the shared model. 0 or 1 embeds one text at a time.
+	Concurrency int `yaml:"concurrency,omitempty"`
 }
 
+// ProviderConfig configures an LLM provider. A nil Temperature or zero
+// MaxTokens leaves the provider's default in place, so temperature 0 can
+// still be set explicitly for reproducible output.
 type ProviderConfig struct {
-	APIKey  string `yaml:"api_key,omitempty"`
-	BaseURL string `yaml:"base_url,omitempty"`
-	Model   string `yaml:"model"`
+	// Type selects the implementation, e.g. openai for any OpenAI-compatible
+	// endpoint. Entries written before it existed are typed by their name.
+	Type        string   `yaml:"type,omitempty"`
+	APIKey      string   `yaml:"api_key,omitempty"`
+	BaseURL     string   `yaml:"base_url,omitempty"`
+	Model       string   `yaml:"model"`
+	Temperature *float64 `yaml:"temperature,omitempty"`
+	MaxTokens   int      `yaml:"max_tokens,omitempty"`
+}
+
+// TypeOr returns the provider type, falling back to the name the provider
+// is configured under.
+func (c ProviderConfig) TypeOr(name string) string {
+	if c.Type != "" {
+		return c.Type
+	}
+	return name
 }
 
 type PostCommitHookConfig struct {
@@ -40,19 +70,135 @@ type PostCommitHookConfig struct {
 	Script    string `yaml:"script,omitempty"`
 	KeyPrefix string `yaml:"key_prefix,omitempty"`
 	Quiet     bool   `yaml:"quiet,omitempty"`
+	// RateLimitPerMinute caps the provider calls the summarize and all
+	// strategies make, e.g. while a rebase replays many commits. Over the
+	// limit, summarize falls back to extract and all skips its summary.
+	// 0 means no limit.
+	RateLimitPerMinute int `yaml:"rate_limit_per_minute,omitempty"`
+	// ScriptTimeout bounds how long the script strategy waits for the
+	// script, e.g. 10s. 0 means DefaultScriptTimeout.
+	ScriptTimeout time.Duration `yaml:"script_timeout,omitempty"`
 }
 
 type HooksConfig struct {
 	PostCommit PostCommitHookConfig `yaml:"post-commit"`
 }
 
+type StorageConfig struct {
+	// Durable fsyncs every memory write before it is renamed into place.
+	Durable bool `yaml:"durable,omitempty"`
+	// ReadOnly rejects every command that would change the store, for
+	// stores shared with others or mounted read-only.
+	ReadOnly bool `yaml:"read_only,omitempty"`
+	// ListExcludes are .memignore-style patterns for files and directories
+	// in the store that listing skips. .git is always skipped.
+	ListExcludes []string `yaml:"list_excludes,omitempty"`
+}
+
+// IndexRemoteConfig points at an S3-compatible bucket the vector index is
+// pushed to and pulled from, so ephemeral machines need not rebuild it.
+type IndexRemoteConfig struct {
+	// Endpoint is the service URL, e.g. https://s3.eu-central-1.amazonaws.com
+	// or http://localhost:9000 for MinIO. Buckets are addressed by path.
+	Endpoint string `yaml:"endpoint,omitempty"`
+	Bucket   string `yaml:"bucket,omitempty"`
+	// Prefix is prepended to every object key, so stores can share a bucket.
+	Prefix string `yaml:"prefix,omitempty"`
+	// Region defaults to us-east-1.
+	Region string `yaml:"region,omitempty"`
+	// AccessKey and SecretKey default to $AWS_ACCESS_KEY_ID and
+	// $AWS_SECRET_ACCESS_KEY.
+	AccessKey string `yaml:"access_key,omitempty"`
+	SecretKey string `yaml:"secret_key,omitempty"`
+	// PullOnLoad fetches the index from the bucket when there is no local
+	// copy to load.
+	PullOnLoad bool `yaml:"pull_on_load,omitempty"`
+}
+
+// Enabled reports whether a remote is configured.
+func (c IndexRemoteConfig) Enabled() bool {
+	return c.Endpoint != "" && c.Bucket != ""
+}
+
+type EditorConfig struct {
+	// Extension names the temp file memories are edited in, so editors
+	// pick the right highlighting. Defaults to DefaultEditorExtension.
+	Extension string `yaml:"extension,omitempty"`
+	// Review makes mem edit show a diff of the change and ask before
+	// saving it, as if --review were given.
+	Review bool `yaml:"review,omitempty"`
+}
+
+// DefaultEditorExtension is used when the config sets no editor extension.
+const DefaultEditorExtension = ".md"
+
+// TempExtension returns the configured extension with a leading dot.
+func (c EditorConfig) TempExtension() string {
+	ext := c.Extension
+	if ext == "" {
+		return DefaultEditorExtension
+	}
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+	return ext
+}
+
+type AttachmentsConfig struct {
+	// Track commits attachment blobs to git. Untracked blobs stay on disk
+	// only and do not grow the history.
+	Track bool `yaml:"track,omitempty"`
+}
+
+// GitConfig configures the store's commits.
+type GitConfig struct {
+	// SigningKey is the path of an OpenPGP private key, as exported by
+	// gpg --export-secret-keys, that every commit is signed with.
+	SigningKey string `yaml:"signing_key,omitempty"`
+	// DefaultBranch names the branch a new store starts on; DefaultBranch
+	// if empty. It is read when the store is initialized.
+	DefaultBranch string `yaml:"default_branch,omitempty"`
+	// MessageTemplate is a text/template for the commit messages of
+	// writes made without -m, such as "{{.Op}}({{.Key}}): update". It sees
+	// Op, e.g. add, edit or set, and Key. Empty keeps the built-in messages.
+	MessageTemplate string `yaml:"message_template,omitempty"`
+}
+
 type Config struct {
 	Embeddings      EmbeddingsConfig          `yaml:"embeddings"`
 	Providers       map[string]ProviderConfig `yaml:"providers,omitempty"`
 	DefaultProvider string                    `yaml:"default_provider,omitempty"`
 	Hooks           HooksConfig               `yaml:"hooks,omitempty"`
+	Keys            KeyPolicy                 `yaml:"keys,omitempty"`
+	Aliases         map[string]string         `yaml:"aliases,omitempty"`
+	Storage         StorageConfig             `yaml:"storage,omitempty"`
+	Attachments     AttachmentsConfig         `yaml:"attachments,omitempty"`
+	Editor          EditorConfig              `yaml:"editor,omitempty"`
+	IndexRemote     IndexRemoteConfig         `yaml:"index_remote,omitempty"`
+	Git             GitConfig                 `yaml:"git,omitempty"`
+	Journal         JournalConfig             `yaml:"journal,omitempty"`
+	Redaction       RedactionConfig           `yaml:"redaction,omitempty"`
+	// Pager is the command long output is paged through, overriding
+	// $PAGER. "cat" turns paging off.
+	Pager string `yaml:"pager,omitempty"`
+	// Models gives tasks such as summarize or hook_summarize their own
+	// provider and model.
+	Models map[string]TaskModelConfig `yaml:"models,omitempty"`
+	// Prices maps model ids to their price, for mem provider usage.
+	Prices map[string]ModelPrice `yaml:"prices,omitempty"`
+	// Scopes names extra stores, name -> directory. Only read from the
+	// global config.
+	Scopes map[string]string `yaml:"scopes,omitempty"`
+	// MaxDepth bounds how many parent directories are searched for a
+	// project store; 0 searches up to the root. Only read from the global
+	// config, and overridden by $MEM_MAX_DEPTH and --depth.
+	MaxDepth int `yaml:"max_depth,omitempty"`
 }
 
+// DefaultNumTrees is used when neither the caller nor the config sets the
+// number of index trees.
+const DefaultNumTrees = 10
+
 func DefaultConfig() *Config {
 	return &Config{
 		Embeddings: EmbeddingsConfig{
@@ -60,6 +206,7 @@ func DefaultConfig() *Config {
 			Model:     DefaultModelFilename,
 			ModelURL:  DefaultModelURL,
 			Dimension: 768,
+			NumTrees:  DefaultNumTrees,
 		},
 		Providers: make(map[string]ProviderConfig),
 	}
@@ -88,6 +235,9 @@ func LoadConfig(scope Scope) (*Config, error) {
 	return &cfg, nil
 }
 
+// configHeader is written at the top of every config.yaml.
+const configHeader = "# mem configuration. See the Configuration section of the README for all options.\n\n"
+
 func SaveConfig(scope Scope, cfg *Config) error {
 	path := scope.ConfigPath()
 
@@ -96,9 +246,24 @@ func SaveConfig(scope Scope, cfg *Config) error {
 		return fmt.Errorf("marshal config: %w", err)
 	}
 
-	if err := os.WriteFile(path, data, 0644); err != nil {
+	if err := writeFileAtomic(path, append([]byte(configHeader), data...), true); err != nil {
 		return fmt.Errorf("write config: %w", err)
 	}
 
 	return nil
 }
+
+// EnsureConfig writes DefaultConfig to the scope's config.yaml unless one
+// already exists, and reports whether it wrote one.
+func EnsureConfig(scope Scope) (bool, error) {
+	if _, err := os.Stat(scope.ConfigPath()); err == nil {
+		return false, nil
+	} else if !os.IsNotExist(err) {
+		return false, fmt.Errorf("stat config: %w", err)
+	}
+
+	if err := SaveConfig(scope, DefaultConfig()); err != nil {
+		return false, err
+	}
+	return true, nil
+}
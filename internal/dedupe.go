@@ -0,0 +1,410 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultDedupeThreshold is the similarity above which two memories are
+// considered duplicates.
+const DefaultDedupeThreshold = 0.9
+
+// Similarity methods reported in DedupeOutput.
+const (
+	DedupeByEmbedding = "embedding"
+	DedupeByShingles  = "shingles"
+	DedupeByContent   = "identical"
+)
+
+const shingleSize = 3
+
+type DedupeInput struct {
+	Prefix    string
+	Threshold float64
+	Scope     string
+	Apply     bool
+	Message   string
+	// Identical groups only memories with the same content, ignoring
+	// Threshold. Apply then keeps the survivor as is and turns the others
+	// into alias memories pointing at it.
+	Identical bool
+}
+
+type DedupeCluster struct {
+	Survivor   string
+	Duplicates []string
+}
+
+type DedupeOutput struct {
+	Method   string
+	Clusters []DedupeCluster
+	Commit   *CommitOutput
+}
+
+// --- DedupeUseCase ---
+
+// DedupeUseCase groups near-identical memories. Similarity is the cosine of
+// the stored index vectors when every memory is indexed, and word-shingle
+// Jaccard similarity otherwise; with Identical, the content hash. The
+// newest memory of a cluster survives.
+type DedupeUseCase struct {
+	resolver *ScopeResolver
+	repoFor  func(Scope) (MemoryRepository, error)
+	histFor  func(Scope) (HistoryRepository, error)
+	indexFor func(Scope) (VectorIndex, error)
+	embedder EmbedderFunc
+}
+
+func NewDedupeUseCase(
+	resolver *ScopeResolver,
+	repoFor func(Scope) (MemoryRepository, error),
+	histFor func(Scope) (HistoryRepository, error),
+	indexFor func(Scope) (VectorIndex, error),
+	embedder EmbedderFunc,
+) *DedupeUseCase {
+	return &DedupeUseCase{
+		resolver: resolver,
+		repoFor:  repoFor,
+		histFor:  histFor,
+		indexFor: indexFor,
+		embedder: embedder,
+	}
+}
+
+func (uc *DedupeUseCase) Execute(ctx context.Context, input DedupeInput) (*DedupeOutput, error) {
+	threshold := input.Threshold
+	if threshold <= 0 {
+		threshold = DefaultDedupeThreshold
+	}
+	if threshold > 1 && !input.Identical {
+		return nil, fmt.Errorf("threshold must be between 0 and 1, got %g", threshold)
+	}
+
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.repoFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+
+	memories, err := repo.List(ctx, input.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("list memories: %w", err)
+	}
+	// Alias memories of one survivor look alike but are not duplicates.
+	memories = slices.DeleteFunc(memories, func(m *Memory) bool {
+		_, ok := aliasMemoryTarget(m.Content)
+		return ok
+	})
+
+	var index VectorIndex
+	if uc.indexFor != nil {
+		if idx, err := uc.indexFor(scope); err == nil {
+			index = idx
+		}
+	}
+
+	var clusters [][]*Memory
+	output := &DedupeOutput{Method: DedupeByContent}
+	if input.Identical {
+		clusters = identicalGroups(memories)
+	} else {
+		method, similar := uc.similarity(index, memories)
+		output.Method = method
+		clusters = clusterMemories(memories, threshold, similar)
+	}
+	for _, c := range clusters {
+		dc := DedupeCluster{Survivor: c[0].Key.String()}
+		for _, dup := range c[1:] {
+			dc.Duplicates = append(dc.Duplicates, dup.Key.String())
+		}
+		output.Clusters = append(output.Clusters, dc)
+	}
+
+	if !input.Apply || len(clusters) == 0 {
+		return output, nil
+	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return nil, err
+	}
+
+	if input.Identical {
+		return uc.aliasIdentical(ctx, scope, repo, index, clusters, input.Message, output)
+	}
+
+	for _, c := range clusters {
+		survivor := mergeCluster(c)
+		if err := repo.Save(ctx, survivor); err != nil {
+			return nil, fmt.Errorf("save %s: %w", survivor.Key, err)
+		}
+		for _, dup := range c[1:] {
+			if err := repo.Delete(ctx, dup.Key); err != nil {
+				return nil, fmt.Errorf("delete %s: %w", dup.Key, err)
+			}
+			if index != nil {
+				unindexMemory(ctx, index, dup.Key)
+			}
+		}
+		if embedder := uc.embedder.Get(); index != nil && embedder != nil {
+			if err := indexMemory(ctx, scope, index, embedder, survivor.Key, string(survivor.Content)); err != nil {
+				skipIndexUpdate(ctx, scope, survivor.Key, SkipEmbeddingFailed, err)
+			}
+		}
+	}
+
+	message := input.Message
+	if message == "" {
+		message = fmt.Sprintf("dedupe: merge %d clusters", len(clusters))
+	}
+
+	hist, err := uc.histFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get history repository: %w", err)
+	}
+
+	commit, err := hist.Commit(ctx, message)
+	if err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	output.Commit = &CommitOutput{
+		Hash:      commit.Hash,
+		Message:   commit.Message,
+		Timestamp: commit.Timestamp,
+	}
+	return output, nil
+}
+
+// aliasIdentical replaces every duplicate of the clusters, which hold
+// identical memories, with an alias memory for its survivor and commits.
+func (uc *DedupeUseCase) aliasIdentical(ctx context.Context, scope Scope, repo MemoryRepository, index VectorIndex, clusters [][]*Memory, message string, output *DedupeOutput) (*DedupeOutput, error) {
+	aliased := 0
+	for _, c := range clusters {
+		for _, dup := range c[1:] {
+			alias := &Memory{
+				Key:       dup.Key,
+				Content:   aliasMemoryContent(c[0].Key),
+				CreatedAt: dup.CreatedAt,
+				UpdatedAt: time.Now(),
+			}
+			if err := repo.Save(ctx, alias); err != nil {
+				return nil, fmt.Errorf("save %s: %w", dup.Key, err)
+			}
+			if index != nil {
+				unindexMemory(ctx, index, dup.Key)
+			}
+			aliased++
+		}
+	}
+
+	if message == "" {
+		message = fmt.Sprintf("dedupe: alias %d identical memories", aliased)
+	}
+
+	hist, err := uc.histFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get history repository: %w", err)
+	}
+	commit, err := hist.Commit(ctx, message)
+	if err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	output.Commit = &CommitOutput{
+		Hash:      commit.Hash,
+		Message:   commit.Message,
+		Timestamp: commit.Timestamp,
+	}
+	return output, nil
+}
+
+// similarity picks the comparison used for the given memories.
+func (uc *DedupeUseCase) similarity(index VectorIndex, memories []*Memory) (string, func(i, j int) float64) {
+	if vectors, ok := indexVectors(index, memories); ok {
+		return DedupeByEmbedding, func(i, j int) float64 { return cosine(vectors[i], vectors[j]) }
+	}
+
+	shingles := make([]map[string]bool, len(memories))
+	for i, mem := range memories {
+		shingles[i] = shingleSet(string(mem.Content))
+	}
+	return DedupeByShingles, func(i, j int) float64 { return jaccard(shingles[i], shingles[j]) }
+}
+
+// indexVectors returns the stored vector of every memory, or false if the
+// index cannot supply all of them.
+func indexVectors(index VectorIndex, memories []*Memory) ([][]float32, bool) {
+	source, ok := index.(interface{ Vector(Key) ([]float32, bool) })
+	if !ok || len(memories) == 0 {
+		return nil, false
+	}
+
+	vectors := make([][]float32, len(memories))
+	for i, mem := range memories {
+		vec, ok := source.Vector(mem.Key)
+		if !ok {
+			return nil, false
+		}
+		vectors[i] = vec
+	}
+	return vectors, true
+}
+
+// clusterMemories joins every pair at or above threshold and returns
+// clusters of two or more, each ordered survivor first.
+func clusterMemories(memories []*Memory, threshold float64, similar func(i, j int) float64) [][]*Memory {
+	parent := make([]int, len(memories))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := range memories {
+		for j := i + 1; j < len(memories); j++ {
+			if similar(i, j) >= threshold {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	groups := make(map[int][]*Memory)
+	for i, mem := range memories {
+		root := find(i)
+		groups[root] = append(groups[root], mem)
+	}
+	return orderClusters(groups)
+}
+
+// identicalGroups groups memories by content hash and returns groups of
+// two or more, each ordered survivor first. Alias memories are left out.
+func identicalGroups(memories []*Memory) [][]*Memory {
+	groups := make(map[string][]*Memory)
+	for _, mem := range memories {
+		if _, ok := aliasMemoryTarget(mem.Content); ok {
+			continue
+		}
+		hash := contentHash(mem.Content)
+		groups[hash] = append(groups[hash], mem)
+	}
+	return orderClusters(groups)
+}
+
+// contentHash returns the hex SHA-256 of content.
+func contentHash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// orderClusters drops groups of one, puts the newest memory of each group
+// first and sorts the groups by their first key.
+func orderClusters[K comparable](groups map[K][]*Memory) [][]*Memory {
+	var clusters [][]*Memory
+	for _, group := range groups {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Slice(group, func(a, b int) bool {
+			if !group[a].UpdatedAt.Equal(group[b].UpdatedAt) {
+				return group[a].UpdatedAt.After(group[b].UpdatedAt)
+			}
+			return group[a].Key < group[b].Key
+		})
+		clusters = append(clusters, group)
+	}
+
+	sort.Slice(clusters, func(a, b int) bool { return clusters[a][0].Key < clusters[b][0].Key })
+	return clusters
+}
+
+// mergeCluster appends lines from the duplicates that the survivor lacks.
+func mergeCluster(cluster []*Memory) *Memory {
+	survivor := cluster[0]
+	content := strings.TrimRight(string(survivor.Content), "\n")
+
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(content, "\n") {
+		seen[strings.TrimSpace(line)] = true
+	}
+
+	var extra []string
+	for _, dup := range cluster[1:] {
+		for _, line := range strings.Split(string(dup.Content), "\n") {
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" || seen[trimmed] {
+				continue
+			}
+			seen[trimmed] = true
+			extra = append(extra, line)
+		}
+	}
+
+	if len(extra) > 0 {
+		content += "\n" + strings.Join(extra, "\n")
+	}
+	if strings.HasSuffix(string(survivor.Content), "\n") {
+		content += "\n"
+	}
+
+	return &Memory{
+		Key:       survivor.Key,
+		Content:   []byte(content),
+		CreatedAt: survivor.CreatedAt,
+		UpdatedAt: time.Now(),
+	}
+}
+
+func shingleSet(text string) map[string]bool {
+	words := strings.Fields(strings.ToLower(text))
+	set := make(map[string]bool)
+	if len(words) < shingleSize {
+		if len(words) > 0 {
+			set[strings.Join(words, " ")] = true
+		}
+		return set
+	}
+	for i := 0; i+shingleSize <= len(words); i++ {
+		set[strings.Join(words[i:i+shingleSize], " ")] = true
+	}
+	return set
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 && len(b) == 0 {
+		return 1
+	}
+	inter := 0
+	for s := range a {
+		if b[s] {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+func cosine(a, b []float32) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
@@ -0,0 +1,291 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// saveAt saves a memory and backdates its file, which is where List reads
+// UpdatedAt from.
+func saveAt(t *testing.T, repo *GitRepository, key, content string, updated time.Time) {
+	t.Helper()
+	if err := repo.Save(context.Background(), NewMemory(Key(key), []byte(content))); err != nil {
+		t.Fatalf("save %s: %v", key, err)
+	}
+	if err := os.Chtimes(repo.keyToPath(Key(key)), updated, updated); err != nil {
+		t.Fatalf("chtimes %s: %v", key, err)
+	}
+}
+
+func TestDedupeShinglesPicksNewestSurvivor(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	repoFor := func(s Scope) (MemoryRepository, error) { return repo, nil }
+	histFor := func(s Scope) (HistoryRepository, error) { return repo, nil }
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	saveAt(t, repo, "notes/old", "deploy with make release then tag the build\nold detail", base)
+	saveAt(t, repo, "notes/new", "deploy with make release then tag the build", base.Add(time.Hour))
+	saveAt(t, repo, "notes/unrelated", "the cafeteria opens at nine", base)
+
+	uc := NewDedupeUseCase(resolver, repoFor, histFor, nil, nil)
+
+	out, err := uc.Execute(ctx, DedupeInput{Threshold: 0.6})
+	if err != nil {
+		t.Fatalf("dedupe: %v", err)
+	}
+	if out.Method != DedupeByShingles {
+		t.Errorf("method = %q, want %q", out.Method, DedupeByShingles)
+	}
+	if len(out.Clusters) != 1 {
+		t.Fatalf("clusters = %+v, want 1", out.Clusters)
+	}
+	if c := out.Clusters[0]; c.Survivor != "notes/new" || len(c.Duplicates) != 1 || c.Duplicates[0] != "notes/old" {
+		t.Errorf("cluster = %+v, want survivor notes/new with duplicate notes/old", c)
+	}
+	if out.Commit != nil {
+		t.Error("report-only run must not commit")
+	}
+
+	before, _ := repo.Log(ctx, 10)
+
+	out, err = uc.Execute(ctx, DedupeInput{Threshold: 0.6, Apply: true})
+	if err != nil {
+		t.Fatalf("dedupe apply: %v", err)
+	}
+	if out.Commit == nil {
+		t.Fatal("apply should commit")
+	}
+
+	after, _ := repo.Log(ctx, 10)
+	if len(after) != len(before)+1 {
+		t.Errorf("expected exactly one new commit, got %d", len(after)-len(before))
+	}
+
+	if exists, _ := repo.Exists(ctx, Key("notes/old")); exists {
+		t.Error("duplicate should be deleted")
+	}
+	merged, err := repo.Get(ctx, Key("notes/new"))
+	if err != nil {
+		t.Fatalf("get survivor: %v", err)
+	}
+	if !strings.Contains(string(merged.Content), "old detail") {
+		t.Errorf("survivor should gain distinct lines, got %q", merged.Content)
+	}
+	if strings.Count(string(merged.Content), "deploy with make release") != 1 {
+		t.Errorf("shared lines should not repeat, got %q", merged.Content)
+	}
+}
+
+func TestDedupeUsesIndexVectors(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	repoFor := func(s Scope) (MemoryRepository, error) { return repo, nil }
+	histFor := func(s Scope) (HistoryRepository, error) { return repo, nil }
+
+	idx, err := NewAnnoyIndex(t.TempDir(), 3)
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+	indexFor := func(s Scope) (VectorIndex, error) { return idx, nil }
+
+	// Texts differ completely, but their vectors are nearly parallel.
+	now := time.Now()
+	saveAt(t, repo, "a", "alpha", now)
+	saveAt(t, repo, "b", "beta", now)
+	saveAt(t, repo, "c", "gamma", now)
+	_ = idx.Add(ctx, Key("a"), Embedding{Vector: []float32{1, 0, 0}})
+	_ = idx.Add(ctx, Key("b"), Embedding{Vector: []float32{0.99, 0.01, 0}})
+	_ = idx.Add(ctx, Key("c"), Embedding{Vector: []float32{0, 0, 1}})
+
+	uc := NewDedupeUseCase(resolver, repoFor, histFor, indexFor, nil)
+	out, err := uc.Execute(ctx, DedupeInput{Threshold: 0.95})
+	if err != nil {
+		t.Fatalf("dedupe: %v", err)
+	}
+	if out.Method != DedupeByEmbedding {
+		t.Errorf("method = %q, want %q", out.Method, DedupeByEmbedding)
+	}
+	if len(out.Clusters) != 1 || len(out.Clusters[0].Duplicates) != 1 {
+		t.Fatalf("clusters = %+v, want one cluster of a and b", out.Clusters)
+	}
+	if got := out.Clusters[0].Survivor + out.Clusters[0].Duplicates[0]; got != "ab" && got != "ba" {
+		t.Errorf("cluster = %+v, want a and b", out.Clusters[0])
+	}
+}
+
+func TestDedupeRejectsBadThreshold(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	repoFor := func(s Scope) (MemoryRepository, error) { return repo, nil }
+
+	uc := NewDedupeUseCase(resolver, repoFor, nil, nil, nil)
+	if _, err := uc.Execute(context.Background(), DedupeInput{Threshold: 1.5}); err == nil {
+		t.Error("expected error for threshold > 1")
+	}
+}
+
+func TestDedupeIdenticalAliasesDuplicates(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	repoFor := func(s Scope) (MemoryRepository, error) { return repo, nil }
+	histFor := func(s Scope) (HistoryRepository, error) { return repo, nil }
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	saveAt(t, repo, "hooks/old", "feat: add parser", base)
+	saveAt(t, repo, "hooks/new", "feat: add parser", base.Add(time.Hour))
+	saveAt(t, repo, "hooks/mid", "feat: add parser", base.Add(time.Minute))
+	saveAt(t, repo, "hooks/near", "feat: add parser\n", base)
+
+	uc := NewDedupeUseCase(resolver, repoFor, histFor, nil, nil)
+	out, err := uc.Execute(ctx, DedupeInput{Identical: true, Threshold: 5, Apply: true})
+	if err != nil {
+		t.Fatalf("dedupe: %v", err)
+	}
+	if out.Method != DedupeByContent || len(out.Clusters) != 1 {
+		t.Fatalf("output = %+v, want one %s cluster", out, DedupeByContent)
+	}
+	if c := out.Clusters[0]; c.Survivor != "hooks/new" || strings.Join(c.Duplicates, " ") != "hooks/mid hooks/old" {
+		t.Errorf("cluster = %+v, want hooks/new keeping hooks/mid and hooks/old", c)
+	}
+	if out.Commit == nil || !strings.Contains(out.Commit.Message, "alias 2 identical memories") {
+		t.Errorf("commit = %+v, want one commit aliasing 2 memories", out.Commit)
+	}
+
+	get := NewGetMemoryUseCase(resolver, repoFor)
+	for _, key := range []string{"hooks/old", "hooks/mid"} {
+		mem, err := repo.Get(ctx, Key(key))
+		if err != nil {
+			t.Fatalf("get %s: %v", key, err)
+		}
+		if target, ok := aliasMemoryTarget(mem.Content); !ok || target != "hooks/new" {
+			t.Errorf("%s = %q, want an alias memory for hooks/new", key, mem.Content)
+		}
+
+		got, err := get.Execute(ctx, GetMemoryInput{Key: key})
+		if err != nil {
+			t.Fatalf("get %s: %v", key, err)
+		}
+		if got.Key != key || got.Content != "feat: add parser" || got.AliasOf != "hooks/new" {
+			t.Errorf("get %s = %+v, want the survivor's content via the alias", key, got)
+		}
+	}
+
+	// A second run finds nothing: alias memories are not duplicates.
+	out, err = uc.Execute(ctx, DedupeInput{Identical: true, Apply: true})
+	if err != nil {
+		t.Fatalf("dedupe again: %v", err)
+	}
+	if len(out.Clusters) != 0 || out.Commit != nil {
+		t.Errorf("second run = %+v, want no clusters and no commit", out)
+	}
+}
+
+func TestFollowAliasMemory(t *testing.T) {
+	repo, _ := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	for key, content := range map[string][]byte{
+		"a":       aliasMemoryContent("b"),
+		"b":       aliasMemoryContent("c"),
+		"c":       []byte("content"),
+		"loop/1":  aliasMemoryContent("loop/2"),
+		"loop/2":  aliasMemoryContent("loop/1"),
+		"dangles": aliasMemoryContent("gone"),
+	} {
+		if err := repo.Save(ctx, NewMemory(Key(key), content)); err != nil {
+			t.Fatalf("save %s: %v", key, err)
+		}
+	}
+
+	follow := func(key string) (*Memory, error) {
+		mem, err := repo.Get(ctx, Key(key))
+		if err != nil {
+			t.Fatalf("get %s: %v", key, err)
+		}
+		return followAliasMemory(ctx, repo, mem)
+	}
+
+	if mem, err := follow("a"); err != nil || mem.Key != "c" {
+		t.Errorf("follow a = %v, %v, want c through b", mem, err)
+	}
+	if mem, err := follow("c"); err != nil || mem.Key != "c" {
+		t.Errorf("follow c = %v, %v, want c itself", mem, err)
+	}
+	if _, err := follow("loop/1"); !errors.Is(err, ErrAliasCycle) {
+		t.Errorf("follow loop/1: err = %v, want ErrAliasCycle", err)
+	}
+	if _, err := follow("dangles"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("follow dangles: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestGetMemoryOpenFollowsAliasMemories(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	large := strings.Repeat("x", 3*aliasMemoryMaxLen) + "\n"
+	for key, content := range map[string][]byte{
+		"a":      aliasMemoryContent("b"),
+		"b":      []byte(large),
+		"marker": []byte(AliasMemoryMarker + "not a key\n" + large),
+		"loop/1": aliasMemoryContent("loop/2"),
+		"loop/2": aliasMemoryContent("loop/1"),
+	} {
+		if err := repo.Save(ctx, NewMemory(Key(key), content)); err != nil {
+			t.Fatalf("save %s: %v", key, err)
+		}
+	}
+
+	getUC := NewGetMemoryUseCase(resolver, func(Scope) (MemoryRepository, error) { return repo, nil })
+	read := func(key string) (string, error) {
+		rc, err := getUC.Open(ctx, GetMemoryInput{Key: key})
+		if err != nil {
+			return "", err
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		return string(data), err
+	}
+
+	if got, err := read("a"); err != nil || got != large {
+		t.Errorf("open a: %d bytes, %v; want the %d bytes of b", len(got), err, len(large))
+	}
+	if got, err := read("marker"); err != nil || got != AliasMemoryMarker+"not a key\n"+large {
+		t.Errorf("open marker: %d bytes, %v; want its own content", len(got), err)
+	}
+	if _, err := read("loop/1"); !errors.Is(err, ErrAliasCycle) {
+		t.Errorf("open loop/1: err = %v, want ErrAliasCycle", err)
+	}
+	if _, err := read("missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("open missing: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestWritesRefuseAliasMemoryContent(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	histFor := func(Scope) (HistoryRepository, error) { return repo, nil }
+	content := string(aliasMemoryContent("notes/other"))
+
+	set := NewSetMemoryUseCase(resolver, repoFor, nil, nil, nil)
+	if err := set.Execute(ctx, SetMemoryInput{Key: "notes/mine", Content: content}); !errors.Is(err, ErrAliasMemoryContent) {
+		t.Errorf("set: err = %v, want ErrAliasMemoryContent", err)
+	}
+	edit := NewEditMemoryUseCase(resolver, repoFor, histFor, nil, nil, nil)
+	if _, err := edit.Execute(ctx, EditMemoryInput{Key: "notes/mine", Content: content}); !errors.Is(err, ErrAliasMemoryContent) {
+		t.Errorf("edit: err = %v, want ErrAliasMemoryContent", err)
+	}
+	if exists, _ := repo.Exists(ctx, "notes/mine"); exists {
+		t.Error("a refused write saved the memory")
+	}
+
+	if err := set.Execute(ctx, SetMemoryInput{Key: "notes/mine", Content: "see " + content}); err != nil {
+		t.Errorf("set with the marker mid-content: %v", err)
+	}
+}
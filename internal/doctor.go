@@ -0,0 +1,266 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type DoctorInput struct {
+	Scope string
+}
+
+type DoctorOutput struct {
+	Memories int
+	// Collisions groups keys that differ only in case. They are distinct
+	// on Linux but the same file on case-insensitive filesystems.
+	Collisions [][]string
+	// Nonconforming lists keys the key policy would rename, such as
+	// mixed-case keys under case_sensitivity: lower.
+	Nonconforming []string
+	// LegacyGlobal is the ~/.mem global store in use although
+	// $XDG_DATA_HOME is set, and XDGGlobal where it can be moved. It is a
+	// hint, not a problem.
+	LegacyGlobal string
+	XDGGlobal    string
+}
+
+// Problems counts the collisions and nonconforming keys found.
+func (o *DoctorOutput) Problems() int {
+	return len(o.Collisions) + len(o.Nonconforming)
+}
+
+// caseCollisions groups keys that fold to the same lower-case key, in key
+// order.
+func caseCollisions(keys []Key) [][]string {
+	folded := make(map[string][]string)
+	var order []string
+	for _, key := range keys {
+		f := strings.ToLower(key.String())
+		if _, ok := folded[f]; !ok {
+			order = append(order, f)
+		}
+		folded[f] = append(folded[f], key.String())
+	}
+
+	var groups [][]string
+	for _, f := range order {
+		if len(folded[f]) > 1 {
+			groups = append(groups, folded[f])
+		}
+	}
+	return groups
+}
+
+func memoryKeys(memories []*Memory) []Key {
+	keys := make([]Key, len(memories))
+	for i, mem := range memories {
+		keys[i] = mem.Key
+	}
+	return keys
+}
+
+// --- DoctorUseCase ---
+
+// DoctorUseCase checks the keys in a store for problems that only show up
+// when the store is synced between machines.
+type DoctorUseCase struct {
+	resolver *ScopeResolver
+	repoFor  func(Scope) (MemoryRepository, error)
+}
+
+func NewDoctorUseCase(
+	resolver *ScopeResolver,
+	repoFor func(Scope) (MemoryRepository, error),
+) *DoctorUseCase {
+	return &DoctorUseCase{
+		resolver: resolver,
+		repoFor:  repoFor,
+	}
+}
+
+func (uc *DoctorUseCase) Execute(ctx context.Context, input DoctorInput) (*DoctorOutput, error) {
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.repoFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	memories, err := repo.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("list memories: %w", err)
+	}
+	keys := memoryKeys(memories)
+
+	output := &DoctorOutput{
+		Memories:   len(memories),
+		Collisions: caseCollisions(keys),
+	}
+	if legacy, ok := uc.resolver.LegacyGlobal(); ok {
+		output.LegacyGlobal = legacy
+		output.XDGGlobal = uc.resolver.XDGGlobal()
+	}
+	for _, key := range keys {
+		if cfg.Keys.Normalize(key) != key {
+			output.Nonconforming = append(output.Nonconforming, key.String())
+		}
+	}
+	return output, nil
+}
+
+// --- MigrateKeysUseCase ---
+
+type MigrateKeysInput struct {
+	Scope   string
+	DryRun  bool
+	Message string
+}
+
+type KeyRename struct {
+	From string
+	To   string
+}
+
+type MigrateKeysOutput struct {
+	Renamed []KeyRename
+	DryRun  bool
+	Commit  *CommitOutput
+}
+
+// ErrKeyCollision is returned when renaming keys to the key policy would
+// make two memories share a key.
+var ErrKeyCollision = errors.New("keys collide")
+
+// MigrateKeysUseCase renames the memories whose keys do not conform to the
+// key policy, for example after switching case_sensitivity to lower, and
+// commits the renames at once.
+type MigrateKeysUseCase struct {
+	resolver *ScopeResolver
+	repoFor  func(Scope) (MemoryRepository, error)
+	histFor  func(Scope) (HistoryRepository, error)
+	indexFor func(Scope) (VectorIndex, error)
+	embedder EmbedderFunc
+}
+
+func NewMigrateKeysUseCase(
+	resolver *ScopeResolver,
+	repoFor func(Scope) (MemoryRepository, error),
+	histFor func(Scope) (HistoryRepository, error),
+	indexFor func(Scope) (VectorIndex, error),
+	embedder EmbedderFunc,
+) *MigrateKeysUseCase {
+	return &MigrateKeysUseCase{
+		resolver: resolver,
+		repoFor:  repoFor,
+		histFor:  histFor,
+		indexFor: indexFor,
+		embedder: embedder,
+	}
+}
+
+func (uc *MigrateKeysUseCase) Execute(ctx context.Context, input MigrateKeysInput) (*MigrateKeysOutput, error) {
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.repoFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		return nil, fmt.Errorf("load config: %w", err)
+	}
+
+	memories, err := repo.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("list memories: %w", err)
+	}
+
+	targets := make(map[Key][]string, len(memories))
+	var renames []*Memory
+	for _, mem := range memories {
+		to := cfg.Keys.Normalize(mem.Key)
+		targets[to] = append(targets[to], mem.Key.String())
+		if to != mem.Key {
+			renames = append(renames, mem)
+		}
+	}
+	var collisions []string
+	for to, from := range targets {
+		if len(from) > 1 {
+			collisions = append(collisions, fmt.Sprintf("%s <- %s", to, strings.Join(from, ", ")))
+		}
+	}
+	if len(collisions) > 0 {
+		slices.Sort(collisions)
+		return nil, fmt.Errorf("%w, merge or delete them first:\n  %s", ErrKeyCollision, strings.Join(collisions, "\n  "))
+	}
+
+	output := &MigrateKeysOutput{DryRun: input.DryRun}
+	for _, mem := range renames {
+		to := cfg.Keys.Normalize(mem.Key)
+		if err := cfg.Keys.Validate(to); err != nil {
+			return nil, err
+		}
+		output.Renamed = append(output.Renamed, KeyRename{From: mem.Key.String(), To: to.String()})
+	}
+	if input.DryRun || len(renames) == 0 {
+		return output, nil
+	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return nil, err
+	}
+
+	var index VectorIndex
+	if uc.indexFor != nil {
+		index, _ = uc.indexFor(scope)
+	}
+
+	// Remove every old file before writing any new one: on a
+	// case-insensitive filesystem the old and new key are the same file.
+	for _, mem := range renames {
+		if err := repo.Delete(ctx, mem.Key); err != nil {
+			return nil, fmt.Errorf("delete %s: %w", mem.Key, err)
+		}
+		if index != nil {
+			unindexMemory(ctx, index, mem.Key)
+		}
+	}
+	for _, mem := range renames {
+		to := cfg.Keys.Normalize(mem.Key)
+		if err := repo.Save(ctx, &Memory{Key: to, Content: mem.Content, Metadata: mem.Metadata}); err != nil {
+			return nil, fmt.Errorf("save %s: %w", to, err)
+		}
+		if embedder := uc.embedder.Get(); index != nil && embedder != nil {
+			if err := indexMemory(ctx, scope, index, embedder, to, string(mem.Content)); err != nil {
+				skipIndexUpdate(ctx, scope, to, SkipEmbeddingFailed, err)
+			}
+		}
+	}
+
+	message := input.Message
+	if message == "" {
+		message = fmt.Sprintf("migrate-keys: rename %d memories", len(renames))
+	}
+
+	hist, err := uc.histFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get history repository: %w", err)
+	}
+
+	commit, err := hist.Commit(ctx, message)
+	if err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	output.Commit = &CommitOutput{
+		Hash:      commit.Hash,
+		Message:   commit.Message,
+		Timestamp: commit.Timestamp,
+	}
+	return output, nil
+}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func setupDoctorTest(t *testing.T, keys ...string) (*GitRepository, *ScopeResolver) {
+	t.Helper()
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	for _, k := range keys {
+		if err := repo.Save(ctx, NewMemory(Key(k), []byte("content of "+k))); err != nil {
+			t.Fatalf("save %s: %v", k, err)
+		}
+	}
+	if _, err := repo.Commit(ctx, "add memories"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	return repo, resolver
+}
+
+func setCaseLower(t *testing.T, resolver *ScopeResolver) {
+	t.Helper()
+	cfg := DefaultConfig()
+	cfg.Keys = KeyPolicy{CaseSensitivity: CaseLower}
+	if err := SaveConfig(resolver.Resolve(""), cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+}
+
+func TestDoctorReportsCaseCollisions(t *testing.T) {
+	repo, resolver := setupDoctorTest(t, "Notes/todo", "notes/todo", "notes/ideas")
+	uc := NewDoctorUseCase(resolver, func(Scope) (MemoryRepository, error) { return repo, nil })
+
+	out, err := uc.Execute(context.Background(), DoctorInput{})
+	if err != nil {
+		t.Fatalf("doctor: %v", err)
+	}
+	if len(out.Collisions) != 1 || !slices.Equal(out.Collisions[0], []string{"Notes/todo", "notes/todo"}) {
+		t.Errorf("collisions = %v", out.Collisions)
+	}
+	if len(out.Nonconforming) != 0 {
+		t.Errorf("the default policy accepts mixed case, got %v", out.Nonconforming)
+	}
+
+	setCaseLower(t, resolver)
+	out, err = uc.Execute(context.Background(), DoctorInput{})
+	if err != nil {
+		t.Fatalf("doctor: %v", err)
+	}
+	if !slices.Equal(out.Nonconforming, []string{"Notes/todo"}) {
+		t.Errorf("nonconforming = %v", out.Nonconforming)
+	}
+}
+
+func newMigrateKeysTest(repo *GitRepository, resolver *ScopeResolver) *MigrateKeysUseCase {
+	return NewMigrateKeysUseCase(resolver,
+		func(Scope) (MemoryRepository, error) { return repo, nil },
+		func(Scope) (HistoryRepository, error) { return repo, nil },
+		nil, nil)
+}
+
+func TestDoctorHintsLegacyGlobal(t *testing.T) {
+	repo, resolver := setupDoctorTest(t, "notes/a")
+	home := t.TempDir()
+	dataHome := t.TempDir()
+	if err := os.Mkdir(filepath.Join(home, ".mem"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", home)
+	t.Setenv("MEM_HOME", "")
+	t.Setenv("XDG_DATA_HOME", dataHome)
+
+	project, _ := resolver.Project()
+	uc := NewDoctorUseCase(NewScopeResolverAt(project.Path, ""), func(Scope) (MemoryRepository, error) { return repo, nil })
+	out, err := uc.Execute(context.Background(), DoctorInput{})
+	if err != nil {
+		t.Fatalf("doctor: %v", err)
+	}
+	if out.LegacyGlobal != filepath.Join(home, ".mem") || out.XDGGlobal != filepath.Join(dataHome, "mem") {
+		t.Errorf("LegacyGlobal = %q, XDGGlobal = %q", out.LegacyGlobal, out.XDGGlobal)
+	}
+	if out.Problems() != 0 {
+		t.Errorf("the hint counted as %d problems", out.Problems())
+	}
+}
+
+func TestMigrateKeysRenamesInOneCommit(t *testing.T) {
+	repo, resolver := setupDoctorTest(t, "Notes/Todo", "ADR/001", "notes/ideas")
+	setCaseLower(t, resolver)
+	ctx := context.Background()
+
+	before, _ := repo.Log(ctx, 0)
+	out, err := newMigrateKeysTest(repo, resolver).Execute(ctx, MigrateKeysInput{})
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if len(out.Renamed) != 2 || out.Commit == nil {
+		t.Fatalf("migrate = %+v", out)
+	}
+	if after, _ := repo.Log(ctx, 0); len(after) != len(before)+1 {
+		t.Errorf("expected one commit, got %d", len(after)-len(before))
+	}
+
+	keys, err := repo.ListKeys(ctx, "")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var got []string
+	for _, k := range keys {
+		got = append(got, k.Key.String())
+	}
+	if want := []string{"adr/001", "notes/ideas", "notes/todo"}; !slices.Equal(got, want) {
+		t.Errorf("keys = %v, want %v", got, want)
+	}
+	if mem, err := repo.Get(ctx, "notes/todo"); err != nil || string(mem.Content) != "content of Notes/Todo" {
+		t.Errorf("renamed memory = %v, %v", mem, err)
+	}
+}
+
+func TestMigrateKeysRefusesCollisions(t *testing.T) {
+	repo, resolver := setupDoctorTest(t, "Notes/todo", "notes/todo")
+	setCaseLower(t, resolver)
+
+	_, err := newMigrateKeysTest(repo, resolver).Execute(context.Background(), MigrateKeysInput{})
+	if !errors.Is(err, ErrKeyCollision) {
+		t.Fatalf("expected ErrKeyCollision, got %v", err)
+	}
+	if _, err := repo.Get(context.Background(), "Notes/todo"); err != nil {
+		t.Errorf("nothing should be renamed: %v", err)
+	}
+}
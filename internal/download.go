@@ -82,41 +82,32 @@ func (d *Downloader) download(ctx context.Context, url, dest string, onProgress
 		return fmt.Errorf("download failed: status %d", resp.StatusCode)
 	}
 
-	tmpFile := dest + ".tmp"
-	f, err := os.Create(tmpFile)
-	if err != nil {
-		return fmt.Errorf("create temp file: %w", err)
-	}
-
 	pw := &ProgressWriter{
 		Total:      resp.ContentLength,
 		OnProgress: onProgress,
 	}
 
-	_, err = io.Copy(f, io.TeeReader(resp.Body, pw))
-	closeErr := f.Close()
-
+	err = writeAtomic(dest, true, func(w io.Writer) error {
+		_, err := io.Copy(w, io.TeeReader(resp.Body, pw))
+		return err
+	})
 	if err != nil {
-		os.Remove(tmpFile)
 		return fmt.Errorf("write file: %w", err)
 	}
-	if closeErr != nil {
-		os.Remove(tmpFile)
-		return fmt.Errorf("close file: %w", closeErr)
-	}
-
-	if err := os.Rename(tmpFile, dest); err != nil {
-		os.Remove(tmpFile)
-		return fmt.Errorf("rename file: %w", err)
-	}
 
 	return nil
 }
 
+// DefaultCacheDir is where downloaded models go: $XDG_CACHE_HOME/mem/models
+// if set, and the platform's user cache directory otherwise.
 func DefaultCacheDir() (string, error) {
-	cacheDir, err := os.UserCacheDir()
-	if err != nil {
-		return "", err
+	// os.UserCacheDir only honors $XDG_CACHE_HOME on Unix other than macOS.
+	cacheDir := xdgDir("XDG_CACHE_HOME")
+	if cacheDir == "" {
+		var err error
+		if cacheDir, err = os.UserCacheDir(); err != nil {
+			return "", err
+		}
 	}
 	return filepath.Join(cacheDir, "mem", "models"), nil
 }
@@ -1,9 +1,17 @@
 package internal
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
+	"slices"
+	"sort"
+	"strings"
 
 	"charm.land/fantasy"
 	"charm.land/fantasy/providers/anthropic"
@@ -12,11 +20,47 @@ import (
 	"charm.land/fantasy/schema"
 )
 
+var (
+	ErrUnsupportedProvider = errors.New("unsupported provider type")
+	ErrNoObject            = errors.New("model returned no object")
+)
+
+// providerTypes are the provider types NewFantasyProvider can construct.
+var providerTypes = []string{"anthropic", "openai", "openrouter"}
+
+// ProviderTypes returns the supported provider types in sorted order.
+func ProviderTypes() []string {
+	return slices.Clone(providerTypes)
+}
+
+// ValidateProviderType fails with ErrUnsupportedProvider unless t is one of
+// ProviderTypes.
+func ValidateProviderType(t string) error {
+	if !slices.Contains(providerTypes, t) {
+		return fmt.Errorf("%w %q; valid types: %s", ErrUnsupportedProvider, t, strings.Join(providerTypes, ", "))
+	}
+	return nil
+}
+
 type FantasyConfig struct {
-	Provider string
-	APIKey   string
-	BaseURL  string
-	Model    string
+	Provider    string
+	APIKey      string
+	BaseURL     string
+	Model       string
+	Temperature *float64
+	MaxTokens   int
+}
+
+// NewFantasyConfig builds the config for the provider stored under name.
+func NewFantasyConfig(name string, cfg ProviderConfig) FantasyConfig {
+	return FantasyConfig{
+		Provider:    cfg.TypeOr(name),
+		APIKey:      cfg.APIKey,
+		BaseURL:     cfg.BaseURL,
+		Model:       cfg.Model,
+		Temperature: cfg.Temperature,
+		MaxTokens:   cfg.MaxTokens,
+	}
 }
 
 var _ Provider = (*FantasyProvider)(nil)
@@ -24,6 +68,7 @@ var _ Provider = (*FantasyProvider)(nil)
 type FantasyProvider struct {
 	model fantasy.LanguageModel
 	name  string
+	cfg   FantasyConfig
 }
 
 func NewFantasyProvider(ctx context.Context, cfg FantasyConfig) (*FantasyProvider, error) {
@@ -50,21 +95,22 @@ func NewFantasyProvider(ctx context.Context, cfg FantasyConfig) (*FantasyProvide
 		provider, err = openrouter.New(opts...)
 
 	default:
-		return nil, fmt.Errorf("unsupported provider: %s", cfg.Provider)
+		return nil, ValidateProviderType(cfg.Provider)
 	}
 
 	if err != nil {
-		return nil, fmt.Errorf("create provider: %w", err)
+		return nil, fmt.Errorf("%w: create provider: %w", ErrProviderUnavailable, err)
 	}
 
 	model, err := provider.LanguageModel(ctx, cfg.Model)
 	if err != nil {
-		return nil, fmt.Errorf("get language model: %w", err)
+		return nil, fmt.Errorf("%w: get language model: %w", ErrProviderUnavailable, err)
 	}
 
 	return &FantasyProvider{
 		model: model,
 		name:  cfg.Provider,
+		cfg:   cfg,
 	}, nil
 }
 
@@ -72,43 +118,116 @@ func (p *FantasyProvider) Complete(ctx context.Context, prompt string) (string,
 	agent := fantasy.NewAgent(p.model)
 
 	result, err := agent.Generate(ctx, fantasy.AgentCall{
-		Prompt: prompt,
+		Prompt:          prompt,
+		Temperature:     p.cfg.Temperature,
+		MaxOutputTokens: p.maxOutputTokens(),
 	})
 	if err != nil {
-		return "", fmt.Errorf("generate: %w", err)
+		return "", fmt.Errorf("%w: generate: %w", ErrProviderUnavailable, err)
 	}
+	addUsage(ctx, result.TotalUsage.InputTokens, result.TotalUsage.OutputTokens)
 
 	return result.Response.Content.Text(), nil
 }
 
+// GenerateObject fills target from the model's native object mode. When
+// the provider has none, or the model answers without a usable object, it
+// asks for the JSON in plain text instead.
 func (p *FantasyProvider) GenerateObject(ctx context.Context, prompt string, target any) error {
-	t := reflect.TypeOf(target)
-	if t.Kind() == reflect.Ptr {
-		t = t.Elem()
+	targetVal := reflect.ValueOf(target)
+	if targetVal.Kind() != reflect.Ptr || targetVal.IsNil() {
+		return fmt.Errorf("target must be a non-nil pointer")
 	}
 
-	s := schema.Generate(t)
+	s := schema.Generate(targetVal.Type().Elem())
 
 	call := fantasy.ObjectCall{
-		Prompt: fantasy.Prompt{fantasy.NewUserMessage(prompt)},
-		Schema: s,
+		Prompt:          fantasy.Prompt{fantasy.NewUserMessage(prompt)},
+		Schema:          s,
+		Temperature:     p.cfg.Temperature,
+		MaxOutputTokens: p.maxOutputTokens(),
 	}
 
 	resp, err := p.model.GenerateObject(ctx, call)
+	if err == nil {
+		addUsage(ctx, resp.Usage.InputTokens, resp.Usage.OutputTokens)
+		if err = decodeObject(resp, target); err == nil {
+			return nil
+		}
+	}
+
+	if textErr := p.generateObjectText(ctx, prompt, s, target); textErr != nil {
+		return fmt.Errorf("generate object: %w; text fallback: %w", err, textErr)
+	}
+	return nil
+}
+
+// decodeObject copies the object of resp into target. Providers hand back
+// either the target's own type or decoded JSON, so anything else goes
+// through a JSON round trip.
+func decodeObject(resp *fantasy.ObjectResponse, target any) error {
+	if resp == nil || (resp.Object == nil && strings.TrimSpace(resp.RawText) == "") {
+		return ErrNoObject
+	}
+
+	targetVal := reflect.ValueOf(target).Elem()
+	if resp.Object != nil {
+		objVal := reflect.ValueOf(resp.Object)
+		if objVal.Type().AssignableTo(targetVal.Type()) {
+			targetVal.Set(objVal)
+			return nil
+		}
+		data, err := json.Marshal(resp.Object)
+		if err != nil {
+			return fmt.Errorf("%w: %T is not assignable to %s", ErrNoObject, resp.Object, targetVal.Type())
+		}
+		return unmarshalJSONText(string(data), target)
+	}
+	return unmarshalJSONText(resp.RawText, target)
+}
+
+// generateObjectText asks for the object as JSON text matching s and
+// retries once, quoting the parse error, when the reply is not valid JSON.
+func (p *FantasyProvider) generateObjectText(ctx context.Context, prompt string, s fantasy.Schema, target any) error {
+	schemaJSON, err := json.Marshal(s)
 	if err != nil {
-		return fmt.Errorf("generate object: %w", err)
+		return fmt.Errorf("encode schema: %w", err)
 	}
+	prompt += "\n\nRespond with only a JSON object matching this JSON schema, without any other text:\n" + string(schemaJSON)
 
-	targetVal := reflect.ValueOf(target)
-	if targetVal.Kind() != reflect.Ptr {
-		return fmt.Errorf("target must be a pointer")
+	text, err := p.Complete(ctx, prompt)
+	if err != nil {
+		return err
+	}
+	parseErr := unmarshalJSONText(text, target)
+	if parseErr == nil {
+		return nil
 	}
 
-	objVal := reflect.ValueOf(resp.Object)
-	if objVal.IsValid() && objVal.Type().AssignableTo(targetVal.Elem().Type()) {
-		targetVal.Elem().Set(objVal)
+	retry := prompt + "\n\nYour previous reply could not be parsed (" + parseErr.Error() + "):\n" + text +
+		"\n\nReply again with only the corrected JSON object."
+	text, err = p.Complete(ctx, retry)
+	if err != nil {
+		return err
 	}
+	return unmarshalJSONText(text, target)
+}
 
+// unmarshalJSONText decodes a JSON reply into target, ignoring surrounding
+// whitespace and a Markdown code fence.
+func unmarshalJSONText(text string, target any) error {
+	text = strings.TrimSpace(text)
+	if rest, ok := strings.CutPrefix(text, "```"); ok {
+		// Drop the fence line, which may name the language, and the closing fence.
+		_, rest, _ = strings.Cut(rest, "\n")
+		text = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(rest), "```"))
+	}
+	if text == "" {
+		return ErrNoObject
+	}
+	if err := json.Unmarshal([]byte(text), target); err != nil {
+		return fmt.Errorf("parse object: %w", err)
+	}
 	return nil
 }
 
@@ -120,8 +239,10 @@ func (p *FantasyProvider) Stream(ctx context.Context, prompt string) (<-chan str
 	go func() {
 		defer close(ch)
 
-		_, err := agent.Stream(ctx, fantasy.AgentStreamCall{
-			Prompt: prompt,
+		result, err := agent.Stream(ctx, fantasy.AgentStreamCall{
+			Prompt:          prompt,
+			Temperature:     p.cfg.Temperature,
+			MaxOutputTokens: p.maxOutputTokens(),
 			OnTextDelta: func(_, text string) error {
 				if text != "" {
 					ch <- text
@@ -131,8 +252,120 @@ func (p *FantasyProvider) Stream(ctx context.Context, prompt string) (<-chan str
 		})
 		if err != nil {
 			ch <- fmt.Sprintf("\n[error: %v]", err)
+			return
 		}
+		addUsage(ctx, result.TotalUsage.InputTokens, result.TotalUsage.OutputTokens)
 	}()
 
 	return ch, nil
 }
+
+// maxOutputTokens returns nil when MaxTokens is unset so the provider
+// default applies.
+func (p *FantasyProvider) maxOutputTokens() *int64 {
+	if p.cfg.MaxTokens <= 0 {
+		return nil
+	}
+	n := int64(p.cfg.MaxTokens)
+	return &n
+}
+
+// Models lists model ids from the OpenAI-compatible /models endpoint for
+// providers that have one.
+func (p *FantasyProvider) Models(ctx context.Context) ([]string, error) {
+	switch p.name {
+	case "openai":
+		return listModels(ctx, cmp.Or(p.cfg.BaseURL, openai.DefaultURL), p.cfg.APIKey)
+	case "openrouter":
+		return listModels(ctx, cmp.Or(p.cfg.BaseURL, openrouter.DefaultURL), p.cfg.APIKey)
+	default:
+		return nil, fmt.Errorf("%s: %w", p.name, ErrModelsUnsupported)
+	}
+}
+
+// Embed requests an embedding of text from the OpenAI-compatible
+// /embeddings endpoint for providers that have one.
+func (p *FantasyProvider) Embed(ctx context.Context, model, text string) ([]float32, error) {
+	switch p.name {
+	case "openai":
+		return createEmbedding(ctx, cmp.Or(p.cfg.BaseURL, openai.DefaultURL), p.cfg.APIKey, model, text)
+	case "openrouter":
+		return createEmbedding(ctx, cmp.Or(p.cfg.BaseURL, openrouter.DefaultURL), p.cfg.APIKey, model, text)
+	default:
+		return nil, fmt.Errorf("%s: %w", p.name, ErrEmbeddingsUnsupported)
+	}
+}
+
+func createEmbedding(ctx context.Context, baseURL, apiKey, model, text string) ([]float32, error) {
+	body, err := json.Marshal(map[string]string{"model": model, "input": text})
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(baseURL, "/")+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("create embedding: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: create embedding: unexpected status %s", ErrProviderUnavailable, resp.Status)
+	}
+
+	var payload struct {
+		Data []struct {
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("decode embedding: %w", err)
+	}
+	if len(payload.Data) == 0 || len(payload.Data[0].Embedding) == 0 {
+		return nil, errors.New("create embedding: empty response")
+	}
+	return payload.Data[0].Embedding, nil
+}
+
+func listModels(ctx context.Context, baseURL, apiKey string) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/models", nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("list models: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: list models: unexpected status %s", ErrProviderUnavailable, resp.Status)
+	}
+
+	var payload struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&payload); err != nil {
+		return nil, fmt.Errorf("decode models: %w", err)
+	}
+
+	models := make([]string, 0, len(payload.Data))
+	for _, m := range payload.Data {
+		models = append(models, m.ID)
+	}
+	sort.Strings(models)
+	return models, nil
+}
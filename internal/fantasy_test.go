@@ -0,0 +1,267 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"charm.land/fantasy"
+)
+
+func TestFantasyProviderModels(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/models" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer sk-test" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"id":"gpt-4o-mini","object":"model"},{"id":"gpt-4o","object":"model"}]}`))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	p, err := NewFantasyProvider(ctx, FantasyConfig{
+		Provider: "openai",
+		APIKey:   "sk-test",
+		BaseURL:  srv.URL + "/v1",
+	})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+
+	models, err := p.Models(ctx)
+	if err != nil {
+		t.Fatalf("models: %v", err)
+	}
+	if want := []string{"gpt-4o", "gpt-4o-mini"}; !slices.Equal(models, want) {
+		t.Errorf("models = %v, want %v", models, want)
+	}
+}
+
+func TestFantasyProviderModelsUnsupported(t *testing.T) {
+	ctx := context.Background()
+	p, err := NewFantasyProvider(ctx, FantasyConfig{Provider: "anthropic", APIKey: "x", Model: "claude"})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+
+	if _, err := p.Models(ctx); !errors.Is(err, ErrModelsUnsupported) {
+		t.Errorf("expected ErrModelsUnsupported, got %v", err)
+	}
+}
+
+func TestFantasyProviderEmbed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Model string `json:"model"`
+			Input string `json:"input"`
+		}
+		if r.URL.Path != "/v1/embeddings" || json.NewDecoder(r.Body).Decode(&req) != nil || req.Model != "embed-small" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"object":"list","data":[{"index":0,"embedding":[0.1,0.2,0.3]}]}`))
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	p, err := NewFantasyProvider(ctx, FantasyConfig{Provider: "openai", APIKey: "sk-test", BaseURL: srv.URL + "/v1"})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	vec, err := p.Embed(ctx, "embed-small", "hello")
+	if err != nil {
+		t.Fatalf("embed: %v", err)
+	}
+	if len(vec) != 3 || vec[2] != 0.3 {
+		t.Errorf("embedding = %v", vec)
+	}
+
+	anthropic, err := NewFantasyProvider(ctx, FantasyConfig{Provider: "anthropic", APIKey: "x", Model: "claude"})
+	if err != nil {
+		t.Fatalf("new provider: %v", err)
+	}
+	if _, err := anthropic.Embed(ctx, "m", "hello"); !errors.Is(err, ErrEmbeddingsUnsupported) {
+		t.Errorf("expected ErrEmbeddingsUnsupported, got %v", err)
+	}
+}
+
+// captureModel is a fantasy.LanguageModel that records the calls it gets.
+type captureModel struct {
+	call       fantasy.Call
+	objectCall fantasy.ObjectCall
+}
+
+func (m *captureModel) Generate(_ context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	m.call = call
+	return &fantasy.Response{
+		Content:      fantasy.ResponseContent{fantasy.TextContent{Text: "ok"}},
+		FinishReason: fantasy.FinishReasonStop,
+	}, nil
+}
+
+func (m *captureModel) Stream(context.Context, fantasy.Call) (fantasy.StreamResponse, error) {
+	return func(func(fantasy.StreamPart) bool) {}, nil
+}
+
+func (m *captureModel) GenerateObject(_ context.Context, call fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	m.objectCall = call
+	return &fantasy.ObjectResponse{Object: map[string]any{"title": "t"}}, nil
+}
+
+func (m *captureModel) StreamObject(context.Context, fantasy.ObjectCall) (fantasy.ObjectStreamResponse, error) {
+	return func(func(fantasy.ObjectStreamPart) bool) {}, nil
+}
+
+func (m *captureModel) Provider() string { return "capture" }
+func (m *captureModel) Model() string    { return "capture" }
+
+func TestFantasyProviderCallParams(t *testing.T) {
+	ctx := context.Background()
+	temperature := 0.0
+
+	model := &captureModel{}
+	p := &FantasyProvider{
+		model: model,
+		name:  "capture",
+		cfg:   NewFantasyConfig("capture", ProviderConfig{Temperature: &temperature, MaxTokens: 256}),
+	}
+
+	if _, err := p.Complete(ctx, "hello"); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if model.call.Temperature == nil || *model.call.Temperature != 0 {
+		t.Errorf("complete temperature = %v, want 0", model.call.Temperature)
+	}
+	if model.call.MaxOutputTokens == nil || *model.call.MaxOutputTokens != 256 {
+		t.Errorf("complete max tokens = %v, want 256", model.call.MaxOutputTokens)
+	}
+
+	var summary Summary
+	if err := p.GenerateObject(ctx, "summarize", &summary); err != nil {
+		t.Fatalf("generate object: %v", err)
+	}
+	if model.objectCall.Temperature == nil || *model.objectCall.Temperature != 0 {
+		t.Errorf("object temperature = %v, want 0", model.objectCall.Temperature)
+	}
+	if model.objectCall.MaxOutputTokens == nil || *model.objectCall.MaxOutputTokens != 256 {
+		t.Errorf("object max tokens = %v, want 256", model.objectCall.MaxOutputTokens)
+	}
+}
+
+func TestFantasyProviderCallParamsDefault(t *testing.T) {
+	model := &captureModel{}
+	p := &FantasyProvider{model: model, name: "capture"}
+
+	if _, err := p.Complete(context.Background(), "hello"); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if model.call.Temperature != nil || model.call.MaxOutputTokens != nil {
+		t.Errorf("expected provider defaults, got temperature=%v max tokens=%v",
+			model.call.Temperature, model.call.MaxOutputTokens)
+	}
+}
+
+// textModel is a fantasy.LanguageModel without an object mode that
+// answers text calls with replies in order.
+type textModel struct {
+	captureModel
+	objectResp *fantasy.ObjectResponse
+	replies    []string
+	prompts    []string
+}
+
+func (m *textModel) Generate(_ context.Context, call fantasy.Call) (*fantasy.Response, error) {
+	for _, msg := range call.Prompt {
+		for _, part := range msg.Content {
+			if text, ok := part.(fantasy.TextPart); ok {
+				m.prompts = append(m.prompts, text.Text)
+			}
+		}
+	}
+	reply := m.replies[0]
+	m.replies = m.replies[1:]
+	return &fantasy.Response{
+		Content:      fantasy.ResponseContent{fantasy.TextContent{Text: reply}},
+		FinishReason: fantasy.FinishReasonStop,
+	}, nil
+}
+
+func (m *textModel) GenerateObject(context.Context, fantasy.ObjectCall) (*fantasy.ObjectResponse, error) {
+	if m.objectResp == nil {
+		return nil, errors.New("object generation not supported")
+	}
+	return m.objectResp, nil
+}
+
+func TestFantasyProviderGenerateObjectTextFallback(t *testing.T) {
+	model := &textModel{replies: []string{"```json\n{\"title\": \"Fenced\", \"tags\": [\"a\"]}\n```"}}
+	p := &FantasyProvider{model: model, name: "text"}
+
+	var summary Summary
+	if err := p.GenerateObject(context.Background(), "summarize", &summary); err != nil {
+		t.Fatalf("generate object: %v", err)
+	}
+	if summary.Title != "Fenced" || !slices.Equal(summary.Tags, []string{"a"}) {
+		t.Errorf("summary = %+v", summary)
+	}
+	if len(model.prompts) != 1 || !strings.Contains(model.prompts[0], `"key_points"`) {
+		t.Errorf("expected the schema in the prompt, got %q", model.prompts)
+	}
+}
+
+func TestFantasyProviderGenerateObjectRetriesParseFailure(t *testing.T) {
+	model := &textModel{replies: []string{"Here is your summary: title Broken", `{"title": "Fixed"}`}}
+	p := &FantasyProvider{model: model, name: "text"}
+
+	var summary Summary
+	if err := p.GenerateObject(context.Background(), "summarize", &summary); err != nil {
+		t.Fatalf("generate object: %v", err)
+	}
+	if summary.Title != "Fixed" {
+		t.Errorf("title = %q, want Fixed", summary.Title)
+	}
+	if len(model.prompts) != 2 || !strings.Contains(model.prompts[1], "could not be parsed") {
+		t.Errorf("expected a correcting retry, got %q", model.prompts)
+	}
+}
+
+func TestFantasyProviderGenerateObjectDecodesMap(t *testing.T) {
+	model := &textModel{objectResp: &fantasy.ObjectResponse{
+		Object: map[string]any{"title": "Native", "key_points": []any{"x"}},
+	}}
+	p := &FantasyProvider{model: model, name: "text"}
+
+	var summary Summary
+	if err := p.GenerateObject(context.Background(), "summarize", &summary); err != nil {
+		t.Fatalf("generate object: %v", err)
+	}
+	if summary.Title != "Native" || !slices.Equal(summary.KeyPoints, []string{"x"}) {
+		t.Errorf("summary = %+v", summary)
+	}
+	if len(model.prompts) != 0 {
+		t.Errorf("expected no text fallback, got %q", model.prompts)
+	}
+}
+
+func TestFantasyProviderGenerateObjectMissing(t *testing.T) {
+	model := &textModel{objectResp: &fantasy.ObjectResponse{}, replies: []string{"no", "still no"}}
+	p := &FantasyProvider{model: model, name: "text"}
+
+	var summary Summary
+	err := p.GenerateObject(context.Background(), "summarize", &summary)
+	if !errors.Is(err, ErrNoObject) {
+		t.Errorf("expected ErrNoObject from the native call, got %v", err)
+	}
+	if err == nil || !strings.Contains(err.Error(), "parse object") {
+		t.Errorf("expected the fallback's parse error, got %v", err)
+	}
+}
@@ -1,11 +1,16 @@
 package internal
 
 import (
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
 	"strings"
 	"time"
@@ -28,18 +33,24 @@ const (
 var _ MemoryRepository = (*GitRepository)(nil)
 var _ BranchRepository = (*GitRepository)(nil)
 var _ HistoryRepository = (*GitRepository)(nil)
+var _ AttachmentStore = (*GitRepository)(nil)
+var _ SnapshotRepository = (*GitRepository)(nil)
 
 type GitRepository struct {
 	repo     *git.Repository
 	worktree *git.Worktree
 	memPath  string
+	durable  bool
+	readOnly bool
+	exclude  *IgnoreMatcher
+	signKey  string // path of the OpenPGP key commits are signed with
 }
 
 func NewGitRepository(scope Scope) (*GitRepository, error) {
 	memPath := scope.MemPath
 
 	if _, err := os.Stat(memPath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("repository not initialized: %s", memPath)
+		return nil, &NotInitializedError{Path: memPath}
 	}
 
 	dotgit := filepath.Join(memPath, ".git")
@@ -61,11 +72,21 @@ func NewGitRepository(scope Scope) (*GitRepository, error) {
 		repo:     repo,
 		worktree: worktree,
 		memPath:  memPath,
+		readOnly: !writable(dotgit),
 	}, nil
 }
 
+// InitRepository creates the store's git repository with an initial
+// commit on git.default_branch from the scope's config, or DefaultBranch.
+// It is safe to run again: an existing repository is kept, and only given
+// the initial commit if it has none yet, e.g. when another tool created it.
 func InitRepository(scope Scope) error {
 	memPath := scope.MemPath
+	storeCfg, err := LoadConfig(scope)
+	if err != nil {
+		return err
+	}
+	branch := plumbing.NewBranchReferenceName(cmp.Or(storeCfg.Git.DefaultBranch, DefaultBranch))
 
 	if err := os.MkdirAll(memPath, 0755); err != nil {
 		return fmt.Errorf("create .mem directory: %w", err)
@@ -76,8 +97,15 @@ func InitRepository(scope Scope) error {
 	storage := filesystem.NewStorage(fs, cache.NewObjectLRUDefault())
 	wt := osfs.New(memPath)
 
-	repo, err := git.Init(storage, wt)
-	if err != nil {
+	repo, err := git.InitWithOptions(storage, wt, git.InitOptions{DefaultBranch: branch})
+	if errors.Is(err, git.ErrRepositoryAlreadyExists) {
+		if repo, err = git.Open(storage, wt); err != nil {
+			return fmt.Errorf("open repository: %w", err)
+		}
+		if _, err := repo.Head(); err == nil {
+			return nil
+		}
+	} else if err != nil {
 		return fmt.Errorf("init repository: %w", err)
 	}
 
@@ -85,7 +113,7 @@ func InitRepository(scope Scope) error {
 	if err != nil {
 		return fmt.Errorf("get config: %w", err)
 	}
-	cfg.Init.DefaultBranch = DefaultBranch
+	cfg.Init.DefaultBranch = branch.Short()
 	if err := repo.SetConfig(cfg); err != nil {
 		return fmt.Errorf("set config: %w", err)
 	}
@@ -104,6 +132,16 @@ func InitRepository(scope Scope) error {
 		return fmt.Errorf("stage init file: %w", err)
 	}
 
+	if _, err := EnsureConfig(scope); err != nil {
+		return err
+	}
+	// A global config under $XDG_CONFIG_HOME lives outside the repository.
+	if filepath.Dir(scope.ConfigPath()) == memPath {
+		if _, err := worktree.Add(filepath.Base(scope.ConfigPath())); err != nil {
+			return fmt.Errorf("stage config: %w", err)
+		}
+	}
+
 	_, err = worktree.Commit("init: initialize mem repository", &git.CommitOptions{
 		Author: &object.Signature{
 			Name:  DefaultAuthor,
@@ -144,6 +182,55 @@ func (r *GitRepository) Get(ctx context.Context, key Key) (*Memory, error) {
 	}, nil
 }
 
+// GetReader opens the content of key for reading without loading it, for
+// memories too large to hold in memory. The caller closes it.
+func (r *GitRepository) GetReader(ctx context.Context, key Key) (io.ReadCloser, error) {
+	f, err := os.Open(r.keyToPath(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open file: %w", err)
+	}
+	return f, nil
+}
+
+// GetAtRef reads key as committed at ref, ignoring the worktree. Both
+// timestamps are the time of the commit ref resolves to.
+func (r *GitRepository) GetAtRef(ctx context.Context, key Key, ref string) (*Memory, error) {
+	hash, err := r.resolveRef(ref)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := r.repo.CommitObject(*hash)
+	if err != nil {
+		return nil, fmt.Errorf("get commit %s: %w", ref, err)
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("get tree: %w", err)
+	}
+
+	file, err := tree.File(key.String())
+	if errors.Is(err, object.ErrFileNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("find %s at %s: %w", key, ref, err)
+	}
+	content, err := file.Contents()
+	if err != nil {
+		return nil, fmt.Errorf("read %s at %s: %w", key, ref, err)
+	}
+
+	return &Memory{
+		Key:       key,
+		Content:   []byte(content),
+		CreatedAt: commit.Committer.When,
+		UpdatedAt: commit.Committer.When,
+	}, nil
+}
+
 func (r *GitRepository) Save(ctx context.Context, mem *Memory) error {
 	path := r.keyToPath(mem.Key)
 
@@ -152,11 +239,11 @@ func (r *GitRepository) Save(ctx context.Context, mem *Memory) error {
 		return fmt.Errorf("create directory: %w", err)
 	}
 
-	if err := os.WriteFile(path, mem.Content, 0644); err != nil {
+	if err := writeFileAtomic(path, mem.Content, r.durable); err != nil {
 		return fmt.Errorf("write file: %w", err)
 	}
 
-	relPath, err := filepath.Rel(r.memPath, path)
+	relPath, err := r.relPath(path)
 	if err != nil {
 		return fmt.Errorf("get relative path: %w", err)
 	}
@@ -168,6 +255,29 @@ func (r *GitRepository) Save(ctx context.Context, mem *Memory) error {
 	return nil
 }
 
+// ReadOnly reports whether the repository could not be written when it was
+// opened, e.g. because it lives on a read-only mount.
+func (r *GitRepository) ReadOnly() bool {
+	return r.readOnly
+}
+
+// SetDurable makes Save fsync each memory before moving it into place, so
+// a write survives power loss as well as a crash.
+func (r *GitRepository) SetDurable(durable bool) {
+	r.durable = durable
+}
+
+// SetSigningKey makes Commit sign with the OpenPGP private key at path,
+// unless the context says WithoutSigning. An empty path signs nothing.
+func (r *GitRepository) SetSigningKey(path string) {
+	r.signKey = path
+}
+
+// SetExclude makes listing skip the files and directories m matches.
+func (r *GitRepository) SetExclude(m *IgnoreMatcher) {
+	r.exclude = m
+}
+
 func (r *GitRepository) Delete(ctx context.Context, key Key) error {
 	path := r.keyToPath(key)
 
@@ -175,7 +285,7 @@ func (r *GitRepository) Delete(ctx context.Context, key Key) error {
 		return ErrNotFound
 	}
 
-	relPath, err := filepath.Rel(r.memPath, path)
+	relPath, err := r.relPath(path)
 	if err != nil {
 		return fmt.Errorf("get relative path: %w", err)
 	}
@@ -190,34 +300,7 @@ func (r *GitRepository) Delete(ctx context.Context, key Key) error {
 func (r *GitRepository) List(ctx context.Context, prefix string) ([]*Memory, error) {
 	var memories []*Memory
 
-	err := filepath.Walk(r.memPath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if info.IsDir() {
-			if info.Name() == ".git" || info.Name() == "vectors" {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-		if info.Name() == ".mem-init" || info.Name() == "config.yaml" {
-			return nil
-		}
-
-		relPath, err := filepath.Rel(r.memPath, path)
-		if err != nil {
-			return err
-		}
-
-		if prefix != "" && !strings.HasPrefix(relPath, prefix) {
-			return nil
-		}
-
-		key, err := NewKey(relPath)
-		if err != nil {
-			return nil
-		}
-
+	err := r.walkKeys(prefix, func(key Key, path string, info os.FileInfo) error {
 		content, err := os.ReadFile(path)
 		if err != nil {
 			return err
@@ -229,17 +312,37 @@ func (r *GitRepository) List(ctx context.Context, prefix string) ([]*Memory, err
 			CreatedAt: r.getFirstCommitTime(key, info.ModTime()),
 			UpdatedAt: info.ModTime(),
 		})
-
 		return nil
 	})
-
 	if err != nil {
-		return nil, fmt.Errorf("walk directory: %w", err)
+		return nil, err
 	}
 
+	sort.Slice(memories, func(i, j int) bool { return memories[i].Key < memories[j].Key })
 	return memories, nil
 }
 
+// ListKeys is like List but only stats each file, so it reads no content
+// and no history.
+func (r *GitRepository) ListKeys(ctx context.Context, prefix string) ([]KeyInfo, error) {
+	var keys []KeyInfo
+
+	err := r.walkKeys(prefix, func(key Key, _ string, info os.FileInfo) error {
+		keys = append(keys, KeyInfo{
+			Key:       key,
+			Size:      info.Size(),
+			UpdatedAt: info.ModTime(),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	return keys, nil
+}
+
 func (r *GitRepository) Exists(ctx context.Context, key Key) (bool, error) {
 	path := r.keyToPath(key)
 	_, err := os.Stat(path)
@@ -254,10 +357,19 @@ func (r *GitRepository) Exists(ctx context.Context, key Key) (bool, error) {
 
 // BranchRepository implementation
 
+// Current returns the checked-out branch. Before the first commit the
+// branch exists only as HEAD's target and Head is empty.
 func (r *GitRepository) Current(ctx context.Context) (*Branch, error) {
-	head, err := r.repo.Head()
+	head, err := r.head()
+	if errors.Is(err, ErrNoCommits) {
+		ref, refErr := r.repo.Storer.Reference(plumbing.HEAD)
+		if refErr != nil {
+			return nil, fmt.Errorf("get HEAD: %w", refErr)
+		}
+		return &Branch{Name: ref.Target().Short()}, nil
+	}
 	if err != nil {
-		return nil, fmt.Errorf("get HEAD: %w", err)
+		return nil, err
 	}
 
 	return &Branch{
@@ -273,17 +385,27 @@ func (r *GitRepository) ListBranches(ctx context.Context) ([]*Branch, error) {
 	}
 
 	var branches []*Branch
+	var tips []plumbing.Hash
 	err = refs.ForEach(func(ref *plumbing.Reference) error {
 		branches = append(branches, &Branch{
 			Name: ref.Name().Short(),
 			Head: ref.Hash().String(),
 		})
+		tips = append(tips, ref.Hash())
 		return nil
 	})
 	if err != nil {
 		return nil, err
 	}
 
+	created, err := r.branchCreatedAt(tips)
+	if err != nil {
+		return nil, err
+	}
+	for i, b := range branches {
+		b.CreatedAt = created[i]
+	}
+
 	sort.Slice(branches, func(i, j int) bool {
 		return branches[i].Name < branches[j].Name
 	})
@@ -291,39 +413,118 @@ func (r *GitRepository) ListBranches(ctx context.Context) ([]*Branch, error) {
 	return branches, nil
 }
 
-func (r *GitRepository) Create(ctx context.Context, name string) (*Branch, error) {
-	head, err := r.repo.Head()
-	if err != nil {
-		return nil, fmt.Errorf("get HEAD: %w", err)
+// branchCreatedAt estimates when each branch tip in tips was created. go-git
+// keeps no reflog, so this is the committer date of the oldest commit that
+// only that branch reaches, or of the tip itself when the branch has no
+// commits of its own.
+func (r *GitRepository) branchCreatedAt(tips []plumbing.Hash) ([]time.Time, error) {
+	reached := make([][]*object.Commit, len(tips))
+	count := make(map[plumbing.Hash]int)
+	for i, tip := range tips {
+		iter, err := r.repo.Log(&git.LogOptions{From: tip})
+		if err != nil {
+			return nil, fmt.Errorf("get log: %w", err)
+		}
+		err = iter.ForEach(func(c *object.Commit) error {
+			reached[i] = append(reached[i], c)
+			count[c.Hash]++
+			return nil
+		})
+		iter.Close()
+		if err != nil {
+			return nil, fmt.Errorf("walk log: %w", err)
+		}
 	}
 
+	created := make([]time.Time, len(tips))
+	for i, commits := range reached {
+		if len(commits) == 0 {
+			continue
+		}
+		created[i] = commits[0].Committer.When
+		for _, c := range commits {
+			if count[c.Hash] == 1 && c.Committer.When.Before(created[i]) {
+				created[i] = c.Committer.When
+			}
+		}
+	}
+	return created, nil
+}
+
+func (r *GitRepository) Create(ctx context.Context, name, from string) (*Branch, error) {
 	refName := plumbing.NewBranchReferenceName(name)
-	ref := plumbing.NewHashReference(refName, head.Hash())
+	if _, err := r.repo.Reference(refName, false); err == nil {
+		return nil, fmt.Errorf("%w: %s", ErrBranchExists, name)
+	}
+
+	if from == "" {
+		from = "HEAD"
+	}
+	hash, err := r.resolveRef(from)
+	if err != nil {
+		return nil, err
+	}
 
+	ref := plumbing.NewHashReference(refName, *hash)
 	if err := r.repo.Storer.SetReference(ref); err != nil {
 		return nil, fmt.Errorf("create branch: %w", err)
 	}
 
 	return &Branch{
 		Name:      name,
-		Head:      head.Hash().String(),
+		Head:      hash.String(),
 		CreatedAt: time.Now(),
 	}, nil
 }
 
-func (r *GitRepository) Switch(ctx context.Context, name string) error {
+func (r *GitRepository) Switch(ctx context.Context, name string, force bool) error {
 	branchRef := plumbing.NewBranchReferenceName(name)
 
+	if !force {
+		n, err := r.uncommittedFiles()
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return fmt.Errorf("%w (%d files); commit or use --force", ErrUncommittedChanges, n)
+		}
+	}
+
+	// mem's own files follow the store, not the branch: keep them as they
+	// are rather than have the checkout reset them. The check above already
+	// refused any other change, so the checkout can always be forced.
+	own := r.readOwnFiles()
 	if err := r.worktree.Checkout(&git.CheckoutOptions{
 		Branch: branchRef,
+		Force:  true,
 	}); err != nil {
 		return fmt.Errorf("checkout branch: %w", err)
 	}
+	for name, data := range own {
+		if err := writeFileAtomic(filepath.Join(r.memPath, name), data, r.durable); err != nil {
+			return fmt.Errorf("restore %s: %w", name, err)
+		}
+	}
 
 	return nil
 }
 
-func (r *GitRepository) DeleteBranch(ctx context.Context, name string) error {
+// ownFiles are the files mem keeps in the worktree for itself. They are
+// not memories, so they never block a switch and are kept across one.
+var ownFiles = []string{"config.yaml", ".mem-init"}
+
+// readOwnFiles returns the content of the ownFiles that exist.
+func (r *GitRepository) readOwnFiles() map[string][]byte {
+	own := make(map[string][]byte, len(ownFiles))
+	for _, name := range ownFiles {
+		if data, err := os.ReadFile(filepath.Join(r.memPath, name)); err == nil {
+			own[name] = data
+		}
+	}
+	return own
+}
+
+func (r *GitRepository) DeleteBranch(ctx context.Context, name string, force bool) error {
 	current, err := r.Current(ctx)
 	if err != nil {
 		return err
@@ -333,6 +534,16 @@ func (r *GitRepository) DeleteBranch(ctx context.Context, name string) error {
 	}
 
 	refName := plumbing.NewBranchReferenceName(name)
+	if !force {
+		merged, err := r.isMerged(refName, plumbing.NewHash(current.Head))
+		if err != nil {
+			return err
+		}
+		if !merged {
+			return fmt.Errorf("%w: %s; use --force to delete it anyway", ErrBranchNotMerged, name)
+		}
+	}
+
 	if err := r.repo.Storer.RemoveReference(refName); err != nil {
 		return fmt.Errorf("delete branch: %w", err)
 	}
@@ -340,16 +551,124 @@ func (r *GitRepository) DeleteBranch(ctx context.Context, name string) error {
 	return nil
 }
 
+// uncommittedFiles counts tracked files that are staged or modified.
+// Untracked files are left alone by a checkout, so they are not counted.
+func (r *GitRepository) uncommittedFiles() (int, error) {
+	paths, err := r.Uncommitted(context.Background())
+	return len(paths), err
+}
+
+// Uncommitted lists the tracked files that are staged or modified, sorted.
+// mem's own files, such as config.yaml, are never committed by mem commit
+// and so are not listed.
+func (r *GitRepository) Uncommitted(ctx context.Context) ([]string, error) {
+	status, err := r.worktree.Status()
+	if err != nil {
+		return nil, fmt.Errorf("get status: %w", err)
+	}
+
+	var paths []string
+	for path, s := range status {
+		if s.Staging == git.Untracked && s.Worktree == git.Untracked {
+			continue
+		}
+		if slices.Contains(ownFiles, path) {
+			continue
+		}
+		if s.Staging != git.Unmodified || s.Worktree != git.Unmodified {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// isMerged reports whether the tip of branch is reachable from head.
+func (r *GitRepository) isMerged(branch plumbing.ReferenceName, head plumbing.Hash) (bool, error) {
+	ref, err := r.repo.Reference(branch, false)
+	if err != nil {
+		return false, fmt.Errorf("branch %s: %w", branch.Short(), err)
+	}
+	if ref.Hash() == head {
+		return true, nil
+	}
+
+	tip, err := r.repo.CommitObject(ref.Hash())
+	if err != nil {
+		return false, fmt.Errorf("get commit: %w", err)
+	}
+	headCommit, err := r.repo.CommitObject(head)
+	if err != nil {
+		return false, fmt.Errorf("get HEAD commit: %w", err)
+	}
+	return tip.IsAncestor(headCommit)
+}
+
+func (r *GitRepository) RenameBranch(ctx context.Context, oldName, newName string) error {
+	oldRef, err := r.repo.Reference(plumbing.NewBranchReferenceName(oldName), false)
+	if err != nil {
+		return fmt.Errorf("branch %s: %w", oldName, err)
+	}
+
+	newRefName := plumbing.NewBranchReferenceName(newName)
+	if _, err := r.repo.Reference(newRefName, false); err == nil {
+		return fmt.Errorf("%w: %s", ErrBranchExists, newName)
+	}
+
+	if err := r.repo.Storer.SetReference(plumbing.NewHashReference(newRefName, oldRef.Hash())); err != nil {
+		return fmt.Errorf("create branch: %w", err)
+	}
+
+	// The new ref points at the same commit, so moving HEAD leaves the
+	// worktree and index untouched.
+	head, err := r.repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return fmt.Errorf("get HEAD: %w", err)
+	}
+	if head.Type() == plumbing.SymbolicReference && head.Target() == oldRef.Name() {
+		if err := r.repo.Storer.SetReference(plumbing.NewSymbolicReference(plumbing.HEAD, newRefName)); err != nil {
+			return fmt.Errorf("update HEAD: %w", err)
+		}
+	}
+
+	if err := r.repo.Storer.RemoveReference(oldRef.Name()); err != nil {
+		return fmt.Errorf("delete branch: %w", err)
+	}
+
+	return nil
+}
+
 // HistoryRepository implementation
 
 func (r *GitRepository) Commit(ctx context.Context, message string) (*Commit, error) {
-	hash, err := r.worktree.Commit(message, &git.CommitOptions{
+	return r.commit(ctx, message, false)
+}
+
+func (r *GitRepository) CommitAllowEmpty(ctx context.Context, message string) (*Commit, error) {
+	return r.commit(ctx, message, true)
+}
+
+func (r *GitRepository) commit(ctx context.Context, message string, allowEmpty bool) (*Commit, error) {
+	opts := &git.CommitOptions{
 		Author: &object.Signature{
 			Name:  DefaultAuthor,
 			Email: DefaultEmail,
 			When:  time.Now(),
 		},
-	})
+		AllowEmptyCommits: allowEmpty,
+	}
+	if r.signKey != "" && !SigningDisabledFrom(ctx) {
+		key, err := LoadSigningKey(r.signKey)
+		if err != nil {
+			return nil, fmt.Errorf("commit: %w", err)
+		}
+		opts.SignKey = key
+	}
+
+	hash, err := r.worktree.Commit(message, opts)
+	if errors.Is(err, git.ErrEmptyCommit) {
+		return nil, ErrNothingToCommit
+	}
 	if err != nil {
 		return nil, fmt.Errorf("commit: %w", err)
 	}
@@ -363,6 +682,9 @@ func (r *GitRepository) Commit(ctx context.Context, message string) (*Commit, er
 }
 
 func (r *GitRepository) Log(ctx context.Context, limit int) ([]*Commit, error) {
+	if _, err := r.head(); err != nil {
+		return nil, err
+	}
 	iter, err := r.repo.Log(&git.LogOptions{})
 	if err != nil {
 		return nil, fmt.Errorf("get log: %w", err)
@@ -387,6 +709,87 @@ func (r *GitRepository) Log(ctx context.Context, limit int) ([]*Commit, error) {
 	return commits, nil
 }
 
+func (r *GitRepository) LogAll(ctx context.Context, limit int) ([]*Commit, error) {
+	branches, err := r.repo.Branches()
+	if err != nil {
+		return nil, fmt.Errorf("list branches: %w", err)
+	}
+	tips := make(map[plumbing.Hash][]string)
+	var pending []plumbing.Hash
+	err = branches.ForEach(func(ref *plumbing.Reference) error {
+		tips[ref.Hash()] = append(tips[ref.Hash()], ref.Name().Short())
+		pending = append(pending, ref.Hash())
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list branches: %w", err)
+	}
+	if len(pending) == 0 {
+		return nil, ErrNoCommits
+	}
+
+	// Count each commit's children first, so a commit is only emitted
+	// once everything above it is.
+	objects := make(map[plumbing.Hash]*object.Commit)
+	children := make(map[plumbing.Hash]int)
+	for len(pending) > 0 {
+		hash := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		if _, ok := objects[hash]; ok {
+			continue
+		}
+		c, err := r.repo.CommitObject(hash)
+		if err != nil {
+			return nil, fmt.Errorf("get commit %s: %w", hash, err)
+		}
+		objects[hash] = c
+		for _, p := range c.ParentHashes {
+			children[p]++
+			pending = append(pending, p)
+		}
+	}
+
+	var ready []*object.Commit
+	for hash := range tips {
+		if children[hash] == 0 {
+			ready = append(ready, objects[hash])
+		}
+	}
+
+	var commits []*Commit
+	for len(ready) > 0 && (limit <= 0 || len(commits) < limit) {
+		newest := 0
+		for i, c := range ready {
+			if newerCommit(c, ready[newest]) {
+				newest = i
+			}
+		}
+		c := ready[newest]
+		ready = slices.Delete(ready, newest, newest+1)
+
+		commit := r.toCommit(c)
+		commit.Refs = tips[c.Hash]
+		slices.Sort(commit.Refs)
+		commits = append(commits, commit)
+
+		for _, p := range c.ParentHashes {
+			if children[p]--; children[p] == 0 {
+				ready = append(ready, objects[p])
+			}
+		}
+	}
+	return commits, nil
+}
+
+// newerCommit orders commits by committer time, then by hash so that
+// commits made within the same second come out in a stable order.
+func newerCommit(a, b *object.Commit) bool {
+	if !a.Committer.When.Equal(b.Committer.When) {
+		return a.Committer.When.After(b.Committer.When)
+	}
+	return a.Hash.String() > b.Hash.String()
+}
+
 func (r *GitRepository) Diff(ctx context.Context, ref string) (string, error) {
 	if ref == "" {
 		return r.diffWorktreeVsHead()
@@ -394,83 +797,216 @@ func (r *GitRepository) Diff(ctx context.Context, ref string) (string, error) {
 	return r.diffHeadVsRef(ref)
 }
 
-func (r *GitRepository) diffWorktreeVsHead() (string, error) {
-	status, err := r.worktree.Status()
+// DiffStat counts added and removed lines per changed file, over the same
+// changes Diff would show.
+func (r *GitRepository) DiffStat(ctx context.Context, ref string) ([]FileStat, error) {
+	if ref != "" {
+		changes, err := r.refChanges(ref)
+		if err != nil {
+			return nil, err
+		}
+		patch, err := changes.Patch()
+		if err != nil {
+			return nil, fmt.Errorf("get patch: %w", err)
+		}
+		var stats []FileStat
+		for _, fs := range patch.Stats() {
+			stats = append(stats, FileStat{Path: fs.Name, Added: fs.Addition, Removed: fs.Deletion})
+		}
+		sort.Slice(stats, func(i, j int) bool { return stats[i].Path < stats[j].Path })
+		return stats, nil
+	}
+
+	changes, err := r.worktreeChanges()
 	if err != nil {
-		return "", fmt.Errorf("get status: %w", err)
+		return nil, err
 	}
+	return fileChangeStats(changes), nil
+}
 
-	if status.IsClean() {
-		return "", nil
+// fileChangeStats counts the lines each change adds and removes.
+func fileChangeStats(changes []fileChange) []FileStat {
+	dmp := diffmatchpatch.New()
+	stats := make([]FileStat, 0, len(changes))
+	for _, c := range changes {
+		added, removed := countLineChanges(c.Old, c.New, dmp)
+		stats = append(stats, FileStat{Path: c.Path, Added: added, Removed: removed})
 	}
+	return stats
+}
 
-	head, err := r.repo.Head()
+// fileChange is a file that differs between HEAD and the worktree. Old is
+// empty for added files and New for deleted ones.
+type fileChange struct {
+	Path   string
+	Status git.StatusCode
+	Old    string
+	New    string
+}
+
+// worktreeChanges lists additions, modifications and deletions against
+// HEAD, sorted by path. Unstaged edits count too, and untracked files that
+// are memories count as additions; mem's own files such as vectors/ are
+// never reported.
+func (r *GitRepository) worktreeChanges() ([]fileChange, error) {
+	status, err := r.worktree.Status()
 	if err != nil {
-		return "", fmt.Errorf("get HEAD: %w", err)
+		return nil, fmt.Errorf("get status: %w", err)
 	}
 
-	headCommit, err := r.repo.CommitObject(head.Hash())
-	if err != nil {
-		return "", fmt.Errorf("get HEAD commit: %w", err)
+	if status.IsClean() {
+		return nil, nil
 	}
 
-	headTree, err := headCommit.Tree()
-	if err != nil {
-		return "", fmt.Errorf("get HEAD tree: %w", err)
+	// Before the first commit every staged file is an addition.
+	headTree := &object.Tree{}
+	head, err := r.head()
+	if err != nil && !errors.Is(err, ErrNoCommits) {
+		return nil, err
+	}
+	if err == nil {
+		headCommit, err := r.repo.CommitObject(head.Hash())
+		if err != nil {
+			return nil, fmt.Errorf("get HEAD commit: %w", err)
+		}
+		if headTree, err = headCommit.Tree(); err != nil {
+			return nil, fmt.Errorf("get HEAD tree: %w", err)
+		}
 	}
 
-	var buf strings.Builder
-	dmp := diffmatchpatch.New()
+	headContent := func(path string) (string, bool) {
+		f, err := headTree.File(path)
+		if err != nil {
+			return "", false
+		}
+		content, err := f.Contents()
+		return content, err == nil
+	}
 
+	var changes []fileChange
 	for path, s := range status {
-		switch {
-		case s.Staging == git.Added:
-			content, readErr := os.ReadFile(filepath.Join(r.memPath, path))
-			if readErr != nil {
+		code := s.Staging
+		if code == git.Unmodified || code == git.Untracked {
+			code = s.Worktree
+		}
+		if code == git.Untracked {
+			if _, ok := r.memoryKey(path); !ok {
 				continue
 			}
-			fmt.Fprintf(&buf, "--- /dev/null\n+++ b/%s\n", path)
-			writeUnifiedHunks(&buf, "", string(content), dmp)
-
-		case s.Staging == git.Modified:
-			f, headErr := headTree.File(path)
-			if headErr != nil {
+			code = git.Added
+		}
+		change := fileChange{Path: path, Status: code}
+		switch code {
+		case git.Added:
+			content, readErr := os.ReadFile(filepath.Join(r.memPath, filepath.FromSlash(path)))
+			if readErr != nil {
 				continue
 			}
-			oldContent, headErr := f.Contents()
-			if headErr != nil {
+			change.New = string(content)
+
+		case git.Modified:
+			oldContent, ok := headContent(path)
+			if !ok {
 				continue
 			}
-			newContent, readErr := os.ReadFile(filepath.Join(r.memPath, path))
+			newContent, readErr := os.ReadFile(filepath.Join(r.memPath, filepath.FromSlash(path)))
 			if readErr != nil {
 				continue
 			}
-			fmt.Fprintf(&buf, "--- a/%s\n+++ b/%s\n", path, path)
-			writeUnifiedHunks(&buf, oldContent, string(newContent), dmp)
+			change.Old, change.New = oldContent, string(newContent)
 
-		case s.Staging == git.Deleted:
-			f, headErr := headTree.File(path)
-			if headErr != nil {
-				continue
-			}
-			oldContent, headErr := f.Contents()
-			if headErr != nil {
+		case git.Deleted:
+			oldContent, ok := headContent(path)
+			if !ok {
 				continue
 			}
-			fmt.Fprintf(&buf, "--- a/%s\n+++ /dev/null\n", path)
-			writeUnifiedHunks(&buf, oldContent, "", dmp)
+			change.Old = oldContent
+
+		default:
+			continue
 		}
+		changes = append(changes, change)
 	}
 
-	return buf.String(), nil
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
 }
 
-func writeUnifiedHunks(buf *strings.Builder, oldText, newText string, dmp *diffmatchpatch.DiffMatchPatch) {
-	// Use line-level diffing for proper unified diff output
+func (r *GitRepository) diffWorktreeVsHead() (string, error) {
+	changes, err := r.worktreeChanges()
+	if err != nil {
+		return "", err
+	}
+	return formatFileChanges(changes), nil
+}
+
+// formatFileChanges renders changes as a unified diff.
+func formatFileChanges(changes []fileChange) string {
+	var buf strings.Builder
+	dmp := diffmatchpatch.New()
+
+	for _, c := range changes {
+		writeFileChange(&buf, c, dmp)
+	}
+
+	return buf.String()
+}
+
+// ContentDiff renders the change from oldText to newText as a unified diff
+// of path, in the format mem diff prints. An empty oldText diffs as a new
+// file.
+func ContentDiff(path, oldText, newText string) string {
+	status := git.Modified
+	if oldText == "" {
+		status = git.Added
+	}
+	var buf strings.Builder
+	writeFileChange(&buf, fileChange{Path: path, Status: status, Old: oldText, New: newText}, diffmatchpatch.New())
+	return buf.String()
+}
+
+// writeFileChange writes the file headers and hunks for one change.
+func writeFileChange(buf *strings.Builder, c fileChange, dmp *diffmatchpatch.DiffMatchPatch) {
+	switch c.Status {
+	case git.Added:
+		fmt.Fprintf(buf, "--- /dev/null\n+++ b/%s\n", c.Path)
+	case git.Modified:
+		fmt.Fprintf(buf, "--- a/%s\n+++ b/%s\n", c.Path, c.Path)
+	case git.Deleted:
+		fmt.Fprintf(buf, "--- a/%s\n+++ /dev/null\n", c.Path)
+	}
+	writeUnifiedHunks(buf, c.Old, c.New, dmp)
+}
+
+// countLineChanges returns how many lines a line-level diff from oldText to
+// newText inserts and deletes.
+func countLineChanges(oldText, newText string, dmp *diffmatchpatch.DiffMatchPatch) (added, removed int) {
+	for _, diff := range lineDiffs(oldText, newText, dmp) {
+		n := strings.Count(diff.Text, "\n")
+		if !strings.HasSuffix(diff.Text, "\n") {
+			n++
+		}
+		switch diff.Type {
+		case diffmatchpatch.DiffInsert:
+			added += n
+		case diffmatchpatch.DiffDelete:
+			removed += n
+		}
+	}
+	return added, removed
+}
+
+// lineDiffs diffs oldText and newText line by line.
+func lineDiffs(oldText, newText string, dmp *diffmatchpatch.DiffMatchPatch) []diffmatchpatch.Diff {
 	a, b, lineArray := dmp.DiffLinesToChars(oldText, newText)
 	diffs := dmp.DiffMain(a, b, false)
 	diffs = dmp.DiffCharsToLines(diffs, lineArray)
-	diffs = dmp.DiffCleanupSemantic(diffs)
+	return dmp.DiffCleanupSemantic(diffs)
+}
+
+func writeUnifiedHunks(buf *strings.Builder, oldText, newText string, dmp *diffmatchpatch.DiffMatchPatch) {
+	// Use line-level diffing for proper unified diff output
+	diffs := lineDiffs(oldText, newText, dmp)
 
 	oldLine := 1
 	newLine := 1
@@ -504,53 +1040,97 @@ func writeUnifiedHunks(buf *strings.Builder, oldText, newText string, dmp *diffm
 }
 
 func (r *GitRepository) diffHeadVsRef(ref string) (string, error) {
-	head, err := r.repo.Head()
+	changes, err := r.refChanges(ref)
 	if err != nil {
-		return "", fmt.Errorf("get HEAD: %w", err)
+		return "", err
 	}
 
-	headCommit, err := r.repo.CommitObject(head.Hash())
+	patch, err := changes.Patch()
 	if err != nil {
-		return "", fmt.Errorf("get HEAD commit: %w", err)
+		return "", fmt.Errorf("get patch: %w", err)
 	}
 
-	resolved, err := r.repo.ResolveRevision(plumbing.Revision(ref))
+	return patch.String(), nil
+}
+
+// refChanges lists the tree changes from ref to HEAD.
+func (r *GitRepository) refChanges(ref string) (object.Changes, error) {
+	headTree, targetTree, err := r.refTrees(ref)
 	if err != nil {
-		return "", fmt.Errorf("resolve ref: %w", err)
+		return nil, err
 	}
 
-	targetCommit, err := r.repo.CommitObject(*resolved)
+	changes, err := targetTree.Diff(headTree)
 	if err != nil {
-		return "", fmt.Errorf("get target commit: %w", err)
+		return nil, fmt.Errorf("diff trees: %w", err)
 	}
+	return changes, nil
+}
 
-	headTree, err := headCommit.Tree()
+// ChangedKeys lists the keys added, modified or deleted between ref and
+// HEAD, sorted. Paths that are not memories, such as mem's own files,
+// trashed keys and excluded paths, are left out.
+func (r *GitRepository) ChangedKeys(ctx context.Context, ref string) ([]Key, error) {
+	changes, err := r.refChanges(ref)
 	if err != nil {
-		return "", fmt.Errorf("get HEAD tree: %w", err)
+		return nil, err
+	}
+
+	var keys []Key
+	for _, c := range changes {
+		for _, name := range []string{c.From.Name, c.To.Name} {
+			if name == "" || r.exclude.MatchPath(name, false) {
+				continue
+			}
+			key, err := NewKey(name)
+			if err != nil || slices.Contains(keys, key) {
+				continue
+			}
+			keys = append(keys, key)
+		}
 	}
+	slices.Sort(keys)
+	return keys, nil
+}
 
-	targetTree, err := targetCommit.Tree()
+// refTrees returns the trees of HEAD and of ref.
+func (r *GitRepository) refTrees(ref string) (head, target *object.Tree, err error) {
+	headRef, err := r.head()
 	if err != nil {
-		return "", fmt.Errorf("get target tree: %w", err)
+		return nil, nil, err
 	}
 
-	changes, err := targetTree.Diff(headTree)
+	headCommit, err := r.repo.CommitObject(headRef.Hash())
 	if err != nil {
-		return "", fmt.Errorf("diff trees: %w", err)
+		return nil, nil, fmt.Errorf("get HEAD commit: %w", err)
 	}
 
-	patch, err := changes.Patch()
+	resolved, err := r.repo.ResolveRevision(plumbing.Revision(ref))
 	if err != nil {
-		return "", fmt.Errorf("get patch: %w", err)
+		return nil, nil, fmt.Errorf("resolve ref: %w", err)
 	}
 
-	return patch.String(), nil
+	targetCommit, err := r.repo.CommitObject(*resolved)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get target commit: %w", err)
+	}
+
+	head, err = headCommit.Tree()
+	if err != nil {
+		return nil, nil, fmt.Errorf("get HEAD tree: %w", err)
+	}
+
+	target, err = targetCommit.Tree()
+	if err != nil {
+		return nil, nil, fmt.Errorf("get target tree: %w", err)
+	}
+	return head, target, nil
 }
 
 func (r *GitRepository) Show(ctx context.Context, ref string) (*Commit, error) {
-	resolved, err := r.repo.ResolveRevision(plumbing.Revision(ref))
+	resolved, err := r.resolveRef(ref)
 	if err != nil {
-		return nil, fmt.Errorf("resolve ref: %w", err)
+		return nil, err
 	}
 
 	commit, err := r.repo.CommitObject(*resolved)
@@ -558,13 +1138,49 @@ func (r *GitRepository) Show(ctx context.Context, ref string) (*Commit, error) {
 		return nil, fmt.Errorf("get commit: %w", err)
 	}
 
-	return r.toCommit(commit), nil
+	parentTree, err := parentTreeOrEmpty(commit)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := commit.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("get tree: %w", err)
+	}
+	changes, err := parentTree.Diff(tree)
+	if err != nil {
+		return nil, fmt.Errorf("diff trees: %w", err)
+	}
+	patch, err := changes.Patch()
+	if err != nil {
+		return nil, fmt.Errorf("get patch: %w", err)
+	}
+
+	out := r.toCommit(commit)
+	out.Diff = patch.String()
+	return out, nil
+}
+
+// parentTreeOrEmpty returns the tree of c's first parent, or the empty tree
+// when c is the root commit, so its diff shows every file as added.
+func parentTreeOrEmpty(c *object.Commit) (*object.Tree, error) {
+	if c.NumParents() == 0 {
+		return &object.Tree{}, nil
+	}
+	parent, err := c.Parent(0)
+	if err != nil {
+		return nil, fmt.Errorf("get parent: %w", err)
+	}
+	tree, err := parent.Tree()
+	if err != nil {
+		return nil, fmt.Errorf("get parent tree: %w", err)
+	}
+	return tree, nil
 }
 
 func (r *GitRepository) Revert(ctx context.Context, ref string) error {
-	resolved, err := r.repo.ResolveRevision(plumbing.Revision(ref))
+	resolved, err := r.resolveRef(ref)
 	if err != nil {
-		return fmt.Errorf("resolve ref: %w", err)
+		return err
 	}
 
 	if err := r.worktree.Reset(&git.ResetOptions{
@@ -577,8 +1193,329 @@ func (r *GitRepository) Revert(ctx context.Context, ref string) error {
 	return nil
 }
 
+// SnapshotRepository implementation
+
+func (r *GitRepository) CreateSnapshot(ctx context.Context, name, message string, force bool) (*Snapshot, error) {
+	refName := plumbing.NewTagReferenceName(name)
+	if _, err := r.repo.Reference(refName, false); err == nil {
+		if !force {
+			return nil, fmt.Errorf("%w: %s; use --force to replace it", ErrSnapshotExists, name)
+		}
+		if err := r.repo.Storer.RemoveReference(refName); err != nil {
+			return nil, fmt.Errorf("remove snapshot: %w", err)
+		}
+	}
+
+	head, err := r.head()
+	if err != nil {
+		return nil, err
+	}
+
+	// A message needs somewhere to live, so only then is the tag annotated.
+	if message != "" {
+		_, err = r.repo.CreateTag(name, head.Hash(), &git.CreateTagOptions{
+			Tagger: &object.Signature{
+				Name:  DefaultAuthor,
+				Email: DefaultEmail,
+				When:  time.Now(),
+			},
+			Message: message,
+		})
+	} else {
+		err = r.repo.Storer.SetReference(plumbing.NewHashReference(refName, head.Hash()))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("create snapshot: %w", err)
+	}
+
+	return r.GetSnapshot(ctx, name)
+}
+
+func (r *GitRepository) GetSnapshot(ctx context.Context, name string) (*Snapshot, error) {
+	ref, err := r.repo.Reference(plumbing.NewTagReferenceName(name), false)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrSnapshotNotFound, name)
+	}
+	return r.toSnapshot(ref)
+}
+
+func (r *GitRepository) ListSnapshots(ctx context.Context) ([]*Snapshot, error) {
+	refs, err := r.repo.Tags()
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots: %w", err)
+	}
+
+	var snapshots []*Snapshot
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		snap, err := r.toSnapshot(ref)
+		if err != nil {
+			return err
+		}
+		snapshots = append(snapshots, snap)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		if !snapshots[i].CreatedAt.Equal(snapshots[j].CreatedAt) {
+			return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+		}
+		return snapshots[i].Name < snapshots[j].Name
+	})
+	return snapshots, nil
+}
+
+// toSnapshot reads a tag ref. An annotated tag carries its own date and
+// message; a lightweight one is dated by the commit it points at.
+func (r *GitRepository) toSnapshot(ref *plumbing.Reference) (*Snapshot, error) {
+	snap := &Snapshot{Name: ref.Name().Short()}
+
+	if tag, err := r.repo.TagObject(ref.Hash()); err == nil {
+		snap.Commit = tag.Target.String()
+		snap.Message = strings.TrimSpace(tag.Message)
+		snap.CreatedAt = tag.Tagger.When
+		return snap, nil
+	}
+
+	commit, err := r.repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot commit: %w", err)
+	}
+	snap.Commit = commit.Hash.String()
+	snap.CreatedAt = commit.Committer.When
+	return snap, nil
+}
+
+// RestoreTree makes the worktree and index match ref without moving HEAD,
+// so the restore becomes a new commit and later history is kept. Files
+// outside HEAD's tree, such as untracked attachments, are left alone.
+func (r *GitRepository) RestoreTree(ctx context.Context, ref string) (int, error) {
+	n, err := r.uncommittedFiles()
+	if err != nil {
+		return 0, err
+	}
+	if n > 0 {
+		return 0, fmt.Errorf("%w (%d files); commit them first", ErrUncommittedChanges, n)
+	}
+
+	headTree, targetTree, err := r.refTrees(ref)
+	if err != nil {
+		return 0, err
+	}
+	changes, err := headTree.Diff(targetTree)
+	if err != nil {
+		return 0, fmt.Errorf("diff trees: %w", err)
+	}
+
+	for _, change := range changes {
+		if change.To.Name == "" {
+			if _, err := r.worktree.Remove(change.From.Name); err != nil {
+				return 0, fmt.Errorf("remove %s: %w", change.From.Name, err)
+			}
+			continue
+		}
+
+		file, err := targetTree.File(change.To.Name)
+		if err != nil {
+			return 0, fmt.Errorf("read %s: %w", change.To.Name, err)
+		}
+		content, err := file.Contents()
+		if err != nil {
+			return 0, fmt.Errorf("read %s: %w", change.To.Name, err)
+		}
+
+		path := filepath.Join(r.memPath, filepath.FromSlash(change.To.Name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return 0, fmt.Errorf("create directory: %w", err)
+		}
+		if err := writeFileAtomic(path, []byte(content), r.durable); err != nil {
+			return 0, fmt.Errorf("write %s: %w", change.To.Name, err)
+		}
+		if _, err := r.worktree.Add(change.To.Name); err != nil {
+			return 0, fmt.Errorf("stage %s: %w", change.To.Name, err)
+		}
+	}
+
+	return len(changes), nil
+}
+
+// PutBlob streams r into the attachments directory under its SHA-256, so
+// the same content is only ever stored once.
+func (r *GitRepository) PutBlob(ctx context.Context, src io.Reader, track bool) (string, error) {
+	dir := filepath.Join(r.memPath, AttachmentsDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("create attachments directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".blob-*")
+	if err != nil {
+		return "", fmt.Errorf("create temp file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	h := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, h), src); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("write blob: %w", err)
+	}
+	if r.durable {
+		if err := tmp.Sync(); err != nil {
+			_ = tmp.Close()
+			return "", fmt.Errorf("sync blob: %w", err)
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("close blob: %w", err)
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	path := filepath.Join(dir, hash)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		if err := renameFile(tmp.Name(), path); err != nil {
+			return "", fmt.Errorf("rename blob: %w", err)
+		}
+	}
+
+	if track {
+		if _, err := r.worktree.Add(filepath.Join(AttachmentsDir, hash)); err != nil {
+			return "", fmt.Errorf("stage blob: %w", err)
+		}
+	}
+	return hash, nil
+}
+
+func (r *GitRepository) OpenBlob(ctx context.Context, hash string) (io.ReadCloser, error) {
+	if !blobHashPattern.MatchString(hash) {
+		return nil, fmt.Errorf("%w: invalid hash %q", ErrAttachmentNotFound, hash)
+	}
+	f, err := os.Open(filepath.Join(r.memPath, AttachmentsDir, hash))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrAttachmentNotFound, hash)
+	}
+	return f, err
+}
+
+// RemoveBlob deletes a blob, staging the removal if it was tracked.
+func (r *GitRepository) RemoveBlob(ctx context.Context, hash string) error {
+	if !blobHashPattern.MatchString(hash) {
+		return fmt.Errorf("%w: invalid hash %q", ErrAttachmentNotFound, hash)
+	}
+	if _, err := r.worktree.Remove(AttachmentsDir + "/" + hash); err == nil {
+		return nil
+	}
+	if err := os.Remove(filepath.Join(r.memPath, AttachmentsDir, hash)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("remove blob: %w", err)
+	}
+	return nil
+}
+
+func (r *GitRepository) ListBlobs(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join(r.memPath, AttachmentsDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read attachments directory: %w", err)
+	}
+
+	var hashes []string
+	for _, e := range entries {
+		if !e.IsDir() && blobHashPattern.MatchString(e.Name()) {
+			hashes = append(hashes, e.Name())
+		}
+	}
+	return hashes, nil
+}
+
 // helpers
 
+// walkKeys calls fn for every memory file under prefix, skipping git,
+// index, template, attachment and config files. Those only live at the top
+// of the store, so a memory such as docs/templates/readme is still listed.
+// Directories that cannot hold a key under prefix, and anything the
+// exclude matcher matches, are not descended into.
+//
+// prefix matches whole path segments: "foo" selects foo and foo/y but not
+// foobar/x. A trailing slash makes no difference.
+func (r *GitRepository) walkKeys(prefix string, fn func(key Key, path string, info os.FileInfo) error) error {
+	prefix = strings.TrimSuffix(prefix, "/")
+	err := filepath.Walk(r.memPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		relPath, err := r.relPath(path)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if info.Name() == ".git" || slices.Contains(reservedNames, relPath) {
+				return filepath.SkipDir
+			}
+			if relPath == strings.TrimSuffix(TrashPrefix, "/") && !underPrefix(prefix, TrashPrefix) {
+				return filepath.SkipDir
+			}
+			if relPath == "." {
+				return nil
+			}
+			if !underPrefix(relPath, prefix) && !underPrefix(prefix, relPath) {
+				return filepath.SkipDir
+			}
+			if r.exclude.MatchPath(relPath, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !underPrefix(relPath, prefix) {
+			return nil
+		}
+
+		key, ok := r.memoryKey(relPath)
+		if !ok {
+			return nil
+		}
+
+		return fn(key, path, info)
+	})
+	if err != nil {
+		return fmt.Errorf("walk directory: %w", err)
+	}
+	return nil
+}
+
+// memoryKey returns the key held by the worktree file at relPath, relative
+// to the store. It is false for mem's own files and for files the exclude
+// matcher matches, directly or through a directory above them, so walkKeys
+// and Diff agree on what a memory is.
+func (r *GitRepository) memoryKey(relPath string) (Key, bool) {
+	if relPath == ".mem-init" || r.exclude.MatchFile(relPath) {
+		return "", false
+	}
+	key, err := parseStoredKey(relPath)
+	return key, err == nil
+}
+
+// underPrefix reports whether path is prefix or lies below it. Every path
+// is under the empty prefix.
+func underPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	return prefix == "" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// parseStoredKey turns a path relative to the store into a key, accepting
+// trashed keys under TrashPrefix.
+func parseStoredKey(relPath string) (Key, error) {
+	if rest, ok := strings.CutPrefix(relPath, TrashPrefix); ok {
+		key, err := NewKey(rest)
+		if err != nil {
+			return "", err
+		}
+		return TrashKey(key), nil
+	}
+	return NewKey(relPath)
+}
+
 func (r *GitRepository) getFirstCommitTime(key Key, fallback time.Time) time.Time {
 	relPath := key.String()
 
@@ -604,8 +1541,45 @@ func (r *GitRepository) getFirstCommitTime(key Key, fallback time.Time) time.Tim
 	return earliest
 }
 
+// head returns HEAD, or ErrNoCommits while the current branch is unborn.
+func (r *GitRepository) head() (*plumbing.Reference, error) {
+	head, err := r.repo.Head()
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, ErrNoCommits
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get HEAD: %w", err)
+	}
+	return head, nil
+}
+
+// resolveRef resolves ref to a commit hash. Nothing resolves before the
+// first commit, which is reported as ErrNoCommits.
+func (r *GitRepository) resolveRef(ref string) (*plumbing.Hash, error) {
+	hash, err := r.repo.ResolveRevision(plumbing.Revision(ref))
+	if err == nil {
+		return hash, nil
+	}
+	if _, headErr := r.head(); errors.Is(headErr, ErrNoCommits) {
+		return nil, ErrNoCommits
+	}
+	return nil, fmt.Errorf("resolve %s: %w", ref, err)
+}
+
+// keyToPath returns the file holding key. Keys always use forward slashes;
+// file paths use the OS separator.
 func (r *GitRepository) keyToPath(key Key) string {
-	return filepath.Join(r.memPath, key.String())
+	return filepath.Join(r.memPath, filepath.FromSlash(key.String()))
+}
+
+// relPath returns path relative to the store with forward slashes, the
+// form both keys and git use.
+func (r *GitRepository) relPath(path string) (string, error) {
+	rel, err := filepath.Rel(r.memPath, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
 }
 
 func (r *GitRepository) toCommit(c *object.Commit) *Commit {
@@ -618,6 +1592,7 @@ func (r *GitRepository) toCommit(c *object.Commit) *Commit {
 		Hash:      c.Hash.String(),
 		Message:   strings.TrimSpace(c.Message),
 		Author:    c.Author.Name,
+		Email:     c.Author.Email,
 		Timestamp: c.Author.When,
 		Parents:   parents,
 	}
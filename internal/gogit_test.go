@@ -2,9 +2,15 @@ package internal
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+
+	"github.com/go-git/go-git/v5"
 )
 
 func setupGitRepo(t *testing.T) (*GitRepository, Scope) {
@@ -31,6 +37,154 @@ func setupGitRepo(t *testing.T) (*GitRepository, Scope) {
 	return repo, scope
 }
 
+func TestInitRepositoryWritesDefaultConfig(t *testing.T) {
+	repo, scope := setupGitRepo(t)
+
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	def := DefaultConfig()
+	if cfg.Embeddings != def.Embeddings {
+		t.Errorf("embeddings = %+v, want defaults %+v", cfg.Embeddings, def.Embeddings)
+	}
+
+	diff, err := repo.Diff(context.Background(), "")
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if diff != "" {
+		t.Errorf("config should be committed by init, got diff %q", diff)
+	}
+}
+
+func TestInitRepositoryDefaultBranch(t *testing.T) {
+	for _, tc := range []struct{ configured, want string }{
+		{"", DefaultBranch},
+		{"trunk", "trunk"},
+	} {
+		tmpDir := t.TempDir()
+		scope := Scope{Type: ScopeProject, Path: tmpDir, MemPath: filepath.Join(tmpDir, ".mem")}
+		if err := os.MkdirAll(scope.MemPath, 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if tc.configured != "" {
+			cfg := DefaultConfig()
+			cfg.Git.DefaultBranch = tc.configured
+			if err := SaveConfig(scope, cfg); err != nil {
+				t.Fatalf("save config: %v", err)
+			}
+		}
+		if err := InitRepository(scope); err != nil {
+			t.Fatalf("init repo: %v", err)
+		}
+
+		repo, err := NewGitRepository(scope)
+		if err != nil {
+			t.Fatalf("new repo: %v", err)
+		}
+		current, err := repo.Current(context.Background())
+		if err != nil {
+			t.Fatalf("current: %v", err)
+		}
+		if current.Name != tc.want || current.Head == "" {
+			t.Errorf("default_branch %q: current = %+v, want %s with the init commit", tc.configured, current, tc.want)
+		}
+	}
+}
+
+func TestInitRepositoryKeepsExistingConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	scope := Scope{
+		Type:    ScopeProject,
+		Path:    tmpDir,
+		MemPath: filepath.Join(tmpDir, ".mem"),
+	}
+	if err := os.MkdirAll(scope.MemPath, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	custom := DefaultConfig()
+	custom.DefaultProvider = "mine"
+	if err := SaveConfig(scope, custom); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	if err := InitRepository(scope); err != nil {
+		t.Fatalf("init repo: %v", err)
+	}
+
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if cfg.DefaultProvider != "mine" {
+		t.Errorf("existing config was overwritten: %+v", cfg)
+	}
+}
+
+func TestGitRepositoryUnbornHead(t *testing.T) {
+	scope := Scope{Type: ScopeProject, MemPath: filepath.Join(t.TempDir(), ".mem")}
+	if _, err := git.PlainInit(scope.MemPath, false); err != nil {
+		t.Fatalf("plain init: %v", err)
+	}
+	repo, err := NewGitRepository(scope)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+	ctx := context.Background()
+
+	branch, err := repo.Current(ctx)
+	if err != nil {
+		t.Fatalf("current: %v", err)
+	}
+	if branch.Name == "" || branch.Head != "" {
+		t.Errorf("current = %+v, want a named branch without a head", branch)
+	}
+	if _, err := repo.Log(ctx, 0); !errors.Is(err, ErrNoCommits) {
+		t.Errorf("log err = %v, want ErrNoCommits", err)
+	}
+	if _, err := repo.Show(ctx, "HEAD"); !errors.Is(err, ErrNoCommits) {
+		t.Errorf("show err = %v, want ErrNoCommits", err)
+	}
+
+	if err := repo.Save(ctx, NewMemory("notes/first", []byte("hello\n"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	diff, err := repo.Diff(ctx, "")
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if !strings.Contains(diff, "+++ b/notes/first") {
+		t.Errorf("diff should show the staged memory as added:\n%s", diff)
+	}
+}
+
+func TestInitRepositoryIdempotent(t *testing.T) {
+	scope := Scope{Type: ScopeProject, MemPath: filepath.Join(t.TempDir(), ".mem")}
+	if _, err := git.PlainInit(scope.MemPath, false); err != nil {
+		t.Fatalf("plain init: %v", err)
+	}
+
+	for range 2 {
+		if err := InitRepository(scope); err != nil {
+			t.Fatalf("init: %v", err)
+		}
+	}
+
+	repo, err := NewGitRepository(scope)
+	if err != nil {
+		t.Fatalf("new repo: %v", err)
+	}
+	commits, err := repo.Log(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if len(commits) != 1 {
+		t.Errorf("got %d commits, want only the initial one", len(commits))
+	}
+}
+
 func TestGitRepositorySaveAndGet(t *testing.T) {
 	repo, _ := setupGitRepo(t)
 	ctx := context.Background()
@@ -52,6 +206,113 @@ func TestGitRepositorySaveAndGet(t *testing.T) {
 	}
 }
 
+func TestGitRepositoryGetAtRef(t *testing.T) {
+	repo, _ := setupGitRepo(t)
+	ctx := context.Background()
+
+	key, _ := NewKey("notes/plan")
+	if err := repo.Save(ctx, NewMemory(key, []byte("v1"))); err != nil {
+		t.Fatalf("save v1: %v", err)
+	}
+	first, err := repo.Commit(ctx, "plan v1")
+	if err != nil {
+		t.Fatalf("commit v1: %v", err)
+	}
+	if err := repo.Save(ctx, NewMemory(key, []byte("v2"))); err != nil {
+		t.Fatalf("save v2: %v", err)
+	}
+	if _, err := repo.Commit(ctx, "plan v2"); err != nil {
+		t.Fatalf("commit v2: %v", err)
+	}
+	// Uncommitted edits are not visible at any ref.
+	if err := repo.Save(ctx, NewMemory(key, []byte("draft"))); err != nil {
+		t.Fatalf("save draft: %v", err)
+	}
+
+	old, err := repo.GetAtRef(ctx, key, first.Hash)
+	if err != nil {
+		t.Fatalf("get at first commit: %v", err)
+	}
+	if string(old.Content) != "v1" {
+		t.Errorf("content at %s = %q, want v1", first.Hash[:7], old.Content)
+	}
+	if !old.UpdatedAt.Equal(first.Timestamp) {
+		t.Errorf("UpdatedAt = %v, want commit time %v", old.UpdatedAt, first.Timestamp)
+	}
+
+	head, err := repo.GetAtRef(ctx, key, "HEAD")
+	if err != nil {
+		t.Fatalf("get at HEAD: %v", err)
+	}
+	if string(head.Content) != "v2" {
+		t.Errorf("content at HEAD = %q, want v2", head.Content)
+	}
+
+	missing, _ := NewKey("notes/missing")
+	if _, err := repo.GetAtRef(ctx, missing, "HEAD"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing key: expected ErrNotFound, got %v", err)
+	}
+	dir, _ := NewKey("notes")
+	if _, err := repo.GetAtRef(ctx, dir, "HEAD"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("directory key: expected ErrNotFound, got %v", err)
+	}
+	if _, err := repo.GetAtRef(ctx, key, "no-such-ref"); err == nil {
+		t.Error("expected error for unknown ref")
+	}
+}
+
+func TestGitRepositorySaveFailureKeepsPriorContent(t *testing.T) {
+	repo, scope := setupGitRepo(t)
+	ctx := context.Background()
+
+	key, _ := NewKey("notes/important")
+	if err := repo.Save(ctx, NewMemory(key, []byte("original"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	renameFile = func(string, string) error { return errors.New("crash") }
+	t.Cleanup(func() { renameFile = os.Rename })
+
+	if err := repo.Save(ctx, NewMemory(key, []byte("half-writ"))); err == nil {
+		t.Fatal("expected save to fail")
+	}
+
+	got, err := repo.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if string(got.Content) != "original" {
+		t.Errorf("content = %q, want prior content intact", got.Content)
+	}
+
+	entries, err := os.ReadDir(filepath.Join(scope.MemPath, "notes"))
+	if err != nil {
+		t.Fatalf("read dir: %v", err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temp file left behind: %v", entries)
+	}
+}
+
+func TestGitRepositorySaveDurable(t *testing.T) {
+	repo, _ := setupGitRepo(t)
+	repo.SetDurable(true)
+	ctx := context.Background()
+
+	key, _ := NewKey("notes/synced")
+	if err := repo.Save(ctx, NewMemory(key, []byte("on disk"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	got, err := repo.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if string(got.Content) != "on disk" {
+		t.Errorf("content = %q, want %q", got.Content, "on disk")
+	}
+}
+
 func TestGitRepositoryDelete(t *testing.T) {
 	repo, _ := setupGitRepo(t)
 	ctx := context.Background()
@@ -114,6 +375,146 @@ func TestGitRepositoryList(t *testing.T) {
 	}
 }
 
+// Only the top-level vectors and templates directories belong to mem; a
+// memory may use those names deeper in its key.
+func TestGitRepositoryListNestedReservedNames(t *testing.T) {
+	repo, scope := setupGitRepo(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"docs/templates/readme", "ml/vectors/notes", "app/config.yaml"} {
+		key, _ := NewKey(name)
+		if err := repo.Save(ctx, NewMemory(key, []byte("content"))); err != nil {
+			t.Fatalf("save %s: %v", name, err)
+		}
+	}
+	if err := os.MkdirAll(scope.TemplatePath(), 0755); err != nil {
+		t.Fatalf("mkdir templates: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(scope.TemplatePath(), "note"), []byte("template"), 0644); err != nil {
+		t.Fatalf("write template: %v", err)
+	}
+
+	all, err := repo.List(ctx, "")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	var keys []string
+	for _, mem := range all {
+		keys = append(keys, mem.Key.String())
+	}
+	if want := []string{"app/config.yaml", "docs/templates/readme", "ml/vectors/notes"}; !slices.Equal(keys, want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+}
+
+// TestGitRepositoryKeyPathRoundTrip guards the key and path conversions,
+// which differ on Windows where paths use backslashes.
+func TestGitRepositoryKeyPathRoundTrip(t *testing.T) {
+	repo, _ := setupGitRepo(t)
+
+	for _, k := range []Key{"notes", "projects/backend/deploy", "a/b/c/d.md"} {
+		path := repo.keyToPath(k)
+		if want := filepath.Join(repo.memPath, filepath.FromSlash(k.String())); path != want {
+			t.Errorf("keyToPath(%q) = %q, want %q", k, path, want)
+		}
+		rel, err := repo.relPath(path)
+		if err != nil {
+			t.Fatalf("relPath(%q): %v", path, err)
+		}
+		if rel != k.String() {
+			t.Errorf("relPath(keyToPath(%q)) = %q", k, rel)
+		}
+		if _, err := NewKey(rel); err != nil {
+			t.Errorf("NewKey(%q): %v", rel, err)
+		}
+	}
+}
+
+func TestGitRepositoryListPrefixAndExclude(t *testing.T) {
+	repo, scope := setupGitRepo(t)
+	ctx := context.Background()
+
+	for _, name := range []string{"a/b", "a-c", "ab/d", "b/e", "drafts/f", "a/drafts/g"} {
+		if err := repo.Save(ctx, NewMemory(Key(name), []byte("content"))); err != nil {
+			t.Fatalf("save %s: %v", name, err)
+		}
+	}
+	// A checkout nested in the store must never produce keys.
+	nested := filepath.Join(scope.MemPath, "b", ".git")
+	if err := os.MkdirAll(nested, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(nested, "HEAD"), []byte("ref: refs/heads/main\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	exclude := &IgnoreMatcher{}
+	exclude.Add("drafts/")
+	repo.SetExclude(exclude)
+
+	for _, tt := range []struct {
+		prefix string
+		want   []string
+	}{
+		{"", []string{"a-c", "a/b", "ab/d", "b/e"}},
+		{"a", []string{"a/b"}},
+		{"a-", nil},
+		{"a/", []string{"a/b"}},
+		{"b", []string{"b/e"}},
+	} {
+		memories, err := repo.List(ctx, tt.prefix)
+		if err != nil {
+			t.Fatalf("list %q: %v", tt.prefix, err)
+		}
+		var got []string
+		for _, mem := range memories {
+			got = append(got, mem.Key.String())
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("list %q = %v, want %v", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+// BenchmarkGitRepositoryListPrefix lists one small prefix of a store with
+// a large history and many memories elsewhere, which pruning never visits.
+func BenchmarkGitRepositoryListPrefix(b *testing.B) {
+	dir := b.TempDir()
+	scope := Scope{Type: ScopeProject, Path: dir, MemPath: filepath.Join(dir, ".mem")}
+	if err := InitRepository(scope); err != nil {
+		b.Fatalf("init repo: %v", err)
+	}
+	repo, err := NewGitRepository(scope)
+	if err != nil {
+		b.Fatalf("new repo: %v", err)
+	}
+
+	write := func(rel string) {
+		path := filepath.Join(scope.MemPath, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			b.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("content"), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	for i := range 5000 {
+		write(fmt.Sprintf(".git/objects/%02x/obj%d", i%256, i))
+		write(fmt.Sprintf("bulk/%d/note", i))
+	}
+	for i := range 10 {
+		write(fmt.Sprintf("wanted/%d", i))
+	}
+
+	ctx := context.Background()
+	for b.Loop() {
+		memories, err := repo.List(ctx, "wanted/")
+		if err != nil || len(memories) != 10 {
+			b.Fatalf("list: %d memories, %v", len(memories), err)
+		}
+	}
+}
+
 func TestGitRepositoryExists(t *testing.T) {
 	repo, _ := setupGitRepo(t)
 	ctx := context.Background()
@@ -169,6 +570,14 @@ func TestGitRepositoryCommitAndLog(t *testing.T) {
 	}
 }
 
+func TestGitRepositoryCommitCleanTree(t *testing.T) {
+	repo, _ := setupGitRepo(t)
+
+	if _, err := repo.Commit(context.Background(), "nothing"); !errors.Is(err, ErrNothingToCommit) {
+		t.Errorf("expected ErrNothingToCommit, got %v", err)
+	}
+}
+
 func TestGitRepositoryBranch(t *testing.T) {
 	repo, _ := setupGitRepo(t)
 	ctx := context.Background()
@@ -181,7 +590,7 @@ func TestGitRepositoryBranch(t *testing.T) {
 		t.Error("current branch name is empty")
 	}
 
-	_, err = repo.Create(ctx, "feature")
+	_, err = repo.Create(ctx, "feature", "")
 	if err != nil {
 		t.Fatalf("create branch: %v", err)
 	}
@@ -194,7 +603,7 @@ func TestGitRepositoryBranch(t *testing.T) {
 		t.Errorf("expected at least 2 branches, got %d", len(branches))
 	}
 
-	if err := repo.Switch(ctx, "feature"); err != nil {
+	if err := repo.Switch(ctx, "feature", false); err != nil {
 		t.Fatalf("switch: %v", err)
 	}
 
@@ -207,6 +616,334 @@ func TestGitRepositoryBranch(t *testing.T) {
 	}
 }
 
+func TestGitRepositoryCreateExistingBranch(t *testing.T) {
+	repo, _ := setupGitRepo(t)
+	ctx := context.Background()
+
+	if _, err := repo.Create(ctx, "feature", ""); err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	if _, err := repo.Create(ctx, "feature", ""); !errors.Is(err, ErrBranchExists) {
+		t.Errorf("expected ErrBranchExists, got %v", err)
+	}
+}
+
+func TestGitRepositoryCreateFromRef(t *testing.T) {
+	repo, _ := setupGitRepo(t)
+	ctx := context.Background()
+
+	initial, err := repo.Current(ctx)
+	if err != nil {
+		t.Fatalf("current: %v", err)
+	}
+
+	key, _ := NewKey("later")
+	if err := repo.Save(ctx, NewMemory(key, []byte("data"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := repo.Commit(ctx, "later commit"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	branch, err := repo.Create(ctx, "old", initial.Head)
+	if err != nil {
+		t.Fatalf("create from %s: %v", initial.Head, err)
+	}
+	if branch.Head != initial.Head {
+		t.Errorf("branch head = %s, want %s", branch.Head, initial.Head)
+	}
+
+	if _, err := repo.Create(ctx, "bogus", "no-such-ref"); err == nil {
+		t.Error("expected error creating from unknown ref")
+	}
+}
+
+func TestGitRepositoryRenameCurrentBranch(t *testing.T) {
+	repo, _ := setupGitRepo(t)
+	ctx := context.Background()
+
+	key, _ := NewKey("kept")
+	if err := repo.Save(ctx, NewMemory(key, []byte("data"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := repo.Commit(ctx, "add kept"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	before, err := repo.Current(ctx)
+	if err != nil {
+		t.Fatalf("current: %v", err)
+	}
+	if err := repo.RenameBranch(ctx, before.Name, "renamed"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+
+	after, err := repo.Current(ctx)
+	if err != nil {
+		t.Fatalf("current after rename: %v", err)
+	}
+	if after.Name != "renamed" || after.Head != before.Head {
+		t.Errorf("current = %s@%s, want renamed@%s", after.Name, after.Head, before.Head)
+	}
+
+	branches, err := repo.ListBranches(ctx)
+	if err != nil {
+		t.Fatalf("list branches: %v", err)
+	}
+	if len(branches) != 1 || branches[0].Name != "renamed" {
+		t.Errorf("branches = %v, want only renamed", branches)
+	}
+
+	if mem, err := repo.Get(ctx, key); err != nil || string(mem.Content) != "data" {
+		t.Errorf("worktree changed by rename: %v", err)
+	}
+	diff, err := repo.Diff(ctx, "")
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if diff != "" {
+		t.Errorf("expected clean worktree after rename, got diff:\n%s", diff)
+	}
+}
+
+func TestGitRepositoryRenameBranchErrors(t *testing.T) {
+	repo, _ := setupGitRepo(t)
+	ctx := context.Background()
+
+	if _, err := repo.Create(ctx, "feature", ""); err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	current, err := repo.Current(ctx)
+	if err != nil {
+		t.Fatalf("current: %v", err)
+	}
+
+	if err := repo.RenameBranch(ctx, "feature", current.Name); !errors.Is(err, ErrBranchExists) {
+		t.Errorf("expected ErrBranchExists, got %v", err)
+	}
+	if err := repo.RenameBranch(ctx, "missing", "other"); err == nil {
+		t.Error("expected error renaming a missing branch")
+	}
+}
+
+func TestGitRepositoryListBranchesCreatedAt(t *testing.T) {
+	repo, _ := setupGitRepo(t)
+	ctx := context.Background()
+
+	if _, err := repo.Create(ctx, "feature", ""); err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	if err := repo.Switch(ctx, "feature", false); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	key, _ := NewKey("only/on/feature")
+	if err := repo.Save(ctx, NewMemory(key, []byte("x"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	commit, err := repo.Commit(ctx, "feature work")
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	branches, err := repo.ListBranches(ctx)
+	if err != nil {
+		t.Fatalf("list branches: %v", err)
+	}
+	for _, b := range branches {
+		if b.CreatedAt.IsZero() {
+			t.Errorf("branch %s has zero CreatedAt", b.Name)
+		}
+		if b.Name == "feature" && !b.CreatedAt.Equal(commit.Timestamp) {
+			t.Errorf("feature CreatedAt = %v, want first unique commit %v", b.CreatedAt, commit.Timestamp)
+		}
+	}
+}
+
+func TestGitRepositoryLogAll(t *testing.T) {
+	repo, _ := setupGitRepo(t)
+	ctx := context.Background()
+
+	commit := func(k, message string) *Commit {
+		t.Helper()
+		key, _ := NewKey(k)
+		if err := repo.Save(ctx, NewMemory(key, []byte(message))); err != nil {
+			t.Fatalf("save %s: %v", k, err)
+		}
+		c, err := repo.Commit(ctx, message)
+		if err != nil {
+			t.Fatalf("commit %s: %v", message, err)
+		}
+		return c
+	}
+
+	base := commit("shared", "base")
+	main, err := repo.Current(ctx)
+	if err != nil {
+		t.Fatalf("current: %v", err)
+	}
+	if _, err := repo.Create(ctx, "feature", ""); err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	mainTip := commit("on/main", "main work")
+	if err := repo.Switch(ctx, "feature", false); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	featureTip := commit("on/feature", "feature work")
+
+	commits, err := repo.LogAll(ctx, 0)
+	if err != nil {
+		t.Fatalf("log all: %v", err)
+	}
+
+	pos := make(map[string]int)
+	for i, c := range commits {
+		if _, dup := pos[c.Hash]; dup {
+			t.Errorf("commit %s listed twice", c.Hash[:7])
+		}
+		pos[c.Hash] = i
+	}
+	for _, tip := range []*Commit{mainTip, featureTip} {
+		if _, ok := pos[tip.Hash]; !ok {
+			t.Errorf("tip %q missing from LogAll", tip.Message)
+		} else if pos[tip.Hash] > pos[base.Hash] {
+			t.Errorf("tip %q listed after its parent", tip.Message)
+		}
+	}
+	if got := commits[pos[mainTip.Hash]].Refs; !slices.Equal(got, []string{main.Name}) {
+		t.Errorf("main tip refs = %v, want [%s]", got, main.Name)
+	}
+	if got := commits[pos[featureTip.Hash]].Refs; !slices.Equal(got, []string{"feature"}) {
+		t.Errorf("feature tip refs = %v, want [feature]", got)
+	}
+
+	// Log only follows HEAD, which is now feature.
+	head, err := repo.Log(ctx, 0)
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	for _, c := range head {
+		if c.Hash == mainTip.Hash {
+			t.Error("Log should not include the main tip while on feature")
+		}
+	}
+
+	limited, err := repo.LogAll(ctx, 2)
+	if err != nil {
+		t.Fatalf("log all limited: %v", err)
+	}
+	if len(limited) != 2 {
+		t.Errorf("limited LogAll returned %d commits, want 2", len(limited))
+	}
+}
+
+func TestGitRepositorySwitchRefusesUncommittedChanges(t *testing.T) {
+	repo, _ := setupGitRepo(t)
+	ctx := context.Background()
+
+	if _, err := repo.Create(ctx, "feature", ""); err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	key, _ := NewKey("staged")
+	if err := repo.Save(ctx, NewMemory(key, []byte("not committed"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	err := repo.Switch(ctx, "feature", false)
+	if !errors.Is(err, ErrUncommittedChanges) {
+		t.Fatalf("expected ErrUncommittedChanges, got %v", err)
+	}
+	if mem, err := repo.Get(ctx, key); err != nil || string(mem.Content) != "not committed" {
+		t.Errorf("refused switch lost the staged memory: %v", err)
+	}
+
+	if err := repo.Switch(ctx, "feature", true); err != nil {
+		t.Fatalf("forced switch: %v", err)
+	}
+	current, err := repo.Current(ctx)
+	if err != nil {
+		t.Fatalf("current: %v", err)
+	}
+	if current.Name != "feature" {
+		t.Errorf("current branch = %q, want %q", current.Name, "feature")
+	}
+}
+
+func TestGitRepositorySwitchKeepsConfig(t *testing.T) {
+	repo, scope := setupGitRepo(t)
+	ctx := context.Background()
+
+	if _, err := repo.Create(ctx, "feature", ""); err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.Providers = map[string]ProviderConfig{"openai": {APIKey: "sk-test", Model: "gpt-4o"}}
+	cfg.DefaultProvider = "openai"
+	if err := SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	uncommitted, err := repo.Uncommitted(ctx)
+	if err != nil {
+		t.Fatalf("uncommitted: %v", err)
+	}
+	if len(uncommitted) != 0 {
+		t.Errorf("Uncommitted() = %v, want config.yaml left out", uncommitted)
+	}
+
+	if err := repo.Switch(ctx, "feature", false); err != nil {
+		t.Fatalf("switch after saving config: %v", err)
+	}
+	loaded, err := LoadConfig(scope)
+	if err != nil {
+		t.Fatalf("load config: %v", err)
+	}
+	if loaded.DefaultProvider != "openai" || loaded.Providers["openai"].APIKey != "sk-test" {
+		t.Errorf("switch lost the saved provider: %+v", loaded.Providers)
+	}
+}
+
+func TestGitRepositoryDeleteUnmergedBranch(t *testing.T) {
+	repo, _ := setupGitRepo(t)
+	ctx := context.Background()
+
+	main, err := repo.Current(ctx)
+	if err != nil {
+		t.Fatalf("current: %v", err)
+	}
+
+	if _, err := repo.Create(ctx, "feature", ""); err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	if err := repo.Switch(ctx, "feature", false); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	key, _ := NewKey("only/on/feature")
+	if err := repo.Save(ctx, NewMemory(key, []byte("x"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := repo.Commit(ctx, "feature work"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if err := repo.Switch(ctx, main.Name, false); err != nil {
+		t.Fatalf("switch back: %v", err)
+	}
+
+	if err := repo.DeleteBranch(ctx, "feature", false); !errors.Is(err, ErrBranchNotMerged) {
+		t.Fatalf("expected ErrBranchNotMerged, got %v", err)
+	}
+	if err := repo.DeleteBranch(ctx, "feature", true); err != nil {
+		t.Fatalf("forced delete: %v", err)
+	}
+
+	branches, err := repo.ListBranches(ctx)
+	if err != nil {
+		t.Fatalf("list branches: %v", err)
+	}
+	if len(branches) != 1 {
+		t.Errorf("expected only %s left, got %v", main.Name, branches)
+	}
+}
+
 func TestGitRepositoryDiffWorktree(t *testing.T) {
 	repo, _ := setupGitRepo(t)
 	ctx := context.Background()
@@ -234,3 +971,99 @@ func TestGitRepositoryDiffWorktree(t *testing.T) {
 		t.Error("expected non-empty diff after staging")
 	}
 }
+
+func TestContentDiff(t *testing.T) {
+	got := ContentDiff("notes/a", "one\ntwo\n", "one\nthree\n")
+	want := "--- a/notes/a\n+++ b/notes/a\n one\n-two\n+three\n"
+	if got != want {
+		t.Errorf("ContentDiff = %q, want %q", got, want)
+	}
+
+	got = ContentDiff("notes/b", "", "new\n")
+	want = "--- /dev/null\n+++ b/notes/b\n+new\n"
+	if got != want {
+		t.Errorf("ContentDiff of a new memory = %q, want %q", got, want)
+	}
+}
+
+func TestGitRepositoryDiffWorktreeUntracked(t *testing.T) {
+	repo, scope := setupGitRepo(t)
+	ctx := context.Background()
+
+	// Written straight to disk, never staged.
+	if err := os.MkdirAll(filepath.Join(scope.MemPath, "notes"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(scope.MemPath, "notes", "loose"), []byte("untracked line\n"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	// mem's own files are not memories and stay out of the diff.
+	if err := os.WriteFile(filepath.Join(scope.VectorPath(), MappingFilename), []byte("{}"), 0644); err != nil {
+		t.Fatalf("write mapping: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(scope.MemPath, UsageFilename), []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("write usage: %v", err)
+	}
+
+	diff, err := repo.Diff(ctx, "")
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if !strings.Contains(diff, "--- /dev/null\n+++ b/notes/loose\n") || !strings.Contains(diff, "+untracked line") {
+		t.Errorf("expected the untracked file as an addition, got:\n%s", diff)
+	}
+	if strings.Contains(diff, "vectors") || strings.Contains(diff, UsageFilename) {
+		t.Errorf("mem's own files should not be diffed, got:\n%s", diff)
+	}
+
+	stats, err := repo.DiffStat(ctx, "")
+	if err != nil {
+		t.Fatalf("diff stat: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Path != "notes/loose" || stats[0].Added != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestGitRepositoryShowRootCommit(t *testing.T) {
+	repo, _ := setupGitRepo(t)
+	ctx := context.Background()
+
+	root, err := repo.Show(ctx, "HEAD")
+	if err != nil {
+		t.Fatalf("show init commit: %v", err)
+	}
+	if len(root.Parents) != 0 {
+		t.Fatalf("expected the init commit, got parents %v", root.Parents)
+	}
+	for _, want := range []string{"+++ b/.mem-init", "+mem repository initialized"} {
+		if !strings.Contains(root.Diff, want) {
+			t.Errorf("init commit diff missing %q:\n%s", want, root.Diff)
+		}
+	}
+
+	key, _ := NewKey("later")
+	if err := repo.Save(ctx, NewMemory(key, []byte("later content"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := repo.Commit(ctx, "add later"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	later, err := repo.Show(ctx, "HEAD")
+	if err != nil {
+		t.Fatalf("show: %v", err)
+	}
+	if !strings.Contains(later.Diff, "+later content") || strings.Contains(later.Diff, ".mem-init") {
+		t.Errorf("expected only the later change against its parent:\n%s", later.Diff)
+	}
+
+	// Diffing against the root commit shows everything added since.
+	diff, err := repo.Diff(ctx, root.Hash)
+	if err != nil {
+		t.Fatalf("diff against root: %v", err)
+	}
+	if !strings.Contains(diff, "+later content") {
+		t.Errorf("diff against root missing the later change:\n%s", diff)
+	}
+}
@@ -20,6 +20,7 @@ type LocalEmbedder struct {
 	dimension int
 	device    Device
 	modelPath string
+	clone     bool // shares model with the embedder it was cloned from
 }
 
 func NewLocalEmbedder(modelPath string, dimension int, opts ...EmbedderOption) (*LocalEmbedder, error) {
@@ -77,16 +78,10 @@ func NewLocalEmbedder(modelPath string, dimension int, opts ...EmbedderOption) (
 		dimension = actualDim
 	}
 
-	ctxParams := gollama.Context_default_params()
-	ctxParams.Embeddings = 1
-	ctxParams.NCtx = 512
-
-	ctx, err = gollama.Init_from_model(model, ctxParams)
+	ctx, err = newEmbeddingContext(model)
 	if err != nil {
-		return nil, fmt.Errorf("init context: %w", err)
+		return nil, err
 	}
-
-	gollama.Set_embeddings(ctx, true)
 	success.Store(true)
 
 	return &LocalEmbedder{
@@ -98,6 +93,58 @@ func NewLocalEmbedder(modelPath string, dimension int, opts ...EmbedderOption) (
 	}, nil
 }
 
+// NewLocalEmbedderPool loads the model once and embeds with size contexts
+// in parallel. A size below two returns a plain LocalEmbedder.
+func NewLocalEmbedderPool(modelPath string, dimension, size int, opts ...EmbedderOption) (Embedder, error) {
+	base, err := NewLocalEmbedder(modelPath, dimension, opts...)
+	if err != nil || size < 2 {
+		return base, err
+	}
+
+	members := []Embedder{base}
+	for len(members) < size {
+		clone, err := base.Clone()
+		if err != nil {
+			_ = NewEmbedderPool(members...).Close()
+			return nil, err
+		}
+		members = append(members, clone)
+	}
+	return NewEmbedderPool(members...), nil
+}
+
+// Clone returns an embedder with its own context on the same model, so the
+// two can embed at the same time. Close the clones before the original,
+// which frees the model.
+func (e *LocalEmbedder) Clone() (*LocalEmbedder, error) {
+	ctx, err := newEmbeddingContext(e.model)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalEmbedder{
+		model:     e.model,
+		ctx:       ctx,
+		dimension: e.dimension,
+		device:    e.device,
+		modelPath: e.modelPath,
+		clone:     true,
+	}, nil
+}
+
+func newEmbeddingContext(model gollama.LlamaModel) (gollama.LlamaContext, error) {
+	ctxParams := gollama.Context_default_params()
+	ctxParams.Embeddings = 1
+	ctxParams.NCtx = 512
+
+	ctx, err := gollama.Init_from_model(model, ctxParams)
+	if err != nil {
+		return 0, fmt.Errorf("init context: %w", err)
+	}
+
+	gollama.Set_embeddings(ctx, true)
+	return ctx, nil
+}
+
 func (e *LocalEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
@@ -171,11 +218,18 @@ func (e *LocalEmbedder) Device() string {
 	return string(e.device)
 }
 
+func (e *LocalEmbedder) Model() string {
+	return e.modelPath
+}
+
 func (e *LocalEmbedder) Close() error {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	gollama.Free(e.ctx)
+	if e.clone {
+		return nil
+	}
 	gollama.Model_free(e.model)
 	gollama.Backend_free()
 
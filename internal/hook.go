@@ -1,18 +1,26 @@
 package internal
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 const HookMarker = "# mem: managed post-commit hook"
 
-// HookScript returns the shell shim content for a given hook type.
+// HookScript returns the shell shim content for a given hook type. Git for
+// Windows runs hooks with its bundled sh, so the same shim works there.
 func HookScript(hookType string) string {
 	return fmt.Sprintf("#!/bin/sh\n%s\nexec mem hook run %s \"$@\"\n", HookMarker, hookType)
 }
@@ -42,13 +50,21 @@ func FindGitDir(dir string) (string, error) {
 // CommitContext holds metadata about a git commit for hook processing.
 type CommitContext struct {
 	Hash    string
-	Message string
+	Message string // subject line
+	Body    string // rest of the message, if any
 	Author  string
+	Time    time.Time
 	Diff    string
 }
 
 // --- InstallHookUseCase ---
 
+// HookStrategies are the post-commit hook strategies.
+var HookStrategies = []string{"extract", "record", "llm-extract", "summarize", "script", "all"}
+
+// ErrUnknownStrategy is returned for a hook strategy not in HookStrategies.
+var ErrUnknownStrategy = errors.New("unknown hook strategy")
+
 type InstallHookInput struct {
 	Scope    string
 	Strategy string
@@ -65,6 +81,9 @@ func NewInstallHookUseCase(resolver *ScopeResolver) *InstallHookUseCase {
 }
 
 func (uc *InstallHookUseCase) Execute(_ context.Context, input InstallHookInput) error {
+	if input.Strategy != "" && !slices.Contains(HookStrategies, input.Strategy) {
+		return fmt.Errorf("%w %q (want one of %s)", ErrUnknownStrategy, input.Strategy, strings.Join(HookStrategies, ", "))
+	}
 	scope := uc.resolver.Resolve(input.Scope)
 
 	gitDir, err := FindGitDir(scope.Path)
@@ -174,91 +193,168 @@ func (uc *UninstallHookUseCase) Execute(_ context.Context, input UninstallHookIn
 
 // --- Extract Strategy ---
 
-var (
-	diffFileAddedRe   = regexp.MustCompile(`(?m)^\+\+\+ b/(.+)$`)
-	diffFileDeletedRe = regexp.MustCompile(`(?m)^--- a/(.+)$`)
-	diffFuncAddedRe   = regexp.MustCompile(`(?m)^\+.*func\s+(\w+)`)
-	diffTypeAddedRe   = regexp.MustCompile(`(?m)^\+.*type\s+(\w+)`)
-	diffFuncRemovedRe = regexp.MustCompile(`(?m)^-.*func\s+(\w+)`)
-	diffTypeRemovedRe = regexp.MustCompile(`(?m)^-.*type\s+(\w+)`)
-	configFileRe      = regexp.MustCompile(`\.(yaml|yml|json|toml)$`)
-)
+var configFileRe = regexp.MustCompile(`\.(yaml|yml|json|toml)$`)
 
-// StrategyExtract parses a diff for structural changes without LLM.
-func StrategyExtract(ctx CommitContext) (string, error) {
-	if ctx.Diff == "" {
-		return "", nil
-	}
+// symbolPatterns find declared functions and types in a diff line, without
+// its leading +/-, for one language.
+type symbolPatterns struct {
+	funcs []*regexp.Regexp
+	types []*regexp.Regexp
+}
 
-	var parts []string
+var (
+	goSymbols = symbolPatterns{
+		funcs: []*regexp.Regexp{regexp.MustCompile(`\bfunc\s+(?:\([^)]*\)\s*)?(\w+)`)},
+		types: []*regexp.Regexp{regexp.MustCompile(`\btype\s+(\w+)`)},
+	}
+	pythonSymbols = symbolPatterns{
+		funcs: []*regexp.Regexp{regexp.MustCompile(`^\s*(?:async\s+)?def\s+(\w+)`)},
+		types: []*regexp.Regexp{regexp.MustCompile(`^\s*class\s+(\w+)`)},
+	}
+	jsSymbols = symbolPatterns{
+		funcs: []*regexp.Regexp{
+			regexp.MustCompile(`\bfunction\s*\*?\s*(\w+)`),
+			regexp.MustCompile(`^\s*export\s+(?:const|let)\s+(\w+)`),
+		},
+		types: []*regexp.Regexp{regexp.MustCompile(`\bclass\s+(\w+)`)},
+	}
+	rustSymbols = symbolPatterns{
+		funcs: []*regexp.Regexp{regexp.MustCompile(`\bfn\s+(\w+)`)},
+		types: []*regexp.Regexp{regexp.MustCompile(`\b(?:struct|trait)\s+(\w+)`)},
+	}
+)
 
-	addedFiles := diffFileAddedRe.FindAllStringSubmatch(ctx.Diff, -1)
-	deletedPrefixes := diffFileDeletedRe.FindAllStringSubmatch(ctx.Diff, -1)
+// symbolsByExt picks the symbol patterns by file extension. Lines outside
+// any file header, as in a bare patch, use the Go patterns.
+var symbolsByExt = map[string]symbolPatterns{
+	".go":  goSymbols,
+	".py":  pythonSymbols,
+	".js":  jsSymbols,
+	".jsx": jsSymbols,
+	".mjs": jsSymbols,
+	".cjs": jsSymbols,
+	".ts":  jsSymbols,
+	".tsx": jsSymbols,
+	".rs":  rustSymbols,
+}
 
-	deletedSet := make(map[string]bool)
-	for _, m := range deletedPrefixes {
-		if m[1] != "/dev/null" {
-			deletedSet[m[1]] = true
-		}
-	}
+// diffFile is one file's part of a unified diff. An empty path is
+// /dev/null: oldPath for added files, newPath for removed ones.
+type diffFile struct {
+	oldPath, newPath string
+	renamed          bool // git's rename from/to headers
+	header           bool // the ---/+++ header was seen
+	added, removed   []string
+}
 
-	var newFiles, removedFiles, configFiles []string
-	for _, m := range addedFiles {
-		name := m[1]
-		if name == "/dev/null" {
-			continue
-		}
-		if !deletedSet[name] {
-			newFiles = append(newFiles, name)
-		}
-		if configFileRe.MatchString(name) {
-			configFiles = append(configFiles, name)
+// parseDiff splits a unified diff into files. Lines before the first file
+// header go into a file without paths.
+func parseDiff(diff string) []*diffFile {
+	lines := strings.Split(diff, "\n")
+	cur := &diffFile{}
+	files := []*diffFile{cur}
+	start := func() {
+		if cur.header || cur.renamed || len(cur.added) > 0 || len(cur.removed) > 0 {
+			cur = &diffFile{}
+			files = append(files, cur)
 		}
 	}
 
-	for _, m := range deletedPrefixes {
-		name := m[1]
-		if name == "/dev/null" {
-			continue
-		}
-		found := false
-		for _, a := range addedFiles {
-			if a[1] == name {
-				found = true
-				break
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			start()
+		case strings.HasPrefix(line, "rename from "):
+			cur.oldPath, cur.renamed = strings.TrimPrefix(line, "rename from "), true
+		case strings.HasPrefix(line, "rename to "):
+			cur.newPath, cur.renamed = strings.TrimPrefix(line, "rename to "), true
+		// A file header is a --- line followed by a +++ line; anything
+		// else is a removed line that happens to start with --.
+		case strings.HasPrefix(line, "--- ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "+++ "):
+			if cur.header || len(cur.added) > 0 || len(cur.removed) > 0 {
+				start()
 			}
-		}
-		if !found {
-			removedFiles = append(removedFiles, name)
+			cur.oldPath = diffPath(strings.TrimPrefix(line, "--- "), "a/")
+			cur.newPath = diffPath(strings.TrimPrefix(lines[i+1], "+++ "), "b/")
+			cur.header = true
+			i++
+		case strings.HasPrefix(line, "+"):
+			cur.added = append(cur.added, line[1:])
+		case strings.HasPrefix(line, "-"):
+			cur.removed = append(cur.removed, line[1:])
 		}
 	}
+	return files
+}
 
-	if len(newFiles) > 0 {
-		parts = append(parts, fmt.Sprintf("added files: %s", strings.Join(newFiles, ", ")))
+// diffPath strips the a/ or b/ prefix from a header path; /dev/null is "".
+func diffPath(path, prefix string) string {
+	path, _, _ = strings.Cut(path, "\t")
+	if path == "/dev/null" {
+		return ""
 	}
-	if len(removedFiles) > 0 {
-		parts = append(parts, fmt.Sprintf("removed files: %s", strings.Join(removedFiles, ", ")))
-	}
-	if len(configFiles) > 0 {
-		parts = append(parts, fmt.Sprintf("config changes: %s", strings.Join(configFiles, ", ")))
+	return strings.TrimPrefix(path, prefix)
+}
+
+// StrategyExtract parses a diff for structural changes without LLM: files
+// added, removed and renamed, config files touched, and the functions and
+// types declared or removed in Go, Python, JavaScript/TypeScript and Rust.
+// Renames come from git's rename headers, or pair a removed and an added
+// file with the same base name.
+func StrategyExtract(ctx CommitContext) (string, error) {
+	if ctx.Diff == "" {
+		return "", nil
 	}
 
-	funcsAdded := uniqueMatches(diffFuncAddedRe.FindAllStringSubmatch(ctx.Diff, -1))
-	typesAdded := uniqueMatches(diffTypeAddedRe.FindAllStringSubmatch(ctx.Diff, -1))
-	funcsRemoved := uniqueMatches(diffFuncRemovedRe.FindAllStringSubmatch(ctx.Diff, -1))
-	typesRemoved := uniqueMatches(diffTypeRemovedRe.FindAllStringSubmatch(ctx.Diff, -1))
+	var newFiles, removedFiles, renames, configFiles []string
+	var funcsAdded, typesAdded, funcsRemoved, typesRemoved []string
+	files := parseDiff(ctx.Diff)
+	for _, f := range files {
+		switch {
+		case f.renamed || (f.oldPath != "" && f.newPath != "" && f.oldPath != f.newPath):
+			renames = append(renames, f.oldPath+" -> "+f.newPath)
+		case f.oldPath == "" && f.newPath != "":
+			newFiles = append(newFiles, f.newPath)
+		case f.oldPath != "" && f.newPath == "":
+			removedFiles = append(removedFiles, f.oldPath)
+		}
+		if f.newPath != "" && configFileRe.MatchString(f.newPath) {
+			configFiles = append(configFiles, f.newPath)
+		}
 
-	if len(funcsAdded) > 0 {
-		parts = append(parts, fmt.Sprintf("new funcs: %s", strings.Join(funcsAdded, ", ")))
-	}
-	if len(typesAdded) > 0 {
-		parts = append(parts, fmt.Sprintf("new types: %s", strings.Join(typesAdded, ", ")))
-	}
-	if len(funcsRemoved) > 0 {
-		parts = append(parts, fmt.Sprintf("removed funcs: %s", strings.Join(funcsRemoved, ", ")))
+		path := cmp.Or(f.newPath, f.oldPath)
+		symbols, ok := symbolsByExt[filepath.Ext(path)]
+		if path == "" {
+			symbols, ok = goSymbols, true
+		}
+		if !ok {
+			continue
+		}
+		funcsAdded = append(funcsAdded, matchSymbols(symbols.funcs, f.added)...)
+		typesAdded = append(typesAdded, matchSymbols(symbols.types, f.added)...)
+		funcsRemoved = append(funcsRemoved, matchSymbols(symbols.funcs, f.removed)...)
+		typesRemoved = append(typesRemoved, matchSymbols(symbols.types, f.removed)...)
 	}
-	if len(typesRemoved) > 0 {
-		parts = append(parts, fmt.Sprintf("removed types: %s", strings.Join(typesRemoved, ", ")))
+	newFiles, removedFiles, renames = pairRenames(newFiles, removedFiles, renames)
+
+	var parts []string
+	for _, p := range []struct {
+		label string
+		names []string
+	}{
+		{"added files", newFiles},
+		{"removed files", removedFiles},
+		{"renamed files", renames},
+		{"config changes", configFiles},
+		{"new funcs", funcsAdded},
+		{"new types", typesAdded},
+		{"removed funcs", funcsRemoved},
+		{"removed types", typesRemoved},
+	} {
+		if names := uniqueStrings(p.names); len(names) > 0 {
+			parts = append(parts, fmt.Sprintf("%s: %s", p.label, strings.Join(names, ", ")))
+		}
 	}
 
 	if len(parts) == 0 {
@@ -273,11 +369,38 @@ func StrategyExtract(ctx CommitContext) (string, error) {
 	return fmt.Sprintf("[%s] %s — %s", shortHash, ctx.Message, strings.Join(parts, "; ")), nil
 }
 
-func uniqueMatches(matches [][]string) []string {
+// pairRenames turns a removed and an added file with the same base name
+// into a rename, for diffs made without rename detection.
+func pairRenames(added, removed, renames []string) ([]string, []string, []string) {
+	var keptAdded []string
+	for _, a := range added {
+		i := slices.IndexFunc(removed, func(r string) bool { return filepath.Base(r) == filepath.Base(a) })
+		if i < 0 {
+			keptAdded = append(keptAdded, a)
+			continue
+		}
+		renames = append(renames, removed[i]+" -> "+a)
+		removed = slices.Delete(removed, i, i+1)
+	}
+	return keptAdded, removed, renames
+}
+
+func matchSymbols(patterns []*regexp.Regexp, lines []string) []string {
+	var names []string
+	for _, line := range lines {
+		for _, re := range patterns {
+			if m := re.FindStringSubmatch(line); m != nil {
+				names = append(names, m[1])
+			}
+		}
+	}
+	return names
+}
+
+func uniqueStrings(names []string) []string {
 	seen := make(map[string]bool)
 	var result []string
-	for _, m := range matches {
-		name := m[1]
+	for _, name := range names {
 		if !seen[name] {
 			seen[name] = true
 			result = append(result, name)
@@ -286,12 +409,56 @@ func uniqueMatches(matches [][]string) []string {
 	return result
 }
 
+// --- Record Strategy ---
+
+// commitRecordDoc is the memory the record strategy stores.
+type commitRecordDoc struct {
+	Commit  string   `yaml:"commit"`
+	Author  string   `yaml:"author,omitempty"`
+	Date    string   `yaml:"date,omitempty"`
+	Message string   `yaml:"message"`
+	Body    string   `yaml:"body,omitempty"`
+	Files   []string `yaml:"files,omitempty"`
+}
+
+// StrategyRecord writes the commit's metadata, message and changed files
+// as a YAML note, without LLM, so keyword search can answer which commit
+// touched a file. Files come from the diff headers; renames are listed as
+// "old -> new".
+func StrategyRecord(cc CommitContext) (string, error) {
+	var files []string
+	for _, f := range parseDiff(cc.Diff) {
+		switch {
+		case f.renamed || (f.oldPath != "" && f.newPath != "" && f.oldPath != f.newPath):
+			files = append(files, f.oldPath+" -> "+f.newPath)
+		case f.oldPath != "" || f.newPath != "":
+			files = append(files, cmp.Or(f.newPath, f.oldPath))
+		}
+	}
+
+	doc := commitRecordDoc{
+		Commit:  cc.Hash,
+		Author:  cc.Author,
+		Message: cc.Message,
+		Body:    strings.TrimSpace(cc.Body),
+		Files:   uniqueStrings(files),
+	}
+	if !cc.Time.IsZero() {
+		doc.Date = cc.Time.Format(time.RFC3339)
+	}
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("marshal commit record: %w", err)
+	}
+	return string(data), nil
+}
+
 // --- Summarize Strategy ---
 
 // StrategySummarize sends the diff to an LLM provider for summarization.
 func StrategySummarize(ctx context.Context, cc CommitContext, provider Provider) (string, error) {
 	if provider == nil {
-		return "", fmt.Errorf("no provider configured: skipping summarize")
+		return "", fmt.Errorf("%w: skipping summarize", ErrNoProvider)
 	}
 
 	prompt := fmt.Sprintf(`Summarize the following git commit in 1-3 sentences.
@@ -306,18 +473,74 @@ Diff:
 	return provider.Complete(ctx, prompt)
 }
 
+// redactCommit returns cc with the parts sent to a provider redacted.
+func redactCommit(redactor *Redactor, cc CommitContext) CommitContext {
+	cc.Message = redactor.Redact(cc.Message)
+	cc.Body = redactor.Redact(cc.Body)
+	cc.Diff = redactor.Redact(cc.Diff)
+	return cc
+}
+
+// --- LLM Extract Strategy ---
+
+// commitFactsDoc is the memory the llm-extract strategy stores.
+type commitFactsDoc struct {
+	Commit      string `yaml:"commit"`
+	Message     string `yaml:"message"`
+	CommitFacts `yaml:",inline"`
+}
+
+// StrategyLLMExtract asks an LLM provider for the CommitFacts of a commit
+// and returns them as a YAML document, so the structured fields stay
+// searchable.
+func StrategyLLMExtract(ctx context.Context, cc CommitContext, provider Provider) (string, error) {
+	if provider == nil {
+		return "", fmt.Errorf("%w: skipping llm-extract", ErrNoProvider)
+	}
+
+	prompt := fmt.Sprintf(`Extract facts from the following git commit: the files it adds and
+removes, the components it touches, whether it breaks compatibility, notes
+for anyone migrating past it (empty if none), and a few tags.
+
+Commit: %s
+Message: %s
+
+Diff:
+%s`, cc.Hash, cc.Message, cc.Diff)
+
+	var facts CommitFacts
+	if err := generateObject(ctx, provider, prompt, &facts); err != nil {
+		return "", err
+	}
+	data, err := yaml.Marshal(commitFactsDoc{Commit: cc.Hash, Message: cc.Message, CommitFacts: facts})
+	if err != nil {
+		return "", fmt.Errorf("marshal commit facts: %w", err)
+	}
+	return string(data), nil
+}
+
 // --- Script Strategy ---
 
-// StrategyScript runs a user-defined script with commit context.
-func StrategyScript(ctx context.Context, cc CommitContext, scriptPath string) error {
+// DefaultScriptTimeout is how long the script strategy waits for a script
+// when hooks.post-commit.script_timeout is not set.
+const DefaultScriptTimeout = 30 * time.Second
+
+// StrategyScript runs a user-defined script with the commit in its
+// environment and the diff on stdin, and returns what it prints to stdout.
+// Its stderr passes through for diagnostics. A script that exits non-zero
+// or is still running when ctx ends is an error.
+func StrategyScript(ctx context.Context, cc CommitContext, scriptPath string) (string, error) {
 	if scriptPath == "" {
-		return fmt.Errorf("no script configured")
+		return "", fmt.Errorf("no script configured")
 	}
 
+	var stdout bytes.Buffer
 	cmd := exec.CommandContext(ctx, scriptPath)
 	cmd.Stdin = strings.NewReader(cc.Diff)
-	cmd.Stdout = os.Stderr
+	cmd.Stdout = &stdout
 	cmd.Stderr = os.Stderr
+	// Children the script started may hold stdout open after it is killed.
+	cmd.WaitDelay = time.Second
 	cmd.Env = append(os.Environ(),
 		"MEM_COMMIT_HASH="+cc.Hash,
 		"MEM_COMMIT_MSG="+cc.Message,
@@ -325,10 +548,13 @@ func StrategyScript(ctx context.Context, cc CommitContext, scriptPath string) er
 	)
 
 	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("script %s: %w", scriptPath, err)
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("script %s: %w", scriptPath, ctx.Err())
+		}
+		return "", fmt.Errorf("script %s: %w", scriptPath, err)
 	}
 
-	return nil
+	return stdout.String(), nil
 }
 
 // --- RunHookUseCase ---
@@ -342,26 +568,27 @@ type ReindexFunc func(ctx context.Context) error
 type RunHookInput struct {
 	HookType      string
 	CommitContext CommitContext
+	NoRedact      bool // send the commit to the provider unredacted
 }
 
 type RunHookUseCase struct {
-	resolver  *ScopeResolver
-	provider  Provider
-	storeFn   StoreFunc
-	reindexFn ReindexFunc
+	resolver    *ScopeResolver
+	providerFor ProviderFunc
+	storeFn     StoreFunc
+	reindexFn   ReindexFunc
 }
 
 func NewRunHookUseCase(
 	resolver *ScopeResolver,
-	provider Provider,
+	providerFor ProviderFunc,
 	storeFn StoreFunc,
 	reindexFn ReindexFunc,
 ) *RunHookUseCase {
 	return &RunHookUseCase{
-		resolver:  resolver,
-		provider:  provider,
-		storeFn:   storeFn,
-		reindexFn: reindexFn,
+		resolver:    resolver,
+		providerFor: providerFor,
+		storeFn:     storeFn,
+		reindexFn:   reindexFn,
 	}
 }
 
@@ -407,18 +634,53 @@ func (uc *RunHookUseCase) Execute(_ context.Context, input RunHookInput) error {
 
 	ctx := context.Background()
 
+	// Bursts such as a rebase would otherwise call the provider per commit.
+	usesProvider := strategy == "summarize" || strategy == "llm-extract" || strategy == "all"
+	limited := usesProvider && hc.RateLimitPerMinute > 0 &&
+		!takeHookToken(scope.HookRatePath(), hc.RateLimitPerMinute, time.Now())
+	if limited {
+		warn("rate limit of %d provider calls a minute reached; not calling the provider for %s", hc.RateLimitPerMinute, shortHash)
+	}
+
+	// Only what the provider sees is redacted; extract and record store the
+	// commit as is. A broken pattern falls back to extract rather than
+	// sending the diff unredacted.
+	sent := cc
+	if usesProvider && !limited {
+		redactor, err := redactorFor(cfg.Redaction, input.NoRedact)
+		if err != nil {
+			warn("%v; not calling the provider for %s", err, shortHash)
+			limited = true
+		}
+		sent = redactCommit(redactor, cc)
+	}
+
 	switch strategy {
 	case "extract":
 		uc.runExtract(ctx, cc, baseKey, warn)
+	case "record":
+		uc.runRecord(ctx, cc, baseKey, warn)
 	case "summarize":
-		uc.runSummarize(ctx, cc, baseKey, warn)
+		if limited {
+			uc.runExtract(ctx, cc, baseKey, warn)
+		} else {
+			uc.runSummarize(ctx, scope, sent, baseKey, warn)
+		}
+	case "llm-extract":
+		if limited {
+			uc.runExtract(ctx, cc, baseKey, warn)
+		} else {
+			uc.runLLMExtract(ctx, scope, sent, baseKey, warn)
+		}
 	case "script":
-		uc.runScript(ctx, cc, hc.Script, warn)
+		uc.runScript(ctx, cc, hc.Script, hc.ScriptTimeout, baseKey+"/script", warn)
 	case "all":
 		uc.runExtract(ctx, cc, baseKey, warn)
-		uc.runSummarize(ctx, cc, baseKey+"/summary", warn)
+		if !limited {
+			uc.runSummarize(ctx, scope, sent, baseKey+"/summary", warn)
+		}
 		if hc.Script != "" {
-			uc.runScript(ctx, cc, hc.Script, warn)
+			uc.runScript(ctx, cc, hc.Script, hc.ScriptTimeout, baseKey+"/script", warn)
 		}
 	}
 
@@ -449,8 +711,30 @@ func (uc *RunHookUseCase) runExtract(ctx context.Context, cc CommitContext, key
 	}
 }
 
-func (uc *RunHookUseCase) runSummarize(ctx context.Context, cc CommitContext, key string, warn func(string, ...any)) {
-	result, err := StrategySummarize(ctx, cc, uc.provider)
+func (uc *RunHookUseCase) runRecord(ctx context.Context, cc CommitContext, key string, warn func(string, ...any)) {
+	result, err := StrategyRecord(cc)
+	if err != nil {
+		warn("record: %v", err)
+		return
+	}
+	if uc.storeFn != nil {
+		if err := uc.storeFn(ctx, key, result); err != nil {
+			warn("record store: %v", err)
+		}
+	}
+}
+
+func (uc *RunHookUseCase) runSummarize(ctx context.Context, scope Scope, cc CommitContext, key string, warn func(string, ...any)) {
+	var provider Provider
+	if uc.providerFor != nil {
+		p, err := uc.providerFor(ctx, scope, TaskHookSummarize, ProviderOverride{})
+		if err != nil && !errors.Is(err, ErrNoProvider) {
+			warn("summarize: %v", err)
+			return
+		}
+		provider = p
+	}
+	result, err := StrategySummarize(ctx, cc, provider)
 	if err != nil {
 		warn("summarize: %v", err)
 		return
@@ -462,8 +746,44 @@ func (uc *RunHookUseCase) runSummarize(ctx context.Context, cc CommitContext, ke
 	}
 }
 
-func (uc *RunHookUseCase) runScript(ctx context.Context, cc CommitContext, script string, warn func(string, ...any)) {
-	if err := StrategyScript(ctx, cc, script); err != nil {
-		warn("script: %v", err)
+func (uc *RunHookUseCase) runLLMExtract(ctx context.Context, scope Scope, cc CommitContext, key string, warn func(string, ...any)) {
+	var provider Provider
+	if uc.providerFor != nil {
+		p, err := uc.providerFor(ctx, scope, TaskHookExtract, ProviderOverride{})
+		if err != nil && !errors.Is(err, ErrNoProvider) {
+			warn("llm-extract: %v", err)
+			return
+		}
+		provider = p
+	}
+	result, err := StrategyLLMExtract(ctx, cc, provider)
+	if err != nil {
+		warn("llm-extract: %v", err)
+		return
+	}
+	if uc.storeFn != nil {
+		if err := uc.storeFn(ctx, key, result); err != nil {
+			warn("llm-extract store: %v", err)
+		}
+	}
+}
+
+// runScript stores the script's output under key, unless it printed
+// nothing or failed.
+func (uc *RunHookUseCase) runScript(ctx context.Context, cc CommitContext, script string, timeout time.Duration, key string, warn func(string, ...any)) {
+	scriptCtx, cancel := context.WithTimeout(ctx, cmp.Or(timeout, DefaultScriptTimeout))
+	defer cancel()
+	result, err := StrategyScript(scriptCtx, cc, script)
+	if err != nil {
+		warn("script: %v; nothing stored", err)
+		return
+	}
+	if strings.TrimSpace(result) == "" {
+		return
+	}
+	if uc.storeFn != nil {
+		if err := uc.storeFn(ctx, key, result); err != nil {
+			warn("script store: %v", err)
+		}
 	}
 }
@@ -2,12 +2,15 @@ package internal
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestHookMarker(t *testing.T) {
@@ -38,8 +41,8 @@ func TestFindGitDir(t *testing.T) {
 	assert.Error(t, err)
 }
 
-// setupHookTestDir creates a temp dir with .git/hooks and .mem, chdirs to it,
-// and returns the dir path, scope, resolver, and a cleanup func.
+// setupHookTestDir creates a temp dir with .git/hooks and .mem and returns
+// the dir path, scope, and a resolver working in it.
 func setupHookTestDir(t *testing.T) (string, Scope, *ScopeResolver) {
 	t.Helper()
 	dir := t.TempDir()
@@ -47,13 +50,8 @@ func setupHookTestDir(t *testing.T) (string, Scope, *ScopeResolver) {
 	memDir := filepath.Join(dir, ".mem")
 	require.NoError(t, os.MkdirAll(memDir, 0755))
 
-	oldWd, err := os.Getwd()
-	require.NoError(t, err)
-	require.NoError(t, os.Chdir(dir))
-	t.Cleanup(func() { _ = os.Chdir(oldWd) })
-
 	scope := Scope{Type: ScopeProject, Path: dir, MemPath: memDir}
-	resolver := &ScopeResolver{homeDir: dir}
+	resolver := NewScopeResolverAt(dir, dir)
 	return dir, scope, resolver
 }
 
@@ -81,6 +79,16 @@ func TestInstallHookUseCase(t *testing.T) {
 	assert.Equal(t, "extract", cfg.Hooks.PostCommit.Strategy)
 }
 
+func TestInstallHookUseCase_UnknownStrategy(t *testing.T) {
+	dir, scope, resolver := setupHookTestDir(t)
+	require.NoError(t, SaveConfig(scope, DefaultConfig()))
+
+	uc := NewInstallHookUseCase(resolver)
+	err := uc.Execute(context.Background(), InstallHookInput{Strategy: "sumarize"})
+	require.ErrorIs(t, err, ErrUnknownStrategy)
+	assert.NoFileExists(t, filepath.Join(dir, ".git", "hooks", "post-commit"))
+}
+
 func TestInstallHookUseCase_ExistingHook_NoForce(t *testing.T) {
 	dir, scope, resolver := setupHookTestDir(t)
 	require.NoError(t, SaveConfig(scope, DefaultConfig()))
@@ -212,6 +220,71 @@ func TestStrategyExtract(t *testing.T) {
 	assert.Contains(t, result, "old.go")
 }
 
+func TestStrategyExtract_Languages(t *testing.T) {
+	tests := []struct {
+		name string
+		diff string
+		want string
+	}{
+		{
+			name: "go methods",
+			diff: "--- a/svc.go\n+++ b/svc.go\n+func (s *Service) Start() error {\n-type oldState int\n",
+			want: "new funcs: Start; removed types: oldState",
+		},
+		{
+			name: "python",
+			diff: "--- /dev/null\n+++ b/app/models.py\n+class User(Base):\n+    def save(self):\n+async def fetch():\n",
+			want: "added files: app/models.py; new funcs: save, fetch; new types: User",
+		},
+		{
+			name: "typescript",
+			diff: "--- a/src/api.ts\n+++ b/src/api.ts\n+export function handler(req) {\n+export const routes = []\n+export class Router {}\n-function legacy() {}\n",
+			want: "new funcs: handler, routes; new types: Router; removed funcs: legacy",
+		},
+		{
+			name: "javascript",
+			diff: "--- a/lib/util.js\n+++ b/lib/util.js\n+function* ids() {}\n+class Cache {}\n",
+			want: "new funcs: ids; new types: Cache",
+		},
+		{
+			name: "rust",
+			diff: "--- a/src/lib.rs\n+++ b/src/lib.rs\n+pub fn parse(input: &str) {}\n+pub struct Parser;\n+trait Visit {}\n-fn old() {}\n",
+			want: "new funcs: parse; new types: Parser, Visit; removed funcs: old",
+		},
+		{
+			name: "unknown extension has no symbols",
+			diff: "--- a/README.md\n+++ b/README.md\n+The func keyword and type names.\n",
+			want: "",
+		},
+		{
+			name: "git rename headers",
+			diff: "diff --git a/old/name.go b/new/name.go\nsimilarity index 100%\nrename from old/name.go\nrename to new/name.go\n",
+			want: "renamed files: old/name.go -> new/name.go",
+		},
+		{
+			name: "rename by base name",
+			diff: "--- a/pkg/a/util.py\n+++ /dev/null\n-def helper():\n--- /dev/null\n+++ b/pkg/b/util.py\n+def helper():\n--- a/gone.py\n+++ /dev/null\n",
+			want: "removed files: gone.py; renamed files: pkg/a/util.py -> pkg/b/util.py; new funcs: helper; removed funcs: helper",
+		},
+		{
+			name: "removed line starting with dashes",
+			diff: "--- a/q.sql\n+++ b/q.sql\n--- a comment\n+SELECT 1;\n",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := StrategyExtract(CommitContext{Hash: "abc1234", Message: "msg", Diff: tt.diff})
+			require.NoError(t, err)
+			if tt.want == "" {
+				assert.Equal(t, "", result)
+				return
+			}
+			assert.Equal(t, "[abc1234] msg — "+tt.want, result)
+		})
+	}
+}
+
 func TestStrategyExtract_EmptyDiff(t *testing.T) {
 	ctx := CommitContext{Hash: "abc1234", Diff: ""}
 	result, err := StrategyExtract(ctx)
@@ -219,18 +292,98 @@ func TestStrategyExtract_EmptyDiff(t *testing.T) {
 	assert.Equal(t, "", result)
 }
 
+// --- Record Strategy tests ---
+
+func TestStrategyRecord(t *testing.T) {
+	cc := CommitContext{
+		Hash:    "abc1234",
+		Message: "feat: add handler",
+		Body:    "Wires the handler into the router.\n",
+		Author:  "dev",
+		Time:    time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Diff: `diff --git a/handler.go b/handler.go
+new file mode 100644
+--- /dev/null
++++ b/handler.go
+@@ -0,0 +1 @@
++func NewHandler() {}
+diff --git a/router.go b/router.go
+--- a/router.go
++++ b/router.go
+@@ -1 +1 @@
+-old
++new
+diff --git a/old.go b/new.go
+similarity index 100%
+rename from old.go
+rename to new.go
+`,
+	}
+
+	result, err := StrategyRecord(cc)
+	require.NoError(t, err)
+
+	var doc commitRecordDoc
+	require.NoError(t, yaml.Unmarshal([]byte(result), &doc))
+	assert.Equal(t, commitRecordDoc{
+		Commit:  "abc1234",
+		Author:  "dev",
+		Date:    "2026-03-01T12:00:00Z",
+		Message: "feat: add handler",
+		Body:    "Wires the handler into the router.",
+		Files:   []string{"handler.go", "router.go", "old.go -> new.go"},
+	}, doc)
+}
+
+func TestRunHookUseCase_Record(t *testing.T) {
+	_, scope, resolver := setupHookTestDir(t)
+
+	cfg := DefaultConfig()
+	cfg.Hooks.PostCommit = PostCommitHookConfig{
+		Enabled:   true,
+		Strategy:  "record",
+		KeyPrefix: "hooks/commits",
+	}
+	require.NoError(t, SaveConfig(scope, cfg))
+
+	stored := map[string]string{}
+	storeFn := func(_ context.Context, key, content string) error {
+		stored[key] = content
+		return nil
+	}
+
+	uc := NewRunHookUseCase(resolver, nil, storeFn, nil)
+	err := uc.Execute(context.Background(), RunHookInput{
+		HookType: "post-commit",
+		CommitContext: CommitContext{
+			Hash:    "abc1234def",
+			Message: "fix: retry uploads",
+			Diff:    "--- a/internal/upload.go\n+++ b/internal/upload.go\n@@ -1 +1 @@\n-a\n+b\n",
+		},
+	})
+	require.NoError(t, err)
+	note := stored["hooks/commits/abc1234"]
+	assert.Contains(t, note, "fix: retry uploads")
+	assert.Contains(t, note, "- internal/upload.go")
+}
+
 // --- Summarize Strategy tests ---
 
 type mockProvider struct {
+	NoModels
 	completeFn func(ctx context.Context, prompt string) (string, error)
+	objectFn   func(ctx context.Context, prompt string, target any) error
 }
 
 func (m *mockProvider) Complete(ctx context.Context, prompt string) (string, error) {
 	return m.completeFn(ctx, prompt)
 }
 
-func (m *mockProvider) GenerateObject(_ context.Context, _ string, _ any) error {
-	return nil
+func (m *mockProvider) GenerateObject(ctx context.Context, prompt string, target any) error {
+	if m.objectFn == nil {
+		return nil
+	}
+	return m.objectFn(ctx, prompt, target)
 }
 
 func (m *mockProvider) Stream(_ context.Context, _ string) (<-chan string, error) {
@@ -270,10 +423,16 @@ func TestStrategySummarize_NilProvider(t *testing.T) {
 
 // --- Script Strategy tests ---
 
+// writeScript writes an executable shell script into a temp dir.
+func writeScript(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "hook.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body), 0755))
+	return path
+}
+
 func TestStrategyScript(t *testing.T) {
-	dir := t.TempDir()
-	scriptPath := filepath.Join(dir, "hook.sh")
-	require.NoError(t, os.WriteFile(scriptPath, []byte("#!/bin/sh\necho $MEM_COMMIT_HASH\n"), 0755))
+	scriptPath := writeScript(t, "echo \"$MEM_COMMIT_HASH $MEM_COMMIT_MSG\"\ncat\necho diagnostics >&2\n")
 
 	cc := CommitContext{
 		Hash:    "abc1234",
@@ -282,19 +441,39 @@ func TestStrategyScript(t *testing.T) {
 		Diff:    "+new line",
 	}
 
-	err := StrategyScript(context.Background(), cc, scriptPath)
+	out, err := StrategyScript(context.Background(), cc, scriptPath)
 	require.NoError(t, err)
+	assert.Equal(t, "abc1234 test commit\n+new line", out)
+}
+
+func TestStrategyScript_Failure(t *testing.T) {
+	scriptPath := writeScript(t, "echo partial\nexit 3\n")
+	out, err := StrategyScript(context.Background(), CommitContext{Hash: "abc1234"}, scriptPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "exit status 3")
+	assert.Empty(t, out)
+}
+
+func TestStrategyScript_Timeout(t *testing.T) {
+	scriptPath := writeScript(t, "sleep 10\n")
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := StrategyScript(ctx, CommitContext{Hash: "abc1234"}, scriptPath)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
 }
 
 func TestStrategyScript_NoScript(t *testing.T) {
 	cc := CommitContext{Hash: "abc1234"}
-	err := StrategyScript(context.Background(), cc, "")
+	_, err := StrategyScript(context.Background(), cc, "")
 	assert.Error(t, err)
 }
 
 func TestStrategyScript_MissingScript(t *testing.T) {
 	cc := CommitContext{Hash: "abc1234"}
-	err := StrategyScript(context.Background(), cc, "/nonexistent/hook.sh")
+	_, err := StrategyScript(context.Background(), cc, "/nonexistent/hook.sh")
 	assert.Error(t, err)
 }
 
@@ -332,6 +511,188 @@ func TestRunHookUseCase_Extract(t *testing.T) {
 	assert.Contains(t, storedContent, "NewHandler")
 }
 
+func TestRunHookUseCase_Script(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		timeout time.Duration
+		stored  map[string]string
+	}{
+		{
+			name:   "stdout is stored",
+			body:   "echo \"notes for $MEM_COMMIT_HASH\"\n",
+			stored: map[string]string{"hooks/commits/abc1234/script": "notes for abc1234def\n"},
+		},
+		{
+			name:   "empty output stores nothing",
+			body:   "echo only diagnostics >&2\n",
+			stored: map[string]string{},
+		},
+		{
+			name:   "failure stores nothing",
+			body:   "echo partial\nexit 1\n",
+			stored: map[string]string{},
+		},
+		{
+			name:    "timeout stores nothing",
+			body:    "echo early\nsleep 10\n",
+			timeout: 100 * time.Millisecond,
+			stored:  map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, scope, resolver := setupHookTestDir(t)
+
+			cfg := DefaultConfig()
+			cfg.Hooks.PostCommit = PostCommitHookConfig{
+				Enabled:       true,
+				Strategy:      "script",
+				Script:        writeScript(t, tt.body),
+				ScriptTimeout: tt.timeout,
+				KeyPrefix:     "hooks/commits",
+				Quiet:         true,
+			}
+			require.NoError(t, SaveConfig(scope, cfg))
+
+			stored := map[string]string{}
+			storeFn := func(_ context.Context, key, content string) error {
+				stored[key] = content
+				return nil
+			}
+
+			uc := NewRunHookUseCase(resolver, nil, storeFn, nil)
+			err := uc.Execute(context.Background(), RunHookInput{
+				HookType: "post-commit",
+				CommitContext: CommitContext{
+					Hash: "abc1234def",
+					Diff: "+func NewHandler() {}",
+				},
+			})
+			require.NoError(t, err)
+			assert.Equal(t, tt.stored, stored)
+		})
+	}
+}
+
+func TestPostCommitHookConfig_ScriptTimeoutYAML(t *testing.T) {
+	var hc PostCommitHookConfig
+	require.NoError(t, yaml.Unmarshal([]byte("script_timeout: 10s\n"), &hc))
+	assert.Equal(t, 10*time.Second, hc.ScriptTimeout)
+}
+
+func TestRunHookUseCase_RateLimit(t *testing.T) {
+	_, scope, resolver := setupHookTestDir(t)
+
+	cfg := DefaultConfig()
+	cfg.Hooks.PostCommit = PostCommitHookConfig{
+		Enabled:            true,
+		Strategy:           "summarize",
+		Quiet:              true,
+		RateLimitPerMinute: 3,
+	}
+	require.NoError(t, SaveConfig(scope, cfg))
+
+	calls := 0
+	provider := &mockProvider{completeFn: func(context.Context, string) (string, error) {
+		calls++
+		return "summary", nil
+	}}
+	providerFor := func(context.Context, Scope, string, ProviderOverride) (Provider, error) {
+		return provider, nil
+	}
+	stored := map[string]string{}
+	storeFn := func(_ context.Context, key, content string) error {
+		stored[key] = content
+		return nil
+	}
+
+	// A rebase replaying commits back to back.
+	uc := NewRunHookUseCase(resolver, providerFor, storeFn, nil)
+	for i := range 5 {
+		err := uc.Execute(context.Background(), RunHookInput{
+			HookType: "post-commit",
+			CommitContext: CommitContext{
+				Hash:    fmt.Sprintf("abc%04d", i),
+				Message: "feat: add handler",
+				Diff:    "+func NewHandler() {}",
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 3, calls, "provider calls past the limit")
+	assert.Equal(t, "summary", stored["hooks/commits/abc0002"])
+	assert.Contains(t, stored["hooks/commits/abc0004"], "NewHandler", "over the limit falls back to extract")
+}
+
+func TestTakeHookTokenRefills(t *testing.T) {
+	path := filepath.Join(t.TempDir(), HookRateFilename)
+	now := time.Now()
+
+	for range 2 {
+		assert.True(t, takeHookToken(path, 2, now))
+	}
+	assert.False(t, takeHookToken(path, 2, now.Add(time.Second)))
+	assert.True(t, takeHookToken(path, 2, now.Add(31*time.Second)), "half a minute refills one token")
+	assert.False(t, takeHookToken(path, 2, now.Add(32*time.Second)))
+}
+
+func TestRunHookUseCase_LLMExtract(t *testing.T) {
+	_, scope, resolver := setupHookTestDir(t)
+
+	cfg := DefaultConfig()
+	cfg.Hooks.PostCommit = PostCommitHookConfig{Enabled: true, Strategy: "llm-extract", Quiet: true}
+	require.NoError(t, SaveConfig(scope, cfg))
+
+	var task, prompt string
+	provider := &mockProvider{objectFn: func(_ context.Context, p string, target any) error {
+		prompt = p
+		*target.(*CommitFacts) = CommitFacts{
+			FilesAdded:     []string{"src/handler.py"},
+			Components:     []string{"api"},
+			Breaking:       true,
+			MigrationNotes: "Rename handle() calls to serve().",
+			Tags:           []string{"api", "python"},
+		}
+		return nil
+	}}
+	providerFor := func(_ context.Context, _ Scope, t string, _ ProviderOverride) (Provider, error) {
+		task = t
+		return provider, nil
+	}
+	var storedKey, storedContent string
+	storeFn := func(_ context.Context, key, content string) error {
+		storedKey, storedContent = key, content
+		return nil
+	}
+
+	uc := NewRunHookUseCase(resolver, providerFor, storeFn, nil)
+	err := uc.Execute(context.Background(), RunHookInput{
+		HookType: "post-commit",
+		CommitContext: CommitContext{
+			Hash:    "abc1234def",
+			Message: "feat!: rename handle to serve",
+			Diff:    "+++ b/src/handler.py\n+def serve(): pass",
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, TaskHookExtract, task)
+	assert.Contains(t, prompt, "def serve()")
+	assert.Equal(t, "hooks/commits/abc1234", storedKey)
+
+	var doc map[string]any
+	require.NoError(t, yaml.Unmarshal([]byte(storedContent), &doc))
+	assert.Equal(t, "abc1234def", doc["commit"])
+	assert.Equal(t, "feat!: rename handle to serve", doc["message"])
+	assert.Equal(t, []any{"src/handler.py"}, doc["files_added"])
+	assert.Equal(t, []any{"api"}, doc["components"])
+	assert.Equal(t, true, doc["breaking"])
+	assert.Equal(t, "Rename handle() calls to serve().", doc["migration_notes"])
+	assert.Equal(t, []any{"api", "python"}, doc["tags"])
+}
+
 func TestRunHookUseCase_Disabled(t *testing.T) {
 	_, scope, resolver := setupHookTestDir(t)
 
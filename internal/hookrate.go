@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// HookRateFilename holds the post-commit hook's rate limit state in a
+// scope's .mem directory. Every hook run is its own process, so the token
+// bucket lives on disk between them. It is never committed.
+const HookRateFilename = "hook-rate.json"
+
+type hookBucket struct {
+	Tokens  float64   `json:"tokens"`
+	Updated time.Time `json:"updated"`
+}
+
+// takeHookToken reports whether the hook may call a provider at now under
+// a limit of perMinute calls, and spends a token if so. The bucket holds
+// perMinute tokens and refills at perMinute a minute, so a burst of up to
+// perMinute calls goes through before the limit engages. A missing or
+// unreadable state file is a full bucket.
+func takeHookToken(path string, perMinute int, now time.Time) bool {
+	capacity := float64(perMinute)
+	bucket := hookBucket{Tokens: capacity, Updated: now}
+	if data, err := os.ReadFile(path); err == nil {
+		var saved hookBucket
+		if json.Unmarshal(data, &saved) == nil && !saved.Updated.After(now) {
+			refill := now.Sub(saved.Updated).Minutes() * capacity
+			bucket.Tokens = min(capacity, saved.Tokens+refill)
+		}
+	}
+
+	ok := bucket.Tokens >= 1
+	if ok {
+		bucket.Tokens--
+	}
+	if data, err := json.Marshal(bucket); err == nil {
+		_ = writeFileAtomic(path, data, false)
+	}
+	return ok
+}
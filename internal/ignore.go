@@ -3,6 +3,7 @@ package internal
 import (
 	"bufio"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
 
@@ -28,13 +29,42 @@ func NewIgnoreMatcher(scope Scope) (*IgnoreMatcher, error) {
 	return m, nil
 }
 
+// Add appends patterns written like the lines of a .memignore file.
+func (m *IgnoreMatcher) Add(patterns ...string) {
+	for _, p := range patterns {
+		m.patterns = append(m.patterns, gitignore.ParsePattern(p, nil))
+	}
+}
+
 // MatchKey returns true if the key should be ignored (blocked from add/edit)
 func (m *IgnoreMatcher) MatchKey(key Key) bool {
-	keyStr := key.String()
-	parts := strings.Split(keyStr, "/")
+	return m.MatchPath(key.String(), false)
+}
+
+// MatchPath reports whether relPath, a slash-separated path inside the
+// store, is ignored. A nil matcher ignores nothing.
+func (m *IgnoreMatcher) MatchPath(relPath string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	parts := strings.Split(relPath, "/")
 
 	for _, p := range m.patterns {
-		if p.Match(parts, false) == gitignore.Exclude {
+		if p.Match(parts, isDir) == gitignore.Exclude {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchFile reports whether the file at relPath, or any directory above
+// it, is ignored.
+func (m *IgnoreMatcher) MatchFile(relPath string) bool {
+	if m.MatchPath(relPath, false) {
+		return true
+	}
+	for dir := path.Dir(relPath); dir != "."; dir = path.Dir(dir) {
+		if m.MatchPath(dir, true) {
 			return true
 		}
 	}
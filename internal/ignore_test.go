@@ -94,3 +94,23 @@ func TestIgnoreMatcherComments(t *testing.T) {
 		t.Error("expected comment not to be a pattern")
 	}
 }
+
+func TestIgnoreMatcherAddAndMatchPath(t *testing.T) {
+	m := &IgnoreMatcher{}
+	m.Add("drafts/", "*.tmp")
+
+	if !m.MatchPath("notes/drafts", true) {
+		t.Error("expected the drafts directory to be ignored")
+	}
+	if m.MatchPath("notes/drafts", false) {
+		t.Error("a directory pattern should not match a file")
+	}
+	if !m.MatchPath("notes/scratch.tmp", false) {
+		t.Error("expected *.tmp to be ignored")
+	}
+
+	var none *IgnoreMatcher
+	if none.MatchPath("anything", false) {
+		t.Error("a nil matcher should ignore nothing")
+	}
+}
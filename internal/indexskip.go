@@ -0,0 +1,133 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PendingIndexFilename counts, in a scope's .mem directory, the index
+// updates writes skipped since the index was last rebuilt. It is never
+// committed.
+const PendingIndexFilename = "pending-index"
+
+// Reasons a write left the index behind.
+const (
+	SkipModelUnavailable = "model unavailable"
+	SkipIndexUnavailable = "index unavailable"
+	SkipEmbeddingFailed  = "embedding failed"
+)
+
+type indexSkipsKey struct{}
+
+// IndexSkips collects the index updates a command skipped, so it can
+// report them once when it ends rather than warn about every memory. All
+// methods are safe on a nil receiver, so skips are only logged unless a
+// collector was attached with WithIndexSkips.
+type IndexSkips struct {
+	mu      sync.Mutex
+	keys    []Key
+	reasons []string
+}
+
+// WithIndexSkips returns a context collecting skipped index updates in s.
+func WithIndexSkips(ctx context.Context, s *IndexSkips) context.Context {
+	return context.WithValue(ctx, indexSkipsKey{}, s)
+}
+
+// IndexSkipsFrom returns the collector stored in ctx, or nil if none.
+func IndexSkipsFrom(ctx context.Context) *IndexSkips {
+	if ctx == nil {
+		return nil
+	}
+	s, _ := ctx.Value(indexSkipsKey{}).(*IndexSkips)
+	return s
+}
+
+func (s *IndexSkips) add(key Key, reason string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append(s.keys, key)
+	if !slices.Contains(s.reasons, reason) {
+		s.reasons = append(s.reasons, reason)
+	}
+}
+
+// Keys returns the memories whose index update was skipped.
+func (s *IndexSkips) Keys() []Key {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.keys)
+}
+
+// Reasons returns why updates were skipped, each reason once, in the
+// order they first happened.
+func (s *IndexSkips) Reasons() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.reasons)
+}
+
+// skipIndexUpdate records that key was written without updating scope's
+// index: in the command's IndexSkips if it has one and as a warning if
+// not, and in the scope's pending count either way.
+func skipIndexUpdate(ctx context.Context, scope Scope, key Key, reason string, err error) {
+	if skips := IndexSkipsFrom(ctx); skips != nil {
+		skips.add(key, reason)
+		LoggerFrom(ctx).Debug("skipping index update", "key", key, "reason", reason, "error", err)
+	} else {
+		LoggerFrom(ctx).Warn("skipping index update: "+reason, "key", key, "error", err)
+	}
+
+	n := PendingIndexUpdates(scope) + 1
+	if err := writeFileAtomic(scope.PendingIndexPath(), []byte(strconv.Itoa(n)+"\n"), false); err != nil {
+		LoggerFrom(ctx).Debug("failed to count pending index update", "error", err)
+	}
+}
+
+// writeEmbedder returns the embedder that indexes a memory just written to
+// key, or nil to leave the index alone. An embedder that is configured but
+// yields none, because its model could not be loaded, skips the update; no
+// embedder or index at all means semantic search is off, which does not.
+func writeEmbedder(ctx context.Context, scope Scope, embedder EmbedderFunc, indexFor func(Scope) (VectorIndex, error), key Key) Embedder {
+	if embedder == nil || indexFor == nil {
+		return nil
+	}
+	e := embedder.Get()
+	if e == nil {
+		skipIndexUpdate(ctx, scope, key, SkipModelUnavailable, ErrNoEmbedder)
+	}
+	return e
+}
+
+// PendingIndexUpdates returns how many index updates writes to scope
+// skipped since its index was last rebuilt in full.
+func PendingIndexUpdates(scope Scope) int {
+	data, err := os.ReadFile(scope.PendingIndexPath())
+	if err != nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(strings.TrimSpace(string(data)))
+	return max(n, 0)
+}
+
+// clearPendingIndexUpdates resets the pending count after a full rebuild.
+func clearPendingIndexUpdates(scope Scope) error {
+	err := os.Remove(scope.PendingIndexPath())
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSkippedIndexUpdatesAreCollected(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	scope := resolver.Resolve("")
+
+	var buf bytes.Buffer
+	skips := &IndexSkips{}
+	ctx := WithIndexSkips(WithLogger(context.Background(), NewLogger(&buf, false, false)), skips)
+
+	repoFor := func(s Scope) (MemoryRepository, error) { return repo, nil }
+	histFor := func(s Scope) (HistoryRepository, error) { return repo, nil }
+	idx := newExactIndex()
+	indexFor := func(s Scope) (VectorIndex, error) { return idx, nil }
+
+	failing := NewSetMemoryUseCase(resolver, repoFor, indexFor, StaticEmbedder(failingEmbedder{}), nil)
+	require.NoError(t, failing.Execute(ctx, SetMemoryInput{Key: "skip/a", Content: "a"}))
+
+	unloaded := NewAddMemoryUseCase(resolver, repoFor, histFor, indexFor, StaticEmbedder(nil), nil)
+	_, err := unloaded.Execute(ctx, AddMemoryInput{Key: "skip/b", Content: "b"})
+	require.NoError(t, err)
+
+	// No embedder at all means semantic search is off, not a skipped update.
+	off := NewSetMemoryUseCase(resolver, repoFor, indexFor, nil, nil)
+	require.NoError(t, off.Execute(ctx, SetMemoryInput{Key: "skip/c", Content: "c"}))
+
+	assert.Equal(t, []Key{"skip/a", "skip/b"}, skips.Keys())
+	assert.Equal(t, []string{SkipEmbeddingFailed, SkipModelUnavailable}, skips.Reasons())
+	assert.NotContains(t, buf.String(), "skipping index update", "collected skips are reported by the command, not warned")
+	assert.Equal(t, 2, PendingIndexUpdates(scope))
+
+	rebuild := NewRebuildIndexUseCase(resolver, repoFor, indexFor, StaticEmbedder(constEmbedder{}))
+	_, err = rebuild.Execute(ctx, RebuildIndexInput{})
+	require.NoError(t, err)
+	assert.Zero(t, PendingIndexUpdates(scope), "a full rebuild clears the pending count")
+}
+
+func TestIndexSkipsNilSafe(t *testing.T) {
+	var skips *IndexSkips
+	skips.add("a", SkipIndexUnavailable)
+	assert.Nil(t, skips.Keys())
+	assert.Nil(t, skips.Reasons())
+	assert.Nil(t, IndexSkipsFrom(context.Background()))
+}
@@ -0,0 +1,291 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+var (
+	ErrNoIndexRemote       = errors.New("no index remote configured; set index_remote in config")
+	ErrRemoteIndexNotFound = errors.New("index not found on remote")
+)
+
+// indexFiles are the files that make up a saved index, in upload order:
+// the mapping goes last, so a reader never finds a mapping without its
+// index.
+var indexFiles = []string{IndexFilename, MappingFilename}
+
+// IndexSyncer copies a saved vector index to and from an S3-compatible
+// bucket.
+type IndexSyncer struct {
+	endpoint *url.URL
+	bucket   string
+	prefix   string
+	region   string
+	creds    aws.Credentials
+	signer   *v4.Signer
+	client   *http.Client
+}
+
+func NewIndexSyncer(cfg IndexRemoteConfig) (*IndexSyncer, error) {
+	if !cfg.Enabled() {
+		return nil, ErrNoIndexRemote
+	}
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Scheme == "" || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid index_remote endpoint %q", cfg.Endpoint)
+	}
+
+	region := cfg.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+	accessKey, secretKey := cfg.AccessKey, cfg.SecretKey
+	if accessKey == "" {
+		accessKey = os.Getenv("AWS_ACCESS_KEY_ID")
+	}
+	if secretKey == "" {
+		secretKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+	}
+
+	return &IndexSyncer{
+		endpoint: endpoint,
+		bucket:   cfg.Bucket,
+		prefix:   strings.Trim(cfg.Prefix, "/"),
+		region:   region,
+		creds: aws.Credentials{
+			AccessKeyID:     accessKey,
+			SecretAccessKey: secretKey,
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		},
+		signer: v4.NewSigner(),
+		client: http.DefaultClient,
+	}, nil
+}
+
+// RemoteIndexDir names the index in dir on the remote: its path below the
+// scope's vectors directory, so each branch index gets its own objects.
+func RemoteIndexDir(scope Scope, dir string) string {
+	rel, err := filepath.Rel(scope.VectorPath(), dir)
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// Location describes where the index named remoteDir is kept, for messages.
+func (s *IndexSyncer) Location(remoteDir string) string {
+	return "s3://" + s.bucket + "/" + s.objectKey(remoteDir, "")
+}
+
+// Push uploads the index saved in dir. It returns the bytes sent.
+func (s *IndexSyncer) Push(ctx context.Context, dir, remoteDir string) (int64, error) {
+	for _, name := range indexFiles {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			return 0, fmt.Errorf("no saved index in %s; run mem index rebuild: %w", dir, err)
+		}
+	}
+
+	var total int64
+	for _, name := range indexFiles {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return total, fmt.Errorf("read %s: %w", name, err)
+		}
+		if err := s.put(ctx, s.objectKey(remoteDir, name), data); err != nil {
+			return total, fmt.Errorf("upload %s: %w", name, err)
+		}
+		total += int64(len(data))
+	}
+	return total, nil
+}
+
+// Pull downloads the index into dir, replacing any local copy. It returns
+// ErrRemoteIndexNotFound when the remote has no index under remoteDir.
+// Every file is downloaded before any is moved into place, so a failed
+// pull leaves the local index as it was.
+func (s *IndexSyncer) Pull(ctx context.Context, dir, remoteDir string) (int64, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return 0, fmt.Errorf("create vectors directory: %w", err)
+	}
+	staging, err := os.MkdirTemp(dir, ".pull-*")
+	if err != nil {
+		return 0, fmt.Errorf("create staging directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	var total int64
+	for _, name := range indexFiles {
+		n, err := s.get(ctx, s.objectKey(remoteDir, name), filepath.Join(staging, name))
+		if err != nil {
+			return 0, fmt.Errorf("download %s: %w", name, err)
+		}
+		total += n
+	}
+	for _, name := range indexFiles {
+		if err := renameFile(filepath.Join(staging, name), filepath.Join(dir, name)); err != nil {
+			return 0, fmt.Errorf("install %s: %w", name, err)
+		}
+	}
+	return total, nil
+}
+
+func (s *IndexSyncer) objectKey(remoteDir, name string) string {
+	return strings.TrimPrefix(path.Join(s.prefix, remoteDir, name), "/")
+}
+
+func (s *IndexSyncer) objectURL(key string) string {
+	u := *s.endpoint
+	u.Path = path.Join("/", u.Path, s.bucket, key)
+	return u.String()
+}
+
+func (s *IndexSyncer) put(ctx context.Context, key string, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key), bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	resp, err := s.do(req, hex.EncodeToString(sum[:]))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+func (s *IndexSyncer) get(ctx context.Context, key, dest string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key), nil)
+	if err != nil {
+		return 0, fmt.Errorf("create request: %w", err)
+	}
+	// sha256 of the empty body.
+	resp, err := s.do(req, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855")
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	var n int64
+	err = writeAtomic(dest, true, func(w io.Writer) error {
+		n, err = io.Copy(w, resp.Body)
+		return err
+	})
+	if err != nil {
+		return n, fmt.Errorf("write %s: %w", filepath.Base(dest), err)
+	}
+	return n, nil
+}
+
+// do signs and sends req, turning error statuses into errors.
+func (s *IndexSyncer) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if err := s.signer.SignHTTP(req.Context(), s.creds, req, payloadHash, "s3", s.region, time.Now()); err != nil {
+		return nil, fmt.Errorf("sign request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrRemoteIndexNotFound
+	}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return nil, fmt.Errorf("%s %s: status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(body)))
+}
+
+// --- SyncIndexUseCase ---
+
+type SyncIndexInput struct {
+	Scope string
+}
+
+type SyncIndexOutput struct {
+	Dir    string // local index directory
+	Remote string // s3:// location
+	Bytes  int64
+}
+
+// SyncIndexUseCase pushes the current branch's index to the configured
+// index remote and pulls it back.
+type SyncIndexUseCase struct {
+	resolver  *ScopeResolver
+	branchFor func(Scope) (BranchRepository, error)
+}
+
+func NewSyncIndexUseCase(
+	resolver *ScopeResolver,
+	branchFor func(Scope) (BranchRepository, error),
+) *SyncIndexUseCase {
+	return &SyncIndexUseCase{
+		resolver:  resolver,
+		branchFor: branchFor,
+	}
+}
+
+func (uc *SyncIndexUseCase) Push(ctx context.Context, input SyncIndexInput) (*SyncIndexOutput, error) {
+	scope, syncer, out, err := uc.prepare(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if IndexStale(out.Dir) {
+		return nil, fmt.Errorf("refusing to push: %w", ErrIndexStale)
+	}
+	if out.Bytes, err = syncer.Push(ctx, out.Dir, RemoteIndexDir(scope, out.Dir)); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (uc *SyncIndexUseCase) Pull(ctx context.Context, input SyncIndexInput) (*SyncIndexOutput, error) {
+	scope, syncer, out, err := uc.prepare(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	if out.Bytes, err = syncer.Pull(ctx, out.Dir, RemoteIndexDir(scope, out.Dir)); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (uc *SyncIndexUseCase) prepare(ctx context.Context, input SyncIndexInput) (Scope, *IndexSyncer, *SyncIndexOutput, error) {
+	scope := uc.resolver.Resolve(input.Scope)
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		return scope, nil, nil, fmt.Errorf("load config: %w", err)
+	}
+	syncer, err := NewIndexSyncer(cfg.IndexRemote)
+	if err != nil {
+		return scope, nil, nil, err
+	}
+
+	var branches BranchRepository
+	if uc.branchFor != nil {
+		branches, _ = uc.branchFor(scope)
+	}
+	dir := IndexPath(ctx, scope, branches)
+	return scope, syncer, &SyncIndexOutput{
+		Dir:    dir,
+		Remote: syncer.Location(RemoteIndexDir(scope, dir)),
+	}, nil
+}
@@ -0,0 +1,266 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 stores objects by URL path and checks that requests are signed
+// and that uploads match their declared payload hash.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, string) {
+	t.Helper()
+	f := &fakeS3{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv.URL
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		http.Error(w, "<Error><Code>AccessDenied</Code></Error>", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch r.Method {
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(body)
+		if r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(sum[:]) {
+			http.Error(w, "<Error><Code>XAmzContentSHA256Mismatch</Code></Error>", http.StatusBadRequest)
+			return
+		}
+		f.objects[r.URL.Path] = body
+	case http.MethodGet:
+		body, ok := f.objects[r.URL.Path]
+		if !ok {
+			http.Error(w, "<Error><Code>NoSuchKey</Code></Error>", http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(body)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func writeFakeIndex(t *testing.T, dir, ann string, keys ...string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	mapping := indexMapping{KeyToID: map[string]uint32{}, IDToKey: map[uint32]string{}}
+	for _, k := range keys {
+		mapping.KeyToID[k] = mapping.NextID
+		mapping.IDToKey[mapping.NextID] = k
+		mapping.NextID++
+	}
+	data, _ := json.Marshal(mapping)
+	if err := os.WriteFile(filepath.Join(dir, MappingFilename), data, 0644); err != nil {
+		t.Fatalf("write mapping: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, IndexFilename), []byte(ann), 0644); err != nil {
+		t.Fatalf("write index: %v", err)
+	}
+}
+
+func TestIndexSyncerPushPull(t *testing.T) {
+	s3, endpoint := newFakeS3(t)
+	syncer, err := NewIndexSyncer(IndexRemoteConfig{
+		Endpoint: endpoint, Bucket: "mem", Prefix: "/team/", AccessKey: "AKID", SecretKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("new syncer: %v", err)
+	}
+	ctx := context.Background()
+
+	scope := Scope{Type: ScopeProject, Path: t.TempDir()}
+	scope.MemPath = filepath.Join(scope.Path, ".mem")
+	dir := scope.BranchVectorPath("main")
+	writeFakeIndex(t, dir, "ann-bytes", "notes/a", "notes/b")
+
+	remoteDir := RemoteIndexDir(scope, dir)
+	if remoteDir != "branches/main" {
+		t.Errorf("remote dir = %q, want branches/main", remoteDir)
+	}
+	if got := syncer.Location(remoteDir); got != "s3://mem/team/branches/main" {
+		t.Errorf("location = %q", got)
+	}
+
+	if _, err := syncer.Push(ctx, dir, remoteDir); err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	for _, name := range []string{IndexFilename, MappingFilename} {
+		if _, ok := s3.objects["/mem/team/branches/main/"+name]; !ok {
+			t.Errorf("object %s not uploaded; have %v", name, s3.objects)
+		}
+	}
+
+	fresh := filepath.Join(t.TempDir(), "vectors")
+	n, err := syncer.Pull(ctx, fresh, remoteDir)
+	if err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+	if n == 0 {
+		t.Error("pull reported no bytes")
+	}
+	ann, _ := os.ReadFile(filepath.Join(fresh, IndexFilename))
+	if string(ann) != "ann-bytes" {
+		t.Errorf("pulled index = %q", ann)
+	}
+	keys, err := IndexedKeys(fresh)
+	if err != nil || len(keys) != 2 {
+		t.Errorf("pulled mapping keys = %v, %v", keys, err)
+	}
+	entries, _ := os.ReadDir(fresh)
+	if len(entries) != 2 {
+		t.Errorf("pull left extra files: %v", entries)
+	}
+}
+
+func TestIndexSyncerPullMissingKeepsLocalIndex(t *testing.T) {
+	_, endpoint := newFakeS3(t)
+	syncer, err := NewIndexSyncer(IndexRemoteConfig{Endpoint: endpoint, Bucket: "mem", AccessKey: "AKID"})
+	if err != nil {
+		t.Fatalf("new syncer: %v", err)
+	}
+
+	dir := t.TempDir()
+	writeFakeIndex(t, dir, "local", "kept")
+	if _, err := syncer.Pull(context.Background(), dir, ""); !errors.Is(err, ErrRemoteIndexNotFound) {
+		t.Fatalf("expected ErrRemoteIndexNotFound, got %v", err)
+	}
+	if keys, _ := IndexedKeys(dir); len(keys) != 1 || keys[0] != "kept" {
+		t.Errorf("local mapping changed: %v", keys)
+	}
+}
+
+func TestIndexSyncerRejectsBadCredentials(t *testing.T) {
+	_, endpoint := newFakeS3(t)
+	syncer, err := NewIndexSyncer(IndexRemoteConfig{Endpoint: endpoint, Bucket: "mem", AccessKey: "WRONG"})
+	if err != nil {
+		t.Fatalf("new syncer: %v", err)
+	}
+	dir := t.TempDir()
+	writeFakeIndex(t, dir, "x")
+	_, err = syncer.Push(context.Background(), dir, "")
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("expected a 403 error, got %v", err)
+	}
+}
+
+func TestNewIndexSyncerRequiresConfig(t *testing.T) {
+	if _, err := NewIndexSyncer(IndexRemoteConfig{}); !errors.Is(err, ErrNoIndexRemote) {
+		t.Errorf("expected ErrNoIndexRemote, got %v", err)
+	}
+	if _, err := NewIndexSyncer(IndexRemoteConfig{Endpoint: "not a url", Bucket: "b"}); err == nil {
+		t.Error("expected error for an endpoint without scheme")
+	}
+}
+
+func TestSyncIndexUseCasePushAndPull(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	s3, endpoint := newFakeS3(t)
+	ctx := context.Background()
+
+	scope := resolver.Resolve("")
+	cfg := DefaultConfig()
+	cfg.IndexRemote = IndexRemoteConfig{Endpoint: endpoint, Bucket: "mem", AccessKey: "AKID"}
+	if err := SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	branchFor := func(Scope) (BranchRepository, error) { return repo, nil }
+	uc := NewSyncIndexUseCase(resolver, branchFor)
+
+	current, err := repo.Current(ctx)
+	if err != nil {
+		t.Fatalf("current: %v", err)
+	}
+	dir := scope.BranchVectorPath(current.Name)
+	writeFakeIndex(t, dir, "ann", "k")
+
+	out, err := uc.Push(ctx, SyncIndexInput{})
+	if err != nil {
+		t.Fatalf("push: %v", err)
+	}
+	if out.Dir != dir || len(s3.objects) != 2 {
+		t.Errorf("push dir = %s, objects = %v", out.Dir, s3.objects)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		t.Fatalf("remove index: %v", err)
+	}
+	if _, err := uc.Pull(ctx, SyncIndexInput{}); err != nil {
+		t.Fatalf("pull: %v", err)
+	}
+	if keys, _ := IndexedKeys(dir); len(keys) != 1 {
+		t.Errorf("pulled keys = %v", keys)
+	}
+
+	if err := MarkIndexStale(dir); err != nil {
+		t.Fatalf("mark stale: %v", err)
+	}
+	if _, err := uc.Push(ctx, SyncIndexInput{}); !errors.Is(err, ErrIndexStale) {
+		t.Errorf("expected ErrIndexStale pushing a stale index, got %v", err)
+	}
+}
+
+func TestAnnoyIndexLoadFetchesRemote(t *testing.T) {
+	dir := t.TempDir()
+	idx, err := NewAnnoyIndex(dir, 3)
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+
+	// The fetch writes only a mapping, so Load stops short of reading an
+	// Annoy file.
+	calls := 0
+	idx.SetRemote(func(ctx context.Context, target string) error {
+		calls++
+		if target != dir {
+			t.Errorf("fetch into %s, want %s", target, dir)
+		}
+		writeFakeIndex(t, target, "", "remote/key")
+		return os.Remove(filepath.Join(target, IndexFilename))
+	})
+	if err := idx.Load(context.Background()); err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if calls != 1 || idx.Len() != 1 {
+		t.Errorf("calls = %d, len = %d; want the remote mapping loaded once", calls, idx.Len())
+	}
+
+	// A local copy now exists, so the remote is not asked again.
+	if err := idx.Load(context.Background()); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("fetch called %d times, want 1", calls)
+	}
+
+	empty, err := NewAnnoyIndex(t.TempDir(), 3)
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+	empty.SetRemote(func(context.Context, string) error { return ErrRemoteIndexNotFound })
+	if err := empty.Load(context.Background()); err != nil {
+		t.Errorf("missing remote index should not fail Load: %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// vectorSource is an index that hands out the vectors it stores.
+type vectorSource interface {
+	Vector(key Key) ([]float32, bool)
+	Keys() []Key
+}
+
+type IndexVectorInput struct {
+	Key   string
+	Scope string
+}
+
+// IndexVector is a vector as stored in the index. Key is the memory key,
+// or a chunk key for memories indexed in chunks.
+type IndexVector struct {
+	Key    string
+	Vector []float32
+	Norm   float64
+}
+
+type IndexVectorOutput struct {
+	Vectors []IndexVector
+}
+
+// --- IndexVectorUseCase ---
+
+// IndexVectorUseCase reads the stored vectors of a memory back from the
+// index, for looking into surprising semantic search results.
+type IndexVectorUseCase struct {
+	resolver *ScopeResolver
+	indexFor func(Scope) (VectorIndex, error)
+}
+
+func NewIndexVectorUseCase(
+	resolver *ScopeResolver,
+	indexFor func(Scope) (VectorIndex, error),
+) *IndexVectorUseCase {
+	return &IndexVectorUseCase{
+		resolver: resolver,
+		indexFor: indexFor,
+	}
+}
+
+func (uc *IndexVectorUseCase) Execute(ctx context.Context, input IndexVectorInput) (*IndexVectorOutput, error) {
+	key, err := NewKey(input.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := uc.resolver.Resolve(input.Scope)
+	index, err := uc.indexFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("open index: %w", err)
+	}
+	source, ok := index.(vectorSource)
+	if !ok {
+		return nil, fmt.Errorf("index does not expose its vectors: %w", ErrNoIndex)
+	}
+
+	key = normalizeKey(scope, key)
+	output := &IndexVectorOutput{}
+	if vec, ok := source.Vector(key); ok {
+		output.Vectors = append(output.Vectors, newIndexVector(key, vec))
+		return output, nil
+	}
+	for _, k := range source.Keys() {
+		if k == key || ParentKey(k) != key {
+			continue
+		}
+		if vec, ok := source.Vector(k); ok {
+			output.Vectors = append(output.Vectors, newIndexVector(k, vec))
+		}
+	}
+	if len(output.Vectors) == 0 {
+		return nil, fmt.Errorf("%s is not indexed: %w", key, ErrNotFound)
+	}
+	return output, nil
+}
+
+func newIndexVector(key Key, vec []float32) IndexVector {
+	var sum float64
+	for _, v := range vec {
+		sum += float64(v) * float64(v)
+	}
+	return IndexVector{Key: key.String(), Vector: vec, Norm: math.Sqrt(sum)}
+}
@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestIndexVectorUseCase(t *testing.T) {
+	_, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	dir := t.TempDir()
+	idx, err := NewAnnoyIndex(dir, 3)
+	if err != nil {
+		t.Fatalf("new index: %v", err)
+	}
+	want := []float32{0.6, 0.8, 0}
+	for key, vec := range map[Key][]float32{
+		"notes/a":          want,
+		ChunkKey("big", 0): {1, 0, 0},
+		ChunkKey("big", 1): {0, 0, 1},
+		"big-other":        {0, 1, 0},
+	} {
+		if err := idx.Add(ctx, key, NewEmbedding(vec, "test")); err != nil {
+			t.Fatalf("add %s: %v", key, err)
+		}
+	}
+	if err := idx.Build(ctx, 2); err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if err := idx.Save(ctx); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	// Read the vectors back from a fresh load, as mem index vector does.
+	uc := NewIndexVectorUseCase(resolver, func(Scope) (VectorIndex, error) {
+		loaded, err := NewAnnoyIndex(dir, 3)
+		if err != nil {
+			return nil, err
+		}
+		return loaded, loaded.Load(ctx)
+	})
+
+	out, err := uc.Execute(ctx, IndexVectorInput{Key: "notes/a"})
+	if err != nil {
+		t.Fatalf("vector notes/a: %v", err)
+	}
+	if len(out.Vectors) != 1 || !slices.Equal(out.Vectors[0].Vector, want) {
+		t.Fatalf("vectors = %+v, want %v", out.Vectors, want)
+	}
+	if got := out.Vectors[0].Norm; math.Abs(got-1) > 1e-6 {
+		t.Errorf("norm = %v, want 1", got)
+	}
+
+	out, err = uc.Execute(ctx, IndexVectorInput{Key: "big"})
+	if err != nil {
+		t.Fatalf("vector big: %v", err)
+	}
+	var keys []string
+	for _, v := range out.Vectors {
+		keys = append(keys, v.Key)
+	}
+	if !slices.Equal(keys, []string{"big#0", "big#1"}) {
+		t.Errorf("chunked memory = %v, want one vector per chunk", keys)
+	}
+
+	if _, err := uc.Execute(ctx, IndexVectorInput{Key: "missing"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing key: err = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"bufio"
+	"strings"
+	"time"
+)
+
+// JournalSectionLayout is the date format of the "## <date>" headings
+// AddMemoryInput.Section inserts.
+const JournalSectionLayout = "2006-01-02"
+
+// JournalConfig configures appending to memories used as logs.
+type JournalConfig struct {
+	// TimestampFormat is the Go time layout mem add --timestamp uses
+	// without a format of its own. Defaults to time.RFC3339.
+	TimestampFormat string `yaml:"timestamp_format,omitempty"`
+}
+
+// appendJournal appends text to existing the way mem add does: on a new
+// line, prefixed with now in layout if layout is set, and after a
+// "## <date>" heading if section is set and the last heading in existing
+// is for another day.
+func appendJournal(existing []byte, text, layout string, section bool, now time.Time) []byte {
+	if layout != "" {
+		text = now.Format(layout) + " " + text
+	}
+
+	if section {
+		heading := "## " + now.Format(JournalSectionLayout)
+		if lastHeading(string(existing)) != heading {
+			if len(existing) == 0 {
+				return []byte(heading + "\n" + text)
+			}
+			prev := strings.TrimRight(string(existing), "\n")
+			return []byte(prev + "\n\n" + heading + "\n" + text)
+		}
+	}
+
+	if len(existing) == 0 {
+		return []byte(text)
+	}
+	return append(existing, []byte("\n"+text)...)
+}
+
+// lastHeading returns the last "## " line of content, or "".
+func lastHeading(content string) string {
+	var last string
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for sc.Scan() {
+		if line := strings.TrimRight(sc.Text(), " \t"); strings.HasPrefix(line, "## ") {
+			last = line
+		}
+	}
+	return last
+}
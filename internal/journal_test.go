@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAppendJournal(t *testing.T) {
+	beforeMidnight := time.Date(2026, 3, 1, 23, 59, 0, 0, time.UTC)
+	afterMidnight := time.Date(2026, 3, 2, 0, 1, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		existing string
+		layout   string
+		section  bool
+		now      time.Time
+		want     string
+	}{
+		{
+			name:     "plain append",
+			existing: "first",
+			now:      beforeMidnight,
+			want:     "first\nsecond",
+		},
+		{
+			name: "empty existing content",
+			now:  beforeMidnight,
+			want: "second",
+		},
+		{
+			name:   "timestamp",
+			layout: time.RFC3339,
+			now:    beforeMidnight,
+			want:   "2026-03-01T23:59:00Z second",
+		},
+		{
+			name:    "section on empty existing content",
+			layout:  "15:04",
+			section: true,
+			now:     beforeMidnight,
+			want:    "## 2026-03-01\n23:59 second",
+		},
+		{
+			name:     "same day adds no heading",
+			existing: "## 2026-03-01\n23:58 first\n",
+			layout:   "15:04",
+			section:  true,
+			now:      beforeMidnight,
+			want:     "## 2026-03-01\n23:58 first\n\n23:59 second",
+		},
+		{
+			name:     "crossing midnight adds a heading",
+			existing: "## 2026-03-01\n23:59 first\n",
+			layout:   "15:04",
+			section:  true,
+			now:      afterMidnight,
+			want:     "## 2026-03-01\n23:59 first\n\n## 2026-03-02\n00:01 second",
+		},
+		{
+			name:     "content without headings gets one",
+			existing: "older notes",
+			section:  true,
+			now:      afterMidnight,
+			want:     "older notes\n\n## 2026-03-02\nsecond",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := appendJournal([]byte(tt.existing), "second", tt.layout, tt.section, tt.now)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
@@ -1,19 +1,60 @@
 package internal
 
-import "context"
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrModelsUnsupported     = errors.New("provider does not support listing models")
+	ErrEmbeddingsUnsupported = errors.New("provider does not support embeddings")
+)
 
 type Embedder interface {
 	Embed(ctx context.Context, text string) ([]float32, error)
 	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
 	Dimension() int
 	Device() string
+	Model() string
 	Close() error
 }
 
+// EmbedderFunc returns the embedder, or nil when there is none. Use cases
+// call it only when they embed, so a model loaded on first call is never
+// loaded by commands that do not need it. A nil EmbedderFunc turns
+// semantic search off; one returning nil means the model failed to load,
+// and writes count the index updates they skip.
+type EmbedderFunc func() Embedder
+
+// Get calls f, treating a nil f as no embedder.
+func (f EmbedderFunc) Get() Embedder {
+	if f == nil {
+		return nil
+	}
+	return f()
+}
+
+// StaticEmbedder returns an EmbedderFunc that always yields e, for callers
+// that build their embedder themselves.
+func StaticEmbedder(e Embedder) EmbedderFunc {
+	return func() Embedder { return e }
+}
+
 type Provider interface {
 	Complete(ctx context.Context, prompt string) (string, error)
 	GenerateObject(ctx context.Context, prompt string, target any) error
 	Stream(ctx context.Context, prompt string) (<-chan string, error)
+	// Models lists the model ids the provider accepts, or returns
+	// ErrModelsUnsupported if its API has no way to ask.
+	Models(ctx context.Context) ([]string, error)
+}
+
+// NoModels is the default Models implementation, for embedding in
+// providers that cannot list their models.
+type NoModels struct{}
+
+func (NoModels) Models(context.Context) ([]string, error) {
+	return nil, ErrModelsUnsupported
 }
 
 // Structured output types for AI features
@@ -25,6 +66,17 @@ type Summary struct {
 	Tags      []string `json:"tags"`
 }
 
+// CommitFacts is what the llm-extract hook strategy asks a provider to
+// pull out of a commit.
+type CommitFacts struct {
+	FilesAdded     []string `json:"files_added" yaml:"files_added"`
+	FilesRemoved   []string `json:"files_removed" yaml:"files_removed"`
+	Components     []string `json:"components" yaml:"components"`
+	Breaking       bool     `json:"breaking" yaml:"breaking"`
+	MigrationNotes string   `json:"migration_notes" yaml:"migration_notes"`
+	Tags           []string `json:"tags" yaml:"tags"`
+}
+
 type AutoTag struct {
 	Tags       []string `json:"tags"`
 	Category   string   `json:"category"`
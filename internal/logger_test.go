@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewLoggerLevels(t *testing.T) {
+	tests := []struct {
+		name      string
+		debug     bool
+		quiet     bool
+		wantDebug bool
+		wantWarn  bool
+	}{
+		{name: "default", wantWarn: true},
+		{name: "quiet", quiet: true},
+		{name: "debug", debug: true, wantDebug: true, wantWarn: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := NewLogger(&buf, tt.debug, tt.quiet)
+
+			logger.Debug("debug line")
+			logger.Warn("warn line")
+
+			assert.Equal(t, tt.wantDebug, strings.Contains(buf.String(), "debug line"))
+			assert.Equal(t, tt.wantWarn, strings.Contains(buf.String(), "warn line"))
+		})
+	}
+}
+
+func TestNewLoggerOmitsTime(t *testing.T) {
+	var buf bytes.Buffer
+	NewLogger(&buf, false, false).Warn("hello")
+
+	assert.NotContains(t, buf.String(), "time=")
+	assert.Contains(t, buf.String(), "msg=hello")
+}
+
+func TestLoggerFromFallsBackToDefault(t *testing.T) {
+	assert.NotNil(t, LoggerFrom(context.Background()))
+}
+
+type failingEmbedder struct{}
+
+func (failingEmbedder) Embed(context.Context, string) ([]float32, error) {
+	return nil, errors.New("boom")
+}
+func (failingEmbedder) EmbedBatch(context.Context, []string) ([][]float32, error) {
+	return nil, errors.New("boom")
+}
+func (failingEmbedder) Dimension() int { return 3 }
+func (failingEmbedder) Device() string { return "cpu" }
+func (failingEmbedder) Model() string  { return "failing" }
+func (failingEmbedder) Close() error   { return nil }
+
+func TestSetMemoryWarnsThroughContextLogger(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), NewLogger(&buf, false, false))
+
+	repoFor := func(s Scope) (MemoryRepository, error) { return repo, nil }
+	failIndex := func(s Scope) (VectorIndex, error) { return nil, ErrNoIndex }
+
+	setUC := NewSetMemoryUseCase(resolver, repoFor, failIndex, StaticEmbedder(failingEmbedder{}), nil)
+	require.NoError(t, setUC.Execute(ctx, SetMemoryInput{Key: "warn/me", Content: "x"}))
+
+	assert.Contains(t, buf.String(), "skipping index update")
+}
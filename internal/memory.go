@@ -3,7 +3,10 @@ package internal
 import (
 	"context"
 	"errors"
+	"fmt"
 	"regexp"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -12,18 +15,51 @@ var (
 	ErrAlreadyExists = errors.New("memory already exists")
 	ErrInvalidKey    = errors.New("invalid key")
 	ErrNoIndex       = errors.New("no vector index available")
+	ErrNoEmbedder    = errors.New("embedder not available")
+	ErrIndexNotBuilt = errors.New("semantic index is empty or not built")
+	// ErrIndexDimension means the index was built by an embedder with a
+	// different dimension, usually after a model change.
+	ErrIndexDimension = errors.New("index dimension does not match the embedder")
+	// ErrIndexStale means the store changed underneath the index, e.g. by
+	// a snapshot restore, and only a full rebuild brings it back in sync.
+	ErrIndexStale = errors.New("index is stale; run mem index rebuild")
 )
 
+// KeyError explains why a key was rejected. It matches ErrInvalidKey with
+// errors.Is, and errors.As recovers the key and the reason.
+type KeyError struct {
+	Key    string
+	Reason string
+}
+
+func (e *KeyError) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%v: %s", ErrInvalidKey, e.Reason)
+	}
+	return fmt.Sprintf("%v %q: %s", ErrInvalidKey, e.Key, e.Reason)
+}
+
+func (e *KeyError) Unwrap() error {
+	return ErrInvalidKey
+}
+
 var keyPattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._/-]*$`)
 
+// reservedNames are the files and directories mem keeps next to the
+// memories in a .mem directory. A key may not start with one of them.
+var reservedNames = []string{AttachmentsDir, "config.yaml", HookRateFilename, PendingIndexFilename, "templates", UsageFilename, "vectors"}
+
 type Key string
 
 func NewKey(s string) (Key, error) {
 	if s == "" {
-		return "", ErrInvalidKey
+		return "", &KeyError{Reason: "key is empty"}
 	}
 	if !keyPattern.MatchString(s) {
-		return "", ErrInvalidKey
+		return "", &KeyError{Key: s, Reason: "must start with a letter or digit and contain only letters, digits, '.', '_', '-' and '/'"}
+	}
+	if first, _, _ := strings.Cut(s, "/"); slices.Contains(reservedNames, first) {
+		return "", &KeyError{Key: s, Reason: fmt.Sprintf("%q is reserved for mem's own files", first)}
 	}
 	return Key(s), nil
 }
@@ -32,6 +68,80 @@ func (k Key) String() string {
 	return string(k)
 }
 
+// KeyPolicy is an optional, per-scope naming scheme for keys. The zero value
+// accepts every key that NewKey accepts.
+type KeyPolicy struct {
+	MaxDepth       int    `yaml:"max_depth,omitempty"`
+	Lowercase      bool   `yaml:"lowercase,omitempty"`
+	AllowedPattern string `yaml:"allowed_pattern,omitempty"`
+
+	// CaseSensitivity is CasePreserve (the default) or CaseLower, which
+	// folds every key to lower case so a store behaves the same on
+	// case-insensitive filesystems.
+	CaseSensitivity string `yaml:"case_sensitivity,omitempty"`
+}
+
+// Values for KeyPolicy.CaseSensitivity.
+const (
+	CasePreserve = "preserve"
+	CaseLower    = "lower"
+)
+
+// NewKey parses s with NewKey, normalizes it and then applies the policy.
+func (p KeyPolicy) NewKey(s string) (Key, error) {
+	key, err := NewKey(s)
+	if err != nil {
+		return "", err
+	}
+	key = p.Normalize(key)
+	if err := p.Validate(key); err != nil {
+		return "", err
+	}
+	return key, nil
+}
+
+// Normalize returns the form of key the store uses under the policy.
+// Keys are ASCII, so case folding is the only normalization needed.
+func (p KeyPolicy) Normalize(key Key) Key {
+	if p.CaseSensitivity == CaseLower {
+		return Key(strings.ToLower(key.String()))
+	}
+	return key
+}
+
+// Validate reports why key does not conform to the policy as a *KeyError.
+func (p KeyPolicy) Validate(key Key) error {
+	s := key.String()
+
+	switch p.CaseSensitivity {
+	case "", CasePreserve, CaseLower:
+	default:
+		return fmt.Errorf("invalid key policy case_sensitivity %q: use %s or %s", p.CaseSensitivity, CasePreserve, CaseLower)
+	}
+
+	if p.MaxDepth > 0 {
+		if depth := strings.Count(s, "/") + 1; depth > p.MaxDepth {
+			return &KeyError{Key: s, Reason: fmt.Sprintf("has %d path segments, policy allows at most %d", depth, p.MaxDepth)}
+		}
+	}
+
+	if p.Lowercase && s != strings.ToLower(s) {
+		return &KeyError{Key: s, Reason: fmt.Sprintf("must be lowercase (try %q)", strings.ToLower(s))}
+	}
+
+	if p.AllowedPattern != "" {
+		re, err := regexp.Compile(p.AllowedPattern)
+		if err != nil {
+			return fmt.Errorf("invalid key policy pattern %q: %w", p.AllowedPattern, err)
+		}
+		if !re.MatchString(s) {
+			return &KeyError{Key: s, Reason: "does not match the allowed pattern " + p.AllowedPattern}
+		}
+	}
+
+	return nil
+}
+
 type Metadata struct {
 	Tags     []string
 	MimeType string
@@ -55,6 +165,13 @@ func NewMemory(key Key, content []byte) *Memory {
 	}
 }
 
+// KeyInfo describes a stored memory without its content.
+type KeyInfo struct {
+	Key       Key
+	Size      int64
+	UpdatedAt time.Time
+}
+
 type MemoryRepository interface {
 	Get(ctx context.Context, key Key) (*Memory, error)
 	Save(ctx context.Context, mem *Memory) error
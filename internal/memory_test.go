@@ -1,6 +1,8 @@
 package internal
 
 import (
+	"errors"
+	"strings"
 	"testing"
 )
 
@@ -47,10 +49,27 @@ func TestNewKeyInvalid(t *testing.T) {
 
 	for _, s := range invalid {
 		_, err := NewKey(s)
-		if err != ErrInvalidKey {
+		if !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("NewKey(%q) expected ErrInvalidKey, got %v", s, err)
+		}
+		var keyErr *KeyError
+		if !errors.As(err, &keyErr) || keyErr.Key != s || keyErr.Reason == "" {
+			t.Errorf("NewKey(%q) should return a *KeyError naming the key and a reason, got %#v", s, err)
+		}
+	}
+}
+
+func TestNewKeyReserved(t *testing.T) {
+	for _, s := range []string{"vectors", "vectors/index.ann", "templates/note", "config.yaml", "attachments/x", "usage.jsonl"} {
+		if _, err := NewKey(s); !errors.Is(err, ErrInvalidKey) {
 			t.Errorf("NewKey(%q) expected ErrInvalidKey, got %v", s, err)
 		}
 	}
+	for _, s := range []string{"docs/templates/readme", "vectors-notes", "my/config.yaml"} {
+		if _, err := NewKey(s); err != nil {
+			t.Errorf("NewKey(%q) returned error: %v", s, err)
+		}
+	}
 }
 
 func TestKeyString(t *testing.T) {
@@ -59,3 +78,72 @@ func TestKeyString(t *testing.T) {
 		t.Errorf("expected 'test/key', got %q", key.String())
 	}
 }
+
+func TestKeyPolicyPermissiveByDefault(t *testing.T) {
+	var policy KeyPolicy
+	for _, s := range []string{"a/b/c/d/e/f", "MixedCase/Key", "x.y_z"} {
+		if _, err := policy.NewKey(s); err != nil {
+			t.Errorf("zero policy rejected %q: %v", s, err)
+		}
+	}
+}
+
+func TestKeyPolicyMaxDepth(t *testing.T) {
+	policy := KeyPolicy{MaxDepth: 2}
+
+	if _, err := policy.NewKey("notes/todo"); err != nil {
+		t.Errorf("expected depth-2 key to pass, got %v", err)
+	}
+
+	_, err := policy.NewKey("notes/2024/todo")
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey for too-deep key, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "at most 2") {
+		t.Errorf("expected helpful message, got %q", err.Error())
+	}
+}
+
+func TestKeyPolicyAllowedPattern(t *testing.T) {
+	policy := KeyPolicy{AllowedPattern: `^(notes|adr)/`}
+
+	if _, err := policy.NewKey("adr/0001"); err != nil {
+		t.Errorf("expected matching key to pass, got %v", err)
+	}
+
+	_, err := policy.NewKey("scratch/idea")
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey for pattern violation, got %v", err)
+	}
+}
+
+func TestKeyPolicyLowercase(t *testing.T) {
+	policy := KeyPolicy{Lowercase: true}
+
+	_, err := policy.NewKey("Notes/Todo")
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", err)
+	}
+	if !strings.Contains(err.Error(), `"notes/todo"`) {
+		t.Errorf("expected suggestion in message, got %q", err.Error())
+	}
+}
+
+func TestKeyPolicyCaseLower(t *testing.T) {
+	policy := KeyPolicy{CaseSensitivity: CaseLower}
+
+	key, err := policy.NewKey("Notes/Todo")
+	if err != nil {
+		t.Fatalf("NewKey: %v", err)
+	}
+	if key != "notes/todo" {
+		t.Errorf("key = %q, want notes/todo", key)
+	}
+
+	if (KeyPolicy{}).Normalize("Notes/Todo") != "Notes/Todo" {
+		t.Error("the default policy must preserve case")
+	}
+	if err := (KeyPolicy{CaseSensitivity: "upper"}).Validate("notes"); err == nil {
+		t.Error("expected an error for an unknown case_sensitivity")
+	}
+}
@@ -0,0 +1,346 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+)
+
+var (
+	_ MemoryRepository  = (*InMemoryRepository)(nil)
+	_ HistoryRepository = (*InMemoryRepository)(nil)
+)
+
+// InMemoryRepository keeps memories in a map and their history as an
+// append-only list of snapshots, for tests and embedding without a
+// filesystem. It has a single branch, DefaultBranch. Refs are commit
+// hashes, unique hash prefixes, HEAD and HEAD~n.
+type InMemoryRepository struct {
+	mu       sync.RWMutex
+	memories map[Key]*Memory
+	commits  []memCommit // oldest first
+}
+
+type memCommit struct {
+	commit Commit
+	tree   map[Key]string
+}
+
+// NewInMemoryRepository returns an empty repository with no commits.
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{memories: make(map[Key]*Memory)}
+}
+
+func (r *InMemoryRepository) Get(ctx context.Context, key Key) (*Memory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	mem, ok := r.memories[key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return cloneMemory(mem), nil
+}
+
+// Save stores a copy of mem. Like a file's modification time, UpdatedAt
+// becomes now; CreatedAt is kept from the first save of the key.
+func (r *InMemoryRepository) Save(ctx context.Context, mem *Memory) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	saved := cloneMemory(mem)
+	saved.UpdatedAt = time.Now()
+	if prev, ok := r.memories[mem.Key]; ok {
+		saved.CreatedAt = prev.CreatedAt
+	} else if saved.CreatedAt.IsZero() {
+		saved.CreatedAt = saved.UpdatedAt
+	}
+	r.memories[mem.Key] = saved
+	return nil
+}
+
+func (r *InMemoryRepository) Delete(ctx context.Context, key Key) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.memories[key]; !ok {
+		return ErrNotFound
+	}
+	delete(r.memories, key)
+	return nil
+}
+
+// List returns the memories under prefix, sorted by key. Trashed memories
+// are only listed when prefix is under TrashPrefix.
+func (r *InMemoryRepository) List(ctx context.Context, prefix string) ([]*Memory, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var memories []*Memory
+	for _, key := range r.keys(prefix) {
+		memories = append(memories, cloneMemory(r.memories[key]))
+	}
+	return memories, nil
+}
+
+// ListKeys is like List without the content.
+func (r *InMemoryRepository) ListKeys(ctx context.Context, prefix string) ([]KeyInfo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var keys []KeyInfo
+	for _, key := range r.keys(prefix) {
+		mem := r.memories[key]
+		keys = append(keys, KeyInfo{Key: key, Size: int64(len(mem.Content)), UpdatedAt: mem.UpdatedAt})
+	}
+	return keys, nil
+}
+
+func (r *InMemoryRepository) Exists(ctx context.Context, key Key) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.memories[key]
+	return ok, nil
+}
+
+// keys returns the sorted keys under prefix; the caller holds mu.
+func (r *InMemoryRepository) keys(prefix string) []Key {
+	prefix = strings.TrimSuffix(prefix, "/")
+	var keys []Key
+	for key := range r.memories {
+		s := key.String()
+		if strings.HasPrefix(s, TrashPrefix) && !underPrefix(prefix, TrashPrefix) {
+			continue
+		}
+		if underPrefix(s, prefix) {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func cloneMemory(mem *Memory) *Memory {
+	c := *mem
+	c.Content = slices.Clone(mem.Content)
+	c.Metadata.Tags = slices.Clone(mem.Metadata.Tags)
+	return &c
+}
+
+// HistoryRepository implementation
+
+func (r *InMemoryRepository) Commit(ctx context.Context, message string) (*Commit, error) {
+	return r.commit(message, false)
+}
+
+func (r *InMemoryRepository) CommitAllowEmpty(ctx context.Context, message string) (*Commit, error) {
+	return r.commit(message, true)
+}
+
+func (r *InMemoryRepository) commit(message string, allowEmpty bool) (*Commit, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tree := r.worktree()
+	var parents []string
+	if head := r.head(); head != nil {
+		if !allowEmpty && maps.Equal(head.tree, tree) {
+			return nil, ErrNothingToCommit
+		}
+		parents = []string{head.commit.Hash}
+	} else if !allowEmpty && len(tree) == 0 {
+		return nil, ErrNothingToCommit
+	}
+
+	now := time.Now()
+	sum := sha256.Sum256([]byte(strings.Join(parents, " ") + "\x00" + message + "\x00" + now.Format(time.RFC3339Nano) + "\x00" + strconv.Itoa(len(r.commits))))
+	c := Commit{
+		Hash:      hex.EncodeToString(sum[:])[:40],
+		Message:   strings.TrimSpace(message),
+		Author:    DefaultAuthor,
+		Email:     DefaultEmail,
+		Timestamp: now,
+		Parents:   parents,
+	}
+	r.commits = append(r.commits, memCommit{commit: c, tree: tree})
+	return cloneCommit(c), nil
+}
+
+func (r *InMemoryRepository) Log(ctx context.Context, limit int) ([]*Commit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.commits) == 0 {
+		return nil, ErrNoCommits
+	}
+	var commits []*Commit
+	for i := len(r.commits) - 1; i >= 0; i-- {
+		if limit > 0 && len(commits) >= limit {
+			break
+		}
+		commits = append(commits, cloneCommit(r.commits[i].commit))
+	}
+	return commits, nil
+}
+
+// LogAll is Log with the branch name on the tip, as there is one branch.
+func (r *InMemoryRepository) LogAll(ctx context.Context, limit int) ([]*Commit, error) {
+	commits, err := r.Log(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range commits {
+		c.Refs = []string{}
+	}
+	commits[0].Refs = []string{DefaultBranch}
+	return commits, nil
+}
+
+// Diff shows uncommitted changes, or with ref the changes from ref to HEAD.
+func (r *InMemoryRepository) Diff(ctx context.Context, ref string) (string, error) {
+	changes, err := r.changes(ref)
+	if err != nil {
+		return "", err
+	}
+	return formatFileChanges(changes), nil
+}
+
+func (r *InMemoryRepository) DiffStat(ctx context.Context, ref string) ([]FileStat, error) {
+	changes, err := r.changes(ref)
+	if err != nil {
+		return nil, err
+	}
+	return fileChangeStats(changes), nil
+}
+
+func (r *InMemoryRepository) Show(ctx context.Context, ref string) (*Commit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	i, err := r.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	var parent map[Key]string
+	if i > 0 {
+		parent = r.commits[i-1].tree
+	}
+	out := cloneCommit(r.commits[i].commit)
+	out.Diff = formatFileChanges(treeChanges(parent, r.commits[i].tree))
+	return out, nil
+}
+
+// Revert resets the memories and the history to ref, dropping the commits
+// after it, like git reset --hard.
+func (r *InMemoryRepository) Revert(ctx context.Context, ref string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i, err := r.resolve(ref)
+	if err != nil {
+		return err
+	}
+	r.commits = r.commits[:i+1]
+	now := time.Now()
+	r.memories = make(map[Key]*Memory, len(r.commits[i].tree))
+	for key, content := range r.commits[i].tree {
+		r.memories[key] = &Memory{Key: key, Content: []byte(content), CreatedAt: now, UpdatedAt: now}
+	}
+	return nil
+}
+
+// changes lists what Diff shows for ref; it takes mu itself.
+func (r *InMemoryRepository) changes(ref string) ([]fileChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if ref == "" {
+		var headTree map[Key]string
+		if head := r.head(); head != nil {
+			headTree = head.tree
+		}
+		return treeChanges(headTree, r.worktree()), nil
+	}
+	i, err := r.resolve(ref)
+	if err != nil {
+		return nil, err
+	}
+	return treeChanges(r.commits[i].tree, r.head().tree), nil
+}
+
+// head returns the latest commit, or nil before the first; the caller
+// holds mu.
+func (r *InMemoryRepository) head() *memCommit {
+	if len(r.commits) == 0 {
+		return nil
+	}
+	return &r.commits[len(r.commits)-1]
+}
+
+// worktree snapshots the current memories; the caller holds mu.
+func (r *InMemoryRepository) worktree() map[Key]string {
+	tree := make(map[Key]string, len(r.memories))
+	for key, mem := range r.memories {
+		tree[key] = string(mem.Content)
+	}
+	return tree
+}
+
+// resolve returns the index of the commit ref names; the caller holds mu.
+func (r *InMemoryRepository) resolve(ref string) (int, error) {
+	if len(r.commits) == 0 {
+		return 0, ErrNoCommits
+	}
+	head := len(r.commits) - 1
+	if ref == "HEAD" {
+		return head, nil
+	}
+	if n, ok := strings.CutPrefix(ref, "HEAD~"); ok {
+		back, err := strconv.Atoi(n)
+		if err != nil || back < 0 || back > head {
+			return 0, fmt.Errorf("resolve %s: %w", ref, ErrNotFound)
+		}
+		return head - back, nil
+	}
+	match := -1
+	for i, c := range r.commits {
+		if len(ref) >= 4 && strings.HasPrefix(c.commit.Hash, ref) {
+			if match >= 0 {
+				return 0, fmt.Errorf("resolve %s: ambiguous commit prefix", ref)
+			}
+			match = i
+		}
+	}
+	if match < 0 {
+		return 0, fmt.Errorf("resolve %s: %w", ref, ErrNotFound)
+	}
+	return match, nil
+}
+
+// treeChanges lists the files that differ from oldTree to newTree, sorted
+// by path.
+func treeChanges(oldTree, newTree map[Key]string) []fileChange {
+	var changes []fileChange
+	for key, content := range newTree {
+		old, ok := oldTree[key]
+		switch {
+		case !ok:
+			changes = append(changes, fileChange{Path: key.String(), Status: git.Added, New: content})
+		case old != content:
+			changes = append(changes, fileChange{Path: key.String(), Status: git.Modified, Old: old, New: content})
+		}
+	}
+	for key, content := range oldTree {
+		if _, ok := newTree[key]; !ok {
+			changes = append(changes, fileChange{Path: key.String(), Status: git.Deleted, Old: content})
+		}
+	}
+	slices.SortFunc(changes, func(a, b fileChange) int { return strings.Compare(a.Path, b.Path) })
+	return changes
+}
+
+func cloneCommit(c Commit) *Commit {
+	c.Parents = slices.Clone(c.Parents)
+	c.Refs = slices.Clone(c.Refs)
+	return &c
+}
@@ -0,0 +1,194 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// inMemoryUseCases wires the core use cases to one InMemoryRepository, the
+// way main wires them to a GitRepository.
+func inMemoryUseCases(repo *InMemoryRepository) *UseCases {
+	resolver := NewScopeResolver()
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	histFor := func(Scope) (HistoryRepository, error) { return repo, nil }
+	nilIndex := func(Scope) (VectorIndex, error) { return nil, ErrNoIndex }
+
+	return &UseCases{
+		SetMemory:     NewSetMemoryUseCase(resolver, repoFor, nilIndex, nil, nil),
+		GetMemory:     NewGetMemoryUseCase(resolver, repoFor),
+		DeleteMemory:  NewDeleteMemoryUseCase(resolver, repoFor, nilIndex),
+		ListMemories:  NewListMemoriesUseCase(resolver, repoFor),
+		AddMemory:     NewAddMemoryUseCase(resolver, repoFor, histFor, nilIndex, nil, nil),
+		Commit:        NewCommitUseCase(resolver, histFor),
+		Log:           NewLogUseCase(resolver, histFor),
+		Diff:          NewDiffUseCase(resolver, histFor),
+		Revert:        NewRevertUseCase(resolver, histFor),
+		KeywordSearch: NewKeywordSearchUseCase(resolver, repoFor),
+		Touch:         NewTouchUseCase(resolver, repoFor, histFor),
+	}
+}
+
+func TestInMemorySetGetListDelete(t *testing.T) {
+	uc := inMemoryUseCases(NewInMemoryRepository())
+	ctx := context.Background()
+
+	for key, content := range map[string]string{"notes/a": "alpha", "notes/b": "beta", "other": "gamma"} {
+		if err := uc.SetMemory.Execute(ctx, SetMemoryInput{Key: key, Content: content}); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+	}
+
+	got, err := uc.GetMemory.Execute(ctx, GetMemoryInput{Key: "notes/a"})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Content != "alpha" {
+		t.Errorf("content = %q", got.Content)
+	}
+
+	list, err := uc.ListMemories.Execute(ctx, ListMemoriesInput{Prefix: "notes"})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(list.Memories) != 2 || list.Memories[0].Key != "notes/a" || list.Memories[1].Key != "notes/b" {
+		t.Errorf("list notes = %+v", list.Memories)
+	}
+
+	if _, err := uc.DeleteMemory.Execute(ctx, DeleteMemoryInput{Key: "notes/a"}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := uc.GetMemory.Execute(ctx, GetMemoryInput{Key: "notes/a"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get deleted: want ErrNotFound, got %v", err)
+	}
+	if _, err := uc.DeleteMemory.Execute(ctx, DeleteMemoryInput{Key: "notes/a"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("delete twice: want ErrNotFound, got %v", err)
+	}
+
+	search, err := uc.KeywordSearch.Execute(ctx, SearchInput{Query: "gamma"})
+	if err != nil {
+		t.Fatalf("search: %v", err)
+	}
+	if len(search.Results) != 1 || search.Results[0].Key != "other" {
+		t.Errorf("search = %+v", search.Results)
+	}
+}
+
+func TestInMemoryHistory(t *testing.T) {
+	repo := NewInMemoryRepository()
+	uc := inMemoryUseCases(repo)
+	ctx := context.Background()
+
+	if _, err := uc.Log.Execute(ctx, LogInput{}); !errors.Is(err, ErrNoCommits) {
+		t.Errorf("log before commits: want ErrNoCommits, got %v", err)
+	}
+	if _, err := uc.Commit.Execute(ctx, CommitInput{Message: "empty"}); !errors.Is(err, ErrNothingToCommit) {
+		t.Errorf("commit empty store: want ErrNothingToCommit, got %v", err)
+	}
+
+	if err := uc.SetMemory.Execute(ctx, SetMemoryInput{Key: "k", Content: "one\n"}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	first, err := uc.Commit.Execute(ctx, CommitInput{Message: "set: k"})
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if _, err := uc.Commit.Execute(ctx, CommitInput{Message: "again"}); !errors.Is(err, ErrNothingToCommit) {
+		t.Errorf("commit clean tree: want ErrNothingToCommit, got %v", err)
+	}
+
+	added, err := uc.AddMemory.Execute(ctx, AddMemoryInput{Key: "k", Content: "two"})
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if added.Created {
+		t.Error("add to an existing key reported Created")
+	}
+	if head, err := repo.Show(ctx, "HEAD"); err != nil || len(head.Parents) != 1 || head.Parents[0] != first.Hash {
+		t.Errorf("HEAD after add = %+v (%v), want a child of %s", head, err, first.Hash)
+	}
+
+	diff, err := uc.Diff.Execute(ctx, DiffInput{Ref: first.Hash[:7]})
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if !strings.Contains(diff.Diff, "+++ b/k") || !strings.Contains(diff.Diff, "+two") {
+		t.Errorf("diff from first commit:\n%s", diff.Diff)
+	}
+
+	if _, err := uc.Touch.Execute(ctx, TouchInput{Key: "k"}); err != nil {
+		t.Fatalf("touch: %v", err)
+	}
+	log, err := uc.Log.Execute(ctx, LogInput{})
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	var messages []string
+	for _, c := range log.Commits {
+		messages = append(messages, c.Message)
+	}
+	if strings.Join(messages, ",") != "touch: k,"+added.Message+",set: k" {
+		t.Errorf("log = %v", messages)
+	}
+
+	reverted, err := uc.Revert.Execute(ctx, RevertInput{Ref: first.Hash})
+	if err != nil {
+		t.Fatalf("revert: %v", err)
+	}
+	if len(reverted.Discarded) != 2 {
+		t.Errorf("discarded = %+v", reverted.Discarded)
+	}
+	got, err := uc.GetMemory.Execute(ctx, GetMemoryInput{Key: "k"})
+	if err != nil {
+		t.Fatalf("get after revert: %v", err)
+	}
+	if got.Content != "one\n" {
+		t.Errorf("content after revert = %q", got.Content)
+	}
+	if commits, _ := repo.Log(ctx, 0); len(commits) != 1 {
+		t.Errorf("history after revert has %d commits, want 1", len(commits))
+	}
+}
+
+func TestInMemoryUncommittedDiff(t *testing.T) {
+	repo := NewInMemoryRepository()
+	ctx := context.Background()
+	key, _ := NewKey("k")
+
+	if err := repo.Save(ctx, NewMemory(key, []byte("old\n"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := repo.Commit(ctx, "set: k"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if err := repo.Save(ctx, NewMemory(key, []byte("new\n"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	diff, err := repo.Diff(ctx, "")
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if !strings.Contains(diff, "-old") || !strings.Contains(diff, "+new") {
+		t.Errorf("diff =\n%s", diff)
+	}
+	stats, err := repo.DiffStat(ctx, "")
+	if err != nil {
+		t.Fatalf("diff stat: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Added != 1 || stats[0].Removed != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	show, err := repo.Show(ctx, "HEAD")
+	if err != nil {
+		t.Fatalf("show: %v", err)
+	}
+	if !strings.Contains(show.Diff, "+old") {
+		t.Errorf("show diff =\n%s", show.Diff)
+	}
+	if _, err := repo.Show(ctx, "HEAD~5"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("show HEAD~5: want ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Operation names recorded by Metrics.IncOp.
+const (
+	OpSet    = "set"
+	OpGet    = "get"
+	OpDelete = "delete"
+	OpSearch = "search"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the latency histograms.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type metricsKey struct{}
+
+// Metrics is a minimal registry rendered in the Prometheus text format.
+// All methods are safe on a nil receiver, so instrumentation is a no-op
+// unless a registry was attached with WithMetrics.
+type Metrics struct {
+	mu         sync.Mutex
+	ops        map[string]uint64
+	embed      histogram
+	provider   histogram
+	indexItems int
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func NewMetrics() *Metrics {
+	return &Metrics{
+		ops:      make(map[string]uint64),
+		embed:    histogram{counts: make([]uint64, len(latencyBuckets))},
+		provider: histogram{counts: make([]uint64, len(latencyBuckets))},
+	}
+}
+
+// WithMetrics returns a context carrying the given registry.
+func WithMetrics(ctx context.Context, m *Metrics) context.Context {
+	return context.WithValue(ctx, metricsKey{}, m)
+}
+
+// MetricsFrom returns the registry stored in ctx, or nil if none.
+func MetricsFrom(ctx context.Context) *Metrics {
+	if ctx == nil {
+		return nil
+	}
+	m, _ := ctx.Value(metricsKey{}).(*Metrics)
+	return m
+}
+
+func (m *Metrics) IncOp(op string) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.ops[op]++
+}
+
+func (m *Metrics) ObserveEmbed(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.embed.observe(d.Seconds())
+}
+
+func (m *Metrics) ObserveProvider(d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.provider.observe(d.Seconds())
+}
+
+func (m *Metrics) SetIndexItems(n int) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.indexItems = n
+}
+
+// ServeHTTP exposes the registry, typically mounted at /metrics.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.Write(w)
+}
+
+// Write renders all metrics in the Prometheus text exposition format.
+func (m *Metrics) Write(w io.Writer) {
+	if m == nil {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP mem_operations_total Memory operations by type.")
+	fmt.Fprintln(w, "# TYPE mem_operations_total counter")
+	ops := make([]string, 0, len(m.ops))
+	for op := range m.ops {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	for _, op := range ops {
+		fmt.Fprintf(w, "mem_operations_total{op=%q} %d\n", op, m.ops[op])
+	}
+
+	m.embed.write(w, "mem_embed_duration_seconds", "Time spent computing embeddings.")
+	m.provider.write(w, "mem_provider_duration_seconds", "Time spent in LLM provider calls.")
+
+	fmt.Fprintln(w, "# HELP mem_index_items Items in the vector index.")
+	fmt.Fprintln(w, "# TYPE mem_index_items gauge")
+	fmt.Fprintf(w, "mem_index_items %d\n", m.indexItems)
+}
+
+func (h *histogram) observe(v float64) {
+	for i, upper := range latencyBuckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+func (h *histogram) write(w io.Writer, name, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s histogram\n", name)
+	for i, upper := range latencyBuckets {
+		fmt.Fprintf(w, "%s_bucket{le=%q} %d\n", name, strconv.FormatFloat(upper, 'g', -1, 64), h.counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", name, h.count)
+	fmt.Fprintf(w, "%s_sum %g\n", name, h.sum)
+	fmt.Fprintf(w, "%s_count %d\n", name, h.count)
+}
+
+// embed runs e.Embed and records its latency.
+func embed(ctx context.Context, e Embedder, text string) ([]float32, error) {
+	start := time.Now()
+	vec, err := e.Embed(ctx, text)
+	MetricsFrom(ctx).ObserveEmbed(time.Since(start))
+	return vec, err
+}
+
+// generateObject runs p.GenerateObject and records its latency.
+func generateObject(ctx context.Context, p Provider, prompt string, target any) error {
+	start := time.Now()
+	err := p.GenerateObject(ctx, prompt, target)
+	MetricsFrom(ctx).ObserveProvider(time.Since(start))
+	return err
+}
+
+// recordIndexSize updates the index gauge when the index can report its size.
+func recordIndexSize(ctx context.Context, index VectorIndex) {
+	if sized, ok := index.(interface{ Len() int }); ok {
+		MetricsFrom(ctx).SetIndexItems(sized.Len())
+	}
+}
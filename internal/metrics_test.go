@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func scrape(t *testing.T, url string) string {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("get metrics: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read metrics: %v", err)
+	}
+	return string(body)
+}
+
+func TestMetricsEndpointCountsSet(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+
+	metrics := NewMetrics()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	if body := scrape(t, srv.URL+"/metrics"); strings.Contains(body, `mem_operations_total{op="set"}`) {
+		t.Fatalf("set counter present before any set:\n%s", body)
+	}
+
+	ctx := WithMetrics(context.Background(), metrics)
+	repoFor := func(s Scope) (MemoryRepository, error) { return repo, nil }
+	setUC := NewSetMemoryUseCase(resolver, repoFor, nil, nil, nil)
+	if err := setUC.Execute(ctx, SetMemoryInput{Key: "a/b", Content: "x"}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	body := scrape(t, srv.URL+"/metrics")
+	if !strings.Contains(body, `mem_operations_total{op="set"} 1`) {
+		t.Errorf("set counter not incremented:\n%s", body)
+	}
+	if !strings.Contains(body, "# TYPE mem_embed_duration_seconds histogram") {
+		t.Errorf("embed histogram missing:\n%s", body)
+	}
+}
+
+func TestMetricsHistogramBuckets(t *testing.T) {
+	m := NewMetrics()
+	m.ObserveProvider(30 * time.Millisecond)
+	m.SetIndexItems(7)
+
+	var sb strings.Builder
+	m.Write(&sb)
+	out := sb.String()
+
+	for _, want := range []string{
+		`mem_provider_duration_seconds_bucket{le="0.025"} 0`,
+		`mem_provider_duration_seconds_bucket{le="0.05"} 1`,
+		`mem_provider_duration_seconds_bucket{le="+Inf"} 1`,
+		"mem_provider_duration_seconds_count 1",
+		"mem_index_items 7",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestMetricsNilIsNoop(t *testing.T) {
+	var m *Metrics
+	m.IncOp(OpGet)
+	m.ObserveEmbed(time.Second)
+	m.SetIndexItems(1)
+
+	if MetricsFrom(context.Background()) != nil {
+		t.Error("MetricsFrom without registry should be nil")
+	}
+}
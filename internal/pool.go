@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var _ Embedder = (*EmbedderPool)(nil)
+
+// EmbedderPool spreads embedding across several embedders, each used by one
+// caller at a time, so a server can embed concurrent requests in parallel.
+type EmbedderPool struct {
+	members []Embedder
+	free    chan Embedder
+}
+
+// NewEmbedderPool pools members, which must embed with the same model.
+// The first member answers Dimension, Device and Model and is closed last.
+func NewEmbedderPool(members ...Embedder) *EmbedderPool {
+	free := make(chan Embedder, len(members))
+	for _, m := range members {
+		free <- m
+	}
+	return &EmbedderPool{members: members, free: free}
+}
+
+// Size returns the number of embedders in the pool.
+func (p *EmbedderPool) Size() int {
+	return len(p.members)
+}
+
+func (p *EmbedderPool) Embed(ctx context.Context, text string) ([]float32, error) {
+	var member Embedder
+	select {
+	case member = <-p.free:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { p.free <- member }()
+
+	return member.Embed(ctx, text)
+}
+
+// EmbedBatch embeds texts in parallel on as many members as are free.
+func (p *EmbedderPool) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	results := make([][]float32, len(texts))
+	errs := make([]error, len(texts))
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, len(p.members))
+	for i, text := range texts {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			emb, err := p.Embed(ctx, text)
+			if err != nil {
+				errs[i] = fmt.Errorf("embed text %d: %w", i, err)
+				return
+			}
+			results[i] = emb
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (p *EmbedderPool) Dimension() int {
+	return p.members[0].Dimension()
+}
+
+func (p *EmbedderPool) Device() string {
+	return p.members[0].Device()
+}
+
+func (p *EmbedderPool) Model() string {
+	return p.members[0].Model()
+}
+
+// Close closes the members in reverse, so clones go before the embedder
+// that owns the model.
+func (p *EmbedderPool) Close() error {
+	var errs []error
+	for i := len(p.members) - 1; i >= 0; i-- {
+		if err := p.members[i].Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// exclusiveEmbedder fails if two callers use it at once, as a llama
+// context would misbehave, and embeds a text as its length.
+type exclusiveEmbedder struct {
+	constEmbedder
+	busy   atomic.Bool
+	calls  atomic.Int32
+	closed bool
+}
+
+func (e *exclusiveEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	if !e.busy.CompareAndSwap(false, true) {
+		return nil, errors.New("embedder used concurrently")
+	}
+	defer e.busy.Store(false)
+	e.calls.Add(1)
+	time.Sleep(time.Millisecond)
+	return []float32{float32(len(text)), 0, 0}, nil
+}
+
+func (e *exclusiveEmbedder) Close() error {
+	e.closed = true
+	return nil
+}
+
+func TestEmbedderPoolParallel(t *testing.T) {
+	members := []*exclusiveEmbedder{{}, {}, {}, {}}
+	pool := NewEmbedderPool(members[0], members[1], members[2], members[3])
+	ctx := context.Background()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := range 64 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			text := fmt.Sprintf("%*d", i+1, i)
+			vec, err := pool.Embed(ctx, text)
+			if err != nil {
+				errs <- err
+				return
+			}
+			if int(vec[0]) != len(text) {
+				errs <- fmt.Errorf("embed %q = %v", text, vec)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+
+	for i, m := range members {
+		if m.calls.Load() == 0 {
+			t.Errorf("member %d was never used", i)
+		}
+	}
+
+	texts := []string{"a", "bb", "ccc", "dddd", "eeeee"}
+	vecs, err := pool.EmbedBatch(ctx, texts)
+	if err != nil {
+		t.Fatalf("embed batch: %v", err)
+	}
+	for i, vec := range vecs {
+		if int(vec[0]) != len(texts[i]) {
+			t.Errorf("batch result %d = %v, want length of %q", i, vec, texts[i])
+		}
+	}
+
+	if err := pool.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	for i, m := range members {
+		if !m.closed {
+			t.Errorf("member %d not closed", i)
+		}
+	}
+}
+
+func TestEmbedderPoolCancelledWhileBusy(t *testing.T) {
+	member := &exclusiveEmbedder{}
+	pool := NewEmbedderPool(member)
+
+	// Hold the only member so the next call has to wait.
+	held := <-pool.free
+	defer func() { pool.free <- held }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := pool.Embed(ctx, "text"); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
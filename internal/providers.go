@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+)
+
+var (
+	ErrNoProvider = errors.New("no provider configured")
+	// ErrProviderUnavailable wraps failures to reach a configured
+	// provider: bad credentials, network errors or an unknown model.
+	ErrProviderUnavailable = errors.New("provider unavailable")
+)
+
+// Tasks that can be given their own provider and model under models: in the
+// config.
+const (
+	TaskSummarize     = "summarize"
+	TaskAutoTag       = "autotag"
+	TaskHookSummarize = "hook_summarize"
+	TaskHookExtract   = "hook_extract"
+	TaskAsk           = "ask"
+	TaskCompact       = "compact"
+)
+
+var knownTasks = []string{TaskAsk, TaskAutoTag, TaskCompact, TaskHookExtract, TaskHookSummarize, TaskSummarize}
+
+// TaskModelConfig picks the provider and model for one task. Empty fields
+// fall back to default_provider and the provider's own model.
+type TaskModelConfig struct {
+	Provider string `yaml:"provider,omitempty"`
+	Model    string `yaml:"model,omitempty"`
+}
+
+// ProviderOverride replaces the configured provider or model for a single
+// run, e.g. from --provider and --model.
+type ProviderOverride struct {
+	Provider string
+	Model    string
+}
+
+// ProviderFunc returns the provider to use for task in scope.
+type ProviderFunc func(ctx context.Context, scope Scope, task string, override ProviderOverride) (Provider, error)
+
+// StaticProvider returns a ProviderFunc that always yields p, for callers
+// that build their provider themselves.
+func StaticProvider(p Provider) ProviderFunc {
+	return func(context.Context, Scope, string, ProviderOverride) (Provider, error) {
+		if p == nil {
+			return nil, ErrNoProvider
+		}
+		return p, nil
+	}
+}
+
+// unknownTasks returns the task names under models: that no use case reads.
+func (c *Config) unknownTasks() []string {
+	var unknown []string
+	for task := range c.Models {
+		if !slices.Contains(knownTasks, task) {
+			unknown = append(unknown, task)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+type providerCacheKey struct {
+	config   string // config path, so scopes never share credentials
+	provider string
+	model    string
+}
+
+// ProviderFactory builds providers on first use and keeps one per
+// provider and model, so tasks sharing a model share a client.
+type ProviderFactory struct {
+	mu        sync.Mutex
+	providers map[providerCacheKey]Provider
+	construct func(context.Context, FantasyConfig) (Provider, error)
+}
+
+func NewProviderFactory() *ProviderFactory {
+	return &ProviderFactory{
+		providers: make(map[providerCacheKey]Provider),
+		construct: func(ctx context.Context, cfg FantasyConfig) (Provider, error) {
+			return NewFantasyProvider(ctx, cfg)
+		},
+	}
+}
+
+// For resolves the provider for task: override first, then the task's
+// models: entry, then default_provider and the provider's model. Calls
+// through the returned provider are logged to the scope's usage.jsonl.
+func (f *ProviderFactory) For(ctx context.Context, scope Scope, task string, override ProviderOverride) (Provider, error) {
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		return nil, err
+	}
+	for _, unknown := range cfg.unknownTasks() {
+		LoggerFrom(ctx).Warn("ignoring unknown task under models", "task", unknown, "config", scope.ConfigPath(), "known", knownTasks)
+	}
+
+	taskCfg := cfg.Models[task]
+	name := cmp.Or(override.Provider, taskCfg.Provider, cfg.DefaultProvider)
+	if name == "" {
+		return nil, fmt.Errorf("%w for %s; run `mem provider add` and `mem provider default`", ErrNoProvider, task)
+	}
+	providerCfg, ok := cfg.Providers[name]
+	if !ok {
+		return nil, fmt.Errorf("provider %q not found; run `mem provider add %s` or pick another with `mem provider default`", name, name)
+	}
+
+	fantasyCfg := NewFantasyConfig(name, providerCfg)
+	fantasyCfg.Model = cmp.Or(override.Model, taskCfg.Model, providerCfg.Model)
+
+	key := providerCacheKey{config: scope.ConfigPath(), provider: name, model: fantasyCfg.Model}
+
+	p, err := f.provider(ctx, key, fantasyCfg)
+	if err != nil {
+		return nil, err
+	}
+	return &meteredProvider{
+		Provider: p,
+		path:     scope.UsagePath(),
+		provider: name,
+		model:    fantasyCfg.Model,
+		task:     task,
+	}, nil
+}
+
+// provider returns the cached provider for key, building it on first use.
+func (f *ProviderFactory) provider(ctx context.Context, key providerCacheKey, cfg FantasyConfig) (Provider, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if p, ok := f.providers[key]; ok {
+		return p, nil
+	}
+	p, err := f.construct(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("create provider %s: %w", key.provider, err)
+	}
+	f.providers[key] = p
+	return p, nil
+}
@@ -0,0 +1,195 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// namedProvider records the config it was built from.
+type namedProvider struct {
+	NoModels
+	cfg FantasyConfig
+}
+
+func (p *namedProvider) Complete(context.Context, string) (string, error)      { return "", nil }
+func (p *namedProvider) GenerateObject(context.Context, string, any) error     { return nil }
+func (p *namedProvider) Stream(context.Context, string) (<-chan string, error) { return nil, nil }
+
+func TestProviderFactoryResolvesPerTask(t *testing.T) {
+	_, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	scope := resolver.Resolve("")
+
+	cfg := DefaultConfig()
+	cfg.Providers["openrouter"] = ProviderConfig{Type: "openrouter", Model: "big"}
+	cfg.Providers["local"] = ProviderConfig{Type: "openai", Model: "local-default"}
+	cfg.DefaultProvider = "openrouter"
+	cfg.Models = map[string]TaskModelConfig{
+		TaskHookSummarize: {Provider: "local", Model: "tiny"},
+		TaskAutoTag:       {Model: "medium"},
+	}
+	if err := SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	built := 0
+	factory := NewProviderFactory()
+	factory.construct = func(_ context.Context, cfg FantasyConfig) (Provider, error) {
+		built++
+		return &namedProvider{cfg: cfg}, nil
+	}
+
+	tests := []struct {
+		task              string
+		override          ProviderOverride
+		wantType, wantMod string
+	}{
+		{TaskSummarize, ProviderOverride{}, "openrouter", "big"},
+		{TaskHookSummarize, ProviderOverride{}, "openai", "tiny"},
+		{TaskAutoTag, ProviderOverride{}, "openrouter", "medium"},
+		{TaskSummarize, ProviderOverride{Provider: "local"}, "openai", "local-default"},
+		{TaskSummarize, ProviderOverride{Model: "huge"}, "openrouter", "huge"},
+	}
+	for _, tt := range tests {
+		p, err := factory.For(ctx, scope, tt.task, tt.override)
+		if err != nil {
+			t.Fatalf("%s %+v: %v", tt.task, tt.override, err)
+		}
+		got := p.(*meteredProvider).Provider.(*namedProvider).cfg
+		if got.Provider != tt.wantType || got.Model != tt.wantMod {
+			t.Errorf("%s %+v: got %s/%s, want %s/%s", tt.task, tt.override, got.Provider, got.Model, tt.wantType, tt.wantMod)
+		}
+	}
+
+	// Asking again for a provider and model already built reuses it.
+	if _, err := factory.For(ctx, scope, TaskSummarize, ProviderOverride{}); err != nil {
+		t.Fatalf("for: %v", err)
+	}
+	if built != len(tests) {
+		t.Errorf("built %d providers, want %d (one per provider/model pair)", built, len(tests))
+	}
+
+	if _, err := factory.For(ctx, scope, TaskSummarize, ProviderOverride{Provider: "missing"}); err == nil {
+		t.Error("expected error for unknown provider")
+	}
+}
+
+func TestProviderFactoryNoProvider(t *testing.T) {
+	_, resolver := setupUseCaseTest(t)
+
+	_, err := NewProviderFactory().For(context.Background(), resolver.Resolve(""), TaskSummarize, ProviderOverride{})
+	if !errors.Is(err, ErrNoProvider) {
+		t.Errorf("expected ErrNoProvider, got %v", err)
+	}
+}
+
+// factsProvider answers GenerateObject with fixed summary and tags.
+type factsProvider struct{ namedProvider }
+
+func (p *factsProvider) GenerateObject(_ context.Context, _ string, target any) error {
+	switch v := target.(type) {
+	case *Summary:
+		*v = Summary{Title: "Deploys", Overview: "How we ship.", KeyPoints: []string{"blue/green"}, Tags: []string{"ops"}}
+	case *AutoTag:
+		*v = AutoTag{Tags: []string{"ops", "deploy"}, Category: "runbook", Confidence: 0.9}
+	}
+	return nil
+}
+
+func TestSummarizeAndAutoTagWithDefaultProvider(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	scope := resolver.Resolve("")
+
+	if err := repo.Save(ctx, NewMemory("ops/deploy", []byte("blue/green deploys via the pipeline"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	cfg := DefaultConfig()
+	cfg.Providers["local"] = ProviderConfig{Type: "openai", Model: "small"}
+	cfg.DefaultProvider = "local"
+	if err := SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	factory := NewProviderFactory()
+	factory.construct = func(_ context.Context, cfg FantasyConfig) (Provider, error) {
+		return &factsProvider{namedProvider{cfg: cfg}}, nil
+	}
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+
+	summary, err := NewSummarizeUseCase(resolver, repoFor, factory.For).Execute(ctx, SummarizeInput{Prefix: "ops"})
+	if err != nil {
+		t.Fatalf("summarize: %v", err)
+	}
+	if summary.Title != "Deploys" || !reflect.DeepEqual(summary.Sources, []string{"ops/deploy"}) {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	tags, err := NewAutoTagUseCase(resolver, repoFor, factory.For).Execute(ctx, AutoTagInput{Key: "ops/deploy"})
+	if err != nil {
+		t.Fatalf("autotag: %v", err)
+	}
+	if tags.Category != "runbook" || !reflect.DeepEqual(tags.Tags, []string{"ops", "deploy"}) {
+		t.Errorf("tags = %+v", tags)
+	}
+
+	records, err := ReadUsage(scope.UsagePath(), time.Time{})
+	if err != nil {
+		t.Fatalf("read usage: %v", err)
+	}
+	if len(records) != 2 || records[0].Task != TaskSummarize || records[1].Task != TaskAutoTag || records[0].Provider != "local" {
+		t.Errorf("usage = %+v", records)
+	}
+
+	// A default that names a removed provider says how to fix it.
+	cfg.DefaultProvider = "gone"
+	if err := SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	_, err = NewSummarizeUseCase(resolver, repoFor, factory.For).Execute(ctx, SummarizeInput{})
+	if err == nil || !strings.Contains(err.Error(), "mem provider add gone") {
+		t.Errorf("dangling default_provider: %v", err)
+	}
+}
+
+func TestProviderFactoryWarnsOnUnknownTasks(t *testing.T) {
+	_, resolver := setupUseCaseTest(t)
+	scope := resolver.Resolve("")
+
+	cfg := DefaultConfig()
+	cfg.Providers["local"] = ProviderConfig{Type: "openai", Model: "m"}
+	cfg.DefaultProvider = "local"
+	cfg.Models = map[string]TaskModelConfig{"sumarize": {Model: "b"}}
+	if err := SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	var buf bytes.Buffer
+	ctx := WithLogger(context.Background(), NewLogger(&buf, false, false))
+	factory := NewProviderFactory()
+	factory.construct = func(_ context.Context, cfg FantasyConfig) (Provider, error) {
+		return &namedProvider{cfg: cfg}, nil
+	}
+	if _, err := factory.For(ctx, scope, TaskSummarize, ProviderOverride{}); err != nil {
+		t.Fatalf("for: %v", err)
+	}
+	if !strings.Contains(buf.String(), "sumarize") {
+		t.Errorf("log = %q, want a warning through the context logger naming the unknown task", buf.String())
+	}
+}
+
+func TestConfigUnknownTasks(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Models = map[string]TaskModelConfig{
+		TaskSummarize: {Model: "a"},
+		"sumarize":    {Model: "b"},
+		"chat":        {Model: "c"},
+	}
+	if got, want := cfg.unknownTasks(), []string{"chat", "sumarize"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("unknownTasks() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+)
+
+var ErrReadOnly = errors.New("store is read-only")
+
+type readOnlyKey struct{}
+
+// WithReadOnly returns a context in which every mutating use case fails
+// with ErrReadOnly, whatever the store itself allows.
+func WithReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+// ReadOnlyFrom reports whether ctx was marked read-only.
+func ReadOnlyFrom(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	readOnly, _ := ctx.Value(readOnlyKey{}).(bool)
+	return readOnly
+}
+
+// checkWritable fails with ErrReadOnly when ctx is read-only, the scope's
+// config sets storage.read_only, or repo reports that its files cannot be
+// written. Mutating use cases call it before touching the worktree.
+func checkWritable(ctx context.Context, scope Scope, repo any) error {
+	if ReadOnlyFrom(ctx) {
+		return fmt.Errorf("%w: --readonly is set", ErrReadOnly)
+	}
+	if cfg, err := LoadConfig(scope); err == nil && cfg.Storage.ReadOnly {
+		return fmt.Errorf("%w: storage.read_only is set in %s", ErrReadOnly, scope.ConfigPath())
+	}
+	if ro, ok := repo.(interface{ ReadOnly() bool }); ok && ro.ReadOnly() {
+		return fmt.Errorf("%w: %s is not writable", ErrReadOnly, scope.MemPath)
+	}
+	return nil
+}
+
+// writable reports whether files can be created in dir.
+func writable(dir string) bool {
+	f, err := os.CreateTemp(dir, ".mem-write-check-*")
+	if err != nil {
+		return false
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+	return true
+}
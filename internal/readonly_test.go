@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadOnlyConfigBlocksWrites(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	histFor := func(Scope) (HistoryRepository, error) { return repo, nil }
+
+	setUC := NewSetMemoryUseCase(resolver, repoFor, nil, nil, nil)
+	if err := setUC.Execute(ctx, SetMemoryInput{Key: "notes/a", Content: "before"}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if _, err := repo.Commit(ctx, "add notes/a"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.Storage.ReadOnly = true
+	if err := SaveConfig(resolver.Resolve(""), cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	if err := setUC.Execute(ctx, SetMemoryInput{Key: "notes/a", Content: "after"}); !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("set: expected ErrReadOnly, got %v", err)
+	}
+	if _, err := NewCommitUseCase(resolver, histFor).Execute(ctx, CommitInput{Message: "x"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("commit: expected ErrReadOnly, got %v", err)
+	}
+	deleteUC := NewDeleteMemoryUseCase(resolver, repoFor, nil)
+	if _, err := deleteUC.Execute(ctx, DeleteMemoryInput{Key: "notes/a"}); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("delete: expected ErrReadOnly, got %v", err)
+	}
+	if _, err := deleteUC.Execute(ctx, DeleteMemoryInput{Key: "notes/a", DryRun: true}); err != nil {
+		t.Errorf("dry-run delete should still work: %v", err)
+	}
+
+	out, err := NewGetMemoryUseCase(resolver, repoFor).Execute(ctx, GetMemoryInput{Key: "notes/a"})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if out.Content != "before" {
+		t.Errorf("content = %q, want %q", out.Content, "before")
+	}
+}
+
+func TestReadOnlyContextBlocksWrites(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := WithReadOnly(context.Background())
+	branchFor := func(Scope) (BranchRepository, error) { return repo, nil }
+
+	if !ReadOnlyFrom(ctx) || ReadOnlyFrom(context.Background()) {
+		t.Fatal("ReadOnlyFrom does not reflect WithReadOnly")
+	}
+
+	_, err := NewBranchCreateUseCase(resolver, branchFor).Execute(ctx, BranchInput{Name: "feature"})
+	if !errors.Is(err, ErrReadOnly) {
+		t.Fatalf("branch create: expected ErrReadOnly, got %v", err)
+	}
+	if _, err := NewBranchListUseCase(resolver, branchFor).Execute(ctx, BranchInput{}); err != nil {
+		t.Errorf("branch list should still work: %v", err)
+	}
+}
+
+func TestWritable(t *testing.T) {
+	dir := t.TempDir()
+	if !writable(dir) {
+		t.Errorf("%s should be writable", dir)
+	}
+	if writable(filepath.Join(dir, "missing")) {
+		t.Error("a missing directory should not be writable")
+	}
+}
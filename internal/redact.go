@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// ErrRedactionPattern means a redaction pattern in the config does not
+// compile. Content is not sent rather than sent unredacted.
+var ErrRedactionPattern = errors.New("invalid redaction pattern")
+
+// RedactionConfig configures what is masked in memory content and diffs
+// before they are sent to an LLM provider.
+type RedactionConfig struct {
+	// Disabled sends content as is, as if every run passed --no-redact.
+	Disabled bool `yaml:"disabled,omitempty"`
+	// NoBuiltins drops the built-in patterns for common API keys, AWS
+	// keys, JWTs and private keys, leaving only Patterns.
+	NoBuiltins bool `yaml:"no_builtins,omitempty"`
+	// Patterns are extra regular expressions whose matches are replaced
+	// with [REDACTED:<label>].
+	Patterns []RedactionPattern `yaml:"patterns,omitempty"`
+}
+
+// RedactionPattern is a regular expression in RE2 syntax and the label
+// its matches are replaced with. Label defaults to "custom".
+type RedactionPattern struct {
+	Label string `yaml:"label,omitempty"`
+	Regex string `yaml:"regex"`
+}
+
+// builtinRedactions are applied unless the config sets no_builtins. They
+// match the shape of the secret, not the name it is assigned to.
+var builtinRedactions = []RedactionPattern{
+	{Label: "private_key", Regex: `-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`},
+	{Label: "aws_access_key", Regex: `\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`},
+	{Label: "aws_secret_key", Regex: `(?i)\baws_?secret_?access_?key\b["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`},
+	{Label: "jwt", Regex: `\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`},
+	{Label: "github_token", Regex: `\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`},
+	{Label: "slack_token", Regex: `\bxox[abposr]-[A-Za-z0-9-]{10,}`},
+	{Label: "google_api_key", Regex: `\bAIza[0-9A-Za-z_-]{35}`},
+	{Label: "api_key", Regex: `\bsk-(?:ant-|proj-)?[A-Za-z0-9_-]{20,}`},
+}
+
+type redactRule struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// Redactor masks secrets in text bound for a provider. A nil Redactor
+// leaves text unchanged.
+type Redactor struct {
+	rules []redactRule
+}
+
+// NewRedactor compiles the built-in patterns, unless cfg drops them, and
+// cfg's own patterns.
+func NewRedactor(cfg RedactionConfig) (*Redactor, error) {
+	var patterns []RedactionPattern
+	if !cfg.NoBuiltins {
+		patterns = append(patterns, builtinRedactions...)
+	}
+	patterns = append(patterns, cfg.Patterns...)
+
+	r := &Redactor{rules: make([]redactRule, 0, len(patterns))}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %v", ErrRedactionPattern, p.Regex, err)
+		}
+		r.rules = append(r.rules, redactRule{
+			re:          re,
+			replacement: "[REDACTED:" + cmp.Or(p.Label, "custom") + "]",
+		})
+	}
+	return r, nil
+}
+
+// Redact replaces every match of the redactor's patterns in s with
+// [REDACTED:<label>], trying the patterns in order.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, rule := range r.rules {
+		s = rule.re.ReplaceAllLiteralString(s, rule.replacement)
+	}
+	return s
+}
+
+// redactorFor returns the redactor for prompts under cfg, or nil when
+// redaction is turned off in cfg or for this run by noRedact.
+func redactorFor(cfg RedactionConfig, noRedact bool) (*Redactor, error) {
+	if cfg.Disabled || noRedact {
+		return nil, nil
+	}
+	return NewRedactor(cfg)
+}
+
+// promptRedactor returns the redactor for prompts built from scope's
+// memories, configured by the scope's redaction section.
+func promptRedactor(scope Scope, noRedact bool) (*Redactor, error) {
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		return nil, err
+	}
+	return redactorFor(cfg.Redaction, noRedact)
+}
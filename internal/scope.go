@@ -1,59 +1,230 @@
 package internal
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 )
 
+var (
+	ErrUnknownScope   = errors.New("unknown scope")
+	ErrNotInitialized = errors.New("store not initialized; run mem init")
+)
+
+// NotInitializedError reports a scope whose .mem directory does not exist.
+// It matches ErrNotInitialized with errors.Is.
+type NotInitializedError struct {
+	Path string
+}
+
+func (e *NotInitializedError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrNotInitialized, e.Path)
+}
+
+func (e *NotInitializedError) Unwrap() error {
+	return ErrNotInitialized
+}
+
 type ScopeType string
 
 const (
 	ScopeGlobal  ScopeType = "global"
 	ScopeProject ScopeType = "project"
+	ScopeCustom  ScopeType = "custom"
 )
 
 type Scope struct {
 	Type    ScopeType
+	Name    string // name from the global config's scopes map; custom scopes only
 	Path    string // working directory root
 	MemPath string // .mem directory path
+
+	configPath string // overrides MemPath/config.yaml; see ScopeResolver.Global
 }
 
 func (s Scope) VectorPath() string {
 	return filepath.Join(s.MemPath, "vectors")
 }
 
+// BranchVectorPath is where the vector index for branch lives.
+func (s Scope) BranchVectorPath(branch string) string {
+	return filepath.Join(s.VectorPath(), "branches", branch)
+}
+
 func (s Scope) ConfigPath() string {
+	if s.configPath != "" {
+		return s.configPath
+	}
 	return filepath.Join(s.MemPath, "config.yaml")
 }
 
+func (s Scope) TemplatePath() string {
+	return filepath.Join(s.MemPath, "templates")
+}
+
+func (s Scope) UsagePath() string {
+	return filepath.Join(s.MemPath, UsageFilename)
+}
+
+func (s Scope) HookRatePath() string {
+	return filepath.Join(s.MemPath, HookRateFilename)
+}
+
+func (s Scope) PendingIndexPath() string {
+	return filepath.Join(s.MemPath, PendingIndexFilename)
+}
+
 type ScopeResolver struct {
 	homeDir string
+	memHome string // overrides ~/.mem as the global store when set
+	workDir string // where the project lookup starts; the process's if empty
+	// dataHome and configHome are $XDG_DATA_HOME and $XDG_CONFIG_HOME.
+	dataHome   string
+	configHome string
+	// maxDepth bounds how many parent directories Project searches for a
+	// .mem; 0 searches up to the filesystem root.
+	maxDepth int
 }
 
+// NewScopeResolver resolves scopes for the process: from its working
+// directory, the user's home directory, $MEM_HOME, $MEM_MAX_DEPTH and the
+// XDG base directories.
 func NewScopeResolver() *ScopeResolver {
-	home, _ := os.UserHomeDir()
-	return &ScopeResolver{homeDir: home}
+	return NewScopeResolverAt("", "")
+}
+
+// NewScopeResolverAt resolves scopes as seen from cwd, with home as the
+// home directory: the project store is looked up from cwd, relative paths
+// resolve against it, and the global store is home/.mem. An explicit home
+// also ignores $MEM_HOME, $MEM_MAX_DEPTH and the XDG variables, so resolvers for different directories can be
+// used side by side, e.g. by a server or by parallel tests. Empty
+// arguments fall back to the process's working directory and to the
+// user's home directory, $MEM_HOME and the XDG base directories.
+func NewScopeResolverAt(cwd, home string) *ScopeResolver {
+	r := &ScopeResolver{homeDir: home, workDir: cwd}
+	if home == "" {
+		r.homeDir, _ = os.UserHomeDir()
+		r.memHome = os.Getenv("MEM_HOME")
+		r.dataHome = xdgDir("XDG_DATA_HOME")
+		r.configHome = xdgDir("XDG_CONFIG_HOME")
+		if depth, err := strconv.Atoi(os.Getenv("MEM_MAX_DEPTH")); err == nil {
+			r.SetMaxDepth(depth)
+		}
+	}
+	return r
+}
+
+// MaxDepth returns how many parent directories Project searches for a
+// project store, 0 meaning all of them.
+func (r *ScopeResolver) MaxDepth() int {
+	return r.maxDepth
+}
+
+// SetMaxDepth limits Project to the working directory and depth of its
+// parents, so a store far up a deeply nested tree is not picked up by
+// accident. 0, or a negative depth, lifts the limit.
+func (r *ScopeResolver) SetMaxDepth(depth int) {
+	r.maxDepth = max(depth, 0)
+}
+
+// xdgDir returns the XDG base directory in env, ignoring relative paths
+// as the specification asks.
+func xdgDir(env string) string {
+	dir := os.Getenv(env)
+	if !filepath.IsAbs(dir) {
+		return ""
+	}
+	return filepath.Clean(dir)
+}
+
+// WorkDir returns the directory the resolver looks up the project from:
+// its cwd, or the process's working directory if it has none.
+func (r *ScopeResolver) WorkDir() (string, error) {
+	if r.workDir != "" {
+		return r.workDir, nil
+	}
+	return os.Getwd()
+}
+
+// abs makes path absolute against the resolver's working directory.
+func (r *ScopeResolver) abs(path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	dir, err := r.WorkDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(dir, path)
 }
 
+// Global returns the global scope. It is stored in $MEM_HOME if set;
+// otherwise in $XDG_DATA_HOME/mem if that is set and no legacy ~/.mem
+// exists, and in ~/.mem if not. With $XDG_CONFIG_HOME set, its config is
+// $XDG_CONFIG_HOME/mem/config.yaml unless the store already has one.
 func (r *ScopeResolver) Global() Scope {
-	memPath := filepath.Join(r.homeDir, ".mem")
-	return Scope{
+	if r.memHome != "" {
+		memHome := r.abs(r.memHome)
+		return Scope{
+			Type:    ScopeGlobal,
+			Path:    filepath.Dir(memHome),
+			MemPath: memHome,
+		}
+	}
+
+	scope := Scope{
 		Type:    ScopeGlobal,
 		Path:    r.homeDir,
-		MemPath: memPath,
+		MemPath: filepath.Join(r.homeDir, ".mem"),
+	}
+	if _, legacy := r.LegacyGlobal(); r.dataHome != "" && !legacy {
+		scope.Path = r.dataHome
+		scope.MemPath = filepath.Join(r.dataHome, "mem")
+	}
+	if r.configHome != "" {
+		if _, err := os.Stat(filepath.Join(scope.MemPath, "config.yaml")); os.IsNotExist(err) {
+			scope.configPath = filepath.Join(r.configHome, "mem", "config.yaml")
+		}
 	}
+	return scope
 }
 
+// LegacyGlobal returns ~/.mem and reports whether Global uses it although
+// $XDG_DATA_HOME is set, i.e. whether the store could move to
+// $XDG_DATA_HOME/mem.
+func (r *ScopeResolver) LegacyGlobal() (string, bool) {
+	legacy := filepath.Join(r.homeDir, ".mem")
+	if r.memHome != "" || r.dataHome == "" {
+		return legacy, false
+	}
+	info, err := os.Stat(legacy)
+	return legacy, err == nil && info.IsDir()
+}
+
+// XDGGlobal returns $XDG_DATA_HOME/mem, or "" if $XDG_DATA_HOME is unset.
+func (r *ScopeResolver) XDGGlobal() string {
+	if r.dataHome == "" {
+		return ""
+	}
+	return filepath.Join(r.dataHome, "mem")
+}
+
+// Project finds the project store: the nearest .mem in the working
+// directory or its parents, up to MaxDepth of them.
 func (r *ScopeResolver) Project() (Scope, bool) {
-	cwd, err := os.Getwd()
+	dir, err := r.WorkDir()
 	if err != nil {
 		return Scope{}, false
 	}
-	return r.findProjectScope(cwd)
+	return r.findProjectScope(dir)
 }
 
 func (r *ScopeResolver) findProjectScope(dir string) (Scope, bool) {
-	for {
+	for depth := 0; ; depth++ {
 		memPath := filepath.Join(dir, ".mem")
 		info, err := os.Stat(memPath)
 		if err == nil && info.IsDir() {
@@ -61,36 +232,107 @@ func (r *ScopeResolver) findProjectScope(dir string) (Scope, bool) {
 		}
 
 		parent := filepath.Dir(dir)
-		if parent == dir {
+		if parent == dir || (r.maxDepth > 0 && depth >= r.maxDepth) {
 			return Scope{}, false
 		}
 		dir = parent
 	}
 }
 
-func (r *ScopeResolver) Resolve(explicit string) Scope {
-	if explicit == "global" {
-		return r.Global()
+// Custom returns the named scopes configured in the global config, keyed by
+// name. Each points at a directory whose store lives in <dir>/.mem.
+func (r *ScopeResolver) Custom() (map[string]Scope, error) {
+	cfg, err := LoadConfig(r.Global())
+	if err != nil {
+		return nil, err
 	}
-	if scope, ok := r.Project(); ok {
-		return scope
+
+	scopes := make(map[string]Scope, len(cfg.Scopes))
+	for name, dir := range cfg.Scopes {
+		if rest, ok := strings.CutPrefix(dir, "~"); ok {
+			dir = filepath.Join(r.homeDir, rest)
+		}
+		dir = r.abs(dir)
+		scopes[name] = Scope{
+			Type:    ScopeCustom,
+			Name:    name,
+			Path:    dir,
+			MemPath: filepath.Join(dir, ".mem"),
+		}
+	}
+	return scopes, nil
+}
+
+// Lookup resolves a --scope hint: "global", "project" or empty for the
+// default, or the name of a custom scope. An unknown name is an error that
+// lists the known scopes.
+func (r *ScopeResolver) Lookup(hint string) (Scope, error) {
+	switch hint {
+	case "global":
+		return r.Global(), nil
+	case "", "project":
+		if scope, ok := r.Project(); ok {
+			return scope, nil
+		}
+		return r.Global(), nil
+	}
+
+	custom, err := r.Custom()
+	if err != nil {
+		return Scope{}, err
+	}
+	if scope, ok := custom[hint]; ok {
+		return scope, nil
 	}
-	return r.Global()
+
+	known := []string{"global", "project"}
+	for name := range custom {
+		known = append(known, name)
+	}
+	sort.Strings(known[2:])
+	return Scope{}, fmt.Errorf("%w %q (known scopes: %s)", ErrUnknownScope, hint, strings.Join(known, ", "))
 }
 
+// Resolve is Lookup for callers that cannot fail; an unknown hint falls back
+// to the default scope. The CLI validates --scope with Lookup up front.
+func (r *ScopeResolver) Resolve(explicit string) Scope {
+	scope, err := r.Lookup(explicit)
+	if err != nil {
+		scope, _ = r.Lookup("")
+	}
+	return scope
+}
+
+// Cascade returns the scopes to search in order: project, custom scopes by
+// name, then global.
 func (r *ScopeResolver) Cascade() []Scope {
 	scopes := []Scope{}
 	if scope, ok := r.Project(); ok {
 		scopes = append(scopes, scope)
 	}
+
+	custom, _ := r.Custom()
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		scopes = append(scopes, custom[name])
+	}
+
 	scopes = append(scopes, r.Global())
 	return scopes
 }
 
 func (r *ScopeResolver) EnvVars(scope Scope, branch, version string) map[string]string {
 	memBin, _ := os.Executable()
+	name := string(scope.Type)
+	if scope.Type == ScopeCustom {
+		name = scope.Name
+	}
 	return map[string]string{
-		"MEM_SCOPE":      string(scope.Type),
+		"MEM_SCOPE":      name,
 		"MEM_SCOPE_PATH": scope.MemPath,
 		"MEM_ROOT":       scope.Path,
 		"MEM_BRANCH":     branch,
@@ -1,8 +1,10 @@
 package internal
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -23,6 +25,8 @@ func TestScopeConfigPath(t *testing.T) {
 }
 
 func TestScopeResolverGlobal(t *testing.T) {
+	t.Setenv("MEM_HOME", "")
+	t.Setenv("XDG_DATA_HOME", "")
 	resolver := NewScopeResolver()
 	scope := resolver.Global()
 
@@ -37,6 +41,76 @@ func TestScopeResolverGlobal(t *testing.T) {
 	}
 }
 
+func TestScopeResolverGlobalMemHome(t *testing.T) {
+	memHome := filepath.Join(t.TempDir(), "store")
+	t.Setenv("MEM_HOME", memHome)
+
+	scope := NewScopeResolver().Global()
+	if scope.MemPath != memHome {
+		t.Errorf("expected MemPath %q, got %q", memHome, scope.MemPath)
+	}
+	if scope.Path != filepath.Dir(memHome) {
+		t.Errorf("expected Path %q, got %q", filepath.Dir(memHome), scope.Path)
+	}
+}
+
+func TestScopeResolverGlobalXDG(t *testing.T) {
+	home := t.TempDir()
+	dataHome := filepath.Join(t.TempDir(), "data")
+	configHome := filepath.Join(t.TempDir(), "config")
+	t.Setenv("HOME", home)
+	t.Setenv("MEM_HOME", "")
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+
+	resolver := NewScopeResolver()
+	scope := resolver.Global()
+	if want := filepath.Join(dataHome, "mem"); scope.MemPath != want {
+		t.Errorf("MemPath = %q, want %q", scope.MemPath, want)
+	}
+	if want := filepath.Join(configHome, "mem", "config.yaml"); scope.ConfigPath() != want {
+		t.Errorf("ConfigPath = %q, want %q", scope.ConfigPath(), want)
+	}
+	if _, ok := resolver.LegacyGlobal(); ok {
+		t.Error("LegacyGlobal reported without ~/.mem")
+	}
+
+	// An existing ~/.mem and its config keep being used.
+	legacy := filepath.Join(home, ".mem")
+	if err := os.MkdirAll(legacy, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(legacy, "config.yaml"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	scope = resolver.Global()
+	if scope.MemPath != legacy || scope.ConfigPath() != filepath.Join(legacy, "config.yaml") {
+		t.Errorf("with ~/.mem: MemPath = %q, ConfigPath = %q", scope.MemPath, scope.ConfigPath())
+	}
+	if path, ok := resolver.LegacyGlobal(); !ok || path != legacy {
+		t.Errorf("LegacyGlobal = %q, %v", path, ok)
+	}
+
+	// Relative XDG paths are ignored.
+	t.Setenv("XDG_DATA_HOME", "relative")
+	if _, ok := NewScopeResolver().LegacyGlobal(); ok {
+		t.Error("relative XDG_DATA_HOME was used")
+	}
+}
+
+func TestDefaultCacheDirXDG(t *testing.T) {
+	cacheHome := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", cacheHome)
+
+	dir, err := DefaultCacheDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(cacheHome, "mem", "models"); dir != want {
+		t.Errorf("DefaultCacheDir = %q, want %q", dir, want)
+	}
+}
+
 func TestScopeResolverProjectNotFound(t *testing.T) {
 	tmp := t.TempDir()
 	orig, _ := os.Getwd()
@@ -111,6 +185,86 @@ func TestScopeResolverProjectInParent(t *testing.T) {
 	}
 }
 
+func TestScopeResolverProjectMaxDepth(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmp, ".mem"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	subDir := filepath.Join(tmp, "a", "b", "c")
+	if err := os.MkdirAll(subDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		depth int
+		found bool
+	}{
+		{depth: 0, found: true},
+		{depth: 1, found: false},
+		{depth: 2, found: false},
+		{depth: 3, found: true},
+		{depth: -1, found: true},
+	}
+	for _, tt := range tests {
+		resolver := NewScopeResolverAt(subDir, filepath.Join(tmp, "home"))
+		resolver.SetMaxDepth(tt.depth)
+		scope, found := resolver.Project()
+		if found != tt.found {
+			t.Errorf("depth %d: Project() found = %v, want %v", tt.depth, found, tt.found)
+		}
+		if found && scope.Path != tmp {
+			t.Errorf("depth %d: Project() = %s, want %s", tt.depth, scope.Path, tmp)
+		}
+		if !found && resolver.Resolve("").Type != ScopeGlobal {
+			t.Errorf("depth %d: want the global store beyond the limit", tt.depth)
+		}
+	}
+}
+
+func TestScopeResolverMaxDepthEnv(t *testing.T) {
+	t.Setenv("MEM_MAX_DEPTH", "2")
+	if got := NewScopeResolver().MaxDepth(); got != 2 {
+		t.Errorf("MaxDepth() = %d, want 2 from $MEM_MAX_DEPTH", got)
+	}
+	if got := NewScopeResolverAt(t.TempDir(), t.TempDir()).MaxDepth(); got != 0 {
+		t.Errorf("MaxDepth() = %d, want an explicit home to ignore $MEM_MAX_DEPTH", got)
+	}
+
+	t.Setenv("MEM_MAX_DEPTH", "many")
+	if got := NewScopeResolver().MaxDepth(); got != 0 {
+		t.Errorf("MaxDepth() = %d, want an invalid value to leave it unbounded", got)
+	}
+}
+
+func TestScopeResolverAt(t *testing.T) {
+	tmp := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmp, ".mem"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	subDir := filepath.Join(tmp, "sub")
+	if err := os.Mkdir(subDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("MEM_HOME", filepath.Join(tmp, "ignored"))
+	home := filepath.Join(tmp, "home")
+
+	resolver := NewScopeResolverAt(subDir, home)
+	scope, found := resolver.Project()
+	if !found || scope.Path != tmp {
+		t.Errorf("Project() = %+v, %v, want the store in %s", scope, found, tmp)
+	}
+	if got := resolver.Global().MemPath; got != filepath.Join(home, ".mem") {
+		t.Errorf("global store = %s, want it in the given home, not $MEM_HOME", got)
+	}
+	if scopes := resolver.Cascade(); len(scopes) != 2 || scopes[0].Path != tmp {
+		t.Errorf("Cascade() = %+v, want the project then the global store", scopes)
+	}
+	if dir, _ := resolver.WorkDir(); dir != subDir {
+		t.Errorf("WorkDir() = %s, want %s", dir, subDir)
+	}
+}
+
 func TestScopeResolverResolveExplicitGlobal(t *testing.T) {
 	resolver := NewScopeResolver()
 	scope := resolver.Resolve("global")
@@ -188,3 +342,81 @@ func TestScopeResolverEnvVars(t *testing.T) {
 		t.Errorf("expected MEM_CONFIG=/project/.mem/config.yaml, got %q", env["MEM_CONFIG"])
 	}
 }
+
+// setupCustomScopes points MEM_HOME at a temp global store whose config
+// names the given scopes, and returns their directories.
+func setupCustomScopes(t *testing.T, names ...string) map[string]string {
+	t.Helper()
+	tmp := t.TempDir()
+	t.Setenv("MEM_HOME", filepath.Join(tmp, "global"))
+
+	cfg := DefaultConfig()
+	cfg.Scopes = make(map[string]string)
+	for _, name := range names {
+		cfg.Scopes[name] = filepath.Join(tmp, name)
+	}
+	if err := os.MkdirAll(filepath.Join(tmp, "global"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+	if err := SaveConfig(NewScopeResolver().Global(), cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+	return cfg.Scopes
+}
+
+func TestScopeResolverLookupCustom(t *testing.T) {
+	dirs := setupCustomScopes(t, "work", "personal")
+	resolver := NewScopeResolver()
+
+	scope, err := resolver.Lookup("work")
+	if err != nil {
+		t.Fatalf("lookup: %v", err)
+	}
+	if scope.Type != ScopeCustom || scope.Name != "work" {
+		t.Errorf("scope = %s/%s, want custom/work", scope.Type, scope.Name)
+	}
+	if scope.MemPath != filepath.Join(dirs["work"], ".mem") {
+		t.Errorf("MemPath = %q, want %q", scope.MemPath, filepath.Join(dirs["work"], ".mem"))
+	}
+	if got := resolver.Resolve("work"); got.MemPath != scope.MemPath {
+		t.Errorf("Resolve(work) = %q, want %q", got.MemPath, scope.MemPath)
+	}
+
+	_, err = resolver.Lookup("wrok")
+	if !errors.Is(err, ErrUnknownScope) {
+		t.Fatalf("expected ErrUnknownScope, got %v", err)
+	}
+	if !strings.Contains(err.Error(), "global, project, personal, work") {
+		t.Errorf("error should list known scopes, got %v", err)
+	}
+}
+
+func TestScopeResolverCascadeCustom(t *testing.T) {
+	setupCustomScopes(t, "work", "personal")
+
+	tmp := t.TempDir()
+	if err := os.Mkdir(filepath.Join(tmp, ".mem"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	orig, _ := os.Getwd()
+	defer func() { _ = os.Chdir(orig) }()
+	_ = os.Chdir(tmp)
+
+	var got []string
+	for _, s := range NewScopeResolver().Cascade() {
+		got = append(got, string(s.Type)+":"+s.Name)
+	}
+	want := []string{"project:", "custom:personal", "custom:work", "global:"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("cascade = %v, want %v", got, want)
+	}
+}
+
+func TestScopeResolverEnvVarsCustom(t *testing.T) {
+	scope := Scope{Type: ScopeCustom, Name: "work", Path: "/work", MemPath: "/work/.mem"}
+
+	env := NewScopeResolver().EnvVars(scope, "main", "1.0.0")
+	if env["MEM_SCOPE"] != "work" {
+		t.Errorf("expected MEM_SCOPE=work, got %q", env["MEM_SCOPE"])
+	}
+}
@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+)
+
+// ErrSigningKey means git.signing_key is set but the key cannot be used.
+// The commit fails rather than being made unsigned.
+var ErrSigningKey = errors.New("cannot load signing key")
+
+// SigningPassphraseEnv holds the passphrase of an encrypted signing key.
+const SigningPassphraseEnv = "MEM_SIGNING_PASSPHRASE"
+
+type noSignKey struct{}
+
+// WithoutSigning returns a context in which commits are not signed, even
+// when the store configures a signing key.
+func WithoutSigning(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noSignKey{}, true)
+}
+
+// SigningDisabledFrom reports whether ctx was marked by WithoutSigning.
+func SigningDisabledFrom(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	disabled, _ := ctx.Value(noSignKey{}).(bool)
+	return disabled
+}
+
+// LoadSigningKey reads the OpenPGP private key at path, armored or binary,
+// as exported by gpg --export-secret-keys. An encrypted key is decrypted
+// with $MEM_SIGNING_PASSPHRASE. A leading ~/ is the home directory.
+func LoadSigningKey(path string) (*openpgp.Entity, error) {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, rest)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSigningKey, err)
+	}
+
+	keys, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	if err != nil {
+		keys, err = openpgp.ReadKeyRing(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: read %s: %v", ErrSigningKey, path, err)
+	}
+
+	for _, key := range keys {
+		if key.PrivateKey == nil {
+			continue
+		}
+		if key.PrivateKey.Encrypted {
+			passphrase := os.Getenv(SigningPassphraseEnv)
+			if passphrase == "" {
+				return nil, fmt.Errorf("%w: %s is encrypted; set %s", ErrSigningKey, path, SigningPassphraseEnv)
+			}
+			if err := key.DecryptPrivateKeys([]byte(passphrase)); err != nil {
+				return nil, fmt.Errorf("%w: decrypt %s: %v", ErrSigningKey, path, err)
+			}
+		}
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: %s holds no private key", ErrSigningKey, path)
+}
@@ -0,0 +1,155 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/armor"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// writeSigningKey writes a new armored private key, encrypted with
+// passphrase unless it is empty, and returns its path and public half.
+func writeSigningKey(t *testing.T, passphrase string) (string, string) {
+	t.Helper()
+	cfg := &packet.Config{Algorithm: packet.PubKeyAlgoEdDSA}
+	entity, err := openpgp.NewEntity("mem", "test", "mem@example.com", cfg)
+	if err != nil {
+		t.Fatalf("new entity: %v", err)
+	}
+
+	var public strings.Builder
+	w, err := armor.Encode(&public, openpgp.PublicKeyType, nil)
+	if err != nil {
+		t.Fatalf("armor: %v", err)
+	}
+	if err := entity.Serialize(w); err != nil {
+		t.Fatalf("serialize public key: %v", err)
+	}
+	w.Close()
+
+	if passphrase != "" {
+		if err := entity.EncryptPrivateKeys([]byte(passphrase), cfg); err != nil {
+			t.Fatalf("encrypt key: %v", err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "signing.asc")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create key file: %v", err)
+	}
+	defer f.Close()
+	w, err = armor.Encode(f, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatalf("armor: %v", err)
+	}
+	if err := entity.SerializePrivateWithoutSigning(w, cfg); err != nil {
+		t.Fatalf("serialize private key: %v", err)
+	}
+	w.Close()
+	return path, public.String()
+}
+
+func TestGitRepositorySignedCommit(t *testing.T) {
+	repo, _ := setupGitRepo(t)
+	ctx := context.Background()
+	keyPath, publicKey := writeSigningKey(t, "")
+	repo.SetSigningKey(keyPath)
+
+	key, _ := NewKey("signed")
+	if err := repo.Save(ctx, NewMemory(key, []byte("content"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	c, err := repo.Commit(ctx, "set: signed")
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	obj, err := repo.repo.CommitObject(plumbing.NewHash(c.Hash))
+	if err != nil {
+		t.Fatalf("commit object: %v", err)
+	}
+	if !strings.HasPrefix(obj.PGPSignature, "-----BEGIN PGP SIGNATURE-----") {
+		t.Fatalf("commit has no PGP signature block: %q", obj.PGPSignature)
+	}
+	if _, err := obj.Verify(publicKey); err != nil {
+		t.Errorf("verify signature: %v", err)
+	}
+}
+
+func TestGitRepositoryWithoutSigning(t *testing.T) {
+	repo, _ := setupGitRepo(t)
+	ctx := context.Background()
+	keyPath, _ := writeSigningKey(t, "")
+	repo.SetSigningKey(keyPath)
+
+	key, _ := NewKey("unsigned")
+	if err := repo.Save(ctx, NewMemory(key, []byte("content"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	c, err := repo.Commit(WithoutSigning(ctx), "set: unsigned")
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	obj, err := repo.repo.CommitObject(plumbing.NewHash(c.Hash))
+	if err != nil {
+		t.Fatalf("commit object: %v", err)
+	}
+	if obj.PGPSignature != "" {
+		t.Errorf("WithoutSigning commit is signed")
+	}
+}
+
+func TestGitRepositorySigningKeyUnusable(t *testing.T) {
+	encrypted, _ := writeSigningKey(t, "secret")
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"missing", filepath.Join(t.TempDir(), "absent.asc")},
+		{"encrypted without passphrase", encrypted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(SigningPassphraseEnv, "")
+			repo, _ := setupGitRepo(t)
+			ctx := context.Background()
+			repo.SetSigningKey(tt.path)
+			before, _ := repo.Log(ctx, 1)
+
+			key, _ := NewKey("k")
+			if err := repo.Save(ctx, NewMemory(key, []byte("content"))); err != nil {
+				t.Fatalf("save: %v", err)
+			}
+			if _, err := repo.Commit(ctx, "set: k"); !errors.Is(err, ErrSigningKey) {
+				t.Fatalf("want ErrSigningKey, got %v", err)
+			}
+			after, _ := repo.Log(ctx, 1)
+			if after[0].Hash != before[0].Hash {
+				t.Error("an unsigned commit was made")
+			}
+		})
+	}
+}
+
+func TestLoadSigningKeyEncrypted(t *testing.T) {
+	path, _ := writeSigningKey(t, "secret")
+	t.Setenv(SigningPassphraseEnv, "secret")
+	key, err := LoadSigningKey(path)
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if key.PrivateKey.Encrypted {
+		t.Error("key was not decrypted")
+	}
+	t.Setenv(SigningPassphraseEnv, "wrong")
+	if _, err := LoadSigningKey(path); !errors.Is(err, ErrSigningKey) {
+		t.Errorf("wrong passphrase: want ErrSigningKey, got %v", err)
+	}
+}
@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	ErrSnapshotExists   = errors.New("snapshot already exists")
+	ErrSnapshotNotFound = errors.New("snapshot not found")
+)
+
+var snapshotNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+type Snapshot struct {
+	Name      string
+	Commit    string
+	Message   string
+	CreatedAt time.Time
+}
+
+// SnapshotRepository keeps named checkpoints of the store as git tags.
+type SnapshotRepository interface {
+	// CreateSnapshot tags HEAD as name. Unless force is set it refuses
+	// with ErrSnapshotExists when the name is taken.
+	CreateSnapshot(ctx context.Context, name, message string, force bool) (*Snapshot, error)
+	GetSnapshot(ctx context.Context, name string) (*Snapshot, error)
+	ListSnapshots(ctx context.Context) ([]*Snapshot, error)
+	// RestoreTree stages the tree of ref over the worktree without moving
+	// HEAD and returns how many files changed.
+	RestoreTree(ctx context.Context, ref string) (int, error)
+}
+
+type SnapshotInput struct {
+	Name    string
+	Message string
+	Scope   string
+	Force   bool // replace an existing snapshot of the same name
+}
+
+type SnapshotOutput struct {
+	Name      string
+	Commit    string
+	Message   string
+	CreatedAt time.Time
+}
+
+type SnapshotRestoreOutput struct {
+	Snapshot SnapshotOutput
+	Changed  int // files staged by the restore; zero means nothing to commit
+}
+
+// validateSnapshotName keeps snapshot names usable as tag names.
+func validateSnapshotName(name string) error {
+	if !snapshotNamePattern.MatchString(name) || strings.Contains(name, "..") || strings.HasSuffix(name, ".lock") {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return nil
+}
+
+func toSnapshotOutput(s *Snapshot) SnapshotOutput {
+	return SnapshotOutput{
+		Name:      s.Name,
+		Commit:    s.Commit,
+		Message:   s.Message,
+		CreatedAt: s.CreatedAt,
+	}
+}
+
+// --- SnapshotUseCase ---
+
+// SnapshotUseCase checkpoints the store before risky bulk edits and brings
+// it back afterwards. A restore is a new commit, so nothing made after the
+// snapshot is lost.
+type SnapshotUseCase struct {
+	resolver  *ScopeResolver
+	snapFor   func(Scope) (SnapshotRepository, error)
+	branchFor func(Scope) (BranchRepository, error)
+}
+
+func NewSnapshotUseCase(
+	resolver *ScopeResolver,
+	snapFor func(Scope) (SnapshotRepository, error),
+	branchFor func(Scope) (BranchRepository, error),
+) *SnapshotUseCase {
+	return &SnapshotUseCase{
+		resolver:  resolver,
+		snapFor:   snapFor,
+		branchFor: branchFor,
+	}
+}
+
+// Create snapshots the last commit under input.Name.
+func (uc *SnapshotUseCase) Create(ctx context.Context, input SnapshotInput) (*SnapshotOutput, error) {
+	if err := validateSnapshotName(input.Name); err != nil {
+		return nil, err
+	}
+
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.snapFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return nil, err
+	}
+
+	snap, err := repo.CreateSnapshot(ctx, input.Name, input.Message, input.Force)
+	if err != nil {
+		return nil, err
+	}
+	out := toSnapshotOutput(snap)
+	return &out, nil
+}
+
+// List returns the snapshots of the scope, oldest first.
+func (uc *SnapshotUseCase) List(ctx context.Context, input SnapshotInput) ([]SnapshotOutput, error) {
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.snapFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+
+	snapshots, err := repo.ListSnapshots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	output := make([]SnapshotOutput, len(snapshots))
+	for i, s := range snapshots {
+		output[i] = toSnapshotOutput(s)
+	}
+	return output, nil
+}
+
+// Restore stages the tree of snapshot input.Name for the caller to commit
+// and marks the branch's vector index stale, since it still describes the
+// memories from before the restore.
+func (uc *SnapshotUseCase) Restore(ctx context.Context, input SnapshotInput) (*SnapshotRestoreOutput, error) {
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.snapFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return nil, err
+	}
+
+	snap, err := repo.GetSnapshot(ctx, input.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	changed, err := repo.RestoreTree(ctx, snap.Commit)
+	if err != nil {
+		return nil, err
+	}
+
+	if changed > 0 {
+		var branches BranchRepository
+		if b, err := uc.branchFor(scope); err == nil {
+			branches = b
+		}
+		if err := MarkIndexStale(IndexPath(ctx, scope, branches)); err != nil {
+			return nil, err
+		}
+	}
+
+	return &SnapshotRestoreOutput{Snapshot: toSnapshotOutput(snap), Changed: changed}, nil
+}
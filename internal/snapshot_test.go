@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSnapshotRestoreKeepsHistory(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	snapFor := func(Scope) (SnapshotRepository, error) { return repo, nil }
+	branchFor := func(Scope) (BranchRepository, error) { return repo, nil }
+	uc := NewSnapshotUseCase(resolver, snapFor, branchFor)
+
+	if err := repo.Save(ctx, &Memory{Key: "a", Content: []byte("v1")}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := repo.Commit(ctx, "set: a"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	snap, err := uc.Create(ctx, SnapshotInput{Name: "v1"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := uc.Create(ctx, SnapshotInput{Name: "v1"}); !errors.Is(err, ErrSnapshotExists) {
+		t.Errorf("expected ErrSnapshotExists, got %v", err)
+	}
+	if _, err := uc.Create(ctx, SnapshotInput{Name: "../x"}); err == nil {
+		t.Error("expected invalid name to fail")
+	}
+
+	if err := repo.Save(ctx, &Memory{Key: "a", Content: []byte("v2")}); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if _, err := repo.Commit(ctx, "set: a"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	out, err := uc.Restore(ctx, SnapshotInput{Name: "v1"})
+	if err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if out.Changed != 1 || out.Snapshot.Commit != snap.Commit {
+		t.Errorf("restore output = %+v", out)
+	}
+
+	mem, err := repo.Get(ctx, "a")
+	if err != nil || string(mem.Content) != "v1" {
+		t.Fatalf("a after restore = %v, %v", mem, err)
+	}
+	head, err := repo.Current(ctx)
+	if err != nil {
+		t.Fatalf("current: %v", err)
+	}
+	if head.Head == snap.Commit {
+		t.Error("restore must not move HEAD back")
+	}
+	if !IndexStale(IndexPath(ctx, resolver.Resolve(""), repo)) {
+		t.Error("restore should mark the index stale")
+	}
+
+	if _, err := uc.Restore(ctx, SnapshotInput{Name: "missing"}); !errors.Is(err, ErrSnapshotNotFound) {
+		t.Errorf("expected ErrSnapshotNotFound, got %v", err)
+	}
+}
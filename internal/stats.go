@@ -0,0 +1,261 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultStatsTop   = 5
+	DefaultStatsWeeks = 8
+)
+
+type StatsInput struct {
+	Prefix string
+	Scope  string
+	Top    int
+	Weeks  int
+	// Duplicates reads every memory to find those with identical content.
+	Duplicates bool
+}
+
+type PrefixStats struct {
+	Prefix   string
+	Memories int
+	Bytes    int64
+}
+
+type KeySize struct {
+	Key   string
+	Bytes int64
+}
+
+// DuplicateGroup is a set of memories with identical content.
+type DuplicateGroup struct {
+	Hash  string // hex SHA-256 of the content
+	Keys  []string
+	Bytes int64 // size of one copy
+}
+
+// Wasted returns the bytes taken by all copies but one.
+func (g DuplicateGroup) Wasted() int64 {
+	return g.Bytes * int64(len(g.Keys)-1)
+}
+
+type WeekStats struct {
+	Start   time.Time
+	Commits int
+}
+
+type StatsOutput struct {
+	Memories int
+	Bytes    int64
+	Prefixes []PrefixStats
+	Largest  []KeySize
+	Weeks    []WeekStats
+	Indexed  int
+	Branches int
+	// Duplicates and WastedBytes are only filled in with
+	// StatsInput.Duplicates. The groups come most wasteful first.
+	Duplicates  []DuplicateGroup
+	WastedBytes int64
+}
+
+// --- StatsUseCase ---
+
+// StatsUseCase summarises a store. Sizes come from ListKeys when the
+// repository supports it so no memory content is read; index coverage comes
+// from the index mapping so no embedder is needed.
+type StatsUseCase struct {
+	resolver  *ScopeResolver
+	repoFor   func(Scope) (MemoryRepository, error)
+	histFor   func(Scope) (HistoryRepository, error)
+	branchFor func(Scope) (BranchRepository, error)
+}
+
+func NewStatsUseCase(
+	resolver *ScopeResolver,
+	repoFor func(Scope) (MemoryRepository, error),
+	histFor func(Scope) (HistoryRepository, error),
+	branchFor func(Scope) (BranchRepository, error),
+) *StatsUseCase {
+	return &StatsUseCase{
+		resolver:  resolver,
+		repoFor:   repoFor,
+		histFor:   histFor,
+		branchFor: branchFor,
+	}
+}
+
+func (uc *StatsUseCase) Execute(ctx context.Context, input StatsInput) (*StatsOutput, error) {
+	top := input.Top
+	if top <= 0 {
+		top = DefaultStatsTop
+	}
+	weeks := input.Weeks
+	if weeks <= 0 {
+		weeks = DefaultStatsWeeks
+	}
+
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.repoFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+
+	keys, err := listKeys(ctx, repo, input.Prefix)
+	if err != nil {
+		return nil, fmt.Errorf("list memories: %w", err)
+	}
+
+	output := &StatsOutput{Memories: len(keys)}
+
+	prefixes := make(map[string]*PrefixStats)
+	for _, k := range keys {
+		output.Bytes += k.Size
+
+		name, _, _ := strings.Cut(k.Key.String(), "/")
+		ps, ok := prefixes[name]
+		if !ok {
+			ps = &PrefixStats{Prefix: name}
+			prefixes[name] = ps
+		}
+		ps.Memories++
+		ps.Bytes += k.Size
+	}
+	for _, ps := range prefixes {
+		output.Prefixes = append(output.Prefixes, *ps)
+	}
+	sort.Slice(output.Prefixes, func(i, j int) bool {
+		return output.Prefixes[i].Prefix < output.Prefixes[j].Prefix
+	})
+
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Size != keys[j].Size {
+			return keys[i].Size > keys[j].Size
+		}
+		return keys[i].Key < keys[j].Key
+	})
+	for _, k := range keys[:min(top, len(keys))] {
+		output.Largest = append(output.Largest, KeySize{Key: k.Key.String(), Bytes: k.Size})
+	}
+
+	if input.Duplicates {
+		memories, err := repo.List(ctx, input.Prefix)
+		if err != nil {
+			return nil, fmt.Errorf("list memories: %w", err)
+		}
+		output.Duplicates = duplicateGroups(memories)
+		for _, g := range output.Duplicates {
+			output.WastedBytes += g.Wasted()
+		}
+	}
+
+	branches, err := uc.branchFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get branch repository: %w", err)
+	}
+	list, err := branches.ListBranches(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("list branches: %w", err)
+	}
+	output.Branches = len(list)
+
+	indexed, err := IndexedKeys(IndexPath(ctx, scope, branches))
+	if err != nil {
+		LoggerFrom(ctx).Warn("skipping index coverage", "error", err)
+	}
+	listed := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		listed[k.Key.String()] = true
+	}
+	counted := make(map[string]bool, len(indexed))
+	for _, key := range indexed {
+		parent := ParentKey(Key(key)).String()
+		if listed[parent] && !counted[parent] {
+			counted[parent] = true
+			output.Indexed++
+		}
+	}
+
+	hist, err := uc.histFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get history repository: %w", err)
+	}
+	commits, err := hist.Log(ctx, 0)
+	if err != nil && !errors.Is(err, ErrNoCommits) {
+		return nil, fmt.Errorf("get log: %w", err)
+	}
+	output.Weeks = commitsPerWeek(commits, weekStart(time.Now()), weeks)
+
+	return output, nil
+}
+
+// listKeys uses the repository's ListKeys when it has one and falls back
+// to List otherwise.
+func listKeys(ctx context.Context, repo MemoryRepository, prefix string) ([]KeyInfo, error) {
+	if lister, ok := repo.(interface {
+		ListKeys(context.Context, string) ([]KeyInfo, error)
+	}); ok {
+		return lister.ListKeys(ctx, prefix)
+	}
+
+	memories, err := repo.List(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]KeyInfo, len(memories))
+	for i, mem := range memories {
+		keys[i] = KeyInfo{Key: mem.Key, Size: int64(len(mem.Content)), UpdatedAt: mem.UpdatedAt}
+	}
+	return keys, nil
+}
+
+// duplicateGroups reports the groups of identical memories, the ones
+// wasting the most bytes first.
+func duplicateGroups(memories []*Memory) []DuplicateGroup {
+	var groups []DuplicateGroup
+	for _, c := range identicalGroups(memories) {
+		g := DuplicateGroup{Hash: contentHash(c[0].Content), Bytes: int64(len(c[0].Content))}
+		for _, mem := range c {
+			g.Keys = append(g.Keys, mem.Key.String())
+		}
+		sort.Strings(g.Keys)
+		groups = append(groups, g)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Wasted() > groups[j].Wasted()
+	})
+	return groups
+}
+
+// commitsPerWeek counts commits in the n weeks ending with the week starting
+// at current, oldest first. Weeks without commits are included.
+func commitsPerWeek(commits []*Commit, current time.Time, n int) []WeekStats {
+	weeks := make([]WeekStats, n)
+	for i := range weeks {
+		weeks[i].Start = current.AddDate(0, 0, -7*(n-1-i))
+	}
+
+	for _, c := range commits {
+		start := weekStart(c.Timestamp)
+		for i := range weeks {
+			if weeks[i].Start.Equal(start) {
+				weeks[i].Commits++
+				break
+			}
+		}
+	}
+	return weeks
+}
+
+// weekStart returns midnight UTC on the Monday of t's week.
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatsUseCase(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	for key, content := range map[string]string{
+		"notes/a": "aaaa",
+		"notes/b": "bb",
+		"hooks/c": "cccccccc",
+		"readme":  "r",
+	} {
+		if err := repo.Save(ctx, NewMemory(Key(key), []byte(content))); err != nil {
+			t.Fatalf("save %s: %v", key, err)
+		}
+	}
+	if _, err := repo.Commit(ctx, "seed"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	mapping := `{"key_to_id":{"notes/a":0,"gone":1},"id_to_key":{"0":"notes/a","1":"gone"},"next_id":2}`
+	vectors := IndexPath(ctx, resolver.Resolve(""), repo)
+	if err := os.MkdirAll(vectors, 0755); err != nil {
+		t.Fatalf("mkdir vectors: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(vectors, MappingFilename), []byte(mapping), 0644); err != nil {
+		t.Fatalf("write mapping: %v", err)
+	}
+
+	repoFor := func(s Scope) (MemoryRepository, error) { return repo, nil }
+	histFor := func(s Scope) (HistoryRepository, error) { return repo, nil }
+	branchFor := func(s Scope) (BranchRepository, error) { return repo, nil }
+	uc := NewStatsUseCase(resolver, repoFor, histFor, branchFor)
+
+	out, err := uc.Execute(ctx, StatsInput{Top: 2, Weeks: 3})
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+
+	if out.Memories != 4 || out.Bytes != 15 {
+		t.Errorf("memories/bytes = %d/%d, want 4/15", out.Memories, out.Bytes)
+	}
+	if out.Indexed != 1 {
+		t.Errorf("indexed = %d, want 1", out.Indexed)
+	}
+	if out.Branches != 1 {
+		t.Errorf("branches = %d, want 1", out.Branches)
+	}
+
+	want := []PrefixStats{{"hooks", 1, 8}, {"notes", 2, 6}, {"readme", 1, 1}}
+	if len(out.Prefixes) != len(want) {
+		t.Fatalf("prefixes = %+v, want %+v", out.Prefixes, want)
+	}
+	for i := range want {
+		if out.Prefixes[i] != want[i] {
+			t.Errorf("prefix[%d] = %+v, want %+v", i, out.Prefixes[i], want[i])
+		}
+	}
+
+	if len(out.Largest) != 2 || out.Largest[0].Key != "hooks/c" || out.Largest[1].Key != "notes/a" {
+		t.Errorf("largest = %+v, want hooks/c then notes/a", out.Largest)
+	}
+
+	if len(out.Weeks) != 3 {
+		t.Fatalf("weeks = %d, want 3", len(out.Weeks))
+	}
+	if got := out.Weeks[2].Commits; got < 1 {
+		t.Errorf("current week commits = %d, want at least 1", got)
+	}
+
+	out, err = uc.Execute(ctx, StatsInput{Prefix: "notes"})
+	if err != nil {
+		t.Fatalf("stats with prefix: %v", err)
+	}
+	if out.Memories != 2 || len(out.Prefixes) != 1 || out.Indexed != 1 {
+		t.Errorf("prefix stats = %+v, want 2 notes memories with 1 indexed", out)
+	}
+}
+
+func TestStatsUseCaseDuplicates(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	for key, content := range map[string]string{
+		"hooks/a": "same summary",
+		"hooks/b": "same summary",
+		"hooks/c": "same summary",
+		"notes/x": "pair",
+		"notes/y": "pair",
+		"notes/z": "unique",
+		"alias/1": string(aliasMemoryContent("hooks/a")),
+		"alias/2": string(aliasMemoryContent("hooks/a")),
+	} {
+		if err := repo.Save(ctx, NewMemory(Key(key), []byte(content))); err != nil {
+			t.Fatalf("save %s: %v", key, err)
+		}
+	}
+
+	repoFor := func(s Scope) (MemoryRepository, error) { return repo, nil }
+	histFor := func(s Scope) (HistoryRepository, error) { return repo, nil }
+	branchFor := func(s Scope) (BranchRepository, error) { return repo, nil }
+	uc := NewStatsUseCase(resolver, repoFor, histFor, branchFor)
+
+	out, err := uc.Execute(ctx, StatsInput{})
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if out.Duplicates != nil || out.WastedBytes != 0 {
+		t.Errorf("duplicates = %+v, want none without StatsInput.Duplicates", out.Duplicates)
+	}
+
+	out, err = uc.Execute(ctx, StatsInput{Duplicates: true})
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if len(out.Duplicates) != 2 {
+		t.Fatalf("duplicates = %+v, want the hooks and notes groups", out.Duplicates)
+	}
+	hooks, notes := out.Duplicates[0], out.Duplicates[1]
+	if strings.Join(hooks.Keys, " ") != "hooks/a hooks/b hooks/c" || hooks.Wasted() != 24 {
+		t.Errorf("first group = %+v, want the three hooks wasting 24 bytes", hooks)
+	}
+	if strings.Join(notes.Keys, " ") != "notes/x notes/y" || notes.Wasted() != 4 {
+		t.Errorf("second group = %+v, want notes/x and notes/y wasting 4 bytes", notes)
+	}
+	if hooks.Hash != contentHash([]byte("same summary")) {
+		t.Errorf("hash = %s, want the SHA-256 of the content", hooks.Hash)
+	}
+	if out.WastedBytes != 28 {
+		t.Errorf("wasted = %d, want 28", out.WastedBytes)
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	// 2025-01-01 was a Wednesday.
+	got := weekStart(time.Date(2025, 1, 1, 15, 0, 0, 0, time.UTC))
+	want := time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC)
+	if !got.Equal(want) {
+		t.Errorf("weekStart = %s, want %s", got, want)
+	}
+}
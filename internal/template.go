@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+const TemplateExt = ".tmpl"
+
+var ErrTemplateNotFound = errors.New("template not found")
+
+var templateNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+type TemplateInput struct {
+	Name    string
+	Content string
+	Scope   string
+}
+
+type RenderTemplateInput struct {
+	Name   string
+	Key    string
+	Author string
+	Vars   map[string]string
+	Scope  string
+}
+
+// --- TemplateUseCase ---
+
+// TemplateUseCase manages memory skeletons stored as text/template files
+// under <scope>/.mem/templates. Lookups cascade from project to global scope.
+type TemplateUseCase struct {
+	resolver *ScopeResolver
+	now      func() time.Time
+}
+
+func NewTemplateUseCase(resolver *ScopeResolver) *TemplateUseCase {
+	return &TemplateUseCase{resolver: resolver, now: time.Now}
+}
+
+// List returns the names of all templates visible from the given scope.
+func (uc *TemplateUseCase) List(input TemplateInput) ([]string, error) {
+	seen := make(map[string]bool)
+	var names []string
+
+	for _, scope := range uc.scopes(input.Scope) {
+		entries, err := os.ReadDir(scope.TemplatePath())
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read templates: %w", err)
+		}
+		for _, e := range entries {
+			if e.IsDir() || !strings.HasSuffix(e.Name(), TemplateExt) {
+				continue
+			}
+			name := strings.TrimSuffix(e.Name(), TemplateExt)
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+// Add stores a template in the resolved scope, replacing any existing one.
+func (uc *TemplateUseCase) Add(input TemplateInput) error {
+	if !templateNamePattern.MatchString(input.Name) {
+		return fmt.Errorf("invalid template name %q", input.Name)
+	}
+	if _, err := template.New(input.Name).Parse(input.Content); err != nil {
+		return fmt.Errorf("parse template: %w", err)
+	}
+
+	scope := uc.resolver.Resolve(input.Scope)
+	dir := scope.TemplatePath()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("create templates directory: %w", err)
+	}
+
+	path := filepath.Join(dir, input.Name+TemplateExt)
+	if err := os.WriteFile(path, []byte(input.Content), 0644); err != nil {
+		return fmt.Errorf("write template: %w", err)
+	}
+	return nil
+}
+
+// Render executes the named template. Built-in fields are Key, Date and
+// Author; user variables are available by name and override built-ins.
+func (uc *TemplateUseCase) Render(_ context.Context, input RenderTemplateInput) (string, error) {
+	raw, err := uc.read(input.Name, input.Scope)
+	if err != nil {
+		return "", err
+	}
+
+	tmpl, err := template.New(input.Name).Option("missingkey=zero").Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("parse template %q: %w", input.Name, err)
+	}
+
+	data := map[string]string{
+		"Key":    input.Key,
+		"Date":   uc.now().Format("2006-01-02"),
+		"Author": input.Author,
+	}
+	for k, v := range input.Vars {
+		data[k] = v
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("render template %q: %w", input.Name, err)
+	}
+	return sb.String(), nil
+}
+
+func (uc *TemplateUseCase) read(name, scopeHint string) (string, error) {
+	if templateNamePattern.MatchString(name) {
+		for _, scope := range uc.scopes(scopeHint) {
+			data, err := os.ReadFile(filepath.Join(scope.TemplatePath(), name+TemplateExt))
+			if err == nil {
+				return string(data), nil
+			}
+			if !os.IsNotExist(err) {
+				return "", fmt.Errorf("read template: %w", err)
+			}
+		}
+	}
+
+	available, _ := uc.List(TemplateInput{Scope: scopeHint})
+	if len(available) == 0 {
+		return "", fmt.Errorf("%w: %q (no templates defined)", ErrTemplateNotFound, name)
+	}
+	return "", fmt.Errorf("%w: %q (available: %s)", ErrTemplateNotFound, name, strings.Join(available, ", "))
+}
+
+func (uc *TemplateUseCase) scopes(scopeHint string) []Scope {
+	if scopeHint != "" {
+		return []Scope{uc.resolver.Resolve(scopeHint)}
+	}
+	return uc.resolver.Cascade()
+}
@@ -0,0 +1,81 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTemplateRender(t *testing.T) {
+	_, resolver := setupUseCaseTest(t)
+	t.Setenv("HOME", t.TempDir())
+
+	uc := NewTemplateUseCase(resolver)
+	uc.now = func() time.Time { return time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC) }
+
+	if err := uc.Add(TemplateInput{
+		Name:    "adr",
+		Content: "# {{.Title}}\nkey: {{.Key}}\ndate: {{.Date}}\nby: {{.Author}}\n",
+	}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	got, err := uc.Render(context.Background(), RenderTemplateInput{
+		Name: "adr", Key: "notes/adr/0005", Author: "alice",
+		Vars: map[string]string{"Title": "Use SQLite"},
+	})
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+
+	want := "# Use SQLite\nkey: notes/adr/0005\ndate: 2025-03-04\nby: alice\n"
+	if got != want {
+		t.Errorf("render = %q, want %q", got, want)
+	}
+}
+
+func TestTemplateListAndMissing(t *testing.T) {
+	_, resolver := setupUseCaseTest(t)
+	t.Setenv("HOME", t.TempDir())
+	uc := NewTemplateUseCase(resolver)
+
+	if _, err := uc.Render(context.Background(), RenderTemplateInput{Name: "adr"}); !errors.Is(err, ErrTemplateNotFound) {
+		t.Fatalf("render with no templates: err = %v, want ErrTemplateNotFound", err)
+	}
+
+	for _, name := range []string{"standup", "adr"} {
+		if err := uc.Add(TemplateInput{Name: name, Content: "{{.Key}}"}); err != nil {
+			t.Fatalf("add %s: %v", name, err)
+		}
+	}
+
+	names, err := uc.List(TemplateInput{})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if strings.Join(names, ",") != "adr,standup" {
+		t.Errorf("list = %v, want [adr standup]", names)
+	}
+
+	_, err = uc.Render(context.Background(), RenderTemplateInput{Name: "rfc"})
+	if !errors.Is(err, ErrTemplateNotFound) {
+		t.Fatalf("err = %v, want ErrTemplateNotFound", err)
+	}
+	if !strings.Contains(err.Error(), "available: adr, standup") {
+		t.Errorf("error %q should list available templates", err)
+	}
+}
+
+func TestTemplateAddRejectsBadInput(t *testing.T) {
+	_, resolver := setupUseCaseTest(t)
+	uc := NewTemplateUseCase(resolver)
+
+	if err := uc.Add(TemplateInput{Name: "../escape", Content: "x"}); err == nil {
+		t.Error("expected error for invalid name")
+	}
+	if err := uc.Add(TemplateInput{Name: "broken", Content: "{{.Key"}); err == nil {
+		t.Error("expected error for unparsable template")
+	}
+}
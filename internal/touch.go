@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+type TouchInput struct {
+	Key   string
+	Scope string
+}
+
+// --- TouchUseCase ---
+
+// TouchUseCase bumps a memory to now without changing its content: the
+// file is rewritten for a fresh UpdatedAt and a "touch: <key>" commit is
+// recorded even though the tree is unchanged.
+type TouchUseCase struct {
+	resolver *ScopeResolver
+	repoFor  func(Scope) (MemoryRepository, error)
+	histFor  func(Scope) (HistoryRepository, error)
+}
+
+func NewTouchUseCase(
+	resolver *ScopeResolver,
+	repoFor func(Scope) (MemoryRepository, error),
+	histFor func(Scope) (HistoryRepository, error),
+) *TouchUseCase {
+	return &TouchUseCase{
+		resolver: resolver,
+		repoFor:  repoFor,
+		histFor:  histFor,
+	}
+}
+
+func (uc *TouchUseCase) Execute(ctx context.Context, input TouchInput) (*CommitOutput, error) {
+	key, err := aliasedKey(uc.resolver, input.Scope, input.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.repoFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+	hist, err := uc.histFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+	if err := checkWritable(ctx, scope, hist); err != nil {
+		return nil, err
+	}
+
+	mem, err := repo.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	mem.UpdatedAt = time.Now()
+	if err := repo.Save(ctx, mem); err != nil {
+		return nil, fmt.Errorf("save memory: %w", err)
+	}
+
+	commit, err := hist.CommitAllowEmpty(ctx, "touch: "+key.String())
+	if err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	output := toCommitOutput(commit)
+	return &output, nil
+}
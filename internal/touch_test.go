@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestTouchUseCaseCommitsUnchangedMemory(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	histFor := func(Scope) (HistoryRepository, error) { return repo, nil }
+
+	key, _ := NewKey("notes/todo")
+	if err := repo.Save(ctx, NewMemory(key, []byte("write tests"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	before, err := repo.Commit(ctx, "set: notes/todo")
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	touch := NewTouchUseCase(resolver, repoFor, histFor)
+	out, err := touch.Execute(ctx, TouchInput{Key: "notes/todo"})
+	if err != nil {
+		t.Fatalf("touch: %v", err)
+	}
+	if out.Message != "touch: notes/todo" {
+		t.Errorf("message = %q", out.Message)
+	}
+	if len(out.Parents) != 1 || out.Parents[0] != before.Hash {
+		t.Errorf("touch commit parents = %v, want [%s]", out.Parents, before.Hash)
+	}
+
+	commits, err := repo.Log(ctx, 1)
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	if len(commits) != 1 || commits[0].Hash != out.Hash {
+		t.Errorf("HEAD should be the touch commit %s, got %v", out.Hash, commits)
+	}
+
+	mem, err := repo.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if string(mem.Content) != "write tests" {
+		t.Errorf("content changed to %q", mem.Content)
+	}
+}
+
+func TestTouchUseCaseMissingKey(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	histFor := func(Scope) (HistoryRepository, error) { return repo, nil }
+
+	touch := NewTouchUseCase(resolver, repoFor, histFor)
+	if _, err := touch.Execute(context.Background(), TouchInput{Key: "missing"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
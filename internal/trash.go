@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// TrashPrefix is where soft-deleted memories are kept. Keys may not start
+// with a dot, so trashed memories never collide with live ones and List
+// skips them unless asked for this prefix.
+const TrashPrefix = ".trash/"
+
+// TrashKey returns the key a memory is moved to when it is soft-deleted.
+func TrashKey(key Key) Key {
+	return Key(TrashPrefix + key.String())
+}
+
+type TrashInput struct {
+	Key   string
+	Scope string
+}
+
+// --- TrashUseCase ---
+
+// TrashUseCase lists, restores and empties memories soft-deleted by
+// DeleteMemoryUseCase. Restored memories are re-embedded when an embedder
+// is configured.
+type TrashUseCase struct {
+	resolver *ScopeResolver
+	repoFor  func(Scope) (MemoryRepository, error)
+	indexFor func(Scope) (VectorIndex, error)
+	embedder EmbedderFunc
+}
+
+func NewTrashUseCase(
+	resolver *ScopeResolver,
+	repoFor func(Scope) (MemoryRepository, error),
+	indexFor func(Scope) (VectorIndex, error),
+	embedder EmbedderFunc,
+) *TrashUseCase {
+	return &TrashUseCase{
+		resolver: resolver,
+		repoFor:  repoFor,
+		indexFor: indexFor,
+		embedder: embedder,
+	}
+}
+
+// List returns the original keys of all trashed memories.
+func (uc *TrashUseCase) List(ctx context.Context, input TrashInput) ([]string, error) {
+	repo, err := uc.repoFor(uc.resolver.Resolve(input.Scope))
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+
+	memories, err := repo.List(ctx, TrashPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("list trash: %w", err)
+	}
+
+	keys := make([]string, len(memories))
+	for i, mem := range memories {
+		keys[i] = strings.TrimPrefix(mem.Key.String(), TrashPrefix)
+	}
+	return keys, nil
+}
+
+// Restore moves a trashed memory back to its original key. It refuses to
+// overwrite a live memory that has since been created under that key.
+func (uc *TrashUseCase) Restore(ctx context.Context, input TrashInput) error {
+	key, err := aliasedKey(uc.resolver, input.Scope, input.Key)
+	if err != nil {
+		return err
+	}
+
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.repoFor(scope)
+	if err != nil {
+		return fmt.Errorf("get repository: %w", err)
+	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return err
+	}
+
+	mem, err := repo.Get(ctx, TrashKey(key))
+	if err != nil {
+		return fmt.Errorf("get trashed memory: %w", err)
+	}
+
+	exists, err := repo.Exists(ctx, key)
+	if err != nil {
+		return fmt.Errorf("check memory: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("restore %s: %w", key, ErrAlreadyExists)
+	}
+
+	if err := repo.Save(ctx, NewMemory(key, mem.Content)); err != nil {
+		return fmt.Errorf("save memory: %w", err)
+	}
+	if err := repo.Delete(ctx, TrashKey(key)); err != nil {
+		return fmt.Errorf("remove from trash: %w", err)
+	}
+
+	embedder := writeEmbedder(ctx, scope, uc.embedder, uc.indexFor, key)
+	if embedder == nil {
+		return nil
+	}
+	index, err := uc.indexFor(scope)
+	if err != nil {
+		skipIndexUpdate(ctx, scope, key, SkipIndexUnavailable, err)
+		return nil
+	}
+	if err := indexMemory(ctx, scope, index, embedder, key, string(mem.Content)); err != nil {
+		skipIndexUpdate(ctx, scope, key, SkipEmbeddingFailed, err)
+		return nil
+	}
+	recordIndexSize(ctx, index)
+
+	return nil
+}
+
+// Empty permanently removes every trashed memory and returns their
+// original keys.
+func (uc *TrashUseCase) Empty(ctx context.Context, input TrashInput) ([]string, error) {
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.repoFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return nil, err
+	}
+
+	memories, err := repo.List(ctx, TrashPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("list trash: %w", err)
+	}
+
+	keys := make([]string, 0, len(memories))
+	for _, mem := range memories {
+		if err := repo.Delete(ctx, mem.Key); err != nil {
+			return nil, fmt.Errorf("delete %s: %w", mem.Key, err)
+		}
+		keys = append(keys, strings.TrimPrefix(mem.Key.String(), TrashPrefix))
+	}
+	return keys, nil
+}
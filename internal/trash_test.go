@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestSoftDeleteRestoreRoundTrip(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+
+	key, _ := NewKey("notes/todo")
+	if err := repo.Save(ctx, NewMemory(key, []byte("write tests"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	del := NewDeleteMemoryUseCase(resolver, repoFor, nil)
+	if _, err := del.Execute(ctx, DeleteMemoryInput{Key: "notes/todo", Soft: true}); err != nil {
+		t.Fatalf("soft delete: %v", err)
+	}
+
+	if exists, _ := repo.Exists(ctx, key); exists {
+		t.Error("expected key to be gone after soft delete")
+	}
+	live, err := repo.List(ctx, "")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(live) != 0 {
+		t.Errorf("expected trashed memory to be hidden from list, got %v", live)
+	}
+
+	trash := NewTrashUseCase(resolver, repoFor, nil, nil)
+	trashed, err := trash.List(ctx, TrashInput{})
+	if err != nil {
+		t.Fatalf("trash list: %v", err)
+	}
+	if len(trashed) != 1 || trashed[0] != "notes/todo" {
+		t.Fatalf("trash list = %v, want [notes/todo]", trashed)
+	}
+
+	if err := trash.Restore(ctx, TrashInput{Key: "notes/todo"}); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	mem, err := repo.Get(ctx, key)
+	if err != nil {
+		t.Fatalf("get restored: %v", err)
+	}
+	if string(mem.Content) != "write tests" {
+		t.Errorf("restored content = %q, want %q", mem.Content, "write tests")
+	}
+	if trashed, _ := trash.List(ctx, TrashInput{}); len(trashed) != 0 {
+		t.Errorf("expected empty trash after restore, got %v", trashed)
+	}
+}
+
+func TestRestoreRefusesToOverwrite(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+
+	key, _ := NewKey("a")
+	if err := repo.Save(ctx, NewMemory(key, []byte("old"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	del := NewDeleteMemoryUseCase(resolver, repoFor, nil)
+	if _, err := del.Execute(ctx, DeleteMemoryInput{Key: "a", Soft: true}); err != nil {
+		t.Fatalf("soft delete: %v", err)
+	}
+	if err := repo.Save(ctx, NewMemory(key, []byte("new"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	trash := NewTrashUseCase(resolver, repoFor, nil, nil)
+	if err := trash.Restore(ctx, TrashInput{Key: "a"}); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists, got %v", err)
+	}
+}
+
+func TestTrashEmpty(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+
+	for _, k := range []string{"a", "b/c"} {
+		key, _ := NewKey(k)
+		if err := repo.Save(ctx, NewMemory(key, []byte(k))); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+	del := NewDeleteMemoryUseCase(resolver, repoFor, nil)
+	if _, err := del.Execute(ctx, DeleteMemoryInput{Key: "a", Soft: true}); err != nil {
+		t.Fatalf("soft delete: %v", err)
+	}
+
+	trash := NewTrashUseCase(resolver, repoFor, nil, nil)
+	emptied, err := trash.Empty(ctx, TrashInput{})
+	if err != nil {
+		t.Fatalf("empty: %v", err)
+	}
+	if len(emptied) != 1 || emptied[0] != "a" {
+		t.Errorf("emptied = %v, want [a]", emptied)
+	}
+	if err := trash.Restore(ctx, TrashInput{Key: "a"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound restoring after empty, got %v", err)
+	}
+
+	live, _ := repo.List(ctx, "")
+	if len(live) != 1 || live[0].Key != "b/c" {
+		t.Errorf("expected b/c to survive, got %v", live)
+	}
+}
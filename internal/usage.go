@@ -0,0 +1,281 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+// UsageFilename is the log of provider calls in a scope's .mem directory.
+// It is never committed.
+const UsageFilename = "usage.jsonl"
+
+// UsageRecord is one line of the usage log. Token counts are zero when the
+// provider did not report them; the character counts are always set.
+type UsageRecord struct {
+	Time         time.Time `json:"time"`
+	Provider     string    `json:"provider"`
+	Model        string    `json:"model"`
+	Task         string    `json:"task"`
+	InputTokens  int64     `json:"input_tokens,omitempty"`
+	OutputTokens int64     `json:"output_tokens,omitempty"`
+	InputChars   int       `json:"input_chars"`
+	OutputChars  int       `json:"output_chars"`
+}
+
+// ModelPrice is what a model costs in USD per million tokens.
+type ModelPrice struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// charsPerToken estimates tokens for calls whose provider reported none.
+const charsPerToken = 4
+
+// Tokens returns the token counts of r, estimated from its character
+// counts when the provider reported none.
+func (r UsageRecord) Tokens() (input, output int64, estimated bool) {
+	if r.InputTokens > 0 || r.OutputTokens > 0 {
+		return r.InputTokens, r.OutputTokens, false
+	}
+	return int64(r.InputChars / charsPerToken), int64(r.OutputChars / charsPerToken), true
+}
+
+// AppendUsage adds rec to the usage log at path.
+func AppendUsage(path string, rec UsageRecord) error {
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadUsage returns the records in the usage log at path made at or after
+// since. A missing log has no records; unreadable lines are skipped.
+func ReadUsage(path string, since time.Time) ([]UsageRecord, error) {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []UsageRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec UsageRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		if rec.Time.Before(since) {
+			continue
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+type usageKey struct{}
+
+// usageCounter collects the token counts a provider reports during one call.
+type usageCounter struct {
+	mu            sync.Mutex
+	input, output int64
+}
+
+// addUsage records tokens reported by a provider against the call in ctx.
+// It is a no-op outside a metered call.
+func addUsage(ctx context.Context, input, output int64) {
+	u, _ := ctx.Value(usageKey{}).(*usageCounter)
+	if u == nil {
+		return
+	}
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.input += input
+	u.output += output
+}
+
+// meteredProvider appends a UsageRecord for every successful call to the
+// usage log of the scope it was resolved for.
+type meteredProvider struct {
+	Provider
+	path     string
+	provider string
+	model    string
+	task     string
+}
+
+func (p *meteredProvider) Complete(ctx context.Context, prompt string) (string, error) {
+	ctx, u := p.begin(ctx)
+	text, err := p.Provider.Complete(ctx, prompt)
+	if err == nil {
+		p.record(ctx, u, len(prompt), len(text))
+	}
+	return text, err
+}
+
+func (p *meteredProvider) GenerateObject(ctx context.Context, prompt string, target any) error {
+	ctx, u := p.begin(ctx)
+	err := p.Provider.GenerateObject(ctx, prompt, target)
+	if err == nil {
+		out, _ := json.Marshal(target)
+		p.record(ctx, u, len(prompt), len(out))
+	}
+	return err
+}
+
+func (p *meteredProvider) Stream(ctx context.Context, prompt string) (<-chan string, error) {
+	ctx, u := p.begin(ctx)
+	in, err := p.Provider.Stream(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan string, cap(in))
+	go func() {
+		defer close(out)
+		chars := 0
+		for text := range in {
+			chars += len(text)
+			out <- text
+		}
+		p.record(ctx, u, len(prompt), chars)
+	}()
+	return out, nil
+}
+
+func (p *meteredProvider) begin(ctx context.Context) (context.Context, *usageCounter) {
+	u := &usageCounter{}
+	return context.WithValue(ctx, usageKey{}, u), u
+}
+
+// record logs the call. Failing to write the log only warns: usage
+// tracking must never fail the call it measures.
+func (p *meteredProvider) record(ctx context.Context, u *usageCounter, inputChars, outputChars int) {
+	u.mu.Lock()
+	rec := UsageRecord{
+		Time:         time.Now().UTC(),
+		Provider:     p.provider,
+		Model:        p.model,
+		Task:         p.task,
+		InputTokens:  u.input,
+		OutputTokens: u.output,
+		InputChars:   inputChars,
+		OutputChars:  outputChars,
+	}
+	u.mu.Unlock()
+
+	if err := AppendUsage(p.path, rec); err != nil {
+		LoggerFrom(ctx).Warn("failed to record provider usage", "path", p.path, "error", err)
+	}
+}
+
+// --- ProviderUsageUseCase ---
+
+type ProviderUsageInput struct {
+	Scope string
+	Since time.Time // zero means all recorded calls
+}
+
+// UsageRow sums the calls of one provider, model and task.
+type UsageRow struct {
+	Provider     string
+	Model        string
+	Task         string
+	Calls        int
+	InputTokens  int64
+	OutputTokens int64
+	Estimated    int     // calls whose tokens were estimated from characters
+	Cost         float64 // USD; zero when the model has no price
+	Priced       bool    // the model has a price under prices: in the config
+}
+
+type ProviderUsageOutput struct {
+	Rows  []UsageRow
+	Total UsageRow
+}
+
+// ProviderUsageUseCase reports how many calls and tokens providers used,
+// and what they cost at the prices in the config.
+type ProviderUsageUseCase struct {
+	resolver *ScopeResolver
+}
+
+func NewProviderUsageUseCase(resolver *ScopeResolver) *ProviderUsageUseCase {
+	return &ProviderUsageUseCase{resolver: resolver}
+}
+
+func (uc *ProviderUsageUseCase) Execute(input ProviderUsageInput) (*ProviderUsageOutput, error) {
+	scope := uc.resolver.Resolve(input.Scope)
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := ReadUsage(scope.UsagePath(), input.Since)
+	if err != nil {
+		return nil, fmt.Errorf("read usage: %w", err)
+	}
+
+	type rowKey struct{ provider, model, task string }
+	rows := make(map[rowKey]*UsageRow)
+	output := &ProviderUsageOutput{Total: UsageRow{Priced: true}}
+	for _, rec := range records {
+		key := rowKey{rec.Provider, rec.Model, rec.Task}
+		row, ok := rows[key]
+		if !ok {
+			_, priced := cfg.Prices[rec.Model]
+			row = &UsageRow{Provider: rec.Provider, Model: rec.Model, Task: rec.Task, Priced: priced}
+			rows[key] = row
+		}
+
+		in, out, estimated := rec.Tokens()
+		row.Calls++
+		row.InputTokens += in
+		row.OutputTokens += out
+		if estimated {
+			row.Estimated++
+		}
+		if price, ok := cfg.Prices[rec.Model]; ok {
+			row.Cost += (float64(in)*price.Input + float64(out)*price.Output) / 1e6
+		}
+	}
+
+	for _, row := range rows {
+		output.Rows = append(output.Rows, *row)
+		output.Total.Calls += row.Calls
+		output.Total.InputTokens += row.InputTokens
+		output.Total.OutputTokens += row.OutputTokens
+		output.Total.Estimated += row.Estimated
+		output.Total.Cost += row.Cost
+		output.Total.Priced = output.Total.Priced && row.Priced
+	}
+	sort.Slice(output.Rows, func(i, j int) bool {
+		a, b := output.Rows[i], output.Rows[j]
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		if a.Task != b.Task {
+			return a.Task < b.Task
+		}
+		return a.Model < b.Model
+	})
+	return output, nil
+}
@@ -0,0 +1,191 @@
+package internal
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// tokenProvider reports token usage the way FantasyProvider does.
+type tokenProvider struct {
+	NoModels
+	input, output int64
+}
+
+func (p *tokenProvider) Complete(ctx context.Context, _ string) (string, error) {
+	addUsage(ctx, p.input, p.output)
+	return "a reply", nil
+}
+
+func (p *tokenProvider) GenerateObject(ctx context.Context, _ string, target any) error {
+	addUsage(ctx, p.input, p.output)
+	target.(*Summary).Title = "title"
+	return nil
+}
+
+func (p *tokenProvider) Stream(context.Context, string) (<-chan string, error) {
+	ch := make(chan string, 2)
+	ch <- "one "
+	ch <- "two"
+	close(ch)
+	return ch, nil
+}
+
+func TestMeteredProviderRecordsUsage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), UsageFilename)
+	ctx := context.Background()
+	p := &meteredProvider{
+		Provider: &tokenProvider{input: 120, output: 30},
+		path:     path, provider: "openai", model: "gpt-4o-mini", task: TaskSummarize,
+	}
+
+	if _, err := p.Complete(ctx, "prompt"); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	var summary Summary
+	if err := p.GenerateObject(ctx, "prompt", &summary); err != nil {
+		t.Fatalf("generate object: %v", err)
+	}
+	ch, err := p.Stream(ctx, "12345678")
+	if err != nil {
+		t.Fatalf("stream: %v", err)
+	}
+	for range ch {
+	}
+
+	// The stream records once its channel is drained; wait for the write.
+	var records []UsageRecord
+	for range 100 {
+		if records, err = ReadUsage(path, time.Time{}); err == nil && len(records) == 3 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want 3 (err %v)", len(records), err)
+	}
+
+	for _, rec := range records[:2] {
+		if rec.Provider != "openai" || rec.Model != "gpt-4o-mini" || rec.Task != TaskSummarize {
+			t.Errorf("record = %+v", rec)
+		}
+		if in, out, estimated := rec.Tokens(); in != 120 || out != 30 || estimated {
+			t.Errorf("tokens = %d/%d estimated=%v, want reported 120/30", in, out, estimated)
+		}
+	}
+
+	// Stream reported no tokens, so they are estimated from characters.
+	if in, out, estimated := records[2].Tokens(); in != 2 || out != 1 || !estimated {
+		t.Errorf("stream tokens = %d/%d estimated=%v, want estimated 2/1", in, out, estimated)
+	}
+}
+
+func TestMeteredProviderIgnoresLogErrors(t *testing.T) {
+	p := &meteredProvider{
+		Provider: &tokenProvider{},
+		path:     filepath.Join(t.TempDir(), "missing", UsageFilename),
+	}
+	if _, err := p.Complete(context.Background(), "prompt"); err != nil {
+		t.Errorf("a failed usage write must not fail the call: %v", err)
+	}
+}
+
+func TestProviderFactoryMetersCalls(t *testing.T) {
+	_, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	scope := resolver.Resolve("")
+
+	cfg := DefaultConfig()
+	cfg.Providers["openai"] = ProviderConfig{Model: "gpt-4o-mini"}
+	cfg.DefaultProvider = "openai"
+	if err := SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	factory := NewProviderFactory()
+	factory.construct = func(context.Context, FantasyConfig) (Provider, error) {
+		return &tokenProvider{input: 10, output: 5}, nil
+	}
+
+	p, err := factory.For(ctx, scope, TaskHookSummarize, ProviderOverride{})
+	if err != nil {
+		t.Fatalf("for: %v", err)
+	}
+	if _, err := p.Complete(ctx, "diff"); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+
+	records, err := ReadUsage(scope.UsagePath(), time.Time{})
+	if err != nil {
+		t.Fatalf("read usage: %v", err)
+	}
+	if len(records) != 1 || records[0].Task != TaskHookSummarize || records[0].Model != "gpt-4o-mini" {
+		t.Errorf("records = %+v", records)
+	}
+}
+
+func TestProviderUsageUseCase(t *testing.T) {
+	_, resolver := setupUseCaseTest(t)
+	scope := resolver.Resolve("")
+
+	cfg := DefaultConfig()
+	cfg.Prices = map[string]ModelPrice{"gpt-4o-mini": {Input: 1, Output: 2}}
+	if err := SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	now := time.Now()
+	for _, rec := range []UsageRecord{
+		{Time: now.Add(-30 * 24 * time.Hour), Provider: "openai", Model: "gpt-4o-mini", Task: TaskSummarize, InputTokens: 1e6},
+		{Time: now, Provider: "openai", Model: "gpt-4o-mini", Task: TaskHookSummarize, InputTokens: 500_000, OutputTokens: 250_000},
+		{Time: now, Provider: "openai", Model: "gpt-4o-mini", Task: TaskHookSummarize, InputTokens: 500_000, OutputTokens: 250_000},
+		{Time: now, Provider: "local", Model: "llama", Task: TaskHookSummarize, InputChars: 400, OutputChars: 40},
+	} {
+		if err := AppendUsage(scope.UsagePath(), rec); err != nil {
+			t.Fatalf("append usage: %v", err)
+		}
+	}
+
+	out, err := NewProviderUsageUseCase(resolver).Execute(ProviderUsageInput{Since: now.Add(-7 * 24 * time.Hour)})
+	if err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+
+	if len(out.Rows) != 2 {
+		t.Fatalf("got %d rows, want 2: %+v", len(out.Rows), out.Rows)
+	}
+	local, openai := out.Rows[0], out.Rows[1]
+	if local.Provider != "local" || local.Calls != 1 || local.InputTokens != 100 || local.Estimated != 1 || local.Priced {
+		t.Errorf("local row = %+v", local)
+	}
+	if openai.Calls != 2 || openai.InputTokens != 1e6 || openai.OutputTokens != 500_000 || !openai.Priced {
+		t.Errorf("openai row = %+v", openai)
+	}
+	if math.Abs(openai.Cost-2) > 1e-9 {
+		t.Errorf("openai cost = %v, want 2", openai.Cost)
+	}
+	if out.Total.Calls != 3 || out.Total.Priced {
+		t.Errorf("total = %+v", out.Total)
+	}
+}
+
+func TestUsageFileIsNotAMemory(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	scope := resolver.Resolve("")
+
+	if err := os.WriteFile(scope.UsagePath(), []byte("{}\n"), 0644); err != nil {
+		t.Fatalf("write usage: %v", err)
+	}
+	memories, err := repo.List(context.Background(), "")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	for _, mem := range memories {
+		if mem.Key.String() == UsageFilename {
+			t.Errorf("usage log listed as a memory")
+		}
+	}
+}
@@ -1,11 +1,19 @@
 package internal
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
-	"log/slog"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // Use case input/output DTOs
@@ -14,6 +22,7 @@ type SetMemoryInput struct {
 	Key     string
 	Content string
 	Scope   string
+	Tags    []string // kept by repositories that store metadata
 }
 
 type GetMemoryInput struct {
@@ -24,38 +33,117 @@ type GetMemoryInput struct {
 type GetMemoryOutput struct {
 	Key       string
 	Content   string
+	Tags      []string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// AliasOf is the key whose content was returned when Key is an alias
+	// memory.
+	AliasOf string
 }
 
 type DeleteMemoryInput struct {
-	Key   string
-	Scope string
+	Key    string
+	Prefix string // delete every key under Prefix instead of a single Key
+	Scope  string
+	DryRun bool
+	Soft   bool // move to TrashPrefix instead of removing
+}
+
+type DeleteMemoryOutput struct {
+	Keys   []string
+	DryRun bool
 }
 
 type ListMemoriesInput struct {
-	Prefix string
-	Scope  string
+	// Prefix matches whole key segments: "foo" lists foo and foo/y but not
+	// foobar/x. With RawPrefix it is a plain string prefix instead.
+	Prefix    string
+	RawPrefix bool
+	Scope     string
+	SortBy    string // SortByKey (default), SortByUpdated or SortByCreated
+	// Limit caps the number of memories listed; 0 lists them all.
+	Limit int
+	// After is a cursor: list only the memories following this key in
+	// the chosen order, i.e. the Next of the previous page.
+	After string
 }
 
+// Orders for ListMemoriesInput.SortBy. Time orders list the newest first.
+const (
+	SortByKey     = "key"
+	SortByUpdated = "updated"
+	SortByCreated = "created"
+)
+
 type ListMemoriesOutput struct {
 	Memories []GetMemoryOutput
+	// Next is the After that lists the following page, or empty when
+	// nothing was cut off by Limit.
+	Next string
 }
 
 type CommitInput struct {
 	Message string
 	Scope   string
+	NoSign  bool // skip git.signing_key for this commit
+	// Op and Key describe the write being committed when Message is
+	// empty: they fill in git.message_template, or make "<op>: <key>".
+	Op  string
+	Key string
 }
 
 type CommitOutput struct {
 	Hash      string
 	Message   string
+	Author    string
+	Email     string
 	Timestamp time.Time
+	Parents   []string
+	Refs      []string // set by log with All
+}
+
+func toCommitOutput(c *Commit) CommitOutput {
+	return CommitOutput{
+		Hash:      c.Hash,
+		Message:   c.Message,
+		Author:    c.Author,
+		Email:     c.Email,
+		Timestamp: c.Timestamp,
+		Parents:   append([]string{}, c.Parents...),
+	}
 }
 
+// LogInput selects commits. The filters combine: a commit is shown only
+// if it matches all of them, and Limit counts matching commits.
 type LogInput struct {
 	Limit int
 	Scope string
+	All   bool // follow every branch, not just HEAD
+
+	Since  time.Time // zero means no lower bound
+	Until  time.Time // zero means no upper bound
+	Grep   string    // case-insensitive substring of the message
+	Author string    // case-insensitive substring of the author name
+}
+
+func (in LogInput) filtered() bool {
+	return !in.Since.IsZero() || !in.Until.IsZero() || in.Grep != "" || in.Author != ""
+}
+
+func (in LogInput) matches(c *Commit) bool {
+	if !in.Since.IsZero() && c.Timestamp.Before(in.Since) {
+		return false
+	}
+	if !in.Until.IsZero() && c.Timestamp.After(in.Until) {
+		return false
+	}
+	if in.Grep != "" && indexFold(c.Message, in.Grep) < 0 {
+		return false
+	}
+	if in.Author != "" && indexFold(c.Author, in.Author) < 0 {
+		return false
+	}
+	return true
 }
 
 type LogOutput struct {
@@ -65,21 +153,32 @@ type LogOutput struct {
 type DiffInput struct {
 	Ref   string
 	Scope string
+	Stat  bool // count changed lines per file instead of building the patch
 }
 
 type DiffOutput struct {
-	Diff string
+	Diff  string
+	Stats []FileStat
 }
 
 type RevertInput struct {
-	Ref   string
-	Scope string
+	Ref    string
+	Scope  string
+	DryRun bool
+}
+
+type RevertOutput struct {
+	Target    CommitOutput
+	Discarded []CommitOutput
+	DryRun    bool
 }
 
 type SearchInput struct {
-	Query string
-	Limit int
-	Scope string
+	Query   string
+	Limit   int
+	Scope   string
+	SearchK int
+	Prefix  string // only search keys under this prefix
 }
 
 type SearchOutput struct {
@@ -89,16 +188,51 @@ type SearchOutput struct {
 type SearchResultOutput struct {
 	Key   string
 	Score float32
+	// Snippet is the first line of content containing a keyword match,
+	// shortened around the match. Empty when only the key matched.
+	Snippet string
+	// Distance, Similarity and Index explain a semantic result: the raw
+	// angular distance, the cosine similarity it stands for, and the
+	// scope whose index returned it.
+	Distance   float32
+	Similarity float32
+	Index      string
 }
 
 type RebuildIndexInput struct {
 	Scope    string
-	NumTrees int
+	NumTrees int // 0 uses embeddings.num_trees from the config
+	DryRun   bool
+	Since    string // only re-embed memories changed between this ref and HEAD
+}
+
+type RebuildIndexOutput struct {
+	Keys    []string
+	Removed []string // with Since, keys deleted since the ref
+	DryRun  bool
+}
+
+type WarmupInput struct {
+	Scope string
+}
+
+type WarmupOutput struct {
+	Device    string
+	Dimension int
+}
+
+type EmbedderInfoOutput struct {
+	Device    string
+	Model     string
+	Dimension int
 }
 
 type SummarizeInput struct {
-	Prefix string
-	Scope  string
+	Prefix   string
+	Scope    string
+	Provider string // overrides the configured provider for this run
+	Model    string // overrides the configured model for this run
+	NoRedact bool   // sends content unredacted, ignoring the redaction config
 }
 
 type SummarizeOutput struct {
@@ -106,11 +240,15 @@ type SummarizeOutput struct {
 	Overview  string
 	KeyPoints []string
 	Tags      []string
+	Sources   []string // keys of the summarized memories
 }
 
 type AutoTagInput struct {
-	Key   string
-	Scope string
+	Key      string
+	Scope    string
+	Provider string // overrides the configured provider for this run
+	Model    string // overrides the configured model for this run
+	NoRedact bool   // sends content unredacted, ignoring the redaction config
 }
 
 type AutoTagOutput struct {
@@ -120,8 +258,11 @@ type AutoTagOutput struct {
 }
 
 type BranchInput struct {
-	Name  string
-	Scope string
+	Name    string
+	From    string // ref to branch from; HEAD if empty
+	NewName string // target name for a rename
+	Scope   string
+	Force   bool // switch over uncommitted changes, delete unmerged branches
 }
 
 type BranchOutput struct {
@@ -145,6 +286,20 @@ type AddMemoryInput struct {
 	Content string
 	Scope   string
 	Message string
+	// Timestamp prefixes the appended text with the time, in
+	// TimestampFormat, else journal.timestamp_format, else RFC 3339.
+	Timestamp       bool
+	TimestampFormat string
+	// Section puts a "## <date>" heading before the text when the last
+	// heading in the memory is for another day.
+	Section bool
+	// Now is the time Timestamp and Section use; zero means now.
+	Now time.Time
+}
+
+type AddMemoryOutput struct {
+	CommitOutput
+	Created bool // the memory did not exist before
 }
 
 type EditMemoryInput struct {
@@ -157,6 +312,7 @@ type EditMemoryInput struct {
 // UseCases is the holder struct that aggregates all use cases.
 type UseCases struct {
 	SetMemory      *SetMemoryUseCase
+	BulkSet        *BulkSetUseCase
 	GetMemory      *GetMemoryUseCase
 	DeleteMemory   *DeleteMemoryUseCase
 	ListMemories   *ListMemoriesUseCase
@@ -169,21 +325,41 @@ type UseCases struct {
 	KeywordSearch  *KeywordSearchUseCase
 	SemanticSearch *SemanticSearchUseCase
 	RebuildIndex   *RebuildIndexUseCase
+	SyncIndex      *SyncIndexUseCase
+	IndexVector    *IndexVectorUseCase
+	Verify         *VerifyUseCase
+	Doctor         *DoctorUseCase
+	MigrateKeys    *MigrateKeysUseCase
+	Warmup         *WarmupUseCase
+	EmbedderInfo   *EmbedderInfoUseCase
 	Summarize      *SummarizeUseCase
+	Ask            *AskUseCase
 	AutoTag        *AutoTagUseCase
 	BranchCurrent  *BranchCurrentUseCase
 	BranchList     *BranchListUseCase
 	BranchCreate   *BranchCreateUseCase
 	BranchSwitch   *BranchSwitchUseCase
 	BranchDelete   *BranchDeleteUseCase
+	BranchRename   *BranchRenameUseCase
 	ProviderList   *ProviderListUseCase
 	ProviderAdd    *ProviderAddUseCase
 	ProviderRemove *ProviderRemoveUseCase
 	ProviderSetDef *ProviderSetDefaultUseCase
 	ProviderTest   *ProviderTestUseCase
+	ProviderModels *ProviderModelsUseCase
+	ProviderUsage  *ProviderUsageUseCase
 	InstallHook    *InstallHookUseCase
 	UninstallHook  *UninstallHookUseCase
 	RunHook        *RunHookUseCase
+	Template       *TemplateUseCase
+	Alias          *AliasUseCase
+	Dedupe         *DedupeUseCase
+	Compact        *CompactUseCase
+	Stats          *StatsUseCase
+	Trash          *TrashUseCase
+	Touch          *TouchUseCase
+	Attachment     *AttachmentUseCase
+	Snapshot       *SnapshotUseCase
 }
 
 // --- SetMemoryUseCase ---
@@ -192,7 +368,7 @@ type SetMemoryUseCase struct {
 	resolver *ScopeResolver
 	repoFor  func(Scope) (MemoryRepository, error)
 	indexFor func(Scope) (VectorIndex, error)
-	embedder Embedder
+	embedder EmbedderFunc
 	ignore   func(Scope) (*IgnoreMatcher, error)
 }
 
@@ -200,7 +376,7 @@ func NewSetMemoryUseCase(
 	resolver *ScopeResolver,
 	repoFor func(Scope) (MemoryRepository, error),
 	indexFor func(Scope) (VectorIndex, error),
-	embedder Embedder,
+	embedder EmbedderFunc,
 	ignore func(Scope) (*IgnoreMatcher, error),
 ) *SetMemoryUseCase {
 	return &SetMemoryUseCase{
@@ -213,28 +389,42 @@ func NewSetMemoryUseCase(
 }
 
 func (uc *SetMemoryUseCase) Execute(ctx context.Context, input SetMemoryInput) error {
-	key, err := NewKey(input.Key)
+	MetricsFrom(ctx).IncOp(OpSet)
+
+	scope := uc.resolver.Resolve(input.Scope)
+
+	name, err := resolveAliasKey(uc.resolver, input.Scope, input.Key)
+	if err != nil {
+		return err
+	}
+	key, err := policyKey(scope, name)
 	if err != nil {
 		return err
 	}
-
-	scope := uc.resolver.Resolve(input.Scope)
 
 	if uc.ignore != nil {
 		matcher, err := uc.ignore(scope)
 		if err == nil && matcher.MatchKey(key) {
-			return fmt.Errorf("key %q is blocked by .memignore", input.Key)
+			return fmt.Errorf("key %q is blocked by .memignore", name)
 		}
 	}
 
+	if err := checkAliasMemoryContent(key, []byte(input.Content)); err != nil {
+		return err
+	}
+
 	repo, err := uc.repoFor(scope)
 	if err != nil {
 		return fmt.Errorf("get repository: %w", err)
 	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return err
+	}
 
 	mem := &Memory{
 		Key:       key,
 		Content:   []byte(input.Content),
+		Metadata:  Metadata{Tags: input.Tags},
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
@@ -243,28 +433,45 @@ func (uc *SetMemoryUseCase) Execute(ctx context.Context, input SetMemoryInput) e
 		return fmt.Errorf("save memory: %w", err)
 	}
 
-	if uc.embedder == nil || uc.indexFor == nil {
+	embedder := writeEmbedder(ctx, scope, uc.embedder, uc.indexFor, key)
+	if embedder == nil {
 		return nil
 	}
 
 	index, err := uc.indexFor(scope)
 	if err != nil {
-		slog.Warn("skipping index update: failed to get index", "error", err)
+		skipIndexUpdate(ctx, scope, key, SkipIndexUnavailable, err)
 		return nil
 	}
 
-	vec, err := uc.embedder.Embed(ctx, input.Content)
-	if err != nil {
-		slog.Warn("skipping index update: embedding failed", "error", err)
+	if err := indexMemory(ctx, scope, index, embedder, key, input.Content); err != nil {
+		skipIndexUpdate(ctx, scope, key, SkipEmbeddingFailed, err)
 		return nil
 	}
-
-	emb := NewEmbedding(vec, "local")
-	_ = index.Add(ctx, key, emb)
+	recordIndexSize(ctx, index)
 
 	return nil
 }
 
+// policyKey parses s as a key under the key policy configured for scope.
+func policyKey(scope Scope, s string) (Key, error) {
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		return "", fmt.Errorf("load config: %w", err)
+	}
+	return cfg.Keys.NewKey(s)
+}
+
+// normalizeKey returns key as stored in scope, for lookups that must find
+// a memory whatever case it was asked for in.
+func normalizeKey(scope Scope, key Key) Key {
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		return key
+	}
+	return cfg.Keys.Normalize(key)
+}
+
 // --- GetMemoryUseCase ---
 
 type GetMemoryUseCase struct {
@@ -283,7 +490,13 @@ func NewGetMemoryUseCase(
 }
 
 func (uc *GetMemoryUseCase) Execute(ctx context.Context, input GetMemoryInput) (*GetMemoryOutput, error) {
-	key, err := NewKey(input.Key)
+	MetricsFrom(ctx).IncOp(OpGet)
+
+	name, err := resolveAliasKey(uc.resolver, input.Scope, input.Key)
+	if err != nil {
+		return nil, err
+	}
+	key, err := NewKey(name)
 	if err != nil {
 		return nil, err
 	}
@@ -299,20 +512,82 @@ func (uc *GetMemoryUseCase) Execute(ctx context.Context, input GetMemoryInput) (
 			continue
 		}
 
-		mem, err := repo.Get(ctx, key)
+		mem, err := repo.Get(ctx, normalizeKey(scope, key))
 		if err != nil {
 			continue
 		}
+		target, err := followAliasMemory(ctx, repo, mem)
+		if err != nil {
+			return nil, err
+		}
 
-		return &GetMemoryOutput{
+		output := &GetMemoryOutput{
 			Key:       mem.Key.String(),
-			Content:   string(mem.Content),
-			CreatedAt: mem.CreatedAt,
-			UpdatedAt: mem.UpdatedAt,
-		}, nil
+			Content:   string(target.Content),
+			Tags:      target.Metadata.Tags,
+			CreatedAt: target.CreatedAt,
+			UpdatedAt: target.UpdatedAt,
+		}
+		if target != mem {
+			output.AliasOf = target.Key.String()
+		}
+		return output, nil
+	}
+
+	return nil, aliasNotFound(input.Key, name)
+}
+
+// Open is Execute for memories too large to read whole: it returns the
+// content as a stream, following alias memories the same way. The caller
+// closes it.
+func (uc *GetMemoryUseCase) Open(ctx context.Context, input GetMemoryInput) (io.ReadCloser, error) {
+	MetricsFrom(ctx).IncOp(OpGet)
+
+	name, err := resolveAliasKey(uc.resolver, input.Scope, input.Key)
+	if err != nil {
+		return nil, err
+	}
+	key, err := NewKey(name)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, ErrNotFound
+	scopes := uc.resolver.Cascade()
+	if input.Scope != "" {
+		scopes = []Scope{uc.resolver.Resolve(input.Scope)}
+	}
+
+	for _, scope := range scopes {
+		repo, err := uc.repoFor(scope)
+		if err != nil {
+			continue
+		}
+
+		scopedKey := normalizeKey(scope, key)
+		rc, err := openMemory(ctx, repo, scopedKey)
+		if err != nil {
+			continue
+		}
+		return followAliasReader(ctx, repo, scopedKey, rc)
+	}
+
+	return nil, aliasNotFound(input.Key, name)
+}
+
+// openMemory uses the repository's GetReader when it has one and falls
+// back to Get otherwise.
+func openMemory(ctx context.Context, repo MemoryRepository, key Key) (io.ReadCloser, error) {
+	if opener, ok := repo.(interface {
+		GetReader(context.Context, Key) (io.ReadCloser, error)
+	}); ok {
+		return opener.GetReader(ctx, key)
+	}
+
+	mem, err := repo.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(mem.Content)), nil
 }
 
 // --- DeleteMemoryUseCase ---
@@ -335,31 +610,107 @@ func NewDeleteMemoryUseCase(
 	}
 }
 
-func (uc *DeleteMemoryUseCase) Execute(ctx context.Context, input DeleteMemoryInput) error {
-	key, err := NewKey(input.Key)
-	if err != nil {
-		return err
-	}
+func (uc *DeleteMemoryUseCase) Execute(ctx context.Context, input DeleteMemoryInput) (*DeleteMemoryOutput, error) {
+	MetricsFrom(ctx).IncOp(OpDelete)
 
 	scope := uc.resolver.Resolve(input.Scope)
 	repo, err := uc.repoFor(scope)
 	if err != nil {
-		return fmt.Errorf("get repository: %w", err)
+		return nil, fmt.Errorf("get repository: %w", err)
 	}
 
-	if err := repo.Delete(ctx, key); err != nil {
-		return fmt.Errorf("delete memory: %w", err)
+	keys, err := uc.targets(ctx, scope, repo, input)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &DeleteMemoryOutput{DryRun: input.DryRun}
+	for _, key := range keys {
+		output.Keys = append(output.Keys, key.String())
+	}
+
+	if input.DryRun {
+		return output, nil
+	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return nil, err
 	}
 
+	var index VectorIndex
 	if uc.indexFor != nil {
-		if index, err := uc.indexFor(scope); err == nil {
-			_ = index.Remove(ctx, key)
+		index, _ = uc.indexFor(scope)
+	}
+
+	for _, key := range keys {
+		if input.Soft {
+			if err := moveToTrash(ctx, repo, key); err != nil {
+				return nil, err
+			}
+		} else if err := repo.Delete(ctx, key); err != nil {
+			return nil, fmt.Errorf("delete memory: %w", err)
+		}
+		if index != nil {
+			unindexMemory(ctx, index, key)
 		}
 	}
 
+	return output, nil
+}
+
+// moveToTrash copies key under TrashPrefix and removes the original. An
+// older trashed copy of the same key is replaced.
+func moveToTrash(ctx context.Context, repo MemoryRepository, key Key) error {
+	mem, err := repo.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("get memory: %w", err)
+	}
+	if err := repo.Save(ctx, NewMemory(TrashKey(key), mem.Content)); err != nil {
+		return fmt.Errorf("save to trash: %w", err)
+	}
+	if err := repo.Delete(ctx, key); err != nil {
+		return fmt.Errorf("delete memory: %w", err)
+	}
 	return nil
 }
 
+// targets resolves the keys a delete would remove without touching the store.
+func (uc *DeleteMemoryUseCase) targets(ctx context.Context, scope Scope, repo MemoryRepository, input DeleteMemoryInput) ([]Key, error) {
+	if input.Prefix != "" {
+		memories, err := repo.List(ctx, input.Prefix)
+		if err != nil {
+			return nil, fmt.Errorf("list memories: %w", err)
+		}
+		if len(memories) == 0 {
+			return nil, ErrNotFound
+		}
+		keys := make([]Key, len(memories))
+		for i, mem := range memories {
+			keys[i] = mem.Key
+		}
+		return keys, nil
+	}
+
+	name, err := resolveAliasKey(uc.resolver, input.Scope, input.Key)
+	if err != nil {
+		return nil, err
+	}
+	key, err := NewKey(name)
+	if err != nil {
+		return nil, err
+	}
+	key = normalizeKey(scope, key)
+
+	exists, err := repo.Exists(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("check memory: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("delete memory: %w", aliasNotFound(input.Key, name))
+	}
+
+	return []Key{key}, nil
+}
+
 // --- ListMemoriesUseCase ---
 
 type ListMemoriesUseCase struct {
@@ -384,19 +735,35 @@ func (uc *ListMemoriesUseCase) Execute(ctx context.Context, input ListMemoriesIn
 		return nil, fmt.Errorf("get repository: %w", err)
 	}
 
-	memories, err := repo.List(ctx, input.Prefix)
+	var memories []*Memory
+	if input.RawPrefix {
+		memories, err = listRawPrefix(ctx, repo, input.Prefix)
+	} else {
+		memories, err = repo.List(ctx, input.Prefix)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := sortMemories(memories, input.SortBy); err != nil {
+		return nil, err
+	}
+	memories, err = pageMemories(memories, input.SortBy, input.After)
 	if err != nil {
 		return nil, err
 	}
 
-	output := &ListMemoriesOutput{
-		Memories: make([]GetMemoryOutput, len(memories)),
+	output := &ListMemoriesOutput{}
+	if input.Limit > 0 && len(memories) > input.Limit {
+		memories = memories[:input.Limit]
+		output.Next = memories[len(memories)-1].Key.String()
 	}
+	output.Memories = make([]GetMemoryOutput, len(memories))
 
 	for i, mem := range memories {
 		output.Memories[i] = GetMemoryOutput{
 			Key:       mem.Key.String(),
 			Content:   string(mem.Content),
+			Tags:      mem.Metadata.Tags,
 			CreatedAt: mem.CreatedAt,
 			UpdatedAt: mem.UpdatedAt,
 		}
@@ -405,6 +772,66 @@ func (uc *ListMemoriesUseCase) Execute(ctx context.Context, input ListMemoriesIn
 	return output, nil
 }
 
+// listRawPrefix lists the memories whose key starts with prefix, cutting
+// through path segments. It lists the enclosing directory and filters.
+func listRawPrefix(ctx context.Context, repo MemoryRepository, prefix string) ([]*Memory, error) {
+	var dir string
+	if i := strings.LastIndex(prefix, "/"); i >= 0 {
+		dir = prefix[:i]
+	}
+
+	memories, err := repo.List(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(memories, func(m *Memory) bool {
+		return !strings.HasPrefix(m.Key.String(), prefix)
+	}), nil
+}
+
+// sortMemories orders memories by key, or newest first by the given time
+// with the key breaking ties.
+func sortMemories(memories []*Memory, by string) error {
+	var at func(*Memory) time.Time
+	switch by {
+	case "", SortByKey:
+	case SortByUpdated:
+		at = func(m *Memory) time.Time { return m.UpdatedAt }
+	case SortByCreated:
+		at = func(m *Memory) time.Time { return m.CreatedAt }
+	default:
+		return fmt.Errorf("unknown sort order %q: use %s, %s or %s", by, SortByKey, SortByUpdated, SortByCreated)
+	}
+
+	sort.SliceStable(memories, func(i, j int) bool {
+		if at != nil && !at(memories[i]).Equal(at(memories[j])) {
+			return at(memories[i]).After(at(memories[j]))
+		}
+		return memories[i].Key < memories[j].Key
+	})
+	return nil
+}
+
+// pageMemories drops the sorted memories up to and including the key
+// after. In key order the key need not exist any more, so a page stays
+// valid when its last memory is deleted; in time order it must.
+func pageMemories(memories []*Memory, by, after string) ([]*Memory, error) {
+	if after == "" {
+		return memories, nil
+	}
+	if by == "" || by == SortByKey {
+		i := sort.Search(len(memories), func(i int) bool {
+			return memories[i].Key.String() > after
+		})
+		return memories[i:], nil
+	}
+	i := slices.IndexFunc(memories, func(m *Memory) bool { return m.Key.String() == after })
+	if i < 0 {
+		return nil, fmt.Errorf("cursor %q: %w", after, ErrNotFound)
+	}
+	return memories[i+1:], nil
+}
+
 // --- AddMemoryUseCase ---
 
 type AddMemoryUseCase struct {
@@ -412,7 +839,7 @@ type AddMemoryUseCase struct {
 	repoFor  func(Scope) (MemoryRepository, error)
 	histFor  func(Scope) (HistoryRepository, error)
 	indexFor func(Scope) (VectorIndex, error)
-	embedder Embedder
+	embedder EmbedderFunc
 	ignore   func(Scope) (*IgnoreMatcher, error)
 }
 
@@ -421,7 +848,7 @@ func NewAddMemoryUseCase(
 	repoFor func(Scope) (MemoryRepository, error),
 	histFor func(Scope) (HistoryRepository, error),
 	indexFor func(Scope) (VectorIndex, error),
-	embedder Embedder,
+	embedder EmbedderFunc,
 	ignore func(Scope) (*IgnoreMatcher, error),
 ) *AddMemoryUseCase {
 	return &AddMemoryUseCase{
@@ -434,18 +861,22 @@ func NewAddMemoryUseCase(
 	}
 }
 
-func (uc *AddMemoryUseCase) Execute(ctx context.Context, input AddMemoryInput) (*CommitOutput, error) {
-	key, err := NewKey(input.Key)
+func (uc *AddMemoryUseCase) Execute(ctx context.Context, input AddMemoryInput) (*AddMemoryOutput, error) {
+	scope := uc.resolver.Resolve(input.Scope)
+
+	name, err := resolveAliasKey(uc.resolver, input.Scope, input.Key)
+	if err != nil {
+		return nil, err
+	}
+	key, err := policyKey(scope, name)
 	if err != nil {
 		return nil, err
 	}
-
-	scope := uc.resolver.Resolve(input.Scope)
 
 	if uc.ignore != nil {
 		matcher, err := uc.ignore(scope)
 		if err == nil && matcher.MatchKey(key) {
-			return nil, fmt.Errorf("key %q is blocked by .memignore", input.Key)
+			return nil, fmt.Errorf("key %q is blocked by .memignore", name)
 		}
 	}
 
@@ -453,13 +884,33 @@ func (uc *AddMemoryUseCase) Execute(ctx context.Context, input AddMemoryInput) (
 	if err != nil {
 		return nil, fmt.Errorf("get repository: %w", err)
 	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return nil, err
+	}
+
+	layout := ""
+	if input.Timestamp {
+		layout = input.TimestampFormat
+		if layout == "" {
+			if cfg, err := LoadConfig(scope); err == nil {
+				layout = cfg.Journal.TimestampFormat
+			}
+		}
+		layout = cmp.Or(layout, time.RFC3339)
+	}
+	now := input.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
 
 	existing, _ := repo.Get(ctx, key)
-	var newContent []byte
+	var oldContent []byte
 	if existing != nil {
-		newContent = append(existing.Content, []byte("\n"+input.Content)...)
-	} else {
-		newContent = []byte(input.Content)
+		oldContent = existing.Content
+	}
+	newContent := appendJournal(oldContent, input.Content, layout, input.Section, now)
+	if err := checkAliasMemoryContent(key, newContent); err != nil {
+		return nil, err
 	}
 
 	mem := &Memory{
@@ -475,7 +926,7 @@ func (uc *AddMemoryUseCase) Execute(ctx context.Context, input AddMemoryInput) (
 
 	message := input.Message
 	if message == "" {
-		message = fmt.Sprintf("add: append to %s", input.Key)
+		message = commitMessage(ctx, scope, "add", name, fmt.Sprintf("add: append to %s", name))
 	}
 
 	hist, err := uc.histFor(scope)
@@ -488,23 +939,23 @@ func (uc *AddMemoryUseCase) Execute(ctx context.Context, input AddMemoryInput) (
 		return nil, fmt.Errorf("commit: %w", err)
 	}
 
-	if uc.embedder != nil && uc.indexFor != nil {
+	if embedder := writeEmbedder(ctx, scope, uc.embedder, uc.indexFor, key); embedder != nil {
 		if index, err := uc.indexFor(scope); err == nil {
-			if vec, err := uc.embedder.Embed(ctx, string(newContent)); err == nil {
-				emb := NewEmbedding(vec, "local")
-				_ = index.Add(ctx, key, emb)
-			} else {
-				slog.Warn("skipping index update: embedding failed", "error", err)
+			if err := indexMemory(ctx, scope, index, embedder, key, string(newContent)); err != nil {
+				skipIndexUpdate(ctx, scope, key, SkipEmbeddingFailed, err)
 			}
 		} else {
-			slog.Warn("skipping index update: failed to get index", "error", err)
+			skipIndexUpdate(ctx, scope, key, SkipIndexUnavailable, err)
 		}
 	}
 
-	return &CommitOutput{
-		Hash:      commit.Hash,
-		Message:   commit.Message,
-		Timestamp: commit.Timestamp,
+	return &AddMemoryOutput{
+		CommitOutput: CommitOutput{
+			Hash:      commit.Hash,
+			Message:   commit.Message,
+			Timestamp: commit.Timestamp,
+		},
+		Created: existing == nil,
 	}, nil
 }
 
@@ -515,7 +966,7 @@ type EditMemoryUseCase struct {
 	repoFor  func(Scope) (MemoryRepository, error)
 	histFor  func(Scope) (HistoryRepository, error)
 	indexFor func(Scope) (VectorIndex, error)
-	embedder Embedder
+	embedder EmbedderFunc
 	ignore   func(Scope) (*IgnoreMatcher, error)
 }
 
@@ -524,7 +975,7 @@ func NewEditMemoryUseCase(
 	repoFor func(Scope) (MemoryRepository, error),
 	histFor func(Scope) (HistoryRepository, error),
 	indexFor func(Scope) (VectorIndex, error),
-	embedder Embedder,
+	embedder EmbedderFunc,
 	ignore func(Scope) (*IgnoreMatcher, error),
 ) *EditMemoryUseCase {
 	return &EditMemoryUseCase{
@@ -538,24 +989,35 @@ func NewEditMemoryUseCase(
 }
 
 func (uc *EditMemoryUseCase) Execute(ctx context.Context, input EditMemoryInput) (*CommitOutput, error) {
-	key, err := NewKey(input.Key)
+	scope := uc.resolver.Resolve(input.Scope)
+
+	name, err := resolveAliasKey(uc.resolver, input.Scope, input.Key)
+	if err != nil {
+		return nil, err
+	}
+	key, err := policyKey(scope, name)
 	if err != nil {
 		return nil, err
 	}
-
-	scope := uc.resolver.Resolve(input.Scope)
 
 	if uc.ignore != nil {
 		matcher, err := uc.ignore(scope)
 		if err == nil && matcher.MatchKey(key) {
-			return nil, fmt.Errorf("key %q is blocked by .memignore", input.Key)
+			return nil, fmt.Errorf("key %q is blocked by .memignore", name)
 		}
 	}
 
+	if err := checkAliasMemoryContent(key, []byte(input.Content)); err != nil {
+		return nil, err
+	}
+
 	repo, err := uc.repoFor(scope)
 	if err != nil {
 		return nil, fmt.Errorf("get repository: %w", err)
 	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return nil, err
+	}
 
 	mem := &Memory{
 		Key:       key,
@@ -570,7 +1032,7 @@ func (uc *EditMemoryUseCase) Execute(ctx context.Context, input EditMemoryInput)
 
 	message := input.Message
 	if message == "" {
-		message = fmt.Sprintf("edit: update %s", input.Key)
+		message = commitMessage(ctx, scope, "edit", name, fmt.Sprintf("edit: update %s", name))
 	}
 
 	hist, err := uc.histFor(scope)
@@ -583,16 +1045,13 @@ func (uc *EditMemoryUseCase) Execute(ctx context.Context, input EditMemoryInput)
 		return nil, fmt.Errorf("commit: %w", err)
 	}
 
-	if uc.embedder != nil && uc.indexFor != nil {
+	if embedder := writeEmbedder(ctx, scope, uc.embedder, uc.indexFor, key); embedder != nil {
 		if index, err := uc.indexFor(scope); err == nil {
-			if vec, err := uc.embedder.Embed(ctx, input.Content); err == nil {
-				emb := NewEmbedding(vec, "local")
-				_ = index.Add(ctx, key, emb)
-			} else {
-				slog.Warn("skipping index update: embedding failed", "error", err)
+			if err := indexMemory(ctx, scope, index, embedder, key, input.Content); err != nil {
+				skipIndexUpdate(ctx, scope, key, SkipEmbeddingFailed, err)
 			}
 		} else {
-			slog.Warn("skipping index update: failed to get index", "error", err)
+			skipIndexUpdate(ctx, scope, key, SkipIndexUnavailable, err)
 		}
 	}
 
@@ -626,17 +1085,25 @@ func (uc *CommitUseCase) Execute(ctx context.Context, input CommitInput) (*Commi
 	if err != nil {
 		return nil, fmt.Errorf("get repository: %w", err)
 	}
+	if err := checkWritable(ctx, scope, hist); err != nil {
+		return nil, err
+	}
 
-	commit, err := hist.Commit(ctx, input.Message)
+	message := input.Message
+	if message == "" && input.Op != "" {
+		message = commitMessage(ctx, scope, input.Op, input.Key, fmt.Sprintf("%s: %s", input.Op, input.Key))
+	}
+
+	if input.NoSign {
+		ctx = WithoutSigning(ctx)
+	}
+	commit, err := hist.Commit(ctx, message)
 	if err != nil {
 		return nil, err
 	}
 
-	return &CommitOutput{
-		Hash:      commit.Hash,
-		Message:   commit.Message,
-		Timestamp: commit.Timestamp,
-	}, nil
+	output := toCommitOutput(commit)
+	return &output, nil
 }
 
 // --- LogUseCase ---
@@ -663,21 +1130,37 @@ func (uc *LogUseCase) Execute(ctx context.Context, input LogInput) (*LogOutput,
 		return nil, fmt.Errorf("get repository: %w", err)
 	}
 
-	commits, err := hist.Log(ctx, input.Limit)
+	log := hist.Log
+	if input.All {
+		log = hist.LogAll
+	}
+	// Filters apply after the walk, so read the whole history and count
+	// only the commits that match.
+	limit := input.Limit
+	if input.filtered() {
+		limit = 0
+	}
+	commits, err := log(ctx, limit)
 	if err != nil {
 		return nil, err
 	}
 
 	output := &LogOutput{
-		Commits: make([]CommitOutput, len(commits)),
+		Commits: make([]CommitOutput, 0, len(commits)),
 	}
 
-	for i, c := range commits {
-		output.Commits[i] = CommitOutput{
-			Hash:      c.Hash,
-			Message:   c.Message,
-			Timestamp: c.Timestamp,
+	for _, c := range commits {
+		if input.Limit > 0 && len(output.Commits) >= input.Limit {
+			break
+		}
+		if !input.matches(c) {
+			continue
+		}
+		out := toCommitOutput(c)
+		if input.All {
+			out.Refs = append([]string{}, c.Refs...)
 		}
+		output.Commits = append(output.Commits, out)
 	}
 
 	return output, nil
@@ -707,6 +1190,14 @@ func (uc *DiffUseCase) Execute(ctx context.Context, input DiffInput) (*DiffOutpu
 		return nil, fmt.Errorf("get repository: %w", err)
 	}
 
+	if input.Stat {
+		stats, err := hist.DiffStat(ctx, input.Ref)
+		if err != nil {
+			return nil, err
+		}
+		return &DiffOutput{Stats: stats}, nil
+	}
+
 	diff, err := hist.Diff(ctx, input.Ref)
 	if err != nil {
 		return nil, err
@@ -732,16 +1223,57 @@ func NewRevertUseCase(
 	}
 }
 
-func (uc *RevertUseCase) Execute(ctx context.Context, input RevertInput) error {
+// Execute resets the store to input.Ref, which must be an ancestor of
+// HEAD, and reports the commits that were discarded.
+func (uc *RevertUseCase) Execute(ctx context.Context, input RevertInput) (*RevertOutput, error) {
 	scope := uc.resolver.Resolve(input.Scope)
 	hist, err := uc.histFor(scope)
 	if err != nil {
-		return fmt.Errorf("get repository: %w", err)
+		return nil, fmt.Errorf("get repository: %w", err)
 	}
 
-	return hist.Revert(ctx, input.Ref)
-}
-
+	target, err := hist.Show(ctx, input.Ref)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := hist.Log(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	output := &RevertOutput{
+		Target: toCommitOutput(target),
+		DryRun: input.DryRun,
+	}
+	found := false
+	for _, c := range commits {
+		if c.Hash == target.Hash {
+			found = true
+			break
+		}
+		output.Discarded = append(output.Discarded, toCommitOutput(c))
+	}
+	// Resetting to a commit off the current branch would discard all of
+	// it, not a run of recent commits.
+	if !found {
+		return nil, fmt.Errorf("%w: %s", ErrNotAncestor, input.Ref)
+	}
+
+	if input.DryRun {
+		return output, nil
+	}
+	if err := checkWritable(ctx, scope, hist); err != nil {
+		return nil, err
+	}
+
+	if err := hist.Revert(ctx, input.Ref); err != nil {
+		return nil, err
+	}
+
+	return output, nil
+}
+
 // --- KeywordSearchUseCase ---
 
 type KeywordSearchUseCase struct {
@@ -760,13 +1292,15 @@ func NewKeywordSearchUseCase(
 }
 
 func (uc *KeywordSearchUseCase) Execute(ctx context.Context, input SearchInput) (*SearchOutput, error) {
+	MetricsFrom(ctx).IncOp(OpSearch)
+
 	scope := uc.resolver.Resolve(input.Scope)
 	repo, err := uc.repoFor(scope)
 	if err != nil {
 		return nil, fmt.Errorf("get repository: %w", err)
 	}
 
-	all, err := repo.List(ctx, "")
+	all, err := repo.List(ctx, input.Prefix)
 	if err != nil {
 		return nil, err
 	}
@@ -775,11 +1309,12 @@ func (uc *KeywordSearchUseCase) Execute(ctx context.Context, input SearchInput)
 	var results []SearchResultOutput
 
 	for _, mem := range all {
-		if strings.Contains(strings.ToLower(string(mem.Content)), queryLower) ||
-			strings.Contains(strings.ToLower(mem.Key.String()), queryLower) {
+		snippet := matchSnippet(string(mem.Content), input.Query)
+		if snippet != "" || strings.Contains(strings.ToLower(mem.Key.String()), queryLower) {
 			results = append(results, SearchResultOutput{
-				Key:   mem.Key.String(),
-				Score: 1.0,
+				Key:     mem.Key.String(),
+				Score:   1.0,
+				Snippet: snippet,
 			})
 		}
 		if input.Limit > 0 && len(results) >= input.Limit {
@@ -790,18 +1325,94 @@ func (uc *KeywordSearchUseCase) Execute(ctx context.Context, input SearchInput)
 	return &SearchOutput{Results: results}, nil
 }
 
+// snippetWidth is the most runes of a line a search snippet shows.
+const snippetWidth = 80
+
+// matchSnippet returns the first line of content containing query, ignoring
+// case, trimmed and cut to snippetWidth runes around the match. It returns
+// "" when nothing matches.
+func matchSnippet(content, query string) string {
+	if query == "" {
+		return ""
+	}
+	for line := range strings.Lines(content) {
+		i := indexFold(line, query)
+		if i < 0 {
+			continue
+		}
+		runes := []rune(line)
+		start := utf8.RuneCountInString(line[:i])
+		// Keep some context before the match.
+		from := max(0, start-snippetWidth/4)
+		to := min(len(runes), from+snippetWidth)
+		snippet := strings.TrimSpace(string(runes[from:to]))
+		if from > 0 {
+			snippet = "…" + snippet
+		}
+		if to < len(runes) && strings.TrimSpace(string(runes[to:])) != "" {
+			snippet += "…"
+		}
+		return snippet
+	}
+	return ""
+}
+
+// MatchRanges returns the byte ranges of the non-overlapping
+// case-insensitive matches of query in s, for highlighting.
+func MatchRanges(s, query string) [][2]int {
+	var ranges [][2]int
+	if query == "" {
+		return nil
+	}
+	for i := 0; i < len(s); {
+		if rest, ok := cutPrefixFold(s[i:], query); ok {
+			end := len(s) - len(rest)
+			ranges = append(ranges, [2]int{i, end})
+			i = end
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+	}
+	return ranges
+}
+
+// indexFold returns the byte index of the first case-insensitive match of
+// substr in s, or -1.
+func indexFold(s, substr string) int {
+	for i := range s {
+		if _, ok := cutPrefixFold(s[i:], substr); ok {
+			return i
+		}
+	}
+	return -1
+}
+
+// cutPrefixFold is strings.CutPrefix ignoring case. Case variants may
+// differ in length, so it returns the rest of s after the match.
+func cutPrefixFold(s, prefix string) (string, bool) {
+	for _, r := range prefix {
+		c, size := utf8.DecodeRuneInString(s)
+		if size == 0 || !strings.EqualFold(string(c), string(r)) {
+			return s, false
+		}
+		s = s[size:]
+	}
+	return s, true
+}
+
 // --- SemanticSearchUseCase ---
 
 type SemanticSearchUseCase struct {
 	resolver *ScopeResolver
 	indexFor func(Scope) (VectorIndex, error)
-	embedder Embedder
+	embedder EmbedderFunc
 }
 
 func NewSemanticSearchUseCase(
 	resolver *ScopeResolver,
 	indexFor func(Scope) (VectorIndex, error),
-	embedder Embedder,
+	embedder EmbedderFunc,
 ) *SemanticSearchUseCase {
 	return &SemanticSearchUseCase{
 		resolver: resolver,
@@ -811,8 +1422,11 @@ func NewSemanticSearchUseCase(
 }
 
 func (uc *SemanticSearchUseCase) Execute(ctx context.Context, input SearchInput) (*SearchOutput, error) {
-	if uc.embedder == nil {
-		return nil, fmt.Errorf("embedder not available")
+	MetricsFrom(ctx).IncOp(OpSearch)
+
+	embedder := uc.embedder.Get()
+	if embedder == nil {
+		return nil, ErrNoEmbedder
 	}
 
 	scope := uc.resolver.Resolve(input.Scope)
@@ -821,13 +1435,26 @@ func (uc *SemanticSearchUseCase) Execute(ctx context.Context, input SearchInput)
 		return nil, fmt.Errorf("get index: %w", err)
 	}
 
-	vec, err := uc.embedder.Embed(ctx, input.Query)
+	vec, err := embed(ctx, embedder, input.Query)
 	if err != nil {
 		return nil, fmt.Errorf("embed query: %w", err)
 	}
 
+	if input.SearchK > 0 {
+		if tunable, ok := index.(interface{ SetSearchK(int) }); ok {
+			tunable.SetSearchK(input.SearchK)
+		}
+	}
+
+	if s, ok := index.(interface{ Stale() bool }); ok && s.Stale() {
+		LoggerFrom(ctx).Warn("index is stale; run `mem index rebuild` for accurate results")
+	}
+
 	emb := NewEmbedding(vec, "local")
-	results, err := index.Search(ctx, emb, input.Limit)
+	results, err := searchParents(ctx, index, emb, input.Limit, input.Prefix)
+	if errors.Is(err, ErrIndexNotBuilt) {
+		return nil, fmt.Errorf("%w; run `mem index rebuild`", err)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -838,8 +1465,11 @@ func (uc *SemanticSearchUseCase) Execute(ctx context.Context, input SearchInput)
 
 	for i, r := range results {
 		output.Results[i] = SearchResultOutput{
-			Key:   r.Key.String(),
-			Score: r.Score,
+			Key:        r.Key.String(),
+			Score:      r.Score,
+			Distance:   r.Distance,
+			Similarity: angularSimilarity(r.Distance),
+			Index:      cmp.Or(scope.Name, string(scope.Type)),
 		}
 	}
 
@@ -852,14 +1482,14 @@ type RebuildIndexUseCase struct {
 	resolver *ScopeResolver
 	repoFor  func(Scope) (MemoryRepository, error)
 	indexFor func(Scope) (VectorIndex, error)
-	embedder Embedder
+	embedder EmbedderFunc
 }
 
 func NewRebuildIndexUseCase(
 	resolver *ScopeResolver,
 	repoFor func(Scope) (MemoryRepository, error),
 	indexFor func(Scope) (VectorIndex, error),
-	embedder Embedder,
+	embedder EmbedderFunc,
 ) *RebuildIndexUseCase {
 	return &RebuildIndexUseCase{
 		resolver: resolver,
@@ -869,69 +1499,234 @@ func NewRebuildIndexUseCase(
 	}
 }
 
-func (uc *RebuildIndexUseCase) Execute(ctx context.Context, input RebuildIndexInput) error {
-	if uc.embedder == nil {
-		return fmt.Errorf("embedder not available")
+func (uc *RebuildIndexUseCase) Execute(ctx context.Context, input RebuildIndexInput) (*RebuildIndexOutput, error) {
+	if !input.DryRun && uc.embedder.Get() == nil {
+		return nil, ErrNoEmbedder
 	}
 
 	scope := uc.resolver.Resolve(input.Scope)
 	repo, err := uc.repoFor(scope)
 	if err != nil {
-		return fmt.Errorf("get repository: %w", err)
+		return nil, fmt.Errorf("get repository: %w", err)
 	}
 
-	index, err := uc.indexFor(scope)
-	if err != nil {
-		return fmt.Errorf("get index: %w", err)
+	if input.Since != "" {
+		return uc.rebuildSince(ctx, scope, repo, input)
 	}
 
 	memories, err := repo.List(ctx, "")
 	if err != nil {
-		return fmt.Errorf("list memories: %w", err)
+		return nil, fmt.Errorf("list memories: %w", err)
+	}
+
+	output := &RebuildIndexOutput{
+		Keys:   make([]string, len(memories)),
+		DryRun: input.DryRun,
+	}
+	for i, mem := range memories {
+		output.Keys[i] = mem.Key.String()
 	}
 
+	if input.DryRun {
+		return output, nil
+	}
+
+	index, err := uc.indexFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get index: %w", err)
+	}
+
+	embedder := uc.embedder.Get()
 	for _, mem := range memories {
-		vec, err := uc.embedder.Embed(ctx, string(mem.Content))
+		if err := indexMemory(ctx, scope, index, embedder, mem.Key, string(mem.Content)); err != nil {
+			continue
+		}
+	}
+
+	if err := index.Build(ctx, numTrees(scope, input.NumTrees)); err != nil {
+		return nil, fmt.Errorf("build index: %w", err)
+	}
+
+	if err := index.Save(ctx); err != nil {
+		return nil, err
+	}
+	if s, ok := index.(interface{ ClearStale() error }); ok {
+		if err := s.ClearStale(); err != nil {
+			return nil, err
+		}
+	}
+	if err := clearPendingIndexUpdates(scope); err != nil {
+		return nil, fmt.Errorf("clear pending index updates: %w", err)
+	}
+	recordIndexSize(ctx, index)
+
+	return output, nil
+}
+
+// rebuildSince re-embeds only the memories changed between input.Since and
+// HEAD and drops the entries of memories deleted since. Unlike a full
+// rebuild it leaves a stale index stale.
+func (uc *RebuildIndexUseCase) rebuildSince(ctx context.Context, scope Scope, repo MemoryRepository, input RebuildIndexInput) (*RebuildIndexOutput, error) {
+	differ, ok := repo.(interface {
+		ChangedKeys(context.Context, string) ([]Key, error)
+	})
+	if !ok {
+		return nil, fmt.Errorf("repository cannot list changes since a ref")
+	}
+	keys, err := differ.ChangedKeys(ctx, input.Since)
+	if err != nil {
+		return nil, fmt.Errorf("changes since %s: %w", input.Since, err)
+	}
+
+	output := &RebuildIndexOutput{DryRun: input.DryRun}
+	var changed []*Memory
+	for _, key := range keys {
+		mem, err := repo.Get(ctx, key)
+		if errors.Is(err, ErrNotFound) {
+			output.Removed = append(output.Removed, key.String())
+			continue
+		}
 		if err != nil {
+			return nil, fmt.Errorf("get %s: %w", key, err)
+		}
+		changed = append(changed, mem)
+		output.Keys = append(output.Keys, key.String())
+	}
+
+	if input.DryRun {
+		return output, nil
+	}
+
+	index, err := uc.indexFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get index: %w", err)
+	}
+
+	for _, key := range keys {
+		unindexMemory(ctx, index, key)
+	}
+	embedder := uc.embedder.Get()
+	for _, mem := range changed {
+		if err := indexMemory(ctx, scope, index, embedder, mem.Key, string(mem.Content)); err != nil {
 			continue
 		}
-		emb := NewEmbedding(vec, "local")
-		_ = index.Add(ctx, mem.Key, emb)
 	}
 
-	if err := index.Build(ctx, input.NumTrees); err != nil {
-		return fmt.Errorf("build index: %w", err)
+	if err := index.Build(ctx, numTrees(scope, input.NumTrees)); err != nil {
+		return nil, fmt.Errorf("build index: %w", err)
+	}
+	if err := index.Save(ctx); err != nil {
+		return nil, err
+	}
+	recordIndexSize(ctx, index)
+
+	return output, nil
+}
+
+// numTrees returns n, or the scope's configured tree count when n is zero.
+func numTrees(scope Scope, n int) int {
+	if n > 0 {
+		return n
 	}
+	if cfg, err := LoadConfig(scope); err == nil && cfg.Embeddings.NumTrees > 0 {
+		return cfg.Embeddings.NumTrees
+	}
+	return DefaultNumTrees
+}
 
-	return index.Save(ctx)
+// --- WarmupUseCase ---
+
+type WarmupUseCase struct {
+	resolver *ScopeResolver
+	indexFor func(Scope) (VectorIndex, error)
+	embedder EmbedderFunc
+}
+
+func NewWarmupUseCase(
+	resolver *ScopeResolver,
+	indexFor func(Scope) (VectorIndex, error),
+	embedder EmbedderFunc,
+) *WarmupUseCase {
+	return &WarmupUseCase{
+		resolver: resolver,
+		indexFor: indexFor,
+		embedder: embedder,
+	}
+}
+
+// Execute makes sure the embedder and the scope's vector index are loaded so
+// the first real search doesn't pay the model-loading cost.
+func (uc *WarmupUseCase) Execute(ctx context.Context, input WarmupInput) (*WarmupOutput, error) {
+	embedder := uc.embedder.Get()
+	if embedder == nil {
+		return nil, ErrNoEmbedder
+	}
+
+	scope := uc.resolver.Resolve(input.Scope)
+	if _, err := uc.indexFor(scope); err != nil {
+		return nil, fmt.Errorf("load index: %w", err)
+	}
+
+	return &WarmupOutput{
+		Device:    embedder.Device(),
+		Dimension: embedder.Dimension(),
+	}, nil
+}
+
+// --- EmbedderInfoUseCase ---
+
+type EmbedderInfoUseCase struct {
+	embedder EmbedderFunc
+}
+
+func NewEmbedderInfoUseCase(embedder EmbedderFunc) *EmbedderInfoUseCase {
+	return &EmbedderInfoUseCase{embedder: embedder}
+}
+
+func (uc *EmbedderInfoUseCase) Execute(_ context.Context) (*EmbedderInfoOutput, error) {
+	embedder := uc.embedder.Get()
+	if embedder == nil {
+		return nil, ErrNoEmbedder
+	}
+
+	return &EmbedderInfoOutput{
+		Device:    embedder.Device(),
+		Model:     embedder.Model(),
+		Dimension: embedder.Dimension(),
+	}, nil
 }
 
 // --- SummarizeUseCase ---
 
 type SummarizeUseCase struct {
-	resolver *ScopeResolver
-	repoFor  func(Scope) (MemoryRepository, error)
-	provider Provider
+	resolver    *ScopeResolver
+	repoFor     func(Scope) (MemoryRepository, error)
+	providerFor ProviderFunc
 }
 
 func NewSummarizeUseCase(
 	resolver *ScopeResolver,
 	repoFor func(Scope) (MemoryRepository, error),
-	provider Provider,
+	providerFor ProviderFunc,
 ) *SummarizeUseCase {
 	return &SummarizeUseCase{
-		resolver: resolver,
-		repoFor:  repoFor,
-		provider: provider,
+		resolver:    resolver,
+		repoFor:     repoFor,
+		providerFor: providerFor,
 	}
 }
 
 func (uc *SummarizeUseCase) Execute(ctx context.Context, input SummarizeInput) (*SummarizeOutput, error) {
-	if uc.provider == nil {
-		return nil, fmt.Errorf("provider not available")
+	if uc.providerFor == nil {
+		return nil, ErrNoProvider
 	}
 
 	scope := uc.resolver.Resolve(input.Scope)
+	provider, err := uc.providerFor(ctx, scope, TaskSummarize, ProviderOverride{Provider: input.Provider, Model: input.Model})
+	if err != nil {
+		return nil, err
+	}
+
 	repo, err := uc.repoFor(scope)
 	if err != nil {
 		return nil, fmt.Errorf("get repository: %w", err)
@@ -946,56 +1741,72 @@ func (uc *SummarizeUseCase) Execute(ctx context.Context, input SummarizeInput) (
 		return &SummarizeOutput{Title: "Empty", Overview: "No memories found"}, nil
 	}
 
+	redactor, err := promptRedactor(scope, input.NoRedact)
+	if err != nil {
+		return nil, err
+	}
+
 	var sb strings.Builder
 	sb.WriteString("Summarize the following memories:\n\n")
 	for _, mem := range memories {
-		sb.WriteString(fmt.Sprintf("## %s\n%s\n\n", mem.Key, string(mem.Content)))
+		sb.WriteString(fmt.Sprintf("## %s\n%s\n\n", mem.Key, redactor.Redact(string(mem.Content))))
 	}
 
 	var summary Summary
-	if err := uc.provider.GenerateObject(ctx, sb.String(), &summary); err != nil {
+	if err := generateObject(ctx, provider, sb.String(), &summary); err != nil {
 		return nil, fmt.Errorf("generate summary: %w", err)
 	}
 
+	sources := make([]string, len(memories))
+	for i, mem := range memories {
+		sources[i] = mem.Key.String()
+	}
+
 	return &SummarizeOutput{
 		Title:     summary.Title,
 		Overview:  summary.Overview,
 		KeyPoints: summary.KeyPoints,
 		Tags:      summary.Tags,
+		Sources:   sources,
 	}, nil
 }
 
 // --- AutoTagUseCase ---
 
 type AutoTagUseCase struct {
-	resolver *ScopeResolver
-	repoFor  func(Scope) (MemoryRepository, error)
-	provider Provider
+	resolver    *ScopeResolver
+	repoFor     func(Scope) (MemoryRepository, error)
+	providerFor ProviderFunc
 }
 
 func NewAutoTagUseCase(
 	resolver *ScopeResolver,
 	repoFor func(Scope) (MemoryRepository, error),
-	provider Provider,
+	providerFor ProviderFunc,
 ) *AutoTagUseCase {
 	return &AutoTagUseCase{
-		resolver: resolver,
-		repoFor:  repoFor,
-		provider: provider,
+		resolver:    resolver,
+		repoFor:     repoFor,
+		providerFor: providerFor,
 	}
 }
 
 func (uc *AutoTagUseCase) Execute(ctx context.Context, input AutoTagInput) (*AutoTagOutput, error) {
-	if uc.provider == nil {
-		return nil, fmt.Errorf("provider not available")
+	if uc.providerFor == nil {
+		return nil, ErrNoProvider
 	}
 
-	key, err := NewKey(input.Key)
+	key, err := aliasedKey(uc.resolver, input.Scope, input.Key)
 	if err != nil {
 		return nil, err
 	}
 
 	scope := uc.resolver.Resolve(input.Scope)
+	provider, err := uc.providerFor(ctx, scope, TaskAutoTag, ProviderOverride{Provider: input.Provider, Model: input.Model})
+	if err != nil {
+		return nil, err
+	}
+
 	repo, err := uc.repoFor(scope)
 	if err != nil {
 		return nil, fmt.Errorf("get repository: %w", err)
@@ -1006,10 +1817,15 @@ func (uc *AutoTagUseCase) Execute(ctx context.Context, input AutoTagInput) (*Aut
 		return nil, fmt.Errorf("get memory: %w", err)
 	}
 
-	prompt := fmt.Sprintf("Generate tags for this content:\n\n%s", string(mem.Content))
+	redactor, err := promptRedactor(scope, input.NoRedact)
+	if err != nil {
+		return nil, err
+	}
+
+	prompt := fmt.Sprintf("Generate tags for this content:\n\n%s", redactor.Redact(string(mem.Content)))
 
 	var tags AutoTag
-	if err := uc.provider.GenerateObject(ctx, prompt, &tags); err != nil {
+	if err := generateObject(ctx, provider, prompt, &tags); err != nil {
 		return nil, fmt.Errorf("generate tags: %w", err)
 	}
 
@@ -1122,8 +1938,11 @@ func (uc *BranchCreateUseCase) Execute(ctx context.Context, input BranchInput) (
 	if err != nil {
 		return nil, fmt.Errorf("get repository: %w", err)
 	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return nil, err
+	}
 
-	branch, err := repo.Create(ctx, input.Name)
+	branch, err := repo.Create(ctx, input.Name, input.From)
 	if err != nil {
 		return nil, err
 	}
@@ -1158,8 +1977,11 @@ func (uc *BranchSwitchUseCase) Execute(ctx context.Context, input BranchInput) e
 	if err != nil {
 		return fmt.Errorf("get repository: %w", err)
 	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return err
+	}
 
-	return repo.Switch(ctx, input.Name)
+	return repo.Switch(ctx, input.Name, input.Force)
 }
 
 // --- BranchDeleteUseCase ---
@@ -1179,14 +2001,79 @@ func NewBranchDeleteUseCase(
 	}
 }
 
+// Execute deletes input.Name and the branch's vector index.
 func (uc *BranchDeleteUseCase) Execute(ctx context.Context, input BranchInput) error {
 	scope := uc.resolver.Resolve(input.Scope)
 	repo, err := uc.branchFor(scope)
 	if err != nil {
 		return fmt.Errorf("get repository: %w", err)
 	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return err
+	}
+
+	if err := repo.DeleteBranch(ctx, input.Name, input.Force); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(scope.BranchVectorPath(input.Name)); err != nil {
+		return fmt.Errorf("remove index: %w", err)
+	}
+	return nil
+}
+
+// --- BranchRenameUseCase ---
+
+type BranchRenameUseCase struct {
+	resolver  *ScopeResolver
+	branchFor func(Scope) (BranchRepository, error)
+}
+
+func NewBranchRenameUseCase(
+	resolver *ScopeResolver,
+	branchFor func(Scope) (BranchRepository, error),
+) *BranchRenameUseCase {
+	return &BranchRenameUseCase{
+		resolver:  resolver,
+		branchFor: branchFor,
+	}
+}
+
+// Execute renames input.Name, or the current branch if it is empty, to
+// input.NewName. The branch's vector index moves with it.
+func (uc *BranchRenameUseCase) Execute(ctx context.Context, input BranchInput) error {
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.branchFor(scope)
+	if err != nil {
+		return fmt.Errorf("get repository: %w", err)
+	}
+	if err := checkWritable(ctx, scope, repo); err != nil {
+		return err
+	}
 
-	return repo.DeleteBranch(ctx, input.Name)
+	oldName := input.Name
+	if oldName == "" {
+		current, err := repo.Current(ctx)
+		if err != nil {
+			return err
+		}
+		oldName = current.Name
+	}
+
+	if err := repo.RenameBranch(ctx, oldName, input.NewName); err != nil {
+		return err
+	}
+
+	oldIndex, newIndex := scope.BranchVectorPath(oldName), scope.BranchVectorPath(input.NewName)
+	if _, err := os.Stat(oldIndex); err != nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(newIndex), 0755); err != nil {
+		return fmt.Errorf("move index: %w", err)
+	}
+	if err := os.Rename(oldIndex, newIndex); err != nil {
+		return fmt.Errorf("move index: %w", err)
+	}
+	return nil
 }
 
 // --- ProviderListUseCase ---
@@ -1223,14 +2110,23 @@ func NewProviderAddUseCase(resolver *ScopeResolver) *ProviderAddUseCase {
 	return &ProviderAddUseCase{resolver: resolver}
 }
 
+// Execute stores input.Config under input.Name. Without an explicit type
+// the name must itself be a provider type, so a typo fails here rather than
+// on the first summarize.
 func (uc *ProviderAddUseCase) Execute(input ProviderInput) error {
+	providerCfg := input.Config
+	providerCfg.Type = providerCfg.TypeOr(input.Name)
+	if err := ValidateProviderType(providerCfg.Type); err != nil {
+		return err
+	}
+
 	scope := uc.resolver.Resolve(input.Scope)
 	cfg, err := LoadConfig(scope)
 	if err != nil {
 		return err
 	}
 
-	cfg.Providers[input.Name] = input.Config
+	cfg.Providers[input.Name] = providerCfg
 	return SaveConfig(scope, cfg)
 }
 
@@ -1302,12 +2198,7 @@ func (uc *ProviderTestUseCase) Execute(ctx context.Context, input ProviderInput)
 		return fmt.Errorf("provider %q not found", input.Name)
 	}
 
-	provider, err := NewFantasyProvider(ctx, FantasyConfig{
-		Provider: input.Name,
-		APIKey:   providerCfg.APIKey,
-		BaseURL:  providerCfg.BaseURL,
-		Model:    providerCfg.Model,
-	})
+	provider, err := NewFantasyProvider(ctx, NewFantasyConfig(input.Name, providerCfg))
 	if err != nil {
 		return fmt.Errorf("create provider: %w", err)
 	}
@@ -1315,3 +2206,73 @@ func (uc *ProviderTestUseCase) Execute(ctx context.Context, input ProviderInput)
 	_, err = provider.Complete(ctx, "Say hello")
 	return err
 }
+
+type ProviderEmbeddingsOutput struct {
+	Model     string
+	Dimension int
+	Latency   time.Duration
+}
+
+// Embeddings checks the provider's embeddings endpoint instead of chat: it
+// embeds a short text with input.Config.Model, or embeddings.model when
+// that is empty, and fails with ErrIndexDimension unless the vector has
+// embeddings.dimension entries.
+func (uc *ProviderTestUseCase) Embeddings(ctx context.Context, input ProviderInput) (*ProviderEmbeddingsOutput, error) {
+	scope := uc.resolver.Resolve(input.Scope)
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	providerCfg, exists := cfg.Providers[input.Name]
+	if !exists {
+		return nil, fmt.Errorf("provider %q not found", input.Name)
+	}
+
+	provider, err := NewFantasyProvider(ctx, NewFantasyConfig(input.Name, providerCfg))
+	if err != nil {
+		return nil, fmt.Errorf("create provider: %w", err)
+	}
+
+	model := cmp.Or(input.Config.Model, cfg.Embeddings.Model)
+	start := time.Now()
+	vec, err := provider.Embed(ctx, model, "hello")
+	if err != nil {
+		return nil, err
+	}
+	out := &ProviderEmbeddingsOutput{Model: model, Dimension: len(vec), Latency: time.Since(start)}
+	if out.Dimension != cfg.Embeddings.Dimension {
+		return out, fmt.Errorf("%w: %s returned %d, embeddings.dimension is %d", ErrIndexDimension, model, out.Dimension, cfg.Embeddings.Dimension)
+	}
+	return out, nil
+}
+
+// --- ProviderModelsUseCase ---
+
+type ProviderModelsUseCase struct {
+	resolver *ScopeResolver
+}
+
+func NewProviderModelsUseCase(resolver *ScopeResolver) *ProviderModelsUseCase {
+	return &ProviderModelsUseCase{resolver: resolver}
+}
+
+func (uc *ProviderModelsUseCase) Execute(ctx context.Context, input ProviderInput) ([]string, error) {
+	scope := uc.resolver.Resolve(input.Scope)
+	cfg, err := LoadConfig(scope)
+	if err != nil {
+		return nil, err
+	}
+
+	providerCfg, exists := cfg.Providers[input.Name]
+	if !exists {
+		return nil, fmt.Errorf("provider %q not found", input.Name)
+	}
+
+	provider, err := NewFantasyProvider(ctx, NewFantasyConfig(input.Name, providerCfg))
+	if err != nil {
+		return nil, fmt.Errorf("create provider: %w", err)
+	}
+
+	return provider.Models(ctx)
+}
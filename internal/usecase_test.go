@@ -2,21 +2,18 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
 )
 
 func setupUseCaseTest(t *testing.T) (*GitRepository, *ScopeResolver) {
 	t.Helper()
 	tmpDir := t.TempDir()
-
-	origWd, _ := os.Getwd()
-	t.Cleanup(func() { _ = os.Chdir(origWd) })
-	if err := os.Chdir(tmpDir); err != nil {
-		t.Fatalf("chdir: %v", err)
-	}
-
 	scope := Scope{
 		Type:    ScopeProject,
 		Path:    tmpDir,
@@ -35,7 +32,7 @@ func setupUseCaseTest(t *testing.T) (*GitRepository, *ScopeResolver) {
 		t.Fatalf("new repo: %v", err)
 	}
 
-	return repo, NewScopeResolver()
+	return repo, NewScopeResolverAt(tmpDir, t.TempDir())
 }
 
 func TestSetAndGetUseCase(t *testing.T) {
@@ -54,9 +51,13 @@ func TestSetAndGetUseCase(t *testing.T) {
 		t.Fatalf("set: %v", err)
 	}
 
-	if _, err := commitUC.Execute(ctx, CommitInput{Message: "test: set uc/key"}); err != nil {
+	commit, err := commitUC.Execute(ctx, CommitInput{Message: "test: set uc/key"})
+	if err != nil {
 		t.Fatalf("commit: %v", err)
 	}
+	if commit.Author != DefaultAuthor || commit.Email != DefaultEmail || len(commit.Parents) != 1 {
+		t.Errorf("commit metadata = %q <%q> parents %v", commit.Author, commit.Email, commit.Parents)
+	}
 
 	out, err := getUC.Execute(ctx, GetMemoryInput{Key: "uc/key"})
 	if err != nil {
@@ -88,7 +89,7 @@ func TestDeleteUseCase(t *testing.T) {
 		t.Fatalf("commit: %v", err)
 	}
 
-	if err := delUC.Execute(ctx, DeleteMemoryInput{Key: "del-me"}); err != nil {
+	if _, err := delUC.Execute(ctx, DeleteMemoryInput{Key: "del-me"}); err != nil {
 		t.Fatalf("delete: %v", err)
 	}
 
@@ -136,6 +137,153 @@ func TestListUseCase(t *testing.T) {
 	}
 }
 
+func TestListUseCaseSortOrder(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	listUC := NewListMemoriesUseCase(resolver, func(Scope) (MemoryRepository, error) { return repo, nil })
+
+	// The walk visits a/ before a-c, but '-' sorts before '/'.
+	base := time.Now().Add(-time.Hour)
+	for i, k := range []Key{"a/b", "a-c", "b"} {
+		if err := repo.Save(ctx, NewMemory(k, []byte("val"))); err != nil {
+			t.Fatalf("save %s: %v", k, err)
+		}
+		at := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(repo.keyToPath(k), at, at); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+
+	for _, tt := range []struct {
+		sortBy string
+		want   []string
+	}{
+		{"", []string{"a-c", "a/b", "b"}},
+		{SortByKey, []string{"a-c", "a/b", "b"}},
+		{SortByUpdated, []string{"b", "a-c", "a/b"}},
+	} {
+		out, err := listUC.Execute(ctx, ListMemoriesInput{SortBy: tt.sortBy})
+		if err != nil {
+			t.Fatalf("list sorted by %q: %v", tt.sortBy, err)
+		}
+		var got []string
+		for _, mem := range out.Memories {
+			got = append(got, mem.Key)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("sorted by %q = %v, want %v", tt.sortBy, got, tt.want)
+		}
+	}
+
+	if _, err := listUC.Execute(ctx, ListMemoriesInput{SortBy: "size"}); err == nil {
+		t.Error("expected an error for an unknown sort order")
+	}
+}
+
+func TestListUseCasePaging(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	listUC := NewListMemoriesUseCase(resolver, func(Scope) (MemoryRepository, error) { return repo, nil })
+
+	keys := []Key{"e", "a/b", "c", "a-c", "d/x"}
+	base := time.Now().Add(-time.Hour)
+	for i, k := range keys {
+		if err := repo.Save(ctx, NewMemory(k, []byte("val"))); err != nil {
+			t.Fatalf("save %s: %v", k, err)
+		}
+		at := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(repo.keyToPath(k), at, at); err != nil {
+			t.Fatalf("chtimes: %v", err)
+		}
+	}
+
+	// pages lists everything two at a time, following the cursors.
+	pages := func(sortBy string) []string {
+		var got []string
+		after := ""
+		for range len(keys) {
+			out, err := listUC.Execute(ctx, ListMemoriesInput{SortBy: sortBy, Limit: 2, After: after})
+			if err != nil {
+				t.Fatalf("list after %q: %v", after, err)
+			}
+			for _, mem := range out.Memories {
+				got = append(got, mem.Key)
+			}
+			if out.Next == "" {
+				return got
+			}
+			if out.Next != got[len(got)-1] {
+				t.Errorf("Next = %q, want the last key listed, %q", out.Next, got[len(got)-1])
+			}
+			after = out.Next
+		}
+		t.Fatalf("sorted by %q: paging did not end", sortBy)
+		return nil
+	}
+
+	if got, want := pages(SortByKey), []string{"a-c", "a/b", "c", "d/x", "e"}; !slices.Equal(got, want) {
+		t.Errorf("pages by key = %v, want %v", got, want)
+	}
+	if got, want := pages(SortByUpdated), []string{"d/x", "a-c", "c", "a/b", "e"}; !slices.Equal(got, want) {
+		t.Errorf("pages by update = %v, want %v", got, want)
+	}
+
+	out, err := listUC.Execute(ctx, ListMemoriesInput{Limit: 5})
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(out.Memories) != 5 || out.Next != "" {
+		t.Errorf("limit 5 listed %d with Next %q, want all 5 and no cursor", len(out.Memories), out.Next)
+	}
+
+	// A cursor whose memory is gone still pages in key order.
+	out, err = listUC.Execute(ctx, ListMemoriesInput{After: "b"})
+	if err != nil {
+		t.Fatalf("list after deleted key: %v", err)
+	}
+	if len(out.Memories) != 3 || out.Memories[0].Key != "c" {
+		t.Errorf("after b = %+v, want c, d/x and e", out.Memories)
+	}
+	if _, err := listUC.Execute(ctx, ListMemoriesInput{SortBy: SortByUpdated, After: "b"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown cursor in time order: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestListUseCasePrefixSegments(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	listUC := NewListMemoriesUseCase(resolver, func(Scope) (MemoryRepository, error) { return repo, nil })
+
+	for _, k := range []Key{"foo/y", "foo/z/w", "foobar/x", "notes/foo", "notes/football"} {
+		if err := repo.Save(ctx, NewMemory(k, []byte("val"))); err != nil {
+			t.Fatalf("save %s: %v", k, err)
+		}
+	}
+
+	for _, tt := range []struct {
+		input ListMemoriesInput
+		want  []string
+	}{
+		{ListMemoriesInput{Prefix: "foo"}, []string{"foo/y", "foo/z/w"}},
+		{ListMemoriesInput{Prefix: "foo/"}, []string{"foo/y", "foo/z/w"}},
+		{ListMemoriesInput{Prefix: "foo", RawPrefix: true}, []string{"foo/y", "foo/z/w", "foobar/x"}},
+		{ListMemoriesInput{Prefix: "notes/foo", RawPrefix: true}, []string{"notes/foo", "notes/football"}},
+		{ListMemoriesInput{Prefix: "notes/foo"}, []string{"notes/foo"}},
+	} {
+		out, err := listUC.Execute(ctx, tt.input)
+		if err != nil {
+			t.Fatalf("list %+v: %v", tt.input, err)
+		}
+		var got []string
+		for _, mem := range out.Memories {
+			got = append(got, mem.Key)
+		}
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("list %+v = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
+
 func TestInvalidKeyUseCase(t *testing.T) {
 	_, resolver := setupUseCaseTest(t)
 
@@ -224,6 +372,38 @@ func TestKeywordSearchUseCase(t *testing.T) {
 	if len(out.Results) > 0 && out.Results[0].Key != "haystack" {
 		t.Errorf("expected key 'haystack', got %q", out.Results[0].Key)
 	}
+	if len(out.Results) > 0 && out.Results[0].Snippet != "needle in the content" {
+		t.Errorf("snippet = %q", out.Results[0].Snippet)
+	}
+}
+
+func TestMatchSnippet(t *testing.T) {
+	long := strings.Repeat("a", 50) + " NEEDLE " + strings.Repeat("b", 100)
+	tests := []struct {
+		content, query, want string
+	}{
+		{"first\n  the Needle line  \nlast", "needle", "the Needle line"},
+		{"no match", "needle", ""},
+		{"anything", "", ""},
+		{"héllo WÖRLD", "wörld", "héllo WÖRLD"},
+		{long, "needle", "…" + strings.Repeat("a", 19) + " NEEDLE " + strings.Repeat("b", 53) + "…"},
+	}
+	for _, tt := range tests {
+		if got := matchSnippet(tt.content, tt.query); got != tt.want {
+			t.Errorf("matchSnippet(%q, %q) = %q, want %q", tt.content, tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestMatchRanges(t *testing.T) {
+	got := MatchRanges("Go go GO gopher", "go")
+	want := [][2]int{{0, 2}, {3, 5}, {6, 8}, {9, 11}}
+	if !slices.Equal(got, want) {
+		t.Errorf("MatchRanges = %v, want %v", got, want)
+	}
+	if got := MatchRanges("aaa", "aa"); !slices.Equal(got, [][2]int{{0, 2}}) {
+		t.Errorf("overlapping matches = %v, want [[0 2]]", got)
+	}
 }
 
 func TestBranchCreateAndSwitchUseCase(t *testing.T) {
@@ -252,3 +432,402 @@ func TestBranchCreateAndSwitchUseCase(t *testing.T) {
 		t.Errorf("current = %q, want %q", current.Name, "dev")
 	}
 }
+
+func TestWarmupUseCaseNoEmbedder(t *testing.T) {
+	_, resolver := setupUseCaseTest(t)
+	nilIndex := func(s Scope) (VectorIndex, error) { return nil, ErrNoIndex }
+
+	uc := NewWarmupUseCase(resolver, nilIndex, nil)
+	if _, err := uc.Execute(context.Background(), WarmupInput{}); err == nil {
+		t.Error("expected error when embedder is unavailable")
+	}
+}
+
+func TestSetUseCaseEnforcesKeyPolicy(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	scope := resolver.Resolve("")
+	cfg := DefaultConfig()
+	cfg.Keys = KeyPolicy{MaxDepth: 1}
+	if err := SaveConfig(scope, cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	repoFor := func(s Scope) (MemoryRepository, error) { return repo, nil }
+	setUC := NewSetMemoryUseCase(resolver, repoFor, nil, nil, nil)
+
+	if err := setUC.Execute(ctx, SetMemoryInput{Key: "flat", Content: "ok"}); err != nil {
+		t.Fatalf("set flat key: %v", err)
+	}
+	if err := setUC.Execute(ctx, SetMemoryInput{Key: "too/deep", Content: "no"}); err == nil {
+		t.Error("expected key policy violation")
+	}
+}
+
+func TestSetUseCaseFoldsKeyCase(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	cfg := DefaultConfig()
+	cfg.Keys = KeyPolicy{CaseSensitivity: CaseLower}
+	if err := SaveConfig(resolver.Resolve(""), cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	repoFor := func(s Scope) (MemoryRepository, error) { return repo, nil }
+	setUC := NewSetMemoryUseCase(resolver, repoFor, nil, nil, nil)
+	if err := setUC.Execute(ctx, SetMemoryInput{Key: "Notes/Todo", Content: "ok"}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if _, err := repo.Get(ctx, "notes/todo"); err != nil {
+		t.Errorf("expected the key to be stored lower case: %v", err)
+	}
+
+	out, err := NewGetMemoryUseCase(resolver, repoFor).Execute(ctx, GetMemoryInput{Key: "NOTES/todo"})
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if out.Content != "ok" {
+		t.Errorf("content = %q", out.Content)
+	}
+}
+
+// countingEmbedder records the texts it embeds.
+type countingEmbedder struct {
+	constEmbedder
+	texts []string
+}
+
+func (e *countingEmbedder) Embed(ctx context.Context, text string) ([]float32, error) {
+	e.texts = append(e.texts, text)
+	return e.constEmbedder.Embed(ctx, text)
+}
+
+func TestRebuildIndexSince(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	save := func(key, content string) {
+		t.Helper()
+		if err := repo.Save(ctx, NewMemory(Key(key), []byte(content))); err != nil {
+			t.Fatalf("save %s: %v", key, err)
+		}
+	}
+	for _, k := range []string{"notes/a", "notes/b", "notes/c", "notes/d"} {
+		save(k, "first "+k)
+	}
+	if _, err := repo.Commit(ctx, "seed"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	idx := newExactIndex()
+	embedder := &countingEmbedder{}
+	uc := NewRebuildIndexUseCase(resolver,
+		func(Scope) (MemoryRepository, error) { return repo, nil },
+		func(Scope) (VectorIndex, error) { return idx, nil },
+		StaticEmbedder(embedder))
+	if _, err := uc.Execute(ctx, RebuildIndexInput{}); err != nil {
+		t.Fatalf("full rebuild: %v", err)
+	}
+	if _, err := repo.CreateSnapshot(ctx, "v1", "", false); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	save("notes/a", "second notes/a")
+	save("notes/b", "second notes/b")
+	if err := repo.Delete(ctx, "notes/c"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := repo.Commit(ctx, "edit"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	embedder.texts = nil
+	out, err := uc.Execute(ctx, RebuildIndexInput{Since: "v1"})
+	if err != nil {
+		t.Fatalf("rebuild --since: %v", err)
+	}
+	if want := []string{"notes/a", "notes/b"}; !slices.Equal(out.Keys, want) {
+		t.Errorf("keys = %v, want %v", out.Keys, want)
+	}
+	if want := []string{"notes/c"}; !slices.Equal(out.Removed, want) {
+		t.Errorf("removed = %v, want %v", out.Removed, want)
+	}
+	if want := []string{"second notes/a", "second notes/b"}; !slices.Equal(embedder.texts, want) {
+		t.Errorf("embedded %q, want %q", embedder.texts, want)
+	}
+	if want := []Key{"notes/a", "notes/b", "notes/d"}; !slices.Equal(idx.Keys(), want) {
+		t.Errorf("index keys = %v, want %v", idx.Keys(), want)
+	}
+}
+
+type constEmbedder struct{}
+
+func (constEmbedder) Embed(context.Context, string) ([]float32, error) {
+	return []float32{1, 0, 0}, nil
+}
+func (constEmbedder) EmbedBatch(_ context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i := range texts {
+		out[i] = []float32{1, 0, 0}
+	}
+	return out, nil
+}
+func (constEmbedder) Dimension() int { return 3 }
+func (constEmbedder) Device() string { return "cpu" }
+func (constEmbedder) Model() string  { return "const" }
+func (constEmbedder) Close() error   { return nil }
+
+func TestSemanticSearchIndexIsPerBranch(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	repoFor := func(s Scope) (MemoryRepository, error) { return repo, nil }
+	branchFor := func(s Scope) (BranchRepository, error) { return repo, nil }
+
+	// Keep each branch's index in memory, keyed by the path it would live at.
+	indexes := make(map[string]*AnnoyIndex)
+	indexFor := func(s Scope) (VectorIndex, error) {
+		path := IndexPath(ctx, s, repo)
+		if idx, ok := indexes[path]; ok {
+			return idx, nil
+		}
+		idx, err := NewAnnoyIndex(path, 3)
+		if err != nil {
+			return nil, err
+		}
+		indexes[path] = idx
+		return idx, nil
+	}
+
+	createUC := NewBranchCreateUseCase(resolver, branchFor)
+	switchUC := NewBranchSwitchUseCase(resolver, branchFor)
+	setUC := NewSetMemoryUseCase(resolver, repoFor, indexFor, StaticEmbedder(constEmbedder{}), nil)
+	searchUC := NewSemanticSearchUseCase(resolver, indexFor, StaticEmbedder(constEmbedder{}))
+
+	if _, err := createUC.Execute(ctx, BranchInput{Name: "b"}); err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+
+	if err := setUC.Execute(ctx, SetMemoryInput{Key: "only/on-main", Content: "main only"}); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if _, err := repo.Commit(ctx, "add only/on-main"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	for _, idx := range indexes {
+		_ = idx.Build(ctx, 1)
+	}
+
+	out, err := searchUC.Execute(ctx, SearchInput{Query: "main", Limit: 5})
+	if err != nil {
+		t.Fatalf("search on main: %v", err)
+	}
+	if len(out.Results) != 1 || out.Results[0].Key != "only/on-main" {
+		t.Fatalf("results on main = %+v, want only/on-main", out.Results)
+	}
+
+	if err := switchUC.Execute(ctx, BranchInput{Name: "b"}); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+
+	out, err = searchUC.Execute(ctx, SearchInput{Query: "main", Limit: 5})
+	if err != nil {
+		t.Fatalf("search on b: %v", err)
+	}
+	for _, r := range out.Results {
+		if r.Key == "only/on-main" {
+			t.Errorf("search on branch b returned %s from main", r.Key)
+		}
+	}
+}
+
+func TestBranchRenameMovesIndex(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	branchFor := func(Scope) (BranchRepository, error) { return repo, nil }
+
+	if _, err := repo.Create(ctx, "feature", ""); err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	scope := resolver.Resolve("")
+	if err := os.MkdirAll(scope.BranchVectorPath("feature"), 0755); err != nil {
+		t.Fatalf("mkdir index: %v", err)
+	}
+
+	uc := NewBranchRenameUseCase(resolver, branchFor)
+	if err := uc.Execute(ctx, BranchInput{Name: "feature", NewName: "topic/x"}); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+
+	if _, err := os.Stat(scope.BranchVectorPath("topic/x")); err != nil {
+		t.Errorf("index not moved: %v", err)
+	}
+	if _, err := os.Stat(scope.BranchVectorPath("feature")); !os.IsNotExist(err) {
+		t.Errorf("old index still present: %v", err)
+	}
+}
+
+func TestIndexPathMigratesLegacyIndex(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	scope := resolver.Resolve("")
+
+	// An index saved before indexes were kept per branch.
+	mapping := `{"key_to_id":{"notes/a":0},"id_to_key":{"0":"notes/a"},"next_id":1}`
+	for name, data := range map[string]string{IndexFilename: "ann", MappingFilename: mapping} {
+		if err := os.WriteFile(filepath.Join(scope.VectorPath(), name), []byte(data), 0644); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+
+	dir := IndexPath(ctx, scope, repo)
+	keys, err := IndexedKeys(dir)
+	if err != nil {
+		t.Fatalf("indexed keys: %v", err)
+	}
+	if !slices.Equal(keys, []string{"notes/a"}) {
+		t.Errorf("keys in %s = %v, want the legacy index's", dir, keys)
+	}
+	if _, err := os.Stat(filepath.Join(scope.VectorPath(), IndexFilename)); !os.IsNotExist(err) {
+		t.Errorf("legacy index still present: %v", err)
+	}
+}
+
+func TestBranchDeleteRemovesIndex(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	branchFor := func(Scope) (BranchRepository, error) { return repo, nil }
+
+	if _, err := repo.Create(ctx, "feature", ""); err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	scope := resolver.Resolve("")
+	if err := os.MkdirAll(scope.BranchVectorPath("feature"), 0755); err != nil {
+		t.Fatalf("mkdir index: %v", err)
+	}
+
+	uc := NewBranchDeleteUseCase(resolver, branchFor)
+	if err := uc.Execute(ctx, BranchInput{Name: "feature"}); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := os.Stat(scope.BranchVectorPath("feature")); !os.IsNotExist(err) {
+		t.Errorf("index of deleted branch still present: %v", err)
+	}
+}
+
+func TestRevertRefusesCommitOffBranch(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+	histFor := func(Scope) (HistoryRepository, error) { return repo, nil }
+
+	main, err := repo.Current(ctx)
+	if err != nil {
+		t.Fatalf("current: %v", err)
+	}
+	if _, err := repo.Create(ctx, "feature", ""); err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	if err := repo.Switch(ctx, "feature", false); err != nil {
+		t.Fatalf("switch: %v", err)
+	}
+	if err := repo.Save(ctx, NewMemory("only/on-feature", []byte("x"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	off, err := repo.Commit(ctx, "feature work")
+	if err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if err := repo.Switch(ctx, main.Name, false); err != nil {
+		t.Fatalf("switch back: %v", err)
+	}
+
+	uc := NewRevertUseCase(resolver, histFor)
+	for _, dryRun := range []bool{true, false} {
+		_, err := uc.Execute(ctx, RevertInput{Ref: off.Hash, DryRun: dryRun})
+		if !errors.Is(err, ErrNotAncestor) {
+			t.Errorf("revert (dry run %v) to a commit off the branch: got %v, want ErrNotAncestor", dryRun, err)
+		}
+	}
+}
+
+// treesIndex records the tree count it was built with.
+type treesIndex struct {
+	*exactIndex
+	trees int
+}
+
+func (x *treesIndex) Build(_ context.Context, numTrees int) error {
+	x.trees = numTrees
+	return nil
+}
+
+func TestRebuildIndexNumTreesFromConfig(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	cfg := DefaultConfig()
+	cfg.Embeddings.NumTrees = 25
+	if err := SaveConfig(resolver.Resolve(""), cfg); err != nil {
+		t.Fatalf("save config: %v", err)
+	}
+
+	idx := &treesIndex{exactIndex: newExactIndex()}
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	indexFor := func(Scope) (VectorIndex, error) { return idx, nil }
+	uc := NewRebuildIndexUseCase(resolver, repoFor, indexFor, StaticEmbedder(constEmbedder{}))
+
+	if _, err := uc.Execute(ctx, RebuildIndexInput{}); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	if idx.trees != 25 {
+		t.Errorf("built with %d trees, want 25 from config", idx.trees)
+	}
+
+	if _, err := uc.Execute(ctx, RebuildIndexInput{NumTrees: 3}); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	if idx.trees != 3 {
+		t.Errorf("built with %d trees, want 3 from input", idx.trees)
+	}
+}
+
+func TestUseCaseErrorsMatchSentinels(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	histFor := func(Scope) (HistoryRepository, error) { return repo, nil }
+	branchFor := func(Scope) (BranchRepository, error) { return repo, nil }
+	nilIndex := func(Scope) (VectorIndex, error) { return nil, ErrNoIndex }
+
+	if _, err := NewGetMemoryUseCase(resolver, repoFor).Execute(ctx, GetMemoryInput{Key: "missing"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get missing: want ErrNotFound, got %v", err)
+	}
+
+	err := NewSetMemoryUseCase(resolver, repoFor, nilIndex, nil, nil).Execute(ctx, SetMemoryInput{Key: "bad key", Content: "x"})
+	var keyErr *KeyError
+	if !errors.Is(err, ErrInvalidKey) || !errors.As(err, &keyErr) || keyErr.Key != "bad key" {
+		t.Errorf("set invalid key: want a *KeyError, got %v", err)
+	}
+
+	if _, err := NewCommitUseCase(resolver, histFor).Execute(ctx, CommitInput{Message: "empty"}); !errors.Is(err, ErrNothingToCommit) {
+		t.Errorf("commit clean tree: want ErrNothingToCommit, got %v", err)
+	}
+
+	create := NewBranchCreateUseCase(resolver, branchFor)
+	if _, err := create.Execute(ctx, BranchInput{Name: "topic"}); err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	if _, err := create.Execute(ctx, BranchInput{Name: "topic"}); !errors.Is(err, ErrBranchExists) {
+		t.Errorf("create existing branch: want ErrBranchExists, got %v", err)
+	}
+
+	missing := Scope{Type: ScopeProject, MemPath: filepath.Join(t.TempDir(), ".mem")}
+	_, err = NewGitRepository(missing)
+	var notInit *NotInitializedError
+	if !errors.Is(err, ErrNotInitialized) || !errors.As(err, &notInit) {
+		t.Errorf("open uninitialized store: want a *NotInitializedError, got %v", err)
+	}
+}
@@ -19,6 +19,15 @@ func NewEmbedding(vec []float32, model string) Embedding {
 type SearchResult struct {
 	Key   Key
 	Score float32 // 0-1, higher is better
+	// Distance is the raw angular distance the index measured, 0-2,
+	// lower is closer. Score is derived from it.
+	Distance float32
+}
+
+// angularSimilarity converts an angular distance, sqrt(2(1-cos)) as Annoy
+// reports it, back to the cosine similarity of the two vectors.
+func angularSimilarity(distance float32) float32 {
+	return 1 - distance*distance/2
 }
 
 type VectorIndex interface {
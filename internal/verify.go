@@ -0,0 +1,153 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+type VerifyInput struct {
+	Scope string
+	Fix   bool // drop orphaned index entries and embed unindexed memories
+}
+
+type VerifyOutput struct {
+	Memories    int
+	Indexed     int      // memories with at least one index entry
+	Orphaned    []string // index keys whose memory no longer exists
+	Unindexed   []string // memories missing from the index
+	Uncommitted []string // tracked files staged or modified in the worktree
+	Stale       bool     // the index is marked stale and needs a full rebuild
+	Fixed       bool
+}
+
+// Problems counts what is still wrong after Execute. With Fix, orphaned
+// and unindexed entries have been repaired and no longer count.
+func (o *VerifyOutput) Problems() int {
+	n := len(o.Uncommitted)
+	if o.Stale {
+		n++
+	}
+	if !o.Fixed {
+		n += len(o.Orphaned) + len(o.Unindexed)
+	}
+	return n
+}
+
+// --- VerifyUseCase ---
+
+// VerifyUseCase cross-checks the memories in the store against the keys in
+// the vector index, which drift apart when an index update fails or files
+// change outside mem.
+type VerifyUseCase struct {
+	resolver *ScopeResolver
+	repoFor  func(Scope) (MemoryRepository, error)
+	indexFor func(Scope) (VectorIndex, error)
+	embedder EmbedderFunc
+}
+
+func NewVerifyUseCase(
+	resolver *ScopeResolver,
+	repoFor func(Scope) (MemoryRepository, error),
+	indexFor func(Scope) (VectorIndex, error),
+	embedder EmbedderFunc,
+) *VerifyUseCase {
+	return &VerifyUseCase{
+		resolver: resolver,
+		repoFor:  repoFor,
+		indexFor: indexFor,
+		embedder: embedder,
+	}
+}
+
+func (uc *VerifyUseCase) Execute(ctx context.Context, input VerifyInput) (*VerifyOutput, error) {
+	scope := uc.resolver.Resolve(input.Scope)
+	repo, err := uc.repoFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get repository: %w", err)
+	}
+
+	memories, err := repo.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("list memories: %w", err)
+	}
+
+	index, err := uc.indexFor(scope)
+	if err != nil {
+		return nil, fmt.Errorf("get index: %w", err)
+	}
+	lister, ok := index.(interface{ Keys() []Key })
+	if !ok {
+		return nil, fmt.Errorf("index cannot list its keys")
+	}
+
+	output := &VerifyOutput{Memories: len(memories)}
+	if stale, ok := index.(interface{ Stale() bool }); ok {
+		output.Stale = stale.Stale()
+	}
+	if worktree, ok := repo.(interface {
+		Uncommitted(context.Context) ([]string, error)
+	}); ok {
+		if output.Uncommitted, err = worktree.Uncommitted(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	stored := make(map[Key]bool, len(memories))
+	for _, mem := range memories {
+		stored[mem.Key] = true
+	}
+	indexed := make(map[Key]bool)
+	var orphaned []Key
+	for _, key := range lister.Keys() {
+		parent := ParentKey(key)
+		if !stored[parent] {
+			orphaned = append(orphaned, key)
+			continue
+		}
+		indexed[parent] = true
+	}
+	output.Indexed = len(indexed)
+
+	var unindexed []*Memory
+	for _, mem := range memories {
+		if !indexed[mem.Key] {
+			unindexed = append(unindexed, mem)
+			output.Unindexed = append(output.Unindexed, mem.Key.String())
+		}
+	}
+	for _, key := range orphaned {
+		output.Orphaned = append(output.Orphaned, key.String())
+	}
+	slices.Sort(output.Unindexed)
+
+	if !input.Fix || (len(orphaned) == 0 && len(unindexed) == 0) {
+		return output, nil
+	}
+	embedder := uc.embedder.Get()
+	if len(unindexed) > 0 && embedder == nil {
+		return nil, ErrNoEmbedder
+	}
+
+	for _, key := range orphaned {
+		if err := index.Remove(ctx, key); err != nil {
+			return nil, fmt.Errorf("remove %s: %w", key, err)
+		}
+	}
+	for _, mem := range unindexed {
+		if err := indexMemory(ctx, scope, index, embedder, mem.Key, string(mem.Content)); err != nil {
+			return nil, fmt.Errorf("index %s: %w", mem.Key, err)
+		}
+	}
+	if err := index.Build(ctx, numTrees(scope, 0)); err != nil {
+		return nil, fmt.Errorf("build index: %w", err)
+	}
+	if err := index.Save(ctx); err != nil {
+		return nil, fmt.Errorf("save index: %w", err)
+	}
+	recordIndexSize(ctx, index)
+
+	output.Fixed = true
+	output.Indexed = len(memories)
+	return output, nil
+}
@@ -0,0 +1,99 @@
+package internal
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+func TestVerifyDesyncedIndex(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	for _, k := range []string{"notes/a", "notes/b"} {
+		key, _ := NewKey(k)
+		if err := repo.Save(ctx, NewMemory(key, []byte("content of "+k))); err != nil {
+			t.Fatalf("save %s: %v", k, err)
+		}
+	}
+	if _, err := repo.Commit(ctx, "add notes"); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	// notes/a is indexed, notes/b never was, and notes/gone was deleted
+	// without its two chunks leaving the index.
+	index := newExactIndex()
+	vec := NewEmbedding([]float32{1, 0, 0}, "local")
+	for _, k := range []Key{"notes/a", "notes/ghost", ChunkKey("notes/gone", 0), ChunkKey("notes/gone", 1)} {
+		_ = index.Add(ctx, k, vec)
+	}
+
+	repoFor := func(Scope) (MemoryRepository, error) { return repo, nil }
+	indexFor := func(Scope) (VectorIndex, error) { return index, nil }
+	uc := NewVerifyUseCase(resolver, repoFor, indexFor, StaticEmbedder(constEmbedder{}))
+
+	out, err := uc.Execute(ctx, VerifyInput{})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if want := []string{"notes/ghost", "notes/gone#0", "notes/gone#1"}; !slices.Equal(out.Orphaned, want) {
+		t.Errorf("orphaned = %v, want %v", out.Orphaned, want)
+	}
+	if want := []string{"notes/b"}; !slices.Equal(out.Unindexed, want) {
+		t.Errorf("unindexed = %v, want %v", out.Unindexed, want)
+	}
+	if out.Memories != 2 || out.Indexed != 1 || out.Problems() != 4 {
+		t.Errorf("memories=%d indexed=%d problems=%d, want 2, 1, 4", out.Memories, out.Indexed, out.Problems())
+	}
+	if index.Contains(ctx, "notes/b") {
+		t.Error("verify without --fix must not change the index")
+	}
+
+	out, err = uc.Execute(ctx, VerifyInput{Fix: true})
+	if err != nil {
+		t.Fatalf("verify --fix: %v", err)
+	}
+	if !out.Fixed || out.Problems() != 0 {
+		t.Errorf("fixed=%v problems=%d, want fixed with none left", out.Fixed, out.Problems())
+	}
+	if want := []Key{"notes/a", "notes/b"}; !slices.Equal(index.Keys(), want) {
+		t.Errorf("index keys after fix = %v, want %v", index.Keys(), want)
+	}
+
+	out, err = uc.Execute(ctx, VerifyInput{})
+	if err != nil {
+		t.Fatalf("verify after fix: %v", err)
+	}
+	if out.Problems() != 0 || out.Indexed != 2 {
+		t.Errorf("after fix: problems=%d indexed=%d, want 0 and 2", out.Problems(), out.Indexed)
+	}
+}
+
+func TestVerifyReportsUncommittedChanges(t *testing.T) {
+	repo, resolver := setupUseCaseTest(t)
+	ctx := context.Background()
+
+	key, _ := NewKey("draft")
+	if err := repo.Save(ctx, NewMemory(key, []byte("not committed"))); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	index := newExactIndex()
+	_ = index.Add(ctx, key, NewEmbedding([]float32{1, 0, 0}, "local"))
+
+	uc := NewVerifyUseCase(resolver,
+		func(Scope) (MemoryRepository, error) { return repo, nil },
+		func(Scope) (VectorIndex, error) { return index, nil },
+		nil)
+
+	out, err := uc.Execute(ctx, VerifyInput{Fix: true})
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if want := []string{"draft"}; !slices.Equal(out.Uncommitted, want) {
+		t.Errorf("uncommitted = %v, want %v", out.Uncommitted, want)
+	}
+	if out.Problems() != 1 {
+		t.Errorf("problems = %d, want 1; --fix does not commit", out.Problems())
+	}
+}
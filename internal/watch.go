@@ -0,0 +1,277 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// DefaultWatchDebounce is how long WatchStore waits for a burst of file
+// events to settle before reporting the keys they touched.
+const DefaultWatchDebounce = 100 * time.Millisecond
+
+// ChangeOp says how a watched memory changed.
+type ChangeOp string
+
+const (
+	ChangeExisting ChangeOp = "existing" // present when the watch started
+	ChangeCreated  ChangeOp = "created"
+	ChangeModified ChangeOp = "modified"
+	ChangeDeleted  ChangeOp = "deleted"
+)
+
+// ChangeEvent reports one memory that changed on disk.
+type ChangeEvent struct {
+	Key  string    `json:"key"`
+	Op   ChangeOp  `json:"op"`
+	Time time.Time `json:"time"`
+}
+
+type WatchOptions struct {
+	Prefix   string
+	Debounce time.Duration  // zero means DefaultWatchDebounce
+	Exclude  *IgnoreMatcher // keys to leave out, like the repository's excludes
+	// Initial sends a ChangeExisting event for every memory under Prefix
+	// before any change.
+	Initial bool
+}
+
+// WatchStore reports changes to the memories under memPath until ctx is
+// canceled, then closes the returned channel. Events are coalesced per key
+// over the debounce window and sorted by key. Only memories are reported:
+// .git, dot-directories, mem's own files and excluded keys are skipped.
+// Directories created while watching are watched too.
+func WatchStore(ctx context.Context, memPath string, opts WatchOptions) (<-chan ChangeEvent, error) {
+	if _, err := os.Stat(memPath); os.IsNotExist(err) {
+		return nil, &NotInitializedError{Path: memPath}
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultWatchDebounce
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("create watcher: %w", err)
+	}
+	w := &storeWatcher{
+		watcher: watcher,
+		memPath: memPath,
+		opts:    opts,
+		known:   make(map[string]bool),
+		pending: make(map[string]bool),
+	}
+	// Register before listing, so nothing written in between is missed.
+	existing, err := w.addTree(memPath)
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	slices.Sort(existing)
+	for _, key := range existing {
+		w.known[key] = true
+	}
+
+	events := make(chan ChangeEvent)
+	go func() {
+		defer close(events)
+		defer watcher.Close()
+		if opts.Initial {
+			now := time.Now()
+			for _, key := range existing {
+				if !w.send(ctx, events, ChangeEvent{Key: key, Op: ChangeExisting, Time: now}) {
+					return
+				}
+			}
+		}
+		w.run(ctx, events)
+	}()
+	return events, nil
+}
+
+type storeWatcher struct {
+	watcher *fsnotify.Watcher
+	memPath string
+	opts    WatchOptions
+	known   map[string]bool // keys that exist, as of the last report
+	pending map[string]bool // keys touched since the last report
+}
+
+func (w *storeWatcher) run(ctx context.Context, events chan<- ChangeEvent) {
+	timer := time.NewTimer(0)
+	if !timer.Stop() {
+		<-timer.C
+	}
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if w.handle(ctx, event) && len(w.pending) > 0 {
+				timer.Reset(w.opts.Debounce)
+			}
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			LoggerFrom(ctx).Warn("watch error", "error", err)
+		case <-timer.C:
+			if !w.flush(ctx, events) {
+				return
+			}
+		}
+	}
+}
+
+// handle records the keys event touches and reports whether it touched any.
+func (w *storeWatcher) handle(ctx context.Context, event fsnotify.Event) bool {
+	rel, err := filepath.Rel(w.memPath, event.Name)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+
+	if event.Has(fsnotify.Create) {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if !w.watchDir(rel) {
+				return false
+			}
+			// Files may land in the directory before it is watched.
+			keys, err := w.addTree(event.Name)
+			if err != nil {
+				LoggerFrom(ctx).Warn("watch directory", "path", event.Name, "error", err)
+			}
+			for _, key := range keys {
+				w.pending[key] = true
+			}
+			return len(keys) > 0
+		}
+	}
+
+	touched := false
+	if key, ok := w.key(rel); ok {
+		w.pending[key] = true
+		touched = true
+	}
+	// A directory moved or removed takes its keys with it.
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		for known := range w.known {
+			if strings.HasPrefix(known, rel+"/") {
+				w.pending[known] = true
+				touched = true
+			}
+		}
+	}
+	return touched
+}
+
+// flush reports the pending keys and reports false if ctx was canceled.
+func (w *storeWatcher) flush(ctx context.Context, events chan<- ChangeEvent) bool {
+	keys := make([]string, 0, len(w.pending))
+	for key := range w.pending {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	clear(w.pending)
+
+	now := time.Now()
+	for _, key := range keys {
+		_, err := os.Stat(filepath.Join(w.memPath, filepath.FromSlash(key)))
+		exists := err == nil
+		var op ChangeOp
+		switch {
+		case exists && w.known[key]:
+			op = ChangeModified
+		case exists:
+			op = ChangeCreated
+			w.known[key] = true
+		case w.known[key]:
+			op = ChangeDeleted
+			delete(w.known, key)
+		default:
+			continue // created and removed within the window
+		}
+		if !w.send(ctx, events, ChangeEvent{Key: key, Op: op, Time: now}) {
+			return false
+		}
+	}
+	return true
+}
+
+func (w *storeWatcher) send(ctx context.Context, events chan<- ChangeEvent, event ChangeEvent) bool {
+	select {
+	case events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// addTree watches dir and the directories below it that can hold
+// memories, and returns the keys already in them.
+func (w *storeWatcher) addTree(dir string) ([]string, error) {
+	var keys []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // vanished while walking
+		}
+		rel, err := filepath.Rel(w.memPath, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if info.IsDir() {
+			if rel != "." && !w.watchDir(rel) {
+				return filepath.SkipDir
+			}
+			return w.watcher.Add(path)
+		}
+		if key, ok := w.key(rel); ok {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("watch %s: %w", dir, err)
+	}
+	return keys, nil
+}
+
+// watchDir reports whether the directory at rel can hold watched memories.
+func (w *storeWatcher) watchDir(rel string) bool {
+	for _, segment := range strings.Split(rel, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return false
+		}
+	}
+	first, _, _ := strings.Cut(rel, "/")
+	if slices.Contains(reservedNames, first) || w.opts.Exclude.MatchFile(rel) {
+		return false
+	}
+	prefix := strings.TrimSuffix(w.opts.Prefix, "/")
+	return underPrefix(rel, prefix) || underPrefix(prefix, rel)
+}
+
+// key returns the key stored at rel if it is a watched memory.
+func (w *storeWatcher) key(rel string) (string, bool) {
+	for _, segment := range strings.Split(rel, "/") {
+		if strings.HasPrefix(segment, ".") {
+			return "", false
+		}
+	}
+	key, err := NewKey(rel)
+	if err != nil || w.opts.Exclude.MatchFile(rel) || !underPrefix(rel, w.opts.Prefix) {
+		return "", false
+	}
+	return key.String(), true
+}
@@ -2,15 +2,23 @@ package v1
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/4thel00z/memories/internal"
 )
 
 // Client provides programmatic access to the memory store.
 type Client struct {
-	uc    *internal.UseCases
-	scope string
+	uc       *internal.UseCases
+	resolver *internal.ScopeResolver
+	scope    string
+	indexFor func(internal.Scope) (internal.VectorIndex, error)
+	custom   bool // memories live in a WithRepository backend, not on disk
 }
 
 // New creates a new Client with the given options.
@@ -22,7 +30,7 @@ func New(opts ...Option) (*Client, error) {
 		opt(cfg)
 	}
 
-	resolver := internal.NewScopeResolver()
+	resolver := internal.NewScopeResolverAt(cfg.workdir, cfg.home)
 
 	repoFor := func(scope internal.Scope) (internal.MemoryRepository, error) {
 		return internal.NewGitRepository(scope)
@@ -30,40 +38,126 @@ func New(opts ...Option) (*Client, error) {
 	histFor := func(scope internal.Scope) (internal.HistoryRepository, error) {
 		return internal.NewGitRepository(scope)
 	}
+	if cfg.repo != nil {
+		repoFor = func(internal.Scope) (internal.MemoryRepository, error) { return cfg.repo, nil }
+		histFor = func(internal.Scope) (internal.HistoryRepository, error) { return cfg.repo, nil }
+	}
 
-	nilIndex := func(scope internal.Scope) (internal.VectorIndex, error) {
+	indexFor := func(scope internal.Scope) (internal.VectorIndex, error) {
 		return nil, internal.ErrNoIndex
 	}
+	var embedder internal.EmbedderFunc
+	if cfg.embedder != nil {
+		embedder = internal.StaticEmbedder(cfg.embedder)
+		indexFor = annoyIndexFor(cfg)
+	}
 
 	uc := &internal.UseCases{
-		SetMemory:    internal.NewSetMemoryUseCase(resolver, repoFor, nilIndex, nil, nil),
+		SetMemory:    internal.NewSetMemoryUseCase(resolver, repoFor, indexFor, embedder, nil),
 		GetMemory:    internal.NewGetMemoryUseCase(resolver, repoFor),
-		DeleteMemory: internal.NewDeleteMemoryUseCase(resolver, repoFor, nilIndex),
+		DeleteMemory: internal.NewDeleteMemoryUseCase(resolver, repoFor, indexFor),
 		ListMemories: internal.NewListMemoriesUseCase(resolver, repoFor),
 		Commit:       internal.NewCommitUseCase(resolver, histFor),
+		BulkSet:      internal.NewBulkSetUseCase(resolver, repoFor, histFor, indexFor, embedder, nil),
 	}
 
 	return &Client{
-		uc:    uc,
-		scope: cfg.scope,
+		uc:       uc,
+		resolver: resolver,
+		scope:    cfg.scope,
+		indexFor: indexFor,
+		custom:   cfg.repo != nil,
 	}, nil
 }
 
-// Set creates or updates a memory.
-func (c *Client) Set(ctx context.Context, key string, value []byte) error {
+// annoyIndexFor opens each index directory once, so every Set and Delete
+// of the client updates the same index.
+func annoyIndexFor(cfg *clientConfig) func(internal.Scope) (internal.VectorIndex, error) {
+	var mu sync.Mutex
+	indexes := make(map[string]*internal.AnnoyIndex)
+
+	return func(scope internal.Scope) (internal.VectorIndex, error) {
+		dir := cfg.indexDir
+		if dir == "" {
+			var branches internal.BranchRepository
+			if repo, err := internal.NewGitRepository(scope); err == nil {
+				branches = repo
+			}
+			dir = internal.IndexPath(context.Background(), scope, branches)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if idx, ok := indexes[dir]; ok {
+			return idx, nil
+		}
+
+		idx, err := internal.NewAnnoyIndex(dir, cfg.embedder.Dimension())
+		if err != nil {
+			return nil, err
+		}
+		if err := idx.Load(context.Background()); err != nil {
+			return nil, err
+		}
+		indexes[dir] = idx
+		return idx, nil
+	}
+}
+
+// Set creates or updates a memory and commits it as "set: <key>", like
+// mem set. Setting a memory to its current content makes no commit.
+func (c *Client) Set(ctx context.Context, key string, value []byte, opts ...SetOption) error {
+	o := applySetOptions(opts)
 	if err := c.uc.SetMemory.Execute(ctx, internal.SetMemoryInput{
-		Key: key, Content: string(value), Scope: c.scope,
+		Key: key, Content: string(value), Scope: c.scope, Tags: o.Tags,
 	}); err != nil {
 		return fmt.Errorf("set: %w", err)
 	}
+	if o.SkipCommit {
+		return nil
+	}
+
+	message := o.CommitMessage
+	if message == "" {
+		message = fmt.Sprintf("set: %s", key)
+	}
+	return c.commit(ctx, message)
+}
+
+// BatchSet writes every memory in values as a single commit, like
+// mem set --from-file. Either all of them are written or none is.
+func (c *Client) BatchSet(ctx context.Context, values map[string][]byte, opts ...SetOption) error {
+	o := applySetOptions(opts)
+	pairs := make([]internal.KeyValue, 0, len(values))
+	for key, value := range values {
+		pairs = append(pairs, internal.KeyValue{Key: key, Content: string(value)})
+	}
+	slices.SortFunc(pairs, func(a, b internal.KeyValue) int { return strings.Compare(a.Key, b.Key) })
 
+	if _, err := c.uc.BulkSet.Execute(ctx, internal.BulkSetInput{
+		Pairs:    pairs,
+		Scope:    c.scope,
+		Message:  o.CommitMessage,
+		Tags:     o.Tags,
+		NoCommit: o.SkipCommit,
+	}); err != nil {
+		return fmt.Errorf("batch set: %w", err)
+	}
+	return nil
+}
+
+// commit commits the staged writes; having nothing to commit is fine.
+func (c *Client) commit(ctx context.Context, message string) error {
 	_, err := c.uc.Commit.Execute(ctx, internal.CommitInput{
-		Message: fmt.Sprintf("set: %s", key), Scope: c.scope,
+		Message: message, Scope: c.scope,
 	})
+	if errors.Is(err, internal.ErrNothingToCommit) {
+		return nil
+	}
 	return err
 }
 
-// Get retrieves a memory by key.
+// Get retrieves a memory by key, or by an alias written as "@name".
 func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
 	out, err := c.uc.GetMemory.Execute(ctx, internal.GetMemoryInput{
 		Key: key, Scope: c.scope,
@@ -74,18 +168,45 @@ func (c *Client) Get(ctx context.Context, key string) ([]byte, error) {
 	return []byte(out.Content), nil
 }
 
+// GetStream copies the memory at key to w without reading it whole, for
+// memories too large to hold in memory, and returns the bytes written.
+func (c *Client) GetStream(ctx context.Context, key string, w io.Writer) (int64, error) {
+	rc, err := c.uc.GetMemory.Open(ctx, internal.GetMemoryInput{
+		Key: key, Scope: c.scope,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+	return io.Copy(w, rc)
+}
+
+// GetMeta retrieves a memory by key with its tags and timestamps.
+func (c *Client) GetMeta(ctx context.Context, key string) (*Memory, error) {
+	out, err := c.uc.GetMemory.Execute(ctx, internal.GetMemoryInput{
+		Key: key, Scope: c.scope,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Memory{
+		Key:       out.Key,
+		Content:   []byte(out.Content),
+		Tags:      out.Tags,
+		CreatedAt: out.CreatedAt,
+		UpdatedAt: out.UpdatedAt,
+	}, nil
+}
+
 // Delete removes a memory.
 func (c *Client) Delete(ctx context.Context, key string) error {
-	if err := c.uc.DeleteMemory.Execute(ctx, internal.DeleteMemoryInput{
+	if _, err := c.uc.DeleteMemory.Execute(ctx, internal.DeleteMemoryInput{
 		Key: key, Scope: c.scope,
 	}); err != nil {
 		return fmt.Errorf("delete: %w", err)
 	}
 
-	_, err := c.uc.Commit.Execute(ctx, internal.CommitInput{
-		Message: fmt.Sprintf("del: %s", key), Scope: c.scope,
-	})
-	return err
+	return c.commit(ctx, fmt.Sprintf("del: %s", key))
 }
 
 // List returns all memories matching the prefix.
@@ -102,6 +223,7 @@ func (c *Client) List(ctx context.Context, prefix string) ([]Memory, error) {
 		memories = append(memories, Memory{
 			Key:       m.Key,
 			Content:   []byte(m.Content),
+			Tags:      m.Tags,
 			CreatedAt: m.CreatedAt,
 			UpdatedAt: m.UpdatedAt,
 		})
@@ -109,6 +231,40 @@ func (c *Client) List(ctx context.Context, prefix string) ([]Memory, error) {
 	return memories, nil
 }
 
+// Watch reports changes to the memories under prefix, made by this or any
+// other process, until ctx is canceled, when the channel is closed. Bursts
+// of writes are coalesced per key, and the store's .memignore and
+// storage.list_excludes apply.
+func (c *Client) Watch(ctx context.Context, prefix string, opts ...WatchOption) (<-chan ChangeEvent, error) {
+	if c.custom {
+		return nil, errors.New("watch: only stores on disk can be watched, not a WithRepository backend")
+	}
+	cfg := &watchConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	scope := c.resolver.Resolve(c.scope)
+	exclude, err := internal.NewIgnoreMatcher(scope)
+	if err != nil {
+		return nil, fmt.Errorf("watch: %w", err)
+	}
+	if storeCfg, err := internal.LoadConfig(scope); err == nil {
+		exclude.Add(storeCfg.Storage.ListExcludes...)
+	}
+
+	events, err := internal.WatchStore(ctx, scope.MemPath, internal.WatchOptions{
+		Prefix:   prefix,
+		Debounce: cfg.debounce,
+		Exclude:  exclude,
+		Initial:  cfg.initial,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("watch: %w", err)
+	}
+	return events, nil
+}
+
 // Close releases any resources held by the client.
 func (c *Client) Close() error {
 	return nil
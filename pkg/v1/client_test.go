@@ -1,23 +1,29 @@
 package v1
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"os"
 	"path/filepath"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/4thel00z/memories/internal"
 )
 
-func setupClientTest(t *testing.T) *Client {
+func setupClientTest(t *testing.T, opts ...Option) *Client {
 	t.Helper()
-	tmpDir := t.TempDir()
+	return newClientAt(t, setupClientStore(t), opts...)
+}
 
-	origWd, _ := os.Getwd()
-	t.Cleanup(func() { _ = os.Chdir(origWd) })
-	if err := os.Chdir(tmpDir); err != nil {
-		t.Fatalf("chdir: %v", err)
-	}
+// setupClientStore initializes a project store in a temp directory and
+// returns the directory.
+func setupClientStore(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
 
 	scope := internal.Scope{
 		Type:    internal.ScopeProject,
@@ -31,12 +37,18 @@ func setupClientTest(t *testing.T) *Client {
 	if err := internal.InitRepository(scope); err != nil {
 		t.Fatalf("init repo: %v", err)
 	}
+	return tmpDir
+}
 
-	client, err := New()
+// newClientAt returns a client for the store in dir, with a global store
+// of its own, without touching the working directory.
+func newClientAt(t *testing.T, dir string, opts ...Option) *Client {
+	t.Helper()
+	opts = append([]Option{WithPath(dir), WithHome(filepath.Join(dir, "home"))}, opts...)
+	client, err := New(opts...)
 	if err != nil {
 		t.Fatalf("new client: %v", err)
 	}
-
 	return client
 }
 
@@ -60,6 +72,38 @@ func TestClientSetAndGet(t *testing.T) {
 	}
 }
 
+func TestClientGetStreamLargeMemory(t *testing.T) {
+	client := setupClientTest(t)
+	defer client.Close()
+
+	ctx := context.Background()
+
+	// 16 MiB that is not one repeated byte, so an offset error shows.
+	want := make([]byte, 16<<20)
+	for i := range want {
+		want[i] = byte(i*7 + i>>13)
+	}
+	if err := client.Set(ctx, "blobs/large", want); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	var got bytes.Buffer
+	n, err := client.GetStream(ctx, "blobs/large", &got)
+	if err != nil {
+		t.Fatalf("get stream: %v", err)
+	}
+	if n != int64(len(want)) {
+		t.Errorf("streamed %d bytes, want %d", n, len(want))
+	}
+	if !bytes.Equal(got.Bytes(), want) {
+		t.Error("streamed content differs from what was set")
+	}
+
+	if _, err := client.GetStream(ctx, "blobs/missing", &got); !errors.Is(err, internal.ErrNotFound) {
+		t.Errorf("missing key: err = %v, want ErrNotFound", err)
+	}
+}
+
 func TestClientDelete(t *testing.T) {
 	client := setupClientTest(t)
 	defer client.Close()
@@ -114,8 +158,8 @@ func TestClientGetNotFound(t *testing.T) {
 	defer client.Close()
 
 	_, err := client.Get(context.Background(), "nonexistent")
-	if err == nil {
-		t.Error("expected error for nonexistent key")
+	if !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound for nonexistent key, got %v", err)
 	}
 }
 
@@ -124,7 +168,283 @@ func TestClientInvalidKey(t *testing.T) {
 	defer client.Close()
 
 	err := client.Set(context.Background(), "", []byte("x"))
-	if err == nil {
-		t.Error("expected error for empty key")
+	if !errors.Is(err, ErrInvalidKey) {
+		t.Errorf("expected ErrInvalidKey for empty key, got %v", err)
+	}
+
+	err = client.Set(context.Background(), "has spaces", []byte("x"))
+	var keyErr *KeyError
+	if !errors.As(err, &keyErr) || keyErr.Key != "has spaces" {
+		t.Errorf("expected a *KeyError for %q, got %v", "has spaces", err)
+	}
+}
+
+type fakeEmbedder struct{}
+
+func (fakeEmbedder) Embed(_ context.Context, text string) ([]float32, error) {
+	return []float32{float32(len(text)), 1, 0}, nil
+}
+
+func (e fakeEmbedder) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	out := make([][]float32, len(texts))
+	for i, text := range texts {
+		out[i], _ = e.Embed(ctx, text)
+	}
+	return out, nil
+}
+
+func (fakeEmbedder) Dimension() int { return 3 }
+func (fakeEmbedder) Device() string { return "cpu" }
+func (fakeEmbedder) Model() string  { return "fake" }
+func (fakeEmbedder) Close() error   { return nil }
+
+func TestClientWithEmbedderIndexesWrites(t *testing.T) {
+	client := setupClientTest(t, WithEmbedder(fakeEmbedder{}), WithIndexDir(t.TempDir()))
+	defer client.Close()
+
+	ctx := context.Background()
+	index, err := client.indexFor(client.resolver.Resolve(""))
+	if err != nil {
+		t.Fatalf("index: %v", err)
+	}
+
+	if err := client.Set(ctx, "notes/indexed", []byte("hello")); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if !index.Contains(ctx, "notes/indexed") {
+		t.Fatal("expected the index to gain an entry after Set")
+	}
+
+	if err := client.Delete(ctx, "notes/indexed"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if index.Contains(ctx, "notes/indexed") {
+		t.Error("expected Delete to remove the index entry")
+	}
+}
+
+func TestClientWithoutEmbedderHasNoIndex(t *testing.T) {
+	client := setupClientTest(t)
+	defer client.Close()
+
+	if _, err := client.indexFor(client.resolver.Resolve("")); !errors.Is(err, internal.ErrNoIndex) {
+		t.Errorf("err = %v, want ErrNoIndex", err)
+	}
+}
+
+func TestClientNotInitialized(t *testing.T) {
+	client := newClientAt(t, t.TempDir())
+	defer client.Close()
+
+	err := client.Set(context.Background(), "k", []byte("v"))
+	var notInit *NotInitializedError
+	if !errors.Is(err, ErrNotInitialized) || !errors.As(err, &notInit) || notInit.Path == "" {
+		t.Errorf("expected a NotInitializedError, got %v", err)
+	}
+}
+
+func TestClientWatchSeesOtherClient(t *testing.T) {
+	dir := setupClientStore(t)
+	watcher := newClientAt(t, dir)
+	defer watcher.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	writer := newClientAt(t, dir)
+	defer writer.Close()
+	if err := writer.Set(ctx, "notes/old", []byte("before")); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := writer.Set(ctx, "other", []byte("outside the prefix")); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+
+	events, err := watcher.Watch(ctx, "notes", WithInitialSnapshot(), WithDebounce(20*time.Millisecond))
+	if err != nil {
+		t.Fatalf("watch: %v", err)
+	}
+	next := func() ChangeEvent {
+		t.Helper()
+		select {
+		case ev, ok := <-events:
+			if !ok {
+				t.Fatal("events closed early")
+			}
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a change event")
+		}
+		return ChangeEvent{}
+	}
+	expect := func(key string, op ChangeOp) {
+		t.Helper()
+		if ev := next(); ev.Key != key || ev.Op != op {
+			t.Errorf("got %s %s, want %s %s", ev.Op, ev.Key, op, key)
+		}
+	}
+
+	expect("notes/old", ChangeExisting)
+
+	if err := writer.Set(ctx, "notes/new/deep", []byte("hi")); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	expect("notes/new/deep", ChangeCreated)
+
+	if err := writer.Set(ctx, "notes/old", []byte("after")); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	expect("notes/old", ChangeModified)
+
+	if err := writer.Set(ctx, "other", []byte("ignored")); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := writer.Delete(ctx, "notes/new/deep"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	expect("notes/new/deep", ChangeDeleted)
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestClientWithRepository(t *testing.T) {
+	tmpDir := t.TempDir()
+	repo := NewInMemoryRepository()
+	client := newClientAt(t, tmpDir, WithRepository(repo))
+	defer client.Close()
+
+	ctx := context.Background()
+	for _, key := range []string{"foo/a", "foo/b", "bar/c"} {
+		if err := client.Set(ctx, key, []byte("content of "+key)); err != nil {
+			t.Fatalf("set %s: %v", key, err)
+		}
+	}
+	got, err := client.Get(ctx, "foo/a")
+	if err != nil || string(got) != "content of foo/a" {
+		t.Errorf("get = %q, %v", got, err)
+	}
+	foos, err := client.List(ctx, "foo")
+	if err != nil || len(foos) != 2 {
+		t.Errorf("list foo = %v, %v", foos, err)
+	}
+	if err := client.Delete(ctx, "foo/a"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := client.Get(ctx, "foo/a"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("get deleted: want ErrNotFound, got %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(tmpDir, ".mem")); !os.IsNotExist(err) {
+		t.Errorf("WithRepository client touched the disk: %v", err)
+	}
+	if exists, _ := repo.Exists(ctx, internal.Key("bar/c")); !exists {
+		t.Error("bar/c not stored in the repository")
+	}
+	if _, err := client.Watch(ctx, ""); err == nil {
+		t.Error("watch on a WithRepository client: want an error")
+	}
+}
+
+func TestClientSetOptions(t *testing.T) {
+	client := setupClientTest(t)
+	defer client.Close()
+	ctx := context.Background()
+	repo, err := internal.NewGitRepository(client.resolver.Resolve(""))
+	if err != nil {
+		t.Fatalf("open repo: %v", err)
+	}
+
+	if err := client.Set(ctx, "a", []byte("one")); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	// Setting the same content again is not an error, as with mem set.
+	if err := client.Set(ctx, "a", []byte("one")); err != nil {
+		t.Fatalf("set unchanged: %v", err)
+	}
+	if err := client.Set(ctx, "b", []byte("two"), WithSkipCommit()); err != nil {
+		t.Fatalf("set skip commit: %v", err)
+	}
+	if err := client.Set(ctx, "c", []byte("three"), WithCommitMessage("custom message")); err != nil {
+		t.Fatalf("set with message: %v", err)
+	}
+	if err := client.BatchSet(ctx, map[string][]byte{"x": []byte("1"), "y": []byte("2")}); err != nil {
+		t.Fatalf("batch set: %v", err)
+	}
+
+	commits, err := repo.Log(ctx, 3)
+	if err != nil {
+		t.Fatalf("log: %v", err)
+	}
+	var messages []string
+	for _, c := range commits {
+		messages = append(messages, c.Message)
+	}
+	want := []string{"set: 2 keys", "custom message", "set: a"}
+	if !slices.Equal(messages, want) {
+		t.Errorf("commits = %q, want %q", messages, want)
+	}
+	if got, err := client.Get(ctx, "b"); err != nil || string(got) != "two" {
+		t.Errorf("get skipped-commit key = %q, %v", got, err)
+	}
+}
+
+func TestClientTagsAndGetMeta(t *testing.T) {
+	client := newClientAt(t, t.TempDir(), WithRepository(NewInMemoryRepository()))
+	defer client.Close()
+	ctx := context.Background()
+
+	if err := client.Set(ctx, "a", []byte("one"), WithTags("work", "todo")); err != nil {
+		t.Fatalf("set: %v", err)
+	}
+	if err := client.BatchSet(ctx, map[string][]byte{"b": []byte("two")}, WithTags("bulk"), WithSkipCommit()); err != nil {
+		t.Fatalf("batch set: %v", err)
+	}
+
+	mem, err := client.GetMeta(ctx, "a")
+	if err != nil {
+		t.Fatalf("get meta: %v", err)
+	}
+	if mem.Key != "a" || string(mem.Content) != "one" || !slices.Equal(mem.Tags, []string{"work", "todo"}) || mem.UpdatedAt.IsZero() {
+		t.Errorf("GetMeta = %+v", mem)
+	}
+	list, err := client.List(ctx, "b")
+	if err != nil || len(list) != 1 || !slices.Equal(list[0].Tags, []string{"bulk"}) {
+		t.Errorf("list b = %+v, %v", list, err)
+	}
+	if _, err := client.GetMeta(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("GetMeta missing: want ErrNotFound, got %v", err)
+	}
+}
+
+func TestClientWorkdirsAreIndependent(t *testing.T) {
+	ctx := context.Background()
+	dirs := []string{setupClientStore(t), setupClientStore(t)}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(dirs))
+	for i, dir := range dirs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := New(WithWorkdir(dir), WithHome(filepath.Join(dir, "home")))
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer client.Close()
+			errs[i] = client.Set(ctx, "owner", []byte(dir))
+		}()
+	}
+	wg.Wait()
+
+	for i, dir := range dirs {
+		if errs[i] != nil {
+			t.Fatalf("client %d: %v", i, errs[i])
+		}
+		got, err := os.ReadFile(filepath.Join(dir, ".mem", "owner"))
+		if err != nil || string(got) != dir {
+			t.Errorf("store %d holds %q, %v; want its own dir", i, got, err)
+		}
 	}
 }
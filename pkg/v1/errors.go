@@ -0,0 +1,25 @@
+package v1
+
+import "github.com/4thel00z/memories/internal"
+
+// Errors returned by Client methods, for use with errors.Is. They are the
+// errors mem itself uses, so they match however deeply a call wraps them.
+var (
+	ErrNotFound            = internal.ErrNotFound
+	ErrInvalidKey          = internal.ErrInvalidKey
+	ErrNotInitialized      = internal.ErrNotInitialized
+	ErrNothingToCommit     = internal.ErrNothingToCommit
+	ErrBranchExists        = internal.ErrBranchExists
+	ErrNoIndex             = internal.ErrNoIndex
+	ErrIndexStale          = internal.ErrIndexStale
+	ErrProviderUnavailable = internal.ErrProviderUnavailable
+	ErrReadOnly            = internal.ErrReadOnly
+)
+
+// KeyError carries the rejected key and the reason; use errors.As. It
+// matches ErrInvalidKey.
+type KeyError = internal.KeyError
+
+// NotInitializedError carries the missing store path; use errors.As. It
+// matches ErrNotInitialized.
+type NotInitializedError = internal.NotInitializedError
@@ -0,0 +1,71 @@
+// Package memtest provides memory stores for tests of code that uses
+// pkg/v1. Each store lives in its own temporary directory and does not
+// depend on the working directory, so tests using it can run in parallel.
+package memtest
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/4thel00z/memories/internal"
+	v1 "github.com/4thel00z/memories/pkg/v1"
+)
+
+// InitStore creates an initialized project store in a temporary directory
+// and returns the directory; the store itself is in its .mem.
+func InitStore(t testing.TB) string {
+	t.Helper()
+	dir := t.TempDir()
+	scope := internal.Scope{
+		Type:    internal.ScopeProject,
+		Path:    dir,
+		MemPath: filepath.Join(dir, ".mem"),
+	}
+	if err := os.MkdirAll(scope.VectorPath(), 0755); err != nil {
+		t.Fatalf("memtest: create store: %v", err)
+	}
+	if err := internal.InitRepository(scope); err != nil {
+		t.Fatalf("memtest: init store: %v", err)
+	}
+	return dir
+}
+
+// NewStore returns a Client bound to a new store from InitStore, with an
+// empty global store of its own, and closes it when the test ends. opts
+// are applied after the path options.
+func NewStore(t testing.TB, opts ...v1.Option) *v1.Client {
+	t.Helper()
+	dir := InitStore(t)
+	opts = append([]v1.Option{
+		v1.WithPath(dir),
+		v1.WithHome(filepath.Join(t.TempDir(), "home")),
+	}, opts...)
+	client, err := v1.New(opts...)
+	if err != nil {
+		t.Fatalf("memtest: new client: %v", err)
+	}
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+// Seed writes memories, key to content, in one commit.
+func Seed(t testing.TB, client *v1.Client, memories map[string]string) {
+	t.Helper()
+	Commit(t, client, "", memories)
+}
+
+// Commit writes memories, key to content, in one commit with message, or
+// the default "set: N keys" if message is empty. Call it once per commit
+// to build up a history.
+func Commit(t testing.TB, client *v1.Client, message string, memories map[string]string) {
+	t.Helper()
+	values := make(map[string][]byte, len(memories))
+	for key, content := range memories {
+		values[key] = []byte(content)
+	}
+	if err := client.BatchSet(context.Background(), values, v1.WithCommitMessage(message)); err != nil {
+		t.Fatalf("memtest: commit %q: %v", message, err)
+	}
+}
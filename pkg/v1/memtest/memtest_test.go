@@ -0,0 +1,29 @@
+package memtest
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestNewStoresAreIsolated(t *testing.T) {
+	for i := range 3 {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+			client := NewStore(t)
+			ctx := context.Background()
+
+			key := fmt.Sprintf("store/%d", i)
+			Seed(t, client, map[string]string{key: "mine"})
+			Commit(t, client, "second", map[string]string{key: "changed"})
+
+			list, err := client.List(ctx, "")
+			if err != nil {
+				t.Fatalf("list: %v", err)
+			}
+			if len(list) != 1 || list[0].Key != key || string(list[0].Content) != "changed" {
+				t.Errorf("store %d holds %+v, want only %s", i, list, key)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package v1
 
+import "time"
+
 // Option configures a Client.
 type Option func(*clientConfig)
 
@@ -7,6 +9,11 @@ type clientConfig struct {
 	cacheDir  string
 	dimension int
 	scope     string
+	embedder  Embedder
+	indexDir  string
+	repo      Repository
+	workdir   string
+	home      string
 }
 
 // WithCacheDir sets the model cache directory.
@@ -29,3 +36,121 @@ func WithScope(scope string) Option {
 		c.scope = scope
 	}
 }
+
+// WithWorkdir makes the client behave as if run in dir rather than the
+// process's working directory: the project store is the nearest .mem at or
+// above dir. Clients with different workdirs can serve different projects
+// in one process.
+func WithWorkdir(dir string) Option {
+	return func(c *clientConfig) {
+		c.workdir = dir
+	}
+}
+
+// WithPath binds the client to the store in dir/.mem; it is WithWorkdir.
+func WithPath(dir string) Option {
+	return WithWorkdir(dir)
+}
+
+// WithHome uses dir as the home directory, so the global store is
+// dir/.mem; $MEM_HOME is then ignored.
+func WithHome(dir string) Option {
+	return func(c *clientConfig) {
+		c.home = dir
+	}
+}
+
+// WithEmbedder keeps a semantic index up to date on Set and Delete using e.
+// Without an embedder the client does not index.
+func WithEmbedder(e Embedder) Option {
+	return func(c *clientConfig) {
+		c.embedder = e
+	}
+}
+
+// WithIndexDir stores the semantic index in dir instead of the scope's
+// vectors directory.
+func WithIndexDir(dir string) Option {
+	return func(c *clientConfig) {
+		c.indexDir = dir
+	}
+}
+
+// WithRepository stores memories in repo instead of the scope's git
+// repository, e.g. NewInMemoryRepository() in tests. Every scope maps to
+// repo.
+func WithRepository(repo Repository) Option {
+	return func(c *clientConfig) {
+		c.repo = repo
+	}
+}
+
+// SetOptions controls how Set and BatchSet record a write.
+type SetOptions struct {
+	// CommitMessage replaces the default, which is the CLI's: "set: <key>"
+	// for Set and "set: N keys" for BatchSet.
+	CommitMessage string
+	// SkipCommit stages the write without committing it, so several
+	// writes can share one commit made later, e.g. by mem commit.
+	SkipCommit bool
+	// Tags are stored with the memory by repositories that keep metadata,
+	// such as InMemoryRepository. The git repository does not yet.
+	Tags []string
+}
+
+// SetOption configures a single Set or BatchSet.
+type SetOption func(*SetOptions)
+
+// WithCommitMessage sets the message of the commit the write makes.
+func WithCommitMessage(message string) SetOption {
+	return func(o *SetOptions) {
+		o.CommitMessage = message
+	}
+}
+
+// WithSkipCommit stages the write without committing it.
+func WithSkipCommit() SetOption {
+	return func(o *SetOptions) {
+		o.SkipCommit = true
+	}
+}
+
+// WithTags tags the written memories.
+func WithTags(tags ...string) SetOption {
+	return func(o *SetOptions) {
+		o.Tags = append(o.Tags, tags...)
+	}
+}
+
+func applySetOptions(opts []SetOption) SetOptions {
+	var o SetOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WatchOption configures Client.Watch.
+type WatchOption func(*watchConfig)
+
+type watchConfig struct {
+	initial  bool
+	debounce time.Duration
+}
+
+// WithInitialSnapshot makes Watch first send a ChangeExisting event for
+// every memory already under the prefix, so a consumer can prime a cache
+// from the same channel.
+func WithInitialSnapshot() WatchOption {
+	return func(c *watchConfig) {
+		c.initial = true
+	}
+}
+
+// WithDebounce sets how long Watch waits for writes to settle before
+// reporting them. The default is 100ms.
+func WithDebounce(d time.Duration) WatchOption {
+	return func(c *watchConfig) {
+		c.debounce = d
+	}
+}
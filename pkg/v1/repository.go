@@ -0,0 +1,22 @@
+package v1
+
+import "github.com/4thel00z/memories/internal"
+
+// Repository is a storage backend for WithRepository: it stores the
+// memories and records their history. A backend that also has a
+// GetReader(ctx, key) (io.ReadCloser, error) method lets GetStream copy
+// memories without reading them whole.
+type Repository interface {
+	internal.MemoryRepository
+	internal.HistoryRepository
+}
+
+// InMemoryRepository is a Repository that keeps everything in memory, for
+// tests and short-lived stores. It has a single branch and no files, so
+// Watch does not work with it.
+type InMemoryRepository = internal.InMemoryRepository
+
+// NewInMemoryRepository returns an empty InMemoryRepository.
+func NewInMemoryRepository() *InMemoryRepository {
+	return internal.NewInMemoryRepository()
+}
@@ -1,6 +1,22 @@
 package v1
 
-import "time"
+import (
+	"context"
+	"time"
+
+	"github.com/4thel00z/memories/internal"
+)
+
+// Embedder turns text into vectors for the semantic index. It has the
+// method set of the embedders mem itself uses.
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+	EmbedBatch(ctx context.Context, texts []string) ([][]float32, error)
+	Dimension() int
+	Device() string
+	Model() string
+	Close() error
+}
 
 // Memory represents a stored memory entry.
 type Memory struct {
@@ -23,3 +39,17 @@ type Commit struct {
 	Message   string    `json:"message"`
 	Timestamp time.Time `json:"timestamp"`
 }
+
+// ChangeEvent reports a memory that was created, modified or deleted, or,
+// with WithInitialSnapshot, one that existed when Watch started.
+type ChangeEvent = internal.ChangeEvent
+
+// ChangeOp is the kind of change a ChangeEvent reports.
+type ChangeOp = internal.ChangeOp
+
+const (
+	ChangeExisting = internal.ChangeExisting
+	ChangeCreated  = internal.ChangeCreated
+	ChangeModified = internal.ChangeModified
+	ChangeDeleted  = internal.ChangeDeleted
+)